@@ -2,6 +2,8 @@ package api
 
 import (
 	"testing"
+
+	"github.com/docker/docker/api/types"
 )
 
 func TestJsonContentType(t *testing.T) {
@@ -17,3 +19,49 @@ func TestJsonContentType(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestAcceptsContentType(t *testing.T) {
+	if !AcceptsContentType("text/event-stream", "text/event-stream") {
+		t.Fail()
+	}
+
+	if !AcceptsContentType("application/json, text/event-stream;q=0.9", "text/event-stream") {
+		t.Fail()
+	}
+
+	if AcceptsContentType("text/event-stream;q=0", "text/event-stream") {
+		t.Fail()
+	}
+
+	if AcceptsContentType("*/*", "text/event-stream") {
+		t.Fail()
+	}
+
+	if AcceptsContentType("", "text/event-stream") {
+		t.Fail()
+	}
+}
+
+func TestDisplayablePortsIPv6(t *testing.T) {
+	ports := []types.Port{
+		{PrivatePort: 80, PublicPort: 8080, Type: "tcp", IP: "::1"},
+		{PrivatePort: 53, PublicPort: 53, Type: "udp", IP: "2001:db8::1"},
+		{PrivatePort: 22, PublicPort: 2222, Type: "tcp", IP: "0.0.0.0"},
+	}
+	expected := "[2001:db8::1]:53->53/udp, 0.0.0.0:2222->22/tcp, [::1]:8080->80/tcp"
+	if got := NewDisplayablePorts(ports); got != expected {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestDisplayablePortsSCTP(t *testing.T) {
+	ports := []types.Port{
+		{PrivatePort: 2905, PublicPort: 2905, Type: "sctp", IP: "0.0.0.0"},
+		{PrivatePort: 2906, PublicPort: 2906, Type: "sctp", IP: "0.0.0.0"},
+		{PrivatePort: 3868, PublicPort: 13868, Type: "sctp", IP: "0.0.0.0"},
+	}
+	expected := "0.0.0.0:2905-2906->2905-2906/sctp, 0.0.0.0:13868->3868/sctp"
+	if got := NewDisplayablePorts(ports); got != expected {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+}
@@ -20,12 +20,18 @@ func (cli *DockerCli) CmdAttach(args ...string) error {
 		cmd     = cli.Subcmd("attach", "CONTAINER", "Attach to a running container", true)
 		noStdin = cmd.Bool([]string{"#nostdin", "-no-stdin"}, false, "Do not attach STDIN")
 		proxy   = cmd.Bool([]string{"#sig-proxy", "-sig-proxy"}, true, "Proxy all received signals to the process")
+		keys    = cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
 	)
 	cmd.Require(flag.Exact, 1)
 
 	cmd.ParseFlags(args, true)
 	name := cmd.Arg(0)
 
+	detachKeys, err := cli.getDetachKeys(*keys)
+	if err != nil {
+		return err
+	}
+
 	stream, _, err := cli.call("GET", "/containers/"+name+"/json", nil, nil)
 	if err != nil {
 		return err
@@ -59,6 +65,9 @@ func (cli *DockerCli) CmdAttach(args ...string) error {
 
 	v := url.Values{}
 	v.Set("stream", "1")
+	if detachKeys != "" {
+		v.Set("detachKeys", detachKeys)
+	}
 	if !*noStdin && config.GetBool("OpenStdin") {
 		v.Set("stdin", "1")
 		in = cli.in
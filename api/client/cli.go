@@ -11,12 +11,15 @@ import (
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
+	"github.com/docker/docker/api"
 	"github.com/docker/docker/pkg/homedir"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/registry"
 )
 
@@ -39,6 +42,17 @@ type DockerCli struct {
 	// isTerminalOut describes if client's STDOUT is a TTY
 	isTerminalOut bool
 	transport     *http.Transport
+	// apiVersion is the version of the remote API the client talks
+	apiVersion version.Version
+	// retries is how many times idempotent requests failing with a
+	// transient error are retried
+	retries int
+	// loginLock keeps the operations run in parallel from prompting for
+	// a login at the same time
+	loginLock sync.Mutex
+	// detachKeys is the key sequence detaching from a container when
+	// --detach-keys isn't given, ctrl-p ctrl-q if empty
+	detachKeys string
 }
 
 var funcMap = template.FuncMap{
@@ -46,15 +60,43 @@ var funcMap = template.FuncMap{
 		a, _ := json.Marshal(v)
 		return string(a)
 	},
+	"join":  join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"split": strings.Split,
+}
+
+// join concatenates the elements of a list with sep. Lists decoded from the
+// JSON of docker inspect are []interface{}, so any slice is accepted.
+func join(list interface{}, sep string) (string, error) {
+	if list == nil {
+		return "", nil
+	}
+	if l, ok := list.([]string); ok {
+		return strings.Join(l, sep), nil
+	}
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: can't join a %T", list)
+	}
+	elems := make([]string, v.Len())
+	for i := range elems {
+		elems[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(elems, sep), nil
 }
 
 func (cli *DockerCli) getMethod(args ...string) (func(...string) error, bool) {
 	camelArgs := make([]string, len(args))
 	for i, s := range args {
-		if len(s) == 0 {
-			return nil, false
+		// Each word of a hyphenated command starts a new word of the
+		// method name: "dial-stdio" is DialStdio.
+		for _, word := range strings.Split(s, "-") {
+			if len(word) == 0 {
+				return nil, false
+			}
+			camelArgs[i] += strings.ToUpper(word[:1]) + strings.ToLower(word[1:])
 		}
-		camelArgs[i] = strings.ToUpper(s[:1]) + strings.ToLower(s[1:])
 	}
 	methodName := "Cmd" + strings.Join(camelArgs, "")
 	method := reflect.ValueOf(cli).MethodByName(methodName)
@@ -134,6 +176,10 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 		scheme        = "http"
 	)
 
+	if proto == "ssh" {
+		// The connection is already encrypted by ssh.
+		tlsConfig = nil
+	}
 	if tlsConfig != nil {
 		scheme = "https"
 	}
@@ -156,13 +202,18 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 
 	// Why 32? See issue 8035
 	timeout := 32 * time.Second
-	if proto == "unix" {
+	switch proto {
+	case "unix":
 		// no need in compressing for local communications
 		tr.DisableCompression = true
 		tr.Dial = func(_, _ string) (net.Conn, error) {
 			return net.DialTimeout(proto, addr, timeout)
 		}
-	} else {
+	case "ssh":
+		tr.Dial = func(_, _ string) (net.Conn, error) {
+			return dialSSH(addr, err)
+		}
+	default:
 		tr.Proxy = http.ProxyFromEnvironment
 		tr.Dial = (&net.Dialer{Timeout: timeout}).Dial
 	}
@@ -181,5 +232,36 @@ func NewDockerCli(in io.ReadCloser, out, err io.Writer, keyFile string, proto, a
 		tlsConfig:     tlsConfig,
 		scheme:        scheme,
 		transport:     tr,
+		apiVersion:    api.APIVERSION,
 	}
 }
+
+// SetRetries makes the client retry the idempotent requests failing with a
+// transient error up to retries times.
+func (cli *DockerCli) SetRetries(retries int) {
+	cli.retries = retries
+}
+
+// SetDetachKeys sets the key sequence detaching the client from a container
+// when the commands aren't given one with --detach-keys.
+func (cli *DockerCli) SetDetachKeys(keys string) {
+	cli.detachKeys = keys
+}
+
+// getDetachKeys returns keys, or the default of the client if they're
+// empty, once checked to be a valid key sequence.
+func (cli *DockerCli) getDetachKeys(keys string) (string, error) {
+	if keys == "" {
+		keys = cli.detachKeys
+	}
+	if _, err := term.ToBytes(keys); err != nil {
+		return "", err
+	}
+	return keys, nil
+}
+
+// SetAPIVersion makes the client talk an older version of the remote API, for
+// daemons that don't support the current one.
+func (cli *DockerCli) SetAPIVersion(v version.Version) {
+	cli.apiVersion = v
+}
@@ -3,6 +3,9 @@ package client
 import (
 	"fmt"
 	"io"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/docker/docker/engine"
@@ -10,33 +13,57 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
-// CmdCp copies files/folders from a path on the container to a directory on the host running the command.
+// CmdCp copies files/folders between a path on the container and a path on
+// the host running the command.
 //
-// If HOSTDIR is '-', the data is written as a tar file to STDOUT.
+// If HOSTDIR is '-', the data is written as a tar file to STDOUT. If HOSTPATH
+// is '-', a tar file read from STDIN is extracted into DIR.
 //
-// Usage: docker cp CONTAINER:PATH HOSTDIR
+// Usage: docker cp CONTAINER:PATH HOSTDIR|-
+// Usage: docker cp HOSTPATH|- CONTAINER:DIR
 func (cli *DockerCli) CmdCp(args ...string) error {
-	cmd := cli.Subcmd("cp", "CONTAINER:PATH HOSTDIR|-", "Copy files/folders from a PATH on the container to a HOSTDIR on the host\nrunning the command. Use '-' to write the data\nas a tar file to STDOUT.", true)
+	cmd := cli.Subcmd("cp", "CONTAINER:PATH HOSTDIR|-\n       docker cp HOSTPATH|- CONTAINER:DIR", "Copy files/folders from a PATH on the container to a HOSTDIR on the host\nrunning the command, or from a HOSTPATH into a DIR on the container.\nUse '-' to write the data as a tar file to STDOUT, or to read\nit from STDIN.", true)
 	cmd.Require(flag.Exact, 2)
 
 	cmd.ParseFlags(args, true)
 
-	var copyData engine.Env
-	info := strings.Split(cmd.Arg(0), ":")
+	srcContainer, srcPath := splitCpArg(cmd.Arg(0))
+	dstContainer, dstPath := splitCpArg(cmd.Arg(1))
+	switch {
+	case srcContainer != "" && dstContainer != "":
+		return fmt.Errorf("Error: copying between containers is not supported")
+	case srcContainer != "":
+		return cli.copyFromContainer(srcContainer, srcPath, dstPath)
+	case dstContainer != "":
+		return cli.copyToContainer(srcPath, dstContainer, dstPath)
+	}
+	return fmt.Errorf("Error: Path not specified")
+}
 
-	if len(info) != 2 {
-		return fmt.Errorf("Error: Path not specified")
+// splitCpArg splits a CONTAINER:PATH argument of docker cp. A host path
+// given as absolute or relative to the current directory can contain a colon.
+func splitCpArg(arg string) (container, path string) {
+	if filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") {
+		return "", arg
 	}
+	parts := strings.SplitN(arg, ":", 2)
+	if len(parts) != 2 {
+		return "", arg
+	}
+	return parts[0], parts[1]
+}
 
-	copyData.Set("Resource", info[1])
-	copyData.Set("HostPath", cmd.Arg(1))
+func (cli *DockerCli) copyFromContainer(container, resource, hostPath string) error {
+	var copyData engine.Env
+	copyData.Set("Resource", resource)
+	copyData.Set("HostPath", hostPath)
 
-	stream, statusCode, err := cli.call("POST", "/containers/"+info[0]+"/copy", copyData, nil)
+	stream, statusCode, err := cli.call("POST", "/containers/"+container+"/copy", copyData, nil)
 	if stream != nil {
 		defer stream.Close()
 	}
 	if statusCode == 404 {
-		return fmt.Errorf("No such container: %v", info[0])
+		return fmt.Errorf("No such container: %v", container)
 	}
 	if err != nil {
 		return err
@@ -56,3 +83,36 @@ func (cli *DockerCli) CmdCp(args ...string) error {
 	}
 	return nil
 }
+
+// copyToContainer sends hostPath as a tar archive, or the tar archive read
+// from STDIN if hostPath is '-', to be unpacked into the directory dir of the
+// container.
+func (cli *DockerCli) copyToContainer(hostPath, container, dir string) error {
+	var content io.Reader
+	if hostPath == "-" {
+		content = cli.in
+	} else {
+		hostPath = filepath.Clean(hostPath)
+		if _, err := os.Lstat(hostPath); err != nil {
+			return err
+		}
+		tarball, err := archive.TarWithOptions(filepath.Dir(hostPath), &archive.TarOptions{
+			Compression:  archive.Uncompressed,
+			IncludeFiles: []string{filepath.Base(hostPath)},
+		})
+		if err != nil {
+			return err
+		}
+		defer tarball.Close()
+		content = tarball
+	}
+
+	v := url.Values{}
+	v.Set("path", dir)
+	headers := map[string][]string{"Content-Type": {"application/x-tar"}}
+	stream, _, _, err := cli.clientRequest("PUT", "/containers/"+container+"/archive?"+v.Encode(), content, headers)
+	if stream != nil {
+		defer stream.Close()
+	}
+	return err
+}
@@ -1,11 +1,16 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/url"
 	"strconv"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/opts"
+	"github.com/docker/docker/pkg/jsonmessage"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers/filters"
 	"github.com/docker/docker/pkg/timeutils"
@@ -20,10 +25,19 @@ func (cli *DockerCli) CmdEvents(args ...string) error {
 	until := cmd.String([]string{"-until"}, "", "Stream events until this timestamp")
 	flFilter := opts.NewListOpts(nil)
 	cmd.Var(&flFilter, []string{"f", "-filter"}, "Filter output based on conditions provided")
+	format := cmd.String([]string{"-format"}, "", "Format the output using the given go template")
 	cmd.Require(flag.Exact, 0)
 
 	cmd.ParseFlags(args, true)
 
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
+
 	var (
 		v               = url.Values{}
 		loc             = time.FixedZone(time.Now().Zone())
@@ -63,8 +77,46 @@ func (cli *DockerCli) CmdEvents(args ...string) error {
 		}
 		v.Set("filters", filterJSON)
 	}
+	if tmpl != nil {
+		body, _, _, err := cli.clientRequest("GET", "/events?"+v.Encode(), nil, nil)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		return formatEvents(body, cli.out, tmpl)
+	}
 	if err := cli.stream("GET", "/events?"+v.Encode(), nil, cli.out, nil); err != nil {
 		return err
 	}
 	return nil
 }
+
+// eventMessage is an event as the daemon sends it: a jsonmessage.JSONMessage
+// along with the labels of the container it is about.
+type eventMessage struct {
+	jsonmessage.JSONMessage
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// formatEvents renders each event of the stream with tmpl, one per line.
+// The template is given the event as an eventMessage, so '{{json .}}'
+// prints it as the daemon sent it.
+func formatEvents(in io.Reader, out io.Writer, tmpl *template.Template) error {
+	dec := json.NewDecoder(in)
+	for {
+		var jm eventMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if jm.Error != nil {
+			return jm.Error
+		}
+		if err := tmpl.Execute(out, jm); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+}
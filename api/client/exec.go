@@ -24,6 +24,10 @@ func (cli *DockerCli) CmdExec(args ...string) error {
 		return &utils.StatusError{StatusCode: 1}
 	}
 
+	if execConfig.DetachKeys, err = cli.getDetachKeys(execConfig.DetachKeys); err != nil {
+		return err
+	}
+
 	stream, _, err := cli.call("POST", "/containers/"+execConfig.Container+"/exec", execConfig, nil)
 	if err != nil {
 		return err
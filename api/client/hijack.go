@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/docker/docker/api"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/stdcopy"
@@ -120,6 +119,9 @@ func tlsDialWithDialer(dialer *net.Dialer, network, addr string, config *tls.Con
 }
 
 func (cli *DockerCli) dial() (net.Conn, error) {
+	if cli.proto == "ssh" {
+		return dialSSH(cli.addr, cli.err)
+	}
 	if cli.tlsConfig != nil && cli.proto != "unix" {
 		// Notice this isn't Go standard's tls.Dial function
 		return tlsDial(cli.proto, cli.addr, cli.tlsConfig)
@@ -138,7 +140,7 @@ func (cli *DockerCli) hijack(method, path string, setRawTerminal bool, in io.Rea
 	if err != nil {
 		return err
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), params)
+	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", cli.apiVersion, path), params)
 	if err != nil {
 		return err
 	}
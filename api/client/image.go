@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/api/types"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// CmdImage lists the commands that manage images.
+//
+// Usage: docker image COMMAND
+func (cli *DockerCli) CmdImage(args ...string) error {
+	description := "Manage images\n\nCommands:\n"
+	for _, command := range [][]string{
+		{"verify", "Verify the content of the layers of an image"},
+	} {
+		description += fmt.Sprintf("    %-10.10s%s\n", command[0], command[1])
+	}
+	cmd := cli.Subcmd("image", "COMMAND", strings.TrimSuffix(description, "\n"), true)
+	cmd.ParseFlags(args, true)
+	if cmd.NArg() > 0 {
+		return fmt.Errorf("docker: 'image %s' is not a docker command. See 'docker image --help'.", cmd.Arg(0))
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdImageVerify computes the sums of the layers of an image again, and
+// reports the layers whose content doesn't match the sum recorded when
+// they were registered.
+//
+// Usage: docker image verify [OPTIONS] IMAGE
+func (cli *DockerCli) CmdImageVerify(args ...string) error {
+	cmd := cli.Subcmd("image verify", "IMAGE", "Verify the content of the layers of an image", true)
+	noTrunc := cmd.Bool([]string{"-no-trunc"}, false, "Don't truncate output")
+	cmd.Require(flag.Exact, 1)
+	cmd.ParseFlags(args, true)
+
+	name := cmd.Arg(0)
+	rdr, _, err := cli.call("POST", "/images/"+name+"/verify", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var verification types.ImageVerification
+	if err := json.NewDecoder(rdr).Decode(&verification); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "LAYER\tSTATUS")
+	corrupted := 0
+	for _, layer := range verification.Layers {
+		id := layer.ID
+		if !*noTrunc {
+			id = stringid.TruncateID(id)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", id, layer.Status)
+		if layer.Status == "corrupted" {
+			corrupted++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if corrupted > 0 {
+		return fmt.Errorf("Error: %s has %d corrupted layer(s)", name, corrupted)
+	}
+	return nil
+}
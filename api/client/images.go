@@ -3,9 +3,12 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -95,6 +98,7 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 	all := cmd.Bool([]string{"a", "-all"}, false, "Show all images (default hides intermediate images)")
 	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
 	showDigests := cmd.Bool([]string{"-digests"}, false, "Show digests")
+	format := cmd.String([]string{"-format"}, "", "Pretty-print images using a Go template")
 	// FIXME: --viz and --tree are deprecated. Remove them in a future version.
 	flViz := cmd.Bool([]string{"#v", "#viz", "#-viz"}, false, "Output graph in graphviz format")
 	flTree := cmd.Bool([]string{"#t", "#tree", "#-tree"}, false, "Output graph in tree format")
@@ -217,8 +221,25 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 			return err
 		}
 
-		w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-		if !*quiet {
+		var tmpl *template.Template
+		if *format != "" && !*quiet {
+			if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+				return fmt.Errorf("Template parsing error: %v", err)
+			}
+		}
+
+		// Unless printed to a terminal, the columns are separated by single
+		// tabs and hold the exact creation times and sizes, for scripts to
+		// parse them
+		var (
+			w  io.Writer = cli.out
+			tw *tabwriter.Writer
+		)
+		if cli.isTerminalOut {
+			tw = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+			w = tw
+		}
+		if !*quiet && tmpl == nil {
 			if *showDigests {
 				fmt.Fprintln(w, "REPOSITORY\tTAG\tDIGEST\tIMAGE ID\tCREATED\tVIRTUAL SIZE")
 			} else {
@@ -253,11 +274,32 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 					tag = ref
 				}
 
-				if !*quiet {
+				if tmpl != nil {
+					ctx := imageContext{
+						ID:           ID,
+						Repository:   repo,
+						Tag:          tag,
+						Digest:       digest,
+						CreatedAt:    time.Unix(int64(image.Created), 0),
+						CreatedSince: units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))),
+						Size:         units.HumanSize(float64(image.Size)),
+						VirtualSize:  units.HumanSize(float64(image.VirtualSize)),
+					}
+					if err := tmpl.Execute(cli.out, ctx); err != nil {
+						return err
+					}
+					fmt.Fprintln(cli.out)
+				} else if !*quiet {
+					created := units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))) + " ago"
+					size := units.HumanSize(float64(image.VirtualSize))
+					if tw == nil {
+						created = time.Unix(int64(image.Created), 0).UTC().Format(time.RFC3339)
+						size = strconv.Itoa(image.VirtualSize)
+					}
 					if *showDigests {
-						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s ago\t%s\n", repo, tag, digest, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", repo, tag, digest, ID, created, size)
 					} else {
-						fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\n", repo, tag, ID, units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(image.Created), 0))), units.HumanSize(float64(image.VirtualSize)))
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", repo, tag, ID, created, size)
 					}
 				} else {
 					fmt.Fprintln(w, ID)
@@ -265,9 +307,22 @@ func (cli *DockerCli) CmdImages(args ...string) error {
 			}
 		}
 
-		if !*quiet {
-			w.Flush()
+		if tw != nil {
+			tw.Flush()
 		}
 	}
 	return nil
 }
+
+// imageContext holds what --format templates of docker images can refer to,
+// for each reference to an image.
+type imageContext struct {
+	ID           string
+	Repository   string
+	Tag          string
+	Digest       string
+	CreatedAt    time.Time
+	CreatedSince string
+	Size         string
+	VirtualSize  string
+}
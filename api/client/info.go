@@ -3,6 +3,7 @@ package client
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -57,6 +58,12 @@ func (cli *DockerCli) CmdInfo(args ...string) error {
 	if remoteInfo.Exists("ExecutionDriver") {
 		fmt.Fprintf(cli.out, "Execution Driver: %s\n", remoteInfo.Get("ExecutionDriver"))
 	}
+	if remoteInfo.Exists("Runtimes") {
+		fmt.Fprintf(cli.out, "Runtimes: %s\n", strings.Join(remoteInfo.GetList("Runtimes"), " "))
+	}
+	if remoteInfo.Exists("DefaultRuntime") {
+		fmt.Fprintf(cli.out, "Default Runtime: %s\n", remoteInfo.Get("DefaultRuntime"))
+	}
 	if remoteInfo.Exists("LoggingDriver") {
 		fmt.Fprintf(cli.out, "Logging Driver: %s\n", remoteInfo.Get("LoggingDriver"))
 	}
@@ -12,11 +12,12 @@ import (
 	"github.com/docker/docker/utils"
 )
 
-// CmdInspect displays low-level information on one or more containers or images.
+// CmdInspect displays low-level information on one or more containers, images
+// or exec instances.
 //
-// Usage: docker inspect [OPTIONS] CONTAINER|IMAGE [CONTAINER|IMAGE...]
+// Usage: docker inspect [OPTIONS] CONTAINER|IMAGE|EXEC [CONTAINER|IMAGE|EXEC...]
 func (cli *DockerCli) CmdInspect(args ...string) error {
-	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE [CONTAINER|IMAGE...]", "Return low-level information on a container or image", true)
+	cmd := cli.Subcmd("inspect", "CONTAINER|IMAGE|EXEC [CONTAINER|IMAGE|EXEC...]", "Return low-level information on a container, image or exec instance", true)
 	tmplStr := cmd.String([]string{"f", "#format", "-format"}, "", "Format the output using the given go template")
 	cmd.Require(flag.Min, 1)
 
@@ -40,15 +41,18 @@ func (cli *DockerCli) CmdInspect(args ...string) error {
 		obj, _, err := readBody(cli.call("GET", "/containers/"+name+"/json", nil, nil))
 		if err != nil {
 			obj, _, err = readBody(cli.call("GET", "/images/"+name+"/json", nil, nil))
-			if err != nil {
-				if strings.Contains(err.Error(), "No such") {
-					fmt.Fprintf(cli.err, "Error: No such image or container: %s\n", name)
-				} else {
-					fmt.Fprintf(cli.err, "%s", err)
-				}
-				status = 1
-				continue
+		}
+		if err != nil && strings.Contains(err.Error(), "No such") {
+			obj, _, err = readBody(cli.call("GET", "/exec/"+name+"/json", nil, nil))
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "No such") {
+				fmt.Fprintf(cli.err, "Error: No such image, container or exec instance: %s\n", name)
+			} else {
+				fmt.Fprintf(cli.err, "%s", err)
 			}
+			status = 1
+			continue
 		}
 
 		if tmpl == nil {
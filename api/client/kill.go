@@ -17,13 +17,16 @@ func (cli *DockerCli) CmdKill(args ...string) error {
 	cmd.ParseFlags(args, true)
 
 	var encounteredError error
-	for _, name := range cmd.Args() {
-		if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/kill?signal=%s", name, *signal), nil, nil)); err != nil {
+	parallelOperation(cmd.Args(), func(name string) error {
+		_, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/kill?signal=%s", name, *signal), nil, nil))
+		return err
+	}, func(name string, err error) {
+		if err != nil {
 			fmt.Fprintf(cli.err, "%s\n", err)
 			encounteredError = fmt.Errorf("Error: failed to kill one or more containers")
 		} else {
 			fmt.Fprintf(cli.out, "%s\n", name)
 		}
-	}
+	})
 	return encounteredError
 }
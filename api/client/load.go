@@ -2,9 +2,12 @@ package client
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
 
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/streamformatter"
 )
 
 // CmdLoad loads an image from a tar archive.
@@ -20,16 +23,35 @@ func (cli *DockerCli) CmdLoad(args ...string) error {
 	cmd.ParseFlags(args, true)
 
 	var (
-		input io.Reader = cli.in
-		err   error
+		input io.ReadCloser = cli.in
+		size  int
 	)
 	if *infile != "" {
-		input, err = os.Open(*infile)
+		file, err := os.Open(*infile)
 		if err != nil {
 			return err
 		}
+		defer file.Close()
+		if fi, err := file.Stat(); err == nil {
+			size = int(fi.Size())
+		}
+		input = file
 	}
-	if err := cli.stream("POST", "/images/load", input, cli.out, nil); err != nil {
+	// The daemon reports the progress of the loading of the layers, once
+	// it has unpacked the whole archive. Until then, the progress of the
+	// sending is all there is, a bar only when the size of the file is known.
+	body := progressreader.New(progressreader.Config{
+		In:        input,
+		Out:       cli.out,
+		Formatter: streamformatter.NewStreamFormatter(false),
+		Size:      size,
+		NewLines:  true,
+		ID:        "",
+		Action:    "Sending image archive to Docker daemon",
+	})
+	// The request closes its body once sent, which would report the progress
+	// again after its end.
+	if err := cli.stream("POST", "/images/load", ioutil.NopCloser(body), cli.out, nil); err != nil {
 		return err
 	}
 	return nil
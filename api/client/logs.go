@@ -3,6 +3,8 @@ package client
 import (
 	"fmt"
 	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/docker/docker/engine"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -13,10 +15,12 @@ import (
 // docker logs [OPTIONS] CONTAINER
 func (cli *DockerCli) CmdLogs(args ...string) error {
 	var (
-		cmd    = cli.Subcmd("logs", "CONTAINER", "Fetch the logs of a container", true)
-		follow = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
-		times  = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
-		tail   = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+		cmd     = cli.Subcmd("logs", "CONTAINER", "Fetch the logs of a container", true)
+		follow  = cmd.Bool([]string{"f", "-follow"}, false, "Follow log output")
+		times   = cmd.Bool([]string{"t", "-timestamps"}, false, "Show timestamps")
+		tail    = cmd.String([]string{"-tail"}, "all", "Number of lines to show from the end of the logs")
+		since   = cmd.String([]string{"-since"}, "", "Show logs since timestamp or relative time (e.g. 10m)")
+		details = cmd.Bool([]string{"-details"}, false, "Show extra details provided to logs")
 	)
 	cmd.Require(flag.Exact, 1)
 
@@ -24,6 +28,14 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 
 	name := cmd.Arg(0)
 
+	var sinceUnix int64
+	if *since != "" {
+		var err error
+		if sinceUnix, err = parseLogsSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+
 	stream, _, err := cli.call("GET", "/containers/"+name+"/json", nil, nil)
 	if err != nil {
 		return err
@@ -49,7 +61,29 @@ func (cli *DockerCli) CmdLogs(args ...string) error {
 	if *follow {
 		v.Set("follow", "1")
 	}
+
+	if *details {
+		v.Set("details", "1")
+	}
 	v.Set("tail", *tail)
+	if sinceUnix > 0 {
+		v.Set("since", strconv.FormatInt(sinceUnix, 10))
+	}
 
 	return cli.streamHelper("GET", "/containers/"+name+"/logs?"+v.Encode(), env.GetSubEnv("Config").GetBool("Tty"), nil, cli.out, cli.err, nil)
 }
+
+// parseLogsSince turns the value of --since, a duration relative to now, an
+// RFC3339 time or a unix timestamp, into the unix timestamp the API takes.
+func parseLogsSince(value string, now time.Time) (int64, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d).Unix(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Unix(), nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return seconds, nil
+	}
+	return 0, fmt.Errorf("Invalid value for --since: %s is neither a duration nor a timestamp", value)
+}
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/utils"
+)
+
+// CmdNetwork lists the commands that manage networks.
+//
+// Usage: docker network COMMAND
+func (cli *DockerCli) CmdNetwork(args ...string) error {
+	description := "Manage Docker networks\n\nCommands:\n"
+	for _, command := range [][]string{
+		{"create", "Create a network"},
+		{"connect", "Connect a container to a network"},
+		{"disconnect", "Disconnect a container from a network"},
+		{"inspect", "Display detailed network information"},
+		{"ls", "List all networks"},
+		{"rm", "Remove one or more networks"},
+	} {
+		description += fmt.Sprintf("    %-12.12s%s\n", command[0], command[1])
+	}
+	cmd := cli.Subcmd("network", "COMMAND", strings.TrimSuffix(description, "\n"), true)
+	cmd.ParseFlags(args, true)
+	if cmd.NArg() > 0 {
+		return fmt.Errorf("docker: 'network %s' is not a docker command. See 'docker network --help'.", cmd.Arg(0))
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdNetworkCreate creates a network, and prints its ID.
+//
+// Usage: docker network create [OPTIONS] NETWORK-NAME
+func (cli *DockerCli) CmdNetworkCreate(args ...string) error {
+	cmd := cli.Subcmd("network create", "NETWORK-NAME", "Create a network", true)
+	driver := cmd.String([]string{"d", "-driver"}, "bridge", "Driver to manage the network")
+	ipamDriver := cmd.String([]string{"-ipam-driver"}, "default", "IP address management driver of the network")
+	subnet := cmd.String([]string{"-subnet"}, "", "Subnet in CIDR format of the network")
+	gateway := cmd.String([]string{"-gateway"}, "", "Gateway of the subnet of the network")
+	internal := cmd.Bool([]string{"-internal"}, false, "Restrict external access to the network")
+	options := make(map[string]string)
+	cmd.Var(opts.NewMapOpts(options), []string{"o", "-opt"}, "Set driver specific options")
+	cmd.Require(flag.Exact, 1)
+	cmd.ParseFlags(args, true)
+
+	create := &types.NetworkCreate{
+		Name:     cmd.Arg(0),
+		Driver:   *driver,
+		Internal: *internal,
+		IPAM:     types.IPAM{Driver: *ipamDriver},
+		Options:  options,
+	}
+	if *subnet != "" || *gateway != "" {
+		create.IPAM.Config = []types.IPAMConfig{{Subnet: *subnet, Gateway: *gateway}}
+	}
+	rdr, _, err := cli.call("POST", "/networks/create", create, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var response types.NetworkCreateResponse
+	if err := json.NewDecoder(rdr).Decode(&response); err != nil {
+		return err
+	}
+	if response.Warning != "" {
+		fmt.Fprintf(cli.err, "WARNING: %s\n", response.Warning)
+	}
+	fmt.Fprintf(cli.out, "%s\n", response.ID)
+	return nil
+}
+
+// CmdNetworkLs lists the networks of the daemon.
+//
+// Usage: docker network ls [OPTIONS]
+func (cli *DockerCli) CmdNetworkLs(args ...string) error {
+	cmd := cli.Subcmd("network ls", "", "List all networks", true)
+	quiet := cmd.Bool([]string{"q", "-quiet"}, false, "Only display numeric IDs")
+	noTrunc := cmd.Bool([]string{"#notrunc", "-no-trunc"}, false, "Don't truncate output")
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	rdr, _, err := cli.call("GET", "/networks", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var networks []types.NetworkResource
+	if err := json.NewDecoder(rdr).Decode(&networks); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if !*quiet {
+		fmt.Fprintln(w, "NETWORK ID\tNAME\tDRIVER")
+	}
+	for _, n := range networks {
+		id := n.ID
+		if !*noTrunc {
+			id = stringid.TruncateID(id)
+		}
+		if *quiet {
+			fmt.Fprintln(w, id)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", id, n.Name, n.Driver)
+	}
+	return w.Flush()
+}
+
+// CmdNetworkInspect displays the settings of one or more networks, with the
+// containers connected to them.
+//
+// Usage: docker network inspect [OPTIONS] NETWORK [NETWORK...]
+func (cli *DockerCli) CmdNetworkInspect(args ...string) error {
+	cmd := cli.Subcmd("network inspect", "NETWORK [NETWORK...]", "Display detailed network information", true)
+	tmplStr := cmd.String([]string{"f", "-format"}, "", "Format the output using the given go template")
+	cmd.Require(flag.Min, 1)
+	cmd.ParseFlags(args, true)
+
+	var tmpl *template.Template
+	if *tmplStr != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*tmplStr); err != nil {
+			fmt.Fprintf(cli.err, "Template parsing error: %v\n", err)
+			return &utils.StatusError{StatusCode: 64,
+				Status: "Template parsing error: " + err.Error()}
+		}
+	}
+
+	indented := new(bytes.Buffer)
+	indented.WriteByte('[')
+	status := 0
+
+	for _, name := range cmd.Args() {
+		obj, _, err := readBody(cli.call("GET", "/networks/"+name, nil, nil))
+		if err != nil {
+			fmt.Fprintf(cli.err, "Error: %s\n", err)
+			status = 1
+			continue
+		}
+
+		if tmpl == nil {
+			if err = json.Indent(indented, obj, "", "    "); err != nil {
+				fmt.Fprintf(cli.err, "%s\n", err)
+				status = 1
+				continue
+			}
+			indented.WriteString(",")
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(obj, &value); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			status = 1
+			continue
+		}
+		if err := tmpl.Execute(cli.out, value); err != nil {
+			return err
+		}
+		cli.out.Write([]byte{'\n'})
+	}
+
+	if tmpl == nil {
+		if indented.Len() > 1 {
+			// Remove trailing ','
+			indented.Truncate(indented.Len() - 1)
+		}
+		indented.WriteString("]\n")
+		if _, err := io.Copy(cli.out, indented); err != nil {
+			return err
+		}
+	}
+
+	if status != 0 {
+		return &utils.StatusError{StatusCode: status}
+	}
+	return nil
+}
+
+// CmdNetworkRm removes one or more networks.
+//
+// Usage: docker network rm NETWORK [NETWORK...]
+func (cli *DockerCli) CmdNetworkRm(args ...string) error {
+	cmd := cli.Subcmd("network rm", "NETWORK [NETWORK...]", "Remove one or more networks", true)
+	cmd.Require(flag.Min, 1)
+	cmd.ParseFlags(args, true)
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		if _, _, err := readBody(cli.call("DELETE", "/networks/"+name, nil, nil)); err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to remove one or more networks")
+			continue
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	return encounteredError
+}
+
+// CmdNetworkConnect connects a container to a network.
+//
+// Usage: docker network connect [OPTIONS] NETWORK CONTAINER
+func (cli *DockerCli) CmdNetworkConnect(args ...string) error {
+	cmd := cli.Subcmd("network connect", "NETWORK CONTAINER", "Connect a container to a network", true)
+	ipv4Address := cmd.String([]string{"-ip"}, "", "IPv4 address of the container on the network (e.g. 172.30.100.104)")
+	ipv6Address := cmd.String([]string{"-ip6"}, "", "IPv6 address of the container on the network (e.g. 2001:db8::33)")
+	aliases := opts.NewListOpts(nil)
+	cmd.Var(&aliases, []string{"-alias"}, "Add a name of the container on the network")
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	connect := &types.NetworkConnect{Container: cmd.Arg(1)}
+	if *ipv4Address != "" || *ipv6Address != "" || aliases.Len() > 0 {
+		connect.EndpointConfig = &types.EndpointConfig{Aliases: aliases.GetAll()}
+	}
+	if *ipv4Address != "" || *ipv6Address != "" {
+		connect.EndpointConfig.IPAMConfig = &types.EndpointIPAMConfig{IPv4Address: *ipv4Address, IPv6Address: *ipv6Address}
+	}
+	_, _, err := readBody(cli.call("POST", "/networks/"+cmd.Arg(0)+"/connect", connect, nil))
+	return err
+}
+
+// CmdNetworkDisconnect disconnects a container from a network.
+//
+// Usage: docker network disconnect NETWORK CONTAINER
+func (cli *DockerCli) CmdNetworkDisconnect(args ...string) error {
+	cmd := cli.Subcmd("network disconnect", "NETWORK CONTAINER", "Disconnect a container from a network", true)
+	cmd.Require(flag.Exact, 2)
+	cmd.ParseFlags(args, true)
+
+	disconnect := &types.NetworkDisconnect{Container: cmd.Arg(1)}
+	_, _, err := readBody(cli.call("POST", "/networks/"+cmd.Arg(0)+"/disconnect", disconnect, nil))
+	return err
+}
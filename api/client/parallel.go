@@ -0,0 +1,26 @@
+package client
+
+// maxParallelOperations is how many of the targets of a command given several
+// of them are handled at the same time.
+const maxParallelOperations = 8
+
+// parallelOperation calls op on each of names, maxParallelOperations of them
+// at a time, and done with the outcome of each in the order of names, as soon
+// as it and the ones before it are known.
+func parallelOperation(names []string, op func(name string) error, done func(name string, err error)) {
+	var (
+		results = make([]chan error, len(names))
+		slots   = make(chan struct{}, maxParallelOperations)
+	)
+	for i, name := range names {
+		results[i] = make(chan error, 1)
+		go func(name string, result chan<- error) {
+			slots <- struct{}{}
+			result <- op(name)
+			<-slots
+		}(name, results[i])
+	}
+	for i, name := range names {
+		done(name, <-results[i])
+	}
+}
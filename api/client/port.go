@@ -2,22 +2,106 @@ package client
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
+// portMapping holds what --format templates of docker port can refer to,
+// for each published port, or range of consecutive ports published to
+// consecutive host ports.
+type portMapping struct {
+	PrivatePort    int
+	PrivatePortEnd int
+	Proto          string
+	HostIP         string
+	HostPort       int
+	HostPortEnd    int
+}
+
+type byPrivatePort []portMapping
+
+func (r byPrivatePort) Len() int      { return len(r) }
+func (r byPrivatePort) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r byPrivatePort) Less(i, j int) bool {
+	if r[i].Proto != r[j].Proto {
+		return r[i].Proto < r[j].Proto
+	}
+	if r[i].HostIP != r[j].HostIP {
+		return r[i].HostIP < r[j].HostIP
+	}
+	if r[i].PrivatePort != r[j].PrivatePort {
+		return r[i].PrivatePort < r[j].PrivatePort
+	}
+	return r[i].HostPort < r[j].HostPort
+}
+
+// portMappings returns the mappings of the given ports, merging consecutive
+// ports published to consecutive host ports of the same address into ranges.
+func portMappings(ports nat.PortMap) []portMapping {
+	mappings := []portMapping{}
+	for port, frontends := range ports {
+		privatePort, err := nat.ParsePort(port.Port())
+		if err != nil {
+			continue
+		}
+		for _, frontend := range frontends {
+			hostPort, err := strconv.Atoi(frontend.HostPort)
+			if err != nil {
+				continue
+			}
+			mappings = append(mappings, portMapping{
+				PrivatePort:    privatePort,
+				PrivatePortEnd: privatePort,
+				Proto:          port.Proto(),
+				HostIP:         frontend.HostIp,
+				HostPort:       hostPort,
+				HostPortEnd:    hostPort,
+			})
+		}
+	}
+	sort.Sort(byPrivatePort(mappings))
+
+	merged := []portMapping{}
+	for _, m := range mappings {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			if last.Proto == m.Proto && last.HostIP == m.HostIP &&
+				last.PrivatePortEnd+1 == m.PrivatePort && last.HostPortEnd+1 == m.HostPort {
+				last.PrivatePortEnd = m.PrivatePort
+				last.HostPortEnd = m.HostPort
+				continue
+			}
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
 // CmdPort lists port mappings for a container.
 // If a private port is specified, it also shows the public-facing port that is NATed to the private port.
 //
-// Usage: docker port CONTAINER [PRIVATE_PORT[/PROTO]]
+// Usage: docker port [OPTIONS] CONTAINER [PRIVATE_PORT[/PROTO]]
 func (cli *DockerCli) CmdPort(args ...string) error {
 	cmd := cli.Subcmd("port", "CONTAINER [PRIVATE_PORT[/PROTO]]", "List port mappings for the CONTAINER, or lookup the public-facing port that\nis NAT-ed to the PRIVATE_PORT", true)
+	format := cmd.String([]string{"-format"}, "", "Pretty-print port mappings using a Go template")
 	cmd.Require(flag.Min, 1)
 	cmd.ParseFlags(args, true)
 
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
+
 	stream, _, err := cli.call("GET", "/containers/"+cmd.Arg(0)+"/json", nil, nil)
 	if err != nil {
 		return err
@@ -45,19 +129,35 @@ func (cli *DockerCli) CmdPort(args ...string) error {
 		}
 		natPort := port + "/" + proto
 		if frontends, exists := ports[nat.Port(port+"/"+proto)]; exists && frontends != nil {
+			if tmpl != nil {
+				return executePortTemplate(cli, tmpl, nat.PortMap{nat.Port(natPort): frontends})
+			}
 			for _, frontend := range frontends {
-				fmt.Fprintf(cli.out, "%s:%s\n", frontend.HostIp, frontend.HostPort)
+				fmt.Fprintln(cli.out, net.JoinHostPort(frontend.HostIp, frontend.HostPort))
 			}
 			return nil
 		}
 		return fmt.Errorf("Error: No public port '%s' published for %s", natPort, cmd.Arg(0))
 	}
 
+	if tmpl != nil {
+		return executePortTemplate(cli, tmpl, ports)
+	}
 	for from, frontends := range ports {
 		for _, frontend := range frontends {
-			fmt.Fprintf(cli.out, "%s -> %s:%s\n", from, frontend.HostIp, frontend.HostPort)
+			fmt.Fprintf(cli.out, "%s -> %s\n", from, net.JoinHostPort(frontend.HostIp, frontend.HostPort))
 		}
 	}
 
 	return nil
 }
+
+func executePortTemplate(cli *DockerCli, tmpl *template.Template, ports nat.PortMap) error {
+	for _, mapping := range portMappings(ports) {
+		if err := tmpl.Execute(cli.out, mapping); err != nil {
+			return err
+		}
+		fmt.Fprintln(cli.out)
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/url"
 
 	"github.com/docker/docker/graph"
@@ -11,26 +13,55 @@ import (
 	"github.com/docker/docker/utils"
 )
 
-// CmdPull pulls an image or a repository from the registry.
+// CmdPull pulls one or more images or repositories from the registry.
 //
-// Usage: docker pull [OPTIONS] IMAGENAME[:TAG|@DIGEST]
+// Several images are pulled at the same time, and the output of each is
+// printed once it is complete.
+//
+// Usage: docker pull [OPTIONS] IMAGENAME[:TAG|@DIGEST] [IMAGENAME[:TAG|@DIGEST]...]
 func (cli *DockerCli) CmdPull(args ...string) error {
-	cmd := cli.Subcmd("pull", "NAME[:TAG|@DIGEST]", "Pull an image or a repository from the registry", true)
+	cmd := cli.Subcmd("pull", "NAME[:TAG|@DIGEST] [NAME[:TAG|@DIGEST]...]", "Pull one or more images or repositories from the registry", true)
 	allTags := cmd.Bool([]string{"a", "-all-tags"}, false, "Download all tagged images in the repository")
-	cmd.Require(flag.Exact, 1)
+	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
+	cli.LoadConfigFile()
+
+	if cmd.NArg() == 1 {
+		return cli.pullRemote(cmd.Arg(0), *allTags, cli.out)
+	}
+
+	var (
+		encounteredError error
+		outputs          = make(map[string]*bytes.Buffer)
+	)
+	for _, remote := range cmd.Args() {
+		outputs[remote] = bytes.NewBuffer(nil)
+	}
+	parallelOperation(cmd.Args(), func(remote string) error {
+		return cli.pullRemote(remote, *allTags, outputs[remote])
+	}, func(remote string, err error) {
+		io.Copy(cli.out, outputs[remote])
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to pull one or more images")
+		}
+	})
+	return encounteredError
+}
+
+// pullRemote pulls remote, writing the progress to out.
+func (cli *DockerCli) pullRemote(remote string, allTags bool, out io.Writer) error {
 	var (
 		v         = url.Values{}
-		remote    = cmd.Arg(0)
 		newRemote = remote
 	)
 	taglessRemote, tag := parsers.ParseRepositoryTag(remote)
-	if tag == "" && !*allTags {
+	if tag == "" && !allTags {
 		newRemote = utils.ImageReference(taglessRemote, graph.DEFAULTTAG)
 	}
-	if tag != "" && *allTags {
+	if tag != "" && allTags {
 		return fmt.Errorf("tag can't be used with --all-tags/-a")
 	}
 
@@ -42,8 +73,6 @@ func (cli *DockerCli) CmdPull(args ...string) error {
 		return err
 	}
 
-	cli.LoadConfigFile()
-
-	_, _, err = cli.clientRequestAttemptLogin("POST", "/images/create?"+v.Encode(), nil, cli.out, repoInfo.Index, "pull")
+	_, _, err = cli.clientRequestAttemptLogin("POST", "/images/create?"+v.Encode(), nil, out, repoInfo.Index, "pull")
 	return err
 }
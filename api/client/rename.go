@@ -1,6 +1,9 @@
 package client
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+)
 
 // CmdRename renames a container.
 //
@@ -18,7 +21,9 @@ func (cli *DockerCli) CmdRename(args ...string) error {
 	oldName := cmd.Arg(0)
 	newName := cmd.Arg(1)
 
-	if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/rename?name=%s", oldName, newName), nil, nil)); err != nil {
+	v := url.Values{}
+	v.Set("name", newName)
+	if _, _, err := readBody(cli.call("POST", fmt.Sprintf("/containers/%s/rename?%s", oldName, v.Encode()), nil, nil)); err != nil {
 		fmt.Fprintf(cli.err, "%s\n", err)
 		return fmt.Errorf("Error: failed to rename container named %s", oldName)
 	}
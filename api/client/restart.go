@@ -13,13 +13,15 @@ import (
 // Usage: docker stop [OPTIONS] CONTAINER [CONTAINER...]
 func (cli *DockerCli) CmdRestart(args ...string) error {
 	cmd := cli.Subcmd("restart", "CONTAINER [CONTAINER...]", "Restart a running container", true)
-	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing the container")
+	nSeconds := cmd.Int([]string{"t", "-time"}, 10, "Seconds to wait for stop before killing the container, the stop timeout of the container by default")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
 	v := url.Values{}
-	v.Set("t", strconv.Itoa(*nSeconds))
+	if cmd.IsSet("t") || cmd.IsSet("-time") {
+		v.Set("t", strconv.Itoa(*nSeconds))
+	}
 
 	var encounteredError error
 	for _, name := range cmd.Args() {
@@ -29,14 +29,16 @@ func (cli *DockerCli) CmdRm(args ...string) error {
 	}
 
 	var encounteredError error
-	for _, name := range cmd.Args() {
+	parallelOperation(cmd.Args(), func(name string) error {
 		_, _, err := readBody(cli.call("DELETE", "/containers/"+name+"?"+val.Encode(), nil, nil))
+		return err
+	}, func(name string, err error) {
 		if err != nil {
 			fmt.Fprintf(cli.err, "%s\n", err)
 			encounteredError = fmt.Errorf("Error: failed to remove one or more containers")
 		} else {
 			fmt.Fprintf(cli.out, "%s\n", name)
 		}
-	}
+	})
 	return encounteredError
 }
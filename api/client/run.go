@@ -44,15 +44,15 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 
 	// These are flags not stored in Config/HostConfig
 	var (
-		flAutoRemove = cmd.Bool([]string{"#rm", "-rm"}, false, "Automatically remove the container when it exits")
-		flDetach     = cmd.Bool([]string{"d", "-detach"}, false, "Run container in background and print container ID")
-		flSigProxy   = cmd.Bool([]string{"#sig-proxy", "-sig-proxy"}, true, "Proxy received signals to the process")
-		flName       = cmd.String([]string{"#name", "-name"}, "", "Assign a name to the container")
-		flAttach     *opts.ListOpts
+		flDetach   = cmd.Bool([]string{"d", "-detach"}, false, "Run container in background and print container ID")
+		flSigProxy = cmd.Bool([]string{"#sig-proxy", "-sig-proxy"}, true, "Proxy received signals to the process")
+		flName     = cmd.String([]string{"#name", "-name"}, "", "Assign a name to the container")
+		flKeys     = cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+		flAttach   *opts.ListOpts
 
 		ErrConflictAttachDetach               = fmt.Errorf("Conflicting options: -a and -d")
 		ErrConflictRestartPolicyAndAutoRemove = fmt.Errorf("Conflicting options: --restart and --rm")
-		ErrConflictDetachAutoRemove           = fmt.Errorf("Conflicting options: --rm and -d")
+		ErrConflictHealthRestartAutoRemove    = fmt.Errorf("Conflicting options: --restart-on-unhealthy and --rm")
 	)
 
 	config, hostConfig, cmd, err := runconfig.Parse(cmd, args)
@@ -72,6 +72,10 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 			}
 		}
 	}
+	detachKeys, err := cli.getDetachKeys(*flKeys)
+	if err != nil {
+		return err
+	}
 	if config.Image == "" {
 		cmd.Usage()
 		return nil
@@ -88,9 +92,6 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 				return ErrConflictAttachDetach
 			}
 		}
-		if *flAutoRemove {
-			return ErrConflictDetachAutoRemove
-		}
 
 		config.AttachStdin = false
 		config.AttachStdout = false
@@ -124,9 +125,12 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 			fmt.Fprintf(cli.out, "%s\n", createResponse.ID)
 		}()
 	}
-	if *flAutoRemove && (hostConfig.RestartPolicy.Name == "always" || hostConfig.RestartPolicy.Name == "on-failure") {
+	if hostConfig.AutoRemove && hostConfig.RestartPolicy.Name != "" && hostConfig.RestartPolicy.Name != "no" {
 		return ErrConflictRestartPolicyAndAutoRemove
 	}
+	if hostConfig.AutoRemove && hostConfig.RestartPolicy.OnUnhealthy {
+		return ErrConflictHealthRestartAutoRemove
+	}
 	// We need to instantiate the chan because the select needs it. It can
 	// be closed but can't be uninitialized.
 	hijacked := make(chan io.Closer)
@@ -144,6 +148,9 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 			v           = url.Values{}
 		)
 		v.Set("stream", "1")
+		if detachKeys != "" {
+			v.Set("detachKeys", detachKeys)
+		}
 		if config.AttachStdin {
 			v.Set("stdin", "1")
 			in = cli.in
@@ -181,13 +188,15 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 		}
 	}
 
-	defer func() {
-		if *flAutoRemove {
-			if _, _, err = readBody(cli.call("DELETE", "/containers/"+createResponse.ID+"?v=1", nil, nil)); err != nil {
-				logrus.Errorf("Error deleting container: %s", err)
-			}
+	// Autoremove: the daemon removes the container once it exits, start
+	// waiting for it before the container can exit.
+	var removed io.ReadCloser
+	if hostConfig.AutoRemove && (config.AttachStdout || config.AttachStderr) {
+		if removed, _, err = cli.call("POST", "/containers/"+createResponse.ID+"/wait?condition=removed", nil, nil); err != nil {
+			return err
 		}
-	}()
+		defer removed.Close()
+	}
 
 	//start the container
 	if _, _, err = readBody(cli.call("POST", "/containers/"+createResponse.ID+"/start", nil, nil)); err != nil {
@@ -217,13 +226,9 @@ func (cli *DockerCli) CmdRun(args ...string) error {
 	var status int
 
 	// Attached mode
-	if *flAutoRemove {
-		// Autoremove: wait for the container to finish, retrieve
-		// the exit code and remove the container
-		if _, _, err := readBody(cli.call("POST", "/containers/"+createResponse.ID+"/wait", nil, nil)); err != nil {
-			return err
-		}
-		if _, status, err = getExitCode(cli, createResponse.ID); err != nil {
+	if removed != nil {
+		// Autoremove: the exit code comes with the removal of the container
+		if status, err = readWaitStatus(removed); err != nil {
 			return err
 		}
 	} else {
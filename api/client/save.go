@@ -17,6 +17,7 @@ import (
 func (cli *DockerCli) CmdSave(args ...string) error {
 	cmd := cli.Subcmd("save", "IMAGE [IMAGE...]", "Save an image(s) to a tar archive (streamed to STDOUT by default)", true)
 	outfile := cmd.String([]string{"o", "-output"}, "", "Write to an file, instead of STDOUT")
+	compress := cmd.String([]string{"-compress"}, "", "Compress the archive with gzip or xz")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
@@ -34,13 +35,16 @@ func (cli *DockerCli) CmdSave(args ...string) error {
 		return errors.New("Cowardly refusing to save to a terminal. Use the -o flag or redirect.")
 	}
 
+	v := url.Values{}
+	if *compress != "" {
+		v.Set("compress", *compress)
+	}
 	if len(cmd.Args()) == 1 {
 		image := cmd.Arg(0)
-		if err := cli.stream("GET", "/images/"+image+"/get", nil, output, nil); err != nil {
+		if err := cli.stream("GET", "/images/"+image+"/get?"+v.Encode(), nil, output, nil); err != nil {
 			return err
 		}
 	} else {
-		v := url.Values{}
 		for _, arg := range cmd.Args() {
 			v.Add("names", arg)
 		}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSSHSocket is the daemon socket on the remote host of an ssh://
+// address that doesn't name one.
+const defaultSSHSocket = "/var/run/docker.sock"
+
+// sshArgs returns the arguments of the ssh command relaying the connection
+// to the daemon socket of addr, [user@]host[:port][/path]. The remote command
+// is run by the shell of the remote user, so the path of the socket is
+// quoted.
+func sshArgs(addr string) []string {
+	target, socket := addr, defaultSSHSocket
+	if i := strings.Index(addr, "/"); i >= 0 {
+		target, socket = addr[:i], addr[i:]
+	}
+	var args []string
+	if i := strings.LastIndex(target, ":"); i >= 0 {
+		args = append(args, "-p", target[i+1:])
+		target = target[:i]
+	}
+	return append(args, "--", target, "docker", "-H", shellQuote("unix://"+socket), "system", "dial-stdio")
+}
+
+// shellQuote quotes s as a single word for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// dialSSH connects to the daemon behind addr by running `docker system
+// dial-stdio` on the remote host through ssh. The messages of ssh, such as
+// password prompts or host key warnings, go to stderr.
+func dialSSH(addr string, stderr io.Writer) (net.Conn, error) {
+	cmd := exec.Command("ssh", sshArgs(addr)...)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, addr: commandAddr(addr)}, nil
+}
+
+// commandConn is a connection over the standard input and output of a
+// command.
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	addr      commandAddr
+	closeOnce sync.Once
+}
+
+func (c *commandConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+func (c *commandConn) Write(b []byte) (int, error) {
+	return c.stdin.Write(b)
+}
+
+// CloseWrite sends EOF to the command, as the hijacked connections do once
+// they have sent all of stdin.
+func (c *commandConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.cmd.Process.Kill()
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// The standard streams of a command have no deadlines.
+func (c *commandConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+type commandAddr string
+
+func (a commandAddr) Network() string {
+	return "ssh"
+}
+
+func (a commandAddr) String() string {
+	return string(a)
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestSSHArgs(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected []string
+	}{
+		{"host", []string{"--", "host", "docker", "-H", "'unix:///var/run/docker.sock'", "system", "dial-stdio"}},
+		{"jdoe@host:2222/run/user/1000/docker.sock", []string{"-p", "2222", "--", "jdoe@host", "docker", "-H", "'unix:///run/user/1000/docker.sock'", "system", "dial-stdio"}},
+		{"host/tmp/$(reboot);'x'.sock", []string{"--", "host", "docker", "-H", `'unix:///tmp/$(reboot);'\''x'\''.sock'`, "system", "dial-stdio"}},
+	}
+	for _, test := range tests {
+		if args := sshArgs(test.addr); !reflect.DeepEqual(args, test.expected) {
+			t.Errorf("Expected %q for %s, got %q", test.expected, test.addr, args)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("No shell")
+	}
+	for _, s := range []string{"plain", "with space", "$(echo no)", "it's", "`x`;|&<>\"\\"} {
+		out, err := exec.Command("sh", "-c", "printf %s "+shellQuote(s)).Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != s {
+			t.Errorf("Expected the shell to get %q, got %q", s, out)
+		}
+	}
+}
@@ -4,14 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"sort"
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/docker/docker/api/types"
-	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/units"
 )
 
@@ -27,10 +29,16 @@ type containerStats struct {
 	err              error
 }
 
-func (s *containerStats) Collect(cli *DockerCli) {
-	stream, _, err := cli.call("GET", "/containers/"+s.Name+"/stats", nil, nil)
+func (s *containerStats) Collect(cli *DockerCli, streamStats bool) {
+	v := url.Values{}
+	if !streamStats {
+		v.Set("stream", "0")
+	}
+	stream, _, err := cli.call("GET", "/containers/"+s.Name+"/stats?"+v.Encode(), nil, nil)
 	if err != nil {
+		s.mu.Lock()
 		s.err = err
+		s.mu.Unlock()
 		return
 	}
 	defer stream.Close()
@@ -49,11 +57,17 @@ func (s *containerStats) Collect(cli *DockerCli) {
 				return
 			}
 			var (
-				memPercent = float64(v.MemoryStats.Usage) / float64(v.MemoryStats.Limit) * 100.0
+				memPercent = 0.0
 				cpuPercent = 0.0
 			)
+			// the limit of a stopped container is zero
+			if v.MemoryStats.Limit != 0 {
+				memPercent = float64(v.MemoryStats.Usage) / float64(v.MemoryStats.Limit) * 100.0
+			}
 			if !start {
 				cpuPercent = calculateCPUPercent(previousCPU, previousSystem, v)
+			} else if !streamStats {
+				cpuPercent = calculateCPUPercent(v.PreCpuStats.CpuUsage.TotalUsage, v.PreCpuStats.SystemUsage, v)
 			}
 			start = false
 			s.mu.Lock()
@@ -67,8 +81,19 @@ func (s *containerStats) Collect(cli *DockerCli) {
 			previousCPU = v.CpuStats.CpuUsage.TotalUsage
 			previousSystem = v.CpuStats.SystemUsage
 			u <- nil
+			if !streamStats {
+				return
+			}
 		}
 	}()
+	if !streamStats {
+		if err := <-u; err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+		}
+		return
+	}
 	for {
 		select {
 		case <-time.After(2 * time.Second):
@@ -90,12 +115,27 @@ func (s *containerStats) Collect(cli *DockerCli) {
 	}
 }
 
-func (s *containerStats) Display(w io.Writer) error {
+func (s *containerStats) Display(w io.Writer, tmpl *template.Template) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if s.err != nil {
 		return s.err
 	}
+	if tmpl != nil {
+		ctx := statsContext{
+			Container: s.Name,
+			CPUPerc:   fmt.Sprintf("%.2f%%", s.CPUPercentage),
+			MemUsage:  units.BytesSize(s.Memory),
+			MemLimit:  units.BytesSize(s.MemoryLimit),
+			MemPerc:   fmt.Sprintf("%.2f%%", s.MemoryPercentage),
+			NetIO:     units.BytesSize(s.NetworkRx) + "/" + units.BytesSize(s.NetworkTx),
+		}
+		if err := tmpl.Execute(w, ctx); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w)
+		return err
+	}
 	fmt.Fprintf(w, "%s\t%.2f%%\t%s/%s\t%.2f%%\t%s/%s\n",
 		s.Name,
 		s.CPUPercentage,
@@ -105,35 +145,81 @@ func (s *containerStats) Display(w io.Writer) error {
 	return nil
 }
 
+// statsContext holds what --format templates of docker stats can refer to,
+// for each container.
+type statsContext struct {
+	Container string
+	CPUPerc   string
+	MemUsage  string
+	MemLimit  string
+	MemPerc   string
+	NetIO     string
+}
+
 // CmdStats displays a live stream of resource usage statistics for one or more containers.
 //
 // This shows real-time information on CPU usage, memory usage, and network I/O.
+// Without any container, it shows the running containers, or all of them with --all.
 //
-// Usage: docker stats CONTAINER [CONTAINER...]
+// Usage: docker stats [OPTIONS] [CONTAINER...]
 func (cli *DockerCli) CmdStats(args ...string) error {
-	cmd := cli.Subcmd("stats", "CONTAINER [CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
-	cmd.Require(flag.Min, 1)
+	cmd := cli.Subcmd("stats", "[CONTAINER...]", "Display a live stream of one or more containers' resource usage statistics", true)
+	all := cmd.Bool([]string{"a", "-all"}, false, "Show all containers (default shows just running)")
+	noStream := cmd.Bool([]string{"-no-stream"}, false, "Disable streaming stats and only pull the first result")
+	format := cmd.String([]string{"-format"}, "", "Pretty-print stats using a Go template")
 	cmd.ParseFlags(args, true)
 
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
+
 	names := cmd.Args()
+	if len(names) == 0 {
+		var err error
+		if names, err = cli.statsContainerNames(*all); err != nil {
+			return err
+		}
+	}
 	sort.Strings(names)
 	var (
 		cStats []*containerStats
 		w      = tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
 	)
 	printHeader := func() {
-		io.WriteString(cli.out, "\033[2J")
-		io.WriteString(cli.out, "\033[H")
-		io.WriteString(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O\n")
+		if !*noStream {
+			io.WriteString(cli.out, "\033[2J")
+			io.WriteString(cli.out, "\033[H")
+		}
+		if tmpl == nil {
+			io.WriteString(w, "CONTAINER\tCPU %\tMEM USAGE/LIMIT\tMEM %\tNET I/O\n")
+		}
 	}
+	var wg sync.WaitGroup
 	for _, n := range names {
 		s := &containerStats{Name: n}
 		cStats = append(cStats, s)
-		go s.Collect(cli)
+		if *noStream {
+			wg.Add(1)
+			go func() {
+				s.Collect(cli, false)
+				wg.Done()
+			}()
+		} else {
+			go s.Collect(cli, true)
+		}
+	}
+	if *noStream {
+		// wait for the single sample of every container.
+		wg.Wait()
+	} else {
+		// do a quick pause so that any failed connections for containers that do not exist are able to be
+		// evicted before we display the initial or default values.
+		time.Sleep(500 * time.Millisecond)
 	}
-	// do a quick pause so that any failed connections for containers that do not exist are able to be
-	// evicted before we display the initial or default values.
-	time.Sleep(500 * time.Millisecond)
 	var errs []string
 	for _, c := range cStats {
 		c.mu.Lock()
@@ -145,11 +231,20 @@ func (cli *DockerCli) CmdStats(args ...string) error {
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, ", "))
 	}
+	if *noStream {
+		printHeader()
+		for _, s := range cStats {
+			if err := s.Display(w, tmpl); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	}
 	for _ = range time.Tick(500 * time.Millisecond) {
 		printHeader()
 		toRemove := []int{}
 		for i, s := range cStats {
-			if err := s.Display(w); err != nil {
+			if err := s.Display(w, tmpl); err != nil {
 				toRemove = append(toRemove, i)
 			}
 		}
@@ -165,6 +260,38 @@ func (cli *DockerCli) CmdStats(args ...string) error {
 	return nil
 }
 
+// statsContainerNames returns the names of the running containers, or of all
+// of them, to show the stats of when none is given.
+func (cli *DockerCli) statsContainerNames(all bool) ([]string, error) {
+	v := url.Values{}
+	if all {
+		v.Set("all", "1")
+	}
+	rdr, _, err := cli.call("GET", "/containers/json?"+v.Encode(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rdr.Close()
+
+	containers := []types.Container{}
+	if err := json.NewDecoder(rdr).Decode(&containers); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, container := range containers {
+		name := stringid.TruncateID(container.ID)
+		// the default name of a container is the one that isn't a link
+		for _, n := range container.Names {
+			if strings.Count(n, "/") == 1 {
+				name = n[1:]
+				break
+			}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
 func calculateCPUPercent(previousCPU, previousSystem uint64, v *types.Stats) float64 {
 	var (
 		cpuPercent = 0.0
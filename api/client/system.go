@@ -0,0 +1,334 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/parsers/filters"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdSystem lists the commands that manage Docker as a whole.
+//
+// Usage: docker system COMMAND
+func (cli *DockerCli) CmdSystem(args ...string) error {
+	description := "Manage Docker\n\nCommands:\n"
+	for _, command := range [][]string{
+		{"bench", "Measure the performance of the storage driver"},
+		{"df", "Show docker disk usage"},
+		{"prune", "Remove unused data"},
+	} {
+		description += fmt.Sprintf("    %-10.10s%s\n", command[0], command[1])
+	}
+	cmd := cli.Subcmd("system", "COMMAND", strings.TrimSuffix(description, "\n"), true)
+	cmd.ParseFlags(args, true)
+	if cmd.NArg() > 0 {
+		return fmt.Errorf("docker: 'system %s' is not a docker command. See 'docker system --help'.", cmd.Arg(0))
+	}
+	cmd.Usage()
+	return nil
+}
+
+// CmdSystemDialStdio relays its standard input and output to the daemon. It
+// is what the client runs on the remote host of an ssh:// address, and is
+// left out of the list of commands of `docker system`.
+//
+// Usage: docker system dial-stdio
+func (cli *DockerCli) CmdSystemDialStdio(args ...string) error {
+	cmd := cli.Subcmd("system dial-stdio", "", "Proxy the standard streams to the daemon", true)
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	conn, err := cli.dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	go func() {
+		io.Copy(conn, cli.in)
+		// Let the daemon close its end once the client is done.
+		if cw, ok := conn.(interface {
+			CloseWrite() error
+		}); ok {
+			cw.CloseWrite()
+		} else {
+			conn.Close()
+		}
+	}()
+	_, err = io.Copy(cli.out, conn)
+	return err
+}
+
+// CmdSystemDf shows the space used by the images, the containers and the
+// volumes of the daemon, and how much of it could be reclaimed.
+//
+// Usage: docker system df [OPTIONS]
+func (cli *DockerCli) CmdSystemDf(args ...string) error {
+	cmd := cli.Subcmd("system df", "", "Show docker disk usage", true)
+	verbose := cmd.Bool([]string{"v", "-verbose"}, false, "Show detailed information on space usage")
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	rdr, _, err := cli.call("GET", "/system/df", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var du types.DiskUsage
+	if err := json.NewDecoder(rdr).Decode(&du); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	if *verbose {
+		printDiskUsageDetails(w, &du)
+	} else {
+		printDiskUsageSummary(w, &du)
+	}
+	return w.Flush()
+}
+
+// printDiskUsageSummary prints the space used by each type of object. The
+// reclaimable space is what removing the images no container uses, the
+// stopped containers and the volumes no container uses would free.
+func printDiskUsageSummary(w *tabwriter.Writer, du *types.DiskUsage) {
+	var (
+		activeImages, activeContainers, activeVolumes int
+		imagesReclaimable                             int64
+		containersSize, containersReclaimable         int64
+		volumesSize, volumesReclaimable               int64
+	)
+	for _, img := range du.Images {
+		if img.Containers > 0 {
+			activeImages++
+		} else {
+			imagesReclaimable += img.UniqueSize
+		}
+	}
+	for _, c := range du.Containers {
+		containersSize += c.SizeRw
+		if c.Running {
+			activeContainers++
+		} else {
+			containersReclaimable += c.SizeRw
+		}
+	}
+	for _, v := range du.Volumes {
+		volumesSize += v.Size
+		if v.Containers > 0 {
+			activeVolumes++
+		} else {
+			volumesReclaimable += v.Size
+		}
+	}
+
+	fmt.Fprintln(w, "TYPE\tTOTAL\tACTIVE\tSIZE\tRECLAIMABLE")
+	fmt.Fprintf(w, "Images\t%d\t%d\t%s\t%s\n", len(du.Images), activeImages, units.HumanSize(float64(du.LayersSize)), reclaimable(imagesReclaimable, du.LayersSize))
+	fmt.Fprintf(w, "Containers\t%d\t%d\t%s\t%s\n", len(du.Containers), activeContainers, units.HumanSize(float64(containersSize)), reclaimable(containersReclaimable, containersSize))
+	fmt.Fprintf(w, "Local Volumes\t%d\t%d\t%s\t%s\n", len(du.Volumes), activeVolumes, units.HumanSize(float64(volumesSize)), reclaimable(volumesReclaimable, volumesSize))
+}
+
+func reclaimable(size, total int64) string {
+	percent := 0
+	if total > 0 {
+		percent = int(size * 100 / total)
+	}
+	return fmt.Sprintf("%s (%d%%)", units.HumanSize(float64(size)), percent)
+}
+
+func printDiskUsageDetails(w *tabwriter.Writer, du *types.DiskUsage) {
+	fmt.Fprintln(w, "Images space usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tSIZE\tSHARED SIZE\tUNIQUE SIZE\tCONTAINERS")
+	for _, img := range du.Images {
+		for _, repoTag := range img.RepoTags {
+			repo, tag := parsers.ParseRepositoryTag(repoTag)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s ago\t%s\t%s\t%s\t%d\n", repo, tag, stringid.TruncateID(img.ID),
+				units.HumanDuration(time.Now().UTC().Sub(time.Unix(int64(img.Created), 0))),
+				units.HumanSize(float64(img.VirtualSize)), units.HumanSize(float64(img.SharedSize)),
+				units.HumanSize(float64(img.UniqueSize)), img.Containers)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Containers space usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "CONTAINER ID\tIMAGE\tSIZE\tSIZE LIMIT\tSTATUS\tNAMES")
+	for _, c := range du.Containers {
+		var names []string
+		for _, name := range c.Names {
+			names = append(names, strings.TrimPrefix(name, "/"))
+		}
+		limit := "-"
+		if c.SizeLimit > 0 {
+			limit = units.HumanSize(float64(c.SizeLimit))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", stringid.TruncateID(c.ID), c.Image,
+			units.HumanSize(float64(c.SizeRw)), limit, c.Status, strings.Join(names, ","))
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Local Volumes space usage:")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "VOLUME ID\tCONTAINERS\tSIZE")
+	for _, v := range du.Volumes {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", stringid.TruncateID(v.ID), v.Containers, units.HumanSize(float64(v.Size)))
+	}
+}
+
+// CmdSystemPrune removes the stopped containers, the dangling images and the
+// volumes no container uses.
+//
+// Usage: docker system prune [OPTIONS]
+func (cli *DockerCli) CmdSystemPrune(args ...string) error {
+	cmd := cli.Subcmd("system prune", "", "Remove unused data", true)
+	force := cmd.Bool([]string{"f", "-force"}, false, "Do not prompt for confirmation")
+	flFilter := opts.NewListOpts(nil)
+	cmd.Var(&flFilter, []string{"-filter"}, "Provide filter values (e.g. 'until=24h')")
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	pruneFilterArgs := filters.Args{}
+	for _, f := range flFilter.GetAll() {
+		var err error
+		pruneFilterArgs, err = filters.ParseFlag(f, pruneFilterArgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !*force {
+		fmt.Fprint(cli.out, "WARNING! This will remove:\n"+
+			"\t- all stopped containers\n"+
+			"\t- all dangling images\n")
+		if len(pruneFilterArgs) == 0 {
+			fmt.Fprint(cli.out, "\t- all volumes not used by at least one container\n")
+		}
+		fmt.Fprint(cli.out, "Are you sure you want to continue? [y/N] ")
+		answer, _ := bufio.NewReader(cli.in).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return nil
+		}
+	}
+
+	v := url.Values{}
+	if len(pruneFilterArgs) > 0 {
+		filterJSON, err := filters.ToParam(pruneFilterArgs)
+		if err != nil {
+			return err
+		}
+		v.Set("filters", filterJSON)
+	}
+	rdr, _, err := cli.call("POST", "/system/prune?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var report types.SystemPruneReport
+	if err := json.NewDecoder(rdr).Decode(&report); err != nil {
+		return err
+	}
+
+	if len(report.ContainersDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Containers:")
+		for _, id := range report.ContainersDeleted {
+			fmt.Fprintln(cli.out, id)
+		}
+		fmt.Fprintln(cli.out)
+	}
+	if len(report.ImagesDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Images:")
+		for _, img := range report.ImagesDeleted {
+			if img.Untagged != "" {
+				fmt.Fprintf(cli.out, "Untagged: %s\n", img.Untagged)
+			} else if img.Deleted != "" {
+				fmt.Fprintf(cli.out, "Deleted: %s\n", img.Deleted)
+			}
+		}
+		fmt.Fprintln(cli.out)
+	}
+	if len(report.VolumesDeleted) > 0 {
+		fmt.Fprintln(cli.out, "Deleted Volumes:")
+		for _, id := range report.VolumesDeleted {
+			fmt.Fprintln(cli.out, id)
+		}
+		fmt.Fprintln(cli.out)
+	}
+	fmt.Fprintf(cli.out, "Total reclaimed space: %s\n", units.HumanSize(float64(report.SpaceReclaimed)))
+	return nil
+}
+
+// CmdSystemBench measures how long the operations of the storage driver of
+// the daemon take on its backing filesystem.
+//
+// Usage: docker system bench [OPTIONS]
+func (cli *DockerCli) CmdSystemBench(args ...string) error {
+	cmd := cli.Subcmd("system bench", "", "Measure the performance of the storage driver", true)
+	layers := cmd.Int([]string{"-layers"}, 10, "Number of layers to create on top of each other")
+	files := cmd.Int([]string{"-files"}, 100, "Number of files to write to each layer")
+	fileSize := cmd.String([]string{"-file-size"}, "64k", "Size of the files written to the layers")
+	cmd.Require(flag.Exact, 0)
+	cmd.ParseFlags(args, true)
+
+	v := url.Values{}
+	v.Set("layers", strconv.Itoa(*layers))
+	v.Set("files", strconv.Itoa(*files))
+	v.Set("filesize", *fileSize)
+	rdr, _, err := cli.call("POST", "/system/bench?"+v.Encode(), nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	var bench types.StorageBenchmark
+	if err := json.NewDecoder(rdr).Decode(&bench); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cli.out, "Storage Driver: %s\n", bench.Driver)
+	if bench.BackingFilesystem != "" {
+		fmt.Fprintf(cli.out, "Backing Filesystem: %s\n", bench.BackingFilesystem)
+	}
+	fmt.Fprintf(cli.out, "Layers: %d, of %d files of %s\n\n", bench.Layers, bench.Files, units.BytesSize(float64(bench.FileSize)))
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tRUNS\tMIN\tAVERAGE\tMAX\tTHROUGHPUT")
+	for _, r := range bench.Results {
+		throughput := "-"
+		if r.Bytes > 0 && r.Average > 0 {
+			throughput = units.HumanSize(float64(r.Bytes)/time.Duration(r.Average).Seconds()) + "/s"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", r.Operation, r.Count,
+			benchDuration(r.Min), benchDuration(r.Average), benchDuration(r.Max), throughput)
+	}
+	return w.Flush()
+}
+
+// benchDuration formats the duration of ns nanoseconds with 2 decimals in
+// the largest unit it has.
+func benchDuration(ns int64) string {
+	d := time.Duration(ns)
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.2fµs", float64(d)/float64(time.Microsecond))
+	}
+}
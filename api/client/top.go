@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"strings"
 	"text/tabwriter"
+	"text/template"
 
 	"github.com/docker/docker/engine"
 	flag "github.com/docker/docker/pkg/mflag"
@@ -12,17 +13,30 @@ import (
 
 // CmdTop displays the running processes of a container.
 //
-// Usage: docker top CONTAINER
+// Usage: docker top [OPTIONS] CONTAINER [ps OPTIONS]
 func (cli *DockerCli) CmdTop(args ...string) error {
 	cmd := cli.Subcmd("top", "CONTAINER [ps OPTIONS]", "Display the running processes of a container", true)
+	fields := cmd.String([]string{"-fields"}, "", "Comma-separated ps format fields to show, e.g. pid,user,args")
+	format := cmd.String([]string{"-format"}, "", "Pretty-print processes using a Go template")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
+
 	val := url.Values{}
 	if cmd.NArg() > 1 {
 		val.Set("ps_args", strings.Join(cmd.Args()[1:], " "))
 	}
+	if *fields != "" {
+		val.Set("fields", *fields)
+	}
 
 	stream, _, err := cli.call("GET", "/containers/"+cmd.Arg(0)+"/top?"+val.Encode(), nil, nil)
 	if err != nil {
@@ -32,12 +46,31 @@ func (cli *DockerCli) CmdTop(args ...string) error {
 	if err := procs.Decode(stream); err != nil {
 		return err
 	}
-	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
-	fmt.Fprintln(w, strings.Join(procs.GetList("Titles"), "\t"))
+	titles := procs.GetList("Titles")
 	processes := [][]string{}
 	if err := procs.GetJson("Processes", &processes); err != nil {
 		return err
 	}
+
+	if tmpl != nil {
+		// each process is a map of the titles of the columns to its values
+		for _, proc := range processes {
+			process := make(map[string]string, len(titles))
+			for i, title := range titles {
+				if i < len(proc) {
+					process[title] = proc[i]
+				}
+			}
+			if err := tmpl.Execute(cli.out, process); err != nil {
+				return err
+			}
+			fmt.Fprintln(cli.out)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cli.out, 20, 1, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(titles, "\t"))
 	for _, proc := range processes {
 		fmt.Fprintln(w, strings.Join(proc, "\t"))
 	}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/units"
+)
+
+// CmdUpdate updates the resource limits of one or more containers.
+//
+// Usage: docker update [OPTIONS] CONTAINER [CONTAINER...]
+func (cli *DockerCli) CmdUpdate(args ...string) error {
+	cmd := cli.Subcmd("update", "CONTAINER [CONTAINER...]", "Update the resource limits of one or more containers", true)
+	flMemory := cmd.String([]string{"m", "-memory"}, "", "Memory limit")
+	flMemorySwap := cmd.String([]string{"-memory-swap"}, "", "Total memory (memory + swap), '-1' to disable swap")
+	flCpuShares := cmd.Int64([]string{"c", "-cpu-shares"}, -1, "CPU shares (relative weight)")
+	flCpuPeriod := cmd.Int64([]string{"-cpu-period"}, -1, "Limit the CPU CFS (Completely Fair Scheduler) period")
+	flCpuQuota := cmd.Int64([]string{"-cpu-quota"}, -1, "Limit the CPU CFS (Completely Fair Scheduler) quota")
+	flCpusetCpus := cmd.String([]string{"-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+	flBlkioWeight := cmd.Int64([]string{"-blkio-weight"}, -1, "Block IO (relative weight), between 10 and 1000")
+	cmd.Require(flag.Min, 1)
+	cmd.ParseFlags(args, true)
+
+	// Only the limits given on the command line are changed.
+	config := map[string]interface{}{}
+	if *flMemory != "" {
+		memory, err := units.RAMInBytes(*flMemory)
+		if err != nil {
+			return err
+		}
+		config["Memory"] = memory
+	}
+	if *flMemorySwap != "" {
+		var memorySwap int64 = -1
+		if *flMemorySwap != "-1" {
+			var err error
+			if memorySwap, err = units.RAMInBytes(*flMemorySwap); err != nil {
+				return err
+			}
+		}
+		config["MemorySwap"] = memorySwap
+	}
+	if *flCpuShares >= 0 {
+		config["CpuShares"] = *flCpuShares
+	}
+	if *flCpuPeriod >= 0 {
+		config["CpuPeriod"] = *flCpuPeriod
+	}
+	if *flCpuQuota >= 0 {
+		config["CpuQuota"] = *flCpuQuota
+	}
+	if *flCpusetCpus != "" {
+		config["CpusetCpus"] = *flCpusetCpus
+	}
+	if *flBlkioWeight >= 0 {
+		config["BlkioWeight"] = *flBlkioWeight
+	}
+	if len(config) == 0 {
+		return fmt.Errorf("You must provide one or more limits to update")
+	}
+
+	var encounteredError error
+	for _, name := range cmd.Args() {
+		stream, _, err := cli.call("POST", fmt.Sprintf("/containers/%s/update", name), config, nil)
+		if err != nil {
+			fmt.Fprintf(cli.err, "%s\n", err)
+			encounteredError = fmt.Errorf("Error: failed to update container named %s", name)
+			continue
+		}
+		var response types.ContainerUpdateResponse
+		err = json.NewDecoder(stream).Decode(&response)
+		stream.Close()
+		if err != nil {
+			return err
+		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintf(cli.err, "WARNING: %s\n", warning)
+		}
+		fmt.Fprintf(cli.out, "%s\n", name)
+	}
+	return encounteredError
+}
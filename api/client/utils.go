@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -19,11 +20,13 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/autogen/dockerversion"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/registry"
 )
@@ -61,11 +64,13 @@ func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers m
 	if expectedPayload && in == nil {
 		in = bytes.NewReader([]byte{})
 	}
-	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", api.APIVERSION, path), in)
+	req, err := http.NewRequest(method, fmt.Sprintf("/v%s%s", cli.apiVersion, path), in)
 	if err != nil {
 		return nil, "", -1, err
 	}
 	req.Header.Set("User-Agent", "Docker-Client/"+dockerversion.VERSION)
+	requestID := stringid.TruncateID(stringid.GenerateRandomID())
+	req.Header.Set("X-Request-Id", requestID)
 	req.URL.Host = cli.addr
 	req.URL.Scheme = cli.scheme
 
@@ -79,7 +84,7 @@ func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers m
 		req.Header.Set("Content-Type", "text/plain")
 	}
 
-	resp, err := cli.HTTPClient().Do(req)
+	resp, err := cli.doRequest(req)
 	statusCode := -1
 	if resp != nil {
 		statusCode = resp.StatusCode
@@ -103,12 +108,81 @@ func (cli *DockerCli) clientRequest(method, path string, in io.Reader, headers m
 		if len(body) == 0 {
 			return nil, "", statusCode, fmt.Errorf("Error: request returned %s for API route and version %s, check if the server supports the requested API version", http.StatusText(statusCode), req.URL)
 		}
+		if api.MatchesContentType(resp.Header.Get("Content-Type"), "application/json") {
+			var jerr types.ErrorResponse
+			if err := json.Unmarshal(body, &jerr); err == nil && jerr.Message != "" {
+				body = []byte(jerr.Message)
+			}
+		}
+		logrus.Debugf("Request %s %s failed, request id %s", method, path, requestID)
 		return nil, "", statusCode, fmt.Errorf("Error response from daemon: %s", bytes.TrimSpace(body))
 	}
 
 	return resp.Body, resp.Header.Get("Content-Type"), statusCode, nil
 }
 
+// doRequest sends req, retrying it with an exponential backoff, as many times
+// as set by --retries, if it is idempotent and fails with a transient error
+// before the daemon processed it: while the daemon restarts, for instance.
+func (cli *DockerCli) doRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := cli.HTTPClient().Do(req)
+		if attempt >= cli.retries || !isIdempotent(req.Method) || !isTransient(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		delay := retryDelay(attempt)
+		logrus.Debugf("Request %s %s failed, retrying in %s", req.Method, req.URL.Path, delay)
+		time.Sleep(delay)
+	}
+}
+
+// isIdempotent tells whether a request with method can be sent again without
+// changing its effect. None of these requests of the client has a body that
+// would have to be sent again.
+func isIdempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "DELETE":
+		return true
+	}
+	return false
+}
+
+// isTransient tells whether the outcome of a request is an error that can go
+// away if it is retried, and that means the daemon didn't process it: failing
+// to connect to the daemon, which isn't listening yet, or a 502, 503 or 504
+// status, from a proxy in front of the daemon for instance. The other server
+// errors and the timeouts of requests the daemon may have processed aren't.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		if operr, ok := err.(*net.OpError); ok && operr.Op == "dial" {
+			return true
+		}
+		// The unix socket doesn't exist while the daemon restarts.
+		return strings.Contains(err.Error(), "connection refused") || strings.Contains(err.Error(), "no such file or directory")
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay is how long to wait before the retry following attempt: 500ms,
+// then doubling up to 8s.
+func retryDelay(attempt int) time.Duration {
+	delay := 500 * time.Millisecond
+	for i := 0; i < attempt && delay < 8*time.Second; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
 func (cli *DockerCli) clientRequestAttemptLogin(method, path string, in io.Reader, out io.Writer, index *registry.IndexInfo, cmdName string) (io.ReadCloser, int, error) {
 	cmdAttempt := func(authConfig registry.AuthConfig) (io.ReadCloser, int, error) {
 		buf, err := json.Marshal(authConfig)
@@ -141,9 +215,17 @@ func (cli *DockerCli) clientRequestAttemptLogin(method, path string, in io.Reade
 	}
 
 	// Resolve the Auth config relevant for this server
+	cli.loginLock.Lock()
 	authConfig := cli.configFile.ResolveAuthConfig(index)
+	cli.loginLock.Unlock()
 	body, statusCode, err := cmdAttempt(authConfig)
 	if statusCode == http.StatusUnauthorized {
+		cli.loginLock.Lock()
+		defer cli.loginLock.Unlock()
+		// Another operation run in parallel may have logged in meanwhile.
+		if newAuthConfig := cli.configFile.ResolveAuthConfig(index); newAuthConfig != authConfig {
+			return cmdAttempt(newAuthConfig)
+		}
 		fmt.Fprintf(cli.out, "\nPlease login prior to %s:\n", cmdName)
 		if err = cli.CmdLogin(index.GetAuthConfigKey()); err != nil {
 			return nil, -1, err
@@ -187,7 +269,10 @@ func (cli *DockerCli) streamBody(body io.ReadCloser, contentType string, setRawT
 	defer body.Close()
 
 	if api.MatchesContentType(contentType, "application/json") {
-		return jsonmessage.DisplayJSONMessagesStream(body, stdout, cli.outFd, cli.isTerminalOut)
+		// Progress bars aren't drawn in the buffers of the operations
+		// run in parallel.
+		_, buffered := stdout.(*bytes.Buffer)
+		return jsonmessage.DisplayJSONMessagesStream(body, stdout, cli.outFd, cli.isTerminalOut && !buffered)
 	}
 	if stdout != nil || stderr != nil {
 		// When TTY is ON, use regular copy
@@ -237,6 +322,19 @@ func waitForExit(cli *DockerCli, containerID string) (int, error) {
 	return out.GetInt("StatusCode"), nil
 }
 
+// readWaitStatus reads the response of a wait for a container from stream,
+// and returns the exit code of the container.
+func readWaitStatus(stream io.Reader) (int, error) {
+	var resp types.ContainerWaitResponse
+	if err := json.NewDecoder(stream).Decode(&resp); err != nil {
+		return -1, err
+	}
+	if resp.Error != "" {
+		return -1, errors.New(resp.Error)
+	}
+	return resp.StatusCode, nil
+}
+
 // getExitCode perform an inspect on the container. It returns
 // the running state and the exit code.
 func getExitCode(cli *DockerCli, containerID string) (bool, int, error) {
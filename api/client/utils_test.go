@@ -0,0 +1,37 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	dialErr := &url.Error{Op: "Get", URL: "http://unix.sock/v1.19/info", Err: &net.OpError{Op: "dial", Net: "unix", Err: errors.New("no such file or directory")}}
+	readErr := &url.Error{Op: "Get", URL: "http://unix.sock/v1.19/info", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}}
+	for _, tc := range []struct {
+		status    int
+		err       error
+		transient bool
+	}{
+		{err: dialErr, transient: true},
+		{err: errors.New("dial unix /var/run/docker.sock: connection refused"), transient: true},
+		{err: readErr, transient: false},
+		{status: http.StatusBadGateway, transient: true},
+		{status: http.StatusServiceUnavailable, transient: true},
+		{status: http.StatusGatewayTimeout, transient: true},
+		{status: http.StatusInternalServerError, transient: false},
+		{status: http.StatusNotFound, transient: false},
+		{status: http.StatusOK, transient: false},
+	} {
+		var resp *http.Response
+		if tc.err == nil {
+			resp = &http.Response{StatusCode: tc.status}
+		}
+		if transient := isTransient(resp, tc.err); transient != tc.transient {
+			t.Errorf("Expected %d, %v to be transient: %t", tc.status, tc.err, tc.transient)
+		}
+	}
+}
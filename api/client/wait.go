@@ -2,29 +2,85 @@ package client
 
 import (
 	"fmt"
+	"text/template"
 
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
+// waitResult holds what --format templates of docker wait can refer to, for
+// each container.
+type waitResult struct {
+	Container  string
+	StatusCode int
+	Error      string `json:",omitempty"`
+}
+
 // CmdWait blocks until a container stops, then prints its exit code.
 //
-// If more than one container is specified, this will wait synchronously on each container.
+// If more than one container is specified, this will wait concurrently on
+// every container. The exit codes are printed in the order of the arguments,
+// or as they arrive with --format.
 //
-// Usage: docker wait CONTAINER [CONTAINER...]
+// Usage: docker wait [OPTIONS] CONTAINER [CONTAINER...]
 func (cli *DockerCli) CmdWait(args ...string) error {
 	cmd := cli.Subcmd("wait", "CONTAINER [CONTAINER...]", "Block until a container stops, then print its exit code.", true)
+	format := cmd.String([]string{"-format"}, "", "Print each exit code as it arrives using a Go template")
 	cmd.Require(flag.Min, 1)
 
 	cmd.ParseFlags(args, true)
 
-	var encounteredError error
-	for _, name := range cmd.Args() {
-		status, err := waitForExit(cli, name)
-		if err != nil {
-			fmt.Fprintf(cli.err, "%s\n", err)
+	var tmpl *template.Template
+	if *format != "" {
+		var err error
+		if tmpl, err = template.New("").Funcs(funcMap).Parse(*format); err != nil {
+			return fmt.Errorf("Template parsing error: %v", err)
+		}
+	}
+
+	type indexedResult struct {
+		index int
+		waitResult
+	}
+	names := cmd.Args()
+	results := make(chan indexedResult, len(names))
+	for i, name := range names {
+		go func(i int, name string) {
+			result := waitResult{Container: name}
+			status, err := waitForExit(cli, name)
+			if err != nil {
+				result.StatusCode = -1
+				result.Error = err.Error()
+			} else {
+				result.StatusCode = status
+			}
+			results <- indexedResult{i, result}
+		}(i, name)
+	}
+
+	var (
+		encounteredError error
+		pending          = make([]*waitResult, len(names))
+		next             = 0
+	)
+	for _ = range names {
+		r := <-results
+		if r.Error != "" {
 			encounteredError = fmt.Errorf("Error: failed to wait one or more containers")
-		} else {
-			fmt.Fprintf(cli.out, "%d\n", status)
+		}
+		if tmpl != nil {
+			if err := tmpl.Execute(cli.out, r.waitResult); err != nil {
+				return err
+			}
+			fmt.Fprintln(cli.out)
+			continue
+		}
+		pending[r.index] = &r.waitResult
+		for ; next < len(pending) && pending[next] != nil; next++ {
+			if pending[next].Error != "" {
+				fmt.Fprintf(cli.err, "%s\n", pending[next].Error)
+			} else {
+				fmt.Fprintf(cli.out, "%d\n", pending[next].StatusCode)
+			}
 		}
 	}
 	return encounteredError
@@ -3,9 +3,11 @@ package api
 import (
 	"fmt"
 	"mime"
+	"net"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Sirupsen/logrus"
@@ -19,6 +21,7 @@ import (
 // Common constants for daemon and client.
 const (
 	APIVERSION            version.Version = "1.19"                 // Current REST API version
+	APIMINVERSION         version.Version = "1.0"                  // Oldest REST API version the daemon still serves
 	DEFAULTHTTPHOST                       = "127.0.0.1"            // Default HTTP Host used if only port is provided to -H flag e.g. docker -d -H tcp://:8080
 	DEFAULTUNIXSOCKET                     = "/var/run/docker.sock" // Docker daemon by default always listens on the default unix socket
 	DefaultDockerfileName string          = "Dockerfile"           // Default filename with Docker commands, read by docker build
@@ -54,7 +57,7 @@ func DisplayablePorts(ports *engine.Table) string {
 		)
 		if port.Get("IP") != "" {
 			if port.GetInt("PublicPort") != current {
-				hostMappings = append(hostMappings, fmt.Sprintf("%s:%d->%d/%s", port.Get("IP"), port.GetInt("PublicPort"), port.GetInt("PrivatePort"), port.Get("Type")))
+				hostMappings = append(hostMappings, fmt.Sprintf("%s->%d/%s", net.JoinHostPort(port.Get("IP"), strconv.Itoa(port.GetInt("PublicPort"))), port.GetInt("PrivatePort"), port.Get("Type")))
 				continue
 			}
 			portKey = fmt.Sprintf("%s/%s", port.Get("IP"), port.Get("Type"))
@@ -109,7 +112,7 @@ func NewDisplayablePorts(ports []types.Port) string {
 		)
 		if port.IP != "" {
 			if port.PublicPort != current {
-				hostMappings = append(hostMappings, fmt.Sprintf("%s:%d->%d/%s", port.IP, port.PublicPort, port.PrivatePort, port.Type))
+				hostMappings = append(hostMappings, fmt.Sprintf("%s->%d/%s", net.JoinHostPort(port.IP, strconv.Itoa(port.PublicPort)), port.PrivatePort, port.Type))
 				continue
 			}
 			portKey = fmt.Sprintf("%s/%s", port.IP, port.Type)
@@ -155,7 +158,8 @@ func FormGroup(key string, start, last int) string {
 		group = fmt.Sprintf("%d-%d", start, last)
 	}
 	if ip != "" {
-		group = fmt.Sprintf("%s:%s->%s", ip, group, group)
+		// An IPv6 address is in brackets, as in [::1]:80->80/tcp
+		group = fmt.Sprintf("%s->%s", net.JoinHostPort(ip, group), group)
 	}
 	return fmt.Sprintf("%s/%s", group, groupType)
 }
@@ -168,6 +172,26 @@ func MatchesContentType(contentType, expectedType string) bool {
 	return err == nil && mimetype == expectedType
 }
 
+// AcceptsContentType reports whether the media ranges listed in an Accept
+// header include expectedType. Ranges with a quality of 0 are skipped and
+// wildcards are not expanded, so clients only get a non-default format when
+// they explicitly ask for it.
+func AcceptsContentType(accept, expectedType string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaRange = strings.TrimSpace(mediaRange)
+		if mediaRange == "" {
+			continue
+		}
+		if _, params, err := mime.ParseMediaType(mediaRange); err == nil && params["q"] == "0" {
+			continue
+		}
+		if MatchesContentType(mediaRange, expectedType) {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadOrCreateTrustKey attempts to load the libtrust key at the given path,
 // otherwise generates a new one
 func LoadOrCreateTrustKey(trustKeyPath string) (libtrust.PrivateKey, error) {
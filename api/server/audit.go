@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// auditHandler records every mutating API call, along with who made it and
+// how it turned out, as json documents, one per line: one as the request
+// arrives, and another once it is served. The first is all there is of
+// the calls that never return, such as a hung attach.
+type auditHandler struct {
+	handler http.Handler
+	logger  *logrus.Logger
+}
+
+// newAuditHandler wraps handler so that mutating calls are recorded to
+// dest, which is either the path of a log file or "syslog". An empty dest
+// disables auditing.
+func newAuditHandler(handler http.Handler, dest string) (http.Handler, error) {
+	if dest == "" {
+		return handler, nil
+	}
+	out, err := openAuditLog(dest)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening audit log %s: %v", dest, err)
+	}
+	logger := logrus.New()
+	logger.Out = out
+	logger.Formatter = &logrus.JSONFormatter{}
+	logger.Level = logrus.InfoLevel
+	return &auditHandler{handler: handler, logger: logger}, nil
+}
+
+func openAuditLog(dest string) (io.Writer, error) {
+	if dest == "syslog" {
+		return newSyslogWriter()
+	}
+	return os.OpenFile(dest, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+}
+
+func (h *auditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS" {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	body, err := peekBody(r, maxAuthzBodySize)
+	if err != nil {
+		httpError(w, r, err)
+		return
+	}
+	fields := logrus.Fields{
+		"request_id": r.Header.Get(requestIDHeader),
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"params":     r.URL.Query(),
+		"user":       requestUser(r),
+		"remote":     r.RemoteAddr,
+	}
+	if len(body) > 0 && int64(len(body)) <= maxAuthzBodySize {
+		if redacted, err := redactJSON(body); err == nil {
+			fields["body"] = string(redacted)
+		}
+	}
+	h.logger.WithFields(fields).Info("API request")
+
+	var (
+		start = time.Now()
+		sw    = &statusResponseWriter{ResponseWriter: w}
+	)
+	if _, ok := w.(http.Hijacker); ok {
+		h.handler.ServeHTTP(hijackableStatusResponseWriter{sw}, r)
+	} else {
+		h.handler.ServeHTTP(sw, r)
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"request_id": fields["request_id"],
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"user":       fields["user"],
+		"remote":     r.RemoteAddr,
+		"status":     sw.status,
+		"duration":   time.Since(start).String(),
+	}).Info("API call")
+}
+
+// redactedFields are the fields of json request bodies which are credentials,
+// such as the password of POST /auth, and never recorded.
+var redactedFields = map[string]bool{
+	"password":      true,
+	"auth":          true,
+	"identitytoken": true,
+	"registrytoken": true,
+}
+
+// redactJSON returns the json document body with the values of its
+// credentials fields, at any depth, replaced.
+func redactJSON(body []byte) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	var redact func(v interface{})
+	redact = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				if redactedFields[strings.ToLower(key)] {
+					v[key] = "<redacted>"
+					continue
+				}
+				redact(value)
+			}
+		case []interface{}:
+			for _, value := range v {
+				redact(value)
+			}
+		}
+	}
+	redact(doc)
+	return json.Marshal(doc)
+}
+
+// statusResponseWriter remembers the status code sent to the client.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// hijackableStatusResponseWriter is a statusResponseWriter whose underlying
+// writer can be hijacked.
+type hijackableStatusResponseWriter struct {
+	*statusResponseWriter
+}
+
+func (w hijackableStatusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	// The status line is written by the handler itself on the raw
+	// connection; record the switch of protocols.
+	w.status = http.StatusSwitchingProtocols
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
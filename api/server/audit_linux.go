@@ -0,0 +1,12 @@
+// +build linux
+
+package server
+
+import (
+	"io"
+	"log/syslog"
+)
+
+func newSyslogWriter() (io.Writer, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTHPRIV, "docker-audit")
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/authorization"
+	"github.com/docker/docker/pkg/ioutils"
+)
+
+// maxAuthzBodySize is the size of the largest request body forwarded to
+// authorization plugins. Larger requests are denied, since plugins only
+// judge requests they see whole.
+const maxAuthzBodySize = 1024 * 1024
+
+var (
+	// authzArchiveRoutes are the routes whose bodies are archives streamed
+	// to the daemon, such as build contexts, which plugins don't get.
+	authzArchiveRoutes = regexp.MustCompile(`^(/v[0-9.]+)?/(build|images/load|images/create|containers/.+/archive)$`)
+	// authzCredentialRoutes are the routes whose bodies hold registry
+	// credentials, which plugins never get.
+	authzCredentialRoutes = regexp.MustCompile(`^(/v[0-9.]+)?/(auth|images/.+/push)$`)
+)
+
+// authzHandler only lets a request reach the API once every configured
+// authorization plugin allowed it.
+type authzHandler struct {
+	handler http.Handler
+	plugins []*authorization.Plugin
+}
+
+func newAuthzHandler(handler http.Handler, plugins []string) http.Handler {
+	if len(plugins) == 0 {
+		return handler
+	}
+	return &authzHandler{handler: handler, plugins: authorization.NewPlugins(plugins)}
+}
+
+func (h *authzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &authorization.Request{
+		User:           requestUser(r),
+		RequestMethod:  r.Method,
+		RequestURI:     r.RequestURI,
+		RequestHeaders: make(map[string]string),
+	}
+	for name := range r.Header {
+		// Never hand out registry credentials to a plugin.
+		if name == "X-Registry-Auth" || name == "X-Registry-Config" || name == "Authorization" {
+			continue
+		}
+		req.RequestHeaders[name] = r.Header.Get(name)
+	}
+	var body []byte
+	if !authzCredentialRoutes.MatchString(r.URL.Path) {
+		var err error
+		if body, err = peekBody(r, maxAuthzBodySize); err != nil {
+			httpError(w, r, err)
+			return
+		}
+	}
+	if int64(len(body)) > maxAuthzBodySize {
+		logrus.Errorf("Refusing %s %s: body larger than %d bytes", r.Method, r.RequestURI, maxAuthzBodySize)
+		writeError(w, r, http.StatusForbidden, errCodeAuthorizationDenied, fmt.Sprintf("authorization denied: request bodies larger than %d bytes can't be authorized", maxAuthzBodySize))
+		return
+	}
+	req.RequestBody = body
+
+	if err := authorization.Authorize(h.plugins, req); err != nil {
+		logrus.Errorf("Refusing %s %s: %v", r.Method, r.RequestURI, err)
+		if _, ok := err.(*authorization.DeniedError); ok {
+			writeError(w, r, http.StatusForbidden, errCodeAuthorizationDenied, err.Error())
+		} else {
+			writeError(w, r, http.StatusInternalServerError, errCodeAuthorizationError, err.Error())
+		}
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// requestUser returns the identity of the client that made r, which is the
+// common name of its TLS client certificate if it presented one.
+func requestUser(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		return r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return ""
+}
+
+// peekBody returns the body of r, whatever its Content-Type, leaving the body
+// intact for the handler: handlers may decode bodies that aren't labeled as
+// json. Streamed archives are left out. It reads one byte more than max of
+// larger bodies, for the caller to tell them apart.
+func peekBody(r *http.Request, max int64) ([]byte, error) {
+	if r.Body == nil || authzArchiveRoutes.MatchString(r.URL.Path) {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, max+1))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = ioutils.NewReadCloserWrapper(io.MultiReader(bytes.NewReader(body), r.Body), r.Body.Close)
+	return body, nil
+}
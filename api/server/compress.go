@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api"
+)
+
+// compressHandler gzips JSON responses larger than threshold bytes for
+// clients sending "Accept-Encoding: gzip". A threshold of 0 or less disables
+// compression altogether.
+type compressHandler struct {
+	handler   http.Handler
+	threshold int
+}
+
+func newCompressHandler(handler http.Handler, threshold int) http.Handler {
+	if threshold <= 0 {
+		return handler
+	}
+	return &compressHandler{handler: handler, threshold: threshold}
+}
+
+// hijackingRoutes matches the paths of the routes that take over the
+// connection, whose responses are never compressed.
+var hijackingRoutes = regexp.MustCompile(`^(/v[0-9.]+)?/(containers/.+/attach(/ws)?|exec/.+/start|events/ws)$`)
+
+func (h *compressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !acceptsGzip(r.Header.Get("Accept-Encoding")) || hijackingRoutes.MatchString(r.URL.Path) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	cw := &compressResponseWriter{ResponseWriter: w, threshold: h.threshold}
+	defer cw.Close()
+	// Only offer hijacking when the connection supports it, as the
+	// streaming endpoints check, e.g. not on HTTP/2.
+	if _, ok := w.(http.Hijacker); ok {
+		h.handler.ServeHTTP(hijackableCompressResponseWriter{cw}, r)
+		return
+	}
+	h.handler.ServeHTTP(cw, r)
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(coding))
+		if err != nil {
+			continue
+		}
+		if name == "gzip" && params["q"] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// compressResponseWriter buffers the beginning of a response until it knows
+// whether the response is worth compressing: either the buffered body grows
+// past the threshold, or the handler completes. Streaming responses, which
+// flush before that point, and hijacked connections are never compressed.
+// It is wrapped in a hijackableCompressResponseWriter when the underlying
+// writer can be hijacked.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	threshold int
+	code      int
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	decided   bool
+	hijacked  bool
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.decided {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.code = code
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(p)
+		if w.buf.Len() >= w.threshold {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide sends the headers and the buffered body, compressed if the response
+// is a large enough JSON document and compression is still allowed.
+func (w *compressResponseWriter) decide(allowCompression bool) error {
+	w.decided = true
+	compress := allowCompression && w.buf.Len() >= w.threshold &&
+		api.MatchesContentType(w.Header().Get("Content-Type"), "application/json")
+	if compress {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if w.code != 0 {
+		w.ResponseWriter.WriteHeader(w.code)
+	}
+	if compress {
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		return err
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressResponseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Close completes the response once the handler has returned.
+func (w *compressResponseWriter) Close() error {
+	if w.hijacked {
+		return nil
+	}
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			return err
+		}
+	}
+	if w.gz != nil {
+		return w.gz.Close()
+	}
+	return nil
+}
+
+// hijackableCompressResponseWriter is a compressResponseWriter whose
+// underlying writer can be hijacked.
+type hijackableCompressResponseWriter struct {
+	*compressResponseWriter
+}
+
+func (w hijackableCompressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	w.hijacked = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
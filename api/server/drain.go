@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/engine"
+)
+
+var (
+	serversLock sync.Mutex
+	servers     []Server
+)
+
+// drainHandler counts the requests being served so that shutdown can wait
+// for them to complete.
+type drainHandler struct {
+	handler http.Handler
+
+	sync.Mutex
+	active   int
+	draining bool
+	idle     chan struct{} // closed once draining with no request left
+}
+
+func newDrainHandler(handler http.Handler) *drainHandler {
+	return &drainHandler{handler: handler, idle: make(chan struct{})}
+}
+
+func (h *drainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Event subscriptions never complete on their own, there is no point
+	// in waiting for them.
+	if strings.HasSuffix(r.URL.Path, "/events") || strings.HasSuffix(r.URL.Path, "/events/ws") {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	h.Lock()
+	h.active++
+	h.Unlock()
+	defer h.done()
+	h.handler.ServeHTTP(w, r)
+}
+
+func (h *drainHandler) done() {
+	h.Lock()
+	defer h.Unlock()
+	h.active--
+	if h.draining && h.active == 0 {
+		h.signalIdle()
+	}
+}
+
+// signalIdle must be called with the lock held.
+func (h *drainHandler) signalIdle() {
+	select {
+	case <-h.idle:
+	default:
+		close(h.idle)
+	}
+}
+
+// start switches to draining, it returns false if it already was.
+func (h *drainHandler) start() bool {
+	h.Lock()
+	defer h.Unlock()
+	if h.draining {
+		return false
+	}
+	h.draining = true
+	if h.active == 0 {
+		h.signalIdle()
+	}
+	return true
+}
+
+func (h *drainHandler) isDraining() bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.draining
+}
+
+// wait blocks until no request is being served anymore or timeout expires.
+// It returns the number of requests still running.
+func (h *drainHandler) wait(timeout time.Duration) int {
+	select {
+	case <-h.idle:
+	case <-time.After(timeout):
+	}
+	h.Lock()
+	defer h.Unlock()
+	return h.active
+}
+
+func registerServer(srv Server) {
+	serversLock.Lock()
+	servers = append(servers, srv)
+	serversLock.Unlock()
+}
+
+// DrainConnections stops the API servers from accepting new connections and
+// gives the requests they are serving, e.g. builds, pulls and attach
+// sessions, the grace period configured on the serveapi job to complete.
+// A "shutdown" event is emitted first so that clients know what is going on.
+// Called through eng.Job("drainconnections")
+func DrainConnections(job *engine.Job) error {
+	serversLock.Lock()
+	srvs := servers
+	servers = nil
+	serversLock.Unlock()
+	if len(srvs) == 0 {
+		return nil
+	}
+
+	if err := job.Eng.Job("log", "shutdown", "daemon", "").Run(); err != nil {
+		logrus.Debugf("Error logging shutdown event: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, srv := range srvs {
+		wg.Add(1)
+		go func(srv Server) {
+			defer wg.Done()
+			if n := srv.Drain(); n > 0 {
+				logrus.Warnf("Interrupting %d API requests still running after the grace period", n)
+			}
+		}(srv)
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/version"
+)
+
+// Error codes sent in error responses. Clients rely on them, so they must
+// never change once released.
+const (
+	errCodeInternal            = "INTERNAL_ERROR"
+	errCodeNotFound            = "NOT_FOUND"
+	errCodeContainerNotFound   = "CONTAINER_NOT_FOUND"
+	errCodeImageNotFound       = "IMAGE_NOT_FOUND"
+	errCodeRepositoryNotFound  = "REPOSITORY_NOT_FOUND"
+	errCodeExecNotFound        = "EXEC_NOT_FOUND"
+	errCodeNetworkNotFound     = "NETWORK_NOT_FOUND"
+	errCodeBadParameter        = "BAD_PARAMETER"
+	errCodeConflict            = "CONFLICT"
+	errCodeNotAcceptable       = "NOT_ACCEPTABLE"
+	errCodeUnauthorized        = "UNAUTHORIZED"
+	errCodeAccountNotActivated = "ACCOUNT_NOT_ACTIVATED"
+	errCodeRequestBodyTooLarge = "REQUEST_BODY_TOO_LARGE"
+	errCodeUnsupportedVersion  = "UNSUPPORTED_API_VERSION"
+	errCodeAuthorizationDenied = "AUTHORIZATION_DENIED"
+	errCodeAuthorizationError  = "AUTHORIZATION_ERROR"
+	errCodeReadOnlySocket      = "READ_ONLY_SOCKET"
+	errCodeTimeout             = "TIMEOUT"
+)
+
+// errorStructVersion is the first API version getting error responses as
+// json documents rather than plain text.
+const errorStructVersion version.Version = "1.19"
+
+var versionPrefix = regexp.MustCompile(`^/v([0-9.]+)/`)
+
+func httpError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+	statusCode, code := errorStatus(err)
+	requestLogger(r).Errorf("HTTP Error: statusCode=%d %v", statusCode, err)
+	writeError(w, r, statusCode, code, err.Error())
+}
+
+// errorStatus tells the status and error code to answer with for err.
+func errorStatus(err error) (int, string) {
+	// FIXME: this is brittle and should not be necessary.
+	// If we need to differentiate between different possible error types, we should
+	// create appropriate error types with clearly defined meaning.
+	errStr := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(errStr, "no such container"), strings.Contains(errStr, "no such id"):
+		return http.StatusNotFound, errCodeContainerNotFound
+	case strings.Contains(errStr, "no such image"):
+		return http.StatusNotFound, errCodeImageNotFound
+	case strings.Contains(errStr, "no such repository"):
+		return http.StatusNotFound, errCodeRepositoryNotFound
+	case strings.Contains(errStr, "no such exec"):
+		return http.StatusNotFound, errCodeExecNotFound
+	case strings.Contains(errStr, "no such network"):
+		return http.StatusNotFound, errCodeNetworkNotFound
+	case strings.Contains(errStr, "no such"):
+		return http.StatusNotFound, errCodeNotFound
+	case strings.Contains(errStr, "bad parameter"), strings.Contains(errStr, "invalid filter"), strings.Contains(errStr, "invalid container name"), strings.Contains(errStr, "invalid network name"):
+		return http.StatusBadRequest, errCodeBadParameter
+	case strings.Contains(errStr, "conflict"):
+		return http.StatusConflict, errCodeConflict
+	case strings.Contains(errStr, "impossible"):
+		return http.StatusNotAcceptable, errCodeNotAcceptable
+	case strings.Contains(errStr, "wrong login/password"):
+		return http.StatusUnauthorized, errCodeUnauthorized
+	case strings.Contains(errStr, "hasn't been activated"):
+		return http.StatusForbidden, errCodeAccountNotActivated
+	case strings.Contains(errStr, "request body too large"):
+		return http.StatusRequestEntityTooLarge, errCodeRequestBodyTooLarge
+	case strings.Contains(errStr, "timed out waiting"):
+		return http.StatusRequestTimeout, errCodeTimeout
+	}
+	return http.StatusInternalServerError, errCodeInternal
+}
+
+// writeError sends an error response, as a types.ErrorResponse for clients
+// of API version 1.19 and later, as plain text for older ones.
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, code, msg string) {
+	if requestVersion(r).LessThan(errorStructVersion) {
+		http.Error(w, msg, statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(&types.ErrorResponse{
+		Code:      code,
+		Message:   msg,
+		RequestID: r.Header.Get(requestIDHeader),
+	})
+}
+
+// requestVersion returns the API version asked for in the path of r, which
+// defaults to the current one. Unlike mux.Vars, it also works outside of
+// the router.
+func requestVersion(r *http.Request) version.Version {
+	if m := versionPrefix.FindStringSubmatch(r.URL.Path); m != nil {
+		return version.Version(m[1])
+	}
+	return api.APIVERSION
+}
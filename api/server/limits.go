@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/pkg/units"
+)
+
+// bodyLimitHandler caps the size of request bodies, so that a client sending
+// a huge build context by mistake gets an error instead of having the daemon
+// exhaust its memory or disk. A limit of 0 or less disables the check.
+type bodyLimitHandler struct {
+	handler      http.Handler
+	buildContext int64
+	jsonBody     int64
+}
+
+// newBodyLimitHandler returns handler with build contexts and json bodies
+// limited to the given human readable sizes, e.g. "10g".
+func newBodyLimitHandler(handler http.Handler, buildContext, jsonBody string) (http.Handler, error) {
+	h := &bodyLimitHandler{handler: handler}
+	var err error
+	if h.buildContext, err = parseBodyLimit(buildContext); err != nil {
+		return nil, fmt.Errorf("Invalid build context limit: %v", err)
+	}
+	if h.jsonBody, err = parseBodyLimit(jsonBody); err != nil {
+		return nil, fmt.Errorf("Invalid json body limit: %v", err)
+	}
+	if h.buildContext <= 0 && h.jsonBody <= 0 {
+		return handler, nil
+	}
+	return h, nil
+}
+
+func parseBodyLimit(size string) (int64, error) {
+	if size == "" || size == "0" {
+		return 0, nil
+	}
+	return units.RAMInBytes(size)
+}
+
+func (h *bodyLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	what, limit := h.limitFor(r)
+	if limit > 0 && r.Body != nil {
+		tooLarge := &bodyTooLargeError{what: what, limit: limit}
+		if r.ContentLength > limit {
+			httpError(w, r, tooLarge)
+			return
+		}
+		r.Body = &limitedBody{ReadCloser: r.Body, n: limit, err: tooLarge}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func (h *bodyLimitHandler) limitFor(r *http.Request) (string, int64) {
+	if r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/build") {
+		return "build context", h.buildContext
+	}
+	if api.MatchesContentType(r.Header.Get("Content-Type"), "application/json") {
+		return "json body", h.jsonBody
+	}
+	return "", 0
+}
+
+type bodyTooLargeError struct {
+	what  string
+	limit int64
+}
+
+func (e *bodyTooLargeError) Error() string {
+	return fmt.Sprintf("Request body too large: the %s exceeds the limit of %s", e.what, units.HumanSize(float64(e.limit)))
+}
+
+// limitedBody reads at most n bytes from a request body and fails with err
+// when the body is larger than that.
+type limitedBody struct {
+	io.ReadCloser
+	n   int64
+	err error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.n < 0 {
+		return 0, b.err
+	}
+	// Read one byte past the limit to tell a body of exactly n bytes from
+	// a larger one.
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.n {
+		n = int(b.n)
+		b.n = -1
+		return n, b.err
+	}
+	b.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// requestIDHeader carries the id of a request, which ties together the
+// response, including errors, and the daemon logs about that request.
+const requestIDHeader = "X-Request-Id"
+
+// requestIDHandler honors the id given by the client, e.g. to correlate
+// requests going through a proxy, or assigns a new one.
+type requestIDHandler struct {
+	handler http.Handler
+}
+
+func (h requestIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID(id) {
+		id = stringid.TruncateID(stringid.GenerateRandomID())
+		r.Header.Set(requestIDHeader, id)
+	}
+	w.Header().Set(requestIDHeader, id)
+	h.handler.ServeHTTP(w, r)
+}
+
+// validRequestID makes sure a client provided id can go to the logs as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestLogger returns a logger tagging its entries with the id of r.
+func requestLogger(r *http.Request) *logrus.Entry {
+	return logrus.WithField("request_id", r.Header.Get(requestIDHeader))
+}
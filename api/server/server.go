@@ -3,9 +3,11 @@ package server
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
 
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -13,6 +15,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"code.google.com/p/go.net/websocket"
 	"github.com/gorilla/mux"
@@ -26,6 +30,7 @@ import (
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -36,21 +41,77 @@ var (
 )
 
 type HttpServer struct {
-	srv *http.Server
-	l   net.Listener
+	srv     *http.Server
+	l       net.Listener
+	drain   *drainHandler
+	grace   time.Duration
+	drained chan struct{}
+}
+
+func newHttpServer(addr string, l net.Listener, handler http.Handler, grace time.Duration) *HttpServer {
+	drain := newDrainHandler(handler)
+	return &HttpServer{
+		srv: &http.Server{
+			Addr:    addr,
+			Handler: drain,
+		},
+		l:       l,
+		drain:   drain,
+		grace:   grace,
+		drained: make(chan struct{}),
+	}
 }
 
 func (s *HttpServer) Serve() error {
-	return s.srv.Serve(s.l)
+	err := s.srv.Serve(s.l)
+	if s.drain.isDraining() {
+		<-s.drained
+	}
+	return err
 }
 func (s *HttpServer) Close() error {
+	if s.drain.isDraining() {
+		return nil
+	}
 	return s.l.Close()
 }
 
+// Drain stops accepting new connections and waits up to the grace period
+// for the requests being served to complete. It returns the number of
+// requests still running when it gave up.
+func (s *HttpServer) Drain() int {
+	if !s.drain.start() {
+		return 0
+	}
+	defer close(s.drained)
+	s.srv.SetKeepAlivesEnabled(false)
+	if err := s.l.Close(); err != nil {
+		logrus.Error(err)
+	}
+	return s.drain.wait(s.grace)
+}
+
 type HttpApiFunc func(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error
 
+// errHijackUnsupported is returned by the streaming endpoints when the
+// request did not come over an HTTP/1.x connection, e.g. on HTTP/2.
+var errHijackUnsupported = errors.New("Impossible to hijack the connection: this endpoint requires HTTP/1.1")
+
+// checkHijackable makes sure the underlying connection of w can be taken
+// over by a streaming endpoint.
+func checkHijackable(w http.ResponseWriter) error {
+	if _, ok := w.(http.Hijacker); !ok {
+		return errHijackUnsupported
+	}
+	return nil
+}
+
 func hijackServer(w http.ResponseWriter) (io.ReadCloser, io.Writer, error) {
-	conn, _, err := w.(http.Hijacker).Hijack()
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errHijackUnsupported
+	}
+	conn, _, err := hijacker.Hijack()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -107,32 +168,6 @@ func parseMultipartForm(r *http.Request) error {
 	return nil
 }
 
-func httpError(w http.ResponseWriter, err error) {
-	statusCode := http.StatusInternalServerError
-	// FIXME: this is brittle and should not be necessary.
-	// If we need to differentiate between different possible error types, we should
-	// create appropriate error types with clearly defined meaning.
-	errStr := strings.ToLower(err.Error())
-	if strings.Contains(errStr, "no such") {
-		statusCode = http.StatusNotFound
-	} else if strings.Contains(errStr, "bad parameter") {
-		statusCode = http.StatusBadRequest
-	} else if strings.Contains(errStr, "conflict") {
-		statusCode = http.StatusConflict
-	} else if strings.Contains(errStr, "impossible") {
-		statusCode = http.StatusNotAcceptable
-	} else if strings.Contains(errStr, "wrong login/password") {
-		statusCode = http.StatusUnauthorized
-	} else if strings.Contains(errStr, "hasn't been activated") {
-		statusCode = http.StatusForbidden
-	}
-
-	if err != nil {
-		logrus.Errorf("HTTP Error: statusCode=%d %v", statusCode, err)
-		http.Error(w, err.Error(), statusCode)
-	}
-}
-
 // writeJSONEnv writes the engine.Env values to the http response stream as a
 // json encoded body.
 func writeJSONEnv(w http.ResponseWriter, code int, v engine.Env) error {
@@ -149,6 +184,31 @@ func writeJSON(w http.ResponseWriter, code int, v interface{}) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
+// writeJSONWithETag writes an already encoded json body tagged with an ETag
+// derived from its content. Clients that already hold this exact
+// representation, as told by If-None-Match, only get a 304 Not Modified.
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, body []byte) error {
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err := w.Write(body)
+	return err
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
 func streamJSON(job *engine.Job, w http.ResponseWriter, flush bool) {
 	w.Header().Set("Content-Type", "application/json")
 	if flush {
@@ -262,9 +322,10 @@ func getImagesJSON(eng *engine.Engine, version version.Version, w http.ResponseW
 	}
 
 	var (
-		err  error
-		outs *engine.Table
-		job  = eng.Job("images")
+		err    error
+		outs   *engine.Table
+		buffer = bytes.NewBuffer(nil)
+		job    = eng.Job("images")
 	)
 
 	job.Setenv("filters", r.Form.Get("filters"))
@@ -273,7 +334,7 @@ func getImagesJSON(eng *engine.Engine, version version.Version, w http.ResponseW
 	job.Setenv("all", r.Form.Get("all"))
 
 	if version.GreaterThanOrEqualTo("1.7") {
-		streamJSON(job, w, false)
+		job.Stdout.Add(buffer)
 	} else if outs, err = job.Stdout.AddListTable(); err != nil {
 		return err
 	}
@@ -282,6 +343,10 @@ func getImagesJSON(eng *engine.Engine, version version.Version, w http.ResponseW
 		return err
 	}
 
+	if version.GreaterThanOrEqualTo("1.7") {
+		return writeJSONWithETag(w, r, buffer.Bytes())
+	}
+
 	if version.LessThan("1.7") && outs != nil { // Convert to legacy format
 		outsLegacy := engine.NewTable("Created", 0)
 		for _, out := range outs.Data {
@@ -314,25 +379,225 @@ func getImagesViz(eng *engine.Engine, version version.Version, w http.ResponseWr
 	return nil
 }
 
+func getSystemDf(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	job := eng.Job("system_df")
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func postSystemPrune(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	job := eng.Job("system_prune")
+	job.Setenv("filters", r.Form.Get("filters"))
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func postSystemBench(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	job := eng.Job("system_bench")
+	job.Setenv("layers", r.Form.Get("layers"))
+	job.Setenv("files", r.Form.Get("files"))
+	job.Setenv("filesize", r.Form.Get("filesize"))
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func getNetworksJSON(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	job := eng.Job("networks")
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func getNetwork(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	job := eng.Job("network_inspect", vars["id"])
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
+func postNetworksCreate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var create types.NetworkCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		return err
+	}
+	var (
+		job      = eng.Job("network_create")
+		response types.NetworkCreateResponse
+		out      = bytes.NewBuffer(nil)
+	)
+	job.Setenv("Name", create.Name)
+	job.Setenv("Driver", create.Driver)
+	job.SetenvBool("Internal", create.Internal)
+	job.Setenv("IPAMDriver", create.IPAM.Driver)
+	job.SetenvJson("Options", create.Options)
+	switch len(create.IPAM.Config) {
+	case 0:
+	case 1:
+		if create.IPAM.Config[0].IPRange != "" {
+			return fmt.Errorf("The addresses of the containers of a network are allocated from its whole subnet, IPRange is not supported")
+		}
+		job.Setenv("Subnet", create.IPAM.Config[0].Subnet)
+		job.Setenv("Gateway", create.IPAM.Config[0].Gateway)
+	default:
+		return fmt.Errorf("Only one subnet per network is supported")
+	}
+	job.Stdout.Add(out)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(out).Decode(&response); err != nil {
+		return err
+	}
+	return writeJSON(w, http.StatusCreated, &response)
+}
+
+func postNetworkConnect(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var connect types.NetworkConnect
+	if err := json.NewDecoder(r.Body).Decode(&connect); err != nil {
+		return err
+	}
+	job := eng.Job("network_connect", vars["id"], connect.Container)
+	if config := connect.EndpointConfig; config != nil {
+		if config.IPAMConfig != nil {
+			job.Setenv("IPv4Address", config.IPAMConfig.IPv4Address)
+			job.Setenv("IPv6Address", config.IPAMConfig.IPv6Address)
+		}
+		job.SetenvList("Aliases", config.Aliases)
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func postNetworkDisconnect(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var disconnect types.NetworkDisconnect
+	if err := json.NewDecoder(r.Body).Decode(&disconnect); err != nil {
+		return err
+	}
+	if err := eng.Job("network_disconnect", vars["id"], disconnect.Container).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func deleteNetworks(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	if err := eng.Job("network_rm", vars["id"]).Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
 func getInfo(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	w.Header().Set("Content-Type", "application/json")
 	eng.ServeHTTP(w, r)
 	return nil
 }
 
+// newEventsJob prepares an events job from the query parameters shared by
+// every events transport.
+func newEventsJob(eng *engine.Engine, r *http.Request) *engine.Job {
+	job := eng.Job("events")
+	job.Setenv("since", r.Form.Get("since"))
+	job.Setenv("until", r.Form.Get("until"))
+	job.Setenv("filters", r.Form.Get("filters"))
+	return job
+}
+
 func getEvents(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
 	}
 
-	var job = eng.Job("events")
-	streamJSON(job, w, true)
-	job.Setenv("since", r.Form.Get("since"))
-	job.Setenv("until", r.Form.Get("until"))
-	job.Setenv("filters", r.Form.Get("filters"))
+	var job = newEventsJob(eng, r)
+	if api.AcceptsContentType(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		job.Stdout.Add(sseWriter{utils.NewWriteFlusher(w)})
+	} else {
+		streamJSON(job, w, true)
+	}
 	return job.Run()
 }
 
+// sseWriter frames every non-empty write as a Server-Sent Events message.
+type sseWriter struct {
+	w io.Writer
+}
+
+func (w sseWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return w.w.Write(p)
+	}
+	if _, err := fmt.Fprintf(w.w, "data: %s\n\n", bytes.TrimSpace(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// wsFrameWriter sends every non-empty write as its own websocket frame, so
+// that each event reaches the consumer as a single message.
+type wsFrameWriter struct {
+	ws *websocket.Conn
+}
+
+func (w wsFrameWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return w.ws.Write(p)
+}
+
+func wsGetEvents(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+
+	if err := checkHijackable(w); err != nil {
+		return err
+	}
+
+	h := websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		job := newEventsJob(eng, r)
+		job.Stdout.Add(wsFrameWriter{ws})
+		if err := job.Run(); err != nil {
+			requestLogger(r).Errorf("Error streaming events over websocket: %s", err)
+		}
+	})
+	h.ServeHTTP(w, r)
+
+	return nil
+}
+
 func getImagesHistory(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -347,6 +612,16 @@ func getImagesHistory(eng *engine.Engine, version version.Version, w http.Respon
 	return nil
 }
 
+func postImagesVerify(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	job := eng.Job("image_verify", vars["name"])
+	streamJSON(job, w, false)
+	return job.Run()
+}
+
 func getContainersChanges(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
@@ -369,6 +644,7 @@ func getContainersTop(eng *engine.Engine, version version.Version, w http.Respon
 	}
 
 	job := eng.Job("top", vars["name"], r.Form.Get("ps_args"))
+	job.Setenv("fields", r.Form.Get("fields"))
 	streamJSON(job, w, false)
 	return job.Run()
 }
@@ -378,9 +654,10 @@ func getContainersJSON(eng *engine.Engine, version version.Version, w http.Respo
 		return err
 	}
 	var (
-		err  error
-		outs *engine.Table
-		job  = eng.Job("containers")
+		err    error
+		outs   *engine.Table
+		buffer = bytes.NewBuffer(nil)
+		job    = eng.Job("containers")
 	)
 
 	job.Setenv("all", r.Form.Get("all"))
@@ -391,13 +668,16 @@ func getContainersJSON(eng *engine.Engine, version version.Version, w http.Respo
 	job.Setenv("filters", r.Form.Get("filters"))
 
 	if version.GreaterThanOrEqualTo("1.5") {
-		streamJSON(job, w, false)
+		job.Stdout.Add(buffer)
 	} else if outs, err = job.Stdout.AddTable(); err != nil {
 		return err
 	}
 	if err = job.Run(); err != nil {
 		return err
 	}
+	if version.GreaterThanOrEqualTo("1.5") {
+		return writeJSONWithETag(w, r, buffer.Bytes())
+	}
 	if version.LessThan("1.5") { // Convert to legacy format
 		for _, out := range outs.Data {
 			ports := engine.NewTable("", 0)
@@ -419,9 +699,17 @@ func getContainersStats(eng *engine.Engine, version version.Version, w http.Resp
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
 	}
+	stream := true
+	if value := r.Form.Get("stream"); value != "" {
+		var err error
+		if stream, err = getBoolParam(value); err != nil {
+			return err
+		}
+	}
 	name := vars["name"]
 	job := eng.Job("container_stats", name)
-	streamJSON(job, w, true)
+	job.SetenvBool("stream", stream)
+	streamJSON(job, w, stream)
 	return job.Run()
 }
 
@@ -446,6 +734,13 @@ func getContainersLogs(eng *engine.Engine, version version.Version, w http.Respo
 	logsJob.Setenv("stdout", r.Form.Get("stdout"))
 	logsJob.Setenv("stderr", r.Form.Get("stderr"))
 	logsJob.Setenv("timestamps", r.Form.Get("timestamps"))
+	logsJob.Setenv("details", r.Form.Get("details"))
+	if since := r.Form.Get("since"); since != "" {
+		if _, err := strconv.ParseInt(since, 10, 64); err != nil {
+			return fmt.Errorf("Bad parameter: since must be a unix timestamp, got %q", since)
+		}
+		logsJob.Setenv("since", since)
+	}
 	// Validate args here, because we can't return not StatusOK after job.Run() call
 	stdout, stderr := logsJob.GetenvBool("stdout"), logsJob.GetenvBool("stderr")
 	if !(stdout || stderr) {
@@ -505,7 +800,7 @@ func postCommit(eng *engine.Engine, version version.Version, w http.ResponseWrit
 	}
 
 	if err := config.Decode(r.Body); err != nil {
-		logrus.Errorf("%s", err)
+		requestLogger(r).Errorf("%s", err)
 	}
 
 	if r.FormValue("pause") == "" && version.GreaterThanOrEqualTo("1.13") {
@@ -683,7 +978,14 @@ func getImagesGet(eng *engine.Engine, version version.Version, w http.ResponseWr
 		return err
 	}
 	if version.GreaterThan("1.0") {
-		w.Header().Set("Content-Type", "application/x-tar")
+		switch r.Form.Get("compress") {
+		case "gzip":
+			w.Header().Set("Content-Type", "application/x-gzip")
+		case "xz":
+			w.Header().Set("Content-Type", "application/x-xz")
+		default:
+			w.Header().Set("Content-Type", "application/x-tar")
+		}
 	}
 	var job *engine.Job
 	if name, ok := vars["name"]; ok {
@@ -691,6 +993,7 @@ func getImagesGet(eng *engine.Engine, version version.Version, w http.ResponseWr
 	} else {
 		job = eng.Job("image_export", r.Form["names"]...)
 	}
+	job.Setenv("compress", r.Form.Get("compress"))
 	job.Stdout.Add(w)
 	return job.Run()
 }
@@ -698,8 +1001,20 @@ func getImagesGet(eng *engine.Engine, version version.Version, w http.ResponseWr
 func postImagesLoad(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	job := eng.Job("load")
 	job.Stdin.Add(r.Body)
-	job.Stdout.Add(w)
-	return job.Run()
+	if version.LessThan("1.19") {
+		job.Stdout.Add(w)
+		return job.Run()
+	}
+	job.SetenvBool("json", true)
+	streamJSON(job, w, true)
+	if err := job.Run(); err != nil {
+		if !job.Stdout.Used() {
+			return err
+		}
+		sf := streamformatter.NewStreamFormatter(true)
+		w.Write(sf.FormatError(err))
+	}
+	return nil
 }
 
 func postContainersCreate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -737,6 +1052,37 @@ func postContainersCreate(eng *engine.Engine, version version.Version, w http.Re
 	})
 }
 
+func postContainersUpdate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+	var (
+		job         = eng.Job("container_update", vars["name"])
+		outWarnings []string
+		warnings    = bytes.NewBuffer(nil)
+	)
+	if err := job.DecodeEnv(r.Body); err != nil {
+		return err
+	}
+	job.Stderr.Add(warnings)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(warnings)
+	for scanner.Scan() {
+		outWarnings = append(outWarnings, scanner.Text())
+	}
+	return writeJSON(w, http.StatusOK, &types.ContainerUpdateResponse{
+		Warnings: outWarnings,
+	})
+}
+
 func postContainersRestart(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -745,7 +1091,9 @@ func postContainersRestart(eng *engine.Engine, version version.Version, w http.R
 		return fmt.Errorf("Missing parameter")
 	}
 	job := eng.Job("restart", vars["name"])
-	job.Setenv("t", r.Form.Get("t"))
+	if t := r.Form.Get("t"); t != "" {
+		job.Setenv("t", t)
+	}
 	if err := job.Run(); err != nil {
 		return err
 	}
@@ -771,6 +1119,78 @@ func postContainerRename(eng *engine.Engine, version version.Version, w http.Res
 	return nil
 }
 
+// batchParallelism caps the number of containers a batch acts on at once.
+const batchParallelism = 8
+
+func postContainersBatch(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := checkForJson(r); err != nil {
+		return err
+	}
+	var req types.ContainerBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("Bad parameter: %v", err)
+	}
+	if len(req.Containers) == 0 {
+		return fmt.Errorf("Bad parameter: no container given")
+	}
+
+	var newJob func(name string) *engine.Job
+	switch req.Action {
+	case "stop":
+		newJob = func(name string) *engine.Job {
+			job := eng.Job("stop", name)
+			if req.Timeout != nil {
+				job.SetenvInt("t", *req.Timeout)
+			}
+			return job
+		}
+	case "kill":
+		newJob = func(name string) *engine.Job {
+			job := eng.Job("kill", name)
+			if req.Signal != "" {
+				job.Args = append(job.Args, req.Signal)
+			}
+			return job
+		}
+	case "remove":
+		newJob = func(name string) *engine.Job {
+			job := eng.Job("rm", name)
+			job.SetenvBool("forceRemove", req.Force)
+			job.SetenvBool("removeVolume", req.RemoveVolumes)
+			return job
+		}
+	default:
+		return fmt.Errorf("Bad parameter: unknown action %q, expected stop, kill or remove", req.Action)
+	}
+
+	var (
+		results = make([]types.ContainerBatchResult, len(req.Containers))
+		slots   = make(chan struct{}, batchParallelism)
+		wg      sync.WaitGroup
+	)
+	for i, name := range req.Containers {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i] = types.ContainerBatchResult{ID: name, StatusCode: http.StatusNoContent}
+			if err := newJob(name).Run(); err != nil {
+				if req.Action == "stop" && err.Error() == "Container already stopped" {
+					results[i].StatusCode = http.StatusNotModified
+					return
+				}
+				results[i].StatusCode, _ = errorStatus(err)
+				results[i].Error = err.Error()
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	return writeJSON(w, http.StatusOK, results)
+}
+
 func deleteContainers(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return err
@@ -850,7 +1270,9 @@ func postContainersStop(eng *engine.Engine, version version.Version, w http.Resp
 		return fmt.Errorf("Missing parameter")
 	}
 	job := eng.Job("stop", vars["name"])
-	job.Setenv("t", r.Form.Get("t"))
+	if t := r.Form.Get("t"); t != "" {
+		job.Setenv("t", t)
+	}
 	if err := job.Run(); err != nil {
 		if err.Error() == "Container already stopped" {
 			w.WriteHeader(http.StatusNotModified)
@@ -866,21 +1288,67 @@ func postContainersWait(eng *engine.Engine, version version.Version, w http.Resp
 	if vars == nil {
 		return fmt.Errorf("Missing parameter")
 	}
+	if err := parseForm(r); err != nil {
+		return err
+	}
 	var (
 		stdoutBuffer = bytes.NewBuffer(nil)
 		job          = eng.Job("wait", vars["name"])
 	)
+	job.Setenv("condition", r.Form.Get("condition"))
+	if timeout := r.Form.Get("timeout"); timeout != "" {
+		t, err := strconv.Atoi(timeout)
+		if err != nil || t < 0 {
+			return fmt.Errorf("Bad parameter: invalid timeout %q", timeout)
+		}
+		job.SetenvInt("timeout", t)
+	}
 	job.Stdout.Add(stdoutBuffer)
-	if err := job.Run(); err != nil {
-		return err
+	// The headers are sent as soon as the removal of the container is
+	// watched, the result follows once it's removed.
+	var started *headerFlusher
+	if r.Form.Get("condition") == "removed" {
+		started = &headerFlusher{w: w}
+		job.Stdout.Add(started)
+	}
+	var (
+		resp = &types.ContainerWaitResponse{}
+		err  = job.Run()
+	)
+	if err == nil {
+		resp.StatusCode, err = strconv.Atoi(engine.Tail(stdoutBuffer, 1))
+	}
+	if started == nil || !started.sent {
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, http.StatusOK, resp)
 	}
-	statusCode, err := strconv.Atoi(engine.Tail(stdoutBuffer, 1))
+	// Too late for an error status.
 	if err != nil {
-		return err
+		resp.StatusCode = -1
+		resp.Error = err.Error()
 	}
-	return writeJSON(w, http.StatusOK, &types.ContainerWaitResponse{
-		StatusCode: statusCode,
-	})
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// headerFlusher sends the headers of a JSON response on the first write,
+// and discards what is written.
+type headerFlusher struct {
+	w    http.ResponseWriter
+	sent bool
+}
+
+func (h *headerFlusher) Write(p []byte) (int, error) {
+	if !h.sent {
+		h.sent = true
+		h.w.Header().Set("Content-Type", "application/json")
+		h.w.WriteHeader(http.StatusOK)
+		if f, ok := h.w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	return len(p), nil
 }
 
 func postContainersResize(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
@@ -911,6 +1379,11 @@ func postContainersAttach(eng *engine.Engine, version version.Version, w http.Re
 		return err
 	}
 
+	detachKeys, err := term.ToBytes(r.Form.Get("detachKeys"))
+	if err != nil {
+		return fmt.Errorf("Bad parameter: %v", err)
+	}
+
 	inStream, outStream, err := hijackServer(w)
 	if err != nil {
 		return err
@@ -934,7 +1407,7 @@ func postContainersAttach(eng *engine.Engine, version version.Version, w http.Re
 	logs := r.Form.Get("logs") != ""
 	stream := r.Form.Get("stream") != ""
 
-	if err := cont.AttachWithLogs(inStream, outStream, errStream, logs, stream); err != nil {
+	if err := cont.AttachWithLogs(inStream, outStream, errStream, logs, stream, detachKeys); err != nil {
 		fmt.Fprintf(outStream, "Error attaching: %s\n", err)
 	}
 	return nil
@@ -953,14 +1426,21 @@ func wsContainersAttach(eng *engine.Engine, version version.Version, w http.Resp
 	if err != nil {
 		return err
 	}
+	if err := checkHijackable(w); err != nil {
+		return err
+	}
+	detachKeys, err := term.ToBytes(r.Form.Get("detachKeys"))
+	if err != nil {
+		return fmt.Errorf("Bad parameter: %v", err)
+	}
 
 	h := websocket.Handler(func(ws *websocket.Conn) {
 		defer ws.Close()
 		logs := r.Form.Get("logs") != ""
 		stream := r.Form.Get("stream") != ""
 
-		if err := cont.AttachWithLogs(ws, ws, ws, logs, stream); err != nil {
-			logrus.Errorf("Error attaching websocket: %s", err)
+		if err := cont.AttachWithLogs(ws, ws, ws, logs, stream, detachKeys); err != nil {
+			requestLogger(r).Errorf("Error attaching websocket: %s", err)
 		}
 	})
 	h.ServeHTTP(w, r)
@@ -1074,7 +1554,7 @@ func postBuild(eng *engine.Engine, version version.Version, w http.ResponseWrite
 			select {
 			case <-finished:
 			case <-closeNotifier.CloseNotify():
-				logrus.Infof("Client disconnected, cancelling job: %s", job.Name)
+				requestLogger(r).Infof("Client disconnected, cancelling job: %s", job.Name)
 				job.Cancel()
 			}
 		}()
@@ -1129,6 +1609,31 @@ func postContainersCopy(eng *engine.Engine, version version.Version, w http.Resp
 	return nil
 }
 
+func putContainersArchive(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if vars == nil {
+		return fmt.Errorf("Missing parameter")
+	}
+
+	path := r.Form.Get("path")
+	if path == "" {
+		return fmt.Errorf("Bad parameter: path cannot be empty")
+	}
+	if path[0] == '/' {
+		path = path[1:]
+	}
+
+	job := eng.Job("container_extract", vars["name"], path)
+	job.Stdin.Add(r.Body)
+	if err := job.Run(); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusOK)
+	return nil
+}
+
 func postContainerExecCreate(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
 	if err := parseForm(r); err != nil {
 		return nil
@@ -1249,16 +1754,17 @@ func ping(eng *engine.Engine, version version.Version, w http.ResponseWriter, r
 func makeHttpHandler(eng *engine.Engine, logging bool, localMethod string, localRoute string, handlerFunc HttpApiFunc, corsHeaders string, dockerVersion version.Version) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// log the request
-		logrus.Debugf("Calling %s %s", localMethod, localRoute)
+		log := requestLogger(r)
+		log.Debugf("Calling %s %s", localMethod, localRoute)
 
 		if logging {
-			logrus.Infof("%s %s", r.Method, r.RequestURI)
+			log.Infof("%s %s", r.Method, r.RequestURI)
 		}
 
 		if strings.Contains(r.Header.Get("User-Agent"), "Docker-Client/") {
 			userAgent := strings.Split(r.Header.Get("User-Agent"), "/")
 			if len(userAgent) == 2 && !dockerVersion.Equal(version.Version(userAgent[1])) {
-				logrus.Debugf("Warning: client and server don't have the same version (client: %s, server: %s)", userAgent[1], dockerVersion)
+				log.Debugf("Warning: client and server don't have the same version (client: %s, server: %s)", userAgent[1], dockerVersion)
 			}
 		}
 		version := version.Version(mux.Vars(r)["version"])
@@ -1269,14 +1775,22 @@ func makeHttpHandler(eng *engine.Engine, logging bool, localMethod string, local
 			writeCorsHeaders(w, r, corsHeaders)
 		}
 
-		if version.GreaterThan(api.APIVERSION) {
-			http.Error(w, fmt.Errorf("client and server don't have same version (client API version: %s, server API version: %s)", version, api.APIVERSION).Error(), http.StatusNotFound)
+		if version.LessThan(api.APIMINVERSION) {
+			writeError(w, r, http.StatusBadRequest, errCodeUnsupportedVersion, fmt.Sprintf("client is too old (client API version: %s, minimum supported API version: %s)", version, api.APIMINVERSION))
 			return
 		}
+		// A newer client talking to an older daemon gets the responses of the
+		// most recent API version we know about instead of an error; the
+		// client can tell which version it was served from the header.
+		if version.GreaterThan(api.APIVERSION) {
+			log.Debugf("Client API version %s is newer than the server's, serving %s", version, api.APIVERSION)
+			version = api.APIVERSION
+		}
+		w.Header().Set("Api-Version", string(version))
 
 		if err := handlerFunc(eng, version, w, r, mux.Vars(r)); err != nil {
-			logrus.Errorf("Handler for %s %s returned error: %s", localMethod, localRoute, err)
-			httpError(w, err)
+			log.Errorf("Handler for %s %s returned error: %s", localMethod, localRoute, err)
+			httpError(w, r, err)
 		}
 	}
 }
@@ -1291,7 +1805,9 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 		"GET": {
 			"/_ping":                          ping,
 			"/events":                         getEvents,
+			"/events/ws":                      wsGetEvents,
 			"/info":                           getInfo,
+			"/system/df":                      getSystemDf,
 			"/version":                        getVersion,
 			"/images/json":                    getImagesJSON,
 			"/images/viz":                     getImagesViz,
@@ -1310,6 +1826,8 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/containers/{name:.*}/stats":     getContainersStats,
 			"/containers/{name:.*}/attach/ws": wsContainersAttach,
 			"/exec/{id:.*}/json":              getExecByID,
+			"/networks":                       getNetworksJSON,
+			"/networks/{id:.*}":               getNetwork,
 		},
 		"POST": {
 			"/auth":                         postAuth,
@@ -1319,7 +1837,9 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/images/load":                  postImagesLoad,
 			"/images/{name:.*}/push":        postImagesPush,
 			"/images/{name:.*}/tag":         postImagesTag,
+			"/images/{name:.*}/verify":      postImagesVerify,
 			"/containers/create":            postContainersCreate,
+			"/containers/batch":             postContainersBatch,
 			"/containers/{name:.*}/kill":    postContainersKill,
 			"/containers/{name:.*}/pause":   postContainersPause,
 			"/containers/{name:.*}/unpause": postContainersUnpause,
@@ -1334,15 +1854,26 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 			"/exec/{name:.*}/start":         postContainerExecStart,
 			"/exec/{name:.*}/resize":        postContainerExecResize,
 			"/containers/{name:.*}/rename":  postContainerRename,
+			"/containers/{name:.*}/update":  postContainersUpdate,
+			"/system/prune":                 postSystemPrune,
+			"/system/bench":                 postSystemBench,
+			"/networks/create":              postNetworksCreate,
+			"/networks/{id:.*}/connect":     postNetworkConnect,
+			"/networks/{id:.*}/disconnect":  postNetworkDisconnect,
+		},
+		"PUT": {
+			"/containers/{name:.*}/archive": putContainersArchive,
 		},
 		"DELETE": {
 			"/containers/{name:.*}": deleteContainers,
 			"/images/{name:.*}":     deleteImages,
+			"/networks/{id:.*}":     deleteNetworks,
 		},
 		"OPTIONS": {
 			"": optionsHandler,
 		},
 	}
+	m["GET"]["/swagger.json"] = getSwagger(m)
 
 	// If "api-cors-header" is not given, but "api-enable-cors" is true, we set cors to "*"
 	// otherwise, all head values will be passed to HTTP handler
@@ -1374,6 +1905,27 @@ func createRouter(eng *engine.Engine, logging, enableCors bool, corsHeaders stri
 	return r
 }
 
+// newHandler builds the http handler serving the remote API for the
+// serveapi job, wrapping the router with the middlewares configured on it.
+func newHandler(job *engine.Job) (http.Handler, error) {
+	r := createRouter(
+		job.Eng,
+		job.GetenvBool("Logging"),
+		job.GetenvBool("EnableCors"),
+		job.Getenv("CorsHeaders"),
+		job.Getenv("Version"),
+	)
+	h, err := newAuditHandler(newAuthzHandler(r, job.GetenvList("AuthzPlugins")), job.Getenv("AuditLog"))
+	if err != nil {
+		return nil, err
+	}
+	h = newCompressHandler(h, job.GetenvInt("CompressionThreshold"))
+	if h, err = newBodyLimitHandler(h, job.Getenv("MaxBuildContext"), job.Getenv("MaxJSONBody")); err != nil {
+		return nil, err
+	}
+	return requestIDHandler{h}, nil
+}
+
 // ServeRequest processes a single http request to the docker remote api.
 // FIXME: refactor this to be part of Server and not require re-creating a new
 // router each time. This requires first moving ListenAndServe into Server.
@@ -1413,6 +1965,36 @@ func allocateDaemonPort(addr string) error {
 type Server interface {
 	Serve() error
 	Close() error
+	Drain() int
+}
+
+// serve runs the given servers until they are all closed, or one of them
+// fails.
+func serve(eng *engine.Engine, srvs []Server) error {
+	chErrors := make(chan error, len(srvs))
+	for _, srv := range srvs {
+		registerServer(srv)
+		eng.OnShutdown(func(srv Server) func() {
+			return func() {
+				if err := srv.Close(); err != nil {
+					logrus.Error(err)
+				}
+			}
+		}(srv))
+		go func(srv Server) {
+			err := srv.Serve()
+			if err != nil && strings.Contains(err.Error(), "use of closed network connection") {
+				err = nil
+			}
+			chErrors <- err
+		}(srv)
+	}
+	for i := 0; i < len(srvs); i++ {
+		if err := <-chErrors; err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ServeApi loops through all of the protocols sent in to docker and spawns
@@ -1433,20 +2015,12 @@ func ServeApi(job *engine.Job) error {
 		}
 		go func() {
 			logrus.Infof("Listening for HTTP on %s (%s)", protoAddrParts[0], protoAddrParts[1])
-			srv, err := NewServer(protoAddrParts[0], protoAddrParts[1], job)
+			srvs, err := NewServer(protoAddrParts[0], protoAddrParts[1], job)
 			if err != nil {
 				chErrors <- err
 				return
 			}
-			job.Eng.OnShutdown(func() {
-				if err := srv.Close(); err != nil {
-					logrus.Error(err)
-				}
-			})
-			if err = srv.Serve(); err != nil && strings.Contains(err.Error(), "use of closed network connection") {
-				err = nil
-			}
-			chErrors <- err
+			chErrors <- serve(job.Eng, srvs)
 		}()
 	}
 
@@ -5,76 +5,79 @@ package server
 import (
 	"fmt"
 	"net"
-	"net/http"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/systemd"
 )
 
-// NewServer sets up the required Server and does protocol specific checking.
-func NewServer(proto, addr string, job *engine.Job) (Server, error) {
-	var (
-		err error
-		l   net.Listener
-		r   = createRouter(
-			job.Eng,
-			job.GetenvBool("Logging"),
-			job.GetenvBool("EnableCors"),
-			job.Getenv("CorsHeaders"),
-			job.Getenv("Version"),
-		)
-	)
+// NewServer sets up the required Servers and does protocol specific checking.
+// Every address maps to a single server, except fd:// ones which can stand
+// for several sockets passed by systemd.
+func NewServer(proto, addr string, job *engine.Job) ([]Server, error) {
+	var l net.Listener
+	r, err := newHandler(job)
+	if err != nil {
+		return nil, err
+	}
+	grace := time.Duration(job.GetenvInt("DrainTimeout")) * time.Second
 	switch proto {
 	case "fd":
+		// Sockets passed in by systemd can be tcp sockets as well, which
+		// get the TLS configuration given in the options of the address.
+		var config *tlsConfig
+		if addr, config, err = listenerTLSConfig(job, addr); err != nil {
+			return nil, err
+		}
 		ls, err := systemd.ListenFD(addr)
 		if err != nil {
 			return nil, err
 		}
-		chErrors := make(chan error, len(ls))
 		// We don't want to start serving on these sockets until the
 		// daemon is initialized and installed. Otherwise required handlers
 		// won't be ready.
 		<-activationLock
-		// Since ListenFD will return one or more sockets we have
-		// to create a go func to spawn off multiple serves
-		for i := range ls {
-			listener := ls[i]
-			go func() {
-				httpSrv := http.Server{Handler: r}
-				chErrors <- httpSrv.Serve(listener)
-			}()
-		}
-		for i := 0; i < len(ls); i++ {
-			if err := <-chErrors; err != nil {
-				return nil, err
+		var servers []Server
+		for _, l := range ls {
+			if _, ok := l.(*net.TCPListener); ok && config != nil {
+				if l, err = setupTls(l, config); err != nil {
+					return nil, err
+				}
 			}
+			logrus.Infof("Listening for HTTP on activated socket %s (%s)", l.Addr().Network(), l.Addr())
+			servers = append(servers, newHttpServer(l.Addr().String(), l, r, grace))
 		}
-		return nil, nil
+		return servers, nil
 	case "tcp":
-		if !job.GetenvBool("TlsVerify") {
+		var config *tlsConfig
+		if addr, config, err = listenerTLSConfig(job, addr); err != nil {
+			return nil, err
+		}
+		if config == nil || !config.Verify {
 			logrus.Infof("/!\\ DON'T BIND ON ANY IP ADDRESS WITHOUT setting -tlsverify IF YOU DON'T KNOW WHAT YOU'RE DOING /!\\")
 		}
-		if l, err = NewTcpSocket(addr, tlsConfigFromJob(job)); err != nil {
+		if l, err = NewTcpSocket(addr, config); err != nil {
 			return nil, err
 		}
 		if err := allocateDaemonPort(addr); err != nil {
 			return nil, err
 		}
 	case "unix":
-		if l, err = NewUnixSocket(addr, job.Getenv("SocketGroup")); err != nil {
+		var opts *unixSocketOptions
+		if addr, opts, err = parseUnixSocketOptions(addr, job.Getenv("SocketGroup")); err != nil {
+			return nil, err
+		}
+		if l, err = NewUnixSocket(addr, opts.group, opts.mode); err != nil {
 			return nil, err
 		}
+		if opts.readOnly {
+			r = readOnlyHandler{r}
+		}
 	default:
 		return nil, fmt.Errorf("Invalid protocol format: %q", proto)
 	}
-	return &HttpServer{
-		&http.Server{
-			Addr:    addr,
-			Handler: r,
-		},
-		l,
-	}, nil
+	return []Server{newHttpServer(addr, l, r, grace)}, nil
 }
 
 // Called through eng.Job("acceptconnections")
@@ -2,15 +2,23 @@ package server
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"code.google.com/p/go.net/websocket"
 	"github.com/docker/docker/api"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/engine"
@@ -43,24 +51,72 @@ func TestGetBoolParam(t *testing.T) {
 }
 
 func TesthttpError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/v1.19/containers/json", nil)
 	r := httptest.NewRecorder()
 
-	httpError(r, fmt.Errorf("No such method"))
+	httpError(r, req, fmt.Errorf("No such method"))
 	if r.Code != http.StatusNotFound {
 		t.Fatalf("Expected %d, got %d", http.StatusNotFound, r.Code)
 	}
 
-	httpError(r, fmt.Errorf("This accound hasn't been activated"))
+	r = httptest.NewRecorder()
+	httpError(r, req, fmt.Errorf("This accound hasn't been activated"))
 	if r.Code != http.StatusForbidden {
 		t.Fatalf("Expected %d, got %d", http.StatusForbidden, r.Code)
 	}
 
-	httpError(r, fmt.Errorf("Some error"))
+	r = httptest.NewRecorder()
+	httpError(r, req, fmt.Errorf("Some error"))
 	if r.Code != http.StatusInternalServerError {
 		t.Fatalf("Expected %d, got %d", http.StatusInternalServerError, r.Code)
 	}
 }
 
+func TestErrorResponses(t *testing.T) {
+	err := fmt.Errorf("No such container: foo")
+
+	req, _ := http.NewRequest("GET", "/v1.19/containers/foo/json", nil)
+	r := httptest.NewRecorder()
+	httpError(r, req, err)
+	if r.Code != http.StatusNotFound {
+		t.Fatalf("Expected %d, got %d", http.StatusNotFound, r.Code)
+	}
+	assertContentType(r, "application/json", t)
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != "CONTAINER_NOT_FOUND" || resp.Message != err.Error() {
+		t.Fatalf("Unexpected error response %+v", resp)
+	}
+
+	req, _ = http.NewRequest("GET", "/v1.18/containers/foo/json", nil)
+	r = httptest.NewRecorder()
+	httpError(r, req, err)
+	if r.Code != http.StatusNotFound {
+		t.Fatalf("Expected %d, got %d", http.StatusNotFound, r.Code)
+	}
+	if ct := r.HeaderMap.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Fatalf("Expected a plain text error for API 1.18, got %s", ct)
+	}
+	if body := strings.TrimSpace(r.Body.String()); body != err.Error() {
+		t.Fatalf("Expected a plain text error for API 1.18, got %q", body)
+	}
+}
+
+func TestHijackServerRequiresHijacker(t *testing.T) {
+	if _, _, err := hijackServer(httptest.NewRecorder()); err != errHijackUnsupported {
+		t.Fatalf("Expected %v, got %v", errHijackUnsupported, err)
+	}
+
+	req, _ := http.NewRequest("POST", "/containers/foo/attach", nil)
+	r := httptest.NewRecorder()
+	httpError(r, req, errHijackUnsupported)
+	if r.Code != http.StatusNotAcceptable {
+		t.Fatalf("Expected %d, got %d", http.StatusNotAcceptable, r.Code)
+	}
+}
+
 func TestGetVersion(t *testing.T) {
 	eng := engine.New()
 	var called bool
@@ -90,6 +146,670 @@ func TestGetVersion(t *testing.T) {
 	}
 }
 
+func TestNewerClientVersionIsDowngraded(t *testing.T) {
+	eng := engine.New()
+	var called bool
+	eng.Register("version", func(job *engine.Job) error {
+		called = true
+		return nil
+	})
+	r := serveRequestUsingVersion("GET", "/version", "999.0", nil, eng, t)
+	if !called {
+		t.Fatalf("handler was not called")
+	}
+	assertHttpNotError(r, t)
+	if v := r.HeaderMap.Get("Api-Version"); v != string(api.APIVERSION) {
+		t.Fatalf("Expected Api-Version %s, got %q", api.APIVERSION, v)
+	}
+}
+
+func TestOlderClientVersionIsRejected(t *testing.T) {
+	eng := engine.New()
+	var called bool
+	eng.Register("version", func(job *engine.Job) error {
+		called = true
+		return nil
+	})
+	r := serveRequestUsingVersion("GET", "/version", "0.1", nil, eng, t)
+	if called {
+		t.Fatalf("handler should not have been called")
+	}
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d, got %d", http.StatusBadRequest, r.Code)
+	}
+}
+
+func TestCompressLargeJSONResponses(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	handler := newCompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"Data": r.URL.Query().Get("data")})
+	}), 1024)
+
+	req, _ := http.NewRequest("GET", "/?data="+large, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r := httptest.NewRecorder()
+	handler.ServeHTTP(r, req)
+	if r.HeaderMap.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected a gzip encoded response, got headers %v", r.HeaderMap)
+	}
+	gz, err := gzip.NewReader(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]string
+	if err := json.NewDecoder(gz).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out["Data"] != large {
+		t.Fatalf("Unexpected decompressed body %v", out)
+	}
+
+	req, _ = http.NewRequest("GET", "/?data=small", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	r = httptest.NewRecorder()
+	handler.ServeHTTP(r, req)
+	if r.HeaderMap.Get("Content-Encoding") != "" {
+		t.Fatalf("Small responses should not be compressed")
+	}
+	assertContentType(r, "application/json", t)
+
+	req, _ = http.NewRequest("GET", "/?data="+large, nil)
+	r = httptest.NewRecorder()
+	handler.ServeHTTP(r, req)
+	if r.HeaderMap.Get("Content-Encoding") != "" {
+		t.Fatalf("Responses should only be compressed when the client accepts gzip")
+	}
+}
+
+func TestWrappedWritersKeepUnhijackable(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	var hijackErr error
+	audited, err := newAuditHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hijackErr = checkHijackable(w)
+	}), filepath.Join(tmp, "audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := newCompressHandler(audited, 1024)
+	req, _ := http.NewRequest("POST", "/containers/web/wait", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if hijackErr != errHijackUnsupported {
+		t.Fatalf("Expected %v for a writer that can't be hijacked, got %v", errHijackUnsupported, hijackErr)
+	}
+}
+
+func TestCompressSkipsHijackingRoutes(t *testing.T) {
+	for _, path := range []string{
+		"/containers/web/attach",
+		"/v1.19/containers/web/attach/ws",
+		"/v1.19/exec/1234/start",
+		"/events/ws",
+	} {
+		var compressed bool
+		handler := newCompressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, compressed = w.(*compressResponseWriter)
+		}), 1024)
+		req, _ := http.NewRequest("POST", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if compressed {
+			t.Fatalf("The response of %s should not be compressed", path)
+		}
+	}
+}
+
+func TestAuditMutatingCalls(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	logfile := filepath.Join(tmp, "audit.log")
+
+	handler, err := newAuditHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}), logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, method := range []string{"GET", "POST"} {
+		req, _ := http.NewRequest(method, "/containers/create?name=web", strings.NewReader(`{"Image":"busybox"}`))
+		req.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	data, err := ioutil.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected only the POST request to be audited, as it arrives and once served, got %q", data)
+	}
+	var entry struct {
+		Msg    string
+		Method string
+		Path   string
+		Params map[string][]string
+		Body   string
+		Status int
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Msg != "API request" || entry.Method != "POST" || entry.Path != "/containers/create" || entry.Status != 0 {
+		t.Fatalf("Unexpected audit entry %s", lines[0])
+	}
+	if len(entry.Params["name"]) != 1 || entry.Params["name"][0] != "web" {
+		t.Fatalf("Expected request parameters in audit entry %s", lines[0])
+	}
+	if entry.Body != `{"Image":"busybox"}` {
+		t.Fatalf("Expected request body in audit entry %s", lines[0])
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Msg != "API call" || entry.Method != "POST" || entry.Status != http.StatusCreated {
+		t.Fatalf("Unexpected audit entry %s", lines[1])
+	}
+}
+
+func TestAuditRedactsCredentials(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	logfile := filepath.Join(tmp, "audit.log")
+
+	handler, err := newAuditHandler(http.NotFoundHandler(), logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", "/auth", strings.NewReader(`{"username":"jdoe","password":"s3cret","serveraddress":"https://index.docker.io/v1/","nested":[{"Auth":"czNjcmV0"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	data, err := ioutil.ReadFile(logfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "s3cret") || strings.Contains(string(data), "czNjcmV0") {
+		t.Fatalf("Expected the credentials to be redacted, got %s", data)
+	}
+	if !strings.Contains(string(data), "jdoe") {
+		t.Fatalf("Expected the rest of the body to be recorded, got %s", data)
+	}
+}
+
+func TestDrainWaitsForRunningRequests(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	srv := newHttpServer(l.Addr().String(), l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}), 5*time.Second)
+	served := make(chan error)
+	go func() {
+		served <- srv.Serve()
+	}()
+	go http.Get("http://" + l.Addr().String() + "/build")
+	<-started
+
+	drained := make(chan int)
+	go func() {
+		drained <- srv.Drain()
+	}()
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a request was still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if _, err := net.Dial("tcp", l.Addr().String()); err == nil {
+		t.Fatal("Expected new connections to be refused while draining")
+	}
+
+	close(release)
+	if n := <-drained; n != 0 {
+		t.Fatalf("Expected every request to complete, %d still running", n)
+	}
+	<-served
+}
+
+func TestDrainGivesUpAfterGracePeriod(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	h := newDrainHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	req, _ := http.NewRequest("POST", "/images/create", nil)
+	go h.ServeHTTP(httptest.NewRecorder(), req)
+	req, _ = http.NewRequest("GET", "/events", nil)
+	go h.ServeHTTP(httptest.NewRecorder(), req)
+
+	for {
+		h.Lock()
+		active := h.active
+		h.Unlock()
+		if active > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.start()
+	if n := h.wait(50 * time.Millisecond); n != 1 {
+		t.Fatalf("Expected 1 request still running, got %d", n)
+	}
+}
+
+func TestRequestBodyLimits(t *testing.T) {
+	handler, err := newBodyLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			httpError(w, r, err)
+		}
+	}), "1k", "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, contentType, body string
+		chunked                 bool
+		code                    int
+	}{
+		{"/build", "application/tar", strings.Repeat("a", 1024), false, http.StatusOK},
+		{"/build", "application/tar", strings.Repeat("a", 1025), false, http.StatusRequestEntityTooLarge},
+		{"/build", "application/tar", strings.Repeat("a", 1025), true, http.StatusRequestEntityTooLarge},
+		{"/containers/create", "application/json", `{"a":"b"}`, false, http.StatusOK},
+		{"/containers/create", "application/json", `{"a":"bcd"}`, true, http.StatusRequestEntityTooLarge},
+		{"/images/load", "application/x-tar", strings.Repeat("a", 2048), false, http.StatusOK},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("POST", test.path, strings.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		if test.chunked {
+			req.ContentLength = -1
+		}
+		r := httptest.NewRecorder()
+		handler.ServeHTTP(r, req)
+		if r.Code != test.code {
+			t.Errorf("POST %s with a %d bytes body: expected %d, got %d", test.path, len(test.body), test.code, r.Code)
+		}
+	}
+
+	if _, err := newBodyLimitHandler(http.NotFoundHandler(), "lots", "0"); err == nil {
+		t.Fatal("Expected an invalid limit to be rejected")
+	}
+}
+
+func TestAuthzLargeBodies(t *testing.T) {
+	handler := newAuthzHandler(http.NotFoundHandler(), []string{"/nonexistent/policy.sock"})
+
+	// A body larger than plugins are given is denied without asking them,
+	// a smaller one reaches the unreachable plugin
+	for size, code := range map[int]int{
+		maxAuthzBodySize + 1: http.StatusForbidden,
+		maxAuthzBodySize:     http.StatusInternalServerError,
+	} {
+		body := `{"a":"` + strings.Repeat("b", size-8) + `"}`
+		req, _ := http.NewRequest("POST", "/containers/create", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		r := httptest.NewRecorder()
+		handler.ServeHTTP(r, req)
+		if r.Code != code {
+			t.Errorf("Expected %d for a %d bytes body, got %d: %s", code, len(body), r.Code, r.Body.String())
+		}
+	}
+}
+
+func TestAuthzForwardsBodiesWhateverTheirContentType(t *testing.T) {
+	for _, test := range []struct {
+		path, contentType string
+		forwarded         bool
+	}{
+		{"/containers/create", "application/json", true},
+		{"/v1.19/containers/web/exec", "text/plain", true},
+		{"/exec/1234/start", "", true},
+		{"/build", "application/tar", false},
+		{"/v1.19/images/load", "text/plain", false},
+		{"/containers/web/archive", "application/x-tar", false},
+	} {
+		req, _ := http.NewRequest("POST", test.path, strings.NewReader(`{"Privileged":true}`))
+		req.Header.Set("Content-Type", test.contentType)
+		body, err := peekBody(req, maxAuthzBodySize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if forwarded := body != nil; forwarded != test.forwarded {
+			t.Errorf("POST %s as %q: expected the body to be forwarded: %t, got %t", test.path, test.contentType, test.forwarded, forwarded)
+		}
+		if rest, _ := ioutil.ReadAll(req.Body); string(rest) != `{"Privileged":true}` {
+			t.Errorf("POST %s: the handler got %q", test.path, rest)
+		}
+	}
+}
+
+func TestListenerTLSConfig(t *testing.T) {
+	job := engine.New().Job("serveapi")
+	job.SetenvBool("Tls", true)
+	job.Setenv("TlsCert", "/certs/cert.pem")
+	job.Setenv("TlsKey", "/certs/key.pem")
+
+	addr, config, err := listenerTLSConfig(job, "0.0.0.0:2375")
+	if err != nil || addr != "0.0.0.0:2375" || config == nil || config.Verify {
+		t.Fatalf("Expected the daemon wide configuration, got %q %+v %v", addr, config, err)
+	}
+
+	addr, config, err = listenerTLSConfig(job, "0.0.0.0:2376?tlsverify=1&tlscacert=/mtls/ca.pem&tlscert=/mtls/cert.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := tlsConfig{CA: "/mtls/ca.pem", Certificate: "/mtls/cert.pem", Key: "/certs/key.pem", Verify: true}
+	if addr != "0.0.0.0:2376" || config == nil || *config != expected {
+		t.Fatalf("Expected %+v for 0.0.0.0:2376, got %q %+v", expected, addr, config)
+	}
+
+	if addr, config, err = listenerTLSConfig(job, "127.0.0.1:2375?tls=false"); err != nil || addr != "127.0.0.1:2375" || config != nil {
+		t.Fatalf("Expected TLS to be disabled, got %q %+v %v", addr, config, err)
+	}
+
+	for _, invalid := range []string{"0.0.0.0:2376?tls=maybe", "0.0.0.0:2376?cert=/cert.pem"} {
+		if _, _, err := listenerTLSConfig(job, invalid); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+}
+
+func TestUnixSocketOptions(t *testing.T) {
+	path, opts, err := parseUnixSocketOptions("/var/run/docker.sock", "docker")
+	if err != nil || path != "/var/run/docker.sock" || *opts != (unixSocketOptions{group: "docker", mode: 0660}) {
+		t.Fatalf("Unexpected defaults %q %+v %v", path, opts, err)
+	}
+	path, opts, err = parseUnixSocketOptions("/var/run/docker-ro.sock?group=monitoring&mode=0640&readonly=true", "docker")
+	if err != nil || path != "/var/run/docker-ro.sock" || *opts != (unixSocketOptions{group: "monitoring", mode: 0640, readOnly: true}) {
+		t.Fatalf("Unexpected options %q %+v %v", path, opts, err)
+	}
+	for _, invalid := range []string{"/sock?mode=999", "/sock?mode=01777", "/sock?readonly=maybe", "/sock?owner=root"} {
+		if _, _, err := parseUnixSocketOptions(invalid, "docker"); err == nil {
+			t.Fatalf("Expected an error for %s", invalid)
+		}
+	}
+
+	h := readOnlyHandler{http.NotFoundHandler()}
+	for method, code := range map[string]int{"GET": http.StatusNotFound, "POST": http.StatusForbidden, "DELETE": http.StatusForbidden} {
+		req, _ := http.NewRequest(method, "/containers/json", nil)
+		r := httptest.NewRecorder()
+		h.ServeHTTP(r, req)
+		if r.Code != code {
+			t.Fatalf("Expected %d for %s on a read-only socket, got %d", code, method, r.Code)
+		}
+	}
+	req, _ := http.NewRequest("GET", "/containers/foo/attach/ws", nil)
+	r := httptest.NewRecorder()
+	h.ServeHTTP(r, req)
+	if r.Code != http.StatusForbidden {
+		t.Fatalf("Expected attaching to be forbidden on a read-only socket, got %d", r.Code)
+	}
+}
+
+func TestGetSwagger(t *testing.T) {
+	eng := engine.New()
+	r := serveRequestUsingVersion("GET", "/swagger.json", "1.18", nil, eng, t)
+	assertHttpNotError(r, t)
+	assertContentType(r, "application/json", t)
+
+	var spec swaggerSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Swagger != "2.0" || spec.BasePath != "/v1.18" {
+		t.Fatalf("Unexpected spec header %+v", spec.Info)
+	}
+	op, exists := spec.Paths["/containers/{name}/json"]["get"]
+	if !exists {
+		t.Fatalf("Expected /containers/{name}/json in %v", spec.Paths)
+	}
+	if op.OperationID != "getContainersByName" {
+		t.Fatalf("Expected getContainersByName, got %s", op.OperationID)
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "name" || op.Parameters[0].In != "path" {
+		t.Fatalf("Unexpected parameters %+v", op.Parameters)
+	}
+	if op := spec.Paths["/swagger.json"]["get"]; op.OperationID != "getSwagger" {
+		t.Fatalf("Expected the spec to describe itself as getSwagger, got %q", op.OperationID)
+	}
+
+	ids := make(map[string]bool)
+	for _, ops := range spec.Paths {
+		for _, op := range ops {
+			if ids[op.OperationID] {
+				t.Fatalf("Duplicate operation id %s", op.OperationID)
+			}
+			ids[op.OperationID] = true
+		}
+	}
+
+	// The handlers described serve routes
+	for name := range swaggerRoutes {
+		if !ids[name] {
+			t.Fatalf("Expected the described handler %s to serve a route", name)
+		}
+	}
+
+	// Query parameters, request bodies and response schemas
+	op = spec.Paths["/containers/json"]["get"]
+	var all *swaggerParameter
+	for i, param := range op.Parameters {
+		if param.Name == "all" {
+			all = &op.Parameters[i]
+		}
+	}
+	if all == nil || all.In != "query" || all.Type != "boolean" {
+		t.Fatalf("Expected the all query parameter, got %+v", op.Parameters)
+	}
+	if schema := op.Responses["200"].Schema; schema == nil || schema.Type != "array" || schema.Items.Ref != "#/definitions/Container" {
+		t.Fatalf("Expected an array of containers, got %+v", op.Responses["200"])
+	}
+	if _, exists := spec.Definitions["Container"].Properties["Names"]; !exists {
+		t.Fatalf("Expected the properties of containers, got %+v", spec.Definitions["Container"])
+	}
+	op = spec.Paths["/containers/create"]["post"]
+	if len(op.Parameters) != 2 || op.Parameters[1].In != "body" || op.Parameters[1].Schema == nil {
+		t.Fatalf("Expected the name and the body of the container, got %+v", op.Parameters)
+	}
+	for _, name := range []string{"Image", "Cmd", "HostConfig"} {
+		if _, exists := op.Parameters[1].Schema.Properties[name]; !exists {
+			t.Fatalf("Expected %s in the body of the container, got %+v", name, op.Parameters[1].Schema)
+		}
+	}
+	if schema := op.Responses["201"].Schema; schema == nil || schema.Ref != "#/definitions/ContainerCreateResponse" {
+		t.Fatalf("Expected a 201 response with the ID of the container, got %+v", op.Responses)
+	}
+	if schema := op.Responses["default"].Schema; schema == nil || schema.Ref != "#/definitions/ErrorResponse" {
+		t.Fatalf("Expected error responses, got %+v", op.Responses)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := requestIDHandler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Header.Get(requestIDHeader)
+		httpError(w, r, fmt.Errorf("No such container: foo"))
+	})}
+
+	req, _ := http.NewRequest("GET", "/containers/foo/json", nil)
+	r := httptest.NewRecorder()
+	h.ServeHTTP(r, req)
+	id := r.HeaderMap.Get(requestIDHeader)
+	if id == "" || id != seen {
+		t.Fatalf("Expected a request id to be assigned, got %q (handler saw %q)", id, seen)
+	}
+	var resp types.ErrorResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != id {
+		t.Fatalf("Expected request id %s in the error response, got %+v", id, resp)
+	}
+
+	for given, honored := range map[string]bool{"lb-1234.5": true, "bad id\n": false} {
+		req, _ := http.NewRequest("GET", "/containers/foo/json", nil)
+		req.Header.Set(requestIDHeader, given)
+		r := httptest.NewRecorder()
+		h.ServeHTTP(r, req)
+		if id := r.HeaderMap.Get(requestIDHeader); (id == given) != honored {
+			t.Fatalf("Request id %q: expected honored=%v, got %q", given, honored, id)
+		}
+	}
+}
+
+func TestPostContainersUpdate(t *testing.T) {
+	eng := engine.New()
+	eng.Register("container_update", func(job *engine.Job) error {
+		if name := job.Args[0]; name != "web" {
+			t.Errorf("Expected container web, got %s", name)
+		}
+		if memory := job.GetenvInt64("Memory"); memory != 268435456 {
+			t.Errorf("Expected a memory limit of 268435456, got %d", memory)
+		}
+		if job.EnvExists("CpuShares") {
+			t.Errorf("Expected no cpu shares, got %s", job.Getenv("CpuShares"))
+		}
+		job.Errorf("Your kernel does not support swap limit capabilities. Limitation discarded.\n")
+		return nil
+	})
+
+	req, _ := http.NewRequest("POST", "/containers/web/update", strings.NewReader(`{"Memory": 268435456}`))
+	req.Header.Set("Content-Type", "application/json")
+	r := httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	assertHttpNotError(r, t)
+	assertContentType(r, "application/json", t)
+
+	var resp types.ContainerUpdateResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "swap limit") {
+		t.Fatalf("Expected the swap limit warning, got %v", resp.Warnings)
+	}
+}
+
+func TestGetContainersStatsStream(t *testing.T) {
+	eng := engine.New()
+	var stream []bool
+	eng.Register("container_stats", func(job *engine.Job) error {
+		stream = append(stream, job.GetenvBool("stream"))
+		return nil
+	})
+
+	for _, target := range []string{"/containers/web/stats", "/containers/web/stats?stream=1", "/containers/web/stats?stream=0"} {
+		r := serveRequest("GET", target, nil, eng, t)
+		assertHttpNotError(r, t)
+	}
+	if expected := []bool{true, true, false}; !reflect.DeepEqual(stream, expected) {
+		t.Fatalf("Expected stream %v, got %v", expected, stream)
+	}
+
+	r := serveRequest("GET", "/containers/web/stats?stream=maybe", nil, eng, t)
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid stream value, got %d", r.Code)
+	}
+}
+
+func TestGetSystemDf(t *testing.T) {
+	eng := engine.New()
+	var called bool
+	eng.Register("system_df", func(job *engine.Job) error {
+		called = true
+		return json.NewEncoder(job.Stdout).Encode(&types.DiskUsage{
+			LayersSize: 1024,
+			Images:     []types.ImageDiskUsage{{ID: "abc", UniqueSize: 1024}},
+		})
+	})
+
+	r := serveRequest("GET", "/system/df", nil, eng, t)
+	if !called {
+		t.Fatalf("handler was not called")
+	}
+	assertHttpNotError(r, t)
+	assertContentType(r, "application/json", t)
+
+	var du types.DiskUsage
+	if err := json.NewDecoder(r.Body).Decode(&du); err != nil {
+		t.Fatal(err)
+	}
+	if du.LayersSize != 1024 || len(du.Images) != 1 || du.Images[0].UniqueSize != 1024 {
+		t.Fatalf("Unexpected disk usage %+v", du)
+	}
+}
+
+func TestPostSystemPrune(t *testing.T) {
+	eng := engine.New()
+	eng.Register("system_prune", func(job *engine.Job) error {
+		if filters := job.Getenv("filters"); filters != `{"until":["24h"]}` {
+			t.Errorf("Expected the until filter, got %s", filters)
+		}
+		return json.NewEncoder(job.Stdout).Encode(&types.SystemPruneReport{
+			ContainersDeleted: []string{"abc"},
+			SpaceReclaimed:    4096,
+		})
+	})
+
+	r := serveRequest("POST", "/system/prune?filters="+url.QueryEscape(`{"until":["24h"]}`), strings.NewReader(""), eng, t)
+	assertHttpNotError(r, t)
+	assertContentType(r, "application/json", t)
+
+	var report types.SystemPruneReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.ContainersDeleted) != 1 || report.SpaceReclaimed != 4096 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+}
+
+func TestPutContainersArchive(t *testing.T) {
+	eng := engine.New()
+	var content string
+	eng.Register("container_extract", func(job *engine.Job) error {
+		if len(job.Args) != 2 || job.Args[0] != "test" || job.Args[1] != "etc/nginx" {
+			t.Fatalf("Unexpected job arguments %v", job.Args)
+		}
+		b, err := ioutil.ReadAll(job.Stdin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		content = string(b)
+		return nil
+	})
+
+	r := serveRequest("PUT", "/containers/test/archive?path=/etc/nginx", strings.NewReader("tar stream"), eng, t)
+	if r.Code != http.StatusOK {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusOK)
+	}
+	if content != "tar stream" {
+		t.Fatalf("Unexpected archive content %q", content)
+	}
+
+	r = serveRequest("PUT", "/containers/test/archive", strings.NewReader(""), eng, t)
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusBadRequest)
+	}
+}
+
 func TestGetInfo(t *testing.T) {
 	eng := engine.New()
 	var called bool
@@ -143,6 +863,46 @@ func TestGetImagesJSON(t *testing.T) {
 	}
 }
 
+func TestGetContainersJSONETag(t *testing.T) {
+	eng := engine.New()
+	eng.Register("containers", func(job *engine.Job) error {
+		_, err := job.Stdout.Write([]byte(`[{"Id":"abc"}]`))
+		return err
+	})
+	r := serveRequest("GET", "/containers/json", nil, eng, t)
+	assertHttpNotError(r, t)
+	assertContentType(r, "application/json", t)
+	etag := r.HeaderMap.Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag header")
+	}
+
+	req, err := http.NewRequest("GET", "/containers/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-None-Match", etag)
+	r = httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	if r.Code != http.StatusNotModified {
+		t.Fatalf("Expected %d, got %d", http.StatusNotModified, r.Code)
+	}
+	if r.Body.Len() != 0 {
+		t.Fatalf("Expected an empty body, got %q", r.Body.String())
+	}
+
+	req, err = http.NewRequest("GET", "/containers/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-None-Match", `"stale"`)
+	r = httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	if r.Code != http.StatusOK || r.Body.String() != `[{"Id":"abc"}]` {
+		t.Fatalf("Expected the full list for a stale ETag, got %d %q", r.Code, r.Body.String())
+	}
+}
+
 func TestGetImagesJSONFilter(t *testing.T) {
 	eng := engine.New()
 	filter := "nothing"
@@ -293,6 +1053,59 @@ func TestGetEvents(t *testing.T) {
 	}
 }
 
+func TestGetEventsServerSentEvents(t *testing.T) {
+	eng := engine.New()
+	eng.Register("events", func(job *engine.Job) error {
+		job.Stdout.Write(nil)
+		job.Stdout.Write([]byte(`{"status":"start","id":"abc"}`))
+		job.Stdout.Write([]byte(`{"status":"die","id":"abc"}`))
+		return nil
+	})
+	req, err := http.NewRequest("GET", "/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	r := httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	assertHttpNotError(r, t)
+	assertContentType(r, "text/event-stream", t)
+	expected := "data: {\"status\":\"start\",\"id\":\"abc\"}\n\ndata: {\"status\":\"die\",\"id\":\"abc\"}\n\n"
+	if r.Body.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, r.Body.String())
+	}
+}
+
+func TestGetEventsWebsocket(t *testing.T) {
+	eng := engine.New()
+	eng.Register("events", func(job *engine.Job) error {
+		if filters := job.Getenv("filters"); filters != `{"event":["die"]}` {
+			t.Errorf("unexpected filters %q", filters)
+		}
+		job.Stdout.Write(nil)
+		job.Stdout.Write([]byte(`{"status":"die","id":"abc"}`))
+		return nil
+	})
+	srv := httptest.NewServer(createRouter(eng, false, false, "", ""))
+	defer srv.Close()
+
+	query := url.Values{}
+	query.Set("filters", `{"event":["die"]}`)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/events/ws?"+query.Encode(), "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	var msg string
+	if err := websocket.Message.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg != `{"status":"die","id":"abc"}` {
+		t.Fatalf("unexpected event frame %q", msg)
+	}
+}
+
 func TestLogs(t *testing.T) {
 	eng := engine.New()
 	var inspect bool
@@ -386,6 +1199,30 @@ func TestLogsNoStreams(t *testing.T) {
 	}
 }
 
+func TestLogsSince(t *testing.T) {
+	eng := engine.New()
+	var since string
+	eng.Register("container_inspect", func(job *engine.Job) error {
+		return nil
+	})
+	eng.Register("logs", func(job *engine.Job) error {
+		since = job.Getenv("since")
+		return nil
+	})
+	r := serveRequest("GET", "/containers/test/logs?stdout=1&since=1429617600", nil, eng, t)
+	if r.Code != http.StatusOK {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusOK)
+	}
+	if since != "1429617600" {
+		t.Fatalf("since: %s, must be 1429617600", since)
+	}
+
+	r = serveRequest("GET", "/containers/test/logs?stdout=1&since=10m", nil, eng, t)
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Got status %d, expected %d", r.Code, http.StatusBadRequest)
+	}
+}
+
 func TestGetImagesHistory(t *testing.T) {
 	eng := engine.New()
 	imageName := "docker-test-image"
@@ -476,6 +1313,75 @@ func TestDeleteContainers(t *testing.T) {
 	}
 }
 
+func TestPostContainersBatch(t *testing.T) {
+	eng := engine.New()
+	eng.Register("stop", func(job *engine.Job) error {
+		if job.GetenvInt("t") != 3 {
+			t.Errorf("Expected a timeout of 3, got %s", job.Getenv("t"))
+		}
+		switch job.Args[0] {
+		case "missing":
+			return fmt.Errorf("No such container: missing")
+		case "stopped":
+			return fmt.Errorf("Container already stopped")
+		}
+		return nil
+	})
+
+	body := `{"Action": "stop", "Containers": ["web", "missing", "stopped"], "Timeout": 3}`
+	req, _ := http.NewRequest("POST", "/containers/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r := httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	assertHttpNotError(r, t)
+
+	var results []types.ContainerBatchResult
+	if err := json.NewDecoder(r.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	expected := []types.ContainerBatchResult{
+		{ID: "web", StatusCode: http.StatusNoContent},
+		{ID: "missing", StatusCode: http.StatusNotFound, Error: "No such container: missing"},
+		{ID: "stopped", StatusCode: http.StatusNotModified},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, results)
+	}
+
+	req, _ = http.NewRequest("POST", "/containers/batch", strings.NewReader(`{"Action": "pause", "Containers": ["web"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	r = httptest.NewRecorder()
+	ServeRequest(eng, api.APIVERSION, r, req)
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d for an unknown action, got %d", http.StatusBadRequest, r.Code)
+	}
+}
+
+func TestPostContainersWaitCondition(t *testing.T) {
+	eng := engine.New()
+	eng.Register("wait", func(job *engine.Job) error {
+		if job.Getenv("condition") != "removed" || job.GetenvInt("timeout") != 30 {
+			t.Errorf("Unexpected wait parameters %s", job.Environ())
+		}
+		job.Printf("%d\n", 3)
+		return nil
+	})
+	r := serveRequest("POST", "/containers/foo/wait?condition=removed&timeout=30", strings.NewReader(""), eng, t)
+	assertHttpNotError(r, t)
+	var resp types.ContainerWaitResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 3 {
+		t.Fatalf("Expected status code 3, got %d", resp.StatusCode)
+	}
+
+	r = serveRequest("POST", "/containers/foo/wait?timeout=soon", strings.NewReader(""), eng, t)
+	if r.Code != http.StatusBadRequest {
+		t.Fatalf("Expected %d for an invalid timeout, got %d", http.StatusBadRequest, r.Code)
+	}
+}
+
 func serveRequest(method, target string, body io.Reader, eng *engine.Engine, t *testing.T) *httptest.ResponseRecorder {
 	return serveRequestUsingVersion(method, target, api.APIVERSION, body, eng, t)
 }
@@ -4,30 +4,29 @@ package server
 import (
 	"errors"
 	"net"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
 )
 
-// NewServer sets up the required Server and does protocol specific checking.
-func NewServer(proto, addr string, job *engine.Job) (Server, error) {
-	var (
-		err error
-		l   net.Listener
-		r   = createRouter(
-			job.Eng,
-			job.GetenvBool("Logging"),
-			job.GetenvBool("EnableCors"),
-			job.Getenv("CorsHeaders"),
-			job.Getenv("Version"),
-		)
-	)
+// NewServer sets up the required Servers and does protocol specific checking.
+func NewServer(proto, addr string, job *engine.Job) ([]Server, error) {
+	var l net.Listener
+	r, err := newHandler(job)
+	if err != nil {
+		return nil, err
+	}
 	switch proto {
 	case "tcp":
-		if !job.GetenvBool("TlsVerify") {
+		var config *tlsConfig
+		if addr, config, err = listenerTLSConfig(job, addr); err != nil {
+			return nil, err
+		}
+		if config == nil || !config.Verify {
 			logrus.Infof("/!\\ DON'T BIND ON ANY IP ADDRESS WITHOUT setting -tlsverify IF YOU DON'T KNOW WHAT YOU'RE DOING /!\\")
 		}
-		if l, err = NewTcpSocket(addr, tlsConfigFromJob(job)); err != nil {
+		if l, err = NewTcpSocket(addr, config); err != nil {
 			return nil, err
 		}
 		if err := allocateDaemonPort(addr); err != nil {
@@ -36,6 +35,7 @@ func NewServer(proto, addr string, job *engine.Job) (Server, error) {
 	default:
 		return nil, errors.New("Invalid protocol format. Windows only supports tcp.")
 	}
+	return []Server{newHttpServer(addr, l, r, time.Duration(job.GetenvInt("DrainTimeout"))*time.Second)}, nil
 }
 
 // Called through eng.Job("acceptconnections")
@@ -0,0 +1,510 @@
+package server
+
+import (
+	"go/ast"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/version"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
+)
+
+// swaggerSpec is the subset of a Swagger 2.0 (OpenAPI) description we fill.
+type swaggerSpec struct {
+	Swagger     string                                 `json:"swagger"`
+	Info        swaggerInfo                            `json:"info"`
+	BasePath    string                                 `json:"basePath"`
+	Schemes     []string                               `json:"schemes"`
+	Consumes    []string                               `json:"consumes"`
+	Produces    []string                               `json:"produces"`
+	Paths       map[string]map[string]swaggerOperation `json:"paths"`
+	Definitions map[string]swaggerSchema               `json:"definitions"`
+
+	// the types described in Definitions, by name
+	definedTypes map[string]reflect.Type
+}
+
+type swaggerInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type swaggerOperation struct {
+	OperationID string                     `json:"operationId"`
+	Consumes    []string                   `json:"consumes,omitempty"`
+	Produces    []string                   `json:"produces,omitempty"`
+	Parameters  []swaggerParameter         `json:"parameters,omitempty"`
+	Responses   map[string]swaggerResponse `json:"responses"`
+}
+
+type swaggerParameter struct {
+	Name             string         `json:"name"`
+	In               string         `json:"in"`
+	Required         bool           `json:"required"`
+	Type             string         `json:"type,omitempty"`
+	Items            *swaggerSchema `json:"items,omitempty"`
+	CollectionFormat string         `json:"collectionFormat,omitempty"`
+	Schema           *swaggerSchema `json:"schema,omitempty"`
+}
+
+type swaggerResponse struct {
+	Description string         `json:"description"`
+	Schema      *swaggerSchema `json:"schema,omitempty"`
+}
+
+type swaggerSchema struct {
+	Ref                  string                   `json:"$ref,omitempty"`
+	Type                 string                   `json:"type,omitempty"`
+	Format               string                   `json:"format,omitempty"`
+	Items                *swaggerSchema           `json:"items,omitempty"`
+	Properties           map[string]swaggerSchema `json:"properties,omitempty"`
+	AdditionalProperties *swaggerSchema           `json:"additionalProperties,omitempty"`
+}
+
+// swaggerRoute describes what the routes of a handler take and return,
+// besides the variables of their paths.
+type swaggerRoute struct {
+	// query and headers are the names of the query parameters and headers,
+	// with their types after a colon unless they are strings, e.g.
+	// "all:boolean". Parameters of type array can be given several times.
+	query   []string
+	headers []string
+	// body is a value of the type of the request body, or a swaggerSchema,
+	// nil for the routes without one.
+	body         interface{}
+	bodyOptional bool
+	consumes     []string
+	// status is the status of the successful responses, 200 if unset.
+	status int
+	// response is a value of the type of the successful responses, or a
+	// swaggerSchema, nil for responses without a body. The responses
+	// streaming JSON messages are described by the type of the messages.
+	response interface{}
+	produces []string
+}
+
+const registryAuthHeader = "X-Registry-Auth"
+
+var (
+	binarySchema = swaggerSchema{Type: "string", Format: "binary"}
+	objectSchema = swaggerSchema{Type: "object"}
+
+	tarContent       = []string{"application/x-tar"}
+	rawStreamContent = []string{"application/vnd.docker.raw-stream"}
+)
+
+// swaggerRoutes describes the routes of each handler of createRouter, by
+// name. The handlers missing from it are described by the variables of
+// their paths only.
+var swaggerRoutes = map[string]swaggerRoute{
+	"ping":       {response: swaggerSchema{Type: "string"}, produces: []string{"text/plain"}},
+	"getInfo":    {response: objectSchema},
+	"getVersion": {response: objectSchema},
+	"postAuth":   {body: registry.AuthConfig{}, response: types.AuthResponse{}},
+	"getEvents": {
+		query:    []string{"since", "until", "filters"},
+		response: jsonmessage.JSONMessage{},
+	},
+	"wsGetEvents": {query: []string{"since", "until", "filters"}},
+	"getSystemDf": {response: types.DiskUsage{}},
+	"postSystemPrune": {
+		query:    []string{"filters"},
+		response: types.SystemPruneReport{},
+	},
+	"postSystemBench": {
+		query:    []string{"layers:integer", "files:integer", "filesize"},
+		response: types.StorageBenchmark{},
+	},
+
+	"getImagesJSON": {
+		query:    []string{"all:boolean", "filter", "filters"},
+		response: []types.Image{},
+	},
+	"getImagesSearch": {
+		query:    []string{"term"},
+		headers:  []string{registryAuthHeader},
+		response: []registry.SearchResult{},
+	},
+	"getImagesGet": {
+		query:    []string{"names:array", "compress"},
+		response: binarySchema,
+		produces: []string{"application/x-tar", "application/x-gzip", "application/x-xz"},
+	},
+	"getImagesHistory": {response: []types.ImageHistory{}},
+	"getImagesByName":  {response: objectSchema},
+	"postImagesCreate": {
+		query:        []string{"fromImage", "fromSrc", "repo", "tag", "changes:array"},
+		headers:      []string{registryAuthHeader},
+		body:         binarySchema,
+		bodyOptional: true,
+		consumes:     tarContent,
+		response:     jsonmessage.JSONMessage{},
+	},
+	"postImagesLoad": {
+		body:     binarySchema,
+		consumes: tarContent,
+		response: jsonmessage.JSONMessage{},
+	},
+	"postImagesPush": {
+		query:    []string{"tag"},
+		headers:  []string{registryAuthHeader},
+		response: jsonmessage.JSONMessage{},
+	},
+	"postImagesTag": {
+		query:  []string{"repo", "tag", "force:boolean"},
+		status: http.StatusCreated,
+	},
+	"postImagesVerify": {response: types.ImageVerification{}},
+	"deleteImages": {
+		query:    []string{"force:boolean", "noprune:boolean"},
+		response: []types.ImageDelete{},
+	},
+	"postCommit": {
+		query:    []string{"container", "repo", "tag", "author", "comment", "pause:boolean", "changes:array"},
+		body:     runconfig.Config{},
+		status:   http.StatusCreated,
+		response: types.ContainerCommitResponse{},
+	},
+	"postBuild": {
+		query: []string{"dockerfile", "t", "remote", "q:boolean", "nocache:boolean", "pull:boolean", "rm:boolean", "forcerm:boolean",
+			"memory:integer", "memswap:integer", "cpushares:integer", "cpusetcpus"},
+		headers:      []string{registryAuthHeader, "X-Registry-Config"},
+		body:         binarySchema,
+		bodyOptional: true,
+		consumes:     tarContent,
+		response:     jsonmessage.JSONMessage{},
+	},
+
+	"getContainersJSON": {
+		query:    []string{"all:boolean", "limit:integer", "since", "before", "size:boolean", "filters"},
+		response: []types.Container{},
+	},
+	"getContainersByName":  {response: objectSchema},
+	"getContainersChanges": {response: []types.ContainerChange{}},
+	"getContainersExport":  {response: binarySchema, produces: tarContent},
+	"getContainersTop": {
+		query: []string{"ps_args", "fields"},
+		response: struct {
+			Titles    []string
+			Processes [][]string
+		}{},
+	},
+	"getContainersLogs": {
+		query:    []string{"follow:boolean", "stdout:boolean", "stderr:boolean", "timestamps:boolean", "details:boolean", "tail", "since"},
+		response: binarySchema,
+		produces: rawStreamContent,
+	},
+	"getContainersStats": {
+		query:    []string{"stream:boolean"},
+		response: types.Stats{},
+	},
+	"postContainersCreate": {
+		query: []string{"name"},
+		body: struct {
+			runconfig.Config
+			HostConfig runconfig.HostConfig
+		}{},
+		status:   http.StatusCreated,
+		response: types.ContainerCreateResponse{},
+	},
+	"postContainersBatch": {
+		body:     types.ContainerBatchRequest{},
+		response: []types.ContainerBatchResult{},
+	},
+	"postContainersStart": {
+		body:         runconfig.HostConfig{},
+		bodyOptional: true,
+		status:       http.StatusNoContent,
+	},
+	"postContainersStop":    {query: []string{"t:integer"}, status: http.StatusNoContent},
+	"postContainersRestart": {query: []string{"t:integer"}, status: http.StatusNoContent},
+	"postContainersKill":    {query: []string{"signal"}, status: http.StatusNoContent},
+	"postContainersPause":   {status: http.StatusNoContent},
+	"postContainersUnpause": {status: http.StatusNoContent},
+	"postContainersWait": {
+		query:    []string{"condition", "timeout:integer"},
+		response: types.ContainerWaitResponse{},
+	},
+	"postContainersResize": {query: []string{"h:integer", "w:integer"}},
+	"postContainersAttach": {
+		query:    []string{"logs:boolean", "stream:boolean", "detachKeys"},
+		response: binarySchema,
+		produces: rawStreamContent,
+	},
+	"wsContainersAttach": {query: []string{"logs:boolean", "stream:boolean", "detachKeys"}},
+	"postContainersCopy": {
+		body:     struct{ Resource string }{},
+		response: binarySchema,
+		produces: tarContent,
+	},
+	"putContainersArchive": {
+		query:    []string{"path"},
+		body:     binarySchema,
+		consumes: tarContent,
+	},
+	"postContainerRename": {query: []string{"name", "t:integer"}, status: http.StatusNoContent},
+	"postContainersUpdate": {
+		body: struct {
+			Memory, MemorySwap, CpuShares, CpuPeriod, CpuQuota, BlkioWeight int64
+			CpusetCpus                                                      string
+		}{},
+		response: types.ContainerUpdateResponse{},
+	},
+	"deleteContainers": {
+		query:  []string{"v:boolean", "force:boolean", "link:boolean"},
+		status: http.StatusNoContent,
+	},
+
+	"postContainerExecCreate": {
+		body:     runconfig.ExecConfig{},
+		status:   http.StatusCreated,
+		response: types.ContainerExecCreateResponse{},
+	},
+	"postContainerExecStart": {
+		body:     struct{ Detach, Tty bool }{},
+		response: binarySchema,
+		produces: rawStreamContent,
+	},
+	"postContainerExecResize": {query: []string{"h:integer", "w:integer"}},
+	"getExecByID":             {response: objectSchema},
+
+	"getNetworksJSON":       {response: []types.NetworkResource{}},
+	"getNetwork":            {response: types.NetworkResource{}},
+	"postNetworksCreate":    {body: types.NetworkCreate{}, status: http.StatusCreated, response: types.NetworkCreateResponse{}},
+	"postNetworkConnect":    {body: types.NetworkConnect{}},
+	"postNetworkDisconnect": {body: types.NetworkDisconnect{}},
+	"deleteNetworks":        {status: http.StatusNoContent},
+}
+
+// routeVariable matches the variables of gorilla/mux routes, e.g. {name:.*}.
+var routeVariable = regexp.MustCompile(`\{([a-zA-Z0-9_]+)(:[^}]*)?\}`)
+
+// newSwaggerSpec describes the routes the API is served from, as registered
+// in createRouter, for the given API version.
+func newSwaggerSpec(routes map[string]map[string]HttpApiFunc, apiVersion version.Version) *swaggerSpec {
+	spec := &swaggerSpec{
+		Swagger:      "2.0",
+		Info:         swaggerInfo{Title: "Docker Remote API", Version: string(apiVersion)},
+		BasePath:     "/v" + string(apiVersion),
+		Schemes:      []string{"http", "https"},
+		Consumes:     []string{"application/json", "text/plain"},
+		Produces:     []string{"application/json", "text/plain"},
+		Paths:        make(map[string]map[string]swaggerOperation),
+		Definitions:  make(map[string]swaggerSchema),
+		definedTypes: make(map[string]reflect.Type),
+	}
+	errorSchema := spec.schemaOf(types.ErrorResponse{})
+
+	// Walk the routes in a stable order so that handlers serving several
+	// routes always get the same operation ids.
+	var keys []string
+	for method, paths := range routes {
+		for path := range paths {
+			if path != "" {
+				keys = append(keys, path+" "+method)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	operationIDs := make(map[string]int)
+	for _, key := range keys {
+		parts := strings.SplitN(key, " ", 2)
+		route, method := parts[0], parts[1]
+
+		name := handlerName(routes[method][route])
+		id := name
+		if operationIDs[id]++; operationIDs[id] > 1 {
+			id += strconv.Itoa(operationIDs[id])
+		}
+		op := swaggerOperation{
+			OperationID: id,
+			Responses: map[string]swaggerResponse{
+				"default": {Description: "Error", Schema: errorSchema},
+			},
+		}
+		for _, m := range routeVariable.FindAllStringSubmatch(route, -1) {
+			op.Parameters = append(op.Parameters, swaggerParameter{Name: m[1], In: "path", Required: true, Type: "string"})
+		}
+
+		desc := swaggerRoutes[name]
+		for _, q := range desc.query {
+			op.Parameters = append(op.Parameters, newSwaggerParameter(q, "query"))
+		}
+		for _, h := range desc.headers {
+			op.Parameters = append(op.Parameters, newSwaggerParameter(h, "header"))
+		}
+		if desc.body != nil {
+			op.Parameters = append(op.Parameters, swaggerParameter{
+				Name:     "body",
+				In:       "body",
+				Required: !desc.bodyOptional,
+				Schema:   spec.schemaOf(desc.body),
+			})
+		}
+		status := desc.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := swaggerResponse{Description: http.StatusText(status)}
+		if desc.response != nil {
+			response.Schema = spec.schemaOf(desc.response)
+		}
+		op.Responses[strconv.Itoa(status)] = response
+		op.Consumes, op.Produces = desc.consumes, desc.produces
+
+		path := routeVariable.ReplaceAllString(route, "{$1}")
+		if spec.Paths[path] == nil {
+			spec.Paths[path] = make(map[string]swaggerOperation)
+		}
+		spec.Paths[path][strings.ToLower(method)] = op
+	}
+	return spec
+}
+
+// newSwaggerParameter returns the parameter in of the given "name:type".
+func newSwaggerParameter(nameAndType, in string) swaggerParameter {
+	param := swaggerParameter{Name: nameAndType, In: in, Type: "string"}
+	if i := strings.Index(nameAndType, ":"); i >= 0 {
+		param.Name, param.Type = nameAndType[:i], nameAndType[i+1:]
+	}
+	if param.Type == "array" {
+		param.Items = &swaggerSchema{Type: "string"}
+		param.CollectionFormat = "multi"
+	}
+	return param
+}
+
+// schemaOf returns the schema of the JSON encoding of v, which is added to
+// the definitions of spec for the named struct types. v can be a
+// swaggerSchema already.
+func (spec *swaggerSpec) schemaOf(v interface{}) *swaggerSchema {
+	if schema, ok := v.(swaggerSchema); ok {
+		return &schema
+	}
+	return spec.schemaOfType(reflect.TypeOf(v))
+}
+
+func (spec *swaggerSpec) schemaOfType(t reflect.Type) *swaggerSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &swaggerSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &swaggerSchema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &swaggerSchema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &swaggerSchema{Type: "number"}
+	case reflect.String:
+		return &swaggerSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded in base64
+			return &swaggerSchema{Type: "string", Format: "byte"}
+		}
+		return &swaggerSchema{Type: "array", Items: spec.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &swaggerSchema{Type: "object", AdditionalProperties: spec.schemaOfType(t.Elem())}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return &swaggerSchema{Type: "string", Format: "date-time"}
+		}
+		if t.Name() == "" || !ast.IsExported(t.Name()) {
+			return spec.structSchema(t)
+		}
+		name := t.Name()
+		if defined, exists := spec.definedTypes[name]; exists && defined != t {
+			// e.g. runconfig.Config and another Config
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		if _, exists := spec.definedTypes[name]; !exists {
+			// Defined before its fields, which may refer to it
+			spec.definedTypes[name] = t
+			spec.Definitions[name] = *spec.structSchema(t)
+		}
+		return &swaggerSchema{Ref: "#/definitions/" + name}
+	}
+	// e.g. interface{}, which can be any value
+	return &swaggerSchema{}
+}
+
+// structSchema returns the schema of the struct type t, whose properties are
+// its fields as encoding/json encodes them, with the fields of its embedded
+// structs.
+func (spec *swaggerSpec) structSchema(t reflect.Type) *swaggerSchema {
+	schema := &swaggerSchema{Type: "object", Properties: make(map[string]swaggerSchema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if i := strings.Index(tag, ","); i >= 0 {
+				tag = tag[:i]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && ft.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			for name, prop := range spec.structSchema(ft).Properties {
+				if _, exists := schema.Properties[name]; !exists {
+					schema.Properties[name] = prop
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		schema.Properties[name] = *spec.schemaOfType(field.Type)
+	}
+	return schema
+}
+
+// handlerName returns the name of the function behind fct, e.g.
+// getContainersJSON, or of the function that created it for closures.
+func handlerName(fct HttpApiFunc) string {
+	// e.g. github.com/docker/docker/api/server.getSwagger.func1
+	name := runtime.FuncForPC(reflect.ValueOf(fct).Pointer()).Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if parts := strings.Split(name, "."); len(parts) > 1 {
+		name = parts[1]
+	}
+	return name
+}
+
+// getSwagger serves the description of routes, which must include itself.
+func getSwagger(routes map[string]map[string]HttpApiFunc) HttpApiFunc {
+	return func(eng *engine.Engine, version version.Version, w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		if version == "" {
+			version = api.APIVERSION
+		}
+		return writeJSON(w, http.StatusOK, newSwaggerSpec(routes, version))
+	}
+}
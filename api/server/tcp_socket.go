@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/pkg/listenbuffer"
@@ -32,6 +35,67 @@ func tlsConfigFromJob(job *engine.Job) *tlsConfig {
 	}
 }
 
+// listenerTLSConfig splits the options of a single tcp listener off its
+// address, e.g. "0.0.0.0:2376?tlsverify=1&tlscacert=/etc/docker/ca.pem",
+// and returns the TLS configuration for that listener: the daemon wide one
+// with the given options applied on top of it. The options are tls,
+// tlsverify, tlscacert, tlscert and tlskey, named after the daemon flags.
+func listenerTLSConfig(job *engine.Job, addr string) (string, *tlsConfig, error) {
+	i := strings.Index(addr, "?")
+	if i < 0 {
+		return addr, tlsConfigFromJob(job), nil
+	}
+	options, err := url.ParseQuery(addr[i+1:])
+	if err != nil {
+		return "", nil, fmt.Errorf("Invalid options for listener %s: %v", addr, err)
+	}
+	addr = addr[:i]
+
+	var (
+		enabled = job.GetenvBool("Tls") || job.GetenvBool("TlsVerify")
+		config  = tlsConfig{
+			Verify:      job.GetenvBool("TlsVerify"),
+			Certificate: job.Getenv("TlsCert"),
+			Key:         job.Getenv("TlsKey"),
+			CA:          job.Getenv("TlsCa"),
+		}
+	)
+	for name := range options {
+		switch name {
+		case "tls", "tlsverify", "tlscacert", "tlscert", "tlskey":
+		default:
+			return "", nil, fmt.Errorf("Unknown option %q for listener %s", name, addr)
+		}
+	}
+	if value := options.Get("tls"); value != "" {
+		if enabled, err = strconv.ParseBool(value); err != nil {
+			return "", nil, fmt.Errorf("Invalid value %q for option tls of listener %s", value, addr)
+		}
+		if !enabled {
+			config.Verify = false
+		}
+	}
+	if value := options.Get("tlsverify"); value != "" {
+		if config.Verify, err = strconv.ParseBool(value); err != nil {
+			return "", nil, fmt.Errorf("Invalid value %q for option tlsverify of listener %s", value, addr)
+		}
+		enabled = enabled || config.Verify
+	}
+	if value := options.Get("tlscacert"); value != "" {
+		config.CA = value
+	}
+	if value := options.Get("tlscert"); value != "" {
+		config.Certificate = value
+	}
+	if value := options.Get("tlskey"); value != "" {
+		config.Key = value
+	}
+	if !enabled {
+		return addr, nil, nil
+	}
+	return addr, &config, nil
+}
+
 func NewTcpSocket(addr string, config *tlsConfig) (net.Listener, error) {
 	l, err := listenbuffer.NewListenBuffer("tcp", addr, activationLock)
 	if err != nil {
@@ -54,8 +118,14 @@ func setupTls(l net.Listener, config *tlsConfig) (net.Listener, error) {
 		return nil, fmt.Errorf("Error reading X509 key pair (%s, %s): %q. Make sure the key is encrypted.",
 			config.Certificate, config.Key, err)
 	}
+	nextProtos := []string{"http/1.1"}
+	if config.Verify {
+		// Clients that can't multiplex over HTTP/2, or that need to hijack
+		// the connection for attach and exec, still negotiate HTTP/1.1.
+		nextProtos = append(append([]string{}, http2Protos...), nextProtos...)
+	}
 	tlsConfig := &tls.Config{
-		NextProtos:   []string{"http/1.1"},
+		NextProtos:   nextProtos,
 		Certificates: []tls.Certificate{tlsCert},
 		// Avoid fallback on insecure SSL protocols
 		MinVersion: tls.VersionTLS10,
@@ -0,0 +1,7 @@
+// +build go1.6
+
+package server
+
+// http2Protos lists the ALPN protocols advertised in addition to HTTP/1.1
+// on verified TLS listeners. net/http serves HTTP/2 on its own from Go 1.6.
+var http2Protos = []string{"h2"}
@@ -0,0 +1,6 @@
+// +build !go1.6
+
+package server
+
+// http2Protos is empty as net/http can't serve HTTP/2 before Go 1.6.
+var http2Protos = []string{}
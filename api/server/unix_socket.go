@@ -3,8 +3,11 @@ package server
 import (
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"syscall"
 
 	"github.com/Sirupsen/logrus"
@@ -12,7 +15,50 @@ import (
 	"github.com/docker/libcontainer/user"
 )
 
-func NewUnixSocket(path, group string) (net.Listener, error) {
+// unixSocketOptions are the options a unix listener can be given after its
+// path, e.g. "/var/run/docker-ro.sock?group=monitoring&mode=0640&readonly=true".
+type unixSocketOptions struct {
+	group    string
+	mode     os.FileMode
+	readOnly bool
+}
+
+// parseUnixSocketOptions splits the options off the address of a unix
+// listener. The group defaults to defaultGroup and the mode to 0660.
+func parseUnixSocketOptions(addr, defaultGroup string) (string, *unixSocketOptions, error) {
+	opts := &unixSocketOptions{group: defaultGroup, mode: 0660}
+	i := strings.Index(addr, "?")
+	if i < 0 {
+		return addr, opts, nil
+	}
+	values, err := url.ParseQuery(addr[i+1:])
+	if err != nil {
+		return "", nil, fmt.Errorf("Invalid options for listener %s: %v", addr, err)
+	}
+	addr = addr[:i]
+	for name := range values {
+		value := values.Get(name)
+		switch name {
+		case "group":
+			opts.group = value
+		case "mode":
+			mode, err := strconv.ParseUint(value, 8, 32)
+			if err != nil || mode > 0777 {
+				return "", nil, fmt.Errorf("Invalid mode %q for listener %s", value, addr)
+			}
+			opts.mode = os.FileMode(mode)
+		case "readonly":
+			if opts.readOnly, err = strconv.ParseBool(value); err != nil {
+				return "", nil, fmt.Errorf("Invalid value %q for option readonly of listener %s", value, addr)
+			}
+		default:
+			return "", nil, fmt.Errorf("Unknown option %q for listener %s", name, addr)
+		}
+	}
+	return addr, opts, nil
+}
+
+func NewUnixSocket(path, group string, mode os.FileMode) (net.Listener, error) {
 	if err := syscall.Unlink(path); err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
@@ -26,7 +72,7 @@ func NewUnixSocket(path, group string) (net.Listener, error) {
 		l.Close()
 		return nil, err
 	}
-	if err := os.Chmod(path, 0660); err != nil {
+	if err := os.Chmod(path, mode); err != nil {
 		l.Close()
 		return nil, err
 	}
@@ -76,3 +122,20 @@ func lookupGidByName(nameOrGid string) (int, error) {
 	}
 	return -1, fmt.Errorf("Group %s not found", nameOrGid)
 }
+
+// readOnlyHandler only lets requests that can't change the state of the
+// daemon through, for sockets handed to monitoring tools.
+type readOnlyHandler struct {
+	handler http.Handler
+}
+
+func (h readOnlyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	readOnly := r.Method == "GET" || r.Method == "HEAD" || r.Method == "OPTIONS"
+	// Attaching over a websocket is a GET, but lets the client write to
+	// the container.
+	if !readOnly || strings.HasSuffix(r.URL.Path, "/attach/ws") {
+		writeError(w, r, http.StatusForbidden, errCodeReadOnlySocket, fmt.Sprintf("%s requests are not allowed on this read-only socket", r.Method))
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -10,6 +10,13 @@ type ContainerCreateResponse struct {
 	Warnings []string `json:"Warnings"`
 }
 
+// ContainerUpdateResponse contains the information returned to a client on
+// the update of the resource limits of a container.
+type ContainerUpdateResponse struct {
+	// Warnings are any warnings encountered during the update of the container.
+	Warnings []string `json:"Warnings"`
+}
+
 // POST /containers/{name:.*}/exec
 type ContainerExecCreateResponse struct {
 	// ID is the exec ID.
@@ -29,6 +36,9 @@ type AuthResponse struct {
 type ContainerWaitResponse struct {
 	// StatusCode is the status code of the wait job
 	StatusCode int `json:"StatusCode"`
+	// Error is the error of a wait for the removal of a container,
+	// which can't be reported with the status of the response
+	Error string `json:",omitempty"`
 }
 
 // POST "/commit?container="+containerID
@@ -51,6 +61,23 @@ type ImageHistory struct {
 	Size      int64
 }
 
+// POST "/images/{name:.*}/verify"
+type ImageVerification struct {
+	ID     string              `json:"Id"`
+	Layers []LayerVerification `json:"Layers"`
+}
+
+// LayerVerification is the outcome of the verification of the layer of an
+// image: "ok" if the sum of its content is still the one recorded when it
+// was registered, "corrupted" if it isn't, and "unverified" if no sum was
+// recorded for it.
+type LayerVerification struct {
+	ID       string `json:"Id"`
+	Status   string `json:"Status"`
+	Expected string `json:"Expected,omitempty"`
+	Actual   string `json:"Actual,omitempty"`
+}
+
 // DELETE "/images/{name:.*}"
 type ImageDelete struct {
 	Untagged string `json:",omitempty"`
@@ -89,3 +116,215 @@ type Container struct {
 	Labels     map[string]string `json:,omitempty"`
 	Status     string            `json:,omitempty"`
 }
+
+// ErrorResponse is the body of error responses from API version 1.19 on.
+type ErrorResponse struct {
+	// Code is a stable identifier of the kind of error, e.g. CONTAINER_NOT_FOUND.
+	Code string `json:"code"`
+
+	// Message is a human readable description of the error.
+	Message string `json:"message"`
+
+	// RequestID identifies the request in the daemon logs.
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// POST /containers/batch
+type ContainerBatchRequest struct {
+	// Action is applied to every container: stop, kill or remove.
+	Action string `json:"Action"`
+
+	// Containers are the ids or names of the containers to act on.
+	Containers []string `json:"Containers"`
+
+	// Timeout is the number of seconds to wait before killing containers
+	// being stopped.
+	Timeout *int `json:"Timeout,omitempty"`
+
+	// Signal is the signal sent to containers being killed, SIGKILL if empty.
+	Signal string `json:"Signal,omitempty"`
+
+	// Force and RemoveVolumes are the force and v options of container
+	// removal.
+	Force         bool `json:"Force,omitempty"`
+	RemoveVolumes bool `json:"RemoveVolumes,omitempty"`
+}
+
+// ContainerBatchResult is the outcome of a batch action for one container.
+type ContainerBatchResult struct {
+	ID string `json:"Id"`
+
+	// StatusCode is the status the single container endpoint would have
+	// answered with.
+	StatusCode int `json:"StatusCode"`
+
+	Error string `json:"Error,omitempty"`
+}
+
+// GET /system/df
+type DiskUsage struct {
+	// LayersSize is the size of all the image layers on disk.
+	LayersSize int64 `json:"LayersSize"`
+
+	Images     []ImageDiskUsage     `json:"Images"`
+	Containers []ContainerDiskUsage `json:"Containers"`
+	Volumes    []VolumeDiskUsage    `json:"Volumes"`
+}
+
+// ImageDiskUsage is the disk usage of an image, as listed by GET /images/json.
+type ImageDiskUsage struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	Created     int      `json:"Created"`
+	VirtualSize int64    `json:"VirtualSize"`
+
+	// SharedSize is the size of the layers of the image that other images
+	// are built on as well, and UniqueSize the size of those that only this
+	// image uses, which removing it would free.
+	SharedSize int64 `json:"SharedSize"`
+	UniqueSize int64 `json:"UniqueSize"`
+
+	// Containers is the number of containers created from the image.
+	Containers int `json:"Containers"`
+}
+
+// ContainerDiskUsage is the disk usage of the writable layer of a container.
+type ContainerDiskUsage struct {
+	ID         string   `json:"Id"`
+	Names      []string `json:"Names"`
+	Image      string   `json:"Image"`
+	Status     string   `json:"Status"`
+	Running    bool     `json:"Running"`
+	SizeRw     int64    `json:"SizeRw"`
+	SizeRootFs int64    `json:"SizeRootFs"`
+
+	// SizeLimit is the limit of the size of the root filesystem of the
+	// container, 0 if it has none.
+	SizeLimit int64 `json:"SizeLimit"`
+}
+
+// VolumeDiskUsage is the disk usage of a volume managed by the daemon.
+type VolumeDiskUsage struct {
+	ID   string `json:"Id"`
+	Path string `json:"Path"`
+	Size int64  `json:"Size"`
+
+	// Containers is the number of containers using the volume.
+	Containers int `json:"Containers"`
+}
+
+// POST /system/prune
+type SystemPruneReport struct {
+	ContainersDeleted []string      `json:"ContainersDeleted"`
+	ImagesDeleted     []ImageDelete `json:"ImagesDeleted"`
+	VolumesDeleted    []string      `json:"VolumesDeleted"`
+
+	// SpaceReclaimed is the disk space freed, in bytes.
+	SpaceReclaimed int64 `json:"SpaceReclaimed"`
+}
+
+// POST /system/bench
+type StorageBenchmark struct {
+	Driver            string `json:"Driver"`
+	BackingFilesystem string `json:"BackingFilesystem"`
+
+	// Layers is the depth of the chain of layers measured, each of which
+	// was written Files files of FileSize bytes.
+	Layers   int   `json:"Layers"`
+	Files    int   `json:"Files"`
+	FileSize int64 `json:"FileSize"`
+
+	Results []StorageBenchmarkResult `json:"Results"`
+}
+
+// StorageBenchmarkResult is the time an operation of the storage driver
+// took, in nanoseconds, over Count runs.
+type StorageBenchmarkResult struct {
+	Operation string `json:"Operation"`
+	Count     int    `json:"Count"`
+	Min       int64  `json:"Min"`
+	Average   int64  `json:"Average"`
+	Max       int64  `json:"Max"`
+	// Bytes is the amount of data the operation wrote or read per run, if
+	// it moves data.
+	Bytes int64 `json:"Bytes,omitempty"`
+}
+
+// GET /networks/(id)
+type NetworkResource struct {
+	Name       string                      `json:"Name"`
+	ID         string                      `json:"Id"`
+	Driver     string                      `json:"Driver"`
+	Internal   bool                        `json:"Internal"`
+	IPAM       IPAM                        `json:"IPAM"`
+	Containers map[string]EndpointResource `json:"Containers"`
+	Options    map[string]string           `json:"Options"`
+}
+
+// IPAM is the IP address management of a network: the subnets of its
+// containers.
+type IPAM struct {
+	Driver string       `json:"Driver"`
+	Config []IPAMConfig `json:"Config"`
+}
+
+type IPAMConfig struct {
+	Subnet string `json:"Subnet,omitempty"`
+	// IPRange is the part of Subnet the addresses of the containers are
+	// allocated from, when they aren't given the whole subnet.
+	IPRange string `json:"IPRange,omitempty"`
+	Gateway string `json:"Gateway,omitempty"`
+}
+
+// EndpointResource is the interface of a container on a network, by the ID
+// of the container.
+type EndpointResource struct {
+	Name        string `json:"Name"`
+	MacAddress  string `json:"MacAddress"`
+	IPv4Address string `json:"IPv4Address"`
+	IPv6Address string `json:"IPv6Address"`
+	// Host is the address of the host of a container of another host of
+	// an overlay network.
+	Host string `json:"Host,omitempty"`
+}
+
+// POST /networks/create
+type NetworkCreate struct {
+	Name   string `json:"Name"`
+	Driver string `json:"Driver"`
+	// Internal is whether the containers of the network are cut off from
+	// the outside world.
+	Internal bool              `json:"Internal"`
+	IPAM     IPAM              `json:"IPAM"`
+	Options  map[string]string `json:"Options"`
+}
+
+// POST /networks/create
+type NetworkCreateResponse struct {
+	ID      string `json:"Id"`
+	Warning string `json:"Warning"`
+}
+
+// POST /networks/(id)/connect
+type NetworkConnect struct {
+	Container      string          `json:"Container"`
+	EndpointConfig *EndpointConfig `json:"EndpointConfig,omitempty"`
+}
+
+// EndpointConfig is the configuration of the interface of a container on a
+// network.
+type EndpointConfig struct {
+	IPAMConfig *EndpointIPAMConfig `json:"IPAMConfig,omitempty"`
+	Aliases    []string            `json:"Aliases,omitempty"`
+}
+
+// EndpointIPAMConfig are the addresses a container asks for on a network.
+type EndpointIPAMConfig struct {
+	IPv4Address string `json:"IPv4Address,omitempty"`
+	IPv6Address string `json:"IPv6Address,omitempty"`
+}
+
+// POST /networks/(id)/disconnect
+type NetworkDisconnect struct {
+	Container string `json:"Container"`
+}
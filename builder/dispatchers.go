@@ -18,6 +18,7 @@ import (
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/nat"
 	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/runconfig"
 )
 
@@ -439,6 +440,67 @@ func volume(b *Builder, args []string, attributes map[string]bool, original stri
 	return nil
 }
 
+// HEALTHCHECK --interval=5s --timeout=3s --retries=3 CMD curl -f http://localhost/
+// HEALTHCHECK NONE
+//
+// Set the command run in the container to check that it is healthy, or
+// disable the health check of the base image. The command is handled like the
+// one of RUN.
+//
+func healthcheck(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) == 1 && args[0] == "NONE" {
+		b.Config.Healthcheck = &runconfig.HealthConfig{Test: []string{"NONE"}}
+		return b.commit("", b.Config.Cmd, "HEALTHCHECK NONE")
+	}
+
+	healthCmd := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	healthCmd.SetOutput(ioutil.Discard)
+	healthCmd.Usage = nil
+	interval := healthCmd.Duration([]string{"-interval"}, 0, "")
+	timeout := healthCmd.Duration([]string{"-timeout"}, 0, "")
+	retries := healthCmd.Int([]string{"-retries"}, 0, "")
+	if err := healthCmd.Parse(args); err != nil {
+		return fmt.Errorf("HEALTHCHECK: %v", err)
+	}
+	if *interval < 0 || *timeout < 0 || *retries < 0 {
+		return fmt.Errorf("HEALTHCHECK options can't be negative")
+	}
+
+	// The parser checked that the options are followed by CMD and the command.
+	test := handleJsonArgs(healthCmd.Args()[1:], attributes)
+	if attributes["json"] {
+		test = append([]string{"CMD"}, test...)
+	} else {
+		test = append([]string{"CMD-SHELL"}, test...)
+	}
+
+	b.Config.Healthcheck = &runconfig.HealthConfig{
+		Test:     test,
+		Interval: *interval,
+		Timeout:  *timeout,
+		Retries:  *retries,
+	}
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("HEALTHCHECK %q", test))
+}
+
+// STOPSIGNAL signal
+//
+// Set the signal sent to the container to stop it, by name or number.
+//
+func stopSignal(b *Builder, args []string, attributes map[string]bool, original string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("STOPSIGNAL requires exactly one argument")
+	}
+
+	sig := args[0]
+	if _, err := signal.ParseSignal(sig); err != nil {
+		return err
+	}
+
+	b.Config.StopSignal = sig
+	return b.commit("", b.Config.Cmd, fmt.Sprintf("STOPSIGNAL %v", args))
+}
+
 // INSERT is no longer accepted, but we still parse it.
 func insert(b *Builder, args []string, attributes map[string]bool, original string) error {
 	return fmt.Errorf("INSERT has been deprecated. Please use ADD instead")
@@ -293,3 +293,47 @@ func parseMaybeJSONToList(rest string) (*Node, map[string]bool, error) {
 
 	return parseStringsWhitespaceDelimited(rest)
 }
+
+// parseHealthConfig parses the arguments of HEALTHCHECK: NONE, or options
+// followed by CMD and a command given like the one of RUN.
+//
+// HEALTHCHECK --interval=5s CMD curl -f http://localhost/ -> (healthcheck "--interval=5s" "CMD" "curl -f http://localhost/")
+func parseHealthConfig(rest string) (*Node, map[string]bool, error) {
+	var top, prev *Node
+	add := func(node *Node) {
+		if prev == nil {
+			top = node
+		} else {
+			prev.Next = node
+		}
+		for prev = node; prev.Next != nil; prev = prev.Next {
+		}
+	}
+
+	rest = strings.TrimSpace(rest)
+	for strings.HasPrefix(rest, "--") {
+		parts := TOKEN_WHITESPACE.Split(rest, 2)
+		add(&Node{Value: parts[0]})
+		rest = ""
+		if len(parts) == 2 {
+			rest = parts[1]
+		}
+	}
+
+	parts := TOKEN_WHITESPACE.Split(rest, 2)
+	keyword := strings.ToUpper(parts[0])
+	switch {
+	case keyword == "NONE" && len(parts) == 1:
+		add(&Node{Value: keyword})
+		return top, nil, nil
+	case keyword == "CMD" && len(parts) == 2:
+		add(&Node{Value: keyword})
+		cmd, attrs, err := parseMaybeJSON(parts[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		add(cmd)
+		return top, attrs, nil
+	}
+	return nil, nil, fmt.Errorf("HEALTHCHECK requires either NONE or CMD and a command")
+}
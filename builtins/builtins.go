@@ -34,7 +34,10 @@ func remote(eng *engine.Engine) error {
 	if err := eng.Register("serveapi", apiserver.ServeApi); err != nil {
 		return err
 	}
-	return eng.Register("acceptconnections", apiserver.AcceptConnections)
+	if err := eng.Register("acceptconnections", apiserver.AcceptConnections); err != nil {
+		return err
+	}
+	return eng.Register("drainconnections", apiserver.DrainConnections)
 }
 
 // daemon: a default execution and storage backend for Docker on Linux,
@@ -61,6 +64,7 @@ func dockerVersion(job *engine.Job) error {
 	v := &engine.Env{}
 	v.SetJson("Version", dockerversion.VERSION)
 	v.SetJson("ApiVersion", api.APIVERSION)
+	v.SetJson("MinAPIVersion", api.APIMINVERSION)
 	v.SetJson("GitCommit", dockerversion.GITCOMMIT)
 	v.Set("GoVersion", runtime.Version())
 	v.Set("Os", runtime.GOOS)
@@ -13,7 +13,10 @@ import (
 	"github.com/docker/docker/utils"
 )
 
-func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer, logs, stream bool) error {
+// AttachWithLogs attaches the streams to the container, after copying its
+// logs to stdout and stderr if logs is set. Reading detachKeys from stdin
+// detaches it, ctrl-p ctrl-q if they're empty.
+func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer, logs, stream bool, detachKeys []byte) error {
 	if logs {
 		cLog, err := c.ReadLog("json")
 		if err != nil && os.IsNotExist(err) {
@@ -68,7 +71,7 @@ func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer
 			io.Copy(w, stdin)
 		}()
 		stdinPipe = r
-		<-c.Attach(stdinPipe, stdout, stderr)
+		<-c.Attach(stdinPipe, stdout, stderr, detachKeys)
 		// If we are in stdinonce mode, wait for the process to end
 		// otherwise, simply return
 		if c.Config.StdinOnce && !c.Config.Tty {
@@ -78,11 +81,11 @@ func (c *Container) AttachWithLogs(stdin io.ReadCloser, stdout, stderr io.Writer
 	return nil
 }
 
-func (c *Container) Attach(stdin io.ReadCloser, stdout io.Writer, stderr io.Writer) chan error {
-	return attach(&c.StreamConfig, c.Config.OpenStdin, c.Config.StdinOnce, c.Config.Tty, stdin, stdout, stderr)
+func (c *Container) Attach(stdin io.ReadCloser, stdout io.Writer, stderr io.Writer, detachKeys []byte) chan error {
+	return attach(&c.StreamConfig, c.Config.OpenStdin, c.Config.StdinOnce, c.Config.Tty, stdin, stdout, stderr, detachKeys)
 }
 
-func attach(streamConfig *StreamConfig, openStdin, stdinOnce, tty bool, stdin io.ReadCloser, stdout io.Writer, stderr io.Writer) chan error {
+func attach(streamConfig *StreamConfig, openStdin, stdinOnce, tty bool, stdin io.ReadCloser, stdout io.Writer, stderr io.Writer, detachKeys []byte) chan error {
 	var (
 		cStdout, cStderr io.ReadCloser
 		cStdin           io.WriteCloser
@@ -129,7 +132,7 @@ func attach(streamConfig *StreamConfig, openStdin, stdinOnce, tty bool, stdin io
 
 		var err error
 		if tty {
-			_, err = utils.CopyEscapable(cStdin, stdin)
+			_, err = utils.CopyEscapable(cStdin, stdin, detachKeys)
 		} else {
 			_, err = io.Copy(cStdin, stdin)
 
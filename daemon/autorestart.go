@@ -0,0 +1,171 @@
+package daemon
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// defaultRestartConcurrency is the number of containers the daemon starts at
+// a time when it restarts them.
+const defaultRestartConcurrency = 10
+
+// shouldAutoRestart returns whether the restart policy of container makes the
+// daemon start it when it starts: "always", "unless-stopped" if the user
+// didn't stop it, and "on-failure" if it failed.
+func (container *Container) shouldAutoRestart() bool {
+	switch container.hostConfig.RestartPolicy.Name {
+	case "always":
+		return true
+	case "unless-stopped":
+		return !container.HasBeenManuallyStopped
+	case "on-failure":
+		return container.ExitCode != 0
+	}
+	return false
+}
+
+// dependencies returns the IDs of the containers that container needs to be
+// running to start: the containers it links to, uses the volumes of, or
+// joins the network or IPC namespace of.
+func (container *Container) dependencies() []string {
+	var (
+		d    = container.daemon
+		deps []string
+	)
+	add := func(name string) {
+		if c, err := d.Get(name); err == nil {
+			deps = append(deps, c.ID)
+		}
+	}
+
+	if children, err := d.Children(container.Name); err == nil {
+		for _, child := range children {
+			deps = append(deps, child.ID)
+		}
+	}
+	for _, spec := range container.hostConfig.VolumesFrom {
+		if id, _, err := parseVolumesFromSpec(spec); err == nil {
+			add(id)
+		}
+	}
+	if mode := container.hostConfig.NetworkMode; mode.IsContainer() {
+		add(strings.SplitN(string(mode), ":", 2)[1])
+	}
+	if mode := container.hostConfig.IpcMode; mode.IsContainer() {
+		add(mode.Container())
+	}
+	if mode := container.hostConfig.PidMode; mode.IsContainer() {
+		add(mode.Container())
+	}
+	return deps
+}
+
+// autoRestart starts the containers whose restart policy requires it,
+// --restart-concurrency at a time, each after the containers it depends on.
+// A "restart_containers: started/total" event from "daemon" reports the
+// progress.
+func (daemon *Daemon) autoRestart(containers []*Container) {
+	var (
+		ids        []string
+		toStart    = make(map[string]*Container)
+		deps       = make(map[string][]string)
+		startTime  = time.Now()
+		progressMu sync.Mutex
+		done       int
+	)
+	for _, container := range containers {
+		if container.IsRunning() || !container.shouldAutoRestart() {
+			continue
+		}
+		ids = append(ids, container.ID)
+		toStart[container.ID] = container
+	}
+	if len(ids) == 0 {
+		return
+	}
+	for _, id := range ids {
+		deps[id] = toStart[id].dependencies()
+	}
+
+	logrus.Debugf("Restarting %d containers...", len(ids))
+	startInOrder(ids, deps, daemon.config.RestartConcurrency, func(id string) {
+		container := toStart[id]
+		logrus.Debugf("Starting container %s", container.ID)
+		if err := container.Start(); err != nil {
+			logrus.Debugf("Failed to start container %s: %s", container.ID, err)
+		}
+
+		progressMu.Lock()
+		done++
+		status := fmt.Sprintf("restart_containers: %d/%d", done, len(ids))
+		progressMu.Unlock()
+		if err := daemon.eng.Job("log", status, "daemon", "").Run(); err != nil {
+			logrus.Errorf("Error logging event %s: %s", status, err)
+		}
+	})
+	logrus.Infof("Restarted %d containers in %s", len(ids), time.Since(startTime))
+}
+
+// startInOrder calls start for each of ids, at most concurrency at a time,
+// once the calls for the dependencies of the ID in deps returned. Dependencies
+// that aren't in ids, or that form a cycle, are ignored.
+func startInOrder(ids []string, deps map[string][]string, concurrency int, start func(id string)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	started := make(map[string]chan struct{}, len(ids))
+	for _, id := range ids {
+		started[id] = make(chan struct{})
+	}
+
+	// Keep the dependencies that don't close a cycle: a depth-first walk
+	// drops those leading back to a container being visited.
+	var (
+		waitFor  = make(map[string][]chan struct{}, len(ids))
+		visiting = make(map[string]bool)
+		visited  = make(map[string]bool)
+		visit    func(id string)
+	)
+	visit = func(id string) {
+		visiting[id] = true
+		for _, dep := range deps[id] {
+			if _, ok := started[dep]; !ok || dep == id || visiting[dep] {
+				continue
+			}
+			if !visited[dep] {
+				visit(dep)
+			}
+			waitFor[id] = append(waitFor[id], started[dep])
+		}
+		visiting[id] = false
+		visited[id] = true
+	}
+	for _, id := range ids {
+		if !visited[id] {
+			visit(id)
+		}
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			defer close(started[id])
+			for _, c := range waitFor[id] {
+				<-c
+			}
+			sem <- struct{}{}
+			start(id)
+			<-sem
+		}(id)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartInOrderDependencies(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	ids := []string{"web", "db", "cache", "data"}
+	deps := map[string][]string{
+		"web":   {"db", "cache"},
+		"db":    {"data", "unknown"},
+		"cache": {},
+	}
+	startInOrder(ids, deps, 4, func(id string) {
+		// give the containers without dependencies a head start
+		if id == "db" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		mu.Lock()
+		order = append(order, id)
+		mu.Unlock()
+	})
+
+	if len(order) != len(ids) {
+		t.Fatalf("Expected %d containers to be started, got %v", len(ids), order)
+	}
+	index := make(map[string]int)
+	for i, id := range order {
+		index[id] = i
+	}
+	for id, ds := range deps {
+		for _, dep := range ds {
+			if i, ok := index[dep]; ok && i > index[id] {
+				t.Fatalf("Expected %s to be started before %s, got %v", dep, id, order)
+			}
+		}
+	}
+}
+
+func TestStartInOrderConcurrency(t *testing.T) {
+	var (
+		mu              sync.Mutex
+		running, maxRun int
+	)
+	ids := []string{"a", "b", "c", "d", "e", "f", "g"}
+	startInOrder(ids, nil, 3, func(id string) {
+		mu.Lock()
+		running++
+		if running > maxRun {
+			maxRun = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+	if maxRun != 3 {
+		t.Fatalf("Expected 3 containers to be started at a time, got %d", maxRun)
+	}
+}
+
+func TestStartInOrderCycle(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		started int
+	)
+	deps := map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+	}
+	done := make(chan struct{})
+	go func() {
+		startInOrder([]string{"a", "b", "c"}, deps, 1, func(id string) {
+			mu.Lock()
+			started++
+			mu.Unlock()
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Dependencies forming a cycle blocked the start of the containers")
+	}
+	if started != 3 {
+		t.Fatalf("Expected 3 containers to be started, got %d", started)
+	}
+}
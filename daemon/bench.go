@@ -0,0 +1,281 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/units"
+)
+
+const (
+	defaultBenchLayers   = 10
+	defaultBenchFiles    = 100
+	defaultBenchFileSize = 64 * 1024
+
+	// maxBenchLayers keeps the chain of layers within the depth of images
+	// every driver supports.
+	maxBenchLayers = 100
+	// maxBenchFiles and maxBenchFileSize bound what a benchmark writes to
+	// each layer, and maxBenchBytes the space it takes, to 10GB.
+	maxBenchFiles    = 10000
+	maxBenchFileSize = 1024 * 1024 * 1024
+	maxBenchBytes    = 10 * maxBenchFileSize
+
+	// benchBufferSize is the size of the buffer the files are written from.
+	benchBufferSize = 32 * 1024
+)
+
+// benchTimer collects the durations of the runs of an operation.
+type benchTimer struct {
+	result types.StorageBenchmarkResult
+	total  time.Duration
+}
+
+// time runs fn, and records how long it took unless it fails.
+func (t *benchTimer) time(fn func() error) error {
+	start := time.Now()
+	if err := fn(); err != nil {
+		return fmt.Errorf("%s: %v", t.result.Operation, err)
+	}
+	d := time.Since(start)
+	if t.result.Count == 0 || int64(d) < t.result.Min {
+		t.result.Min = int64(d)
+	}
+	if int64(d) > t.result.Max {
+		t.result.Max = int64(d)
+	}
+	t.total += d
+	t.result.Count++
+	t.result.Average = int64(t.total) / int64(t.result.Count)
+	return nil
+}
+
+// SystemBench measures how long the operations of the storage driver take
+// on a chain of layers of its own, created on the backing filesystem of the
+// daemon and removed afterwards, much like the ones of a build: each layer
+// is created on top of the previous one, mounted, written files, unmounted,
+// diffed against its parent and committed as the layer of an image would
+// be, by applying its diff to a new layer.
+func (daemon *Daemon) SystemBench(job *engine.Job) error {
+	bench := &types.StorageBenchmark{
+		Driver:   daemon.driver.String(),
+		Layers:   defaultBenchLayers,
+		Files:    defaultBenchFiles,
+		FileSize: defaultBenchFileSize,
+	}
+	for _, kv := range daemon.driver.Status() {
+		if kv[0] == "Backing Filesystem" {
+			bench.BackingFilesystem = kv[1]
+		}
+	}
+
+	if err := setBenchOptions(bench, job.Getenv("layers"), job.Getenv("files"), job.Getenv("filesize")); err != nil {
+		return err
+	}
+	free, err := freeSpace(daemon.config.Root)
+	if err != nil {
+		return err
+	}
+	if size := benchSize(bench); size > free {
+		return fmt.Errorf("The benchmark takes up to %s, more than the %s free in %s", units.BytesSize(float64(size)), units.BytesSize(float64(free)), daemon.config.Root)
+	}
+
+	var (
+		create  = &benchTimer{result: types.StorageBenchmarkResult{Operation: "create"}}
+		mount   = &benchTimer{result: types.StorageBenchmarkResult{Operation: "mount"}}
+		write   = &benchTimer{result: types.StorageBenchmarkResult{Operation: "write", Bytes: int64(bench.Files) * bench.FileSize}}
+		unmount = &benchTimer{result: types.StorageBenchmarkResult{Operation: "unmount"}}
+		diff    = &benchTimer{result: types.StorageBenchmarkResult{Operation: "diff"}}
+		commit  = &benchTimer{result: types.StorageBenchmarkResult{Operation: "commit"}}
+		remove  = &benchTimer{result: types.StorageBenchmarkResult{Operation: "remove"}}
+		layers  []string
+	)
+	defer func() {
+		// Leave nothing behind, even when a run fails
+		for i := len(layers) - 1; i >= 0; i-- {
+			id := layers[i]
+			if err := remove.time(func() error { return daemon.driver.Remove(id) }); err != nil {
+				logrus.Warnf("Error removing the benchmark layer %s: %v", id, err)
+			}
+		}
+	}()
+
+	tmp, err := ioutil.TempDir(daemon.config.Root, "bench")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	buf := make([]byte, benchBufferSize)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+
+	parent := ""
+	for i := 0; i < bench.Layers; i++ {
+		id := stringid.GenerateRandomID()
+		if err := create.time(func() error { return daemon.driver.Create(id, parent) }); err != nil {
+			return err
+		}
+		layers = append(layers, id)
+
+		var dir string
+		if err := mount.time(func() (err error) {
+			dir, err = daemon.driver.Get(id, "")
+			return err
+		}); err != nil {
+			return err
+		}
+		err := write.time(func() error { return benchWriteFiles(dir, i, bench.Files, bench.FileSize, buf) })
+		if err2 := unmount.time(func() error { return daemon.driver.Put(id) }); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+
+		// The diff is buffered in a file, as the ones of docker commit are
+		// sent to the graph, for its size to be known before it's applied.
+		archive, err := ioutil.TempFile(tmp, "diff")
+		if err != nil {
+			return err
+		}
+		if err := diff.time(func() error {
+			layer, err := daemon.driver.Diff(id, parent)
+			if err != nil {
+				return err
+			}
+			defer layer.Close()
+			_, err = io.Copy(archive, layer)
+			return err
+		}); err != nil {
+			archive.Close()
+			return err
+		}
+
+		commitID := stringid.GenerateRandomID()
+		err = commit.time(func() error {
+			if _, err := archive.Seek(0, 0); err != nil {
+				return err
+			}
+			if err := daemon.driver.Create(commitID, parent); err != nil {
+				return err
+			}
+			layers = append(layers, commitID)
+			_, err := daemon.driver.ApplyDiff(commitID, parent, archive)
+			return err
+		})
+		if fi, err := archive.Stat(); err == nil {
+			diff.result.Bytes, commit.result.Bytes = fi.Size(), fi.Size()
+		}
+		archive.Close()
+		os.Remove(archive.Name())
+		if err != nil {
+			return err
+		}
+
+		// The next layer is created on top of the one committed, as the
+		// steps of a build are
+		parent = commitID
+	}
+
+	// Remove the layers before reporting, for their removal to be measured
+	for i := len(layers) - 1; i >= 0; i-- {
+		id := layers[i]
+		layers = layers[:i]
+		if err := remove.time(func() error { return daemon.driver.Remove(id) }); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range []*benchTimer{create, mount, write, unmount, diff, commit, remove} {
+		bench.Results = append(bench.Results, t.result)
+	}
+	return json.NewEncoder(job.Stdout).Encode(bench)
+}
+
+// setBenchOptions sets the number of layers, of files per layer and the size
+// of the files of bench from their values in the request, unless empty.
+func setBenchOptions(bench *types.StorageBenchmark, layers, files, fileSize string) error {
+	var err error
+	if layers != "" {
+		if bench.Layers, err = strconv.Atoi(layers); err != nil || bench.Layers < 1 || bench.Layers > maxBenchLayers {
+			return fmt.Errorf("Invalid number of layers %q, must be between 1 and %d", layers, maxBenchLayers)
+		}
+	}
+	if files != "" {
+		if bench.Files, err = strconv.Atoi(files); err != nil || bench.Files < 0 || bench.Files > maxBenchFiles {
+			return fmt.Errorf("Invalid number of files %q, must be between 0 and %d", files, maxBenchFiles)
+		}
+	}
+	if fileSize != "" {
+		if bench.FileSize, err = units.RAMInBytes(fileSize); err != nil || bench.FileSize < 0 || bench.FileSize > maxBenchFileSize {
+			return fmt.Errorf("Invalid file size %q, must be between 0 and %s", fileSize, units.BytesSize(maxBenchFileSize))
+		}
+	}
+	if size := benchSize(bench); size > maxBenchBytes {
+		return fmt.Errorf("Invalid benchmark, %d layers of %d files of %s take %s, more than the %s at most", bench.Layers, bench.Files, units.BytesSize(float64(bench.FileSize)), units.BytesSize(float64(size)), units.BytesSize(maxBenchBytes))
+	}
+	return nil
+}
+
+// benchSize returns the space bench takes at most: the files of every
+// layer twice, as each layer is committed to another one, and the diff of
+// the layer being committed.
+func benchSize(bench *types.StorageBenchmark) int64 {
+	return (2*int64(bench.Layers) + 1) * int64(bench.Files) * bench.FileSize
+}
+
+// freeSpace returns the space of the filesystem of path available to
+// unprivileged users.
+func freeSpace(path string) (int64, error) {
+	var buf syscall.Statfs_t
+	if err := syscall.Statfs(path, &buf); err != nil {
+		return 0, err
+	}
+	return int64(buf.Bavail) * int64(buf.Bsize), nil
+}
+
+// benchWriteFiles writes count files of size bytes, repeating buf, to the
+// directory of the layer mounted at dir, and syncs them to the backing
+// filesystem.
+func benchWriteFiles(dir string, layer, count int, size int64, buf []byte) error {
+	dir = path.Join(dir, "bench", strconv.Itoa(layer))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		f, err := os.Create(path.Join(dir, strconv.Itoa(i)))
+		if err != nil {
+			return err
+		}
+		for left := size; left > 0 && err == nil; left -= int64(len(buf)) {
+			p := buf
+			if left < int64(len(p)) {
+				p = p[:left]
+			}
+			_, err = f.Write(p)
+		}
+		if err == nil {
+			err = f.Sync()
+		}
+		if err2 := f.Close(); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestSetBenchOptions(t *testing.T) {
+	tests := []struct {
+		layers, files, fileSize string
+		valid                   bool
+		expected                types.StorageBenchmark
+	}{
+		{"", "", "", true, types.StorageBenchmark{Layers: defaultBenchLayers, Files: defaultBenchFiles, FileSize: defaultBenchFileSize}},
+		{"5", "10", "1m", true, types.StorageBenchmark{Layers: 5, Files: 10, FileSize: 1024 * 1024}},
+		{"100", "0", "1g", true, types.StorageBenchmark{Layers: 100, Files: 0, FileSize: 1024 * 1024 * 1024}},
+		{"2", "2", "1g", true, types.StorageBenchmark{Layers: 2, Files: 2, FileSize: 1024 * 1024 * 1024}},
+		{"0", "", "", false, types.StorageBenchmark{}},
+		{"101", "", "", false, types.StorageBenchmark{}},
+		{"", "-1", "", false, types.StorageBenchmark{}},
+		{"", "10001", "", false, types.StorageBenchmark{}},
+		{"", "", "2g", false, types.StorageBenchmark{}},
+		{"", "", "100000000g", false, types.StorageBenchmark{}},
+		{"", "", "lots", false, types.StorageBenchmark{}},
+		{"", "11", "1g", false, types.StorageBenchmark{}},
+		{"10", "10", "1g", false, types.StorageBenchmark{}},
+		{"100", "100", "1m", false, types.StorageBenchmark{}},
+	}
+	for _, test := range tests {
+		bench := &types.StorageBenchmark{Layers: defaultBenchLayers, Files: defaultBenchFiles, FileSize: defaultBenchFileSize}
+		err := setBenchOptions(bench, test.layers, test.files, test.fileSize)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("Expected %q layers of %q files of %q to be invalid", test.layers, test.files, test.fileSize)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %q layers of %q files of %q to be valid, got %v", test.layers, test.files, test.fileSize, err)
+			continue
+		}
+		if bench.Layers != test.expected.Layers || bench.Files != test.expected.Files || bench.FileSize != test.expected.FileSize {
+			t.Errorf("Expected %+v, got %+v", test.expected, bench)
+		}
+	}
+}
+
+func TestBenchWriteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-bench-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The files are larger than the buffer, and not a multiple of its size
+	buf := []byte("0123456789")
+	if err := benchWriteFiles(dir, 3, 2, 25, buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"0", "1"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "bench", "3", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "0123456789012345678901234" {
+			t.Fatalf("Unexpected content of file %s: %q", name, data)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	"net"
+	"time"
 
 	"github.com/docker/docker/daemon/networkdriver"
 	"github.com/docker/docker/opts"
@@ -23,10 +24,17 @@ type Config struct {
 	Pidfile                     string
 	Root                        string
 	AutoRestart                 bool
+	LiveRestore                 bool
+	RestartConcurrency          int
 	Dns                         []string
 	DnsSearch                   []string
+	DnsOptions                  []string
 	EnableIPv6                  bool
 	EnableIptables              bool
+	IptablesChainPrefix         string
+	PublishedPortRange          string
+	MacAddressPrefix            string
+	MacAddressMode              string
 	EnableIpForward             bool
 	EnableIpMasq                bool
 	DefaultIp                   net.IP
@@ -37,12 +45,31 @@ type Config struct {
 	InterContainerCommunication bool
 	GraphDriver                 string
 	GraphOptions                []string
+	GraphMigrateFrom            string
+	MigrateImageIDs             bool
+	GCHighWatermark             int
+	GCLowWatermark              int
+	GCExcludeLabels             []string
+	GCGracePeriod               time.Duration
 	ExecDriver                  string
+	Runtimes                    map[string]string
+	DefaultRuntime              string
+	Init                        bool
+	CgroupParent                string
+	PidsLimit                   int64
 	Mtu                         int
 	SocketGroup                 string
 	EnableCors                  bool
 	CorsHeaders                 string
+	CompressionThreshold        int
+	AuthzPlugins                []string
+	AuditLog                    string
+	DrainTimeout                int
+	MaxBuildContext             string
+	MaxJSONBody                 string
 	DisableNetwork              bool
+	ClusterStore                string
+	ClusterAdvertise            string
 	EnableSelinuxSupport        bool
 	Context                     map[string][]string
 	TrustKeyPath                string
@@ -59,7 +86,13 @@ func (config *Config) InstallFlags() {
 	flag.StringVar(&config.Pidfile, []string{"p", "-pidfile"}, "/var/run/docker.pid", "Path to use for daemon PID file")
 	flag.StringVar(&config.Root, []string{"g", "-graph"}, "/var/lib/docker", "Root of the Docker runtime")
 	flag.BoolVar(&config.AutoRestart, []string{"#r", "#-restart"}, true, "--restart on the daemon has been deprecated in favor of --restart policies on docker run")
+	flag.IntVar(&config.RestartConcurrency, []string{"-restart-concurrency"}, defaultRestartConcurrency, "Number of containers started at a time when the daemon restarts them")
+	flag.BoolVar(&config.LiveRestore, []string{"-live-restore"}, false, "Keep containers running while the daemon is down")
 	flag.BoolVar(&config.EnableIptables, []string{"#iptables", "-iptables"}, true, "Enable addition of iptables rules")
+	flag.StringVar(&config.IptablesChainPrefix, []string{"-iptables-chain-prefix"}, "DOCKER", "Prefix of the names of the iptables chains of the daemon")
+	flag.StringVar(&config.PublishedPortRange, []string{"-published-port-range"}, "", "Range of the host ports of the ports published without one, as START-END")
+	flag.StringVar(&config.MacAddressPrefix, []string{"-mac-address-prefix"}, "02:42", "First 2 bytes of the MAC addresses of the containers")
+	flag.StringVar(&config.MacAddressMode, []string{"-mac-address-mode"}, "ip", "Derive the MAC addresses of the containers from their 'ip' or 'name'")
 	flag.BoolVar(&config.EnableIpForward, []string{"#ip-forward", "-ip-forward"}, true, "Enable net.ipv4.ip_forward")
 	flag.BoolVar(&config.EnableIpMasq, []string{"-ip-masq"}, true, "Enable IP masquerading")
 	flag.BoolVar(&config.EnableIPv6, []string{"-ipv6"}, false, "Enable IPv6 networking")
@@ -70,20 +103,43 @@ func (config *Config) InstallFlags() {
 	flag.BoolVar(&config.InterContainerCommunication, []string{"#icc", "-icc"}, true, "Enable inter-container communication")
 	flag.StringVar(&config.GraphDriver, []string{"s", "-storage-driver"}, "", "Storage driver to use")
 	flag.StringVar(&config.ExecDriver, []string{"e", "-exec-driver"}, "native", "Exec driver to use")
+	config.Runtimes = make(map[string]string)
+	opts.MapVar(config.Runtimes, []string{"-add-runtime"}, "Add an OCI runtime, as name=path, that containers can run with")
+	flag.StringVar(&config.DefaultRuntime, []string{"-default-runtime"}, "", "Runtime of the containers that don't choose one, the exec driver by default")
+	flag.StringVar(&config.CgroupParent, []string{"-cgroup-parent"}, "", "Parent cgroup of the containers that don't choose one")
+	flag.Int64Var(&config.PidsLimit, []string{"-pids-limit"}, 0, "Maximum number of processes of the containers that don't choose one")
+	flag.BoolVar(&config.Init, []string{"-init"}, false, "Run an init in the containers to forward signals and reap processes")
 	flag.BoolVar(&config.EnableSelinuxSupport, []string{"-selinux-enabled"}, false, "Enable selinux support")
 	flag.IntVar(&config.Mtu, []string{"#mtu", "-mtu"}, 0, "Set the containers network MTU")
 	flag.StringVar(&config.SocketGroup, []string{"G", "-group"}, "docker", "Group for the unix socket")
 	flag.BoolVar(&config.EnableCors, []string{"#api-enable-cors", "#-api-enable-cors"}, false, "Enable CORS headers in the remote API, this is deprecated by --api-cors-header")
 	flag.StringVar(&config.CorsHeaders, []string{"-api-cors-header"}, "", "Set CORS headers in the remote API")
+	flag.IntVar(&config.CompressionThreshold, []string{"-api-compression-threshold"}, 1024, "Gzip remote API JSON responses larger than this many bytes, 0 to disable")
+	opts.ListVar(&config.AuthzPlugins, []string{"-authz-plugin"}, "Authorization plugins to consult for every API request")
+	flag.StringVar(&config.AuditLog, []string{"-audit-log"}, "", "Record mutating API calls to this file, or to syslog if set to 'syslog'")
+	flag.IntVar(&config.DrainTimeout, []string{"-api-drain-timeout"}, 10, "Seconds to wait for in-flight API requests to complete when the daemon stops")
+	flag.StringVar(&config.MaxBuildContext, []string{"-api-max-build-context"}, "0", "Maximum size of a build context uploaded through the remote API, 0 for no limit")
+	flag.StringVar(&config.MaxJSONBody, []string{"-api-max-json-body"}, "10m", "Maximum size of a JSON request body sent to the remote API, 0 for no limit")
 	opts.IPVar(&config.DefaultIp, []string{"#ip", "-ip"}, "0.0.0.0", "Default IP when binding container ports")
+	flag.StringVar(&config.ClusterStore, []string{"-cluster-store"}, "", "Key-value store of the cluster, for the overlay networks")
+	flag.StringVar(&config.ClusterAdvertise, []string{"-cluster-advertise"}, "", "Address or interface the other daemons of the cluster reach this host at")
 	opts.ListVar(&config.GraphOptions, []string{"-storage-opt"}, "Set storage driver options")
+	flag.StringVar(&config.GraphMigrateFrom, []string{"-storage-migrate-from"}, "", "Storage driver to migrate the images and containers from")
+	flag.BoolVar(&config.MigrateImageIDs, []string{"-migrate-image-ids"}, false, "Migrate the tagged images to IDs derived from their content")
+	flag.IntVar(&config.GCHighWatermark, []string{"-gc-high-watermark"}, 0, "Percent of disk usage of the root at which to remove exited containers and dangling images, 0 to disable")
+	flag.IntVar(&config.GCLowWatermark, []string{"-gc-low-watermark"}, 0, "Percent of disk usage of the root to remove exited containers and dangling images down to")
+	opts.ListVar(&config.GCExcludeLabels, []string{"-gc-exclude-label"}, "Keep the containers and images with this label, as key or key=value, from garbage collection")
+	flag.DurationVar(&config.GCGracePeriod, []string{"-gc-grace-period"}, 0, "Time since they exited before the exited containers may be garbage collected")
 	// FIXME: why the inconsistency between "hosts" and "sockets"?
 	opts.IPListVar(&config.Dns, []string{"#dns", "-dns"}, "DNS server to use")
 	opts.DnsSearchListVar(&config.DnsSearch, []string{"-dns-search"}, "DNS search domains to use")
+	opts.DnsOptListVar(&config.DnsOptions, []string{"-dns-opt"}, "DNS options to use")
 	opts.LabelListVar(&config.Labels, []string{"-label"}, "Set key=value labels to the daemon")
 	config.Ulimits = make(map[string]*ulimit.Ulimit)
 	opts.UlimitMapVar(config.Ulimits, []string{"-default-ulimit"}, "Set default ulimits for containers")
 	flag.StringVar(&config.LogConfig.Type, []string{"-log-driver"}, "json-file", "Containers logging driver")
+	config.LogConfig.Config = make(map[string]string)
+	opts.MapVar(config.LogConfig.Config, []string{"-log-opt"}, "Default options of the containers logging driver")
 }
 
 func getDefaultNetworkMtu() int {
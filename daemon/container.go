@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -21,20 +24,24 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/daemon/logger"
 	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/daemon/logger/syslog"
+	"github.com/docker/docker/daemon/networkdriver/overlay"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/links"
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/broadcastwriter"
+	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/directory"
 	"github.com/docker/docker/pkg/etchosts"
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/resolvconf"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/symlink"
 	"github.com/docker/docker/pkg/ulimit"
@@ -44,6 +51,10 @@ import (
 
 const DefaultPathEnv = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
 
+// defaultStopTimeout is the number of seconds a container gets to stop
+// before it is killed, unless its config says otherwise.
+const defaultStopTimeout = 10
+
 var (
 	ErrNotATTY               = errors.New("The PTY is not a file")
 	ErrNoTTY                 = errors.New("No PTY found")
@@ -91,6 +102,7 @@ type Container struct {
 	AppArmorProfile          string
 	RestartCount             int
 	UpdateDns                bool
+	HasBeenManuallyStopped   bool // stopped by the user, not to be restarted with the daemon
 
 	// Maps container paths to volume paths.  The key in this is the path to which
 	// the volume is being mounted inside the container.  Value is the path of the
@@ -101,9 +113,11 @@ type Container struct {
 	VolumesRW  map[string]bool
 	hostConfig *runconfig.HostConfig
 
-	activeLinks  map[string]*links.Link
-	monitor      *containerMonitor
-	execCommands *execStore
+	activeLinks       map[string]*links.Link
+	monitor           *containerMonitor
+	execCommands      *execStore
+	healthStop        chan struct{} // closed to stop the health check
+	restartInProgress bool          // stopped by docker restart, to be started again
 	// logDriver for closing
 	logDriver          logger.Logger
 	logCopier          *logger.Copier
@@ -200,7 +214,11 @@ func (container *Container) WriteHostConfig() error {
 
 func (container *Container) LogEvent(action string) {
 	d := container.daemon
-	if err := d.eng.Job("log", action, container.ID, d.Repositories().ImageName(container.ImageID)).Run(); err != nil {
+	job := d.eng.Job("log", action, container.ID, d.Repositories().ImageName(container.ImageID))
+	if container.Config != nil && len(container.Config.Labels) > 0 {
+		job.SetenvJson("labels", container.Config.Labels)
+	}
+	if err := job.Run(); err != nil {
 		logrus.Errorf("Error logging event %s for %s: %s", action, container.ID, err)
 	}
 }
@@ -254,6 +272,23 @@ func getDevicesFromPath(deviceMapping runconfig.DeviceMapping) (devs []*configs.
 	return devs, fmt.Errorf("error gathering device information while adding custom device %q: %s", deviceMapping.PathOnHost, err)
 }
 
+// getThrottleDevices returns the block IO limits of throttleDevices, by
+// device number.
+func getThrottleDevices(throttleDevices []runconfig.ThrottleDevice) ([]execdriver.ThrottleDevice, error) {
+	var devs []execdriver.ThrottleDevice
+	for _, d := range throttleDevices {
+		device, err := devices.DeviceFromPath(d.Path, "")
+		if err != nil {
+			return nil, fmt.Errorf("error gathering device information while limiting the block IO of %q: %s", d.Path, err)
+		}
+		if device.Type != 'b' {
+			return nil, fmt.Errorf("Cannot limit the block IO of %q: it's not a block device", d.Path)
+		}
+		devs = append(devs, execdriver.ThrottleDevice{Major: device.Major, Minor: device.Minor, Rate: d.Rate})
+	}
+	return devs, nil
+}
+
 func populateCommand(c *Container, env []string) error {
 	en := &execdriver.Network{
 		Mtu:       c.daemon.config.Mtu,
@@ -265,7 +300,13 @@ func populateCommand(c *Container, env []string) error {
 	case "none":
 	case "host":
 		en.HostNetworking = true
-	case "bridge", "": // empty string to support existing containers
+	case "container":
+		nc, err := c.getNetworkedContainer()
+		if err != nil {
+			return err
+		}
+		en.ContainerID = nc.ID
+	default: // the default bridge network, or a user-defined one
 		if !c.Config.NetworkDisabled {
 			network := c.NetworkSettings
 			en.Interface = &execdriver.NetworkInterface{
@@ -279,15 +320,19 @@ func populateCommand(c *Container, env []string) error {
 				GlobalIPv6PrefixLen:  network.GlobalIPv6PrefixLen,
 				IPv6Gateway:          network.IPv6Gateway,
 			}
+			if n, err := c.daemon.networks.Get(c.hostConfig.NetworkMode.NetworkName()); err == nil && (n.Driver == "bridge" || n.onLan()) {
+				en.Interface.Mtu = c.interfaceMtu(n)
+				setParent(en.Interface, n)
+			}
+			for _, name := range c.connectedNetworks() {
+				endpoint := network.Networks[name]
+				n, err := c.daemon.networks.Get(endpoint.NetworkID)
+				if err != nil {
+					return err
+				}
+				en.Interfaces = append(en.Interfaces, c.networkInterface(n, endpoint))
+			}
 		}
-	case "container":
-		nc, err := c.getNetworkedContainer()
-		if err != nil {
-			return err
-		}
-		en.ContainerID = nc.ID
-	default:
-		return fmt.Errorf("invalid network mode: %s", c.hostConfig.NetworkMode)
 	}
 
 	ipc := &execdriver.Ipc{}
@@ -303,7 +348,20 @@ func populateCommand(c *Container, env []string) error {
 	}
 
 	pid := &execdriver.Pid{}
-	pid.HostPid = c.hostConfig.PidMode.IsHost()
+
+	if c.hostConfig.PidMode.IsContainer() {
+		pc, err := c.getPidContainer()
+		if err != nil {
+			return err
+		}
+		pid.ContainerID = pc.ID
+	} else {
+		pid.HostPid = c.hostConfig.PidMode.IsHost()
+	}
+
+	uts := &execdriver.UTS{
+		HostUTS: c.hostConfig.UTSMode.IsHost(),
+	}
 
 	// Build lists of devices allowed and created within the container.
 	var userSpecifiedDevices []*configs.Device
@@ -348,11 +406,29 @@ func populateCommand(c *Container, env []string) error {
 	}
 
 	resources := &execdriver.Resources{
-		Memory:     c.hostConfig.Memory,
-		MemorySwap: c.hostConfig.MemorySwap,
-		CpuShares:  c.hostConfig.CpuShares,
-		CpusetCpus: c.hostConfig.CpusetCpus,
-		Rlimits:    rlimits,
+		Memory:         c.hostConfig.Memory,
+		MemorySwap:     c.hostConfig.MemorySwap,
+		CpuShares:      c.hostConfig.CpuShares,
+		CpuPeriod:      c.hostConfig.CpuPeriod,
+		CpuQuota:       c.hostConfig.CpuQuota,
+		CpusetCpus:     c.hostConfig.CpusetCpus,
+		BlkioWeight:    c.hostConfig.BlkioWeight,
+		PidsLimit:      c.pidsLimit(),
+		OomKillDisable: c.hostConfig.OomKillDisable,
+		OomScoreAdj:    c.hostConfig.OomScoreAdj,
+		Rlimits:        rlimits,
+	}
+	if resources.DeviceReadBps, err = getThrottleDevices(c.hostConfig.DeviceReadBps); err != nil {
+		return err
+	}
+	if resources.DeviceWriteBps, err = getThrottleDevices(c.hostConfig.DeviceWriteBps); err != nil {
+		return err
+	}
+	if resources.DeviceReadIOps, err = getThrottleDevices(c.hostConfig.DeviceReadIOps); err != nil {
+		return err
+	}
+	if resources.DeviceWriteIOps, err = getThrottleDevices(c.hostConfig.DeviceWriteIOps); err != nil {
+		return err
 	}
 
 	processConfig := execdriver.ProcessConfig{
@@ -363,7 +439,19 @@ func populateCommand(c *Container, env []string) error {
 		User:       c.Config.User,
 	}
 
+	if c.runsInit() {
+		processConfig.Entrypoint = execdriver.InitPath
+		processConfig.Arguments = append([]string{c.Path}, c.Args...)
+	}
+
 	processConfig.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	// Containers that don't choose a parent cgroup get the one the daemon
+	// has when they start.
+	cgroupParent := c.hostConfig.CgroupParent
+	if cgroupParent == "" {
+		cgroupParent = c.daemon.config.CgroupParent
+	}
 	processConfig.Env = env
 
 	c.command = &execdriver.Command{
@@ -375,6 +463,7 @@ func populateCommand(c *Container, env []string) error {
 		Network:            en,
 		Ipc:                ipc,
 		Pid:                pid,
+		UTS:                uts,
 		Resources:          resources,
 		AllowedDevices:     allowedDevices,
 		AutoCreatedDevices: autoCreatedDevices,
@@ -385,7 +474,7 @@ func populateCommand(c *Container, env []string) error {
 		MountLabel:         c.GetMountLabel(),
 		LxcConfig:          lxcConfig,
 		AppArmorProfile:    c.AppArmorProfile,
-		CgroupParent:       c.hostConfig.CgroupParent,
+		CgroupParent:       cgroupParent,
 	}
 
 	return nil
@@ -402,6 +491,7 @@ func (container *Container) Start() (err error) {
 	if container.removalInProgress || container.Dead {
 		return fmt.Errorf("Container is marked for removal and cannot be started.")
 	}
+	container.HasBeenManuallyStopped = false
 
 	// if we encounter an error during start we need to ensure that any other
 	// setup has been cleaned up properly
@@ -545,6 +635,26 @@ func (container *Container) buildHostsFiles(IP string) error {
 		extraContent = append(extraContent, etchosts.Record{Hosts: aliasList, IP: child.NetworkSettings.IPAddress})
 	}
 
+	// The containers of the overlay networks, on every host
+	for _, name := range container.connectedNetworks() {
+		endpoint := container.NetworkSettings.Networks[name]
+		n, err := container.daemon.networks.Get(name)
+		if err != nil || n.Driver != "overlay" || endpoint == nil || endpoint.IPAddress == "" {
+			continue
+		}
+		peers, err := container.daemon.overlay.Endpoints(n.ID)
+		if err != nil {
+			logrus.Warnf("Unable to get the containers of network %s: %v", n.Name, err)
+			continue
+		}
+		for _, peer := range peers {
+			if peer.ContainerID != container.ID {
+				hosts := strings.Join(append([]string{peer.Name}, peer.Aliases...), " ")
+				extraContent = append(extraContent, etchosts.Record{Hosts: hosts, IP: peer.IP})
+			}
+		}
+	}
+
 	for _, extraHost := range container.hostConfig.ExtraHosts {
 		// allow IPv6 addresses in extra hosts; only split on first ":"
 		parts := strings.SplitN(extraHost, ":", 2)
@@ -574,8 +684,30 @@ func (container *Container) AllocateNetwork() error {
 		eng = container.daemon.eng
 	)
 
+	network, err := container.daemon.networks.Get(mode.NetworkName())
+	if err != nil {
+		return err
+	}
+	if network.onLan() {
+		return container.allocateLanNetwork(network)
+	}
+	if network.Driver == "overlay" {
+		// The container reaches the world, and publishes its ports, through
+		// the default bridge network.
+		if network, err = container.daemon.networks.Get("bridge"); err != nil {
+			return err
+		}
+	}
+	bridge := network.bridge()
+
 	job := eng.Job("allocate_interface", container.ID)
+	job.Setenv("Bridge", bridge)
 	job.Setenv("RequestedMac", container.Config.MacAddress)
+	job.Setenv("ContainerName", container.Name)
+	if network.Name == mode.NetworkName() {
+		job.Setenv("RequestedIP", container.Config.IPv4Address)
+		job.Setenv("RequestedIPv6", container.Config.IPv6Address)
+	}
 	if env, err = job.Stdout.AddEnv(); err != nil {
 		return err
 	}
@@ -589,12 +721,12 @@ func (container *Container) AllocateNetwork() error {
 
 	if container.Config.PortSpecs != nil {
 		if err = migratePortMappings(container.Config, container.hostConfig); err != nil {
-			eng.Job("release_interface", container.ID).Run()
+			releaseInterface(eng, container.ID, bridge)
 			return err
 		}
 		container.Config.PortSpecs = nil
 		if err = container.WriteHostConfig(); err != nil {
-			eng.Job("release_interface", container.ID).Run()
+			releaseInterface(eng, container.ID, bridge)
 			return err
 		}
 	}
@@ -622,14 +754,34 @@ func (container *Container) AllocateNetwork() error {
 
 	container.NetworkSettings.PortMapping = nil
 
+	allocated := make(map[nat.Port]bool)
+	for _, ports := range publishedRanges(portSpecs, bindings) {
+		if err = container.allocatePortRange(eng, bridge, ports, bindings); err != nil {
+			releaseInterface(eng, container.ID, bridge)
+			return err
+		}
+		for _, port := range ports {
+			allocated[port] = true
+		}
+	}
 	for port := range portSpecs {
-		if err = container.allocatePort(eng, port, bindings); err != nil {
-			eng.Job("release_interface", container.ID).Run()
+		if allocated[port] {
+			continue
+		}
+		if err = container.allocatePort(eng, bridge, port, bindings); err != nil {
+			releaseInterface(eng, container.ID, bridge)
 			return err
 		}
 	}
 	container.WriteHostConfig()
 
+	// The other networks the container is connected to
+	endpoints, err := container.allocateConnectedEndpoints()
+	if err != nil {
+		releaseInterface(eng, container.ID, bridge)
+		return err
+	}
+
 	container.NetworkSettings.Ports = bindings
 	container.NetworkSettings.Bridge = env.Get("Bridge")
 	container.NetworkSettings.IPAddress = env.Get("IP")
@@ -642,19 +794,457 @@ func (container *Container) AllocateNetwork() error {
 	container.NetworkSettings.GlobalIPv6PrefixLen = env.GetInt("GlobalIPv6PrefixLen")
 	container.NetworkSettings.IPv6Gateway = env.Get("IPv6Gateway")
 
+	if network.Name == mode.NetworkName() {
+		endpoints[network.Name] = &EndpointSettings{
+			NetworkID:           network.ID,
+			IPAddress:           container.NetworkSettings.IPAddress,
+			IPPrefixLen:         container.NetworkSettings.IPPrefixLen,
+			MacAddress:          container.NetworkSettings.MacAddress,
+			Gateway:             container.NetworkSettings.Gateway,
+			GlobalIPv6Address:   container.NetworkSettings.GlobalIPv6Address,
+			GlobalIPv6PrefixLen: container.NetworkSettings.GlobalIPv6PrefixLen,
+			IPv6Gateway:         container.NetworkSettings.IPv6Gateway,
+		}
+		if config := container.ipamConfig(network.Name); *config != (EndpointIPAMConfig{}) {
+			endpoints[network.Name].IPAMConfig = config
+		}
+		endpoints[network.Name].Aliases = container.aliases(network.Name)
+	}
+	container.NetworkSettings.Networks = endpoints
+
 	return nil
 }
 
+// allocateLanNetwork allocates the interface of the container on n, the
+// network of its network mode, a network of the macvlan or ipvlan driver.
+// The container has no interface on a bridge, nor published ports, it's on
+// the LAN of the parent of n, whose router is its gateway.
+func (container *Container) allocateLanNetwork(n *Network) error {
+	config := container.ipamConfig(n.Name)
+	endpoint, err := container.allocateEndpoint(n, config.IPv4Address, "", container.Config.MacAddress, container.aliases(n.Name))
+	if err != nil {
+		return err
+	}
+	endpoint.Gateway = n.Gateway
+	if *config != (EndpointIPAMConfig{}) {
+		endpoint.IPAMConfig = config
+	}
+	endpoints, err := container.allocateConnectedEndpoints()
+	if err != nil {
+		container.releaseEndpoint(n, endpoint)
+		return err
+	}
+	endpoints[n.Name] = endpoint
+
+	container.NetworkSettings.IPAddress = endpoint.IPAddress
+	container.NetworkSettings.IPPrefixLen = endpoint.IPPrefixLen
+	container.NetworkSettings.MacAddress = endpoint.MacAddress
+	container.NetworkSettings.Gateway = endpoint.Gateway
+	container.NetworkSettings.Networks = endpoints
+	return nil
+}
+
+// allocateConnectedEndpoints allocates the interfaces of the container on
+// the networks of connectedNetworks, and returns them by network name.
+func (container *Container) allocateConnectedEndpoints() (map[string]*EndpointSettings, error) {
+	endpoints := make(map[string]*EndpointSettings)
+	for _, name := range container.connectedNetworks() {
+		n, err := container.daemon.networks.Get(name)
+		if err == nil {
+			config := container.ipamConfig(name)
+			endpoints[name], err = container.allocateEndpoint(n, config.IPv4Address, config.IPv6Address, "", container.aliases(name))
+			if err == nil && *config != (EndpointIPAMConfig{}) {
+				endpoints[name].IPAMConfig = config
+			}
+		}
+		if err != nil {
+			container.releaseEndpoints(endpoints)
+			return nil, fmt.Errorf("Unable to connect to network %s: %s", name, err)
+		}
+	}
+	return endpoints, nil
+}
+
 func (container *Container) ReleaseNetwork() {
 	if container.Config.NetworkDisabled || !container.hostConfig.NetworkMode.IsPrivate() {
 		return
 	}
 	eng := container.daemon.eng
 
-	job := eng.Job("release_interface", container.ID)
+	// The containers of the networks of the macvlan and ipvlan drivers
+	// have no interface on a bridge.
+	if container.NetworkSettings.Bridge != "" {
+		job := eng.Job("release_interface", container.ID)
+		job.Setenv("Bridge", container.NetworkSettings.Bridge)
+		job.SetenvBool("overrideShutdown", true)
+		job.Run()
+	}
+
+	container.releaseEndpoints(container.NetworkSettings.Networks)
+	container.resetNetworkSettings()
+}
+
+// resetNetworkSettings forgets the interfaces of the container. It stays
+// connected to its networks, it gets new interfaces on them when it starts
+// again.
+func (container *Container) resetNetworkSettings() {
+	networks := make(map[string]*EndpointSettings)
+	for name, endpoint := range container.NetworkSettings.Networks {
+		networks[name] = &EndpointSettings{IPAMConfig: endpoint.IPAMConfig, Aliases: endpoint.Aliases}
+	}
+	container.NetworkSettings = &NetworkSettings{Networks: networks}
+}
+
+// connectedNetworks returns the names of the networks the container gets an
+// interface on in addition to the one of its network settings: the overlay
+// network of its network mode first, then the networks it was connected to
+// with `docker network connect`, sorted so that the container gets its
+// interfaces on them in the same order every time.
+func (container *Container) connectedNetworks() []string {
+	var (
+		names []string
+		mode  = container.hostConfig.NetworkMode.NetworkName()
+	)
+	for name := range container.NetworkSettings.Networks {
+		if name != mode {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if n, err := container.daemon.networks.Get(mode); err == nil && n.Driver == "overlay" {
+		names = append([]string{mode}, names...)
+	}
+	return names
+}
+
+// ipamConfig returns the addresses the container asked for on the network
+// named name: with --ip and --ip6 for the network of its network mode, with
+// `docker network connect` for the others.
+func (container *Container) ipamConfig(name string) *EndpointIPAMConfig {
+	if name == container.hostConfig.NetworkMode.NetworkName() {
+		return &EndpointIPAMConfig{
+			IPv4Address: container.Config.IPv4Address,
+			IPv6Address: container.Config.IPv6Address,
+		}
+	}
+	if endpoint := container.NetworkSettings.Networks[name]; endpoint != nil && endpoint.IPAMConfig != nil {
+		config := *endpoint.IPAMConfig
+		return &config
+	}
+	return &EndpointIPAMConfig{}
+}
+
+// aliases returns the names the container has on the network named name, in
+// addition to its own: given with --net-alias for the network of its network
+// mode, with `docker network connect --alias` for the others.
+func (container *Container) aliases(name string) []string {
+	if name == container.hostConfig.NetworkMode.NetworkName() {
+		return container.Config.NetworkAliases
+	}
+	if endpoint := container.NetworkSettings.Networks[name]; endpoint != nil {
+		return endpoint.Aliases
+	}
+	return nil
+}
+
+// allocateEndpoint allocates an interface of the container on n, which has
+// no default gateway: the one of the network mode of the container is the
+// default route. ip and ip6 are the addresses of the interface, empty for
+// any free ones, and aliases the names of the container on n.
+func (container *Container) allocateEndpoint(n *Network, ip, ip6, mac string, aliases []string) (*EndpointSettings, error) {
+	if n.Driver == "overlay" || n.onLan() {
+		if ip6 != "" {
+			return nil, fmt.Errorf("Network %s has no IPv6 subnet", n.Name)
+		}
+		if n.onLan() {
+			return container.allocateLanEndpoint(n, ip, mac, aliases)
+		}
+		return container.allocateOverlayEndpoint(n, ip, aliases)
+	}
+	job := container.daemon.eng.Job("allocate_interface", container.ID)
+	job.Setenv("Bridge", n.bridge())
+	job.Setenv("RequestedIP", ip)
+	job.Setenv("RequestedIPv6", ip6)
+	job.Setenv("RequestedMac", mac)
+	job.Setenv("ContainerName", container.Name)
+	env, err := job.Stdout.AddEnv()
+	if err != nil {
+		return nil, err
+	}
+	if err := job.Run(); err != nil {
+		return nil, err
+	}
+	return &EndpointSettings{
+		NetworkID:           n.ID,
+		IPAddress:           env.Get("IP"),
+		IPPrefixLen:         env.GetInt("IPPrefixLen"),
+		MacAddress:          env.Get("MacAddress"),
+		GlobalIPv6Address:   env.Get("GlobalIPv6"),
+		GlobalIPv6PrefixLen: env.GetInt("GlobalIPv6PrefixLen"),
+		Aliases:             aliases,
+	}, nil
+}
+
+// allocateOverlayEndpoint allocates an interface of the container on n, a
+// network of the overlay driver, whose address is ip if it isn't empty. Its
+// MAC address is derived from it. The containers of every host resolve the
+// container by its name and aliases.
+func (container *Container) allocateOverlayEndpoint(n *Network, ip string, aliases []string) (*EndpointSettings, error) {
+	if container.daemon.overlay == nil {
+		return nil, fmt.Errorf("Network %s requires a cluster store, see --cluster-store", n.Name)
+	}
+	subnet := n.subnet()
+	if subnet == nil {
+		return nil, fmt.Errorf("Network %s has no subnet", n.Name)
+	}
+	e, err := container.daemon.overlay.Join(n.ID, subnet, n.vxlanID(), n.mtu(), container.ID, strings.TrimPrefix(container.Name, "/"), aliases, ip)
+	if err != nil {
+		return nil, err
+	}
+	ones, _ := subnet.Mask.Size()
+	return &EndpointSettings{
+		NetworkID:   n.ID,
+		IPAddress:   e.IP,
+		IPPrefixLen: ones,
+		MacAddress:  e.MacAddress,
+		Aliases:     aliases,
+	}, nil
+}
+
+// allocateLanEndpoint allocates an interface of the container on n, a
+// network of the macvlan or ipvlan driver, whose address is ip if it isn't
+// empty. A macvlan interface has the MAC address mac, or one derived from
+// its address, an ipvlan one has the MAC address of its parent.
+func (container *Container) allocateLanEndpoint(n *Network, ip, mac string, aliases []string) (*EndpointSettings, error) {
+	subnet := n.subnet()
+	if subnet == nil {
+		return nil, fmt.Errorf("Network %s has no subnet", n.Name)
+	}
+	var requested net.IP
+	if ip != "" {
+		if requested = net.ParseIP(ip); requested == nil {
+			return nil, fmt.Errorf("%s is not a valid IPv4 address", ip)
+		}
+	}
+	addr, err := container.daemon.lan.RequestIP(n.ID, requested)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case n.Driver == "ipvlan":
+		mac = ""
+	case mac == "":
+		mac = container.daemon.lanMacAddress(addr, container.Name)
+	}
+	ones, _ := subnet.Mask.Size()
+	return &EndpointSettings{
+		NetworkID:   n.ID,
+		IPAddress:   addr.String(),
+		IPPrefixLen: ones,
+		MacAddress:  mac,
+		Aliases:     aliases,
+	}, nil
+}
+
+// releaseEndpoints releases the interfaces of the container on the networks
+// of endpoints, but the one of its network settings, which ReleaseNetwork
+// releases.
+func (container *Container) releaseEndpoints(endpoints map[string]*EndpointSettings) {
+	for _, endpoint := range endpoints {
+		if endpoint.IPAddress == "" {
+			continue
+		}
+		n, err := container.daemon.networks.Get(endpoint.NetworkID)
+		if err != nil || n.Driver == "bridge" && n.bridge() == container.NetworkSettings.Bridge {
+			continue
+		}
+		container.releaseEndpoint(n, endpoint)
+	}
+}
+
+func (container *Container) releaseEndpoint(n *Network, endpoint *EndpointSettings) {
+	if n.onLan() {
+		if err := container.daemon.lan.ReleaseIP(n.ID, net.ParseIP(endpoint.IPAddress)); err != nil {
+			logrus.Errorf("Unable to release the endpoint of %s on network %s: %v", container.ID, n.Name, err)
+		}
+		return
+	}
+	if n.Driver == "overlay" {
+		if err := container.daemon.overlay.Leave(n.ID, endpoint.IPAddress); err != nil {
+			logrus.Errorf("Unable to release the endpoint of %s on network %s: %v", container.ID, n.Name, err)
+		}
+		return
+	}
+	releaseInterface(container.daemon.eng, container.ID, n.bridge())
+}
+
+// interfaceMtu returns the MTU of the interfaces on n, 0 for the MTU of the
+// network of the daemon.
+func (container *Container) interfaceMtu(n *Network) int {
+	switch n.Driver {
+	case "overlay":
+		if mtu := n.mtu(); mtu != 0 {
+			return mtu
+		}
+		return container.daemon.config.Mtu - overlay.Overhead
+	case "bridge", "macvlan", "ipvlan":
+		return n.mtu()
+	}
+	return 0
+}
+
+// networkInterface returns the interface of the container on n, one of the
+// networks it is connected to, whose settings are endpoint.
+func (container *Container) networkInterface(n *Network, endpoint *EndpointSettings) *execdriver.NetworkInterface {
+	iface := &execdriver.NetworkInterface{
+		Bridge:              n.bridge(),
+		IPAddress:           endpoint.IPAddress,
+		IPPrefixLen:         endpoint.IPPrefixLen,
+		MacAddress:          endpoint.MacAddress,
+		GlobalIPv6Address:   endpoint.GlobalIPv6Address,
+		GlobalIPv6PrefixLen: endpoint.GlobalIPv6PrefixLen,
+		Mtu:                 container.interfaceMtu(n),
+	}
+	setParent(iface, n)
+	return iface
+}
+
+// setParent makes iface an interface on the parent of n, if n is a network
+// of the macvlan or ipvlan driver.
+func setParent(iface *execdriver.NetworkInterface, n *Network) {
+	if n.onLan() {
+		iface.Type = n.Driver
+		iface.Parent = n.parent()
+		iface.Mode = n.lanMode()
+	}
+}
+
+func releaseInterface(eng *engine.Engine, id, bridge string) {
+	job := eng.Job("release_interface", id)
+	job.Setenv("Bridge", bridge)
 	job.SetenvBool("overrideShutdown", true)
 	job.Run()
-	container.NetworkSettings = &NetworkSettings{}
+}
+
+// ConnectToNetwork connects the container to n, in addition to the network
+// of its network mode. A running container gets its interface on n right
+// away, the next free ethN. config holds the addresses of the container on
+// n, nil for any free ones, and aliases its names on n in addition to its
+// own.
+func (container *Container) ConnectToNetwork(n *Network, config *EndpointIPAMConfig, aliases []string) error {
+	container.Lock()
+	defer container.Unlock()
+
+	mode := container.hostConfig.NetworkMode
+	if !mode.IsPrivate() {
+		return fmt.Errorf("Container %s doesn't have a network stack of its own (--net=%s), it can't be connected to a network", container.ID, mode)
+	}
+	if n.Driver != "bridge" && n.Driver != "overlay" && !n.onLan() {
+		return fmt.Errorf("Containers can't be connected to the %s network", n.Name)
+	}
+	if _, connected := container.NetworkSettings.Networks[n.Name]; connected || mode.NetworkName() == n.Name {
+		return fmt.Errorf("Container %s is already connected to network %s", container.ID, n.Name)
+	}
+	if config == nil {
+		config = &EndpointIPAMConfig{}
+	}
+	if err := checkIPAMConfig(n, config); err != nil {
+		return err
+	}
+	if err := checkNetworkAliases(n, aliases); err != nil {
+		return err
+	}
+	if err := checkIngressBandwidth(n, container.hostConfig); err != nil {
+		return err
+	}
+
+	endpoint := &EndpointSettings{}
+	if container.Running && container.isNetworkAllocated() {
+		var err error
+		if endpoint, err = container.allocateEndpoint(n, config.IPv4Address, config.IPv6Address, "", aliases); err != nil {
+			return err
+		}
+		veth, err := execdriver.NewVeth("", container.networkInterface(n, endpoint), container.daemon.config.Mtu)
+		if err == nil {
+			err = execdriver.AddInterface(container.Pid, veth)
+		}
+		if err != nil {
+			container.releaseEndpoint(n, endpoint)
+			return err
+		}
+		if err := container.limitBandwidth(container.Pid); err != nil {
+			execdriver.RemoveInterface(container.Pid, endpoint.IPAddress)
+			container.releaseEndpoint(n, endpoint)
+			return err
+		}
+	}
+	if *config != (EndpointIPAMConfig{}) {
+		endpoint.IPAMConfig = config
+	}
+	endpoint.Aliases = aliases
+	if container.NetworkSettings.Networks == nil {
+		container.NetworkSettings.Networks = make(map[string]*EndpointSettings)
+	}
+	container.NetworkSettings.Networks[n.Name] = endpoint
+	if err := container.updateOverlayHosts(n); err != nil {
+		return err
+	}
+	return container.toDisk()
+}
+
+// DisconnectFromNetwork disconnects the container from n, a network it was
+// connected to with ConnectToNetwork.
+func (container *Container) DisconnectFromNetwork(n *Network) error {
+	container.Lock()
+	defer container.Unlock()
+
+	if container.hostConfig.NetworkMode.NetworkName() == n.Name {
+		return fmt.Errorf("Container %s can't be disconnected from %s, the network of its network mode", container.ID, n.Name)
+	}
+	endpoint, connected := container.NetworkSettings.Networks[n.Name]
+	if !connected {
+		return fmt.Errorf("Container %s is not connected to network %s", container.ID, n.Name)
+	}
+	if container.Running && endpoint.IPAddress != "" {
+		// The interfaces of the ipvlan driver have no MAC address of
+		// their own.
+		addr := endpoint.MacAddress
+		if addr == "" {
+			addr = endpoint.IPAddress
+		}
+		if err := execdriver.RemoveInterface(container.Pid, addr); err != nil {
+			return err
+		}
+		container.releaseEndpoint(n, endpoint)
+	}
+	delete(container.NetworkSettings.Networks, n.Name)
+	if err := container.updateOverlayHosts(n); err != nil {
+		return err
+	}
+	return container.toDisk()
+}
+
+// updateOverlayHosts rebuilds the hosts file of the running container when
+// it was connected to, or disconnected from, n, a network of the overlay
+// driver, so that it lists the containers of n.
+func (container *Container) updateOverlayHosts(n *Network) error {
+	if n.Driver != "overlay" || !container.Running || !container.isNetworkAllocated() {
+		return nil
+	}
+	return container.buildHostsFiles(container.NetworkSettings.IPAddress)
+}
+
+// limitBandwidth limits the bandwidth of the interfaces of the container,
+// whose process is pid, to the rates of its host config.
+func (container *Container) limitBandwidth(pid int) error {
+	b := &execdriver.Bandwidth{
+		Egress:  container.hostConfig.NetEgressBps,
+		Ingress: container.hostConfig.NetIngressBps,
+	}
+	if *b == (execdriver.Bandwidth{}) || !container.hostConfig.NetworkMode.IsPrivate() {
+		return nil
+	}
+	return execdriver.LimitBandwidth(pid, b)
 }
 
 func (container *Container) isNetworkAllocated() bool {
@@ -670,19 +1260,46 @@ func (container *Container) RestoreNetwork() error {
 		return nil
 	}
 
-	eng := container.daemon.eng
+	var (
+		eng    = container.daemon.eng
+		bridge = container.NetworkSettings.Bridge
+		names  = container.connectedNetworks()
+	)
 
-	// Re-allocate the interface with the same IP and MAC address.
-	job := eng.Job("allocate_interface", container.ID)
-	job.Setenv("RequestedIP", container.NetworkSettings.IPAddress)
-	job.Setenv("RequestedMac", container.NetworkSettings.MacAddress)
-	if err := job.Run(); err != nil {
-		return err
+	if n, err := container.daemon.networks.Get(mode.NetworkName()); err == nil && n.onLan() {
+		// The container has no interface on a bridge, only the one on
+		// the network of its network mode.
+		names = append([]string{n.Name}, names...)
+	} else {
+		// Re-allocate the interface with the same IP and MAC address.
+		job := eng.Job("allocate_interface", container.ID)
+		job.Setenv("Bridge", bridge)
+		job.Setenv("RequestedIP", container.NetworkSettings.IPAddress)
+		job.Setenv("RequestedMac", container.NetworkSettings.MacAddress)
+		job.Setenv("ContainerName", container.Name)
+		if err := job.Run(); err != nil {
+			return err
+		}
+
+		// Re-allocate any previously allocated ports.
+		for port := range container.NetworkSettings.Ports {
+			if err := container.allocatePort(eng, bridge, port, container.NetworkSettings.Ports); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Re-allocate any previously allocated ports.
-	for port := range container.NetworkSettings.Ports {
-		if err := container.allocatePort(eng, port, container.NetworkSettings.Ports); err != nil {
+	// And the interfaces on the other networks.
+	for _, name := range names {
+		endpoint := container.NetworkSettings.Networks[name]
+		if endpoint.IPAddress == "" {
+			continue
+		}
+		n, err := container.daemon.networks.Get(endpoint.NetworkID)
+		if err != nil {
+			return err
+		}
+		if _, err := container.allocateEndpoint(n, endpoint.IPAddress, endpoint.GlobalIPv6Address, endpoint.MacAddress, endpoint.Aliases); err != nil {
 			return err
 		}
 	}
@@ -796,14 +1413,62 @@ func (container *Container) Kill() error {
 	return nil
 }
 
+// setManuallyStopped records that the user stopped the container, so that the
+// daemon doesn't restart it on startup with the unless-stopped policy.
+func (container *Container) setManuallyStopped() {
+	container.Lock()
+	container.HasBeenManuallyStopped = true
+	container.Unlock()
+}
+
+// stopTimeout returns the number of seconds container gets to stop before
+// it is killed, unless the caller asks for another timeout.
+func (container *Container) stopTimeout() int {
+	if container.Config.StopTimeout != nil {
+		return *container.Config.StopTimeout
+	}
+	return defaultStopTimeout
+}
+
+// runsInit returns whether container runs the command of its config under
+// the init of docker, as chosen by its host config or else by the daemon.
+func (container *Container) runsInit() bool {
+	if container.hostConfig.Init != nil {
+		return *container.hostConfig.Init
+	}
+	return container.daemon.config.Init
+}
+
+// pidsLimit returns the maximum number of processes of container, chosen by
+// its host config or else by the daemon, 0 for no limit.
+func (container *Container) pidsLimit() int64 {
+	limit := container.hostConfig.PidsLimit
+	if limit == 0 {
+		limit = container.daemon.config.PidsLimit
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// stopSignal returns the signal container is sent to stop it.
+func (container *Container) stopSignal() int {
+	if sig, err := signal.ParseSignal(container.Config.StopSignal); err == nil {
+		return int(sig)
+	}
+	return int(syscall.SIGTERM)
+}
+
 func (container *Container) Stop(seconds int) error {
 	if !container.IsRunning() {
 		return nil
 	}
 
-	// 1. Send a SIGTERM
-	if err := container.killPossiblyDeadProcess(15); err != nil {
-		logrus.Infof("Failed to send SIGTERM to the process, force killing")
+	// 1. Send the stop signal, SIGTERM by default
+	stopSignal := container.stopSignal()
+	if err := container.killPossiblyDeadProcess(stopSignal); err != nil {
+		logrus.Infof("Failed to send signal %d to the process, force killing", stopSignal)
 		if err := container.killPossiblyDeadProcess(9); err != nil {
 			return err
 		}
@@ -811,7 +1476,7 @@ func (container *Container) Stop(seconds int) error {
 
 	// 2. Wait for the process to exit on its own
 	if _, err := container.WaitStop(time.Duration(seconds) * time.Second); err != nil {
-		logrus.Infof("Container %v failed to exit within %d seconds of SIGTERM - using the force", container.ID, seconds)
+		logrus.Infof("Container %v failed to exit within %d seconds of signal %d - using the force", container.ID, seconds, stopSignal)
 		// 3. If it doesn't, then send SIGKILL
 		if err := container.Kill(); err != nil {
 			container.WaitStop(-1 * time.Second)
@@ -829,6 +1494,16 @@ func (container *Container) Restart(seconds int) error {
 		defer container.Unmount()
 	}
 
+	// Stopping the container doesn't remove it if run with --rm
+	container.Lock()
+	container.restartInProgress = true
+	container.Unlock()
+	defer func() {
+		container.Lock()
+		container.restartInProgress = false
+		container.Unlock()
+	}()
+
 	if err := container.Stop(seconds); err != nil {
 		return err
 	}
@@ -862,22 +1537,14 @@ func (container *Container) ExportRw() (archive.Archive, error) {
 		nil
 }
 
-func (container *Container) Export() (archive.Archive, error) {
+// Export writes a tar archive of the filesystem of container to w, read
+// from the mount of the storage driver as it's written.
+func (container *Container) Export(w io.Writer) error {
 	if err := container.Mount(); err != nil {
-		return nil, err
-	}
-
-	archive, err := archive.Tar(container.basefs, archive.Uncompressed)
-	if err != nil {
-		container.Unmount()
-		return nil, err
+		return err
 	}
-	return ioutils.NewReadCloserWrapper(archive, func() error {
-			err := archive.Close()
-			container.Unmount()
-			return err
-		}),
-		nil
+	defer container.Unmount()
+	return archive.WriteTar(w, container.basefs, &archive.TarOptions{Compression: archive.Uncompressed})
 }
 
 func (container *Container) Mount() error {
@@ -952,8 +1619,16 @@ func (container *Container) GetSize() (int64, int64) {
 	}
 	defer container.Unmount()
 
-	initID := fmt.Sprintf("%s-init", container.ID)
-	sizeRw, err = driver.DiffSize(container.ID, initID)
+	// The drivers which account for the space of their layers tell it
+	// without walking the files of the container
+	err = graphdriver.ErrNotSupported
+	if usageDriver, ok := driver.(graphdriver.UsageDriver); ok {
+		sizeRw, _, err = usageDriver.Usage(container.ID)
+	}
+	if err != nil {
+		initID := fmt.Sprintf("%s-init", container.ID)
+		sizeRw, err = driver.DiffSize(container.ID, initID)
+	}
 	if err != nil {
 		logrus.Errorf("Driver %s couldn't return diff size of container %s: %s", driver, container.ID, err)
 		// FIXME: GetSize should return an error. Not changing it now in case
@@ -1029,6 +1704,43 @@ func (container *Container) Copy(resource string) (io.ReadCloser, error) {
 		nil
 }
 
+// Extract unpacks the tar archive content into the directory resource of the
+// container, or of the volume mounted there. The files are owned by root.
+func (container *Container) Extract(resource string, content io.Reader) error {
+	// Paths such as data/../etc would pass for paths within the volume at
+	// /data otherwise
+	resource = strings.TrimPrefix(filepath.Clean("/"+resource), "/")
+
+	// Check if this is actually in a volume
+	for _, mnt := range container.VolumeMounts() {
+		if len(mnt.MountToPath) > 0 && (resource == mnt.MountToPath[1:] || strings.HasPrefix(resource, mnt.MountToPath[1:]+"/")) {
+			return mnt.Extract(resource, content)
+		}
+	}
+
+	if container.hostConfig.ReadonlyRootfs {
+		return fmt.Errorf("Cannot extract to %s: the root filesystem of the container is read-only", resource)
+	}
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	defer container.Unmount()
+
+	basePath, err := container.getResourcePath(resource)
+	if err != nil {
+		return err
+	}
+	stat, err := os.Stat(basePath)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("Cannot extract to %s: not a directory", resource)
+	}
+	return chrootarchive.Untar(content, basePath, &archive.TarOptions{NoLchown: true})
+}
+
 // Returns true if the container exposes a certain port
 func (container *Container) Exposes(p nat.Port) bool {
 	_, exists := container.Config.ExposedPorts[p]
@@ -1109,23 +1821,38 @@ func (container *Container) setupContainerDns() error {
 	}
 
 	if config.NetworkMode != "host" {
+		var (
+			dns        = resolvconf.GetNameservers(resolvConf)
+			dnsSearch  = resolvconf.GetSearchDomains(resolvConf)
+			dnsOptions = resolvconf.GetOptions(resolvConf)
+		)
+		if len(config.DnsSearch) > 0 {
+			dnsSearch = config.DnsSearch
+		} else if len(daemon.config.DnsSearch) > 0 {
+			dnsSearch = daemon.config.DnsSearch
+		}
+		if len(config.DnsOptions) > 0 {
+			dnsOptions = config.DnsOptions
+		} else if len(daemon.config.DnsOptions) > 0 {
+			dnsOptions = daemon.config.DnsOptions
+		}
+
+		// The containers of the user-defined networks use the DNS server of
+		// the daemon, which resolves the names of the containers on their
+		// networks and forwards the other queries to their nameservers.
+		if ns := daemon.resolverAddress(string(config.NetworkMode)); ns != "" {
+			return resolvconf.Build(container.ResolvConfPath, []string{ns}, dnsSearch, dnsOptions)
+		}
+
 		// check configurations for any container/daemon dns settings
-		if len(config.Dns) > 0 || len(daemon.config.Dns) > 0 || len(config.DnsSearch) > 0 || len(daemon.config.DnsSearch) > 0 {
-			var (
-				dns       = resolvconf.GetNameservers(resolvConf)
-				dnsSearch = resolvconf.GetSearchDomains(resolvConf)
-			)
+		if len(config.Dns) > 0 || len(daemon.config.Dns) > 0 || len(config.DnsSearch) > 0 || len(daemon.config.DnsSearch) > 0 ||
+			len(config.DnsOptions) > 0 || len(daemon.config.DnsOptions) > 0 {
 			if len(config.Dns) > 0 {
 				dns = config.Dns
 			} else if len(daemon.config.Dns) > 0 {
 				dns = daemon.config.Dns
 			}
-			if len(config.DnsSearch) > 0 {
-				dnsSearch = config.DnsSearch
-			} else if len(daemon.config.DnsSearch) > 0 {
-				dnsSearch = daemon.config.DnsSearch
-			}
-			return resolvconf.Build(container.ResolvConfPath, dns, dnsSearch)
+			return resolvconf.Build(container.ResolvConfPath, dns, dnsSearch, dnsOptions)
 		}
 
 		// replace any localhost/127.*, and remove IPv6 nameservers if IPv6 disabled in daemon
@@ -1217,6 +1944,9 @@ func (container *Container) updateResolvConf(updatedResolvConf []byte, newResolv
 	return nil
 }
 
+// updateParentsHosts points the containers linking to the container at its
+// IP address, which may have changed since they started: the records of its
+// alias in their /etc/hosts, and the iptables rules of their links.
 func (container *Container) updateParentsHosts() error {
 	refs := container.daemon.ContainerGraph().RefPaths(container.ID)
 	for _, ref := range refs {
@@ -1234,25 +1964,52 @@ func (container *Container) updateParentsHosts() error {
 			if err := etchosts.Update(c.HostsPath, container.NetworkSettings.IPAddress, ref.Name); err != nil {
 				logrus.Errorf("Failed to update /etc/hosts in parent container %s for alias %s: %v", c.ID, ref.Name, err)
 			}
+			// The parent may be starting, waiting for the lock of the
+			// container to check that it runs: its own lock is taken aside
+			go c.updateLink(ref.Name, container.NetworkSettings.IPAddress)
 		}
 	}
 	return nil
 }
 
+// updateLink moves the iptables rules of the active link of the container
+// named alias to childIP, the new address of the linked container.
+func (container *Container) updateLink(alias, childIP string) {
+	container.Lock()
+	defer container.Unlock()
+	link, exists := container.activeLinks[alias]
+	if !exists || !link.IsEnabled || link.ChildIP == childIP {
+		return
+	}
+	link.Disable()
+	link.ChildIP = childIP
+	if err := link.Enable(); err != nil {
+		logrus.Errorf("Failed to update link %s of container %s to %s: %v", alias, container.ID, childIP, err)
+	}
+}
+
+// setHostHostname gives container the hostname and domain name of the host.
+func (container *Container) setHostHostname() error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	container.Config.Hostname = hostname
+
+	parts := strings.SplitN(hostname, ".", 2)
+	if len(parts) > 1 {
+		container.Config.Hostname = parts[0]
+		container.Config.Domainname = parts[1]
+	}
+	return nil
+}
+
 func (container *Container) initializeNetworking() error {
-	var err error
 	if container.hostConfig.NetworkMode.IsHost() {
-		container.Config.Hostname, err = os.Hostname()
-		if err != nil {
+		if err := container.setHostHostname(); err != nil {
 			return err
 		}
 
-		parts := strings.SplitN(container.Config.Hostname, ".", 2)
-		if len(parts) > 1 {
-			container.Config.Hostname = parts[0]
-			container.Config.Domainname = parts[1]
-		}
-
 		content, err := ioutil.ReadFile("/etc/hosts")
 		if os.IsNotExist(err) {
 			return container.buildHostnameAndHostsFiles("")
@@ -1285,6 +2042,11 @@ func (container *Container) initializeNetworking() error {
 		container.Config.Domainname = nc.Config.Domainname
 		return nil
 	}
+	if container.hostConfig.UTSMode.IsHost() {
+		if err := container.setHostHostname(); err != nil {
+			return err
+		}
+	}
 	if container.daemon.config.DisableNetwork {
 		container.Config.NetworkDisabled = true
 		return container.buildHostnameAndHostsFiles("127.0.1.1")
@@ -1418,10 +2180,7 @@ func (container *Container) setupWorkingDirectory() error {
 }
 
 func (container *Container) startLogging() error {
-	cfg := container.hostConfig.LogConfig
-	if cfg.Type == "" {
-		cfg = container.daemon.defaultLogConfig
-	}
+	cfg := container.daemon.logConfig(container.hostConfig.LogConfig)
 	var l logger.Logger
 	switch cfg.Type {
 	case "json-file":
@@ -1430,7 +2189,7 @@ func (container *Container) startLogging() error {
 			return err
 		}
 
-		dl, err := jsonfilelog.New(pth)
+		dl, err := jsonfilelog.New(pth, container.logAttributes(cfg))
 		if err != nil {
 			return err
 		}
@@ -1458,9 +2217,44 @@ func (container *Container) startLogging() error {
 	return nil
 }
 
+// logAttributes returns the container labels and environment variables that
+// the labels and env options of its logging driver select, or nil when there
+// are none.
+func (container *Container) logAttributes(cfg runconfig.LogConfig) map[string]string {
+	attrs := make(map[string]string)
+	if labels := cfg.Config["labels"]; labels != "" {
+		for _, name := range strings.Split(labels, ",") {
+			if value, ok := container.Config.Labels[name]; ok {
+				attrs[name] = value
+			}
+		}
+	}
+	if env := cfg.Config["env"]; env != "" {
+		envMapping := make(map[string]string)
+		for _, e := range container.Config.Env {
+			if kv := strings.SplitN(e, "=", 2); len(kv) == 2 {
+				envMapping[kv[0]] = kv[1]
+			}
+		}
+		for _, name := range strings.Split(env, ",") {
+			if value, ok := envMapping[name]; ok {
+				attrs[name] = value
+			}
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
 func (container *Container) waitForStart() error {
 	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	return container.runMonitor()
+}
 
+// runMonitor starts the monitor of container and waits for the process to run.
+func (container *Container) runMonitor() error {
 	// block until we either receive an error from the initial start of the container's
 	// process or until the process is running in the container
 	select {
@@ -1472,7 +2266,7 @@ func (container *Container) waitForStart() error {
 	return nil
 }
 
-func (container *Container) allocatePort(eng *engine.Engine, port nat.Port, bindings nat.PortMap) error {
+func (container *Container) allocatePort(eng *engine.Engine, bridge string, port nat.Port, bindings nat.PortMap) error {
 	binding := bindings[port]
 	if container.hostConfig.PublishAllPorts && len(binding) == 0 {
 		binding = append(binding, nat.PortBinding{})
@@ -1482,6 +2276,7 @@ func (container *Container) allocatePort(eng *engine.Engine, port nat.Port, bind
 		b := binding[i]
 
 		job := eng.Job("allocate_port", container.ID)
+		job.Setenv("Bridge", bridge)
 		job.Setenv("HostIP", b.HostIp)
 		job.Setenv("HostPort", b.HostPort)
 		job.Setenv("Proto", port.Proto())
@@ -1503,6 +2298,78 @@ func (container *Container) allocatePort(eng *engine.Engine, port nat.Port, bind
 	return nil
 }
 
+// allocatePortRange publishes ports, consecutive ports of the container, on
+// as many consecutive ports of the host with a single mapping.
+func (container *Container) allocatePortRange(eng *engine.Engine, bridge string, ports []nat.Port, bindings nat.PortMap) error {
+	var (
+		first = ports[0]
+		b     = bindings[first][0]
+		job   = eng.Job("allocate_port", container.ID)
+	)
+	job.Setenv("Bridge", bridge)
+	job.Setenv("HostIP", b.HostIp)
+	job.Setenv("HostPort", b.HostPort)
+	job.Setenv("Proto", first.Proto())
+	job.Setenv("ContainerPort", first.Port())
+	job.Setenv("ContainerPortEnd", ports[len(ports)-1].Port())
+
+	portEnv, err := job.Stdout.AddEnv()
+	if err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	hostPort := portEnv.GetInt("HostPort")
+	for i, port := range ports {
+		bindings[port] = []nat.PortBinding{{
+			HostIp:   portEnv.Get("HostIP"),
+			HostPort: strconv.Itoa(hostPort + i),
+		}}
+	}
+	return nil
+}
+
+// publishedRanges returns the ranges of consecutive ports of portSpecs
+// published on as many consecutive ports of the host, in their only
+// binding, such as the ranges of -p 5000-6000:5000-6000. They are mapped
+// at once, since mapping them port by port would take minutes for large
+// ranges.
+func publishedRanges(portSpecs nat.PortSet, bindings nat.PortMap) [][]nat.Port {
+	// follows returns whether next continues the range of port.
+	follows := func(port, next nat.Port) bool {
+		if _, exposed := portSpecs[next]; !exposed || len(bindings[port]) != 1 || len(bindings[next]) != 1 {
+			return false
+		}
+		b, nb := bindings[port][0], bindings[next][0]
+		hostPort, err := strconv.Atoi(b.HostPort)
+		if err != nil || hostPort == 0 {
+			return false
+		}
+		return nb.HostIp == b.HostIp && nb.HostPort == strconv.Itoa(hostPort+1)
+	}
+	next := func(port nat.Port) nat.Port {
+		return nat.NewPort(port.Proto(), strconv.Itoa(port.Int()+1))
+	}
+
+	var ranges [][]nat.Port
+	for port := range portSpecs {
+		if port.Int() > 1 && follows(nat.NewPort(port.Proto(), strconv.Itoa(port.Int()-1)), port) {
+			// Not the first port of its range.
+			continue
+		}
+		ports := []nat.Port{port}
+		for follows(port, next(port)) {
+			port = next(port)
+			ports = append(ports, port)
+		}
+		if len(ports) > 1 {
+			ranges = append(ranges, ports)
+		}
+	}
+	return ranges
+}
+
 func (container *Container) GetProcessLabel() string {
 	// even if we have a process label return "" if we are running
 	// in privileged mode
@@ -1531,6 +2398,18 @@ func (container *Container) getIpcContainer() (*Container, error) {
 	return c, nil
 }
 
+func (container *Container) getPidContainer() (*Container, error) {
+	containerID := container.hostConfig.PidMode.Container()
+	c, err := container.daemon.Get(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsRunning() {
+		return nil, fmt.Errorf("cannot join PID of a non running container: %s", containerID)
+	}
+	return c, nil
+}
+
 func (container *Container) getNetworkedContainer() (*Container, error) {
 	parts := strings.SplitN(string(container.hostConfig.NetworkMode), ":", 2)
 	switch parts[0] {
@@ -1556,10 +2435,13 @@ func (container *Container) Stats() (*execdriver.ResourceStats, error) {
 }
 
 func (c *Container) LogDriverType() string {
+	return c.LogConfig().Type
+}
+
+// LogConfig returns the logging driver of the container and its options,
+// the driver defaulting to the one of the daemon.
+func (c *Container) LogConfig() runconfig.LogConfig {
 	c.Lock()
 	defer c.Unlock()
-	if c.hostConfig.LogConfig.Type == "" {
-		return c.daemon.defaultLogConfig.Type
-	}
-	return c.hostConfig.LogConfig.Type
+	return c.daemon.logConfig(c.hostConfig.LogConfig)
 }
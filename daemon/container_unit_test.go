@@ -195,3 +195,30 @@ func TestValidContainerNames(t *testing.T) {
 		}
 	}
 }
+
+func TestPublishedRanges(t *testing.T) {
+	ports, bindings, err := nat.ParsePortSpecs([]string{
+		"5000-5002:5000-5002",
+		"7000-7001:6000-6001/udp",
+		"8080:80",
+		"9000-9001",
+		"127.0.0.1:1000:1000",
+		"0.0.0.0:1001:1001",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ranges := make(map[nat.Port]int)
+	for _, r := range publishedRanges(ports, bindings) {
+		ranges[r[0]] = len(r)
+	}
+	expected := map[nat.Port]int{"5000/tcp": 3, "6000/udp": 2}
+	if len(ranges) != len(expected) {
+		t.Fatalf("Expected the ranges %v, got %v", expected, ranges)
+	}
+	for port, n := range expected {
+		if ranges[port] != n {
+			t.Fatalf("Expected the ranges %v, got %v", expected, ranges)
+		}
+	}
+}
@@ -33,3 +33,23 @@ func (daemon *Daemon) ContainerCopy(job *engine.Job) error {
 	}
 	return nil
 }
+
+// ContainerExtract unpacks the tar archive read from the job's stdin into a
+// directory of the container.
+func (daemon *Daemon) ContainerExtract(job *engine.Job) error {
+	if len(job.Args) != 2 {
+		return fmt.Errorf("Usage: %s CONTAINER PATH\n", job.Name)
+	}
+
+	var (
+		name     = job.Args[0]
+		resource = job.Args[1]
+	)
+
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+
+	return container.Extract(resource, job.Stdin)
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/daemon/logger/jsonfilelog"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/libcontainer/label"
@@ -23,25 +26,62 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) error {
 	config := runconfig.ContainerConfigFromJob(job)
 	hostConfig := runconfig.ContainerHostConfigFromJob(job)
 
-	if len(hostConfig.LxcConf) > 0 && !strings.Contains(daemon.ExecutionDriver().Name(), "lxc") {
-		return fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", daemon.ExecutionDriver().Name())
+	ed, err := daemon.setRuntime(hostConfig)
+	if err != nil {
+		return err
 	}
-	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
-		return fmt.Errorf("Minimum memory limit allowed is 4MB")
+	if len(hostConfig.LxcConf) > 0 && !strings.Contains(ed.Name(), "lxc") {
+		return fmt.Errorf("Cannot use --lxc-conf with execdriver: %s", ed.Name())
 	}
-	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
-		job.Errorf("Your kernel does not support memory limit capabilities. Limitation discarded.\n")
-		hostConfig.Memory = 0
+	// The lxc driver can't join the PID namespace of another container
+	if hostConfig.PidMode.IsContainer() && strings.Contains(ed.Name(), "lxc") {
+		return fmt.Errorf("Cannot use --pid=%s with execdriver: %s", hostConfig.PidMode, ed.Name())
 	}
-	if hostConfig.Memory > 0 && hostConfig.MemorySwap != -1 && !daemon.SystemConfig().SwapLimit {
-		job.Errorf("Your kernel does not support swap limit capabilities. Limitation discarded.\n")
-		hostConfig.MemorySwap = -1
+	if hostConfig.AutoRemove {
+		if hostConfig.RestartPolicy.Name != "" && hostConfig.RestartPolicy.Name != "no" {
+			return fmt.Errorf("Conflicting options: --restart and --rm")
+		}
+		if hostConfig.RestartPolicy.OnUnhealthy {
+			return fmt.Errorf("Conflicting options: --restart-on-unhealthy and --rm")
+		}
 	}
-	if hostConfig.Memory > 0 && hostConfig.MemorySwap > 0 && hostConfig.MemorySwap < hostConfig.Memory {
-		return fmt.Errorf("Minimum memoryswap limit should be larger than memory limit, see usage.\n")
+	warnings, err := daemon.verifyResources(hostConfig)
+	if err != nil {
+		return err
 	}
-	if hostConfig.Memory == 0 && hostConfig.MemorySwap > 0 {
-		return fmt.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.\n")
+	for _, warning := range warnings {
+		job.Errorf("%s\n", warning)
+	}
+	if err := daemon.verifyLogConfig(hostConfig.LogConfig); err != nil {
+		return err
+	}
+	if err := verifyHealthConfig(config.Healthcheck); err != nil {
+		return err
+	}
+	if err := daemon.verifyNetworkMode(hostConfig); err != nil {
+		return err
+	}
+	if err := daemon.verifyIPAMConfig(config, hostConfig); err != nil {
+		return err
+	}
+	if err := daemon.verifyNetworkAliases(config, hostConfig); err != nil {
+		return err
+	}
+	if err := daemon.verifyBandwidth(hostConfig); err != nil {
+		return err
+	}
+	if err := daemon.verifyStorageOpt(hostConfig); err != nil {
+		return err
+	}
+	if config.MacAddress != "" {
+		if _, err := opts.ValidateMACAddress(config.MacAddress); err != nil {
+			return err
+		}
+	}
+	for _, host := range hostConfig.ExtraHosts {
+		if _, err := opts.ValidateExtraHost(host); err != nil {
+			return err
+		}
 	}
 
 	container, buildWarnings, err := daemon.Create(config, hostConfig, name)
@@ -69,6 +109,84 @@ func (daemon *Daemon) ContainerCreate(job *engine.Job) error {
 	return nil
 }
 
+// verifyResources checks the resource limits of hostConfig, and discards the
+// ones the kernel doesn't support with a warning.
+func (daemon *Daemon) verifyResources(hostConfig *runconfig.HostConfig) ([]string, error) {
+	var warnings []string
+	if hostConfig.Memory != 0 && hostConfig.Memory < 4194304 {
+		return nil, fmt.Errorf("Minimum memory limit allowed is 4MB")
+	}
+	if hostConfig.Memory > 0 && !daemon.SystemConfig().MemoryLimit {
+		warnings = append(warnings, "Your kernel does not support memory limit capabilities. Limitation discarded.")
+		hostConfig.Memory = 0
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemorySwap != -1 && !daemon.SystemConfig().SwapLimit {
+		warnings = append(warnings, "Your kernel does not support swap limit capabilities. Limitation discarded.")
+		hostConfig.MemorySwap = -1
+	}
+	if hostConfig.Memory > 0 && hostConfig.MemorySwap > 0 && hostConfig.MemorySwap < hostConfig.Memory {
+		return nil, fmt.Errorf("Minimum memoryswap limit should be larger than memory limit, see usage.\n")
+	}
+	if hostConfig.Memory == 0 && hostConfig.MemorySwap > 0 {
+		return nil, fmt.Errorf("You should always set the Memory limit when using Memoryswap limit, see usage.\n")
+	}
+	if hostConfig.OomKillDisable && !daemon.SystemConfig().OomKillDisable {
+		warnings = append(warnings, "Your kernel does not support oom kill disable. Setting discarded.")
+		hostConfig.OomKillDisable = false
+	}
+	if hostConfig.OomKillDisable && hostConfig.Memory == 0 {
+		warnings = append(warnings, "The OOM killer of the container is disabled without a memory limit: it may exhaust the memory of the host.")
+	}
+	if hostConfig.OomScoreAdj < -1000 || hostConfig.OomScoreAdj > 1000 {
+		return nil, fmt.Errorf("Invalid OomScoreAdj %d: it must be between -1000 and 1000", hostConfig.OomScoreAdj)
+	}
+	if hostConfig.CpuPeriod != 0 && (hostConfig.CpuPeriod < 1000 || hostConfig.CpuPeriod > 1000000) {
+		return nil, fmt.Errorf("Invalid CpuPeriod %d: it must be between 1000 (1ms) and 1000000 (1s)", hostConfig.CpuPeriod)
+	}
+	if hostConfig.CpuQuota != 0 && hostConfig.CpuQuota < 1000 {
+		return nil, fmt.Errorf("Invalid CpuQuota %d: it must be at least 1000 (1ms)", hostConfig.CpuQuota)
+	}
+	if hostConfig.CpuPeriod > 0 && !daemon.SystemConfig().CpuCfsPeriod {
+		warnings = append(warnings, "Your kernel does not support CPU cfs period. Period discarded.")
+		hostConfig.CpuPeriod = 0
+	}
+	if hostConfig.CpuQuota > 0 && !daemon.SystemConfig().CpuCfsQuota {
+		warnings = append(warnings, "Your kernel does not support CPU cfs quota. Quota discarded.")
+		hostConfig.CpuQuota = 0
+	}
+	if hostConfig.BlkioWeight != 0 && (hostConfig.BlkioWeight < 10 || hostConfig.BlkioWeight > 1000) {
+		return nil, fmt.Errorf("Invalid BlkioWeight %d: it must be between 10 and 1000", hostConfig.BlkioWeight)
+	}
+	if (len(hostConfig.DeviceReadBps) > 0 || len(hostConfig.DeviceWriteBps) > 0 || len(hostConfig.DeviceReadIOps) > 0 || len(hostConfig.DeviceWriteIOps) > 0) && !daemon.SystemConfig().BlkioThrottle {
+		warnings = append(warnings, "Your kernel does not support block IO throttling. Device limits discarded.")
+		hostConfig.DeviceReadBps = nil
+		hostConfig.DeviceWriteBps = nil
+		hostConfig.DeviceReadIOps = nil
+		hostConfig.DeviceWriteIOps = nil
+	}
+	if hostConfig.PidsLimit < -1 {
+		return nil, fmt.Errorf("Invalid PidsLimit %d: it must be positive, or -1 for no limit", hostConfig.PidsLimit)
+	}
+	if hostConfig.PidsLimit > 0 && !daemon.SystemConfig().PidsLimit {
+		warnings = append(warnings, "Your kernel does not support pids limit capabilities. Limitation discarded.")
+		hostConfig.PidsLimit = 0
+	}
+	return warnings, nil
+}
+
+// verifyStorageOpt checks that the storage driver can create the writable
+// layer of the container with the storage options of hostConfig.
+func (daemon *Daemon) verifyStorageOpt(hostConfig *runconfig.HostConfig) error {
+	if len(hostConfig.StorageOpt) == 0 {
+		return nil
+	}
+	if _, ok := daemon.driver.(graphdriver.StorageOptDriver); !ok {
+		return fmt.Errorf("--storage-opt is not supported by the %s storage driver", daemon.driver)
+	}
+	_, err := graphdriver.ParseStorageOptSize(hostConfig.StorageOpt)
+	return err
+}
+
 // Create creates a new container from the given configuration with a given name.
 func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.HostConfig, name string) (*Container, []string, error) {
 	var (
@@ -102,13 +220,18 @@ func (daemon *Daemon) Create(config *runconfig.Config, hostConfig *runconfig.Hos
 			return nil, nil, err
 		}
 	}
+	ed, err := daemon.setRuntime(hostConfig)
+	if err != nil {
+		return nil, nil, err
+	}
 	if container, err = daemon.newContainer(name, config, imgID); err != nil {
 		return nil, nil, err
 	}
+	container.ExecDriver = ed.Name()
 	if err := daemon.Register(container); err != nil {
 		return nil, nil, err
 	}
-	if err := daemon.createRootfs(container); err != nil {
+	if err := daemon.createRootfs(container, hostConfig.StorageOpt); err != nil {
 		return nil, nil, err
 	}
 	if hostConfig != nil {
@@ -144,5 +267,51 @@ func (daemon *Daemon) GenerateSecurityOpt(ipcMode runconfig.IpcMode, pidMode run
 
 		return label.DupSecOpt(c.ProcessLabel), nil
 	}
+	if pidContainer := pidMode.Container(); pidContainer != "" {
+		c, err := daemon.Get(pidContainer)
+		if err != nil {
+			return nil, err
+		}
+		if !c.IsRunning() {
+			return nil, fmt.Errorf("cannot join PID of a non running container: %s", pidContainer)
+		}
+
+		return label.DupSecOpt(c.ProcessLabel), nil
+	}
 	return nil, nil
 }
+
+// verifyLogConfig checks that the logging driver of a container supports the
+// options it is given.
+func (daemon *Daemon) verifyLogConfig(cfg runconfig.LogConfig) error {
+	cfg = daemon.logConfig(cfg)
+	if cfg.Type == "json-file" {
+		return jsonfilelog.ValidateLogOpt(cfg.Config)
+	}
+	for key := range cfg.Config {
+		return fmt.Errorf("Unknown log opt '%s' for %s log driver", key, cfg.Type)
+	}
+	return nil
+}
+
+// verifyHealthConfig checks that a health check is disabled with NONE, or
+// runs a command with CMD or CMD-SHELL.
+func verifyHealthConfig(config *runconfig.HealthConfig) error {
+	if config == nil || len(config.Test) == 0 {
+		return nil
+	}
+	switch config.Test[0] {
+	case "NONE":
+		return nil
+	case "CMD", "CMD-SHELL":
+		if len(config.Test) < 2 {
+			return fmt.Errorf("Health check %s requires a command", config.Test[0])
+		}
+	default:
+		return fmt.Errorf("Invalid health check test %q: it must start with NONE, CMD or CMD-SHELL", config.Test[0])
+	}
+	if config.Interval < 0 || config.Timeout < 0 || config.Retries < 0 {
+		return fmt.Errorf("Health check interval, timeout and retries can't be negative")
+	}
+	return nil
+}
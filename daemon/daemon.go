@@ -25,18 +25,24 @@ import (
 	"github.com/docker/docker/daemon/graphdriver"
 	_ "github.com/docker/docker/daemon/graphdriver/vfs"
 	_ "github.com/docker/docker/daemon/networkdriver/bridge"
+	"github.com/docker/docker/daemon/networkdriver/macvlan"
+	"github.com/docker/docker/daemon/networkdriver/overlay"
+	"github.com/docker/docker/daemon/networkdriver/resolver"
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/graph"
 	"github.com/docker/docker/image"
+	"github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/broadcastwriter"
 	"github.com/docker/docker/pkg/graphdb"
 	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/pkg/kvstore"
 	"github.com/docker/docker/pkg/namesgenerator"
 	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/pidfile"
 	"github.com/docker/docker/pkg/resolvconf"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/pkg/sysinfo"
 	"github.com/docker/docker/pkg/truncindex"
@@ -52,6 +58,11 @@ import (
 var (
 	validContainerNameChars   = `[a-zA-Z0-9][a-zA-Z0-9_.-]`
 	validContainerNamePattern = regexp.MustCompile(`^/?` + validContainerNameChars + `+$`)
+
+	// The chains of the daemon are named after the prefix, up to the 28
+	// characters of the names of iptables chains: the prefix, and the
+	// prefix followed by -ISOLATION.
+	validIptablesChainPrefix = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,18}$`)
 )
 
 type contStore struct {
@@ -100,47 +111,70 @@ type Daemon struct {
 	idIndex          *truncindex.TruncIndex
 	sysInfo          *sysinfo.SysInfo
 	volumes          *volumes.Repository
+	networks         *networkStore
+	clusterStore     kvstore.Store
+	overlay          *overlay.Driver
+	lan              *macvlan.Driver
 	eng              *engine.Engine
 	config           *Config
 	containerGraph   *graphdb.Database
 	driver           graphdriver.Driver
 	execDriver       execdriver.Driver
+	runtimes         map[string]execdriver.Driver
 	trustStore       *trust.TrustStore
 	statsCollector   *statsCollector
 	defaultLogConfig runconfig.LogConfig
 	RegistryService  *registry.Service
+
+	// reloadLock protects the settings that Reload changes
+	reloadLock sync.Mutex
+
+	// resolvers are the DNS servers of the networks, by network ID
+	resolvers     map[string]*resolver.Server
+	resolversLock sync.Mutex
 }
 
 // Install installs daemon capabilities to eng.
 func (daemon *Daemon) Install(eng *engine.Engine) error {
 	// FIXME: remove ImageDelete's dependency on Daemon, then move to graph/
 	for name, method := range map[string]engine.Handler{
-		"commit":            daemon.ContainerCommit,
-		"container_changes": daemon.ContainerChanges,
-		"container_copy":    daemon.ContainerCopy,
-		"container_rename":  daemon.ContainerRename,
-		"container_inspect": daemon.ContainerInspect,
-		"container_stats":   daemon.ContainerStats,
-		"containers":        daemon.Containers,
-		"create":            daemon.ContainerCreate,
-		"rm":                daemon.ContainerRm,
-		"export":            daemon.ContainerExport,
-		"info":              daemon.CmdInfo,
-		"kill":              daemon.ContainerKill,
-		"logs":              daemon.ContainerLogs,
-		"pause":             daemon.ContainerPause,
-		"resize":            daemon.ContainerResize,
-		"restart":           daemon.ContainerRestart,
-		"start":             daemon.ContainerStart,
-		"stop":              daemon.ContainerStop,
-		"top":               daemon.ContainerTop,
-		"unpause":           daemon.ContainerUnpause,
-		"wait":              daemon.ContainerWait,
-		"image_delete":      daemon.ImageDelete, // FIXME: see above
-		"execCreate":        daemon.ContainerExecCreate,
-		"execStart":         daemon.ContainerExecStart,
-		"execResize":        daemon.ContainerExecResize,
-		"execInspect":       daemon.ContainerExecInspect,
+		"commit":             daemon.ContainerCommit,
+		"container_changes":  daemon.ContainerChanges,
+		"container_copy":     daemon.ContainerCopy,
+		"container_extract":  daemon.ContainerExtract,
+		"container_rename":   daemon.ContainerRename,
+		"container_inspect":  daemon.ContainerInspect,
+		"container_stats":    daemon.ContainerStats,
+		"container_update":   daemon.ContainerUpdate,
+		"containers":         daemon.Containers,
+		"create":             daemon.ContainerCreate,
+		"rm":                 daemon.ContainerRm,
+		"export":             daemon.ContainerExport,
+		"info":               daemon.CmdInfo,
+		"kill":               daemon.ContainerKill,
+		"logs":               daemon.ContainerLogs,
+		"pause":              daemon.ContainerPause,
+		"resize":             daemon.ContainerResize,
+		"restart":            daemon.ContainerRestart,
+		"start":              daemon.ContainerStart,
+		"stop":               daemon.ContainerStop,
+		"system_df":          daemon.SystemDiskUsage,
+		"system_prune":       daemon.SystemPrune,
+		"system_bench":       daemon.SystemBench,
+		"top":                daemon.ContainerTop,
+		"unpause":            daemon.ContainerUnpause,
+		"wait":               daemon.ContainerWait,
+		"image_delete":       daemon.ImageDelete, // FIXME: see above
+		"network_create":     daemon.NetworkCreate,
+		"networks":           daemon.Networks,
+		"network_inspect":    daemon.NetworkInspect,
+		"network_rm":         daemon.NetworkRm,
+		"network_connect":    daemon.NetworkConnect,
+		"network_disconnect": daemon.NetworkDisconnect,
+		"execCreate":         daemon.ContainerExecCreate,
+		"execStart":          daemon.ContainerExecStart,
+		"execResize":         daemon.ContainerExecResize,
+		"execInspect":        daemon.ContainerExecInspect,
 	} {
 		if err := eng.Register(name, method); err != nil {
 			return err
@@ -160,11 +194,11 @@ func (daemon *Daemon) Install(eng *engine.Engine) error {
 
 // Get looks for a container using the provided information, which could be
 // one of the following inputs from the caller:
-//  - A full container ID, which will exact match a container in daemon's list
-//  - A container name, which will only exact match via the GetByName() function
-//  - A partial container ID prefix (e.g. short ID) of any length that is
-//    unique enough to only return a single container object
-//  If none of these searches succeed, an error is returned
+//   - A full container ID, which will exact match a container in daemon's list
+//   - A container name, which will only exact match via the GetByName() function
+//   - A partial container ID prefix (e.g. short ID) of any length that is
+//     unique enough to only return a single container object
+//     If none of these searches succeed, an error is returned
 func (daemon *Daemon) Get(prefixOrName string) (*Container, error) {
 	if containerByID := daemon.containers.Get(prefixOrName); containerByID != nil {
 		// prefix is an exact match to a full container ID
@@ -259,47 +293,58 @@ func (daemon *Daemon) register(container *Container, updateSuffixarray bool) err
 
 	// FIXME: if the container is supposed to be running but is not, auto restart it?
 	//        if so, then we need to restart monitor and init a new lock
-	// If the container is supposed to be running, make sure of it
-	if container.IsRunning() {
-		logrus.Debugf("killing old running container %s", container.ID)
+	// If the container is supposed to be running, make sure of it, unless
+	// restore re-attaches to it
+	if container.IsRunning() && !(container.canLiveRestore() && container.hasOutputFifos()) {
+		return daemon.killStaleContainer(container)
+	}
+	return nil
+}
 
-		existingPid := container.Pid
-		container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
+// killStaleContainer kills the process of a container marked as running by a
+// previous daemon, and marks it as stopped.
+func (daemon *Daemon) killStaleContainer(container *Container) error {
+	logrus.Debugf("killing old running container %s", container.ID)
 
-		// We only have to handle this for lxc because the other drivers will ensure that
-		// no processes are left when docker dies
-		if container.ExecDriver == "" || strings.Contains(container.ExecDriver, "lxc") {
-			lxc.KillLxc(container.ID, 9)
-		} else {
-			// use the current driver and ensure that the container is dead x.x
-			cmd := &execdriver.Command{
-				ID: container.ID,
-			}
-			var err error
-			cmd.ProcessConfig.Process, err = os.FindProcess(existingPid)
-			if err != nil {
-				logrus.Debugf("cannot find existing process for %d", existingPid)
-			}
-			daemon.execDriver.Terminate(cmd)
-		}
+	existingPid := container.Pid
+	container.SetStopped(&execdriver.ExitStatus{ExitCode: 0})
 
-		if err := container.Unmount(); err != nil {
-			logrus.Debugf("unmount error %s", err)
+	ed, err := container.execDriver()
+	if err != nil {
+		logrus.Debugf("%s", err)
+	}
+	// lxc containers are killed by name, the native driver finds the process
+	// of the container from the state it saved
+	if container.ExecDriver == "" || strings.Contains(container.ExecDriver, "lxc") {
+		lxc.KillLxc(container.ID, 9)
+	} else if ed != nil {
+		// use the driver of the container and ensure that it is dead x.x
+		cmd := &execdriver.Command{
+			ID: container.ID,
 		}
-		if err := container.ToDisk(); err != nil {
-			logrus.Debugf("saving stopped state to disk %s", err)
+		var err error
+		cmd.ProcessConfig.Process, err = os.FindProcess(existingPid)
+		if err != nil {
+			logrus.Debugf("cannot find existing process for %d", existingPid)
 		}
+		ed.Terminate(cmd)
+	}
 
-		info := daemon.execDriver.Info(container.ID)
-		if !info.IsRunning() {
-			logrus.Debugf("Container %s was supposed to be running but is not.", container.ID)
+	if err := container.Unmount(); err != nil {
+		logrus.Debugf("unmount error %s", err)
+	}
+	if err := container.ToDisk(); err != nil {
+		logrus.Debugf("saving stopped state to disk %s", err)
+	}
 
-			logrus.Debug("Marking as stopped")
+	if ed != nil && !ed.Info(container.ID).IsRunning() {
+		logrus.Debugf("Container %s was supposed to be running but is not.", container.ID)
 
-			container.SetStopped(&execdriver.ExitStatus{ExitCode: -127})
-			if err := container.ToDisk(); err != nil {
-				return err
-			}
+		logrus.Debug("Marking as stopped")
+
+		container.SetStopped(&execdriver.ExitStatus{ExitCode: -127})
+		if err := container.ToDisk(); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -394,23 +439,36 @@ func (daemon *Daemon) restore() error {
 		registeredContainers = append(registeredContainers, container)
 	}
 
-	// check the restart policy on the containers and restart any container with
-	// the restart policy of "always"
-	if daemon.config.AutoRestart {
-		logrus.Debug("Restarting containers...")
-
-		for _, container := range registeredContainers {
-			if container.hostConfig.RestartPolicy.Name == "always" ||
-				(container.hostConfig.RestartPolicy.Name == "on-failure" && container.ExitCode != 0) {
-				logrus.Debugf("Starting container %s", container.ID)
-
-				if err := container.Start(); err != nil {
-					logrus.Debugf("Failed to start container %s: %s", container.ID, err)
-				}
+	// re-attach to the containers left running by a daemon with live restore
+	// enabled, register only kept them running if they can be restored
+	for _, container := range registeredContainers {
+		if !container.IsRunning() {
+			continue
+		}
+		logrus.Debugf("Restoring container %s", container.ID)
+		if err := container.restoreRunning(); err != nil {
+			logrus.Errorf("Failed to restore container %s: %s", container.ID, err)
+			if err := daemon.killStaleContainer(container); err != nil {
+				logrus.Debugf("Failed to stop container %s: %s", container.ID, err)
 			}
 		}
 	}
 
+	// remove the containers run with --rm that exited while the daemon was down
+	for _, container := range registeredContainers {
+		if container.hostConfig.AutoRemove && !container.IsRunning() && !container.StartedAt.IsZero() {
+			logrus.Debugf("Removing container %s", container.ID)
+			daemon.autoRemove(container)
+		}
+	}
+
+	// check the restart policy on the containers and restart any container with
+	// the restart policy of "always", or "unless-stopped" if it wasn't stopped
+	// by the user
+	if daemon.config.AutoRestart {
+		daemon.autoRestart(registeredContainers)
+	}
+
 	if !debug {
 		if logrus.GetLevel() == logrus.InfoLevel {
 			fmt.Println()
@@ -502,6 +560,11 @@ func (daemon *Daemon) mergeAndVerifyConfig(config *runconfig.Config, img *image.
 	if len(config.Entrypoint) == 0 && len(config.Cmd) == 0 {
 		return nil, fmt.Errorf("No command specified")
 	}
+	if config.StopSignal != "" {
+		if _, err := signal.ParseSignal(config.StopSignal); err != nil {
+			return nil, err
+		}
+	}
 	return warnings, nil
 }
 
@@ -656,7 +719,6 @@ func (daemon *Daemon) newContainer(name string, config *runconfig.Config, imgID
 		NetworkSettings: &NetworkSettings{},
 		Name:            name,
 		Driver:          daemon.driver.String(),
-		ExecDriver:      daemon.execDriver.Name(),
 		State:           NewState(),
 		execCommands:    newExecStore(),
 	}
@@ -664,7 +726,9 @@ func (daemon *Daemon) newContainer(name string, config *runconfig.Config, imgID
 	return container, err
 }
 
-func (daemon *Daemon) createRootfs(container *Container) error {
+// createRootfs creates the init layer of container and its writable layer,
+// with the options of storageOpt if there are any.
+func (daemon *Daemon) createRootfs(container *Container, storageOpt map[string]string) error {
 	// Step 1: create the container directory.
 	// This doubles as a barrier to avoid race conditions.
 	if err := os.Mkdir(container.root, 0700); err != nil {
@@ -684,6 +748,13 @@ func (daemon *Daemon) createRootfs(container *Container) error {
 		return err
 	}
 
+	if len(storageOpt) > 0 {
+		driver, ok := daemon.driver.(graphdriver.StorageOptDriver)
+		if !ok {
+			return fmt.Errorf("--storage-opt is not supported by the %s storage driver", daemon.driver)
+		}
+		return driver.CreateWithStorageOpt(container.ID, initID, storageOpt)
+	}
 	if err := daemon.driver.Create(container.ID, initID); err != nil {
 		return err
 	}
@@ -816,6 +887,26 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 	if !config.EnableIptables && config.EnableIpMasq {
 		config.EnableIpMasq = false
 	}
+	if !validIptablesChainPrefix.MatchString(config.IptablesChainPrefix) {
+		return nil, fmt.Errorf("Invalid --iptables-chain-prefix %q, it must be at most 18 letters, digits, '-' or '_'", config.IptablesChainPrefix)
+	}
+	if config.PublishedPortRange != "" {
+		if start, _, err := parsers.ParsePortRange(config.PublishedPortRange); err != nil || start == 0 {
+			return nil, fmt.Errorf("Invalid --published-port-range %q, it must be a range of ports such as 40000-49999", config.PublishedPortRange)
+		}
+	}
+	if _, err := opts.ValidateMACAddress(config.MacAddressPrefix + ":00:00:00:01"); err != nil {
+		return nil, fmt.Errorf("Invalid --mac-address-prefix %q, it must be the first 2 bytes of a unicast MAC address, such as 02:42", config.MacAddressPrefix)
+	}
+	if config.MacAddressMode != "ip" && config.MacAddressMode != "name" {
+		return nil, fmt.Errorf("Invalid --mac-address-mode %q, it must be 'ip' or 'name'", config.MacAddressMode)
+	}
+	if err := validateGCWatermarks(config.GCHighWatermark, config.GCLowWatermark); err != nil {
+		return nil, err
+	}
+	if config.GCGracePeriod < 0 {
+		return nil, fmt.Errorf("Invalid --gc-grace-period %s, it can't be negative", config.GCGracePeriod)
+	}
 	config.DisableNetwork = config.BridgeIface == disableNetworkBridge
 
 	// Claim the pidfile first, to avoid any and all unexpected race conditions.
@@ -910,6 +1001,12 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		return nil, err
 	}
 
+	if config.GraphMigrateFrom != "" {
+		if err := migrateGraphDriver(config.Root, config.GraphMigrateFrom, driver); err != nil {
+			return nil, err
+		}
+	}
+
 	logrus.Debug("Creating images graph")
 	g, err := graph.NewGraph(path.Join(config.Root, "graph"), driver)
 	if err != nil {
@@ -936,6 +1033,11 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 	if err != nil {
 		return nil, fmt.Errorf("Couldn't create Tag store: %s", err)
 	}
+	if config.MigrateImageIDs {
+		if err := repositories.MigrateImageIDs(); err != nil {
+			return nil, fmt.Errorf("Couldn't migrate the IDs of the images: %s", err)
+		}
+	}
 
 	trustDir := path.Join(config.Root, "trust")
 	if err := os.MkdirAll(trustDir, 0700); err != nil && !os.IsExist(err) {
@@ -946,10 +1048,17 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		return nil, fmt.Errorf("could not create trust store: %s", err)
 	}
 
+	// The settings of the default bridge network, nil when networking is
+	// disabled.
+	var bridgeNetwork *engine.Env
 	if !config.DisableNetwork {
 		job := eng.Job("init_networkdriver")
 
 		job.SetenvBool("EnableIptables", config.EnableIptables)
+		job.Setenv("IptablesChainPrefix", config.IptablesChainPrefix)
+		job.Setenv("PublishedPortRange", config.PublishedPortRange)
+		job.Setenv("MacAddressPrefix", config.MacAddressPrefix)
+		job.Setenv("MacAddressMode", config.MacAddressMode)
 		job.SetenvBool("InterContainerCommunication", config.InterContainerCommunication)
 		job.SetenvBool("EnableIpForward", config.EnableIpForward)
 		job.SetenvBool("EnableIpMasq", config.EnableIpMasq)
@@ -960,11 +1069,19 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		job.Setenv("FixedCIDRv6", config.FixedCIDRv6)
 		job.Setenv("DefaultBindingIP", config.DefaultIp.String())
 
+		if bridgeNetwork, err = job.Stdout.AddEnv(); err != nil {
+			return nil, err
+		}
 		if err := job.Run(); err != nil {
 			return nil, err
 		}
 	}
 
+	networks, err := newNetworkStore(filepath.Join(config.Root, "networks"))
+	if err != nil {
+		return nil, err
+	}
+
 	graphdbPath := path.Join(config.Root, "linkgraph.db")
 	graph, err := graphdb.NewSqliteConn(graphdbPath)
 	if err != nil {
@@ -991,18 +1108,30 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		if _, err := utils.CopyFile(sysInitPath, localCopy); err != nil {
 			return nil, err
 		}
-		if err := os.Chmod(localCopy, 0700); err != nil {
+		// Containers running as any user may run it as their init.
+		if err := os.Chmod(localCopy, 0755); err != nil {
 			return nil, err
 		}
 		sysInitPath = localCopy
 	}
 
 	sysInfo := sysinfo.New(false)
+	if config.PidsLimit < -1 {
+		return nil, fmt.Errorf("Invalid --pids-limit %d: it must be positive, or -1 for no limit", config.PidsLimit)
+	}
+	if config.PidsLimit > 0 && !sysInfo.PidsLimit {
+		logrus.Warnf("Your kernel does not support pids limit capabilities. Default limitation discarded.")
+		config.PidsLimit = 0
+	}
 	const runDir = "/var/run/docker"
 	ed, err := execdrivers.NewDriver(config.ExecDriver, runDir, config.Root, sysInitPath, sysInfo)
 	if err != nil {
 		return nil, err
 	}
+	runtimes, err := newRuntimes(config, runDir)
+	if err != nil {
+		return nil, err
+	}
 
 	daemon := &Daemon{
 		ID:               trustKey.PublicKey().KeyID(),
@@ -1014,11 +1143,14 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		idIndex:          truncindex.NewTruncIndex([]string{}),
 		sysInfo:          sysInfo,
 		volumes:          volumes,
+		networks:         networks,
+		lan:              macvlan.New(),
 		config:           config,
 		containerGraph:   graph,
 		driver:           driver,
 		sysInitPath:      sysInitPath,
 		execDriver:       ed,
+		runtimes:         runtimes,
 		eng:              eng,
 		trustStore:       t,
 		statsCollector:   newStatsCollector(1 * time.Second),
@@ -1026,12 +1158,24 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		RegistryService:  registryService,
 	}
 
+	if err := daemon.verifyLogConfig(config.LogConfig); err != nil {
+		return nil, err
+	}
+
 	eng.OnShutdown(func() {
 		if err := daemon.shutdown(); err != nil {
 			logrus.Errorf("Error during daemon.shutdown(): %v", err)
 		}
 	})
 
+	if err := daemon.initCluster(); err != nil {
+		return nil, err
+	}
+
+	if err := daemon.initNetworks(bridgeNetwork); err != nil {
+		return nil, err
+	}
+
 	if err := daemon.restore(); err != nil {
 		return nil, err
 	}
@@ -1041,6 +1185,10 @@ func NewDaemonFromDirectory(config *Config, eng *engine.Engine, registryService
 		return nil, err
 	}
 
+	if config.GCHighWatermark > 0 {
+		go daemon.gcLoop()
+	}
+
 	return daemon, nil
 }
 
@@ -1050,13 +1198,18 @@ func (daemon *Daemon) shutdown() error {
 	for _, container := range daemon.List() {
 		c := container
 		if c.IsRunning() {
+			if c.canLiveRestore() {
+				logrus.Debugf("leaving %s running", c.ID)
+				continue
+			}
 			logrus.Debugf("stopping %s", c.ID)
 			group.Add(1)
 
 			go func() {
 				defer group.Done()
-				if err := c.KillSig(15); err != nil {
-					logrus.Debugf("kill 15 error for %s - %s", c.ID, err)
+				sig := c.stopSignal()
+				if err := c.KillSig(sig); err != nil {
+					logrus.Debugf("kill %d error for %s - %s", sig, c.ID, err)
 				}
 				c.WaitStop(-1 * time.Second)
 				logrus.Debugf("container stopped %s", c.ID)
@@ -1099,31 +1252,59 @@ func (daemon *Daemon) Diff(container *Container) (archive.Archive, error) {
 }
 
 func (daemon *Daemon) Run(c *Container, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
-	return daemon.execDriver.Run(c.command, pipes, startCallback)
+	ed, err := c.execDriver()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	return ed.Run(c.command, pipes, startCallback)
 }
 
 func (daemon *Daemon) Pause(c *Container) error {
-	if err := daemon.execDriver.Pause(c.command); err != nil {
+	ed, err := c.execDriver()
+	if err != nil {
+		return err
+	}
+	if err := ed.Pause(c.command); err != nil {
 		return err
 	}
 	c.SetPaused()
+	// a daemon restoring the container needs to know it
+	if err := c.ToDisk(); err != nil {
+		logrus.Debugf("%s", err)
+	}
 	return nil
 }
 
 func (daemon *Daemon) Unpause(c *Container) error {
-	if err := daemon.execDriver.Unpause(c.command); err != nil {
+	ed, err := c.execDriver()
+	if err != nil {
+		return err
+	}
+	if err := ed.Unpause(c.command); err != nil {
 		return err
 	}
 	c.SetUnpaused()
+	// a daemon restoring the container needs to know it
+	if err := c.ToDisk(); err != nil {
+		logrus.Debugf("%s", err)
+	}
 	return nil
 }
 
 func (daemon *Daemon) Kill(c *Container, sig int) error {
-	return daemon.execDriver.Kill(c.command, sig)
+	ed, err := c.execDriver()
+	if err != nil {
+		return err
+	}
+	return ed.Kill(c.command, sig)
 }
 
 func (daemon *Daemon) Stats(c *Container) (*execdriver.ResourceStats, error) {
-	return daemon.execDriver.Stats(c.ID)
+	ed, err := c.execDriver()
+	if err != nil {
+		return nil, err
+	}
+	return ed.Stats(c.ID)
 }
 
 func (daemon *Daemon) SubscribeToContainerStats(name string) (chan interface{}, error) {
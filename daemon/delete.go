@@ -81,6 +81,34 @@ func (daemon *Daemon) ContainerRm(job *engine.Job) error {
 	return nil
 }
 
+// autoRemove removes container and its volumes once it has exited, for
+// containers run with --rm, unless it was started again since, or is being
+// restarted.
+func (daemon *Daemon) autoRemove(container *Container) {
+	// Checked along with marking the removal, which keeps the container from
+	// being started again
+	container.Lock()
+	if container.Running || container.Restarting || container.restartInProgress || container.removalInProgress || container.Dead {
+		container.Unlock()
+		logrus.Debugf("Not removing container %s, which is running or being restarted or removed", container.ID)
+		return
+	}
+	container.removalInProgress = true
+	container.Unlock()
+	defer container.ResetRemovalInProgress()
+
+	daemon.statsCollector.stopCollection(container)
+	if daemon.containers.Get(container.ID) == nil {
+		return
+	}
+	if err := daemon.rm(container, false); err != nil {
+		logrus.Errorf("Cannot destroy container %s: %v", container.ID, err)
+		return
+	}
+	container.LogEvent("destroy")
+	daemon.DeleteVolumes(container.VolumePaths())
+}
+
 func (daemon *Daemon) DeleteVolumes(volumeIDs map[string]struct{}) {
 	for id := range volumeIDs {
 		if err := daemon.volumes.Delete(id); err != nil {
@@ -116,6 +144,11 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 
 	defer container.ResetRemovalInProgress()
 
+	return daemon.rm(container, forceRemove)
+}
+
+// rm removes container, which is marked as being removed.
+func (daemon *Daemon) rm(container *Container, forceRemove bool) (err error) {
 	if err = container.Stop(3); err != nil {
 		return err
 	}
@@ -134,6 +167,7 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 		if err != nil && forceRemove {
 			daemon.idIndex.Delete(container.ID)
 			daemon.containers.Delete(container.ID)
+			container.SetRemoved()
 		}
 	}()
 
@@ -155,13 +189,17 @@ func (daemon *Daemon) commonRm(container *Container, forceRemove bool) (err erro
 		return fmt.Errorf("Unable to remove filesystem for %v: %v", container.ID, err)
 	}
 
-	if err = daemon.execDriver.Clean(container.ID); err != nil {
-		return fmt.Errorf("Unable to remove execdriver data for %s: %s", container.ID, err)
+	// the data of a runtime the daemon no longer has is left behind
+	if ed, err := container.execDriver(); err == nil {
+		if err := ed.Clean(container.ID); err != nil {
+			return fmt.Errorf("Unable to remove execdriver data for %s: %s", container.ID, err)
+		}
 	}
 
 	selinuxFreeLxcContexts(container.ProcessLabel)
 	daemon.idIndex.Delete(container.ID)
 	daemon.containers.Delete(container.ID)
+	container.SetRemoved()
 
 	return nil
 }
@@ -0,0 +1,21 @@
+package daemon
+
+import "testing"
+
+func TestAutoRemoveStartedAgain(t *testing.T) {
+	// The daemon would fail to remove the containers if it tried
+	daemon := &Daemon{}
+	for name, startedAgain := range map[string]func(*Container){
+		"running":    func(c *Container) { c.Running = true },
+		"restarting": func(c *Container) { c.Running, c.Restarting = true, true },
+		"restart":    func(c *Container) { c.restartInProgress = true },
+		"removed":    func(c *Container) { c.Dead = true },
+	} {
+		container := &Container{ID: "foo", State: NewState()}
+		startedAgain(container)
+		daemon.autoRemove(container)
+		if container.removalInProgress {
+			t.Fatalf("Expected the %s container not to be removed", name)
+		}
+	}
+}
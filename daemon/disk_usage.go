@@ -0,0 +1,142 @@
+package daemon
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/pkg/directory"
+)
+
+type imagesByCreated []types.ImageDiskUsage
+
+func (r imagesByCreated) Len() int           { return len(r) }
+func (r imagesByCreated) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r imagesByCreated) Less(i, j int) bool { return r[i].Created < r[j].Created }
+
+// sizeLimit returns the limit of the size of the root filesystem of
+// container, which the drivers accounting for the space of their layers
+// tell, and the other ones take from its storage options.
+func (daemon *Daemon) sizeLimit(container *Container) int64 {
+	if driver, ok := daemon.driver.(graphdriver.UsageDriver); ok {
+		if _, limit, err := driver.Usage(container.ID); err == nil {
+			return limit
+		}
+	}
+	if container.hostConfig == nil {
+		return 0
+	}
+	size, err := graphdriver.ParseStorageOptSize(container.hostConfig.StorageOpt)
+	if err != nil {
+		return 0
+	}
+	return int64(size)
+}
+
+// SystemDiskUsage reports the space used by the images, the writable layers
+// of the containers and the volumes of the daemon.
+func (daemon *Daemon) SystemDiskUsage(job *engine.Job) error {
+	images, err := daemon.Graph().Map()
+	if err != nil {
+		return err
+	}
+	heads, err := daemon.Graph().Heads()
+	if err != nil {
+		return err
+	}
+	refs := daemon.Repositories().ByID()
+
+	du := &types.DiskUsage{
+		Images:     []types.ImageDiskUsage{},
+		Containers: []types.ContainerDiskUsage{},
+		Volumes:    []types.VolumeDiskUsage{},
+	}
+	for _, img := range images {
+		du.LayersSize += img.Size
+	}
+
+	containersByImage := make(map[string]int)
+	for _, container := range daemon.List() {
+		containersByImage[container.ImageID]++
+		sizeRw, sizeRootFs := container.GetSize()
+		du.Containers = append(du.Containers, types.ContainerDiskUsage{
+			ID:         container.ID,
+			Names:      []string{container.Name},
+			Image:      container.Config.Image,
+			Status:     container.State.String(),
+			Running:    container.IsRunning(),
+			SizeRw:     sizeRw,
+			SizeRootFs: sizeRootFs,
+			SizeLimit:  daemon.sizeLimit(container),
+		})
+	}
+
+	// The images are the ones docker images lists, the tagged ones and the
+	// heads; a layer is shared when more than one of them is built on it.
+	var (
+		listed = make(map[string][]*image.Image)
+		users  = make(map[string]int)
+	)
+	for id, img := range images {
+		_, tagged := refs[id]
+		if _, head := heads[id]; !tagged && !head {
+			continue
+		}
+		for layer := img; layer != nil; layer = images[layer.Parent] {
+			listed[id] = append(listed[id], layer)
+			users[layer.ID]++
+		}
+	}
+	for id, layers := range listed {
+		img := images[id]
+		usage := types.ImageDiskUsage{
+			ID:         id,
+			RepoTags:   []string{},
+			Created:    int(img.Created.Unix()),
+			Containers: containersByImage[id],
+		}
+		for _, ref := range refs[id] {
+			if !strings.Contains(ref, "@") {
+				usage.RepoTags = append(usage.RepoTags, ref)
+			}
+		}
+		if len(usage.RepoTags) == 0 {
+			usage.RepoTags = append(usage.RepoTags, "<none>:<none>")
+		}
+		for _, layer := range layers {
+			usage.VirtualSize += layer.Size
+			if users[layer.ID] > 1 {
+				usage.SharedSize += layer.Size
+			} else {
+				usage.UniqueSize += layer.Size
+			}
+		}
+		du.Images = append(du.Images, usage)
+	}
+	sort.Sort(sort.Reverse(imagesByCreated(du.Images)))
+
+	// Bind mounts are not counted, their data doesn't live in the daemon's
+	// root and isn't the daemon's to reclaim.
+	for _, v := range daemon.volumes.List() {
+		if v.IsBindMount {
+			continue
+		}
+		size, err := directory.Size(v.Path)
+		if err != nil {
+			logrus.Errorf("Failed to compute size of volume %s: %s", v.ID, err)
+		}
+		du.Volumes = append(du.Volumes, types.VolumeDiskUsage{
+			ID:         v.ID,
+			Path:       v.Path,
+			Size:       size,
+			Containers: len(v.Containers()),
+		})
+	}
+
+	return json.NewEncoder(job.Stdout).Encode(du)
+}
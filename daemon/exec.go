@@ -15,7 +15,9 @@ import (
 	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/pkg/term"
 	"github.com/docker/docker/runconfig"
+	"github.com/docker/docker/utils"
 )
 
 type execConfig struct {
@@ -23,6 +25,8 @@ type execConfig struct {
 	ID            string
 	Running       bool
 	ExitCode      int
+	Pid           int    // host pid of the command while it runs
+	DetachKeys    []byte // keys detaching the client from the command
 	ProcessConfig execdriver.ProcessConfig
 	StreamConfig
 	OpenStdin  bool
@@ -116,10 +120,6 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) error {
 		return fmt.Errorf("Usage: %s [options] container command [args]", job.Name)
 	}
 
-	if strings.HasPrefix(d.execDriver.Name(), lxc.DriverName) {
-		return lxc.ErrExec
-	}
-
 	var name = job.Args[0]
 
 	container, err := d.getActiveContainer(name)
@@ -127,18 +127,36 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) error {
 		return err
 	}
 
+	ed, err := container.execDriver()
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(ed.Name(), lxc.DriverName) {
+		return lxc.ErrExec
+	}
+
 	config, err := runconfig.ExecConfigFromJob(job)
 	if err != nil {
 		return err
 	}
 
+	detachKeys, err := term.ToBytes(config.DetachKeys)
+	if err != nil {
+		return fmt.Errorf("Bad parameter: %v", err)
+	}
+
 	entrypoint, args := d.getEntrypointAndArgs(nil, config.Cmd)
 
 	processConfig := execdriver.ProcessConfig{
 		Tty:        config.Tty,
+		User:       config.User,
 		Entrypoint: entrypoint,
 		Arguments:  args,
 	}
+	if len(config.Env) > 0 {
+		// The variables given override those of the container.
+		processConfig.Env = utils.ReplaceOrAppendEnvValues(container.command.ProcessConfig.Env, config.Env)
+	}
 
 	execConfig := &execConfig{
 		ID:            stringid.GenerateRandomID(),
@@ -149,6 +167,7 @@ func (d *Daemon) ContainerExecCreate(job *engine.Job) error {
 		ProcessConfig: processConfig,
 		Container:     container,
 		Running:       false,
+		DetachKeys:    detachKeys,
 	}
 
 	container.LogEvent("exec_create: " + execConfig.ProcessConfig.Entrypoint + " " + strings.Join(execConfig.ProcessConfig.Arguments, " "))
@@ -218,7 +237,7 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) error {
 		execConfig.StreamConfig.stdinPipe = ioutils.NopWriteCloser(ioutil.Discard) // Silently drop stdin
 	}
 
-	attachErr := attach(&execConfig.StreamConfig, execConfig.OpenStdin, true, execConfig.ProcessConfig.Tty, cStdin, cStdout, cStderr)
+	attachErr := attach(&execConfig.StreamConfig, execConfig.OpenStdin, true, execConfig.ProcessConfig.Tty, cStdin, cStdout, cStderr, execConfig.DetachKeys)
 
 	execErr := make(chan error)
 
@@ -247,15 +266,22 @@ func (d *Daemon) ContainerExecStart(job *engine.Job) error {
 }
 
 func (d *Daemon) Exec(c *Container, execConfig *execConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
-	exitStatus, err := d.execDriver.Exec(c.command, &execConfig.ProcessConfig, pipes, startCallback)
+	ed, err := c.execDriver()
+	if err != nil {
+		return 128, err
+	}
+	exitStatus, err := ed.Exec(c.command, &execConfig.ProcessConfig, pipes, startCallback)
 
 	// On err, make sure we don't leave ExitCode at zero
 	if err != nil && exitStatus == 0 {
 		exitStatus = 128
 	}
 
+	execConfig.Lock()
 	execConfig.ExitCode = exitStatus
 	execConfig.Running = false
+	execConfig.Pid = 0
+	execConfig.Unlock()
 
 	return exitStatus, err
 }
@@ -279,6 +305,9 @@ func (container *Container) Exec(execConfig *execConfig) error {
 				c.Close()
 			}
 		}
+		execConfig.Lock()
+		execConfig.Pid = pid
+		execConfig.Unlock()
 		close(waitStart)
 	}
 
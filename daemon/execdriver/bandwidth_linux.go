@@ -0,0 +1,171 @@
+// +build linux
+
+package execdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/libcontainer/system"
+)
+
+// minBurst is the least burst of the token bucket filters of the interfaces,
+// which must hold a few frames of the largest MTU.
+const minBurst = 32 * 1024
+
+// LimitBandwidth limits the bandwidth of every interface of the network
+// namespace of pid, the process of a running container, to b. The traffic
+// the container sends is shaped on its interfaces, the traffic it receives
+// on the peers of its veths on the host: the macvlan and ipvlan interfaces
+// have none, their ingress isn't limited. It requires tc, of iproute2.
+func LimitBandwidth(pid int, b *Bandwidth) error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return runNetworkSetup("shape", strconv.Itoa(pid), string(data))
+}
+
+// limitBandwidth is LimitBandwidth, for a process of its own since it joins
+// the network namespace of pid.
+func limitBandwidth(pid int, b *Bandwidth) error {
+	runtime.LockOSThread()
+	host, err := os.Open(fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid()))
+	if err != nil {
+		return err
+	}
+	defer host.Close()
+	ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	if err := joinNetworkNamespace(ns); err != nil {
+		return err
+	}
+
+	links, err := listLinks()
+	if err != nil {
+		return err
+	}
+	var peers []int
+	for _, l := range links {
+		if l.flags&syscall.IFF_LOOPBACK != 0 {
+			continue
+		}
+		if b.Egress != 0 {
+			if err := shapeInterface(l.name, b.Egress); err != nil {
+				return err
+			}
+		}
+		if l.kind == "veth" && l.peer != 0 {
+			peers = append(peers, l.peer)
+		}
+	}
+	if b.Ingress == 0 {
+		return nil
+	}
+
+	// The traffic the container receives leaves the host by the peers.
+	if err := system.Setns(host.Fd(), syscall.CLONE_NEWNET); err != nil {
+		return err
+	}
+	for _, index := range peers {
+		peer, err := net.InterfaceByIndex(index)
+		if err != nil {
+			return err
+		}
+		if err := shapeInterface(peer.Name, b.Ingress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shapeInterface limits the rate of the traffic name sends to rate, in
+// bytes per second, with a token bucket filter whose burst is 20ms of
+// traffic. It replaces the one the interface had.
+func shapeInterface(name string, rate int64) error {
+	burst := rate / 50
+	if burst < minBurst {
+		burst = minBurst
+	}
+	args := []string{"qdisc", "replace", "dev", name, "root", "tbf",
+		"rate", fmt.Sprintf("%dbit", rate*8), "burst", strconv.FormatInt(burst, 10), "latency", "50ms"}
+	if output, err := exec.Command("tc", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Unable to limit the bandwidth of %s: %s (%v)", name, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// link is an interface of the network namespace of the calling thread:
+// its kind, as veth or macvlan, and the index of its peer, or parent, in
+// another namespace.
+type link struct {
+	name  string
+	flags uint32
+	kind  string
+	peer  int
+}
+
+// listLinks returns the interfaces of the network namespace of the calling
+// thread, which the net package doesn't know the kind and peers of.
+func listLinks() ([]link, error) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	var links []link
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWLINK {
+			continue
+		}
+		info := (*syscall.IfInfomsg)(unsafe.Pointer(&m.Data[0]))
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, err
+		}
+		l := link{flags: info.Flags}
+		for _, attr := range attrs {
+			switch attr.Attr.Type {
+			case syscall.IFLA_IFNAME:
+				l.name = strings.TrimRight(string(attr.Value), "\x00")
+			case syscall.IFLA_LINK:
+				l.peer = int(*(*uint32)(unsafe.Pointer(&attr.Value[0])))
+			case syscall.IFLA_LINKINFO:
+				l.kind = linkKind(attr.Value)
+			}
+		}
+		links = append(links, l)
+	}
+	return links, nil
+}
+
+// linkKind returns the kind of the nested attributes info, the
+// IFLA_LINKINFO of a link.
+func linkKind(info []byte) string {
+	for len(info) >= syscall.SizeofRtAttr {
+		length := int(*(*uint16)(unsafe.Pointer(&info[0])))
+		typ := *(*uint16)(unsafe.Pointer(&info[2]))
+		if length < syscall.SizeofRtAttr || length > len(info) {
+			break
+		}
+		if typ == iflaInfoKind {
+			return strings.TrimRight(string(info[syscall.SizeofRtAttr:length]), "\x00")
+		}
+		info = info[(length+syscall.RTA_ALIGNTO-1) & ^(syscall.RTA_ALIGNTO-1):]
+	}
+	return ""
+}
@@ -57,15 +57,23 @@ type ExitStatus struct {
 
 	// Whether the container encountered an OOM.
 	OOMKilled bool
+
+	// Whether the exit code of the container couldn't be collected, its
+	// process not being a child of the daemon. ExitCode is -1 then.
+	ExitCodeUnknown bool
 }
 
 type Driver interface {
 	Run(c *Command, pipes *Pipes, startCallback StartCallback) (ExitStatus, error) // Run executes the process and blocks until the process exits and returns the exit code
+	// Restore re-attaches to the process of a container that a previous daemon
+	// left running, blocks until the process exits and returns its exit status
+	Restore(c *Command, startCallback StartCallback) (ExitStatus, error)
 	// Exec executes the process in an existing container, blocks until the process exits and returns the exit code
 	Exec(c *Command, processConfig *ProcessConfig, pipes *Pipes, startCallback StartCallback) (int, error)
 	Kill(c *Command, sig int) error
 	Pause(c *Command) error
 	Unpause(c *Command) error
+	Update(c *Command) error                      // Update applies the resources of the command to the running container
 	Name() string                                 // Driver name
 	Info(id string) Info                          // "temporary" hack (until we move state from core to plugins)
 	GetPidsForContainer(id string) ([]int, error) // Returns a list of pids for the given container.
@@ -76,10 +84,11 @@ type Driver interface {
 
 // Network settings of the container
 type Network struct {
-	Interface      *NetworkInterface `json:"interface"` // if interface is nil then networking is disabled
-	Mtu            int               `json:"mtu"`
-	ContainerID    string            `json:"container_id"` // id of the container to join network.
-	HostNetworking bool              `json:"host_networking"`
+	Interface      *NetworkInterface   `json:"interface"`  // if interface is nil then networking is disabled
+	Interfaces     []*NetworkInterface `json:"interfaces"` // interfaces of the other networks, eth1 and on
+	Mtu            int                 `json:"mtu"`
+	ContainerID    string              `json:"container_id"` // id of the container to join network.
+	HostNetworking bool                `json:"host_networking"`
 }
 
 // IPC settings of the container
@@ -90,7 +99,8 @@ type Ipc struct {
 
 // PID settings of the container
 type Pid struct {
-	HostPid bool `json:"host_pid"`
+	ContainerID string `json:"container_id"` // id of the container to join pid.
+	HostPid     bool   `json:"host_pid"`
 }
 
 type NetworkInterface struct {
@@ -103,14 +113,37 @@ type NetworkInterface struct {
 	LinkLocalIPv6Address string `json:"link_local_ipv6"`
 	GlobalIPv6PrefixLen  int    `json:"global_ipv6_prefix_len"`
 	IPv6Gateway          string `json:"ipv6_gateway"`
+	Mtu                  int    `json:"mtu,omitempty"` // the MTU of the network if 0
+	// Type is macvlan or ipvlan for an interface on Parent, in Mode,
+	// rather than a veth on Bridge.
+	Type   string `json:"type,omitempty"`
+	Parent string `json:"parent,omitempty"`
+	Mode   string `json:"mode,omitempty"`
 }
 
 type Resources struct {
-	Memory     int64            `json:"memory"`
-	MemorySwap int64            `json:"memory_swap"`
-	CpuShares  int64            `json:"cpu_shares"`
-	CpusetCpus string           `json:"cpuset_cpus"`
-	Rlimits    []*ulimit.Rlimit `json:"rlimits"`
+	Memory          int64            `json:"memory"`
+	MemorySwap      int64            `json:"memory_swap"`
+	CpuShares       int64            `json:"cpu_shares"`
+	CpuPeriod       int64            `json:"cpu_period"`
+	CpuQuota        int64            `json:"cpu_quota"`
+	CpusetCpus      string           `json:"cpuset_cpus"`
+	BlkioWeight     int64            `json:"blkio_weight"`
+	DeviceReadBps   []ThrottleDevice `json:"device_read_bps"`
+	DeviceWriteBps  []ThrottleDevice `json:"device_write_bps"`
+	DeviceReadIOps  []ThrottleDevice `json:"device_read_iops"`
+	DeviceWriteIOps []ThrottleDevice `json:"device_write_iops"`
+	PidsLimit       int64            `json:"pids_limit"` // maximum number of processes, 0 for no limit
+	OomKillDisable  bool             `json:"oom_kill_disable"`
+	OomScoreAdj     int              `json:"oom_score_adj"`
+	Rlimits         []*ulimit.Rlimit `json:"rlimits"`
+}
+
+// ThrottleDevice is a limit of the rate of the block IO to a device.
+type ThrottleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"`
 }
 
 type ResourceStats struct {
@@ -141,6 +174,11 @@ type ProcessConfig struct {
 	Console    string   `json:"-"` // dev/console path
 }
 
+// UTS settings of the container
+type UTS struct {
+	HostUTS bool `json:"host_uts"`
+}
+
 // Process wrapps an os/exec.Cmd to add more metadata
 type Command struct {
 	ID                 string            `json:"id"`
@@ -152,6 +190,7 @@ type Command struct {
 	Network            *Network          `json:"network"`
 	Ipc                *Ipc              `json:"ipc"`
 	Pid                *Pid              `json:"pid"`
+	UTS                *UTS              `json:"uts"`
 	Resources          *Resources        `json:"resources"`
 	Mounts             []Mount           `json:"mounts"`
 	AllowedDevices     []*configs.Device `json:"allowed_devices"`
@@ -200,10 +239,14 @@ func getEnv(key string, env []string) string {
 func SetupCgroups(container *configs.Config, c *Command) error {
 	if c.Resources != nil {
 		container.Cgroups.CpuShares = c.Resources.CpuShares
+		container.Cgroups.CpuPeriod = c.Resources.CpuPeriod
+		container.Cgroups.CpuQuota = c.Resources.CpuQuota
 		container.Cgroups.Memory = c.Resources.Memory
 		container.Cgroups.MemoryReservation = c.Resources.Memory
 		container.Cgroups.MemorySwap = c.Resources.MemorySwap
 		container.Cgroups.CpusetCpus = c.Resources.CpusetCpus
+		container.Cgroups.BlkioWeight = c.Resources.BlkioWeight
+		container.Cgroups.OomKillDisable = c.Resources.OomKillDisable
 	}
 
 	return nil
@@ -0,0 +1,5 @@
+package execdriver
+
+// InitPath is where the dockerinit binary is mounted in the containers that
+// run it as their init process, which then runs the command of the container.
+const InitPath = "/dev/init"
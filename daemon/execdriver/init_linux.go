@@ -0,0 +1,85 @@
+// +build linux
+
+package execdriver
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/docker/pkg/term"
+)
+
+func init() {
+	reexec.Register(InitPath, containerInit)
+}
+
+// containerInit is the init process of the containers run with --init. It
+// runs its arguments as its only child, forwards the signals it receives to
+// the child, reaps the processes left behind in the container, and exits with
+// the status of the child once the child exits.
+func containerInit() {
+	if len(os.Args) < 2 {
+		initFatal(1, fmt.Errorf("Usage: %s COMMAND [ARG...]", InitPath))
+	}
+
+	// catch every signal before the child starts, so that none is missed
+	sigc := make(chan os.Signal, 128)
+	signal.Notify(sigc)
+
+	path, err := exec.LookPath(os.Args[1])
+	if err != nil {
+		initFatal(127, err)
+	}
+	cmd := exec.Command(path, os.Args[2:]...)
+	cmd.Args[0] = os.Args[1]
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// The child gets a process group of its own, so that the signals of the
+	// terminal reach it once, and not through the init process as well.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		initFatal(126, err)
+	}
+	child := cmd.Process.Pid
+
+	if term.IsTerminal(os.Stdin.Fd()) {
+		pgid := int32(child)
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&pgid))); errno != 0 {
+			fmt.Fprintf(os.Stderr, "Unable to give the terminal to %s: %v\n", os.Args[1], errno)
+		}
+		// the child is stopped if it read the terminal before getting it
+		syscall.Kill(-child, syscall.SIGCONT)
+	}
+
+	for sig := range sigc {
+		if sig != syscall.SIGCHLD {
+			syscall.Kill(child, sig.(syscall.Signal))
+			continue
+		}
+		for {
+			var status syscall.WaitStatus
+			pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+			if err == syscall.EINTR {
+				continue
+			}
+			if pid <= 0 || err != nil {
+				break
+			}
+			if pid == child {
+				if status.Signaled() {
+					os.Exit(128 + int(status.Signal()))
+				}
+				os.Exit(status.ExitStatus())
+			}
+		}
+	}
+}
+
+func initFatal(code int, err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(code)
+}
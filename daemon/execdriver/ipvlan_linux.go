@@ -0,0 +1,115 @@
+// +build linux
+
+package execdriver
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// The rtnetlink attributes of ipvlan interfaces, which neither the syscall
+// package nor the netlink one of libcontainer have.
+const (
+	iflaInfoKind   = 1
+	iflaInfoData   = 2
+	iflaIpvlanMode = 1
+
+	ipvlanModeL2 = 0
+	ipvlanModeL3 = 1
+)
+
+// addIpvlan creates the ipvlan interface name on parent, in mode, l2 or
+// l3.
+func addIpvlan(parent, name, mode string) error {
+	m := uint16(ipvlanModeL2)
+	switch mode {
+	case "", "l2":
+	case "l3":
+		m = ipvlanModeL3
+	default:
+		return fmt.Errorf("Unknown ipvlan mode %s", mode)
+	}
+	iface, err := net.InterfaceByName(parent)
+	if err != nil {
+		return err
+	}
+
+	modeData := make([]byte, 2)
+	*(*uint16)(unsafe.Pointer(&modeData[0])) = m
+	index := make([]byte, 4)
+	*(*uint32)(unsafe.Pointer(&index[0])) = uint32(iface.Index)
+
+	msg := make([]byte, syscall.SizeofIfInfomsg)
+	msg[0] = syscall.AF_UNSPEC
+	msg = append(msg, rtAttr(syscall.IFLA_IFNAME, append([]byte(name), 0))...)
+	msg = append(msg, rtAttr(syscall.IFLA_LINK, index)...)
+	msg = append(msg, rtAttr(syscall.IFLA_LINKINFO,
+		rtAttr(iflaInfoKind, []byte("ipvlan")),
+		rtAttr(iflaInfoData, rtAttr(iflaIpvlanMode, modeData)),
+	)...)
+	return rtnetlinkRequest(syscall.RTM_NEWLINK, syscall.NLM_F_CREATE|syscall.NLM_F_EXCL, msg)
+}
+
+// rtAttr returns a struct rtattr of type typ, whose payload is the
+// concatenation of data.
+func rtAttr(typ int, data ...[]byte) []byte {
+	var payload []byte
+	for _, d := range data {
+		payload = append(payload, d...)
+	}
+	length := syscall.SizeofRtAttr + len(payload)
+	b := make([]byte, syscall.SizeofRtAttr, (length+syscall.RTA_ALIGNTO-1) & ^(syscall.RTA_ALIGNTO-1))
+	*(*uint16)(unsafe.Pointer(&b[0])) = uint16(length)
+	*(*uint16)(unsafe.Pointer(&b[2])) = uint16(typ)
+	b = append(b, payload...)
+	return b[:cap(b)]
+}
+
+// rtnetlinkRequest sends a rtnetlink request, and waits for its
+// acknowledgement.
+func rtnetlinkRequest(typ uint16, flags int, data []byte) error {
+	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(s)
+	if err := syscall.Bind(s, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	const seq = 1
+	msg := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(data))
+	*(*syscall.NlMsghdr)(unsafe.Pointer(&msg[0])) = syscall.NlMsghdr{
+		Len:   uint32(syscall.NLMSG_HDRLEN + len(data)),
+		Type:  typ,
+		Flags: uint16(syscall.NLM_F_REQUEST | syscall.NLM_F_ACK | flags),
+		Seq:   seq,
+	}
+	msg = append(msg, data...)
+	if err := syscall.Sendto(s, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, syscall.Getpagesize())
+	for {
+		n, _, err := syscall.Recvfrom(s, buf, 0)
+		if err != nil {
+			return err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq || m.Header.Type != syscall.NLMSG_ERROR {
+				continue
+			}
+			if errno := -*(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+				return syscall.Errno(errno)
+			}
+			return nil
+		}
+	}
+}
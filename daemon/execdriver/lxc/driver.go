@@ -85,6 +85,14 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		dataPath = d.containerDir(c.ID)
 	)
 
+	if c.Network.Interface != nil {
+		for _, iface := range append([]*execdriver.NetworkInterface{c.Network.Interface}, c.Network.Interfaces...) {
+			if iface.Type == "ipvlan" {
+				return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("The lxc execution driver doesn't support the interfaces of the ipvlan driver")
+			}
+		}
+	}
+
 	if c.ProcessConfig.Tty {
 		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
 	} else {
@@ -144,6 +152,11 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 			)
 		}
 	}
+	if c.UTS != nil && c.UTS.HostUTS {
+		params = append(params,
+			"--share-uts", "1",
+		)
+	}
 
 	params = append(params,
 		"--",
@@ -239,6 +252,11 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	if err != nil {
 		return terminate(err)
 	}
+	if c.Resources != nil {
+		if err := execdriver.SetOomScoreAdj(pid, c.Resources.OomScoreAdj); err != nil {
+			return terminate(err)
+		}
+	}
 
 	cgroupPaths, err := cgroupPaths(c.ID)
 	if err != nil {
@@ -268,7 +286,7 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	}
 
 	oomKill := false
-	oomKillNotification, err := notifyOnOOM(cgroupPaths)
+	oomKillNotification, err := execdriver.NotifyOnOOM(cgroupPaths)
 
 	<-waitLock
 
@@ -287,54 +305,6 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	return execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill}, waitErr
 }
 
-// copy from libcontainer
-func notifyOnOOM(paths map[string]string) (<-chan struct{}, error) {
-	dir := paths["memory"]
-	if dir == "" {
-		return nil, fmt.Errorf("There is no path for %q in state", "memory")
-	}
-	oomControl, err := os.Open(filepath.Join(dir, "memory.oom_control"))
-	if err != nil {
-		return nil, err
-	}
-	fd, _, syserr := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.FD_CLOEXEC, 0)
-	if syserr != 0 {
-		oomControl.Close()
-		return nil, syserr
-	}
-
-	eventfd := os.NewFile(fd, "eventfd")
-
-	eventControlPath := filepath.Join(dir, "cgroup.event_control")
-	data := fmt.Sprintf("%d %d", eventfd.Fd(), oomControl.Fd())
-	if err := ioutil.WriteFile(eventControlPath, []byte(data), 0700); err != nil {
-		eventfd.Close()
-		oomControl.Close()
-		return nil, err
-	}
-	ch := make(chan struct{})
-	go func() {
-		defer func() {
-			close(ch)
-			eventfd.Close()
-			oomControl.Close()
-		}()
-		buf := make([]byte, 8)
-		for {
-			if _, err := eventfd.Read(buf); err != nil {
-				return
-			}
-			// When a cgroup is destroyed, an event is sent to eventfd.
-			// So if the control path is gone, return instead of notifying.
-			if _, err := os.Lstat(eventControlPath); os.IsNotExist(err) {
-				return
-			}
-			ch <- struct{}{}
-		}
-	}()
-	return ch, nil
-}
-
 // createContainer populates and configures the container type with the
 // data provided by the execdriver.Command
 func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error) {
@@ -488,6 +458,62 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return err
 }
 
+// Update writes the resources of the command to the cgroups of the running
+// container with lxc-cgroup.
+func (d *driver) Update(c *execdriver.Command) error {
+	if _, err := exec.LookPath("lxc-cgroup"); err != nil {
+		return err
+	}
+	r := c.Resources
+	if r == nil {
+		return nil
+	}
+	var values [][2]string
+	if r.Memory != 0 {
+		memSwap := strconv.FormatInt(getMemorySwap(r), 10)
+		memory := strconv.FormatInt(r.Memory, 10)
+		// memsw.limit_in_bytes can't be lower than limit_in_bytes, the
+		// order they are written in depends on whether the limit grows.
+		if getMemorySwap(r) == 0 {
+			values = append(values, [2]string{"memory.limit_in_bytes", memory})
+		} else {
+			values = append(values,
+				[2]string{"memory.memsw.limit_in_bytes", memSwap},
+				[2]string{"memory.limit_in_bytes", memory},
+				[2]string{"memory.memsw.limit_in_bytes", memSwap})
+		}
+		values = append(values, [2]string{"memory.soft_limit_in_bytes", memory})
+	}
+	if r.CpuShares != 0 {
+		values = append(values, [2]string{"cpu.shares", strconv.FormatInt(r.CpuShares, 10)})
+	}
+	if r.CpuPeriod != 0 {
+		values = append(values, [2]string{"cpu.cfs_period_us", strconv.FormatInt(r.CpuPeriod, 10)})
+	}
+	if r.CpuQuota != 0 {
+		values = append(values, [2]string{"cpu.cfs_quota_us", strconv.FormatInt(r.CpuQuota, 10)})
+	}
+	if r.CpusetCpus != "" {
+		values = append(values, [2]string{"cpuset.cpus", r.CpusetCpus})
+	}
+	if r.BlkioWeight != 0 {
+		values = append(values, [2]string{"blkio.weight", strconv.FormatInt(r.BlkioWeight, 10)})
+	}
+	for i, v := range values {
+		output, err := exec.Command("lxc-cgroup", "-n", c.ID, v[0], v[1]).CombinedOutput()
+		// Writing memsw first fails when the limit shrinks, it is
+		// written again after limit_in_bytes.
+		if err != nil && !(i == 0 && v[0] == "memory.memsw.limit_in_bytes") {
+			return fmt.Errorf("Err: %s Output: %s", err, output)
+		}
+	}
+	return nil
+}
+
+func (d *driver) Restore(c *execdriver.Command, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("Restoring running containers is not supported by the lxc driver")
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	return KillLxc(c.ID, 9)
 }
@@ -16,10 +16,19 @@ import (
 const LxcTemplate = `
 {{if .Network.Interface}}
 # network configuration
+{{if .Network.Interface.OnParent}}
+lxc.network.type = macvlan
+lxc.network.link = {{.Network.Interface.Parent}}
+lxc.network.macvlan.mode = {{.Network.Interface.Mode}}
+{{if .Network.Interface.Mtu}}
+lxc.network.mtu = {{.Network.Interface.Mtu}}
+{{end}}
+{{else}}
 lxc.network.type = veth
 lxc.network.link = {{.Network.Interface.Bridge}}
+lxc.network.mtu = {{if .Network.Interface.Mtu}}{{.Network.Interface.Mtu}}{{else}}{{.Network.Mtu}}{{end}}
+{{end}}
 lxc.network.name = eth0
-lxc.network.mtu = {{.Network.Mtu}}
 lxc.network.flags = up
 {{else if .Network.HostNetworking}}
 lxc.network.type = none
@@ -104,12 +113,39 @@ lxc.cgroup.memory.soft_limit_in_bytes = {{.Resources.Memory}}
 lxc.cgroup.memory.memsw.limit_in_bytes = {{$memSwap}}
 {{end}}
 {{end}}
+{{if .Resources.OomKillDisable}}
+lxc.cgroup.memory.oom_control = 1
+{{end}}
 {{if .Resources.CpuShares}}
 lxc.cgroup.cpu.shares = {{.Resources.CpuShares}}
 {{end}}
+{{if .Resources.CpuPeriod}}
+lxc.cgroup.cpu.cfs_period_us = {{.Resources.CpuPeriod}}
+{{end}}
+{{if .Resources.CpuQuota}}
+lxc.cgroup.cpu.cfs_quota_us = {{.Resources.CpuQuota}}
+{{end}}
 {{if .Resources.CpusetCpus}}
 lxc.cgroup.cpuset.cpus = {{.Resources.CpusetCpus}}
 {{end}}
+{{if .Resources.BlkioWeight}}
+lxc.cgroup.blkio.weight = {{.Resources.BlkioWeight}}
+{{end}}
+{{range .Resources.DeviceReadBps}}
+lxc.cgroup.blkio.throttle.read_bps_device = {{.Major}}:{{.Minor}} {{.Rate}}
+{{end}}
+{{range .Resources.DeviceWriteBps}}
+lxc.cgroup.blkio.throttle.write_bps_device = {{.Major}}:{{.Minor}} {{.Rate}}
+{{end}}
+{{range .Resources.DeviceReadIOps}}
+lxc.cgroup.blkio.throttle.read_iops_device = {{.Major}}:{{.Minor}} {{.Rate}}
+{{end}}
+{{range .Resources.DeviceWriteIOps}}
+lxc.cgroup.blkio.throttle.write_iops_device = {{.Major}}:{{.Minor}} {{.Rate}}
+{{end}}
+{{if .Resources.PidsLimit}}
+lxc.cgroup.pids.max = {{.Resources.PidsLimit}}
+{{end}}
 {{end}}
 
 {{if .LxcConfig}}
@@ -128,9 +164,30 @@ lxc.network.ipv4.gateway = {{.Network.Interface.Gateway}}
 {{if .Network.Interface.MacAddress}}
 lxc.network.hwaddr = {{.Network.Interface.MacAddress}}
 {{end}}
-{{if .ProcessConfig.Env}}
-lxc.utsname = {{getHostname .ProcessConfig.Env}}
+{{range $i, $iface := .Network.Interfaces}}
+# interface of another network
+{{if $iface.OnParent}}
+lxc.network.type = macvlan
+lxc.network.link = {{$iface.Parent}}
+lxc.network.macvlan.mode = {{$iface.Mode}}
+{{if $iface.Mtu}}
+lxc.network.mtu = {{$iface.Mtu}}
+{{end}}
+{{else}}
+lxc.network.type = veth
+lxc.network.link = {{$iface.Bridge}}
+lxc.network.mtu = {{if $iface.Mtu}}{{$iface.Mtu}}{{else}}{{$.Network.Mtu}}{{end}}
+{{end}}
+lxc.network.name = {{interfaceName $i}}
+lxc.network.flags = up
+lxc.network.ipv4 = {{$iface.IPAddress}}/{{$iface.IPPrefixLen}}
+{{if $iface.MacAddress}}
+lxc.network.hwaddr = {{$iface.MacAddress}}
 {{end}}
+{{end}}
+{{if .ProcessConfig.Env}}{{if not (isHostUTS .UTS)}}
+lxc.utsname = {{getHostname .ProcessConfig.Env}}
+{{end}}{{end}}
 
 {{if .ProcessConfig.Privileged}}
 # No cap values are needed, as lxc is starting in privileged mode
@@ -235,6 +292,18 @@ func getHostname(env []string) string {
 	return ""
 }
 
+// isHostUTS returns whether the container shares the UTS namespace of the
+// host, whose hostname it must not set.
+func isHostUTS(uts *execdriver.UTS) bool {
+	return uts != nil && uts.HostUTS
+}
+
+// interfaceName returns the name of the interface of the i-th of the other
+// networks of a container, eth0 being the one of its own.
+func interfaceName(i int) string {
+	return fmt.Sprintf("eth%d", i+1)
+}
+
 func init() {
 	var err error
 	funcMap := template.FuncMap{
@@ -245,6 +314,8 @@ func init() {
 		"keepCapabilities":  keepCapabilities,
 		"dropList":          dropList,
 		"getHostname":       getHostname,
+		"isHostUTS":         isHostUTS,
+		"interfaceName":     interfaceName,
 	}
 	LxcTemplateCompiled, err = template.New("lxc").Funcs(funcMap).Parse(LxcTemplate)
 	if err != nil {
@@ -271,6 +271,14 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 				IPPrefixLen: 24,
 				Bridge:      "docker0",
 			},
+			Interfaces: []*execdriver.NetworkInterface{
+				{
+					IPAddress:   "10.20.0.2",
+					IPPrefixLen: 16,
+					Bridge:      "br-test",
+					Mtu:         1450,
+				},
+			},
 		},
 		ProcessConfig:   processConfig,
 		CapAdd:          []string{"net_admin", "syslog"},
@@ -289,6 +297,10 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 	grepFile(t, p, "lxc.network.ipv4 = 10.10.10.10/24")
 	grepFile(t, p, "lxc.network.ipv4.gateway = 10.10.10.1")
 	grepFile(t, p, "lxc.network.flags = up")
+	grepFile(t, p, "lxc.network.link = br-test")
+	grepFile(t, p, "lxc.network.mtu = 1450")
+	grepFile(t, p, "lxc.network.name = eth1")
+	grepFile(t, p, "lxc.network.ipv4 = 10.20.0.2/16")
 	grepFile(t, p, "lxc.aa_profile = lxc-container-default-with-nesting")
 	// hostname
 	grepFile(t, p, "lxc.utsname = testhost")
@@ -306,6 +318,47 @@ func TestCustomLxcConfigMisc(t *testing.T) {
 	grepFileWithReverse(t, p, fmt.Sprintf("lxc.cap.keep = %d", capability.CAP_MKNOD), true)
 }
 
+func TestLxcConfigMacvlan(t *testing.T) {
+	root, err := ioutil.TempDir("", "TestLxcConfigMacvlan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	os.MkdirAll(path.Join(root, "containers", "1"), 0777)
+	driver, err := NewDriver(root, root, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	command := &execdriver.Command{
+		ID: "1",
+		Network: &execdriver.Network{
+			Mtu: 1500,
+			Interface: &execdriver.NetworkInterface{
+				Gateway:     "192.168.1.1",
+				IPAddress:   "192.168.1.20",
+				IPPrefixLen: 24,
+				MacAddress:  "02:42:c0:a8:01:14",
+				Type:        "macvlan",
+				Parent:      "eth0.10",
+				Mode:        "bridge",
+			},
+		},
+	}
+
+	p, err := driver.generateLXCConfig(command)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grepFile(t, p, "lxc.network.type = macvlan")
+	grepFile(t, p, "lxc.network.link = eth0.10")
+	grepFile(t, p, "lxc.network.macvlan.mode = bridge")
+	grepFile(t, p, "lxc.network.name = eth0")
+	grepFile(t, p, "lxc.network.ipv4 = 192.168.1.20/24")
+	grepFile(t, p, "lxc.network.ipv4.gateway = 192.168.1.1")
+	grepFile(t, p, "lxc.network.hwaddr = 02:42:c0:a8:01:14")
+	grepFileWithReverse(t, p, "lxc.network.mtu = 1500", true)
+}
+
 func TestCustomLxcConfigMiscOverride(t *testing.T) {
 	root, err := ioutil.TempDir("", "TestCustomLxcConfig")
 	if err != nil {
@@ -0,0 +1,160 @@
+// +build linux,cgo
+
+package native
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/configs"
+)
+
+// cgroupsManager adds the cgroup settings libcontainer doesn't manage to the
+// cgroups of a container: the pids cgroup, when the number of its processes
+// is limited, and the block IO limits of devices. It also adjusts the OOM
+// score of the container, which libcontainer doesn't support either.
+type cgroupsManager struct {
+	cgroups.Manager
+	cgroup    *configs.Cgroup
+	resources *execdriver.Resources
+	pidsPath  string
+}
+
+// extraCgroups returns the option of the libcontainer factory that makes the
+// cgroups managers of newManager apply the resources of the containers that
+// libcontainer doesn't manage too.
+func (d *driver) extraCgroups(newManager func(*libcontainer.LinuxFactory) error) func(*libcontainer.LinuxFactory) error {
+	return func(l *libcontainer.LinuxFactory) error {
+		if err := newManager(l); err != nil {
+			return err
+		}
+		newCgroupsManager := l.NewCgroupsManager
+		l.NewCgroupsManager = func(config *configs.Cgroup, paths map[string]string) cgroups.Manager {
+			d.Lock()
+			resources := d.resources[config.Name]
+			d.Unlock()
+			return &cgroupsManager{
+				Manager:   newCgroupsManager(config, paths),
+				cgroup:    config,
+				resources: resources,
+				pidsPath:  paths["pids"],
+			}
+		}
+		return nil
+	}
+}
+
+// Apply joins pid to the cgroups of the container, and to its pids cgroup
+// if the number of its processes is limited. The OOM score of pid, the init
+// of the container which hasn't run the command of the container yet, is
+// adjusted here too so that all the processes of the container inherit it.
+func (m *cgroupsManager) Apply(pid int) error {
+	if err := m.Manager.Apply(pid); err != nil {
+		return err
+	}
+	if m.resources == nil {
+		return nil
+	}
+	if err := m.applyPidsLimit(pid); err != nil {
+		return err
+	}
+	if err := m.applyThrottleDevices(); err != nil {
+		return err
+	}
+	return execdriver.SetOomScoreAdj(pid, m.resources.OomScoreAdj)
+}
+
+func (m *cgroupsManager) applyPidsLimit(pid int) error {
+	if m.resources.PidsLimit <= 0 {
+		return nil
+	}
+	path, err := pidsCgroupPath(m.cgroup)
+	if err == nil {
+		err = os.MkdirAll(path, 0755)
+	}
+	if err == nil {
+		m.pidsPath = path
+		err = writeCgroupFile(path, "pids.max", strconv.FormatInt(m.resources.PidsLimit, 10))
+	}
+	if err == nil {
+		err = writeCgroupFile(path, "cgroup.procs", strconv.Itoa(pid))
+	}
+	return err
+}
+
+// applyThrottleDevices writes the block IO limits of the devices to the
+// blkio cgroup of the container.
+func (m *cgroupsManager) applyThrottleDevices() error {
+	files := []struct {
+		name    string
+		devices []execdriver.ThrottleDevice
+	}{
+		{"blkio.throttle.read_bps_device", m.resources.DeviceReadBps},
+		{"blkio.throttle.write_bps_device", m.resources.DeviceWriteBps},
+		{"blkio.throttle.read_iops_device", m.resources.DeviceReadIOps},
+		{"blkio.throttle.write_iops_device", m.resources.DeviceWriteIOps},
+	}
+	path := m.Manager.GetPaths()["blkio"]
+	for _, file := range files {
+		for _, d := range file.devices {
+			if path == "" {
+				return fmt.Errorf("Cannot limit the block IO of the container: the blkio cgroup isn't mounted")
+			}
+			if err := writeCgroupFile(path, file.name, fmt.Sprintf("%d:%d %d", d.Major, d.Minor, d.Rate)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetPaths returns the paths of the cgroups of the container, including its
+// pids cgroup, so that it's destroyed when the container is loaded again.
+func (m *cgroupsManager) GetPaths() map[string]string {
+	paths := make(map[string]string)
+	for subsystem, path := range m.Manager.GetPaths() {
+		paths[subsystem] = path
+	}
+	if m.pidsPath != "" {
+		paths["pids"] = m.pidsPath
+	}
+	return paths
+}
+
+func (m *cgroupsManager) Destroy() error {
+	err := m.Manager.Destroy()
+	if m.pidsPath != "" {
+		if rmErr := cgroups.RemovePaths(map[string]string{"pids": m.pidsPath}); err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
+
+// pidsCgroupPath returns the path of the pids cgroup of c, where the cgroups
+// filesystem manager of libcontainer would put it.
+func pidsCgroupPath(c *configs.Cgroup) (string, error) {
+	mountpoint, err := cgroups.FindCgroupMountpoint("pids")
+	if err != nil {
+		return "", err
+	}
+	cgroup := filepath.Join(c.Parent, c.Name)
+	if filepath.IsAbs(cgroup) {
+		return filepath.Join(mountpoint, cgroup), nil
+	}
+	initPath, err := cgroups.GetInitCgroupDir("pids")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(mountpoint, initPath, cgroup), nil
+}
+
+func writeCgroupFile(dir, file, data string) error {
+	return ioutil.WriteFile(filepath.Join(dir, file), []byte(data), 0700)
+}
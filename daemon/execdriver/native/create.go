@@ -31,6 +31,10 @@ func (d *driver) createContainer(c *execdriver.Command) (*configs.Config, error)
 		return nil, err
 	}
 
+	if err := d.createUTS(container, c); err != nil {
+		return nil, err
+	}
+
 	if err := d.createNetwork(container, c); err != nil {
 		return nil, err
 	}
@@ -93,6 +97,9 @@ func (d *driver) createNetwork(container *configs.Config, c *execdriver.Command)
 		container.Namespaces.Remove(configs.NEWNET)
 		return nil
 	}
+	if c.Network.HasInterfacesOnParents() {
+		return d.createNetworkNamespace(container, c)
+	}
 
 	container.Networks = []*configs.Network{
 		{
@@ -108,7 +115,7 @@ func (d *driver) createNetwork(container *configs.Config, c *execdriver.Command)
 		vethNetwork := configs.Network{
 			Name:              "eth0",
 			HostInterfaceName: iName,
-			Mtu:               c.Network.Mtu,
+			Mtu:               interfaceMtu(c, c.Network.Interface),
 			Address:           fmt.Sprintf("%s/%d", c.Network.Interface.IPAddress, c.Network.Interface.IPPrefixLen),
 			MacAddress:        c.Network.Interface.MacAddress,
 			Gateway:           c.Network.Interface.Gateway,
@@ -120,6 +127,26 @@ func (d *driver) createNetwork(container *configs.Config, c *execdriver.Command)
 			vethNetwork.IPv6Gateway = c.Network.Interface.IPv6Gateway
 		}
 		container.Networks = append(container.Networks, &vethNetwork)
+
+		for i, iface := range c.Network.Interfaces {
+			iName, err := generateIfaceName()
+			if err != nil {
+				return err
+			}
+			vethNetwork := configs.Network{
+				Name:              fmt.Sprintf("eth%d", i+1),
+				HostInterfaceName: iName,
+				Mtu:               interfaceMtu(c, iface),
+				Address:           fmt.Sprintf("%s/%d", iface.IPAddress, iface.IPPrefixLen),
+				MacAddress:        iface.MacAddress,
+				Type:              "veth",
+				Bridge:            iface.Bridge,
+			}
+			if iface.GlobalIPv6Address != "" {
+				vethNetwork.IPv6Address = fmt.Sprintf("%s/%d", iface.GlobalIPv6Address, iface.GlobalIPv6PrefixLen)
+			}
+			container.Networks = append(container.Networks, &vethNetwork)
+		}
 	}
 
 	if c.Network.ContainerID != "" {
@@ -142,6 +169,45 @@ func (d *driver) createNetwork(container *configs.Config, c *execdriver.Command)
 	return nil
 }
 
+// createNetworkNamespace sets up the interfaces of the container of c in a
+// network namespace of its own, which the container joins: libcontainer
+// only creates veths, not the macvlan and ipvlan interfaces on parents.
+func (d *driver) createNetworkNamespace(container *configs.Config, c *execdriver.Command) error {
+	var veths []*execdriver.Veth
+	for i, iface := range append([]*execdriver.NetworkInterface{c.Network.Interface}, c.Network.Interfaces...) {
+		v, err := execdriver.NewVeth(fmt.Sprintf("eth%d", i), iface, c.Network.Mtu)
+		if err != nil {
+			return err
+		}
+		veths = append(veths, v)
+	}
+	path := d.networkNamespacePath(c.ID)
+	// A namespace left by a container that failed to start would hold
+	// its addresses.
+	execdriver.RemoveNetworkNamespace(path)
+	if err := execdriver.CreateNetworkNamespace(path, veths); err != nil {
+		return err
+	}
+	container.Networks = nil
+	container.Namespaces.Add(configs.NEWNET, path)
+	return nil
+}
+
+// networkNamespacePath returns where the network namespace created for the
+// container id, if any, is bound.
+func (d *driver) networkNamespacePath(id string) string {
+	return filepath.Join(d.root, "netns", id)
+}
+
+// interfaceMtu returns the MTU of the interface iface of the container of
+// c: its own, or the one of the network of the container.
+func interfaceMtu(c *execdriver.Command, iface *execdriver.NetworkInterface) int {
+	if iface.Mtu != 0 {
+		return iface.Mtu
+	}
+	return c.Network.Mtu
+}
+
 func (d *driver) createIpc(container *configs.Config, c *execdriver.Command) error {
 	if c.Ipc.HostIpc {
 		container.Namespaces.Remove(configs.NEWIPC)
@@ -149,30 +215,61 @@ func (d *driver) createIpc(container *configs.Config, c *execdriver.Command) err
 	}
 
 	if c.Ipc.ContainerID != "" {
-		d.Lock()
-		active := d.activeContainers[c.Ipc.ContainerID]
-		d.Unlock()
-
-		if active == nil {
-			return fmt.Errorf("%s is not a valid running container to join", c.Ipc.ContainerID)
-		}
-
-		state, err := active.State()
+		path, err := d.namespacePath(c.Ipc.ContainerID, configs.NEWIPC)
 		if err != nil {
 			return err
 		}
-		container.Namespaces.Add(configs.NEWIPC, state.NamespacePaths[configs.NEWIPC])
+		container.Namespaces.Add(configs.NEWIPC, path)
 	}
 
 	return nil
 }
 
+// namespacePath returns the path of the namespace of type t of the running
+// container id.
+func (d *driver) namespacePath(id string, t configs.NamespaceType) (string, error) {
+	d.Lock()
+	active := d.activeContainers[id]
+	d.Unlock()
+
+	if active == nil {
+		return "", fmt.Errorf("%s is not a valid running container to join", id)
+	}
+
+	state, err := active.State()
+	if err != nil {
+		return "", err
+	}
+	return state.NamespacePaths[t], nil
+}
+
 func (d *driver) createPid(container *configs.Config, c *execdriver.Command) error {
 	if c.Pid.HostPid {
 		container.Namespaces.Remove(configs.NEWPID)
 		return nil
 	}
 
+	// libcontainer joins the namespaces of a container in its first process,
+	// which only moves the children of that process into a PID namespace:
+	// Run forks that process in the namespace instead.
+	if c.Pid.ContainerID != "" {
+		if _, err := d.namespacePath(c.Pid.ContainerID, configs.NEWPID); err != nil {
+			return err
+		}
+		container.Namespaces.Remove(configs.NEWPID)
+	}
+
+	return nil
+}
+
+func (d *driver) createUTS(container *configs.Config, c *execdriver.Command) error {
+	if c.UTS.HostUTS {
+		container.Namespaces.Remove(configs.NEWUTS)
+		// The hostname would be set on the host.
+		container.Hostname = ""
+		return nil
+	}
+
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -31,10 +32,15 @@ const (
 	Version    = "0.2"
 )
 
+// restorePollInterval is how often the daemon checks whether the process of a
+// restored container exited.
+const restorePollInterval = 100 * time.Millisecond
+
 type driver struct {
 	root             string
 	initPath         string
 	activeContainers map[string]libcontainer.Container
+	resources        map[string]*execdriver.Resources
 	machineMemory    int64
 	factory          libcontainer.Factory
 	sync.Mutex
@@ -53,6 +59,14 @@ func NewDriver(root, initPath string) (*driver, error) {
 	if err := apparmor.InstallDefaultProfile(); err != nil {
 		return nil, err
 	}
+	d := &driver{
+		root:             root,
+		initPath:         initPath,
+		activeContainers: make(map[string]libcontainer.Container),
+		resources:        make(map[string]*execdriver.Resources),
+		machineMemory:    meminfo.MemTotal,
+	}
+
 	cgm := libcontainer.Cgroupfs
 	if systemd.UseSystemd() {
 		cgm = libcontainer.SystemdCgroups
@@ -60,20 +74,15 @@ func NewDriver(root, initPath string) (*driver, error) {
 
 	f, err := libcontainer.New(
 		root,
-		cgm,
+		d.extraCgroups(cgm),
 		libcontainer.InitPath(reexec.Self(), DriverName),
 	)
 	if err != nil {
 		return nil, err
 	}
+	d.factory = f
 
-	return &driver{
-		root:             root,
-		initPath:         initPath,
-		activeContainers: make(map[string]libcontainer.Container),
-		machineMemory:    meminfo.MemTotal,
-		factory:          f,
-	}, nil
+	return d, nil
 }
 
 type execOutput struct {
@@ -82,6 +91,15 @@ type execOutput struct {
 }
 
 func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	// The network namespace created for the container, if any, goes with
+	// the container once it's created, and right away otherwise.
+	created := false
+	defer func() {
+		if !created {
+			execdriver.RemoveNetworkNamespace(d.networkNamespacePath(c.ID))
+		}
+	}()
+
 	// take the Command and populate the libcontainer.Config from it
 	container, err := d.createContainer(c)
 	if err != nil {
@@ -128,10 +146,17 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	}
 	c.ProcessConfig.Terminal = term
 
+	d.Lock()
+	d.resources[c.ID] = c.Resources
+	d.Unlock()
 	cont, err := d.factory.Create(c.ID, container)
 	if err != nil {
+		d.Lock()
+		delete(d.resources, c.ID)
+		d.Unlock()
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
+	created = true
 	d.Lock()
 	d.activeContainers[c.ID] = cont
 	d.Unlock()
@@ -140,7 +165,15 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 		d.cleanContainer(c.ID)
 	}()
 
-	if err := cont.Start(p); err != nil {
+	start := func() error { return cont.Start(p) }
+	if c.Pid.ContainerID != "" {
+		path, err := d.namespacePath(c.Pid.ContainerID, configs.NEWPID)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		start = func() error { return inPidNamespace(path, func() error { return cont.Start(p) }) }
+	}
+	if err := start(); err != nil {
 		return execdriver.ExitStatus{ExitCode: -1}, err
 	}
 
@@ -174,6 +207,62 @@ func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallba
 	return execdriver.ExitStatus{ExitCode: utils.ExitStatus(ps.Sys().(syscall.WaitStatus)), OOMKilled: oomKill}, nil
 }
 
+// Restore re-attaches to the init process of a container that a previous
+// daemon left running. The process isn't a child of the daemon, so its exit
+// status is unknown.
+func (d *driver) Restore(c *execdriver.Command, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	cont, err := d.factory.Load(c.ID)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	state, err := cont.State()
+	if err != nil {
+		d.cleanContainer(c.ID)
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	pid := state.InitProcessPid
+	// make sure the pid wasn't reused by another process
+	if startTime, err := system.GetProcessStartTime(pid); err != nil || startTime != state.InitProcessStartTime {
+		d.cleanContainer(c.ID)
+		return execdriver.ExitStatus{ExitCode: -1}, execdriver.ErrNotRunning
+	}
+
+	c.ProcessConfig.Terminal = &execdriver.StdConsole{}
+	d.Lock()
+	d.activeContainers[c.ID] = cont
+	d.Unlock()
+	defer func() {
+		cont.Destroy()
+		d.cleanContainer(c.ID)
+	}()
+
+	if startCallback != nil {
+		startCallback(&c.ProcessConfig, pid)
+	}
+
+	oom := notifyOnOOM(cont)
+	exitCode, known := waitRestored(pid)
+	cont.Destroy()
+	_, oomKill := <-oom
+	return execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill, ExitCodeUnknown: !known}, nil
+}
+
+// waitRestored polls pid until the process exits and returns its exit code,
+// or -1 and false if it can't be collected. The process of the container has
+// to be reaped before its cgroups can be removed.
+func waitRestored(pid int) (int, bool) {
+	for {
+		var ws syscall.WaitStatus
+		if wpid, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); err == nil && wpid == pid {
+			return utils.ExitStatus(ws), true
+		}
+		if err := syscall.Kill(pid, 0); err == syscall.ESRCH {
+			return -1, false
+		}
+		time.Sleep(restorePollInterval)
+	}
+}
+
 // notifyOnOOM returns a channel that signals if the container received an OOM notification
 // for any process.  If it is unable to subscribe to OOM notifications then a closed
 // channel is returned as it will be non-blocking and return the correct result when read.
@@ -252,12 +341,53 @@ func (d *driver) Unpause(c *execdriver.Command) error {
 	return active.Resume()
 }
 
+func (d *driver) Update(c *execdriver.Command) error {
+	if systemd.UseSystemd() {
+		return fmt.Errorf("Updating the resources of a running container is not supported with systemd cgroups")
+	}
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return fmt.Errorf("active container for %s does not exist", c.ID)
+	}
+	config := active.Config()
+	old := *config.Cgroups
+	cgroups := old
+	config.Cgroups = &cgroups
+	if err := execdriver.SetupCgroups(&config, c); err != nil {
+		return err
+	}
+
+	// The memory limit can't be raised above the memory+swap limit, which
+	// has to be raised first.
+	if cgroups.Memory > old.Memory && cgroups.MemorySwap >= 0 {
+		swap := cgroups
+		swap.Memory, swap.MemoryReservation = 0, 0
+		if swap.MemorySwap == 0 {
+			swap.MemorySwap = cgroups.Memory * 2
+		}
+		swapConfig := config
+		swapConfig.Cgroups = &swap
+		if err := active.Set(swapConfig); err != nil {
+			return err
+		}
+	}
+	return active.Set(config)
+}
+
 func (d *driver) Terminate(c *execdriver.Command) error {
 	defer d.cleanContainer(c.ID)
 	// lets check the start time for the process
+	d.Lock()
 	active := d.activeContainers[c.ID]
+	d.Unlock()
 	if active == nil {
-		return fmt.Errorf("active container for %s does not exist", c.ID)
+		// the container may have been left running by a previous daemon
+		var err error
+		if active, err = d.factory.Load(c.ID); err != nil {
+			return fmt.Errorf("active container for %s does not exist", c.ID)
+		}
 	}
 	state, err := active.State()
 	if err != nil {
@@ -304,7 +434,11 @@ func (d *driver) GetPidsForContainer(id string) ([]int, error) {
 func (d *driver) cleanContainer(id string) error {
 	d.Lock()
 	delete(d.activeContainers, id)
+	delete(d.resources, id)
 	d.Unlock()
+	if err := execdriver.RemoveNetworkNamespace(d.networkNamespacePath(id)); err != nil {
+		logrus.Warnf("Unable to remove the network namespace of %s: %v", id, err)
+	}
 	return os.RemoveAll(filepath.Join(d.root, id))
 }
 
@@ -399,3 +533,41 @@ func (t *TtyConsole) AttachPipes(pipes *execdriver.Pipes) error {
 func (t *TtyConsole) Close() error {
 	return t.console.Close()
 }
+
+// inPidNamespace calls fn on a thread whose children are created in the PID
+// namespace at path, for the processes fn forks to be in that namespace.
+func inPidNamespace(path string, fn func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		runtime.LockOSThread()
+		origin, err := os.Open("/proc/self/ns/pid")
+		if err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+		defer origin.Close()
+		ns, err := os.Open(path)
+		if err != nil {
+			runtime.UnlockOSThread()
+			errCh <- err
+			return
+		}
+		defer ns.Close()
+
+		if err := system.Setns(ns.Fd(), syscall.CLONE_NEWPID); err != nil {
+			runtime.UnlockOSThread()
+			errCh <- fmt.Errorf("Unable to join the PID namespace %s: %v", path, err)
+			return
+		}
+		errCh <- fn()
+		if err := system.Setns(origin.Fd(), syscall.CLONE_NEWPID); err != nil {
+			// The thread would create the processes of the daemon in the
+			// namespace, so it's never given back to the runtime.
+			logrus.Errorf("Unable to restore the PID namespace of a thread of the daemon: %v", err)
+			select {}
+		}
+		runtime.UnlockOSThread()
+	}()
+	return <-errCh
+}
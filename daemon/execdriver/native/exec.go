@@ -30,6 +30,14 @@ func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessCo
 		Cwd:  c.WorkingDir,
 		User: c.ProcessConfig.User,
 	}
+	// The user and the environment of the container are used unless the
+	// exec overrides them.
+	if processConfig.User != "" {
+		p.User = processConfig.User
+	}
+	if processConfig.Env != nil {
+		p.Env = processConfig.Env
+	}
 
 	if processConfig.Tty {
 		config := active.Config()
@@ -0,0 +1,88 @@
+package execdriver
+
+import (
+	"fmt"
+
+	"github.com/docker/libcontainer/utils"
+)
+
+// Veth is a veth pair attached to Bridge, whose peer is an interface of the
+// network namespace of a container, or a macvlan or ipvlan interface on
+// Parent.
+type Veth struct {
+	Name              string // The first free ethN if empty
+	Type              string // veth if empty, or macvlan or ipvlan
+	HostInterfaceName string
+	Bridge            string
+	Parent            string
+	Mode              string
+	Mtu               int // The MTU of the parent if 0, for an interface on a parent
+	MacAddress        string
+	Address           string
+	Gateway           string
+	IPv6Address       string
+	IPv6Gateway       string
+}
+
+// Bandwidth is the rate limits of every interface of a container, in bytes
+// per second, none if 0: Egress of the traffic it sends, Ingress of the
+// traffic it receives.
+type Bandwidth struct {
+	Egress  int64
+	Ingress int64
+}
+
+// NewVeth returns the veth pair of iface, named name in the container. Its
+// peer on the host is named at random. mtu is the MTU of the network, for
+// a veth which doesn't have its own.
+func NewVeth(name string, iface *NetworkInterface, mtu int) (*Veth, error) {
+	var hostName string
+	if iface.OnParent() {
+		mtu = 0
+	} else {
+		var err error
+		if hostName, err = utils.GenerateRandomName("veth", 7); err != nil {
+			return nil, err
+		}
+	}
+	if iface.Mtu != 0 {
+		mtu = iface.Mtu
+	}
+	v := &Veth{
+		Name:              name,
+		Type:              iface.Type,
+		HostInterfaceName: hostName,
+		Bridge:            iface.Bridge,
+		Parent:            iface.Parent,
+		Mode:              iface.Mode,
+		Mtu:               mtu,
+		MacAddress:        iface.MacAddress,
+		Address:           fmt.Sprintf("%s/%d", iface.IPAddress, iface.IPPrefixLen),
+		Gateway:           iface.Gateway,
+		IPv6Gateway:       iface.IPv6Gateway,
+	}
+	if iface.GlobalIPv6Address != "" {
+		v.IPv6Address = fmt.Sprintf("%s/%d", iface.GlobalIPv6Address, iface.GlobalIPv6PrefixLen)
+	}
+	return v, nil
+}
+
+// OnParent returns whether iface is a macvlan or ipvlan interface on a
+// parent interface of the host, rather than a veth.
+func (iface *NetworkInterface) OnParent() bool {
+	return iface.Type != "" && iface.Type != "veth"
+}
+
+// HasInterfacesOnParents returns whether an interface of n is on a parent
+// interface of the host, which libcontainer can't create.
+func (n *Network) HasInterfacesOnParents() bool {
+	if n.Interface == nil {
+		return false
+	}
+	for _, iface := range append([]*NetworkInterface{n.Interface}, n.Interfaces...) {
+		if iface.OnParent() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,389 @@
+// +build linux
+
+package execdriver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/docker/libcontainer/netlink"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/utils"
+)
+
+// networkSetupName is the name the docker binary is run with to add or
+// remove an interface of a running container, to limit its bandwidth, or to
+// create the network namespace of a container.
+const networkSetupName = "docker-network-setup"
+
+func init() {
+	reexec.Register(networkSetupName, networkSetup)
+}
+
+// SetupVeths creates veths in the network namespace of pid, after bringing
+// up its loopback interface. It is the veth strategy of libcontainer. The
+// calling thread is left in the namespace, it's meant to be run by a process
+// of its own.
+func SetupVeths(pid int, veths []*Veth) error {
+	ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return setupVeths(ns, veths)
+}
+
+// setupVeths is SetupVeths for the network namespace ns.
+func setupVeths(ns *os.File, veths []*Veth) error {
+	tmpNames := make([]string, len(veths))
+	for i, v := range veths {
+		var err error
+		if tmpNames[i], err = utils.GenerateRandomName("veth", 7); err != nil {
+			return err
+		}
+		if err := createVeth(v, tmpNames[i], ns); err != nil {
+			return err
+		}
+	}
+
+	// The rest happens in the network namespace of the container.
+	if err := joinNetworkNamespace(ns); err != nil {
+		return err
+	}
+
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		return err
+	}
+	if err := netlink.NetworkLinkUp(lo); err != nil {
+		return err
+	}
+	for i, v := range veths {
+		if err := setupVeth(v, tmpNames[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinNetworkNamespace(ns *os.File) error {
+	runtime.LockOSThread()
+	return system.Setns(ns.Fd(), syscall.CLONE_NEWNET)
+}
+
+// createVeth creates the veth pair of v, attaches it to the bridge and moves
+// its peer, named tmpName, into the network namespace ns. An interface on a
+// parent is created by createOnParent instead.
+func createVeth(v *Veth, tmpName string, ns *os.File) (err error) {
+	if v.Type != "" && v.Type != "veth" {
+		return createOnParent(v, tmpName, ns)
+	}
+	defer func() {
+		if err != nil {
+			netlink.NetworkLinkDel(v.HostInterfaceName)
+		}
+	}()
+	bridge, err := net.InterfaceByName(v.Bridge)
+	if err != nil {
+		return err
+	}
+	if err := netlink.NetworkCreateVethPair(v.HostInterfaceName, tmpName, 0); err != nil {
+		return err
+	}
+	host, err := net.InterfaceByName(v.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	if err := netlink.AddToBridge(host, bridge); err != nil {
+		return err
+	}
+	if err := netlink.NetworkSetMTU(host, v.Mtu); err != nil {
+		return err
+	}
+	if err := netlink.NetworkLinkUp(host); err != nil {
+		return err
+	}
+	child, err := net.InterfaceByName(tmpName)
+	if err != nil {
+		return err
+	}
+	return netlink.NetworkSetNsFd(child, int(ns.Fd()))
+}
+
+// createOnParent creates v, a macvlan or ipvlan interface on its parent,
+// named tmpName, and moves it into the network namespace ns.
+func createOnParent(v *Veth, tmpName string, ns *os.File) error {
+	var err error
+	switch v.Type {
+	case "macvlan":
+		err = netlink.NetworkLinkAddMacVlan(v.Parent, tmpName, v.Mode)
+	case "ipvlan":
+		err = addIpvlan(v.Parent, tmpName, v.Mode)
+	default:
+		return fmt.Errorf("Unknown interface type %s", v.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("Unable to create the %s interface on %s: %v", v.Type, v.Parent, err)
+	}
+	iface, err := net.InterfaceByName(tmpName)
+	if err == nil {
+		err = netlink.NetworkSetNsFd(iface, int(ns.Fd()))
+	}
+	if err != nil {
+		netlink.NetworkLinkDel(tmpName)
+		return err
+	}
+	return nil
+}
+
+// setupVeth renames the peer of v, named tmpName, and configures it. It has
+// to be run in the network namespace of the container.
+func setupVeth(v *Veth, tmpName string) error {
+	name := v.Name
+	if name == "" {
+		var err error
+		if name, err = freeInterfaceName(); err != nil {
+			return err
+		}
+	}
+	child, err := net.InterfaceByName(tmpName)
+	if err != nil {
+		return err
+	}
+	if err := netlink.NetworkChangeName(child, name); err != nil {
+		return err
+	}
+	// get the interface again after we changed the name as the index also changes.
+	if child, err = net.InterfaceByName(name); err != nil {
+		return err
+	}
+	if v.MacAddress != "" {
+		if err := netlink.NetworkSetMacAddress(child, v.MacAddress); err != nil {
+			return err
+		}
+	}
+	ip, ipNet, err := net.ParseCIDR(v.Address)
+	if err != nil {
+		return err
+	}
+	if err := netlink.NetworkLinkAddIp(child, ip, ipNet); err != nil {
+		return err
+	}
+	if v.IPv6Address != "" {
+		if ip, ipNet, err = net.ParseCIDR(v.IPv6Address); err != nil {
+			return err
+		}
+		if err := netlink.NetworkLinkAddIp(child, ip, ipNet); err != nil {
+			return err
+		}
+	}
+	if v.Mtu != 0 {
+		if err := netlink.NetworkSetMTU(child, v.Mtu); err != nil {
+			return err
+		}
+	}
+	if err := netlink.NetworkLinkUp(child); err != nil {
+		return err
+	}
+	if v.Gateway != "" {
+		if err := netlink.AddDefaultGw(v.Gateway, name); err != nil {
+			return err
+		}
+	}
+	if v.IPv6Gateway != "" {
+		if err := netlink.AddDefaultGw(v.IPv6Gateway, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freeInterfaceName returns the first ethN that isn't the name of an
+// interface of the current network namespace.
+func freeInterfaceName() (string, error) {
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("eth%d", i)
+		if _, err := net.InterfaceByName(name); err != nil {
+			return name, nil
+		}
+	}
+}
+
+// AddInterface creates v in the network namespace of pid, the process of a
+// running container.
+func AddInterface(pid int, v *Veth) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return runNetworkSetup("add", strconv.Itoa(pid), string(b))
+}
+
+// RemoveInterface removes the interface whose MAC address, or IP address,
+// is addr from the network namespace of pid, the process of a running
+// container. Its peer on the host goes with it.
+func RemoveInterface(pid int, addr string) error {
+	return runNetworkSetup("remove", strconv.Itoa(pid), addr)
+}
+
+// CreateNetworkNamespace creates a network namespace bound to path, with the
+// interfaces of veths, for a container to join. It lives on after the
+// container, until RemoveNetworkNamespace.
+func CreateNetworkNamespace(path string, veths []*Veth) error {
+	b, err := json.Marshal(veths)
+	if err != nil {
+		return err
+	}
+	return runNetworkSetup("netns", path, string(b))
+}
+
+// RemoveNetworkNamespace removes the network namespace bound to path, if
+// there is one, and its interfaces with it.
+func RemoveNetworkNamespace(path string) error {
+	syscall.Unmount(path, syscall.MNT_DETACH)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func runNetworkSetup(args ...string) error {
+	cmd := reexec.Command(append([]string{networkSetupName}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(output) != 0 {
+			return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+	return nil
+}
+
+// networkSetup is run to add or remove an interface of a running container,
+// to limit its bandwidth, or to create the network namespace of a container,
+// as a process of its own since it joins the network namespace.
+func networkSetup() {
+	if len(os.Args) != 4 {
+		networkSetupFatal(fmt.Errorf("Usage: %s add|remove|shape PID VETH|ADDRESS|BANDWIDTH, or %[1]s netns PATH VETHS", networkSetupName))
+	}
+	var err error
+	switch os.Args[1] {
+	case "add", "remove", "shape":
+		var pid int
+		if pid, err = strconv.Atoi(os.Args[2]); err != nil {
+			break
+		}
+		switch os.Args[1] {
+		case "remove":
+			err = removeVeth(pid, os.Args[3])
+		case "shape":
+			b := &Bandwidth{}
+			if err = json.Unmarshal([]byte(os.Args[3]), b); err == nil {
+				err = limitBandwidth(pid, b)
+			}
+		default:
+			v := &Veth{}
+			if err = json.Unmarshal([]byte(os.Args[3]), v); err == nil {
+				err = SetupVeths(pid, []*Veth{v})
+			}
+		}
+	case "netns":
+		var veths []*Veth
+		if err = json.Unmarshal([]byte(os.Args[3]), &veths); err == nil {
+			err = createNetworkNamespace(os.Args[2], veths)
+		}
+	default:
+		err = fmt.Errorf("Unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		networkSetupFatal(err)
+	}
+	os.Exit(0)
+}
+
+// createNetworkNamespace creates a network namespace, binds it to path, and
+// sets up veths in it. The calling thread is left in the namespace.
+func createNetworkNamespace(path string, veths []*Veth) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	f.Close()
+	defer func() {
+		if err != nil {
+			RemoveNetworkNamespace(path)
+		}
+	}()
+
+	runtime.LockOSThread()
+	self := fmt.Sprintf("/proc/self/task/%d/ns/net", syscall.Gettid())
+	host, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer host.Close()
+	if err := syscall.Unshare(syscall.CLONE_NEWNET); err != nil {
+		return err
+	}
+	if err := syscall.Mount(self, path, "", syscall.MS_BIND, ""); err != nil {
+		return err
+	}
+	if err := system.Setns(host.Fd(), syscall.CLONE_NEWNET); err != nil {
+		return err
+	}
+
+	ns, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	return setupVeths(ns, veths)
+}
+
+func networkSetupFatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// removeVeth removes the interface whose MAC address, or IP address, is
+// addr. The interfaces of the ipvlan driver share the MAC address of their
+// parent, they are removed by IP address.
+func removeVeth(pid int, addr string) error {
+	ns, err := os.Open(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+	if err := joinNetworkNamespace(ns); err != nil {
+		return err
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if iface.HardwareAddr.String() == addr {
+			return netlink.NetworkLinkDel(iface.Name)
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return err
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.String() == addr {
+				return netlink.NetworkLinkDel(iface.Name)
+			}
+		}
+	}
+	return fmt.Errorf("No interface with address %s", addr)
+}
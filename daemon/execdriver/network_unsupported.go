@@ -0,0 +1,17 @@
+// +build !linux
+
+package execdriver
+
+import "fmt"
+
+func AddInterface(pid int, v *Veth) error {
+	return fmt.Errorf("Adding interfaces to running containers is not supported on this platform")
+}
+
+func RemoveInterface(pid int, addr string) error {
+	return fmt.Errorf("Removing interfaces from running containers is not supported on this platform")
+}
+
+func LimitBandwidth(pid int, b *Bandwidth) error {
+	return fmt.Errorf("Limiting the bandwidth of containers is not supported on this platform")
+}
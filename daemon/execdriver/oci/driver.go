@@ -0,0 +1,627 @@
+// +build linux
+
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	sysinfo "github.com/docker/docker/pkg/system"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/utils"
+	"github.com/kr/pty"
+)
+
+const DriverName = "oci"
+
+// startTimeout is how long a runtime has to create the process of a
+// container once it is started.
+const startTimeout = 10 * time.Second
+
+// driver runs containers with a runtime implementing the command line of
+// runc, from OCI bundles.
+type driver struct {
+	name             string // name of the runtime in the daemon configuration
+	path             string // path to the binary of the runtime
+	root             string // bundles of the containers, and the state of the runtime in root/state
+	activeContainers map[string]*activeContainer
+	machineMemory    int64
+	sync.Mutex
+}
+
+type activeContainer struct {
+	container *configs.Config
+	pid       int
+}
+
+// NewDriver returns the driver running containers with the runtime called
+// name, whose binary is at path.
+func NewDriver(name, path, root string) (*driver, error) {
+	path, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("Runtime %s: %v", name, err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "state"), 0700); err != nil {
+		return nil, err
+	}
+	meminfo, err := sysinfo.ReadMemInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &driver{
+		name:             name,
+		path:             path,
+		root:             root,
+		activeContainers: make(map[string]*activeContainer),
+		machineMemory:    meminfo.MemTotal,
+	}, nil
+}
+
+func (d *driver) Name() string {
+	return fmt.Sprintf("%s-%s", DriverName, d.name)
+}
+
+// bundle returns the directory of the bundle of the container id.
+func (d *driver) bundle(id string) string {
+	return filepath.Join(d.root, id)
+}
+
+// command returns the command running the runtime with args.
+func (d *driver) command(args ...string) *exec.Cmd {
+	return exec.Command(d.path, append([]string{"--root", filepath.Join(d.root, "state")}, args...)...)
+}
+
+// runtime runs the runtime with args and returns its output.
+func (d *driver) runtime(stdin io.Reader, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := d.command(args...)
+	cmd.Stdin = stdin
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v: %s", d.name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func (d *driver) Run(c *execdriver.Command, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	container, err := createContainer(c, d.namespacePaths)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	s, err := createSpec(c, container)
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	var networks []stateNetwork
+	if newNetworkNamespace(container) {
+		veths, err := newVeths(c)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		for _, v := range veths {
+			// The interfaces on parents don't have a peer on the host
+			// to get the statistics of.
+			if v.HostInterfaceName != "" {
+				networks = append(networks, stateNetwork{Type: "veth", HostInterfaceName: v.HostInterfaceName})
+			}
+		}
+		h, err := newNetworkHook(veths)
+		if err != nil {
+			return execdriver.ExitStatus{ExitCode: -1}, err
+		}
+		s.Hooks = &hooks{Prestart: []hook{h}}
+	}
+
+	bundle := d.bundle(c.ID)
+	if err := writeJSON(filepath.Join(bundle, "config.json"), s); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	// The runtime keeps the state of a container it couldn't delete, which
+	// would prevent running it again.
+	d.runtime(nil, "delete", "--force", c.ID)
+
+	var term execdriver.Terminal
+	if c.ProcessConfig.Tty {
+		term, err = NewTtyConsole(&c.ProcessConfig, pipes)
+	} else {
+		term, err = execdriver.NewStdConsole(&c.ProcessConfig, pipes)
+	}
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	c.ProcessConfig.Terminal = term
+
+	runtimeLog := filepath.Join(bundle, "runtime.log")
+	pidFile := filepath.Join(bundle, "pid")
+	os.Remove(pidFile)
+	cmd := d.command("--log", runtimeLog, "run", "--bundle", bundle, "--pid-file", pidFile, c.ID)
+	c.ProcessConfig.Path = cmd.Path
+	c.ProcessConfig.Args = cmd.Args
+	logrus.Debugf("%s params %s", d.name, cmd.Args)
+
+	if err := c.ProcessConfig.Start(); err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+
+	var (
+		waitErr  error
+		waitLock = make(chan struct{})
+	)
+	go func() {
+		if err := c.ProcessConfig.Wait(); err != nil {
+			if _, ok := err.(*exec.ExitError); !ok { // Do not propagate the error if it's simply a status code != 0
+				waitErr = err
+			}
+		}
+		close(waitLock)
+	}()
+
+	terminate := func(terr error) (execdriver.ExitStatus, error) {
+		if c.ProcessConfig.Process != nil {
+			c.ProcessConfig.Process.Kill()
+			<-waitLock
+		}
+		d.runtime(nil, "delete", "--force", c.ID)
+		return execdriver.ExitStatus{ExitCode: -1}, terr
+	}
+	pid, err := waitForPid(pidFile, waitLock)
+	if err != nil {
+		return terminate(err)
+	}
+	if pid == -1 {
+		// The runtime exited before creating the container.
+		return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("%s failed to start the container: %s", d.name, lastLine(runtimeLog, waitErr, c))
+	}
+
+	d.Lock()
+	d.activeContainers[c.ID] = &activeContainer{
+		container: container,
+		pid:       pid,
+	}
+	d.Unlock()
+	defer func() {
+		d.Lock()
+		delete(d.activeContainers, c.ID)
+		d.Unlock()
+	}()
+
+	cgroupPaths := cgroupPaths(s.Linux.CgroupsPath)
+	if err := writeJSON(filepath.Join(bundle, "state.json"), &state{
+		InitProcessPid: pid,
+		CgroupPaths:    cgroupPaths,
+		Networks:       networks,
+	}); err != nil {
+		return terminate(err)
+	}
+
+	c.ContainerPid = pid
+
+	if startCallback != nil {
+		logrus.Debugf("Invoking startCallback")
+		startCallback(&c.ProcessConfig, pid)
+	}
+
+	oomKill := false
+	oomKillNotification, err := execdriver.NotifyOnOOM(cgroupPaths)
+
+	<-waitLock
+	// The runtime removes the cgroups of the container when it is deleted.
+	d.runtime(nil, "delete", "--force", c.ID)
+
+	if err == nil {
+		// A runtime which doesn't use the cgroups of the container would
+		// leave them, and never notify.
+		if _, err := os.Stat(cgroupPaths["memory"]); os.IsNotExist(err) {
+			_, oomKill = <-oomKillNotification
+		}
+		logrus.Debugf("oomKill %t waitErr %v", oomKill, waitErr)
+	} else {
+		logrus.Warnf("Your kernel does not support OOM notifications: %s", err)
+	}
+
+	exitCode := getExitCode(&c.ProcessConfig)
+	if oomKill {
+		exitCode = 137
+	}
+	return execdriver.ExitStatus{ExitCode: exitCode, OOMKilled: oomKill}, waitErr
+}
+
+// newNetworkNamespace returns whether the container gets a network namespace
+// of its own, rather than joining one.
+func newNetworkNamespace(container *configs.Config) bool {
+	for _, ns := range container.Namespaces {
+		if ns.Type == configs.NEWNET {
+			return ns.Path == ""
+		}
+	}
+	return false
+}
+
+// newVeths returns the network interfaces of the container of c: eth0, and
+// one more for each of the other networks it's connected to.
+func newVeths(c *execdriver.Command) ([]*execdriver.Veth, error) {
+	if c.Network.Interface == nil {
+		return nil, nil
+	}
+	var veths []*execdriver.Veth
+	for i, iface := range append([]*execdriver.NetworkInterface{c.Network.Interface}, c.Network.Interfaces...) {
+		v, err := execdriver.NewVeth(fmt.Sprintf("eth%d", i), iface, c.Network.Mtu)
+		if err != nil {
+			return nil, err
+		}
+		veths = append(veths, v)
+	}
+	return veths, nil
+}
+
+// state is the state of a running container, state.json in its bundle, as
+// execdriver.Stats reads it.
+type state struct {
+	InitProcessPid int               `json:"init_process_pid"`
+	CgroupPaths    map[string]string `json:"cgroup_paths"`
+	Networks       []stateNetwork    `json:"networks"`
+}
+
+type stateNetwork struct {
+	Type              string
+	HostInterfaceName string
+}
+
+// cgroupPaths returns the paths of the cgroups at path in the hierarchy of
+// every subsystem that is mounted.
+func cgroupPaths(path string) map[string]string {
+	paths := make(map[string]string)
+	subsystems, err := cgroups.GetAllSubsystems()
+	if err != nil {
+		return paths
+	}
+	for _, subsystem := range subsystems {
+		mountpoint, err := cgroups.FindCgroupMountpoint(subsystem)
+		if err != nil {
+			continue
+		}
+		paths[subsystem] = filepath.Join(mountpoint, path)
+	}
+	return paths
+}
+
+// waitForPid waits for the runtime to write the pid of the process of a
+// container to pidFile. It returns -1 if the runtime exits without writing
+// it; the process may have run and exited already otherwise.
+func waitForPid(pidFile string, waitLock chan struct{}) (int, error) {
+	for now := time.Now(); time.Since(now) < startTimeout; {
+		if pid, err := readPid(pidFile); err == nil {
+			return pid, nil
+		}
+		select {
+		case <-waitLock:
+			if pid, err := readPid(pidFile); err == nil {
+				return pid, nil
+			}
+			return -1, nil
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	return -1, execdriver.ErrNotRunning
+}
+
+func readPid(pidFile string) (int, error) {
+	b, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// lastLine returns the last line the runtime logged, or how it exited if it
+// logged nothing.
+func lastLine(runtimeLog string, waitErr error, c *execdriver.Command) string {
+	var line string
+	if f, err := os.Open(runtimeLog); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if l := strings.TrimSpace(scanner.Text()); l != "" {
+				line = l
+			}
+		}
+	}
+	if line != "" {
+		return line
+	}
+	if waitErr != nil {
+		return waitErr.Error()
+	}
+	return fmt.Sprintf("exit status %d", getExitCode(&c.ProcessConfig))
+}
+
+func writeJSON(path string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// getExitCode returns the exit code of the process, -1 if it hasn't exited.
+func getExitCode(processConfig *execdriver.ProcessConfig) int {
+	if processConfig.ProcessState == nil {
+		return -1
+	}
+	return processConfig.ProcessState.Sys().(syscall.WaitStatus).ExitStatus()
+}
+
+// namespacePaths returns the paths of the namespaces of the running
+// container id.
+func (d *driver) namespacePaths(id string) (map[configs.NamespaceType]string, error) {
+	d.Lock()
+	active := d.activeContainers[id]
+	d.Unlock()
+	if active == nil {
+		return nil, fmt.Errorf("%s is not a valid running container to join", id)
+	}
+	return map[configs.NamespaceType]string{
+		configs.NEWNET: fmt.Sprintf("/proc/%d/ns/net", active.pid),
+		configs.NEWIPC: fmt.Sprintf("/proc/%d/ns/ipc", active.pid),
+		configs.NEWPID: fmt.Sprintf("/proc/%d/ns/pid", active.pid),
+	}, nil
+}
+
+func (d *driver) Exec(c *execdriver.Command, processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes, startCallback execdriver.StartCallback) (int, error) {
+	d.Lock()
+	active := d.activeContainers[c.ID]
+	d.Unlock()
+	if active == nil {
+		return -1, fmt.Errorf("No active container exists with ID %s", c.ID)
+	}
+
+	p, err := newProcess(c, processConfig, active.container)
+	if err != nil {
+		return -1, err
+	}
+	name, err := utils.GenerateRandomName("exec-", 16)
+	if err != nil {
+		return -1, err
+	}
+	processFile := filepath.Join(d.bundle(c.ID), name+".json")
+	pidFile := filepath.Join(d.bundle(c.ID), name+".pid")
+	defer os.Remove(processFile)
+	defer os.Remove(pidFile)
+	if err := writeJSON(processFile, p); err != nil {
+		return -1, err
+	}
+
+	if processConfig.SysProcAttr == nil {
+		processConfig.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	}
+	var term execdriver.Terminal
+	if processConfig.Tty {
+		term, err = NewTtyConsole(processConfig, pipes)
+	} else {
+		term, err = execdriver.NewStdConsole(processConfig, pipes)
+	}
+	if err != nil {
+		return -1, err
+	}
+	processConfig.Terminal = term
+
+	cmd := d.command("exec", "--process", processFile, "--pid-file", pidFile, c.ID)
+	processConfig.Path = cmd.Path
+	processConfig.Args = cmd.Args
+	if err := processConfig.Start(); err != nil {
+		return -1, err
+	}
+
+	waitLock := make(chan struct{})
+	var waitErr error
+	go func() {
+		if err := processConfig.Wait(); err != nil {
+			if _, ok := err.(*exec.ExitError); !ok {
+				waitErr = err
+			}
+		}
+		close(waitLock)
+	}()
+
+	pid, err := waitForPid(pidFile, waitLock)
+	if err != nil {
+		processConfig.Process.Kill()
+		<-waitLock
+		return -1, err
+	}
+	if pid == -1 {
+		pid = processConfig.Process.Pid
+	}
+	if startCallback != nil {
+		startCallback(processConfig, pid)
+	}
+
+	<-waitLock
+	return getExitCode(processConfig), waitErr
+}
+
+func (d *driver) Kill(c *execdriver.Command, sig int) error {
+	_, err := d.runtime(nil, "kill", c.ID, strconv.Itoa(sig))
+	return err
+}
+
+func (d *driver) Pause(c *execdriver.Command) error {
+	_, err := d.runtime(nil, "pause", c.ID)
+	return err
+}
+
+func (d *driver) Unpause(c *execdriver.Command) error {
+	_, err := d.runtime(nil, "resume", c.ID)
+	return err
+}
+
+// Update gives the runtime the resources of the command for the cgroups of
+// the running container.
+func (d *driver) Update(c *execdriver.Command) error {
+	d.Lock()
+	defer d.Unlock()
+	active := d.activeContainers[c.ID]
+	if active == nil {
+		return execdriver.ErrNotRunning
+	}
+	container := *active.container
+	cgroup := *container.Cgroups
+	container.Cgroups = &cgroup
+	if err := execdriver.SetupCgroups(&container, c); err != nil {
+		return err
+	}
+	r := specResources(&cgroup)
+	r.Devices = nil
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := d.runtime(bytes.NewReader(b), "update", "--resources", "-", c.ID); err != nil {
+		return err
+	}
+	active.container = &container
+	return nil
+}
+
+func (d *driver) Restore(c *execdriver.Command, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	return execdriver.ExitStatus{ExitCode: -1}, fmt.Errorf("Restoring running containers is not supported by the %s runtime", d.name)
+}
+
+func (d *driver) Terminate(c *execdriver.Command) error {
+	_, err := d.runtime(nil, "delete", "--force", c.ID)
+	return err
+}
+
+type info struct {
+	ID     string
+	driver *driver
+}
+
+func (i *info) IsRunning() bool {
+	i.driver.Lock()
+	defer i.driver.Unlock()
+	_, ok := i.driver.activeContainers[i.ID]
+	return ok
+}
+
+func (d *driver) Info(id string) execdriver.Info {
+	return &info{
+		ID:     id,
+		driver: d,
+	}
+}
+
+func (d *driver) GetPidsForContainer(id string) ([]int, error) {
+	output, err := d.runtime(nil, "ps", "--format", "json", id)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	if err := json.Unmarshal(output, &pids); err != nil {
+		return nil, fmt.Errorf("Error decoding the processes of %s: %v", id, err)
+	}
+	return pids, nil
+}
+
+// Clean removes the bundle of the container.
+func (d *driver) Clean(id string) error {
+	return os.RemoveAll(d.bundle(id))
+}
+
+func (d *driver) Stats(id string) (*execdriver.ResourceStats, error) {
+	d.Lock()
+	active := d.activeContainers[id]
+	d.Unlock()
+	if active == nil {
+		return nil, execdriver.ErrNotRunning
+	}
+	return execdriver.Stats(d.bundle(id), active.container.Cgroups.Memory, d.machineMemory)
+}
+
+type TtyConsole struct {
+	MasterPty *os.File
+	SlavePty  *os.File
+}
+
+// NewTtyConsole gives the runtime a pty as its standard streams. The runtime
+// creates the console of the container, and copies between the two.
+func NewTtyConsole(processConfig *execdriver.ProcessConfig, pipes *execdriver.Pipes) (*TtyConsole, error) {
+	ptyMaster, ptySlave, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	tty := &TtyConsole{
+		MasterPty: ptyMaster,
+		SlavePty:  ptySlave,
+	}
+
+	if err := tty.AttachPipes(&processConfig.Cmd, pipes); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	return tty, nil
+}
+
+func (t *TtyConsole) Master() *os.File {
+	return t.MasterPty
+}
+
+func (t *TtyConsole) Resize(h, w int) error {
+	return term.SetWinsize(t.MasterPty.Fd(), &term.Winsize{Height: uint16(h), Width: uint16(w)})
+}
+
+func (t *TtyConsole) AttachPipes(command *exec.Cmd, pipes *execdriver.Pipes) error {
+	command.Stdout = t.SlavePty
+	command.Stderr = t.SlavePty
+
+	go func() {
+		if wb, ok := pipes.Stdout.(interface {
+			CloseWriters() error
+		}); ok {
+			defer wb.CloseWriters()
+		}
+
+		io.Copy(pipes.Stdout, t.MasterPty)
+	}()
+
+	if pipes.Stdin != nil {
+		command.Stdin = t.SlavePty
+		// The runtime resizes the console of the container when its
+		// controlling terminal is.
+		command.SysProcAttr.Setctty = true
+
+		go func() {
+			io.Copy(t.MasterPty, pipes.Stdin)
+
+			pipes.Stdin.Close()
+		}()
+	}
+	return nil
+}
+
+func (t *TtyConsole) Close() error {
+	t.SlavePty.Close()
+	return t.MasterPty.Close()
+}
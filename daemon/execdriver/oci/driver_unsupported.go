@@ -0,0 +1,15 @@
+// +build !linux
+
+package oci
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/daemon/execdriver"
+)
+
+const DriverName = "oci"
+
+func NewDriver(name, path, root string) (execdriver.Driver, error) {
+	return nil, fmt.Errorf("oci driver not supported on non-linux")
+}
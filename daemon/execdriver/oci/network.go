@@ -0,0 +1,60 @@
+// +build linux
+
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/reexec"
+)
+
+// networkHookName is the name the docker binary is run with as the prestart
+// hook setting up the network of the containers.
+const networkHookName = "docker-oci-network"
+
+func init() {
+	reexec.Register(networkHookName, networkHook)
+}
+
+// newNetworkHook returns the prestart hook that brings up the loopback
+// interface of a container, and creates veths.
+func newNetworkHook(veths []*execdriver.Veth) (hook, error) {
+	b, err := json.Marshal(veths)
+	if err != nil {
+		return hook{}, err
+	}
+	return hook{
+		// The binary of the daemon, even if it was replaced since it started.
+		Path: fmt.Sprintf("/proc/%d/exe", os.Getpid()),
+		Args: []string{networkHookName, string(b)},
+	}, nil
+}
+
+// networkHook is run by the runtime once the namespaces of a container are
+// created, with the state of the container on its standard input.
+func networkHook() {
+	var state struct {
+		Pid int `json:"pid"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&state); err != nil {
+		fatal(fmt.Errorf("Error decoding the state of the container: %v", err))
+	}
+	var veths []*execdriver.Veth
+	if len(os.Args) > 1 {
+		if err := json.Unmarshal([]byte(os.Args[1]), &veths); err != nil {
+			fatal(err)
+		}
+	}
+	if err := execdriver.SetupVeths(state.Pid, veths); err != nil {
+		fatal(err)
+	}
+	os.Exit(0)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,573 @@
+// +build linux
+
+package oci
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/docker/pkg/ulimit"
+	"github.com/docker/libcontainer/configs"
+	"github.com/docker/libcontainer/devices"
+	"github.com/docker/libcontainer/user"
+)
+
+// specVersion is the version of the OCI runtime specification the
+// configuration of the containers follows.
+const specVersion = "1.0.2"
+
+// spec is the configuration of a container, config.json in its bundle, as
+// defined by the OCI runtime specification. Only the settings Docker uses are
+// declared.
+type spec struct {
+	Version  string  `json:"ociVersion"`
+	Process  process `json:"process"`
+	Root     root    `json:"root"`
+	Hostname string  `json:"hostname,omitempty"`
+	Mounts   []mount `json:"mounts"`
+	Hooks    *hooks  `json:"hooks,omitempty"`
+	Linux    linux   `json:"linux"`
+}
+
+type process struct {
+	Terminal        bool          `json:"terminal,omitempty"`
+	User            processUser   `json:"user"`
+	Args            []string      `json:"args"`
+	Env             []string      `json:"env,omitempty"`
+	Cwd             string        `json:"cwd"`
+	Capabilities    *capabilities `json:"capabilities,omitempty"`
+	Rlimits         []rlimit      `json:"rlimits,omitempty"`
+	ApparmorProfile string        `json:"apparmorProfile,omitempty"`
+	SelinuxLabel    string        `json:"selinuxLabel,omitempty"`
+	OOMScoreAdj     *int          `json:"oomScoreAdj,omitempty"`
+}
+
+type processUser struct {
+	UID            uint32   `json:"uid"`
+	GID            uint32   `json:"gid"`
+	AdditionalGids []uint32 `json:"additionalGids,omitempty"`
+}
+
+type capabilities struct {
+	Bounding    []string `json:"bounding"`
+	Effective   []string `json:"effective"`
+	Inheritable []string `json:"inheritable"`
+	Permitted   []string `json:"permitted"`
+}
+
+type rlimit struct {
+	Type string `json:"type"`
+	Hard uint64 `json:"hard"`
+	Soft uint64 `json:"soft"`
+}
+
+type root struct {
+	Path     string `json:"path"`
+	Readonly bool   `json:"readonly,omitempty"`
+}
+
+type mount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type,omitempty"`
+	Source      string   `json:"source,omitempty"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type hooks struct {
+	Prestart []hook `json:"prestart,omitempty"`
+}
+
+type hook struct {
+	Path string   `json:"path"`
+	Args []string `json:"args,omitempty"`
+}
+
+type linux struct {
+	Namespaces    []namespace `json:"namespaces"`
+	Devices       []device    `json:"devices,omitempty"`
+	CgroupsPath   string      `json:"cgroupsPath,omitempty"`
+	Resources     *resources  `json:"resources,omitempty"`
+	MaskedPaths   []string    `json:"maskedPaths,omitempty"`
+	ReadonlyPaths []string    `json:"readonlyPaths,omitempty"`
+	MountLabel    string      `json:"mountLabel,omitempty"`
+}
+
+type namespace struct {
+	Type string `json:"type"`
+	Path string `json:"path,omitempty"`
+}
+
+type device struct {
+	Type     string `json:"type"`
+	Path     string `json:"path"`
+	Major    int64  `json:"major"`
+	Minor    int64  `json:"minor"`
+	FileMode uint32 `json:"fileMode"`
+	UID      uint32 `json:"uid"`
+	GID      uint32 `json:"gid"`
+}
+
+type resources struct {
+	Devices []deviceCgroup `json:"devices,omitempty"`
+	Memory  *memory        `json:"memory,omitempty"`
+	CPU     *cpu           `json:"cpu,omitempty"`
+	BlockIO *blockIO       `json:"blockIO,omitempty"`
+	Pids    *pids          `json:"pids,omitempty"`
+}
+
+type deviceCgroup struct {
+	Allow  bool   `json:"allow"`
+	Type   string `json:"type,omitempty"`
+	Major  *int64 `json:"major,omitempty"`
+	Minor  *int64 `json:"minor,omitempty"`
+	Access string `json:"access,omitempty"`
+}
+
+type memory struct {
+	Limit            *int64 `json:"limit,omitempty"`
+	Reservation      *int64 `json:"reservation,omitempty"`
+	Swap             *int64 `json:"swap,omitempty"`
+	DisableOOMKiller *bool  `json:"disableOOMKiller,omitempty"`
+}
+
+type cpu struct {
+	Shares *uint64 `json:"shares,omitempty"`
+	Quota  *int64  `json:"quota,omitempty"`
+	Period *uint64 `json:"period,omitempty"`
+	Cpus   string  `json:"cpus,omitempty"`
+}
+
+type blockIO struct {
+	Weight                  *uint16          `json:"weight,omitempty"`
+	ThrottleReadBpsDevice   []throttleDevice `json:"throttleReadBpsDevice,omitempty"`
+	ThrottleWriteBpsDevice  []throttleDevice `json:"throttleWriteBpsDevice,omitempty"`
+	ThrottleReadIOPSDevice  []throttleDevice `json:"throttleReadIOPSDevice,omitempty"`
+	ThrottleWriteIOPSDevice []throttleDevice `json:"throttleWriteIOPSDevice,omitempty"`
+}
+
+type throttleDevice struct {
+	Major int64  `json:"major"`
+	Minor int64  `json:"minor"`
+	Rate  uint64 `json:"rate"`
+}
+
+type pids struct {
+	Limit int64 `json:"limit"`
+}
+
+var namespaceTypes = map[configs.NamespaceType]string{
+	configs.NEWNS:   "mount",
+	configs.NEWUTS:  "uts",
+	configs.NEWIPC:  "ipc",
+	configs.NEWPID:  "pid",
+	configs.NEWNET:  "network",
+	configs.NEWUSER: "user",
+}
+
+var rlimitTypes = map[int]string{
+	ulimit.RLIMIT_AS:         "RLIMIT_AS",
+	ulimit.RLIMIT_CORE:       "RLIMIT_CORE",
+	ulimit.RLIMIT_CPU:        "RLIMIT_CPU",
+	ulimit.RLIMIT_DATA:       "RLIMIT_DATA",
+	ulimit.RLIMIT_FSIZE:      "RLIMIT_FSIZE",
+	ulimit.RLIMIT_LOCKS:      "RLIMIT_LOCKS",
+	ulimit.RLIMIT_MEMLOCK:    "RLIMIT_MEMLOCK",
+	ulimit.RLIMIT_MSGQUEUE:   "RLIMIT_MSGQUEUE",
+	ulimit.RLIMIT_NICE:       "RLIMIT_NICE",
+	ulimit.RLIMIT_NOFILE:     "RLIMIT_NOFILE",
+	ulimit.RLIMIT_NPROC:      "RLIMIT_NPROC",
+	ulimit.RLIMIT_RSS:        "RLIMIT_RSS",
+	ulimit.RLIMIT_RTPRIO:     "RLIMIT_RTPRIO",
+	ulimit.RLIMIT_RTTIME:     "RLIMIT_RTTIME",
+	ulimit.RLIMIT_SIGPENDING: "RLIMIT_SIGPENDING",
+	ulimit.RLIMIT_STACK:      "RLIMIT_STACK",
+}
+
+// mountOptions are the options of the mount flags, with their recursive
+// variant if they have one.
+var mountOptions = []struct {
+	flag        int
+	option, rec string
+}{
+	{flag: syscall.MS_RDONLY, option: "ro"},
+	{flag: syscall.MS_NOSUID, option: "nosuid"},
+	{flag: syscall.MS_NODEV, option: "nodev"},
+	{flag: syscall.MS_NOEXEC, option: "noexec"},
+	{flag: syscall.MS_STRICTATIME, option: "strictatime"},
+	{flag: syscall.MS_BIND, option: "bind", rec: "rbind"},
+	{flag: syscall.MS_PRIVATE, option: "private", rec: "rprivate"},
+	{flag: syscall.MS_SLAVE, option: "slave", rec: "rslave"},
+	{flag: syscall.MS_SHARED, option: "shared", rec: "rshared"},
+}
+
+// createContainer returns the libcontainer configuration of the container of
+// c, which joins the namespaces of other containers of the runtime at nsPaths.
+func createContainer(c *execdriver.Command, nsPaths func(id string) (map[configs.NamespaceType]string, error)) (*configs.Config, error) {
+	container := execdriver.InitContainer(c)
+
+	if c.Network.HostNetworking {
+		container.Namespaces.Remove(configs.NEWNET)
+	} else if c.Network.ContainerID != "" {
+		paths, err := nsPaths(c.Network.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		container.Namespaces.Add(configs.NEWNET, paths[configs.NEWNET])
+	}
+	if c.Ipc.HostIpc {
+		container.Namespaces.Remove(configs.NEWIPC)
+	} else if c.Ipc.ContainerID != "" {
+		paths, err := nsPaths(c.Ipc.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		container.Namespaces.Add(configs.NEWIPC, paths[configs.NEWIPC])
+	}
+	if c.Pid.HostPid {
+		container.Namespaces.Remove(configs.NEWPID)
+	} else if c.Pid.ContainerID != "" {
+		paths, err := nsPaths(c.Pid.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		container.Namespaces.Add(configs.NEWPID, paths[configs.NEWPID])
+	}
+	if c.UTS.HostUTS {
+		container.Namespaces.Remove(configs.NEWUTS)
+		container.Hostname = ""
+	}
+
+	if c.ProcessConfig.Privileged {
+		// clear readonly for /sys
+		for i := range container.Mounts {
+			if container.Mounts[i].Destination == "/sys" {
+				container.Mounts[i].Flags &= ^syscall.MS_RDONLY
+			}
+		}
+		container.ReadonlyPaths = nil
+		container.MaskPaths = nil
+		container.Capabilities = execdriver.GetAllCapabilities()
+		container.Cgroups.AllowAllDevices = true
+		hostDevices, err := devices.HostDevices()
+		if err != nil {
+			return nil, err
+		}
+		container.Devices = hostDevices
+	} else {
+		var err error
+		if container.Capabilities, err = execdriver.TweakCapabilities(container.Capabilities, c.CapAdd, c.CapDrop); err != nil {
+			return nil, err
+		}
+	}
+	if c.AppArmorProfile != "" {
+		container.AppArmorProfile = c.AppArmorProfile
+	}
+	if err := execdriver.SetupCgroups(container, c); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// createSpec returns the OCI configuration of container, the container of c.
+func createSpec(c *execdriver.Command, container *configs.Config) (*spec, error) {
+	s := &spec{
+		Version: specVersion,
+		Root: root{
+			Path:     c.Rootfs,
+			Readonly: c.ReadonlyRootfs,
+		},
+		Hostname: container.Hostname,
+		Linux: linux{
+			CgroupsPath:   cgroupsPath(c),
+			MaskedPaths:   container.MaskPaths,
+			ReadonlyPaths: container.ReadonlyPaths,
+			MountLabel:    c.MountLabel,
+		},
+	}
+
+	p, err := newProcess(c, &c.ProcessConfig, container)
+	if err != nil {
+		return nil, err
+	}
+	s.Process = *p
+
+	for _, ns := range container.Namespaces {
+		s.Linux.Namespaces = append(s.Linux.Namespaces, namespace{Type: namespaceTypes[ns.Type], Path: ns.Path})
+	}
+	if s.Mounts, err = specMounts(container, c); err != nil {
+		return nil, err
+	}
+	for _, d := range container.Devices {
+		s.Linux.Devices = append(s.Linux.Devices, device{
+			Type:     string(d.Type),
+			Path:     d.Path,
+			Major:    d.Major,
+			Minor:    d.Minor,
+			FileMode: uint32(d.FileMode),
+			UID:      d.Uid,
+			GID:      d.Gid,
+		})
+	}
+	s.Linux.Resources = specResources(container.Cgroups)
+	if c.Resources != nil && c.Resources.PidsLimit > 0 {
+		s.Linux.Resources.Pids = &pids{Limit: c.Resources.PidsLimit}
+	}
+	if c.Resources != nil {
+		specThrottleDevices(s.Linux.Resources, c.Resources)
+	}
+	return s, nil
+}
+
+// newProcess returns the OCI process running processConfig in the container
+// of c.
+func newProcess(c *execdriver.Command, processConfig *execdriver.ProcessConfig, container *configs.Config) (*process, error) {
+	p := &process{
+		Terminal:        processConfig.Tty,
+		Args:            append([]string{processConfig.Entrypoint}, processConfig.Arguments...),
+		Env:             processConfig.Env,
+		Cwd:             c.WorkingDir,
+		ApparmorProfile: container.AppArmorProfile,
+		SelinuxLabel:    c.ProcessLabel,
+	}
+	if p.Env == nil {
+		p.Env = c.ProcessConfig.Env
+	}
+	if p.Cwd == "" {
+		p.Cwd = "/"
+	}
+	if c.Resources != nil && c.Resources.OomScoreAdj != 0 {
+		p.OOMScoreAdj = &c.Resources.OomScoreAdj
+	}
+
+	userSpec := processConfig.User
+	if userSpec == "" {
+		userSpec = c.ProcessConfig.User
+	}
+	execUser, err := lookupUser(c.Rootfs, userSpec)
+	if err != nil {
+		return nil, err
+	}
+	p.User.UID, p.User.GID = uint32(execUser.Uid), uint32(execUser.Gid)
+	for _, gid := range execUser.Sgids {
+		p.User.AdditionalGids = append(p.User.AdditionalGids, uint32(gid))
+	}
+	if !hasEnv(p.Env, "HOME") {
+		p.Env = append(p.Env, "HOME="+execUser.Home)
+	}
+
+	caps := container.Capabilities
+	if processConfig.Privileged {
+		caps = execdriver.GetAllCapabilities()
+	}
+	p.Capabilities = &capabilities{}
+	for _, name := range caps {
+		name = "CAP_" + name
+		p.Capabilities.Bounding = append(p.Capabilities.Bounding, name)
+		p.Capabilities.Effective = append(p.Capabilities.Effective, name)
+		p.Capabilities.Inheritable = append(p.Capabilities.Inheritable, name)
+		p.Capabilities.Permitted = append(p.Capabilities.Permitted, name)
+	}
+
+	if c.Resources != nil {
+		for _, l := range c.Resources.Rlimits {
+			p.Rlimits = append(p.Rlimits, rlimit{Type: rlimitTypes[l.Type], Hard: l.Hard, Soft: l.Soft})
+		}
+	}
+	return p, nil
+}
+
+// lookupUser returns the user of userSpec in the /etc/passwd and /etc/group
+// files of rootfs, root by default.
+func lookupUser(rootfs, userSpec string) (*user.ExecUser, error) {
+	passwdPath, err := symlink.FollowSymlinkInScope(filepath.Join(rootfs, "/etc/passwd"), rootfs)
+	if err != nil {
+		return nil, err
+	}
+	groupPath, err := symlink.FollowSymlinkInScope(filepath.Join(rootfs, "/etc/group"), rootfs)
+	if err != nil {
+		return nil, err
+	}
+	execUser, err := user.GetExecUserPath(userSpec, &user.ExecUser{Home: "/"}, passwdPath, groupPath)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to find user %s: %v", userSpec, err)
+	}
+	return execUser, nil
+}
+
+func hasEnv(env []string, key string) bool {
+	for _, e := range env {
+		if strings.SplitN(e, "=", 2)[0] == key {
+			return true
+		}
+	}
+	return false
+}
+
+// cgroupsPath returns the path of the cgroups of the container of c,
+// relative to the root of the hierarchies.
+func cgroupsPath(c *execdriver.Command) string {
+	parent := "docker"
+	if c.CgroupParent != "" {
+		parent = c.CgroupParent
+	}
+	return filepath.Join("/", parent, c.ID)
+}
+
+// specMounts returns the default mounts of container that c doesn't
+// override, followed by the mounts of c.
+func specMounts(container *configs.Config, c *execdriver.Command) ([]mount, error) {
+	userMounts := make(map[string]struct{})
+	for _, m := range c.Mounts {
+		userMounts[m.Destination] = struct{}{}
+	}
+
+	var mounts []mount
+	_, mountDev := userMounts["/dev"]
+	for _, m := range container.Mounts {
+		if _, ok := userMounts[m.Destination]; ok {
+			continue
+		}
+		if mountDev && strings.HasPrefix(m.Destination, "/dev/") {
+			continue
+		}
+		mounts = append(mounts, mount{
+			Destination: m.Destination,
+			Type:        m.Device,
+			Source:      m.Source,
+			Options:     specMountOptions(m.Flags, m.Data),
+		})
+	}
+
+	for _, m := range c.Mounts {
+		dest, err := symlink.FollowSymlinkInScope(filepath.Join(c.Rootfs, m.Destination), c.Rootfs)
+		if err != nil {
+			return nil, err
+		}
+		dest, err = filepath.Rel(c.Rootfs, dest)
+		if err != nil {
+			return nil, err
+		}
+		flags := syscall.MS_BIND | syscall.MS_REC
+		if !m.Writable {
+			flags |= syscall.MS_RDONLY
+		}
+		if m.Slave {
+			flags |= syscall.MS_SLAVE
+		}
+		mounts = append(mounts, mount{
+			Destination: filepath.Join("/", dest),
+			Type:        "bind",
+			Source:      m.Source,
+			Options:     specMountOptions(flags, ""),
+		})
+	}
+	return mounts, nil
+}
+
+// specMountOptions returns the OCI mount options of the mount flags and data.
+func specMountOptions(flags int, data string) []string {
+	var options []string
+	for _, o := range mountOptions {
+		if flags&o.flag == 0 {
+			continue
+		}
+		if o.rec != "" && flags&syscall.MS_REC != 0 {
+			options = append(options, o.rec)
+		} else {
+			options = append(options, o.option)
+		}
+	}
+	if data != "" {
+		options = append(options, strings.Split(data, ",")...)
+	}
+	return options
+}
+
+// specResources returns the OCI resources of cgroup.
+func specResources(cgroup *configs.Cgroup) *resources {
+	r := &resources{}
+	if cgroup.AllowAllDevices {
+		r.Devices = []deviceCgroup{{Allow: true, Access: "rwm"}}
+	} else {
+		r.Devices = []deviceCgroup{{Allow: false, Access: "rwm"}}
+		for _, d := range cgroup.AllowedDevices {
+			r.Devices = append(r.Devices, deviceCgroup{
+				Allow:  true,
+				Type:   string(d.Type),
+				Major:  deviceNumber(d.Major),
+				Minor:  deviceNumber(d.Minor),
+				Access: d.Permissions,
+			})
+		}
+	}
+
+	if cgroup.Memory != 0 || cgroup.MemorySwap != 0 || cgroup.OomKillDisable {
+		r.Memory = &memory{}
+		if cgroup.Memory != 0 {
+			r.Memory.Limit = &cgroup.Memory
+			r.Memory.Reservation = &cgroup.MemoryReservation
+		}
+		if cgroup.MemorySwap != 0 {
+			r.Memory.Swap = &cgroup.MemorySwap
+		}
+		if cgroup.OomKillDisable {
+			r.Memory.DisableOOMKiller = &cgroup.OomKillDisable
+		}
+	}
+	if cgroup.CpuShares != 0 || cgroup.CpuPeriod != 0 || cgroup.CpuQuota != 0 || cgroup.CpusetCpus != "" {
+		r.CPU = &cpu{Cpus: cgroup.CpusetCpus}
+		if cgroup.CpuShares != 0 {
+			shares := uint64(cgroup.CpuShares)
+			r.CPU.Shares = &shares
+		}
+		if cgroup.CpuQuota != 0 {
+			r.CPU.Quota = &cgroup.CpuQuota
+		}
+		if cgroup.CpuPeriod != 0 {
+			period := uint64(cgroup.CpuPeriod)
+			r.CPU.Period = &period
+		}
+	}
+	if cgroup.BlkioWeight != 0 {
+		weight := uint16(cgroup.BlkioWeight)
+		r.BlockIO = &blockIO{Weight: &weight}
+	}
+	return r
+}
+
+// specThrottleDevices adds the block IO limits of the devices of resources
+// to r.
+func specThrottleDevices(r *resources, resources *execdriver.Resources) {
+	if len(resources.DeviceReadBps) == 0 && len(resources.DeviceWriteBps) == 0 &&
+		len(resources.DeviceReadIOps) == 0 && len(resources.DeviceWriteIOps) == 0 {
+		return
+	}
+	if r.BlockIO == nil {
+		r.BlockIO = &blockIO{}
+	}
+	r.BlockIO.ThrottleReadBpsDevice = specThrottleDevice(resources.DeviceReadBps)
+	r.BlockIO.ThrottleWriteBpsDevice = specThrottleDevice(resources.DeviceWriteBps)
+	r.BlockIO.ThrottleReadIOPSDevice = specThrottleDevice(resources.DeviceReadIOps)
+	r.BlockIO.ThrottleWriteIOPSDevice = specThrottleDevice(resources.DeviceWriteIOps)
+}
+
+// specThrottleDevice returns the OCI block IO limits of devices.
+func specThrottleDevice(devices []execdriver.ThrottleDevice) []throttleDevice {
+	var throttleDevices []throttleDevice
+	for _, d := range devices {
+		throttleDevices = append(throttleDevices, throttleDevice{Major: d.Major, Minor: d.Minor, Rate: d.Rate})
+	}
+	return throttleDevices
+}
+
+// deviceNumber returns the OCI major or minor number of n, nil for any.
+func deviceNumber(n int64) *int64 {
+	if n == configs.Wildcard {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,87 @@
+// +build linux
+
+package oci
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/libcontainer/configs"
+)
+
+func TestSpecMountOptions(t *testing.T) {
+	cases := []struct {
+		flags   int
+		data    string
+		options []string
+	}{
+		{0, "", nil},
+		{syscall.MS_NOSUID | syscall.MS_NOEXEC | syscall.MS_NODEV, "mode=755", []string{"nosuid", "nodev", "noexec", "mode=755"}},
+		{syscall.MS_BIND | syscall.MS_RDONLY, "", []string{"ro", "bind"}},
+		{syscall.MS_BIND | syscall.MS_REC | syscall.MS_SLAVE, "", []string{"rbind", "rslave"}},
+		{syscall.MS_STRICTATIME, "mode=1777,size=65536k", []string{"strictatime", "mode=1777", "size=65536k"}},
+	}
+	for _, c := range cases {
+		if options := specMountOptions(c.flags, c.data); !reflect.DeepEqual(options, c.options) {
+			t.Fatalf("Expected the options of %#x %q to be %v, got %v", c.flags, c.data, c.options, options)
+		}
+	}
+}
+
+func TestSpecResources(t *testing.T) {
+	r := specResources(&configs.Cgroup{
+		AllowedDevices: []*configs.Device{
+			{Type: 'c', Major: 1, Minor: 3, Permissions: "rwm"},
+			{Type: 'c', Major: configs.Wildcard, Minor: configs.Wildcard, Permissions: "m"},
+		},
+		Memory:         1 << 20,
+		OomKillDisable: true,
+		CpuShares:      512,
+		CpuPeriod:      50000,
+		CpuQuota:       25000,
+		BlkioWeight:    300,
+	})
+
+	if len(r.Devices) != 3 || r.Devices[0].Allow {
+		t.Fatalf("Expected all the devices to be denied before the allowed ones, got %+v", r.Devices)
+	}
+	if d := r.Devices[1]; !d.Allow || d.Type != "c" || *d.Major != 1 || *d.Minor != 3 || d.Access != "rwm" {
+		t.Fatalf("Expected /dev/null to be allowed, got %+v", d)
+	}
+	if d := r.Devices[2]; d.Major != nil || d.Minor != nil {
+		t.Fatalf("Expected wildcard device numbers to be left out, got %+v", d)
+	}
+	if r.Memory == nil || *r.Memory.Limit != 1<<20 || r.Memory.Swap != nil || !*r.Memory.DisableOOMKiller {
+		t.Fatalf("Expected a memory limit without swap limit nor OOM killer, got %+v", r.Memory)
+	}
+	if r.CPU == nil || *r.CPU.Shares != 512 {
+		t.Fatalf("Expected 512 CPU shares, got %+v", r.CPU)
+	}
+	if *r.CPU.Period != 50000 || *r.CPU.Quota != 25000 {
+		t.Fatalf("Expected a CPU quota of 25000 per period of 50000, got %+v", r.CPU)
+	}
+	if r.BlockIO == nil || *r.BlockIO.Weight != 300 {
+		t.Fatalf("Expected a block IO weight of 300, got %+v", r.BlockIO)
+	}
+
+	r = specResources(&configs.Cgroup{AllowAllDevices: true})
+	if len(r.Devices) != 1 || !r.Devices[0].Allow || r.Memory != nil || r.CPU != nil || r.BlockIO != nil {
+		t.Fatalf("Expected all the devices to be allowed without limits, got %+v", r)
+	}
+
+	specThrottleDevices(r, &execdriver.Resources{})
+	if r.BlockIO != nil {
+		t.Fatalf("Expected no block IO limits, got %+v", r.BlockIO)
+	}
+	specThrottleDevices(r, &execdriver.Resources{
+		DeviceReadBps:   []execdriver.ThrottleDevice{{Major: 8, Minor: 0, Rate: 1048576}},
+		DeviceWriteIOps: []execdriver.ThrottleDevice{{Major: 8, Minor: 16, Rate: 100}},
+	})
+	if r.BlockIO == nil || !reflect.DeepEqual(r.BlockIO.ThrottleReadBpsDevice, []throttleDevice{{8, 0, 1048576}}) ||
+		!reflect.DeepEqual(r.BlockIO.ThrottleWriteIOPSDevice, []throttleDevice{{8, 16, 100}}) ||
+		r.BlockIO.ThrottleWriteBpsDevice != nil || r.BlockIO.ThrottleReadIOPSDevice != nil {
+		t.Fatalf("Expected a read bps limit of 8:0 and a write iops limit of 8:16, got %+v", r.BlockIO)
+	}
+}
@@ -2,7 +2,6 @@ package daemon
 
 import (
 	"fmt"
-	"io"
 
 	"github.com/docker/docker/engine"
 )
@@ -18,14 +17,8 @@ func (daemon *Daemon) ContainerExport(job *engine.Job) error {
 		return err
 	}
 
-	data, err := container.Export()
-	if err != nil {
-		return fmt.Errorf("%s: %s", name, err)
-	}
-	defer data.Close()
-
 	// Stream the entire contents of the container (basically a volatile snapshot)
-	if _, err := io.Copy(job.Stdout, data); err != nil {
+	if err := container.Export(job.Stdout); err != nil {
 		return fmt.Errorf("%s: %s", name, err)
 	}
 	// FIXME: factor job-specific LogEvent to engine.Job.Run()
@@ -0,0 +1,220 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/units"
+)
+
+// gcInterval is how often the daemon checks the usage of the filesystem of
+// its root against the watermarks.
+const gcInterval = time.Minute
+
+// validateGCWatermarks returns an error unless high and low are percents of
+// the space of a filesystem, low below high. A high watermark of 0 disables
+// the garbage collection.
+func validateGCWatermarks(high, low int) error {
+	if high == 0 {
+		return nil
+	}
+	if high < 0 || high > 100 {
+		return fmt.Errorf("Invalid --gc-high-watermark %d, it must be between 0 and 100", high)
+	}
+	if low < 0 || low >= high {
+		return fmt.Errorf("Invalid --gc-low-watermark %d, it must be between 0 and the high watermark", low)
+	}
+	return nil
+}
+
+// diskUsage returns the percent of the space of the filesystem of path in
+// use, out of the space available to unprivileged users, as df does.
+func diskUsage(path string) (int, error) {
+	var buf syscall.Statfs_t
+	if err := syscall.Statfs(path, &buf); err != nil {
+		return 0, err
+	}
+	return usedPercent(uint64(buf.Blocks), uint64(buf.Bfree), uint64(buf.Bavail)), nil
+}
+
+func usedPercent(blocks, free, avail uint64) int {
+	used := blocks - free
+	if used+avail == 0 {
+		return 0
+	}
+	// Rounded up, so that a filesystem is only 100% used when it's full
+	return int((used*100 + used + avail - 1) / (used + avail))
+}
+
+// gcExcluded returns whether labels match one of exclude, given as a key,
+// or as key=value.
+func gcExcluded(labels map[string]string, exclude []string) bool {
+	for _, e := range exclude {
+		parts := strings.SplitN(e, "=", 2)
+		value, exists := labels[parts[0]]
+		if exists && (len(parts) == 1 || parts[1] == value) {
+			return true
+		}
+	}
+	return false
+}
+
+// gcCandidate is a container or an image the garbage collection may
+// remove, since it exited or was created at a given time.
+type gcCandidate struct {
+	id    string
+	since time.Time
+}
+
+type gcCandidates []gcCandidate
+
+func (c gcCandidates) Len() int           { return len(c) }
+func (c gcCandidates) Less(i, j int) bool { return c[i].since.Before(c[j].since) }
+func (c gcCandidates) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// gcContainers returns the containers the garbage collection may remove,
+// those which ran and exited more than grace ago, since they exited, oldest
+// first. The containers created but never started are left to their users,
+// as are the ones with one of the labels excluded.
+func gcContainers(containers []*Container, exclude []string, grace time.Duration) gcCandidates {
+	var candidates gcCandidates
+	for _, container := range containers {
+		container.Lock()
+		exited := !container.Running && !container.Restarting && !container.StartedAt.IsZero() && !container.FinishedAt.IsZero()
+		finishedAt := container.FinishedAt
+		container.Unlock()
+		if !exited || time.Since(finishedAt) < grace || gcExcluded(container.Config.Labels, exclude) {
+			continue
+		}
+		candidates = append(candidates, gcCandidate{container.ID, finishedAt})
+	}
+	sort.Sort(candidates)
+	return candidates
+}
+
+// gcLoop checks the usage of the filesystem of the root of the daemon
+// every gcInterval, and collects garbage when it reaches the high
+// watermark.
+func (daemon *Daemon) gcLoop() {
+	for _ = range time.Tick(gcInterval) {
+		usage, err := diskUsage(daemon.config.Root)
+		if err != nil {
+			logrus.Errorf("Error getting the disk usage of %s: %v", daemon.config.Root, err)
+			continue
+		}
+		if usage >= daemon.config.GCHighWatermark {
+			daemon.collectGarbage(usage)
+		}
+	}
+}
+
+// collectGarbage removes the exited containers, those which exited first
+// first, then the dangling images, oldest first, until the usage of the
+// filesystem of the root of the daemon drops below the low watermark or
+// there is nothing left to remove. The containers and images with one of
+// the labels excluded are kept, as are the containers which exited within
+// the grace period, and the images registered since the previous check,
+// which a pull or a build in progress may not have tagged yet.
+func (daemon *Daemon) collectGarbage(usage int) {
+	var (
+		root      = daemon.config.Root
+		low       = daemon.config.GCLowWatermark
+		exclude   = daemon.config.GCExcludeLabels
+		reclaimed int64
+		nrCtrs    int
+		nrImgs    int
+	)
+	logrus.Infof("Disk usage of %s is %d%%, removing exited containers and dangling images", root, usage)
+	daemon.logGCEvent(fmt.Sprintf("gc_start: %d%% used", usage))
+
+	// below updates the usage after each removal, and returns whether it
+	// dropped below the low watermark.
+	below := func() bool {
+		if u, err := diskUsage(root); err == nil {
+			usage = u
+		}
+		return usage < low
+	}
+
+	for _, c := range gcContainers(daemon.List(), exclude, daemon.config.GCGracePeriod) {
+		if below() {
+			break
+		}
+		container, err := daemon.Get(c.id)
+		if err != nil {
+			// Removed since it was listed
+			continue
+		}
+		sizeRw, _ := container.GetSize()
+		if err := daemon.eng.Job("rm", container.ID).Run(); err != nil {
+			logrus.Warnf("Cannot remove container %s: %s", container.ID, err)
+			continue
+		}
+		reclaimed += sizeRw
+		nrCtrs++
+	}
+
+	// The images of the containers removed may be dangling now
+	if !below() {
+		images, err := daemon.Graph().Map()
+		if err != nil {
+			logrus.Errorf("Error listing the images: %v", err)
+		}
+		heads, err := daemon.Graph().Heads()
+		if err != nil {
+			logrus.Errorf("Error listing the images: %v", err)
+		}
+		refs := daemon.Repositories().ByID()
+		var dangling gcCandidates
+		for id, img := range heads {
+			if _, tagged := refs[id]; tagged {
+				continue
+			}
+			if img.Config != nil && gcExcluded(img.Config.Labels, exclude) {
+				continue
+			}
+			if fi, err := os.Stat(daemon.Graph().ImageRoot(id)); err != nil || time.Since(fi.ModTime()) < gcInterval {
+				continue
+			}
+			dangling = append(dangling, gcCandidate{id, img.Created})
+		}
+		sort.Sort(dangling)
+		for _, c := range dangling {
+			if below() {
+				break
+			}
+			// An image still used by a container can't be deleted, which
+			// is not worth a warning.
+			if err := daemon.canDeleteImage(c.id, false); err != nil {
+				continue
+			}
+			list := []types.ImageDelete{}
+			if err := daemon.DeleteImage(daemon.eng, c.id, &list, true, false, false); err != nil {
+				logrus.Warnf("Cannot remove image %s: %s", c.id, err)
+			}
+			for _, deleted := range list {
+				if img, exists := images[deleted.Deleted]; exists {
+					reclaimed += img.Size
+					nrImgs++
+				}
+			}
+		}
+	}
+
+	logrus.Infof("Removed %d containers and %d images, reclaiming %s, disk usage of %s is %d%%",
+		nrCtrs, nrImgs, units.HumanSize(float64(reclaimed)), root, usage)
+	daemon.logGCEvent(fmt.Sprintf("gc: %d containers, %d images, %s reclaimed, %d%% used",
+		nrCtrs, nrImgs, units.HumanSize(float64(reclaimed)), usage))
+}
+
+func (daemon *Daemon) logGCEvent(status string) {
+	if err := daemon.eng.Job("log", status, "daemon", "").Run(); err != nil {
+		logrus.Errorf("Error logging event %s: %s", status, err)
+	}
+}
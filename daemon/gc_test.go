@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestValidateGCWatermarks(t *testing.T) {
+	for _, w := range [][2]int{{0, 0}, {90, 80}, {90, 0}, {100, 99}} {
+		if err := validateGCWatermarks(w[0], w[1]); err != nil {
+			t.Fatalf("Unexpected error for %v: %s", w, err)
+		}
+	}
+	for _, w := range [][2]int{{-1, 0}, {101, 80}, {80, 80}, {80, 90}, {80, -1}} {
+		if err := validateGCWatermarks(w[0], w[1]); err == nil {
+			t.Fatalf("Expected an error for %v", w)
+		}
+	}
+}
+
+func TestUsedPercent(t *testing.T) {
+	for _, c := range []struct {
+		blocks, free, avail uint64
+		expected            int
+	}{
+		{100, 100, 100, 0},
+		{100, 50, 50, 50},
+		// The blocks reserved to root don't count
+		{100, 50, 40, 56},
+		{100, 0, 0, 100},
+		{1000, 1, 1, 100},
+		{0, 0, 0, 0},
+	} {
+		if used := usedPercent(c.blocks, c.free, c.avail); used != c.expected {
+			t.Fatalf("Expected %d%% for %+v, got %d%%", c.expected, c, used)
+		}
+	}
+}
+
+func TestGCExcluded(t *testing.T) {
+	labels := map[string]string{"keep": "", "com.example.tier": "db"}
+	for _, exclude := range [][]string{{"keep"}, {"com.example.tier"}, {"other", "com.example.tier=db"}} {
+		if !gcExcluded(labels, exclude) {
+			t.Fatalf("Expected %v to be excluded by %v", labels, exclude)
+		}
+	}
+	for _, exclude := range [][]string{nil, {"other"}, {"com.example.tier=web"}, {"keep=yes"}} {
+		if gcExcluded(labels, exclude) {
+			t.Fatalf("Expected %v not to be excluded by %v", labels, exclude)
+		}
+	}
+}
+
+func TestGCCandidatesOldestFirst(t *testing.T) {
+	now := time.Now()
+	candidates := gcCandidates{
+		{"b", now.Add(-time.Hour)},
+		{"c", now},
+		{"a", now.Add(-24 * time.Hour)},
+	}
+	sort.Sort(candidates)
+	for i, id := range []string{"a", "b", "c"} {
+		if candidates[i].id != id {
+			t.Fatalf("Expected %s at %d, got %s", id, i, candidates[i].id)
+		}
+	}
+}
+
+func TestGCContainers(t *testing.T) {
+	now := time.Now()
+	container := func(id string, started, finished time.Time, running bool, labels map[string]string) *Container {
+		c := &Container{ID: id, State: NewState(), Config: &runconfig.Config{Labels: labels}}
+		c.StartedAt, c.FinishedAt, c.Running = started, finished, running
+		return c
+	}
+	containers := []*Container{
+		container("recent", now.Add(-2*time.Hour), now.Add(-time.Minute), false, nil),
+		container("created", time.Time{}, time.Time{}, false, nil),
+		container("running", now.Add(-time.Hour), now.Add(-2*time.Hour), true, nil),
+		container("kept", now.Add(-48*time.Hour), now.Add(-47*time.Hour), false, map[string]string{"keep": ""}),
+		container("old", now.Add(-25*time.Hour), now.Add(-24*time.Hour), false, nil),
+	}
+	candidates := gcContainers(containers, []string{"keep"}, 0)
+	if len(candidates) != 2 || candidates[0].id != "old" || candidates[1].id != "recent" {
+		t.Fatalf("Expected the exited containers in the order they exited, got %v", candidates)
+	}
+	candidates = gcContainers(containers, []string{"keep"}, time.Hour)
+	if len(candidates) != 1 || candidates[0].id != "old" {
+		t.Fatalf("Expected the containers which exited more than an hour ago, got %v", candidates)
+	}
+}
@@ -10,16 +10,29 @@ package btrfs
 import "C"
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
 	"os"
 	"path"
+	"sync"
 	"syscall"
 	"unsafe"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/daemon/graphdriver"
 	"github.com/docker/docker/pkg/mount"
 )
 
+// The object ids and key types of the quota tree, from ctree.h of
+// btrfs-progs.
+const (
+	btrfsQuotaTreeObjectid = 8
+	btrfsFirstFreeObjectid = 256
+	btrfsQgroupInfoKey     = 242
+	btrfsQgroupLimitKey    = 244
+)
+
 func init() {
 	graphdriver.Register("btrfs", Init)
 }
@@ -53,6 +66,9 @@ func Init(home string, options []string) (graphdriver.Driver, error) {
 
 type Driver struct {
 	home string
+
+	sync.Mutex   // Protects quotaEnabled
+	quotaEnabled bool
 }
 
 func (d *Driver) String() string {
@@ -166,6 +182,163 @@ func subvolDelete(path, name string) error {
 	return nil
 }
 
+// subvolLimitQgroup limits the data the subvolume path refers to, shared
+// with the subvolume it's a snapshot of or not, to size bytes.
+func subvolLimitQgroup(path string, size uint64) error {
+	dir, err := openDir(path)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_qgroup_limit_args
+	args.lim.max_referenced = C.__u64(size)
+	args.lim.flags = C.BTRFS_QGROUP_LIMIT_MAX_RFER
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QGROUP_LIMIT,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to limit qgroup for %s: %v", path, errno.Error())
+	}
+	return nil
+}
+
+// subvolId returns the id of the subvolume path, which is also the id of
+// its quota group.
+func subvolId(path string) (uint64, error) {
+	dir, err := openDir(path)
+	if err != nil {
+		return 0, err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_ino_lookup_args
+	args.objectid = btrfsFirstFreeObjectid
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_INO_LOOKUP,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return 0, fmt.Errorf("Failed to look up btrfs subvolume id of %s: %v", path, errno.Error())
+	}
+	return uint64(args.treeid), nil
+}
+
+// searchQuotaTree returns the item of type keyType of the quota group
+// qgroupid, from the quota tree of the filesystem of path, or nil if there
+// isn't any, such as when the quotas are disabled.
+func searchQuotaTree(path string, keyType uint32, qgroupid uint64) ([]byte, error) {
+	dir, err := openDir(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_search_args
+	args.key.tree_id = btrfsQuotaTreeObjectid
+	args.key.min_type = C.__u32(keyType)
+	args.key.max_type = C.__u32(keyType)
+	args.key.min_offset = C.__u64(qgroupid)
+	args.key.max_offset = C.__u64(qgroupid)
+	args.key.max_transid = C.__u64(math.MaxUint64)
+	args.key.nr_items = 1
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_TREE_SEARCH,
+		uintptr(unsafe.Pointer(&args)))
+	if errno == syscall.ENOENT {
+		// There is no quota tree
+		return nil, nil
+	}
+	if errno != 0 {
+		return nil, fmt.Errorf("Failed to search btrfs quota tree of %s: %v", path, errno.Error())
+	}
+	if args.key.nr_items == 0 {
+		return nil, nil
+	}
+
+	// The buffer holds the header of the item, followed by the item
+	header := (*C.struct_btrfs_ioctl_search_header)(unsafe.Pointer(&args.buf[0]))
+	start := int(unsafe.Sizeof(*header))
+	buf := C.GoBytes(unsafe.Pointer(&args.buf[0]), C.int(len(args.buf)))
+	if start+int(header.len) > len(buf) {
+		return nil, fmt.Errorf("Invalid btrfs quota tree item of %s", path)
+	}
+	return buf[start : start+int(header.len)], nil
+}
+
+// subvolQgroupUsage returns the bytes the subvolume path refers to that no
+// other subvolume shares, and the limit of the data it refers to, 0 if it's
+// not limited. It returns graphdriver.ErrNotSupported if path has no quota
+// group.
+func subvolQgroupUsage(path string) (excl, maxRfer uint64, err error) {
+	id, err := subvolId(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// struct btrfs_qgroup_info_item is the generation of the quota group,
+	// followed by its rfer, rfer_cmpr, excl and excl_cmpr, and struct
+	// btrfs_qgroup_limit_item its flags, followed by its max_rfer, all
+	// little endian 64 bit integers.
+	info, err := searchQuotaTree(path, btrfsQgroupInfoKey, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(info) < 32 {
+		return 0, 0, graphdriver.ErrNotSupported
+	}
+	excl = binary.LittleEndian.Uint64(info[24:32])
+
+	limit, err := searchQuotaTree(path, btrfsQgroupLimitKey, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(limit) >= 16 && binary.LittleEndian.Uint64(limit[0:8])&C.BTRFS_QGROUP_LIMIT_MAX_RFER != 0 {
+		maxRfer = binary.LittleEndian.Uint64(limit[8:16])
+	}
+	return excl, maxRfer, nil
+}
+
+// qgroupDestroy destroys the quota group qgroupid of the filesystem of path.
+func qgroupDestroy(path string, qgroupid uint64) error {
+	dir, err := openDir(path)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_qgroup_create_args
+	args.qgroupid = C.__u64(qgroupid)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QGROUP_CREATE,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to destroy btrfs qgroup %d: %v", qgroupid, errno.Error())
+	}
+	return nil
+}
+
+// enableQuota enables the quota groups of the filesystem of the driver,
+// which limit the size of the subvolumes.
+func (d *Driver) enableQuota() error {
+	d.Lock()
+	defer d.Unlock()
+	if d.quotaEnabled {
+		return nil
+	}
+
+	dir, err := openDir(d.home)
+	if err != nil {
+		return err
+	}
+	defer closeDir(dir)
+
+	var args C.struct_btrfs_ioctl_quota_ctl_args
+	args.cmd = C.BTRFS_QUOTA_CTL_ENABLE
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, getDirFd(dir), C.BTRFS_IOC_QUOTA_CTL,
+		uintptr(unsafe.Pointer(&args)))
+	if errno != 0 {
+		return fmt.Errorf("Failed to enable btrfs quota for %s: %v", d.home, errno.Error())
+	}
+	d.quotaEnabled = true
+	return nil
+}
+
 func (d *Driver) subvolumesDir() string {
 	return path.Join(d.home, "subvolumes")
 }
@@ -195,17 +368,61 @@ func (d *Driver) Create(id string, parent string) error {
 	return nil
 }
 
+// CreateWithStorageOpt creates the subvolume id like Create does, with a
+// quota group limiting its size to the "size" of storageOpt.
+func (d *Driver) CreateWithStorageOpt(id, parent string, storageOpt map[string]string) error {
+	size, err := graphdriver.ParseStorageOptSize(storageOpt)
+	if err != nil {
+		return err
+	}
+	if err := d.Create(id, parent); err != nil {
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	if err := d.enableQuota(); err != nil {
+		d.Remove(id)
+		return err
+	}
+	if err := subvolLimitQgroup(d.subvolumesDirId(id), size); err != nil {
+		d.Remove(id)
+		return err
+	}
+	return nil
+}
+
 func (d *Driver) Remove(id string) error {
 	dir := d.subvolumesDirId(id)
 	if _, err := os.Stat(dir); err != nil {
 		return err
 	}
+	qgroupid, err := subvolId(dir)
+	if err != nil {
+		return err
+	}
 	if err := subvolDelete(d.subvolumesDir(), id); err != nil {
 		return err
 	}
+	// The quota group of the subvolume outlives it, if quotas are enabled
+	if err := qgroupDestroy(d.subvolumesDir(), qgroupid); err != nil {
+		logrus.Debugf("Failed to remove the qgroup of %s: %s", id, err)
+	}
 	return os.RemoveAll(dir)
 }
 
+// Usage returns the bytes of the subvolume id that no other subvolume
+// shares, and its size limit, from its quota group. It returns
+// graphdriver.ErrNotSupported when the quotas are disabled.
+func (d *Driver) Usage(id string) (size, limit int64, err error) {
+	excl, maxRfer, err := subvolQgroupUsage(d.subvolumesDirId(id))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(excl), int64(maxRfer), nil
+}
+
 func (d *Driver) Get(id, mountLabel string) (string, error) {
 	dir := d.subvolumesDirId(id)
 	st, err := os.Stat(dir)
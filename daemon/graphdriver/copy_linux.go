@@ -1,41 +1,25 @@
 // +build linux
 
-package overlay
+package graphdriver
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"syscall"
 
+	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/system"
 )
 
 type CopyFlags int
 
 const (
+	// CopyHardlink makes CopyDir link the regular files of the destination
+	// to the ones of the source instead of copying them.
 	CopyHardlink CopyFlags = 1 << iota
 )
 
-func copyRegular(srcPath, dstPath string, mode os.FileMode) error {
-	srcFile, err := os.Open(srcPath)
-	if err != nil {
-		return err
-	}
-	defer srcFile.Close()
-
-	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE, mode)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, srcFile)
-
-	return err
-}
-
 func copyXattr(srcPath, dstPath, attr string) error {
 	data, err := system.Lgetxattr(srcPath, attr)
 	if err != nil {
@@ -49,7 +33,28 @@ func copyXattr(srcPath, dstPath, attr string) error {
 	return nil
 }
 
-func copyDir(srcDir, dstDir string, flags CopyFlags) error {
+// CopyDir copies the content of srcDir to dstDir, with the ownership,
+// permissions, capabilities and times of each file. The regular files are
+// copied with archive.CopyRegular, which makes them share their data with
+// the source on the filesystems supporting reflinks, and the files linked
+// to each other in srcDir are in dstDir as well.
+func CopyDir(srcDir, dstDir string, flags CopyFlags) error {
+	type inode struct {
+		dev uint64
+		ino uint64
+	}
+	type dir struct {
+		path string
+		ts   []syscall.Timespec
+	}
+	var (
+		// links are the copies of the files of srcDir with several links
+		links = make(map[inode]string)
+		// dirs are the directories copied, whose times are set once their
+		// content is, as it changes them.
+		dirs []dir
+	)
+
 	err := filepath.Walk(srcDir, func(srcPath string, f os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -62,9 +67,6 @@ func copyDir(srcDir, dstDir string, flags CopyFlags) error {
 		}
 
 		dstPath := filepath.Join(dstDir, relPath)
-		if err != nil {
-			return err
-		}
 
 		stat, ok := f.Sys().(*syscall.Stat_t)
 		if !ok {
@@ -78,7 +80,15 @@ func copyDir(srcDir, dstDir string, flags CopyFlags) error {
 					return err
 				}
 			} else {
-				if err := copyRegular(srcPath, dstPath, f.Mode()); err != nil {
+				if stat.Nlink > 1 {
+					key := inode{uint64(stat.Dev), uint64(stat.Ino)}
+					if link, exists := links[key]; exists {
+						// The metadata is the one of the copy linked to
+						return os.Link(link, dstPath)
+					}
+					links[key] = dstPath
+				}
+				if err := archive.CopyRegular(srcPath, dstPath, f.Mode()); err != nil {
 					return err
 				}
 			}
@@ -87,6 +97,7 @@ func copyDir(srcDir, dstDir string, flags CopyFlags) error {
 			if err := os.Mkdir(dstPath, f.Mode()); err != nil && !os.IsExist(err) {
 				return err
 			}
+			dirs = append(dirs, dir{dstPath, []syscall.Timespec{stat.Atim, stat.Mtim}})
 
 		case os.ModeSymlink:
 			link, err := os.Readlink(srcPath)
@@ -140,6 +151,9 @@ func copyDir(srcDir, dstDir string, flags CopyFlags) error {
 			}
 		}
 
+		if f.IsDir() {
+			return nil
+		}
 		ts := []syscall.Timespec{stat.Atim, stat.Mtim}
 		// syscall.UtimesNano doesn't support a NOFOLLOW flag atm, and
 		if !isSymlink {
@@ -153,5 +167,16 @@ func copyDir(srcDir, dstDir string, flags CopyFlags) error {
 		}
 		return nil
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// The deepest directories go first, for their times not to change
+	// their parents' ones
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := system.UtimesNano(dirs[i].path, dirs[i].ts); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,86 @@
+// +build linux
+
+package graphdriver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCopyDir(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-test-copy-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src")
+	if err := os.MkdirAll(filepath.Join(src, "dir"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "dir", "file"), []byte("content"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(src, "dir", "file"), filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("dir/file", filepath.Join(src, "symlink")); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1430000000, 0)
+	if err := os.Chtimes(filepath.Join(src, "dir"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(tmp, "dst")
+	if err := os.Mkdir(dst, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyDir(src, dst, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "dir", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "content" {
+		t.Fatalf("Expected the content of the file to be copied, got %q", content)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "symlink")); err != nil || link != "dir/file" {
+		t.Fatalf("Expected a symlink to dir/file, got %q (%v)", link, err)
+	}
+
+	// The files linked to each other in src are in dst, and not to src
+	var file, link, orig syscall.Stat_t
+	if err := syscall.Stat(filepath.Join(dst, "dir", "file"), &file); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Stat(filepath.Join(dst, "link"), &link); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Stat(filepath.Join(src, "link"), &orig); err != nil {
+		t.Fatal(err)
+	}
+	if file.Ino != link.Ino || file.Ino == orig.Ino {
+		t.Fatalf("Expected the links of dst to be linked to each other only")
+	}
+	if file.Mode&0777 != 0640 {
+		t.Fatalf("Expected the mode of the file to be 0640, got %o", file.Mode&0777)
+	}
+
+	fi, err := os.Stat(filepath.Join(dst, "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(mtime) {
+		t.Fatalf("Expected the directory to be modified at %s, got %s", mtime, fi.ModTime())
+	}
+	if fi.Mode().Perm() != 0750 {
+		t.Fatalf("Expected the mode of the directory to be 0750, got %o", fi.Mode().Perm())
+	}
+}
@@ -0,0 +1,23 @@
+// +build daemon,!linux
+
+package graphdriver
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/pkg/chrootarchive"
+)
+
+type CopyFlags int
+
+const (
+	CopyHardlink CopyFlags = 1 << iota
+)
+
+// CopyDir copies the content of srcDir to dstDir through a tar archive.
+func CopyDir(srcDir, dstDir string, flags CopyFlags) error {
+	if flags&CopyHardlink != 0 {
+		return fmt.Errorf("Linking the files of a copy is not supported")
+	}
+	return chrootarchive.CopyWithTar(srcDir, dstDir)
+}
@@ -0,0 +1,266 @@
+// +build linux
+
+package devmapper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// The volume group, thin pool and metadata profile of LVM the daemon
+// creates on the block device of dm.directlvm_device.
+const (
+	lvmVolumeGroup  = "docker"
+	lvmThinPool     = "thinpool"
+	lvmProfileName  = "docker-thinpool"
+	lvmProfileDir   = "/etc/lvm/profile"
+	lvmSetupCfgFile = "setup-config.json"
+)
+
+// directLVMConfig is the configuration of the thin pool the daemon sets up
+// on a block device, which it keeps in the root of the driver to only set
+// it up once.
+type directLVMConfig struct {
+	Device              string
+	ThinpPercent        uint64
+	ThinpMetaPercent    uint64
+	AutoExtendPercent   uint64
+	AutoExtendThreshold uint64
+}
+
+func newDirectLVMConfig() *directLVMConfig {
+	return &directLVMConfig{
+		ThinpPercent:        95,
+		ThinpMetaPercent:    1,
+		AutoExtendPercent:   20,
+		AutoExtendThreshold: 80,
+	}
+}
+
+func validateLVMConfig(cfg *directLVMConfig) error {
+	if cfg.Device == "" {
+		return fmt.Errorf("dm.directlvm_device is required to set up direct-lvm")
+	}
+	if cfg.ThinpPercent == 0 || cfg.ThinpMetaPercent == 0 {
+		return fmt.Errorf("dm.thinp_percent and dm.thinp_metapercent must be greater than 0")
+	}
+	if cfg.ThinpPercent+cfg.ThinpMetaPercent > 100 {
+		return fmt.Errorf("The sum of dm.thinp_percent and dm.thinp_metapercent must not be greater than 100")
+	}
+	if cfg.AutoExtendThreshold == 0 || cfg.AutoExtendThreshold > 100 {
+		return fmt.Errorf("dm.thinp_autoextend_threshold must be between 1 and 100")
+	}
+	if cfg.AutoExtendPercent == 0 {
+		return fmt.Errorf("dm.thinp_autoextend_percent must be greater than 0")
+	}
+	return nil
+}
+
+// checkDevAvailable returns an error unless dev is a block device LVM can
+// use.
+func checkDevAvailable(dev string) error {
+	fi, err := os.Stat(dev)
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeDevice == 0 || fi.Mode()&os.ModeCharDevice != 0 {
+		return fmt.Errorf("%s is not a block device", dev)
+	}
+
+	out, err := exec.Command("lvmdiskscan").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("Error running lvmdiskscan: %v: %s", err, out)
+	}
+	if !bytes.Contains(out, []byte(dev)) {
+		return fmt.Errorf("%s is not available for use with devicemapper", dev)
+	}
+	return nil
+}
+
+// checkDevInVG returns an error if dev is a physical volume of a volume
+// group already.
+func checkDevInVG(dev string) error {
+	out, err := exec.Command("pvdisplay", dev).CombinedOutput()
+	if err != nil {
+		// dev is not a physical volume
+		return nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		fields := strings.SplitAfter(strings.TrimSpace(s.Text()), "VG Name")
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			return fmt.Errorf("%s is already part of volume group %q", dev, strings.TrimSpace(fields[1]))
+		}
+	}
+	return s.Err()
+}
+
+// checkDevHasFS returns an error if dev holds a filesystem.
+func checkDevHasFS(dev string) error {
+	out, err := exec.Command("blkid", dev, "-s", "TYPE", "-o", "value").CombinedOutput()
+	if err != nil {
+		// blkid exits with 2 when it finds nothing on dev
+		if exitErr, ok := err.(*exec.ExitError); ok && len(out) == 0 && !exitErr.Success() {
+			return nil
+		}
+		return fmt.Errorf("Error running blkid: %v: %s", err, out)
+	}
+	if fs := strings.TrimSpace(string(out)); fs != "" {
+		return fmt.Errorf("%s has a filesystem already, use dm.directlvm_device_force=true if you want to wipe the device", dev)
+	}
+	return nil
+}
+
+// readLVMConfig returns the configuration of the thin pool set up in root,
+// or an empty one if there isn't any.
+func readLVMConfig(root string) (*directLVMConfig, error) {
+	var cfg directLVMConfig
+
+	b, err := ioutil.ReadFile(path.Join(root, lvmSetupCfgFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &cfg, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("Error reading the direct-lvm configuration %s: %v", lvmSetupCfgFile, err)
+	}
+	return &cfg, nil
+}
+
+func writeLVMConfig(root string, cfg *directLVMConfig) error {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(root, lvmSetupCfgFile), b, 0600)
+}
+
+func lvm(args ...string) error {
+	logrus.Debugf("devmapper: running %s", strings.Join(args, " "))
+	if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Error running %s: %v: %s", args[0], err, out)
+	}
+	return nil
+}
+
+// lvmStep is a command of the setup of direct-lvm, with the command
+// removing what it created, if any.
+type lvmStep struct {
+	do, undo []string
+}
+
+// runLVMSteps runs steps in order with run. If one fails, it undoes the
+// steps it ran, in the reverse order, so that the setup can be tried again
+// on the device.
+func runLVMSteps(steps []lvmStep, run func(args ...string) error) error {
+	for i, step := range steps {
+		err := run(step.do...)
+		if err == nil {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if steps[j].undo == nil {
+				continue
+			}
+			if err := run(steps[j].undo...); err != nil {
+				logrus.Errorf("devmapper: error rolling back the direct-lvm setup: %v", err)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// setupDirectLVM turns the block device of cfg into a physical volume of
+// the docker volume group, with a thin pool of cfg.ThinpPercent of its
+// space and a metadata volume of cfg.ThinpMetaPercent, which LVM extends by
+// cfg.AutoExtendPercent when the pool is cfg.AutoExtendThreshold full.
+// Nothing is left on the device if it fails.
+func setupDirectLVM(cfg *directLVMConfig, chunkSize uint32, force bool) error {
+	if err := os.MkdirAll(lvmProfileDir, 0755); err != nil {
+		return err
+	}
+	profilePath := path.Join(lvmProfileDir, lvmProfileName+".profile")
+	profile := fmt.Sprintf("activation {\n\tthin_pool_autoextend_threshold=%d\n\tthin_pool_autoextend_percent=%d\n}\n",
+		cfg.AutoExtendThreshold, cfg.AutoExtendPercent)
+	if err := ioutil.WriteFile(profilePath, []byte(profile), 0644); err != nil {
+		return err
+	}
+
+	pvCreate := []string{"pvcreate", cfg.Device}
+	if force {
+		pvCreate = []string{"pvcreate", "-f", cfg.Device}
+	}
+	// Removing the volume group removes its logical volumes, the thin pool
+	// and its metadata volume whatever step they got to.
+	steps := []lvmStep{
+		{pvCreate, []string{"pvremove", "-y", cfg.Device}},
+		{[]string{"vgcreate", lvmVolumeGroup, cfg.Device}, []string{"vgremove", "-y", "-f", lvmVolumeGroup}},
+		{[]string{"lvcreate", "--wipesignatures", "y", "-n", lvmThinPool, lvmVolumeGroup, "--extents", fmt.Sprintf("%d%%VG", cfg.ThinpPercent)}, nil},
+		{[]string{"lvcreate", "--wipesignatures", "y", "-n", lvmThinPool + "meta", lvmVolumeGroup, "--extents", fmt.Sprintf("%d%%VG", cfg.ThinpMetaPercent)}, nil},
+		{[]string{"lvconvert", "-y", "--zero", "n", "-c", fmt.Sprintf("%dK", chunkSize/2),
+			"--thinpool", lvmVolumeGroup + "/" + lvmThinPool,
+			"--poolmetadata", lvmVolumeGroup + "/" + lvmThinPool + "meta"}, nil},
+		{[]string{"lvchange", "--metadataprofile", lvmProfileName, lvmVolumeGroup + "/" + lvmThinPool}, nil},
+	}
+	if err := runLVMSteps(steps, lvm); err != nil {
+		os.Remove(profilePath)
+		return err
+	}
+	return nil
+}
+
+// setupDirectLVM sets up the thin pool of cfg the first time the daemon
+// runs with it, and makes devices use it.
+func (devices *DeviceSet) setupDirectLVM(cfg *directLVMConfig, force bool) error {
+	if err := validateLVMConfig(cfg); err != nil {
+		return err
+	}
+
+	prevCfg, err := readLVMConfig(devices.root)
+	if err != nil {
+		return err
+	}
+	if prevCfg.Device != "" {
+		if !reflect.DeepEqual(prevCfg, cfg) {
+			return fmt.Errorf("Changing the direct-lvm configuration is not supported, it was set up with %+v", *prevCfg)
+		}
+	} else {
+		if err := checkDevAvailable(cfg.Device); err != nil {
+			return err
+		}
+		if err := checkDevInVG(cfg.Device); err != nil {
+			return err
+		}
+		if !force {
+			if err := checkDevHasFS(cfg.Device); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(devices.root, 0700); err != nil {
+			return err
+		}
+		if err := setupDirectLVM(cfg, devices.thinpBlockSize, force); err != nil {
+			return err
+		}
+		if err := writeLVMConfig(devices.root, cfg); err != nil {
+			return err
+		}
+	}
+
+	devices.thinPoolDevice = lvmVolumeGroup + "-" + lvmThinPool
+	return nil
+}
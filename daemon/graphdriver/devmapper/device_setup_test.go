@@ -0,0 +1,142 @@
+// +build linux
+
+package devmapper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestValidateLVMConfig(t *testing.T) {
+	valid := func() *directLVMConfig {
+		cfg := newDirectLVMConfig()
+		cfg.Device = "/dev/xvdf"
+		return cfg
+	}
+	for _, c := range []struct {
+		name  string
+		tweak func(*directLVMConfig)
+		valid bool
+	}{
+		{"defaults", func(cfg *directLVMConfig) {}, true},
+		{"whole device", func(cfg *directLVMConfig) { cfg.ThinpPercent, cfg.ThinpMetaPercent = 99, 1 }, true},
+		{"threshold of 100", func(cfg *directLVMConfig) { cfg.AutoExtendThreshold = 100 }, true},
+		{"no device", func(cfg *directLVMConfig) { cfg.Device = "" }, false},
+		{"no thin pool", func(cfg *directLVMConfig) { cfg.ThinpPercent = 0 }, false},
+		{"no metadata", func(cfg *directLVMConfig) { cfg.ThinpMetaPercent = 0 }, false},
+		{"more than the device", func(cfg *directLVMConfig) { cfg.ThinpPercent, cfg.ThinpMetaPercent = 95, 6 }, false},
+		{"no threshold", func(cfg *directLVMConfig) { cfg.AutoExtendThreshold = 0 }, false},
+		{"threshold over 100", func(cfg *directLVMConfig) { cfg.AutoExtendThreshold = 101 }, false},
+		{"no autoextend", func(cfg *directLVMConfig) { cfg.AutoExtendPercent = 0 }, false},
+	} {
+		cfg := valid()
+		c.tweak(cfg)
+		err := validateLVMConfig(cfg)
+		if c.valid && err != nil {
+			t.Fatalf("%s: expected %+v to be valid, got %v", c.name, *cfg, err)
+		}
+		if !c.valid && err == nil {
+			t.Fatalf("%s: expected %+v to be invalid", c.name, *cfg)
+		}
+	}
+}
+
+func TestReadLVMConfig(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		content  string // no setup-config.json if empty
+		expected *directLVMConfig
+		invalid  bool
+	}{
+		{"not set up", "", &directLVMConfig{}, false},
+		{
+			"set up",
+			`{"Device":"/dev/xvdf","ThinpPercent":90,"ThinpMetaPercent":2,"AutoExtendPercent":10,"AutoExtendThreshold":70}`,
+			&directLVMConfig{Device: "/dev/xvdf", ThinpPercent: 90, ThinpMetaPercent: 2, AutoExtendPercent: 10, AutoExtendThreshold: 70},
+			false,
+		},
+		{"corrupted", `{"Device":`, nil, true},
+	} {
+		root, err := ioutil.TempDir("", "docker-devmapper-lvm")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(root)
+		if c.content != "" {
+			if err := ioutil.WriteFile(path.Join(root, lvmSetupCfgFile), []byte(c.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg, err := readLVMConfig(root)
+		if c.invalid {
+			if err == nil {
+				t.Fatalf("%s: expected an error, got %+v", c.name, *cfg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(cfg, c.expected) {
+			t.Fatalf("%s: expected %+v, got %+v", c.name, *c.expected, *cfg)
+		}
+	}
+}
+
+func TestWriteAndReadLVMConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-devmapper-lvm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	cfg := newDirectLVMConfig()
+	cfg.Device = "/dev/xvdf"
+	if err := writeLVMConfig(root, cfg); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readLVMConfig(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, cfg) {
+		t.Fatalf("Expected %+v, got %+v", *cfg, *read)
+	}
+}
+
+func TestRunLVMStepsRollback(t *testing.T) {
+	steps := []lvmStep{
+		{[]string{"pvcreate", "/dev/xvdf"}, []string{"pvremove", "/dev/xvdf"}},
+		{[]string{"vgcreate", "docker", "/dev/xvdf"}, []string{"vgremove", "docker"}},
+		{[]string{"lvcreate", "thinpool"}, nil},
+		{[]string{"lvconvert", "thinpool"}, nil},
+	}
+	var ran []string
+	run := func(args ...string) error {
+		ran = append(ran, args[0])
+		if args[0] == "lvconvert" {
+			return fmt.Errorf("lvconvert failed")
+		}
+		return nil
+	}
+	if err := runLVMSteps(steps, run); err == nil || err.Error() != "lvconvert failed" {
+		t.Fatalf("Expected the error of lvconvert, got %v", err)
+	}
+	expected := []string{"pvcreate", "vgcreate", "lvcreate", "lvconvert", "vgremove", "pvremove"}
+	if !reflect.DeepEqual(ran, expected) {
+		t.Fatalf("Expected %v to run, got %v", expected, ran)
+	}
+
+	ran = nil
+	if err := runLVMSteps(steps[:3], run); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"pvcreate", "vgcreate", "lvcreate"}; !reflect.DeepEqual(ran, expected) {
+		t.Fatalf("Expected %v to run, got %v", expected, ran)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -39,6 +40,10 @@ var (
 	DMLogLevel int = devicemapper.LogLevelFatal
 )
 
+// deletionWorkerInterval is how often the deletion of the busy devices is
+// retried, with dm.use_deferred_deletion.
+const deletionWorkerInterval = 30 * time.Second
+
 const deviceSetMetaFile string = "deviceset-metadata"
 const transactionMetaFile string = "transaction-metadata"
 
@@ -54,7 +59,10 @@ type DevInfo struct {
 	Size          uint64 `json:"size"`
 	TransactionId uint64 `json:"transaction_id"`
 	Initialized   bool   `json:"initialized"`
-	devices       *DeviceSet
+	// Deleted is set when the device was busy when it was deleted, and
+	// the deletion is to be retried later.
+	Deleted bool `json:"deleted"`
+	devices *DeviceSet
 
 	mountCount int
 	mountPath  string
@@ -101,6 +109,10 @@ type DeviceSet struct {
 	thinpBlockSize       uint32
 	thinPoolDevice       string
 	Transaction          `json:"-"`
+	deferredRemove       bool // use deferred removal
+	deferredDelete       bool // use deferred deletion
+	nrDeletedDevices     uint // number of deleted devices
+	deletionWorkerTicker *time.Ticker
 }
 
 type DiskUsage struct {
@@ -119,6 +131,12 @@ type Status struct {
 	Metadata          DiskUsage
 	SectorSize        uint64
 	UdevSyncSupported bool
+	// DeferredRemoveEnabled and DeferredDeleteEnabled tell whether the
+	// busy devices are removed and deleted once their last user closes
+	// them.
+	DeferredRemoveEnabled      bool
+	DeferredDeleteEnabled      bool
+	DeferredDeletedDeviceCount uint
 }
 
 type DevStatus struct {
@@ -357,6 +375,13 @@ func (devices *DeviceSet) deviceFileWalkFunction(path string, finfo os.FileInfo)
 
 	devices.Lock()
 	devices.markDeviceIdUsed(dinfo.DeviceId)
+	if dinfo.Deleted {
+		// Keep the device to retry its deletion
+		devices.nrDeletedDevices++
+		devices.devicesLock.Lock()
+		devices.Devices[hash] = dinfo
+		devices.devicesLock.Unlock()
+	}
 	devices.Unlock()
 
 	logrus.Debugf("Added deviceId=%d to DeviceIdMap", dinfo.DeviceId)
@@ -432,6 +457,12 @@ func (devices *DeviceSet) registerDevice(id int, hash string, size uint64, trans
 func (devices *DeviceSet) activateDeviceIfNeeded(info *DevInfo) error {
 	logrus.Debugf("activateDeviceIfNeeded(%v)", info.Hash)
 
+	// The device might be waiting for its deferred removal, which would
+	// remove it under the feet of its new user
+	if err := devices.cancelDeferredRemoval(info); err != nil {
+		return fmt.Errorf("Error cancelling the deferred removal of device %s: %s", info.Hash, err)
+	}
+
 	if devinfo, _ := devicemapper.GetInfo(info.Name()); devinfo != nil && devinfo.Exists != 0 {
 		return nil
 	}
@@ -591,7 +622,7 @@ func (devices *DeviceSet) createRegisterDevice(hash string) (*DevInfo, error) {
 	return info, nil
 }
 
-func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *DevInfo) error {
+func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *DevInfo, size uint64) error {
 	deviceId, err := devices.getNextFreeDeviceId()
 	if err != nil {
 		return err
@@ -626,7 +657,7 @@ func (devices *DeviceSet) createRegisterSnapDevice(hash string, baseInfo *DevInf
 		break
 	}
 
-	if _, err := devices.registerDevice(deviceId, hash, baseInfo.Size, devices.OpenTransactionId); err != nil {
+	if _, err := devices.registerDevice(deviceId, hash, size, devices.OpenTransactionId); err != nil {
 		devicemapper.DeleteDevice(devices.getPoolDevName(), deviceId)
 		devices.markDeviceIdFree(deviceId)
 		logrus.Debugf("Error registering device: %s", err)
@@ -745,6 +776,17 @@ func (devices *DeviceSet) DMLog(level int, file string, line int, dmError int, m
 	}
 }
 
+// driverSupportsDeferredRemoval tells whether the device mapper driver of
+// the kernel, of the given version, removes the devices once their last
+// user closes them, as of version 4.27.0.
+func driverSupportsDeferredRemoval(version string) (bool, error) {
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "%d.%d", &major, &minor); err != nil {
+		return false, fmt.Errorf("Error parsing the version of the device mapper driver %q: %s", version, err)
+	}
+	return major > 4 || (major == 4 && minor >= 27), nil
+}
+
 func major(device uint64) uint64 {
 	return (device >> 8) & 0xfff
 }
@@ -962,12 +1004,25 @@ func (devices *DeviceSet) initDevmapper(doInit bool) error {
 	// give ourselves to libdm as a log handler
 	devicemapper.LogInit(devices)
 
-	_, err := devicemapper.GetDriverVersion()
+	version, err := devicemapper.GetDriverVersion()
 	if err != nil {
 		// Can't even get driver version, assume not supported
 		return graphdriver.ErrNotSupported
 	}
 
+	if devices.deferredRemove {
+		if !devicemapper.LibraryDeferredRemovalSupport {
+			return fmt.Errorf("dm.use_deferred_removal requires a libdevmapper with deferred removal, 1.02.89 or later")
+		}
+		supported, err := driverSupportsDeferredRemoval(version)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("dm.use_deferred_removal requires the device mapper driver 4.27.0 or later, the kernel has %s", version)
+		}
+	}
+
 	// https://github.com/docker/docker/issues/4036
 	if supported := devicemapper.UdevSetSyncSupport(true); !supported {
 		logrus.Warnf("Udev sync is not supported. This will lead to unexpected behavior, data loss and errors")
@@ -1022,6 +1077,8 @@ func (devices *DeviceSet) initDevmapper(doInit bool) error {
 		)
 
 		if devices.dataDevice == "" {
+			logrus.Warnf("Usage of loopback devices is strongly discouraged for production use. Please use `--storage-opt dm.directlvm_device` or `--storage-opt dm.thinpooldev`.")
+
 			// Make sure the sparse images exist in <root>/devicemapper/data
 
 			hasData := devices.hasImage("data")
@@ -1114,12 +1171,19 @@ func (devices *DeviceSet) initDevmapper(doInit bool) error {
 		}
 	}
 
+	if devices.deferredDelete {
+		devices.deletionWorkerTicker = time.NewTicker(deletionWorkerInterval)
+		go devices.deletionWorker(devices.deletionWorkerTicker)
+	}
+
 	return nil
 }
 
-func (devices *DeviceSet) AddDevice(hash, baseHash string) error {
-	logrus.Debugf("[deviceset] AddDevice(hash=%s basehash=%s)", hash, baseHash)
-	defer logrus.Debugf("[deviceset] AddDevice(hash=%s basehash=%s) END", hash, baseHash)
+// AddDevice creates the device hash as a snapshot of baseHash, of size
+// bytes, or of the size of baseHash if it's 0.
+func (devices *DeviceSet) AddDevice(hash, baseHash string, size uint64) error {
+	logrus.Debugf("[deviceset] AddDevice(hash=%s basehash=%s size=%d)", hash, baseHash, size)
+	defer logrus.Debugf("[deviceset] AddDevice(hash=%s basehash=%s size=%d) END", hash, baseHash, size)
 
 	baseInfo, err := devices.lookupDevice(baseHash)
 	if err != nil {
@@ -1133,18 +1197,93 @@ func (devices *DeviceSet) AddDevice(hash, baseHash string) error {
 	defer devices.Unlock()
 
 	if info, _ := devices.lookupDevice(hash); info != nil {
-		return fmt.Errorf("device %s already exists", hash)
+		if !info.Deleted {
+			return fmt.Errorf("device %s already exists", hash)
+		}
+		// The device of a removed layer of the same id is still waiting
+		// for its deferred deletion
+		if err := devices.deleteDevice(info, true); err != nil {
+			return fmt.Errorf("device %s already exists and can't be deleted yet: %s", hash, err)
+		}
+	}
+
+	if size == 0 {
+		size = baseInfo.Size
+	}
+	if size < baseInfo.Size {
+		return fmt.Errorf("The size of device %s can't be smaller than the one of its base, %s", hash, units.HumanSize(float64(baseInfo.Size)))
+	}
+
+	if err := devices.createRegisterSnapDevice(hash, baseInfo, size); err != nil {
+		return err
+	}
+
+	if size > baseInfo.Size {
+		info, err := devices.lookupDevice(hash)
+		if err != nil {
+			return err
+		}
+		if err := devices.growFS(info); err != nil {
+			if err := devices.deleteDevice(info, true); err != nil {
+				logrus.Debugf("Error removing device %s: %s", hash, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// growFS grows the filesystem of the snapshot info, the size of its base,
+// to the size of info.
+func (devices *DeviceSet) growFS(info *DevInfo) error {
+	if err := devices.activateDeviceIfNeeded(info); err != nil {
+		return fmt.Errorf("Error activating devmapper device for '%s': %s", info.Hash, err)
+	}
+	defer devices.deactivateDevice(info)
+
+	fstype, err := ProbeFsType(info.DevName())
+	if err != nil {
+		return err
 	}
 
-	if err := devices.createRegisterSnapDevice(hash, baseInfo); err != nil {
+	// Both resize2fs and xfs_growfs grow mounted filesystems
+	mountPoint, err := ioutil.TempDir(devices.root, "grow-")
+	if err != nil {
 		return err
 	}
+	defer os.RemoveAll(mountPoint)
 
+	options := devices.mountOptions
+	if fstype == "xfs" {
+		options = joinMountOptions(options, "nouuid")
+	}
+	if err := syscall.Mount(info.DevName(), mountPoint, fstype, syscall.MS_MGC_VAL, options); err != nil {
+		return fmt.Errorf("Error mounting '%s' on '%s': %s", info.DevName(), mountPoint, err)
+	}
+	defer syscall.Unmount(mountPoint, syscall.MNT_DETACH)
+
+	var cmd *exec.Cmd
+	switch fstype {
+	case "ext4":
+		cmd = exec.Command("resize2fs", info.DevName())
+	case "xfs":
+		cmd = exec.Command("xfs_growfs", mountPoint)
+	default:
+		return fmt.Errorf("Can't grow filesystem %s of device %s", fstype, info.Hash)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("Error growing the filesystem of device %s: %v: %s", info.Hash, err, out)
+	}
 	return nil
 }
 
-func (devices *DeviceSet) deleteDevice(info *DevInfo) error {
-	if devices.doBlkDiscard {
+// deleteDevice deactivates the device info and deletes it from the pool. If
+// the device is busy and deferred deletion is enabled, it is marked as
+// deleted instead, and the deletion worker retries it later, unless
+// syncDelete is set.
+func (devices *DeviceSet) deleteDevice(info *DevInfo, syncDelete bool) error {
+	if devices.doBlkDiscard && !info.Deleted {
 		// This is a workaround for the kernel not discarding block so
 		// on the thin pool when we remove a thinp device, so we do it
 		// manually
@@ -1155,12 +1294,9 @@ func (devices *DeviceSet) deleteDevice(info *DevInfo) error {
 		}
 	}
 
-	devinfo, _ := devicemapper.GetInfo(info.Name())
-	if devinfo != nil && devinfo.Exists != 0 {
-		if err := devices.removeDevice(info.Name()); err != nil {
-			logrus.Debugf("Error removing device: %s", err)
-			return err
-		}
+	if err := devices.deactivateDevice(info); err != nil {
+		logrus.Debugf("Error removing device: %s", err)
+		return err
 	}
 
 	if err := devices.openTransaction(info.Hash, info.DeviceId); err != nil {
@@ -1168,11 +1304,26 @@ func (devices *DeviceSet) deleteDevice(info *DevInfo) error {
 		return err
 	}
 
-	if err := devicemapper.DeleteDevice(devices.getPoolDevName(), info.DeviceId); err != nil {
+	err := devicemapper.DeleteDevice(devices.getPoolDevName(), info.DeviceId)
+	if err != nil && (syncDelete || !devices.deferredDelete || err != devicemapper.ErrBusy) {
 		logrus.Debugf("Error deleting device: %s", err)
 		return err
 	}
 
+	if err != nil {
+		// Some mount namespace still holds the device
+		logrus.Debugf("Device %s is busy, deferring its deletion", info.Hash)
+		if !info.Deleted {
+			info.Deleted = true
+			if err := devices.saveMetadata(info); err != nil {
+				info.Deleted = false
+				return err
+			}
+			devices.nrDeletedDevices++
+		}
+		return devices.closeTransaction()
+	}
+
 	if err := devices.unregisterDevice(info.DeviceId, info.Hash); err != nil {
 		return err
 	}
@@ -1181,6 +1332,9 @@ func (devices *DeviceSet) deleteDevice(info *DevInfo) error {
 		return err
 	}
 
+	if info.Deleted {
+		devices.nrDeletedDevices--
+	}
 	devices.markDeviceIdFree(info.DeviceId)
 
 	return nil
@@ -1198,7 +1352,45 @@ func (devices *DeviceSet) DeleteDevice(hash string) error {
 	devices.Lock()
 	defer devices.Unlock()
 
-	return devices.deleteDevice(info)
+	return devices.deleteDevice(info, false)
+}
+
+// deletionWorker retries the deletion of the devices marked as deleted on
+// every tick of ticker.
+func (devices *DeviceSet) deletionWorker(ticker *time.Ticker) {
+	for range ticker.C {
+		devices.cleanupDeletedDevices()
+	}
+}
+
+func (devices *DeviceSet) cleanupDeletedDevices() {
+	devices.Lock()
+	if devices.nrDeletedDevices == 0 {
+		devices.Unlock()
+		return
+	}
+	var deleted []*DevInfo
+	devices.devicesLock.Lock()
+	for _, info := range devices.Devices {
+		if info.Deleted {
+			deleted = append(deleted, info)
+		}
+	}
+	devices.devicesLock.Unlock()
+	devices.Unlock()
+
+	for _, info := range deleted {
+		info.lock.Lock()
+		devices.Lock()
+		// The device might have been deleted or reused in the meantime
+		if info.Deleted {
+			if err := devices.deleteDevice(info, false); err != nil {
+				logrus.Warnf("Error deleting device %s: %s", info.Hash, err)
+			}
+		}
+		devices.Unlock()
+		info.lock.Unlock()
+	}
 }
 
 func (devices *DeviceSet) deactivatePool() error {
@@ -1225,17 +1417,60 @@ func (devices *DeviceSet) deactivateDevice(info *DevInfo) error {
 	logrus.Debugf("[devmapper] deactivateDevice(%s)", info.Hash)
 	defer logrus.Debugf("[devmapper] deactivateDevice END(%s)", info.Hash)
 
-	devinfo, err := devicemapper.GetInfo(info.Name())
+	var (
+		devinfo *devicemapper.Info
+		err     error
+	)
+	if devices.deferredRemove {
+		devinfo, err = devicemapper.GetInfoWithDeferred(info.Name())
+	} else {
+		devinfo, err = devicemapper.GetInfo(info.Name())
+	}
 	if err != nil {
 		return err
 	}
-	if devinfo.Exists != 0 {
-		if err := devices.removeDevice(info.Name()); err != nil {
+	if devinfo.Exists == 0 || devinfo.DeferredRemove != 0 {
+		return nil
+	}
+
+	if devices.deferredRemove {
+		return devicemapper.RemoveDeviceDeferred(info.Name())
+	}
+	return devices.removeDevice(info.Name())
+}
+
+// cancelDeferredRemoval cancels the deferred removal of the device info, if
+// it's waiting for it.
+func (devices *DeviceSet) cancelDeferredRemoval(info *DevInfo) error {
+	if !devices.deferredRemove {
+		return nil
+	}
+
+	devinfo, err := devicemapper.GetInfoWithDeferred(info.Name())
+	if err != nil {
+		return err
+	}
+	if devinfo.DeferredRemove == 0 {
+		return nil
+	}
+
+	for i := 0; i < 100; i++ {
+		err = devicemapper.CancelDeferredRemove(info.Name())
+		if err == nil || err == devicemapper.ErrEnxio {
+			// The device might have been removed already
+			return nil
+		}
+		if err != devicemapper.ErrBusy {
 			return err
 		}
+
+		// The device is being removed, wait for it
+		devices.Unlock()
+		time.Sleep(100 * time.Millisecond)
+		devices.Lock()
 	}
 
-	return nil
+	return err
 }
 
 // Issues the underlying dm remove operation.
@@ -1269,6 +1504,10 @@ func (devices *DeviceSet) Shutdown() error {
 	logrus.Debugf("[devmapper] Shutting down DeviceSet: %s", devices.root)
 	defer logrus.Debugf("[deviceset %s] Shutdown() END", devices.devicePrefix)
 
+	if devices.deletionWorkerTicker != nil {
+		devices.deletionWorkerTicker.Stop()
+	}
+
 	var devs []*DevInfo
 
 	devices.devicesLock.Lock()
@@ -1332,6 +1571,10 @@ func (devices *DeviceSet) MountDevice(hash, path, mountLabel string) error {
 	devices.Lock()
 	defer devices.Unlock()
 
+	if info.Deleted {
+		return fmt.Errorf("Can't mount device %s, it is waiting for its deferred deletion", hash)
+	}
+
 	if info.mountCount > 0 {
 		if path != info.mountPath {
 			return fmt.Errorf("Trying to mount devmapper device in multiple places (%s, %s)", info.mountPath, path)
@@ -1420,7 +1663,7 @@ func (devices *DeviceSet) HasDevice(hash string) bool {
 	defer devices.Unlock()
 
 	info, _ := devices.lookupDevice(hash)
-	return info != nil
+	return info != nil && !info.Deleted
 }
 
 func (devices *DeviceSet) HasActivatedDevice(hash string) bool {
@@ -1446,13 +1689,16 @@ func (devices *DeviceSet) List() []string {
 	devices.devicesLock.Lock()
 	ids := make([]string, len(devices.Devices))
 	i := 0
-	for k := range devices.Devices {
+	for k, info := range devices.Devices {
+		if info.Deleted {
+			continue
+		}
 		ids[i] = k
 		i++
 	}
 	devices.devicesLock.Unlock()
 
-	return ids
+	return ids[:i]
 }
 
 func (devices *DeviceSet) deviceStatus(devName string) (sizeInSectors, mappedSectors, highestMappedSector uint64, err error) {
@@ -1557,6 +1803,9 @@ func (devices *DeviceSet) Status() *Status {
 	status.MetadataFile = devices.MetadataDevicePath()
 	status.MetadataLoopback = devices.metadataLoopFile
 	status.UdevSyncSupported = devicemapper.UdevSyncSupported()
+	status.DeferredRemoveEnabled = devices.deferredRemove
+	status.DeferredDeleteEnabled = devices.deferredDelete
+	status.DeferredDeletedDeviceCount = devices.nrDeletedDevices
 
 	totalSizeInSectors, _, dataUsed, dataTotal, metadataUsed, metadataTotal, err := devices.poolStatus()
 	if err == nil {
@@ -1607,7 +1856,11 @@ func NewDeviceSet(root string, doInit bool, options []string) (*DeviceSet, error
 		deviceIdMap:          make([]byte, DeviceIdMapSz),
 	}
 
-	foundBlkDiscard := false
+	var (
+		foundBlkDiscard bool
+		lvmSetupConfig  = newDirectLVMConfig()
+		lvmSetupForce   bool
+	)
 	for _, option := range options {
 		key, val, err := parsers.ParseKeyValueOpt(option)
 		if err != nil {
@@ -1661,11 +1914,58 @@ func NewDeviceSet(root string, doInit bool, options []string) (*DeviceSet, error
 			}
 			// convert to 512b sectors
 			devices.thinpBlockSize = uint32(size) >> 9
+		case "dm.use_deferred_removal":
+			devices.deferredRemove, err = strconv.ParseBool(val)
+			if err != nil {
+				return nil, err
+			}
+		case "dm.use_deferred_deletion":
+			devices.deferredDelete, err = strconv.ParseBool(val)
+			if err != nil {
+				return nil, err
+			}
+		case "dm.directlvm_device":
+			lvmSetupConfig.Device = val
+		case "dm.directlvm_device_force":
+			lvmSetupForce, err = strconv.ParseBool(val)
+			if err != nil {
+				return nil, err
+			}
+		case "dm.thinp_percent", "dm.thinp_metapercent", "dm.thinp_autoextend_threshold", "dm.thinp_autoextend_percent":
+			percent, err := strconv.ParseUint(strings.TrimSuffix(val, "%"), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid value for %s: %v", key, err)
+			}
+			switch key {
+			case "dm.thinp_percent":
+				lvmSetupConfig.ThinpPercent = percent
+			case "dm.thinp_metapercent":
+				lvmSetupConfig.ThinpMetaPercent = percent
+			case "dm.thinp_autoextend_threshold":
+				lvmSetupConfig.AutoExtendThreshold = percent
+			case "dm.thinp_autoextend_percent":
+				lvmSetupConfig.AutoExtendPercent = percent
+			}
 		default:
 			return nil, fmt.Errorf("Unknown option %s\n", key)
 		}
 	}
 
+	if lvmSetupConfig.Device != "" {
+		if devices.dataDevice != "" || devices.metadataDevice != "" || devices.thinPoolDevice != "" {
+			return nil, fmt.Errorf("dm.directlvm_device can't be used with dm.datadev, dm.metadatadev or dm.thinpooldev")
+		}
+		if err := devices.setupDirectLVM(lvmSetupConfig, lvmSetupForce); err != nil {
+			return nil, err
+		}
+	} else if !reflect.DeepEqual(lvmSetupConfig, newDirectLVMConfig()) || lvmSetupForce {
+		return nil, fmt.Errorf("The dm.thinp_* and dm.directlvm_device_force options require dm.directlvm_device")
+	}
+
+	if devices.deferredDelete && !devices.deferredRemove {
+		return nil, fmt.Errorf("dm.use_deferred_deletion requires dm.use_deferred_removal")
+	}
+
 	// By default, don't do blk discard hack on raw devices, its rarely useful and is expensive
 	if !foundBlkDiscard && (devices.dataDevice != "" || devices.thinPoolDevice != "") {
 		devices.doBlkDiscard = false
@@ -77,6 +77,9 @@ func (d *Driver) Status() [][2]string {
 		{"Metadata Space Total", fmt.Sprintf("%s", units.HumanSize(float64(s.Metadata.Total)))},
 		{"Metadata Space Available", fmt.Sprintf("%s", units.HumanSize(float64(s.Metadata.Available)))},
 		{"Udev Sync Supported", fmt.Sprintf("%v", s.UdevSyncSupported)},
+		{"Deferred Removal Enabled", fmt.Sprintf("%v", s.DeferredRemoveEnabled)},
+		{"Deferred Deletion Enabled", fmt.Sprintf("%v", s.DeferredDeleteEnabled)},
+		{"Deferred Deleted Device Count", fmt.Sprintf("%v", s.DeferredDeletedDeviceCount)},
 	}
 	if len(s.DataLoopback) > 0 {
 		status = append(status, [2]string{"Data loop file", s.DataLoopback})
@@ -101,13 +104,25 @@ func (d *Driver) Cleanup() error {
 }
 
 func (d *Driver) Create(id, parent string) error {
-	if err := d.DeviceSet.AddDevice(id, parent); err != nil {
+	if err := d.DeviceSet.AddDevice(id, parent, 0); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// CreateWithStorageOpt creates the device id like Create does, with a
+// filesystem of the "size" of storageOpt, which can't be smaller than the
+// one of parent.
+func (d *Driver) CreateWithStorageOpt(id, parent string, storageOpt map[string]string) error {
+	size, err := graphdriver.ParseStorageOptSize(storageOpt)
+	if err != nil {
+		return err
+	}
+
+	return d.DeviceSet.AddDevice(id, parent, size)
+}
+
 func (d *Driver) Remove(id string) error {
 	if !d.DeviceSet.HasDevice(id) {
 		// Consider removing a non-existing device a no-op
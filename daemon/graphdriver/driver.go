@@ -9,6 +9,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/units"
 )
 
 type FsMagic uint32
@@ -27,6 +28,7 @@ const (
 	FsMagicJffs2Fs     = FsMagic(0x000072b6)
 	FsMagicZfs         = FsMagic(0x2fc12fc1)
 	FsMagicXfs         = FsMagic(0x58465342)
+	FsMagicOverlay     = FsMagic(0x794C7630)
 	FsMagicUnsupported = FsMagic(0x00000000)
 )
 
@@ -61,6 +63,7 @@ var (
 		FsMagicJffs2Fs:     "jffs2",
 		FsMagicZfs:         "zfs",
 		FsMagicXfs:         "xfs",
+		FsMagicOverlay:     "overlayfs",
 		FsMagicUnsupported: "unsupported",
 	}
 )
@@ -119,6 +122,45 @@ type Driver interface {
 	DiffSize(id, parent string) (size int64, err error)
 }
 
+// StorageOptDriver is the interface of the drivers which can create layers
+// with options, such as the limit of their size.
+type StorageOptDriver interface {
+	// CreateWithStorageOpt creates a new, empty, filesystem layer like
+	// Create does, with the options of storageOpt, such as its "size".
+	CreateWithStorageOpt(id, parent string, storageOpt map[string]string) error
+}
+
+// UsageDriver is the interface of the drivers which account for the space
+// their layers use on disk, such as btrfs with quota groups.
+type UsageDriver interface {
+	// Usage returns the bytes the layer id uses on disk that no other layer
+	// shares, and the limit of its size, 0 if it has none. It returns
+	// ErrNotSupported if the driver doesn't account for the layer.
+	Usage(id string) (size, limit int64, err error)
+}
+
+// ParseStorageOptSize returns the "size" of storageOpt in bytes, 0 if it's
+// not set, for the drivers which don't take any other option.
+func ParseStorageOptSize(storageOpt map[string]string) (uint64, error) {
+	var size uint64
+	for key, val := range storageOpt {
+		switch strings.ToLower(key) {
+		case "size":
+			s, err := units.RAMInBytes(val)
+			if err != nil {
+				return 0, err
+			}
+			if s <= 0 {
+				return 0, fmt.Errorf("Invalid size %s: it must be a positive number of bytes", val)
+			}
+			size = uint64(s)
+		default:
+			return 0, fmt.Errorf("Unknown storage option %s", key)
+		}
+	}
+	return size, nil
+}
+
 func init() {
 	drivers = make(map[string]InitFunc)
 }
@@ -27,8 +27,34 @@ type naiveDiffDriver struct {
 //     Changes(id, parent string) ([]archive.Change, error)
 //     ApplyDiff(id, parent string, diff archive.ArchiveReader) (size int64, err error)
 //     DiffSize(id, parent string) (size int64, err error)
+//
+// The driver it returns implements StorageOptDriver if the given ProtoDriver
+// does.
 func NaiveDiffDriver(driver ProtoDriver) Driver {
-	return &naiveDiffDriver{ProtoDriver: driver}
+	gdw := &naiveDiffDriver{ProtoDriver: driver}
+	if _, ok := driver.(StorageOptDriver); ok {
+		return &naiveDiffStorageOptDriver{gdw}
+	}
+	return gdw
+}
+
+// Usage returns the usage of the layer id of the ProtoDriver if it
+// implements UsageDriver, and ErrNotSupported otherwise.
+func (gdw *naiveDiffDriver) Usage(id string) (size, limit int64, err error) {
+	if driver, ok := gdw.ProtoDriver.(UsageDriver); ok {
+		return driver.Usage(id)
+	}
+	return 0, 0, ErrNotSupported
+}
+
+// naiveDiffStorageOptDriver is the naiveDiffDriver of a ProtoDriver which
+// implements StorageOptDriver.
+type naiveDiffStorageOptDriver struct {
+	*naiveDiffDriver
+}
+
+func (gdw *naiveDiffStorageOptDriver) CreateWithStorageOpt(id, parent string, storageOpt map[string]string) error {
+	return gdw.ProtoDriver.(StorageOptDriver).CreateWithStorageOpt(id, parent, storageOpt)
 }
 
 // Diff produces an archive of the changes between the specified
@@ -249,7 +249,7 @@ func (d *Driver) Create(id string, parent string) (retErr error) {
 		return err
 	}
 
-	return copyDir(parentUpperDir, upperDir, 0)
+	return graphdriver.CopyDir(parentUpperDir, upperDir, 0)
 }
 
 func (d *Driver) dir(id string) string {
@@ -371,7 +371,7 @@ func (d *Driver) ApplyDiff(id string, parent string, diff archive.ArchiveReader)
 		}
 	}()
 
-	if err = copyDir(parentRootDir, tmpRootDir, CopyHardlink); err != nil {
+	if err = graphdriver.CopyDir(parentRootDir, tmpRootDir, graphdriver.CopyHardlink); err != nil {
 		return 0, err
 	}
 
@@ -0,0 +1,363 @@
+// +build linux
+
+package overlay2
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/libcontainer/label"
+)
+
+// This backend uses the overlay union filesystem of the kernel, with the
+// multiple lower layers of Linux 4.0 and later: unlike the overlay driver,
+// which copies the whole filesystem of the parent of each image with hard
+// links, a layer only holds its own changes, and is mounted over all the
+// layers of its ancestors.
+//
+// Each layer has a "diff" directory, its changes, which is the upper layer
+// of its overlay, and a "link" file with the name of the symbolic link to
+// "diff" in the "l" directory of the driver. Unless the layer is a base
+// layer, whose "diff" is used as is, it also has a "lower" file, the
+// colon-separated links of the "diff" of its parent and of the ancestors
+// of its parent, topmost first, as well as the "work" directory overlay
+// requires and the "merged" directory it's mounted in. The short links keep
+// the options of the mounts of deep layers within the size the kernel
+// accepts.
+
+const (
+	linkDir = "l"
+
+	// linkLen is the length of the names of the links to the layers.
+	linkLen = 26
+
+	// maxDepth is the maximum number of lower layers of a layer.
+	maxDepth = 128
+)
+
+type ActiveMount struct {
+	count   int
+	path    string
+	mounted bool
+}
+
+type Driver struct {
+	home       string
+	sync.Mutex // Protects concurrent modification to active
+	active     map[string]*ActiveMount
+}
+
+var backingFs = "<unknown>"
+
+func init() {
+	graphdriver.Register("overlay2", Init)
+}
+
+// Init returns the overlay2 driver, rooted at home, if the kernel supports
+// overlay with multiple lower layers on the filesystem of home.
+func Init(home string, options []string) (graphdriver.Driver, error) {
+	if err := supportsOverlay(); err != nil {
+		return nil, graphdriver.ErrNotSupported
+	}
+
+	fsMagic, err := graphdriver.GetFSMagic(home)
+	if err != nil {
+		return nil, err
+	}
+	if fsName, ok := graphdriver.FsNames[fsMagic]; ok {
+		backingFs = fsName
+	}
+
+	switch fsMagic {
+	case graphdriver.FsMagicBtrfs, graphdriver.FsMagicAufs, graphdriver.FsMagicZfs, graphdriver.FsMagicOverlay:
+		logrus.Errorf("'overlay2' is not supported over %s.", backingFs)
+		return nil, graphdriver.ErrIncompatibleFS
+	}
+
+	if err := os.MkdirAll(path.Join(home, linkDir), 0700); err != nil {
+		return nil, err
+	}
+	if err := supportsMultipleLowerDir(home); err != nil {
+		logrus.Errorf("'overlay2' requires the multiple lower layers of the overlay filesystem of Linux 4.0 or later: %v", err)
+		return nil, graphdriver.ErrNotSupported
+	}
+
+	d := &Driver{
+		home:   home,
+		active: make(map[string]*ActiveMount),
+	}
+
+	return graphdriver.NaiveDiffDriver(d), nil
+}
+
+func supportsOverlay() error {
+	// We can try to modprobe overlay first before looking at
+	// proc/filesystems for when overlay is supported
+	exec.Command("modprobe", "overlay").Run()
+
+	f, err := os.Open("/proc/filesystems")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if s.Text() == "nodev\toverlay" {
+			return nil
+		}
+	}
+	logrus.Error("'overlay' not found as a supported filesystem on this host. Please ensure kernel is new enough and has overlay support loaded.")
+	return graphdriver.ErrNotSupported
+}
+
+// supportsMultipleLowerDir mounts an overlay of two lower layers in a
+// temporary directory of home, which fails on kernels older than 4.0.
+func supportsMultipleLowerDir(home string) error {
+	dir, err := ioutil.TempDir(home, "check-overlay2")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"lower1", "lower2", "upper", "work", "merged"} {
+		if err := os.Mkdir(path.Join(dir, name), 0700); err != nil {
+			return err
+		}
+	}
+	opts := fmt.Sprintf("lowerdir=%s:%s,upperdir=%s,workdir=%s",
+		path.Join(dir, "lower2"), path.Join(dir, "lower1"), path.Join(dir, "upper"), path.Join(dir, "work"))
+	merged := path.Join(dir, "merged")
+	if err := syscall.Mount("overlay", merged, "overlay", 0, opts); err != nil {
+		return err
+	}
+	return syscall.Unmount(merged, 0)
+}
+
+func (d *Driver) String() string {
+	return "overlay2"
+}
+
+func (d *Driver) Status() [][2]string {
+	return [][2]string{
+		{"Backing Filesystem", backingFs},
+	}
+}
+
+func (d *Driver) Cleanup() error {
+	return nil
+}
+
+func (d *Driver) dir(id string) string {
+	return path.Join(d.home, id)
+}
+
+// Create creates the layer id, whose changes are on top of the layers of
+// parent, if it isn't empty.
+func (d *Driver) Create(id string, parent string) (retErr error) {
+	dir := d.dir(id)
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return err
+	}
+
+	defer func() {
+		// Clean up on failure
+		if retErr != nil {
+			d.Remove(id)
+		}
+	}()
+
+	var (
+		mode  os.FileMode = 0755
+		lower string
+	)
+	if parent != "" {
+		var err error
+		if lower, err = d.lowerOf(parent); err != nil {
+			return err
+		}
+		// The root of the layer keeps the mode of the one of its parent,
+		// the root directory of its upper layer is the root of the
+		// overlay.
+		s, err := os.Lstat(path.Join(d.dir(parent), "diff"))
+		if err != nil {
+			return err
+		}
+		mode = s.Mode()
+	}
+
+	if err := os.Mkdir(path.Join(dir, "diff"), mode); err != nil {
+		return err
+	}
+	if err := d.link(id); err != nil {
+		return err
+	}
+	if parent == "" {
+		return nil
+	}
+
+	if err := os.Mkdir(path.Join(dir, "work"), 0700); err != nil {
+		return err
+	}
+	if err := os.Mkdir(path.Join(dir, "merged"), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path.Join(dir, "lower"), []byte(lower), 0644)
+}
+
+// lowerOf returns the lower layers of the children of parent: the link to
+// the diff of parent, followed by the lower layers of parent.
+func (d *Driver) lowerOf(parent string) (string, error) {
+	link, err := ioutil.ReadFile(path.Join(d.dir(parent), "link"))
+	if err != nil {
+		return "", err
+	}
+	lowers := []string{path.Join(linkDir, string(link))}
+
+	parentLower, err := ioutil.ReadFile(path.Join(d.dir(parent), "lower"))
+	if err == nil {
+		lowers = append(lowers, strings.Split(string(parentLower), ":")...)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	if len(lowers) > maxDepth {
+		return "", fmt.Errorf("Cannot create a layer of more than %d parent layers", maxDepth)
+	}
+	return strings.Join(lowers, ":"), nil
+}
+
+// link creates the link to the diff of the layer id in the link directory
+// of the driver, under a random name it writes to the link file of id.
+func (d *Driver) link(id string) error {
+	for {
+		name := stringid.GenerateRandomID()[:linkLen]
+		err := os.Symlink(path.Join("..", id, "diff"), path.Join(d.home, linkDir, name))
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path.Join(d.dir(id), "link"), []byte(name), 0644)
+	}
+}
+
+// Remove removes the layer id and its link.
+func (d *Driver) Remove(id string) error {
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	if link, err := ioutil.ReadFile(path.Join(dir, "link")); err == nil && len(link) > 0 {
+		if err := os.Remove(path.Join(d.home, linkDir, string(link))); err != nil && !os.IsNotExist(err) {
+			logrus.Debugf("Failed to remove the link of layer %s: %v", id, err)
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// Get mounts the layer id over its lower layers, and returns the directory
+// it's mounted in, or the diff of id if it's a base layer.
+func (d *Driver) Get(id string, mountLabel string) (string, error) {
+	// Protect the d.active from concurrent access
+	d.Lock()
+	defer d.Unlock()
+
+	mount := d.active[id]
+	if mount != nil {
+		mount.count++
+		return mount.path, nil
+	}
+
+	mount = &ActiveMount{count: 1}
+
+	dir := d.dir(id)
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	// A base layer has no lower layers to mount over
+	diffDir := path.Join(dir, "diff")
+	lower, err := ioutil.ReadFile(path.Join(dir, "lower"))
+	if os.IsNotExist(err) {
+		mount.path = diffDir
+		d.active[id] = mount
+		return mount.path, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	var (
+		lowers    = strings.Split(string(lower), ":")
+		absLowers = make([]string, len(lowers))
+		mergedDir = path.Join(dir, "merged")
+	)
+	for i, l := range lowers {
+		absLowers[i] = path.Join(d.home, l)
+	}
+	opts := label.FormatMountLabel(fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s",
+		strings.Join(absLowers, ":"), diffDir, path.Join(dir, "work")), mountLabel)
+	if len(opts) < syscall.Getpagesize() {
+		err = syscall.Mount("overlay", mergedDir, "overlay", 0, opts)
+	} else {
+		// The options of the mounts are limited to a page, the links to
+		// the lower layers are given relative to the home of the driver
+		// instead.
+		opts = label.FormatMountLabel(fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s",
+			string(lower), path.Join(id, "diff"), path.Join(id, "work")), mountLabel)
+		if len(opts) >= syscall.Getpagesize() {
+			return "", fmt.Errorf("The mount options of layer %s are too long: %d bytes", id, len(opts))
+		}
+		err = mountFrom(d.home, "overlay", path.Join(id, "merged"), "overlay", opts)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error creating overlay mount to %s: %v", mergedDir, err)
+	}
+	mount.path = mergedDir
+	mount.mounted = true
+	d.active[id] = mount
+
+	return mount.path, nil
+}
+
+func (d *Driver) Put(id string) error {
+	// Protect the d.active from concurrent access
+	d.Lock()
+	defer d.Unlock()
+
+	mount := d.active[id]
+	if mount == nil {
+		logrus.Debugf("Put on a non-mounted device %s", id)
+		return nil
+	}
+
+	mount.count--
+	if mount.count > 0 {
+		return nil
+	}
+
+	defer delete(d.active, id)
+	if mount.mounted {
+		err := syscall.Unmount(mount.path, 0)
+		if err != nil {
+			logrus.Debugf("Failed to unmount %s overlay: %v", id, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *Driver) Exists(id string) bool {
+	_, err := os.Stat(d.dir(id))
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package overlay2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver/graphtest"
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+// This avoids creating a new driver for each test if all tests are run
+// Make sure to put new tests between TestOverlaySetup and TestOverlayTeardown
+func TestOverlaySetup(t *testing.T) {
+	graphtest.GetDriver(t, "overlay2")
+}
+
+func TestOverlayCreateEmpty(t *testing.T) {
+	graphtest.DriverTestCreateEmpty(t, "overlay2")
+}
+
+func TestOverlayCreateBase(t *testing.T) {
+	graphtest.DriverTestCreateBase(t, "overlay2")
+}
+
+func TestOverlayCreateSnap(t *testing.T) {
+	graphtest.DriverTestCreateSnap(t, "overlay2")
+}
+
+// Every layer of a chain deeper than the options of an overlay mount of
+// absolute paths allow is visible, and the files removed in a layer are
+// hidden from its children.
+func TestOverlayDeepLayers(t *testing.T) {
+	driver := graphtest.GetDriver(t, "overlay2")
+	defer graphtest.PutDriver(t)
+
+	const depth = 100
+	parent := ""
+	for i := 0; i < depth; i++ {
+		id := fmt.Sprintf("deep%d", i)
+		if err := driver.Create(id, parent); err != nil {
+			t.Fatal(err)
+		}
+		defer driver.Remove(id)
+		dir, err := driver.Get(id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path.Join(dir, id), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if i == depth-1 {
+			if err := os.Remove(path.Join(dir, "deep0")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := driver.Put(id); err != nil {
+			t.Fatal(err)
+		}
+		parent = id
+	}
+
+	if err := driver.Create("deep", parent); err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Remove("deep")
+	dir, err := driver.Get("deep", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer driver.Put("deep")
+	for i := 1; i < depth; i++ {
+		if _, err := os.Stat(path.Join(dir, fmt.Sprintf("deep%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(path.Join(dir, "deep0")); !os.IsNotExist(err) {
+		t.Fatalf("Expected deep0 to be removed, got %v", err)
+	}
+}
+
+func TestOverlayTeardown(t *testing.T) {
+	graphtest.PutDriver(t)
+}
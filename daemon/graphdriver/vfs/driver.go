@@ -6,7 +6,6 @@ import (
 	"path"
 
 	"github.com/docker/docker/daemon/graphdriver"
-	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/libcontainer/label"
 )
 
@@ -56,10 +55,8 @@ func (d *Driver) Create(id, parent string) error {
 	if err != nil {
 		return fmt.Errorf("%s: %s", parent, err)
 	}
-	if err := chrootarchive.CopyWithTar(parentDir, dir); err != nil {
-		return err
-	}
-	return nil
+	// Copied with reflinks when the filesystem supports them
+	return graphdriver.CopyDir(parentDir, dir, 0)
 }
 
 func (d *Driver) dir(id string) string {
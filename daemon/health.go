@@ -0,0 +1,254 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/lxc"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/runconfig"
+)
+
+// Status of a container with a health check.
+const (
+	healthStarting  = "starting"
+	healthHealthy   = "healthy"
+	healthUnhealthy = "unhealthy"
+)
+
+const (
+	defaultProbeInterval = 30 * time.Second
+	defaultProbeTimeout  = 30 * time.Second
+	defaultProbeRetries  = 3
+
+	// maxHealthLogEntries is the number of results kept in the health state.
+	maxHealthLogEntries = 5
+	// maxHealthOutputLen is the number of bytes of output kept per result.
+	maxHealthOutputLen = 4096
+)
+
+// Health is the state of the health check of a running container.
+type Health struct {
+	Status        string
+	FailingStreak int // number of consecutive failures
+	Log           []*HealthcheckResult
+}
+
+// HealthcheckResult is the outcome of one run of the health check.
+type HealthcheckResult struct {
+	Start    time.Time
+	End      time.Time
+	ExitCode int
+	Output   string
+}
+
+// update records result and returns whether the status changed. The
+// container becomes healthy as soon as a check passes, and unhealthy after
+// retries consecutive failures.
+func (h *Health) update(result *HealthcheckResult, retries int) bool {
+	h.Log = append(h.Log, result)
+	if len(h.Log) > maxHealthLogEntries {
+		h.Log = h.Log[len(h.Log)-maxHealthLogEntries:]
+	}
+
+	oldStatus := h.Status
+	if result.ExitCode == 0 {
+		h.FailingStreak = 0
+		h.Status = healthHealthy
+	} else {
+		h.FailingStreak++
+		if h.FailingStreak >= retries {
+			h.Status = healthUnhealthy
+		}
+	}
+	return h.Status != oldStatus
+}
+
+// healthOutput keeps the start of the output of a health check. Its writes
+// come from both stdout and stderr.
+type healthOutput struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *healthOutput) Write(p []byte) (int, error) {
+	o.Lock()
+	defer o.Unlock()
+	if n := maxHealthOutputLen - o.buf.Len(); n > 0 {
+		if len(p) < n {
+			n = len(p)
+		}
+		o.buf.Write(p[:n])
+	}
+	return len(p), nil
+}
+
+func (o *healthOutput) String() string {
+	o.Lock()
+	defer o.Unlock()
+	return o.buf.String()
+}
+
+// startHealthcheck sets up the health state of a container that was just
+// started and begins running its health check, if it has one. The caller
+// must hold the container lock.
+func (container *Container) startHealthcheck() {
+	container.Health = nil
+	config := container.Config.Healthcheck
+	if config == nil || len(config.Test) == 0 || config.Test[0] == "NONE" {
+		return
+	}
+	d := container.daemon
+	ed, err := container.execDriver()
+	if err != nil {
+		logrus.Debugf("Not running the health check of %s: %s", container.ID, err)
+		return
+	}
+	if strings.HasPrefix(ed.Name(), lxc.DriverName) {
+		logrus.Debugf("Not running the health check of %s: the lxc driver doesn't support exec", container.ID)
+		return
+	}
+
+	container.Health = &Health{Status: healthStarting}
+	container.healthStop = make(chan struct{})
+	go d.monitorHealth(container, config, container.healthStop)
+}
+
+// stopHealthcheck stops the health check of a container whose process exited.
+func (container *Container) stopHealthcheck() {
+	if container.healthStop != nil {
+		close(container.healthStop)
+		container.healthStop = nil
+	}
+}
+
+// monitorHealth runs the health check of c every interval until stop is
+// closed, and records the results in the state of c.
+func (d *Daemon) monitorHealth(c *Container, config *runconfig.HealthConfig, stop chan struct{}) {
+	interval := config.Interval
+	if interval == 0 {
+		interval = defaultProbeInterval
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultProbeTimeout
+	}
+	retries := config.Retries
+	if retries == 0 {
+		retries = defaultProbeRetries
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+
+		// The check of a paused container would only time out.
+		if c.IsPaused() {
+			continue
+		}
+		result := d.runHealthcheck(c, config.Test, timeout)
+
+		c.Lock()
+		select {
+		case <-stop:
+			// The container exited while the check was running.
+			c.Unlock()
+			return
+		default:
+		}
+		changed := c.Health.update(result, retries)
+		status := c.Health.Status
+		restart := changed && status == healthUnhealthy && c.hostConfig.RestartPolicy.OnUnhealthy
+		if err := c.toDisk(); err != nil {
+			logrus.Debugf("%s", err)
+		}
+		c.Unlock()
+
+		if changed {
+			c.LogEvent("health_status: " + status)
+		}
+		if restart {
+			// Restarting the container starts a new health check.
+			logrus.Infof("Restarting container %s: its health check failed %d times in a row", stringid.TruncateID(c.ID), retries)
+			if err := c.Restart(c.stopTimeout()); err != nil {
+				logrus.Errorf("Error restarting unhealthy container %s: %s", c.ID, err)
+				return
+			}
+			c.LogEvent("restart")
+			return
+		}
+	}
+}
+
+// runHealthcheck runs test, ["CMD", args...] or ["CMD-SHELL", command], in
+// the container. The check fails if it doesn't exit within timeout.
+func (d *Daemon) runHealthcheck(c *Container, test []string, timeout time.Duration) *HealthcheckResult {
+	var processConfig execdriver.ProcessConfig
+	if test[0] == "CMD-SHELL" {
+		processConfig.Entrypoint = "/bin/sh"
+		processConfig.Arguments = []string{"-c", strings.Join(test[1:], " ")}
+	} else {
+		processConfig.Entrypoint = test[1]
+		processConfig.Arguments = test[2:]
+	}
+
+	var (
+		output   healthOutput
+		pidLock  sync.Mutex
+		pid      int
+		exitCode int
+		err      error
+		done     = make(chan struct{})
+	)
+	pipes := execdriver.NewPipes(nil, &output, &output, false)
+	callback := func(_ *execdriver.ProcessConfig, p int) {
+		pidLock.Lock()
+		pid = p
+		pidLock.Unlock()
+	}
+
+	result := &HealthcheckResult{Start: time.Now().UTC()}
+	ed, err := c.execDriver()
+	if err != nil {
+		result.ExitCode = -1
+		result.Output = err.Error()
+		result.End = time.Now().UTC()
+		return result
+	}
+	go func() {
+		exitCode, err = ed.Exec(c.command, &processConfig, pipes, callback)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		result.ExitCode = exitCode
+		result.Output = output.String()
+		if err != nil {
+			if result.ExitCode == 0 {
+				result.ExitCode = -1
+			}
+			result.Output = err.Error()
+		}
+	case <-time.After(timeout):
+		pidLock.Lock()
+		if pid != 0 {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+		pidLock.Unlock()
+		<-done
+		result.ExitCode = -1
+		result.Output = fmt.Sprintf("Health check exceeded timeout (%v)", timeout)
+	}
+	result.End = time.Now().UTC()
+	return result
+}
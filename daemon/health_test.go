@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHealthUpdate(t *testing.T) {
+	h := &Health{Status: healthStarting}
+
+	if h.update(&HealthcheckResult{ExitCode: 1}, 2) {
+		t.Fatal("One failure out of 2 retries shouldn't change the status")
+	}
+	if h.Status != healthStarting || h.FailingStreak != 1 {
+		t.Fatalf("Expected starting with a failing streak of 1, got %s and %d", h.Status, h.FailingStreak)
+	}
+	if !h.update(&HealthcheckResult{ExitCode: 0}, 2) || h.Status != healthHealthy {
+		t.Fatalf("Expected a passing check to make the container healthy, got %s", h.Status)
+	}
+	if h.FailingStreak != 0 {
+		t.Fatalf("Expected a passing check to reset the failing streak, got %d", h.FailingStreak)
+	}
+	h.update(&HealthcheckResult{ExitCode: 1}, 2)
+	if !h.update(&HealthcheckResult{ExitCode: -1}, 2) || h.Status != healthUnhealthy {
+		t.Fatalf("Expected 2 failures to make the container unhealthy, got %s", h.Status)
+	}
+	if h.update(&HealthcheckResult{ExitCode: 1}, 2) {
+		t.Fatal("Another failure shouldn't change the status")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.update(&HealthcheckResult{ExitCode: i}, 2)
+	}
+	if len(h.Log) != maxHealthLogEntries {
+		t.Fatalf("Expected %d results in the log, got %d", maxHealthLogEntries, len(h.Log))
+	}
+	if last := h.Log[len(h.Log)-1]; last.ExitCode != 9 {
+		t.Fatalf("Expected the last result to be kept, got exit code %d", last.ExitCode)
+	}
+}
+
+func TestHealthOutputLimit(t *testing.T) {
+	var output healthOutput
+	chunk := strings.Repeat("x", 1000)
+	for i := 0; i < 10; i++ {
+		if n, err := output.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write returned %d, %v", n, err)
+		}
+	}
+	if len(output.String()) != maxHealthOutputLen {
+		t.Fatalf("Expected %d bytes of output, got %d", maxHealthOutputLen, len(output.String()))
+	}
+}
+
+func TestStateStringHealth(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	s.Health = &Health{Status: healthHealthy}
+	if status := s.String(); !strings.HasSuffix(status, "(healthy)") {
+		t.Fatalf("Expected the health status in %q", status)
+	}
+	s.SetPaused()
+	if status := s.String(); !strings.HasSuffix(status, "(Paused)") {
+		t.Fatalf("Expected a paused container to show as paused, got %q", status)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/docker/docker/pkg/parsers/operatingsystem"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
 
@@ -70,12 +71,14 @@ func (daemon *Daemon) CmdInfo(job *engine.Job) error {
 	v.SetInt("NGoroutines", runtime.NumGoroutine())
 	v.Set("SystemTime", time.Now().Format(time.RFC3339Nano))
 	v.Set("ExecutionDriver", daemon.ExecutionDriver().Name())
-	v.Set("LoggingDriver", daemon.defaultLogConfig.Type)
+	v.SetList("Runtimes", daemon.runtimeNames())
+	v.Set("DefaultRuntime", daemon.defaultRuntime())
+	v.Set("LoggingDriver", daemon.logConfig(runconfig.LogConfig{}).Type)
 	v.SetInt("NEventsListener", env.GetInt("count"))
 	v.Set("KernelVersion", kernelVersion)
 	v.Set("OperatingSystem", operatingSystem)
 	v.Set("IndexServerAddress", registry.IndexServerAddress())
-	v.SetJson("RegistryConfig", daemon.RegistryService.Config)
+	v.SetJson("RegistryConfig", daemon.RegistryService.Config())
 	v.Set("InitSha1", dockerversion.INITSHA1)
 	v.Set("InitPath", initPath)
 	v.SetInt("NCPU", runtime.NumCPU())
@@ -94,7 +97,7 @@ func (daemon *Daemon) CmdInfo(job *engine.Job) error {
 	if hostname, err := os.Hostname(); err == nil {
 		v.SetJson("Name", hostname)
 	}
-	v.SetList("Labels", daemon.Config().Labels)
+	v.SetList("Labels", daemon.labels())
 	if _, err := v.WriteTo(job.Stdout); err != nil {
 		return err
 	}
@@ -65,7 +65,7 @@ func (daemon *Daemon) ContainerInspect(job *engine.Job) error {
 	// we need this trick to preserve empty log driver, so
 	// container will use daemon defaults even if daemon change them
 	if container.hostConfig.LogConfig.Type == "" {
-		container.hostConfig.LogConfig = daemon.defaultLogConfig
+		container.hostConfig.LogConfig = daemon.logConfig(runconfig.LogConfig{})
 		defer func() {
 			container.hostConfig.LogConfig = runconfig.LogConfig{}
 		}()
@@ -90,7 +90,10 @@ func (daemon *Daemon) ContainerExecInspect(job *engine.Job) error {
 		return err
 	}
 
-	b, err := json.Marshal(*eConfig)
+	// The pid and the exit code of the command change while it runs
+	eConfig.Lock()
+	b, err := json.Marshal(eConfig)
+	eConfig.Unlock()
 	if err != nil {
 		return err
 	}
@@ -46,16 +46,39 @@ func (daemon *Daemon) ContainerKill(job *engine.Job) error {
 
 	// If no signal is passed, or SIGKILL, perform regular Kill (SIGKILL + wait())
 	if sig == 0 || syscall.Signal(sig) == syscall.SIGKILL {
+		container.setManuallyStopped()
 		if err := container.Kill(); err != nil {
 			return fmt.Errorf("Cannot kill container %s: %s", name, err)
 		}
 		container.LogEvent("kill")
 	} else {
-		// Otherwise, just send the requested signal
+		// Otherwise, just send the requested signal. The monitor doesn't
+		// restart the container once it exits, so a signal terminating it
+		// stops it like docker stop does.
+		terminates := container.isTerminationSignal(syscall.Signal(sig))
+		if terminates {
+			container.setManuallyStopped()
+		}
 		if err := container.KillSig(int(sig)); err != nil {
+			if terminates {
+				container.Lock()
+				container.HasBeenManuallyStopped = false
+				container.Unlock()
+			}
 			return fmt.Errorf("Cannot kill container %s: %s", name, err)
 		}
 		// FIXME: Add event for signals
 	}
 	return nil
 }
+
+// isTerminationSignal returns whether sig asks container to terminate: its
+// stop signal, or SIGTERM, SIGINT or SIGQUIT. Other signals, like SIGHUP or
+// SIGUSR1, are commonly handled without exiting, e.g. to reload.
+func (container *Container) isTerminationSignal(sig syscall.Signal) bool {
+	switch sig {
+	case syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT:
+		return true
+	}
+	return int(sig) == container.stopSignal()
+}
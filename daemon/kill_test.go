@@ -0,0 +1,31 @@
+package daemon
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/runconfig"
+)
+
+func TestIsTerminationSignal(t *testing.T) {
+	cases := []struct {
+		stopSignal string
+		sig        syscall.Signal
+		terminates bool
+	}{
+		{"", syscall.SIGTERM, true},
+		{"", syscall.SIGINT, true},
+		{"", syscall.SIGQUIT, true},
+		{"", syscall.SIGHUP, false},
+		{"", syscall.SIGUSR1, false},
+		{"SIGUSR1", syscall.SIGUSR1, true},
+		{"USR2", syscall.SIGUSR2, true},
+		{"USR2", syscall.SIGHUP, false},
+	}
+	for _, c := range cases {
+		container := &Container{Config: &runconfig.Config{StopSignal: c.stopSignal}}
+		if terminates := container.isTerminationSignal(c.sig); terminates != c.terminates {
+			t.Errorf("isTerminationSignal(%d) with the stop signal %q: got %t, expected %t", c.sig, c.stopSignal, terminates, c.terminates)
+		}
+	}
+}
@@ -18,6 +18,15 @@ import (
 	"github.com/docker/docker/utils"
 )
 
+var acceptedPsFilterTags = map[string]struct{}{
+	"ancestor": {},
+	"exited":   {},
+	"id":       {},
+	"label":    {},
+	"name":     {},
+	"status":   {},
+}
+
 // List returns an array of all containers registered in the daemon.
 func (daemon *Daemon) List() []*Container {
 	return daemon.containers.List()
@@ -47,6 +56,11 @@ func (daemon *Daemon) Containers(job *engine.Job) error {
 	if err != nil {
 		return err
 	}
+	for name := range psFilters {
+		if _, ok := acceptedPsFilterTags[name]; !ok {
+			return fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
 	if i, ok := psFilters["exited"]; ok {
 		for _, value := range i {
 			code, err := strconv.Atoi(value)
@@ -64,6 +78,13 @@ func (daemon *Daemon) Containers(job *engine.Job) error {
 			}
 		}
 	}
+	var ancestors map[string]bool
+	if i, ok := psFilters["ancestor"]; ok {
+		if ancestors, err = daemon.lookupAncestors(i); err != nil {
+			return err
+		}
+	}
+
 	names := map[string][]string{}
 	daemon.ContainerGraph().Walk("/", func(p string, e *graphdb.Entity) error {
 		names[e.ID()] = append(names[e.ID()], p)
@@ -104,6 +125,10 @@ func (daemon *Daemon) Containers(job *engine.Job) error {
 			return nil
 		}
 
+		if ancestors != nil && !daemon.descendsFrom(container.ImageID, ancestors) {
+			return nil
+		}
+
 		if before != "" && !foundBefore {
 			if container.ID == beforeCont.ID {
 				foundBefore = true
@@ -208,3 +233,40 @@ func (daemon *Daemon) Containers(job *engine.Job) error {
 	}
 	return nil
 }
+
+// lookupAncestors resolves the images of the ancestor filter to their IDs.
+func (daemon *Daemon) lookupAncestors(names []string) (map[string]bool, error) {
+	ancestors := make(map[string]bool, len(names))
+	for _, name := range names {
+		img, err := daemon.Repositories().LookupImage(name)
+		if err != nil || img == nil {
+			return nil, fmt.Errorf("No such image: %s", name)
+		}
+		ancestors[img.ID] = true
+	}
+	return ancestors, nil
+}
+
+// descendsFrom returns whether the image id is one of the ancestors or is
+// built on top of one of them.
+func (daemon *Daemon) descendsFrom(id string, ancestors map[string]bool) bool {
+	var walked []string
+	for id != "" {
+		if found, ok := ancestors[id]; ok {
+			for _, w := range walked {
+				ancestors[w] = found
+			}
+			return found
+		}
+		walked = append(walked, id)
+		img, err := daemon.graph.Get(id)
+		if err != nil || img == nil {
+			break
+		}
+		id = img.Parent
+	}
+	for _, w := range walked {
+		ancestors[w] = false
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/lxc"
+)
+
+// Files in the container root through which the output of a container goes
+// when live restore is enabled. They outlive the daemon, so the next one can
+// resume reading the output.
+const (
+	stdoutFifo = "stdout.fifo"
+	stderrFifo = "stderr.fifo"
+)
+
+// fifoDrainTimeout is how long the output left in the FIFOs of a container is
+// read after its process exits.
+const fifoDrainTimeout = 100 * time.Millisecond
+
+// canLiveRestore returns whether the process of container can keep running
+// while the daemon is down. The daemon can't reconnect a terminal or stdin,
+// nor the process of a container running with an OCI runtime.
+func (container *Container) canLiveRestore() bool {
+	ed, err := container.execDriver()
+	return container.daemon.config.LiveRestore &&
+		!container.Config.Tty && !container.Config.OpenStdin &&
+		err == nil && ed == container.daemon.execDriver &&
+		!strings.HasPrefix(ed.Name(), lxc.DriverName)
+}
+
+// hasOutputFifos returns whether container was started by a daemon with live
+// restore enabled and may still be running.
+func (container *Container) hasOutputFifos() bool {
+	path, err := container.getRootResourcePath(stdoutFifo)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// outputFifos copies the output of a container from its FIFOs to its stdout
+// and stderr.
+type outputFifos struct {
+	stdout *os.File
+	stderr *os.File
+	wg     sync.WaitGroup
+}
+
+// openOutputFifos opens the output FIFOs of container, creating them unless
+// a previous daemon did, and starts copying from them.
+func (container *Container) openOutputFifos() (*outputFifos, error) {
+	var (
+		f   = &outputFifos{}
+		err error
+	)
+	if f.stdout, err = container.openFifo(stdoutFifo); err != nil {
+		return nil, err
+	}
+	if f.stderr, err = container.openFifo(stderrFifo); err != nil {
+		f.stdout.Close()
+		return nil, err
+	}
+	f.copy(container.stdout, f.stdout)
+	f.copy(container.stderr, f.stderr)
+	return f, nil
+}
+
+func (container *Container) openFifo(name string) (*os.File, error) {
+	path, err := container.getRootResourcePath(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Mkfifo(path, 0600); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	// The daemon opens the FIFO for writing too, and passes that file to the
+	// container: the opening doesn't block, and the process gets no SIGPIPE
+	// while no daemon reads its output.
+	return os.OpenFile(path, os.O_RDWR, 0)
+}
+
+func (f *outputFifos) copy(dst io.Writer, src *os.File) {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if _, err := io.Copy(dst, src); err != nil && !os.IsTimeout(err) {
+			logrus.Debugf("Error copying container output from %s: %s", src.Name(), err)
+		}
+	}()
+}
+
+// close reads what is left in the FIFOs once the process of the container
+// exited, and removes them. The daemon holds a writer of the FIFOs, so their
+// end is never reached.
+func (f *outputFifos) close() {
+	deadline := time.Now().Add(fifoDrainTimeout)
+	f.stdout.SetReadDeadline(deadline)
+	f.stderr.SetReadDeadline(deadline)
+	f.wg.Wait()
+	for _, file := range []*os.File{f.stdout, f.stderr} {
+		file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			logrus.Debugf("Error removing %s: %s", file.Name(), err)
+		}
+	}
+}
+
+// restoreRunning re-attaches to the process of a container that a previous
+// daemon with live restore enabled left running, and monitors it again as if
+// this daemon had started it.
+func (container *Container) restoreRunning() (err error) {
+	container.Lock()
+	defer container.Unlock()
+
+	defer func() {
+		if err != nil {
+			container.cleanup()
+		}
+	}()
+
+	if err := container.Mount(); err != nil {
+		return err
+	}
+	if err := container.RestoreNetwork(); err != nil {
+		return err
+	}
+	linkedEnv, err := container.setupLinkedContainers()
+	if err != nil {
+		return err
+	}
+	env := container.createDaemonEnvironment(linkedEnv)
+	if err := populateCommand(container, env); err != nil {
+		return err
+	}
+
+	container.monitor = newContainerMonitor(container, container.hostConfig.RestartPolicy)
+	container.monitor.restoring = true
+	return container.runMonitor()
+}
+
+// reattach re-attaches to the process of c left running by a previous daemon.
+func (daemon *Daemon) reattach(c *Container, startCallback execdriver.StartCallback) (execdriver.ExitStatus, error) {
+	ed, err := c.execDriver()
+	if err != nil {
+		return execdriver.ExitStatus{ExitCode: -1}, err
+	}
+	return ed.Restore(c.command, startCallback)
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/pkg/broadcastwriter"
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestOutputFifos(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-liverestore-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	var stdout, stderr bytes.Buffer
+	container := &Container{
+		root:   root,
+		stdout: broadcastwriter.New(),
+		stderr: broadcastwriter.New(),
+	}
+	container.stdout.AddWriter(ioutils.NopWriteCloser(&stdout), "")
+	container.stderr.AddWriter(ioutils.NopWriteCloser(&stderr), "")
+
+	fifos, err := container.openOutputFifos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !container.hasOutputFifos() {
+		t.Fatal("Expected the output FIFOs to exist")
+	}
+	// The output is written before anything reads it, as while the daemon is down.
+	if _, err := fifos.stdout.WriteString("out\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fifos.stderr.WriteString("err\n"); err != nil {
+		t.Fatal(err)
+	}
+	fifos.close()
+
+	if stdout.String() != "out\n" || stderr.String() != "err\n" {
+		t.Fatalf("Expected the output of the FIFOs to be copied, got %q and %q", stdout.String(), stderr.String())
+	}
+	for _, name := range []string{stdoutFifo, stderrFifo} {
+		if _, err := os.Stat(filepath.Join(root, name)); !os.IsNotExist(err) {
+			t.Fatalf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	if container.hasOutputFifos() {
+		t.Fatal("Expected no output FIFOs once they are closed")
+	}
+}
+
+func TestUnknownExitCodeIsNoFailure(t *testing.T) {
+	m := &containerMonitor{restartPolicy: runconfig.RestartPolicy{Name: "on-failure"}}
+	if m.shouldRestart(execdriver.ExitStatus{ExitCode: -1, ExitCodeUnknown: true}) {
+		t.Fatal("Expected a restored container whose exit code is unknown not to be restarted on failure")
+	}
+	if !m.shouldRestart(execdriver.ExitStatus{ExitCode: 1}) {
+		t.Fatal("Expected a failed container to be restarted on failure")
+	}
+
+	m.restartPolicy.Name = "always"
+	if !m.shouldRestart(execdriver.ExitStatus{ExitCode: -1, ExitCodeUnknown: true}) {
+		t.Fatal("Expected a container to always be restarted")
+	}
+}
@@ -2,6 +2,7 @@ package jsonfilelog
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"sync"
 
@@ -13,23 +14,40 @@ import (
 // JSONFileLogger is Logger implementation for default docker logging:
 // JSON objects to file
 type JSONFileLogger struct {
-	buf *bytes.Buffer
-	f   *os.File   // store for closing
-	mu  sync.Mutex // protects buffer
+	buf   *bytes.Buffer
+	f     *os.File   // store for closing
+	mu    sync.Mutex // protects buffer
+	extra map[string]string
 }
 
-// New creates new JSONFileLogger which writes to filename
-func New(filename string) (logger.Logger, error) {
+// New creates new JSONFileLogger which writes to filename. The extra
+// attributes, if any, are recorded with every entry.
+func New(filename string, extra map[string]string) (logger.Logger, error) {
 	log, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return nil, err
 	}
 	return &JSONFileLogger{
-		f:   log,
-		buf: bytes.NewBuffer(nil),
+		f:     log,
+		buf:   bytes.NewBuffer(nil),
+		extra: extra,
 	}, nil
 }
 
+// ValidateLogOpt checks the options of the json-file logging driver: labels
+// and env, the comma separated names of the container labels and
+// environment variables to record with every entry.
+func ValidateLogOpt(cfg map[string]string) error {
+	for key := range cfg {
+		switch key {
+		case "labels", "env":
+		default:
+			return fmt.Errorf("Unknown log opt '%s' for json-file log driver", key)
+		}
+	}
+	return nil
+}
+
 // Log converts logger.Message to jsonlog.JSONLog and serializes it to file
 func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	l.mu.Lock()
@@ -38,7 +56,7 @@ func (l *JSONFileLogger) Log(msg *logger.Message) error {
 	if err != nil {
 		return err
 	}
-	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Created: timestamp}).MarshalJSONBuf(l.buf)
+	err = (&jsonlog.JSONLogBytes{Log: append(msg.Line, '\n'), Stream: msg.Source, Created: timestamp, Attrs: l.extra}).MarshalJSONBuf(l.buf)
 	if err != nil {
 		return err
 	}
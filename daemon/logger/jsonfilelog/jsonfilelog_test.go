@@ -18,7 +18,7 @@ func TestJSONFileLogger(t *testing.T) {
 	}
 	defer os.RemoveAll(tmp)
 	filename := filepath.Join(tmp, "container.log")
-	l, err := New(filename)
+	l, err := New(filename, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -47,6 +47,42 @@ func TestJSONFileLogger(t *testing.T) {
 	}
 }
 
+func TestJSONFileLoggerExtra(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-logger-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	filename := filepath.Join(tmp, "container.log")
+	l, err := New(filename, map[string]string{"app": "web", "env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	cid := "a7317399f3f857173c6179d44823594f8294678dea9999662e5c625b5a1c7657"
+	if err := l.Log(&logger.Message{ContainerID: cid, Line: []byte("line1"), Source: "src1"}); err != nil {
+		t.Fatal(err)
+	}
+	res, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"log":"line1\n","stream":"src1","attrs":{"app":"web","env":"prod"},"time":"0001-01-01T00:00:00Z"}
+`
+	if string(res) != expected {
+		t.Fatalf("Wrong log content: %q, expected %q", res, expected)
+	}
+}
+
+func TestValidateLogOpt(t *testing.T) {
+	if err := ValidateLogOpt(map[string]string{"labels": "app", "env": "ENV"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateLogOpt(map[string]string{"max-size": "10m"}); err == nil {
+		t.Fatal("Expected an error for an unknown log opt")
+	}
+}
+
 func BenchmarkJSONFileLogger(b *testing.B) {
 	tmp, err := ioutil.TempDir("", "docker-logger-")
 	if err != nil {
@@ -54,7 +90,7 @@ func BenchmarkJSONFileLogger(b *testing.B) {
 	}
 	defer os.RemoveAll(tmp)
 	filename := filepath.Join(tmp, "container.log")
-	l, err := New(filename)
+	l, err := New(filename, nil)
 	if err != nil {
 		b.Fatal(err)
 	}
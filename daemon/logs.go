@@ -8,6 +8,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
@@ -28,6 +29,8 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) error {
 		tail   = job.Getenv("tail")
 		follow = job.GetenvBool("follow")
 		times  = job.GetenvBool("timestamps")
+		since  = job.GetenvInt64("since")
+		detail = job.GetenvBool("details")
 		lines  = -1
 		format string
 	)
@@ -100,6 +103,15 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) error {
 					logrus.Errorf("Error streaming logs: %s", err)
 					break
 				}
+				// Entries older than since are skipped; the legacy
+				// logs above carry no time to filter on.
+				if since > 0 && l.Created.Before(time.Unix(since, 0)) {
+					l.Reset()
+					continue
+				}
+				if detail {
+					l.Log = l.Details() + l.Log
+				}
 				logLine := l.Log
 				if times {
 					// format can be "" or time format, so here can't be error
@@ -116,6 +128,12 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) error {
 		}
 	}
 	if follow && container.IsRunning() {
+		// The entries followed aren't read from the log file, and lack
+		// the attributes it records.
+		var attrs map[string]string
+		if detail {
+			attrs = container.logAttributes(container.LogConfig())
+		}
 		errors := make(chan error, 2)
 		wg := sync.WaitGroup{}
 
@@ -124,7 +142,7 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) error {
 			stdoutPipe := container.StdoutLogPipe()
 			defer stdoutPipe.Close()
 			go func() {
-				errors <- jsonlog.WriteLog(stdoutPipe, job.Stdout, format)
+				errors <- jsonlog.WriteLog(stdoutPipe, job.Stdout, format, attrs)
 				wg.Done()
 			}()
 		}
@@ -133,7 +151,7 @@ func (daemon *Daemon) ContainerLogs(job *engine.Job) error {
 			stderrPipe := container.StderrLogPipe()
 			defer stderrPipe.Close()
 			go func() {
-				errors <- jsonlog.WriteLog(stderrPipe, job.Stderr, format)
+				errors <- jsonlog.WriteLog(stderrPipe, job.Stderr, format, attrs)
 				wg.Done()
 			}()
 		}
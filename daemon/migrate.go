@@ -0,0 +1,280 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/graphdriver"
+	"github.com/docker/docker/graph"
+	"github.com/docker/docker/image"
+)
+
+// migrateGraphDriver recreates the layers of the images and containers of
+// the storage driver from in the driver to, so that switching drivers
+// doesn't require pulling the images again, and adds the tags of the images
+// of from to the ones of to. Each layer is replayed by applying its diff to
+// its parent in to, parents first. The layers to holds already are skipped,
+// unless their migration was interrupted, which makes an interrupted
+// migration resume where it stopped. The data of from is left as it is.
+func migrateGraphDriver(root, from string, to graphdriver.Driver) error {
+	if from == to.String() {
+		return fmt.Errorf("Can't migrate the %s storage driver to itself", from)
+	}
+	fromDriver, err := graphdriver.GetDriver(from, root, nil)
+	if err != nil {
+		return fmt.Errorf("Error initializing the %s storage driver to migrate from: %v", from, err)
+	}
+	defer fromDriver.Cleanup()
+
+	m := &layerMigrator{
+		from:    fromDriver,
+		to:      to,
+		pending: path.Join(root, "migrating-"+to.String()),
+	}
+	if err := os.MkdirAll(m.pending, 0700); err != nil {
+		return err
+	}
+
+	logrus.Infof("Migrating the images and containers of the %s storage driver to %s", fromDriver, to)
+	if err := m.migrateImages(path.Join(root, "graph")); err != nil {
+		return err
+	}
+	if err := m.migrateContainers(path.Join(root, "containers")); err != nil {
+		return err
+	}
+	// Only removed once no layer is left pending
+	os.Remove(m.pending)
+
+	if err := migrateRepositories(root, from, to.String()); err != nil {
+		return err
+	}
+	logrus.Infof("Migration of the %s storage driver to %s done", fromDriver, to)
+	return nil
+}
+
+// layerMigrator recreates the layers of the driver from in the driver to.
+type layerMigrator struct {
+	from, to graphdriver.Driver
+	// pending is the directory of the markers of the layers being migrated,
+	// each an empty file named after its layer. A layer is marked before it
+	// is created in to, and the marker removed once its diff is applied, so
+	// that the layers an interrupted migration left half applied are
+	// recreated rather than kept when it resumes.
+	pending string
+}
+
+// migrateImages migrates the layers of the images of the graph at root.
+// The images whose layer, or the layer of a parent, can't be migrated are
+// left behind with a warning.
+func (m *layerMigrator) migrateImages(root string) error {
+	fis, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	parents := make(map[string]string)
+	for _, fi := range fis {
+		if !fi.IsDir() || fi.Name() == "_tmp" {
+			continue
+		}
+		img, err := image.LoadImage(path.Join(root, fi.Name()))
+		if err != nil {
+			logrus.Warnf("Not migrating image %s: %v", fi.Name(), err)
+			continue
+		}
+		parents[img.ID] = img.Parent
+	}
+
+	migrated := make(map[string]error)
+	var migrate func(id string) error
+	migrate = func(id string) error {
+		if err, done := migrated[id]; done {
+			return err
+		}
+		parent, exists := parents[id]
+		if !exists {
+			return fmt.Errorf("Image %s doesn't exist", id)
+		}
+		var err error
+		if parent != "" {
+			err = migrate(parent)
+		}
+		if err == nil {
+			err = m.migrateLayer(id, parent, nil)
+		}
+		migrated[id] = err
+		return err
+	}
+
+	for id := range parents {
+		if err := migrate(id); err != nil {
+			logrus.Warnf("Not migrating image %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// migrateContainers migrates the init and read-write layers of the
+// containers at root created with from, and makes them use to.
+func (m *layerMigrator) migrateContainers(root string) error {
+	fis, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, fi := range fis {
+		container := &Container{
+			root:         path.Join(root, fi.Name()),
+			State:        NewState(),
+			execCommands: newExecStore(),
+		}
+		if err := container.FromDisk(); err != nil {
+			logrus.Warnf("Not migrating container %s: %v", fi.Name(), err)
+			continue
+		}
+		// The containers of the drivers older than the Driver field are aufs ones
+		if container.Driver != m.from.String() && (container.Driver != "" || m.from.String() != "aufs") {
+			continue
+		}
+
+		initID := fmt.Sprintf("%s-init", container.ID)
+		if err := m.migrateLayer(initID, container.ImageID, nil); err != nil {
+			logrus.Warnf("Not migrating container %s: %v", container.ID, err)
+			continue
+		}
+		if err := m.migrateLayer(container.ID, initID, container.hostConfig.StorageOpt); err != nil {
+			logrus.Warnf("Not migrating container %s: %v", container.ID, err)
+			continue
+		}
+
+		container.Driver = m.to.String()
+		if err := container.ToDisk(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateLayer recreates the layer id of from in to, on top of parent, with
+// the diff of id and parent, and the options of storageOpt if there are
+// any.
+func (m *layerMigrator) migrateLayer(id, parent string, storageOpt map[string]string) error {
+	marker := path.Join(m.pending, id)
+	if m.to.Exists(id) {
+		if _, err := os.Stat(marker); os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		logrus.Debugf("Removing layer %s, whose migration was interrupted", id)
+		if err := m.to.Remove(id); err != nil {
+			return fmt.Errorf("Error removing layer %s, whose migration was interrupted: %v", id, err)
+		}
+	}
+	if !m.from.Exists(id) {
+		return fmt.Errorf("Layer %s doesn't exist in the %s storage driver", id, m.from)
+	}
+	logrus.Debugf("Migrating layer %s", id)
+
+	if err := writeMarker(marker); err != nil {
+		return err
+	}
+	if len(storageOpt) > 0 {
+		driver, ok := m.to.(graphdriver.StorageOptDriver)
+		if !ok {
+			os.Remove(marker)
+			return fmt.Errorf("--storage-opt is not supported by the %s storage driver", m.to)
+		}
+		if err := driver.CreateWithStorageOpt(id, parent, storageOpt); err != nil {
+			return err
+		}
+	} else if err := m.to.Create(id, parent); err != nil {
+		return err
+	}
+
+	diff, err := m.from.Diff(id, parent)
+	if err == nil {
+		_, err = m.to.ApplyDiff(id, parent, diff)
+		diff.Close()
+	}
+	if err != nil {
+		if err := m.to.Remove(id); err != nil {
+			logrus.Debugf("Error removing layer %s: %v", id, err)
+		}
+		return fmt.Errorf("Error migrating layer %s: %v", id, err)
+	}
+	return os.Remove(marker)
+}
+
+// writeMarker creates the empty file marker, synced to disk for it to be
+// there after a crash.
+func writeMarker(marker string) error {
+	f, err := os.OpenFile(marker, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// repositoriesFile is the content of the file of a graph.TagStore.
+type repositoriesFile struct {
+	Repositories map[string]graph.Repository
+}
+
+// migrateRepositories adds the tags of the images of the driver from to the
+// ones of the driver to, unless to has them already.
+func migrateRepositories(root, from, to string) error {
+	fromData, err := ioutil.ReadFile(path.Join(root, "repositories-"+from))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var fromStore, toStore repositoriesFile
+	if err := json.Unmarshal(fromData, &fromStore); err != nil {
+		return err
+	}
+
+	toPath := path.Join(root, "repositories-"+to)
+	if toData, err := ioutil.ReadFile(toPath); err == nil {
+		if err := json.Unmarshal(toData, &toStore); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if toStore.Repositories == nil {
+		toStore.Repositories = make(map[string]graph.Repository)
+	}
+
+	for name, fromRepo := range fromStore.Repositories {
+		repo, exists := toStore.Repositories[name]
+		if !exists {
+			repo = make(graph.Repository)
+			toStore.Repositories[name] = repo
+		}
+		for tag, id := range fromRepo {
+			if _, exists := repo[tag]; !exists {
+				repo[tag] = id
+			}
+		}
+	}
+
+	data, err := json.Marshal(toStore)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(toPath, data, 0600)
+}
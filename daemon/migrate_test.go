@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/daemon/graphdriver/vfs"
+	"github.com/docker/docker/graph"
+	"github.com/docker/docker/pkg/reexec"
+)
+
+func init() {
+	reexec.Init()
+}
+
+func TestMigrateRepositories(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-migrate-repositories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	from := `{"Repositories":{"busybox":{"latest":"aaa","1.0":"bbb"},"ubuntu":{"14.04":"ccc"}}}`
+	to := `{"Repositories":{"busybox":{"latest":"ddd"}}}`
+	if err := ioutil.WriteFile(path.Join(root, "repositories-aufs"), []byte(from), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "repositories-overlay"), []byte(to), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateRepositories(root, "aufs", "overlay"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path.Join(root, "repositories-overlay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store repositoriesFile
+	if err := json.Unmarshal(data, &store); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]graph.Repository{
+		"busybox": {"latest": "ddd", "1.0": "bbb"},
+		"ubuntu":  {"14.04": "ccc"},
+	}
+	if !reflect.DeepEqual(store.Repositories, expected) {
+		t.Fatalf("Expected %v, got %v", expected, store.Repositories)
+	}
+
+	// Without tags to migrate to, the ones of from are copied
+	if err := migrateRepositories(root, "aufs", "btrfs"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadFile(path.Join(root, "repositories-btrfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Repositories = nil
+	if err := json.Unmarshal(data, &store); err != nil {
+		t.Fatal(err)
+	}
+	expected["busybox"]["latest"] = "aaa"
+	if !reflect.DeepEqual(store.Repositories, expected) {
+		t.Fatalf("Expected %v, got %v", expected, store.Repositories)
+	}
+}
+
+func TestMigrateLayerResumed(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-migrate-layer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	from, err := vfs.Init(path.Join(root, "from"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := vfs.Init(path.Join(root, "to"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &layerMigrator{from: from, to: to, pending: path.Join(root, "migrating-vfs")}
+	if err := os.MkdirAll(m.pending, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeLayer := func(driver interface {
+		Create(id, parent string) error
+		Get(id, mountLabel string) (string, error)
+		Put(id string) error
+	}, id string, files ...string) {
+		if err := driver.Create(id, ""); err != nil {
+			t.Fatal(err)
+		}
+		dir, err := driver.Get(id, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer driver.Put(id)
+		for _, name := range files {
+			if err := ioutil.WriteFile(path.Join(dir, name), []byte(name), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeLayer(from, "half", "a", "b")
+	writeLayer(from, "done", "a", "b")
+	// An interrupted migration left half the files of a layer, and another
+	// layer whole
+	writeLayer(to, "half", "a")
+	if err := writeMarker(path.Join(m.pending, "half")); err != nil {
+		t.Fatal(err)
+	}
+	writeLayer(to, "done", "a")
+
+	for _, id := range []string{"half", "done"} {
+		if err := m.migrateLayer(id, "", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := to.Get("half", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Put("half")
+	if _, err := os.Stat(path.Join(dir, "b")); err != nil {
+		t.Fatalf("Expected the interrupted layer to be migrated again: %v", err)
+	}
+	if _, err := os.Stat(path.Join(m.pending, "half")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the marker of the migrated layer to be removed, got %v", err)
+	}
+	dir, err = to.Get("done", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Put("done")
+	if _, err := os.Stat(path.Join(dir, "b")); !os.IsNotExist(err) {
+		t.Fatalf("Expected the layer migrated already to be kept as it is, got %v", err)
+	}
+}
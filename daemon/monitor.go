@@ -1,9 +1,11 @@
 package daemon
 
 import (
+	"fmt"
 	"io"
 	"os/exec"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -50,6 +52,14 @@ type containerMonitor struct {
 
 	// lastStartTime is the time which the monitor last exec'd the container's process
 	lastStartTime time.Time
+
+	// restoring is set while the monitor re-attaches to a process that a
+	// previous daemon started
+	restoring bool
+
+	// startErr is the error of the setup of the process once started,
+	// which kills it before it is reported running
+	startErr error
 }
 
 // newContainerMonitor returns an initialized containerMonitor for the provided container
@@ -115,13 +125,20 @@ func (m *containerMonitor) Start() error {
 			defer m.container.Unlock()
 		}
 		m.Close()
+		if afterRun && m.container.hostConfig.AutoRemove {
+			go m.container.daemon.autoRemove(m.container)
+		}
 	}()
 
-	// reset the restart count
-	m.container.RestartCount = -1
+	// reset the restart count, unless the process was started by a previous daemon
+	if !m.restoring {
+		m.container.RestartCount = -1
+	}
 
 	for {
-		m.container.RestartCount++
+		if !m.restoring {
+			m.container.RestartCount++
+		}
 
 		if err := m.container.startLogging(); err != nil {
 			m.resetContainer(false)
@@ -131,14 +148,34 @@ func (m *containerMonitor) Start() error {
 
 		pipes := execdriver.NewPipes(m.container.stdin, m.container.stdout, m.container.stderr, m.container.Config.OpenStdin)
 
-		m.container.LogEvent("start")
+		var fifos *outputFifos
+		if m.container.canLiveRestore() {
+			if fifos, err = m.container.openOutputFifos(); err != nil {
+				m.resetContainer(false)
 
-		m.lastStartTime = time.Now()
+				return err
+			}
+			pipes = execdriver.NewPipes(nil, fifos.stdout, fifos.stderr, false)
+		}
 
-		if exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback); err != nil {
+		if m.restoring {
+			m.lastStartTime = m.container.StartedAt
+			exitStatus, err = m.container.daemon.reattach(m.container, m.callback)
+		} else {
+			m.container.LogEvent("start")
+			m.lastStartTime = time.Now()
+			exitStatus, err = m.container.daemon.Run(m.container, pipes, m.callback)
+		}
+		if fifos != nil {
+			fifos.close()
+		}
+		if m.startErr != nil {
+			err, m.startErr = m.startErr, nil
+		}
+		if err != nil {
 			// if we receive an internal error from the initial start of a container then lets
 			// return it instead of entering the restart loop
-			if m.container.RestartCount == 0 {
+			if m.container.RestartCount == 0 || m.restoring {
 				m.container.ExitCode = -1
 				m.resetContainer(false)
 
@@ -147,13 +184,16 @@ func (m *containerMonitor) Start() error {
 
 			logrus.Errorf("Error running container: %s", err)
 		}
+		m.restoring = false
 
 		// here container.Lock is already lost
 		afterRun = true
+		m.container.stopHealthcheck()
 
-		m.resetMonitor(err == nil && exitStatus.ExitCode == 0)
+		// an exit code that couldn't be collected is no failure
+		m.resetMonitor(err == nil && (exitStatus.ExitCode == 0 || exitStatus.ExitCodeUnknown))
 
-		if m.shouldRestart(exitStatus.ExitCode) {
+		if m.shouldRestart(exitStatus) {
 			m.container.SetRestarting(&exitStatus)
 			if exitStatus.OOMKilled {
 				m.container.LogEvent("oom")
@@ -214,7 +254,7 @@ func (m *containerMonitor) waitForNextRestart() {
 
 // shouldRestart checks the restart policy and applies the rules to determine if
 // the container's process should be restarted
-func (m *containerMonitor) shouldRestart(exitCode int) bool {
+func (m *containerMonitor) shouldRestart(exitStatus execdriver.ExitStatus) bool {
 	m.mux.Lock()
 	defer m.mux.Unlock()
 
@@ -224,7 +264,7 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 	}
 
 	switch m.restartPolicy.Name {
-	case "always":
+	case "always", "unless-stopped":
 		return true
 	case "on-failure":
 		// the default value of 0 for MaximumRetryCount means that we will not enforce a maximum count
@@ -234,7 +274,7 @@ func (m *containerMonitor) shouldRestart(exitCode int) bool {
 			return false
 		}
 
-		return exitCode != 0
+		return exitStatus.ExitCode != 0 && !exitStatus.ExitCodeUnknown
 	}
 
 	return false
@@ -252,7 +292,24 @@ func (m *containerMonitor) callback(processConfig *execdriver.ProcessConfig, pid
 		}
 	}
 
+	if !m.restoring {
+		// the container must not run without the limits it asked for
+		if err := m.container.limitBandwidth(pid); err != nil {
+			m.startErr = fmt.Errorf("Unable to limit the bandwidth of the container: %v", err)
+			if err := syscall.Kill(pid, syscall.SIGKILL); err != nil {
+				logrus.Errorf("Unable to kill %s: %v", m.container.ID, err)
+			}
+			return
+		}
+	}
+
+	startedAt, paused := m.container.StartedAt, m.container.Paused
 	m.container.setRunning(pid)
+	if m.restoring {
+		// the process runs on from a previous daemon
+		m.container.StartedAt, m.container.Paused = startedAt, paused
+	}
+	m.container.startHealthcheck()
 
 	// signal that the process has started
 	// close channel only if not closed
@@ -0,0 +1,1241 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/daemon/networkdriver/ipam"
+	"github.com/docker/docker/daemon/networkdriver/macvlan"
+	"github.com/docker/docker/daemon/networkdriver/overlay"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/kvstore"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/docker/docker/runconfig"
+)
+
+const (
+	// bridgeNameOption is the option of the networks of the bridge driver
+	// that names their bridge on the host.
+	bridgeNameOption = "com.docker.network.bridge.name"
+
+	// The options of the networks of the bridge driver that enable
+	// inter-container communication, and IP masquerading, on them. They
+	// default to the settings of the daemon.
+	bridgeICCOption    = "com.docker.network.bridge.enable_icc"
+	bridgeIPMasqOption = "com.docker.network.bridge.enable_ip_masquerade"
+
+	// bridgeIptablesOption is the option of the networks of the bridge
+	// driver that leaves their iptables rules to the operator when false,
+	// for hosts whose firewall is managed otherwise.
+	bridgeIptablesOption = "com.docker.network.bridge.enable_iptables"
+
+	// mtuOption is the option of the networks of the bridge and overlay
+	// drivers that sets the MTU of the interfaces of their containers, the
+	// one of the daemon by default, less the VXLAN overhead for overlay.
+	// The interfaces of the macvlan and ipvlan drivers have the MTU of
+	// their parent by default.
+	mtuOption = "com.docker.network.driver.mtu"
+
+	// vxlanIDOption is the option of the networks of the overlay driver
+	// that gives the VXLAN ID of their tunnels.
+	vxlanIDOption = "com.docker.network.driver.overlay.vxlanid_list"
+
+	// The directories of the networks of the overlay driver in the cluster
+	// store: the networks by ID, and their IDs by name.
+	clusterNetworksKey     = "network"
+	clusterNetworkNamesKey = "networkname"
+)
+
+var validNetworkNamePattern = regexp.MustCompile(`^` + validContainerNameChars + `+$`)
+
+// Network is a network containers can be connected to: one of the networks
+// every daemon has, named after their driver, or one created with `docker
+// network create`.
+type Network struct {
+	ID         string
+	Name       string
+	Driver     string
+	IPAMDriver string // The IPAM driver of a network of the bridge driver, empty for the built-in one
+	Subnet     string
+	Gateway    string
+	Options    map[string]string
+	// Internal is whether n, a network of the bridge driver, is cut off
+	// from the outside world: its containers only reach each other, and
+	// the host.
+	Internal bool `json:",omitempty"`
+	// ParentCreated is whether the parent of a network of the macvlan or
+	// ipvlan driver is a VLAN sub-interface created for it, which goes
+	// with the network.
+	ParentCreated bool `json:",omitempty"`
+	// BridgeCreated is whether the bridge of a network of the bridge
+	// driver was created for it, and goes with the network. A bridge the
+	// host already had, given with com.docker.network.bridge.name, is
+	// left to it.
+	BridgeCreated bool `json:",omitempty"`
+}
+
+// predefined returns whether n is one of the networks every daemon has,
+// which can't be removed.
+func (n *Network) predefined() bool {
+	switch n.Name {
+	case "bridge", "host", "none":
+		return true
+	}
+	return false
+}
+
+// bridge returns the name of the bridge the containers of n are attached to
+// on the host, empty if n isn't a network of the bridge or overlay driver.
+func (n *Network) bridge() string {
+	if n.Driver == "overlay" {
+		return overlay.BridgeName(n.ID)
+	}
+	return n.Options[bridgeNameOption]
+}
+
+// onLan returns whether n is a network of the macvlan or ipvlan driver,
+// whose containers are on the LAN of its parent.
+func (n *Network) onLan() bool {
+	return macvlan.IsDriver(n.Driver)
+}
+
+// parent returns the interface of the host n, a network of the macvlan or
+// ipvlan driver, is the LAN of.
+func (n *Network) parent() string {
+	return n.Options[macvlan.ParentOption]
+}
+
+// lanMode returns the mode of the interfaces of n, a network of the macvlan
+// or ipvlan driver.
+func (n *Network) lanMode() string {
+	return n.Options[macvlan.ModeOption(n.Driver)]
+}
+
+// mtu returns the MTU of the interfaces on n, a network of the bridge,
+// overlay, macvlan or ipvlan driver, 0 if it doesn't have its own.
+func (n *Network) mtu() int {
+	mtu, _ := strconv.Atoi(n.Options[mtuOption])
+	return mtu
+}
+
+// vxlanID returns the VXLAN ID of n, a network of the overlay driver.
+func (n *Network) vxlanID() uint32 {
+	vni, _ := strconv.ParseUint(n.Options[vxlanIDOption], 10, 32)
+	return uint32(vni)
+}
+
+// subnet returns the parsed subnet of n, nil if it has none.
+func (n *Network) subnet() *net.IPNet {
+	_, subnet, err := net.ParseCIDR(n.Subnet)
+	if err != nil {
+		return nil
+	}
+	return subnet
+}
+
+// networkStore keeps the networks of the daemon, each in the config.json of
+// its directory under root.
+type networkStore struct {
+	sync.Mutex
+	root     string
+	networks map[string]*Network
+}
+
+func newNetworkStore(root string) (*networkStore, error) {
+	if err := os.MkdirAll(root, 0700); err != nil && !os.IsExist(err) {
+		return nil, err
+	}
+	dir, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &networkStore{root: root, networks: make(map[string]*Network)}
+	for _, fi := range dir {
+		data, err := ioutil.ReadFile(filepath.Join(root, fi.Name(), "config.json"))
+		if err != nil {
+			logrus.Debugf("Error restoring network %s: %v", fi.Name(), err)
+			continue
+		}
+		n := &Network{}
+		if err := json.Unmarshal(data, n); err != nil {
+			logrus.Debugf("Error restoring network %s: %v", fi.Name(), err)
+			continue
+		}
+		s.networks[n.ID] = n
+	}
+	return s, nil
+}
+
+// Get returns the network whose name, ID or unique prefix of ID is
+// nameOrID.
+func (s *networkStore) Get(nameOrID string) (*Network, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.get(nameOrID)
+}
+
+func (s *networkStore) get(nameOrID string) (*Network, error) {
+	if nameOrID == "" {
+		return nil, fmt.Errorf("No such network: %s", nameOrID)
+	}
+	if n, exists := s.networks[nameOrID]; exists {
+		return n, nil
+	}
+	var match *Network
+	for _, n := range s.networks {
+		if n.Name == nameOrID {
+			return n, nil
+		}
+		if strings.HasPrefix(n.ID, nameOrID) {
+			if match != nil {
+				return nil, fmt.Errorf("Network %s is ambiguous, more than one network ID starts with it", nameOrID)
+			}
+			match = n
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("No such network: %s", nameOrID)
+	}
+	return match, nil
+}
+
+// List returns the networks of the store, sorted by name.
+func (s *networkStore) List() []*Network {
+	s.Lock()
+	defer s.Unlock()
+	networks := make([]*Network, 0, len(s.networks))
+	for _, n := range s.networks {
+		networks = append(networks, n)
+	}
+	sort.Sort(networksByName(networks))
+	return networks
+}
+
+// add stores a new network named name, after checking that no other network
+// is.
+func (s *networkStore) add(name, driver string) (*Network, error) {
+	s.Lock()
+	defer s.Unlock()
+	if err := s.checkName(name); err != nil {
+		return nil, err
+	}
+	n := &Network{
+		ID:      stringid.GenerateRandomID(),
+		Name:    name,
+		Driver:  driver,
+		Options: make(map[string]string),
+	}
+	s.networks[n.ID] = n
+	return n, nil
+}
+
+func (s *networkStore) checkName(name string) error {
+	for _, n := range s.networks {
+		if n.Name == name {
+			return fmt.Errorf("Conflict. The network name %s is already in use by network %s.", name, stringid.TruncateID(n.ID))
+		}
+	}
+	return nil
+}
+
+// setClusterNetworks replaces the networks of the overlay driver of the
+// store, which are kept in the cluster store rather than on disk, by
+// networks.
+func (s *networkStore) setClusterNetworks(networks []*Network) {
+	s.Lock()
+	defer s.Unlock()
+	for id, n := range s.networks {
+		if n.Driver == "overlay" {
+			delete(s.networks, id)
+		}
+	}
+	for _, n := range networks {
+		s.networks[n.ID] = n
+	}
+}
+
+// save writes n to disk.
+func (s *networkStore) save(n *Network) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(s.root, n.ID)
+	if err := os.MkdirAll(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "config.json"), data, 0600)
+}
+
+// remove deletes n, from disk too.
+func (s *networkStore) remove(n *Network) error {
+	s.Lock()
+	defer s.Unlock()
+	if err := os.RemoveAll(filepath.Join(s.root, n.ID)); err != nil {
+		return err
+	}
+	delete(s.networks, n.ID)
+	return nil
+}
+
+type networksByName []*Network
+
+func (r networksByName) Len() int           { return len(r) }
+func (r networksByName) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r networksByName) Less(i, j int) bool { return r[i].Name < r[j].Name }
+
+// initNetworks stores the networks every daemon has, and sets up the bridges
+// of the networks created with `docker network create` and their DNS
+// servers, as well as the networks of the macvlan and ipvlan drivers.
+// bridge is the output of init_networkdriver, nil when networking is
+// disabled.
+func (daemon *Daemon) initNetworks(bridge *engine.Env) error {
+	predefined := map[string]string{"host": "host", "none": "null"}
+	if bridge != nil {
+		predefined["bridge"] = "bridge"
+	}
+	for name, driver := range predefined {
+		n, err := daemon.networks.Get(name)
+		if err != nil {
+			if n, err = daemon.networks.add(name, driver); err != nil {
+				return err
+			}
+		}
+		if name == "bridge" {
+			// The settings of the default bridge may change with the
+			// flags of the daemon.
+			n.Subnet = bridge.Get("Subnet")
+			n.Gateway = bridge.Get("Gateway")
+			n.Options[bridgeNameOption] = bridge.Get("Bridge")
+		}
+		if err := daemon.networks.save(n); err != nil {
+			return err
+		}
+	}
+
+	daemon.eng.OnShutdown(daemon.stopResolvers)
+	if bridge != nil && daemon.overlay != nil {
+		if n, err := daemon.networks.Get("bridge"); err == nil {
+			daemon.startResolver(n)
+		}
+	}
+
+	for _, n := range daemon.networks.List() {
+		if n.predefined() || n.Driver != "bridge" && !n.onLan() {
+			continue
+		}
+		if bridge == nil {
+			logrus.Warnf("Network %s is unavailable: networking is disabled", n.Name)
+			continue
+		}
+		if n.onLan() {
+			created := n.ParentCreated
+			if err := daemon.createLanNetwork(n); err != nil {
+				logrus.Errorf("Unable to set up network %s: %v", n.Name, err)
+			} else if n.ParentCreated != created {
+				daemon.networks.save(n)
+			}
+			continue
+		}
+		created := n.BridgeCreated
+		if err := daemon.createBridge(n); err != nil {
+			logrus.Errorf("Unable to set up network %s: %v", n.Name, err)
+		} else if n.BridgeCreated != created {
+			daemon.networks.save(n)
+		}
+	}
+
+	if daemon.clusterStore != nil {
+		return daemon.watchClusterNetworks()
+	}
+	return nil
+}
+
+// initCluster connects the daemon to its cluster store, if it has one, to
+// share overlay networks with the other daemons of the cluster.
+func (daemon *Daemon) initCluster() error {
+	config := daemon.config
+	if config.ClusterStore == "" {
+		if config.ClusterAdvertise != "" {
+			return fmt.Errorf("--cluster-advertise requires --cluster-store")
+		}
+		return nil
+	}
+	if config.DisableNetwork {
+		return fmt.Errorf("--cluster-store can't be used with networking disabled")
+	}
+	if config.ClusterAdvertise == "" {
+		return fmt.Errorf("--cluster-store requires --cluster-advertise, the address the other daemons of the cluster reach this host at")
+	}
+	hostIP, err := advertiseAddress(config.ClusterAdvertise)
+	if err != nil {
+		return err
+	}
+	store, err := kvstore.New(config.ClusterStore)
+	if err != nil {
+		return err
+	}
+	daemon.eng.OnShutdown(store.Close)
+
+	daemon.clusterStore = store
+	daemon.overlay = overlay.New(store, hostIP, config.Mtu, daemon.updateOverlayHosts)
+	return nil
+}
+
+// advertiseAddress returns the IPv4 address of advertise, an address or the
+// name of an interface.
+func advertiseAddress(advertise string) (net.IP, error) {
+	if ip := net.ParseIP(advertise); ip != nil {
+		if ip.To4() == nil {
+			return nil, fmt.Errorf("Invalid --cluster-advertise %s: only IPv4 addresses are supported", advertise)
+		}
+		return ip.To4(), nil
+	}
+	addr, _, err := networkdriver.GetIfaceAddr(advertise)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid --cluster-advertise %s: %v", advertise, err)
+	}
+	return addr.(*net.IPNet).IP, nil
+}
+
+// watchClusterNetworks keeps the networks of the overlay driver in sync with
+// the cluster store, starting with the ones it has now.
+func (daemon *Daemon) watchClusterNetworks() error {
+	stopCh := make(chan struct{})
+	ch, err := daemon.clusterStore.WatchTree(clusterNetworksKey, stopCh)
+	if err != nil {
+		return fmt.Errorf("Unable to get the networks of the cluster store: %v", err)
+	}
+	daemon.networks.setClusterNetworks(decodeNetworks(<-ch))
+	daemon.eng.OnShutdown(func() {
+		close(stopCh)
+	})
+
+	go func() {
+		for pairs := range ch {
+			daemon.networks.setClusterNetworks(decodeNetworks(pairs))
+		}
+	}()
+	return nil
+}
+
+// syncClusterNetworks gets the networks of the overlay driver from the
+// cluster store right away, rather than when the watch gets them.
+func (daemon *Daemon) syncClusterNetworks() error {
+	pairs, err := daemon.clusterStore.List(clusterNetworksKey)
+	if err != nil {
+		return err
+	}
+	daemon.networks.setClusterNetworks(decodeNetworks(pairs))
+	return nil
+}
+
+func decodeNetworks(pairs []*kvstore.KVPair) []*Network {
+	var networks []*Network
+	for _, p := range pairs {
+		n := &Network{}
+		if err := json.Unmarshal(p.Value, n); err != nil {
+			logrus.Debugf("Ignoring the network %s of the cluster store: %v", p.Key, err)
+			continue
+		}
+		networks = append(networks, n)
+	}
+	return networks
+}
+
+// createBridge sets up the bridge of n, and starts its DNS server. A new
+// network is given a free subnet by the bridge driver, an existing one gets
+// its subnet back.
+func (daemon *Daemon) createBridge(n *Network) error {
+	job := daemon.eng.Job("create_network")
+	job.Setenv("Bridge", n.bridge())
+	job.Setenv("IPAMDriver", n.IPAMDriver)
+	job.SetenvBool("Internal", n.Internal)
+	if icc, err := strconv.ParseBool(n.Options[bridgeICCOption]); err == nil {
+		job.SetenvBool("EnableICC", icc)
+	}
+	if ipMasq, err := strconv.ParseBool(n.Options[bridgeIPMasqOption]); err == nil {
+		job.SetenvBool("EnableIpMasq", ipMasq)
+	}
+	if enabled, err := strconv.ParseBool(n.Options[bridgeIptablesOption]); err == nil {
+		job.SetenvBool("EnableIptables", enabled)
+	}
+	if n.Subnet != "" {
+		_, subnet, err := net.ParseCIDR(n.Subnet)
+		if err != nil {
+			return err
+		}
+		ones, _ := subnet.Mask.Size()
+		job.Setenv("Address", fmt.Sprintf("%s/%d", n.Gateway, ones))
+	}
+	env, err := job.Stdout.AddEnv()
+	if err != nil {
+		return err
+	}
+	if err := job.Run(); err != nil {
+		return err
+	}
+	n.Subnet = env.Get("Subnet")
+	n.Gateway = env.Get("Gateway")
+	if env.GetBool("Created") {
+		n.BridgeCreated = true
+	}
+	daemon.startResolver(n)
+	return nil
+}
+
+// createLanNetwork sets up n, a network of the macvlan or ipvlan driver: its
+// parent, if it's a VLAN sub-interface the host doesn't have, and the
+// addresses of its containers.
+func (daemon *Daemon) createLanNetwork(n *Network) error {
+	subnet := n.subnet()
+	if subnet == nil {
+		return fmt.Errorf("Network %s has no subnet", n.Name)
+	}
+	created, err := daemon.lan.CreateNetwork(n.ID, n.parent(), subnet, net.ParseIP(n.Gateway))
+	if err != nil {
+		return err
+	}
+	if created {
+		n.ParentCreated = true
+	}
+	return nil
+}
+
+// lanMacAddress returns the MAC address of the macvlan interface at ip of
+// the container named name, generated as the bridge driver does, after
+// --mac-address-prefix and --mac-address-mode.
+func (daemon *Daemon) lanMacAddress(ip net.IP, name string) string {
+	prefix := net.HardwareAddr{0x02, 0x42}
+	if mac, err := net.ParseMAC(daemon.config.MacAddressPrefix + ":00:00:00:00"); err == nil {
+		prefix = mac[:2]
+	}
+	if daemon.config.MacAddressMode == "name" {
+		return networkdriver.GenerateMacAddrFromName(prefix, name).String()
+	}
+	return networkdriver.GenerateMacAddr(prefix, ip).String()
+}
+
+// deleteLanNetwork releases n, a network of the macvlan or ipvlan driver,
+// and deletes its parent if it was created for it and no other network
+// uses it.
+func (daemon *Daemon) deleteLanNetwork(n *Network) error {
+	deleteParent := n.ParentCreated
+	for _, other := range daemon.networks.List() {
+		if other.ID != n.ID && other.onLan() && other.parent() == n.parent() {
+			deleteParent = false
+		}
+	}
+	return daemon.lan.DeleteNetwork(n.ID, n.parent(), deleteParent)
+}
+
+// NetworkCreate creates a network: one with a bridge of its own on the host,
+// an overlay network shared by the daemons of the cluster, or one on the LAN
+// of an interface of the host.
+func (daemon *Daemon) NetworkCreate(job *engine.Job) error {
+	var (
+		name       = job.Getenv("Name")
+		driver     = job.Getenv("Driver")
+		ipamDriver = job.Getenv("IPAMDriver")
+		subnet     = job.Getenv("Subnet")
+		gateway    = job.Getenv("Gateway")
+		internal   = job.GetenvBool("Internal")
+		options    map[string]string
+	)
+	if driver == "" {
+		driver = "bridge"
+	}
+	if ipamDriver == ipam.DefaultDriver {
+		ipamDriver = ""
+	}
+	if err := job.GetenvJson("Options", &options); err != nil {
+		return err
+	}
+	if !validNetworkNamePattern.MatchString(name) {
+		return fmt.Errorf("Invalid network name (%s), only %s are allowed", name, validContainerNameChars)
+	}
+	if driver != "bridge" && driver != "overlay" && !macvlan.IsDriver(driver) {
+		return fmt.Errorf("Unknown network driver: %s", driver)
+	}
+	if daemon.config.DisableNetwork {
+		return fmt.Errorf("Cannot create network %s: networking is disabled", name)
+	}
+	if internal {
+		if err := daemon.checkInternal(driver, options); err != nil {
+			return err
+		}
+	}
+	if driver == "overlay" {
+		if ipamDriver != "" {
+			return fmt.Errorf("The overlay driver allocates the addresses of its networks across the cluster, it doesn't support IPAM drivers")
+		}
+		if subnet != "" || gateway != "" {
+			return fmt.Errorf("The overlay driver picks the subnets of its networks")
+		}
+		if err := checkOverlayOptions(options); err != nil {
+			return err
+		}
+		return daemon.createOverlayNetwork(job, name, options)
+	}
+
+	iface := options[bridgeNameOption]
+	if macvlan.IsDriver(driver) {
+		if ipamDriver != "" {
+			return fmt.Errorf("The %s driver doesn't support IPAM drivers", driver)
+		}
+		if subnet == "" {
+			return fmt.Errorf("The %s driver requires the subnet of the LAN, see --subnet", driver)
+		}
+		if err := checkLanOptions(driver, options); err != nil {
+			return err
+		}
+		iface = options[macvlan.ParentOption]
+	} else if err := daemon.checkBridgeOptions(options); err != nil {
+		return err
+	}
+	if subnet != "" {
+		var err error
+		if subnet, gateway, err = daemon.checkSubnet(subnet, gateway, iface); err != nil {
+			return err
+		}
+	} else if gateway != "" {
+		return fmt.Errorf("The gateway of a network requires its subnet")
+	}
+
+	n, err := daemon.networks.add(name, driver)
+	if err != nil {
+		return err
+	}
+	n.IPAMDriver = ipamDriver
+	n.Subnet = subnet
+	n.Gateway = gateway
+	n.Internal = internal
+	for k, v := range options {
+		n.Options[k] = v
+	}
+	if n.onLan() {
+		if mode := macvlan.ModeOption(driver); n.Options[mode] == "" {
+			n.Options[mode] = macvlan.Modes[driver][0]
+		}
+		if err := daemon.createLanNetwork(n); err != nil {
+			daemon.networks.remove(n)
+			return err
+		}
+		if err := daemon.networks.save(n); err != nil {
+			daemon.deleteLanNetwork(n)
+			daemon.networks.remove(n)
+			return err
+		}
+		return json.NewEncoder(job.Stdout).Encode(&types.NetworkCreateResponse{ID: n.ID})
+	}
+
+	if n.Options[bridgeNameOption] == "" {
+		n.Options[bridgeNameOption] = "br-" + stringid.TruncateID(n.ID)
+	}
+	if err := daemon.createBridge(n); err != nil {
+		daemon.networks.remove(n)
+		return err
+	}
+	if err := daemon.networks.save(n); err != nil {
+		daemon.deleteBridge(n)
+		daemon.networks.remove(n)
+		return err
+	}
+
+	return json.NewEncoder(job.Stdout).Encode(&types.NetworkCreateResponse{ID: n.ID})
+}
+
+// checkBridgeOptions checks the options of a new network of the bridge
+// driver.
+func (daemon *Daemon) checkBridgeOptions(options map[string]string) error {
+	for k, v := range options {
+		switch k {
+		case bridgeNameOption:
+			if v == "" || len(v) > 15 || strings.ContainsAny(v, "/ \t\n:") {
+				return fmt.Errorf("Invalid bridge name: %q", v)
+			}
+			for _, n := range daemon.networks.List() {
+				if n.bridge() == v {
+					return fmt.Errorf("Conflict. The bridge %s is already used by network %s.", v, n.Name)
+				}
+			}
+		case bridgeICCOption, bridgeIPMasqOption:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return fmt.Errorf("Invalid value for %s: %q, it must be a boolean", k, v)
+			}
+		case bridgeIptablesOption:
+			enabled, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("Invalid value for %s: %q, it must be a boolean", k, v)
+			}
+			if enabled && !daemon.config.EnableIptables {
+				return fmt.Errorf("Unable to enable iptables on the network, they are disabled on the daemon")
+			}
+		case mtuOption:
+			if err := checkMtu(v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unknown option of the bridge driver: %s", k)
+		}
+	}
+	return nil
+}
+
+// checkInternal checks that a new network of driver with options can be
+// internal: its bridge is isolated by the iptables rules of the daemon, and
+// not masqueraded.
+func (daemon *Daemon) checkInternal(driver string, options map[string]string) error {
+	if driver != "bridge" {
+		return fmt.Errorf("Only the networks of the bridge driver can be internal")
+	}
+	if !daemon.config.EnableIptables {
+		return fmt.Errorf("An internal network requires iptables, they are disabled on the daemon")
+	}
+	if enabled, err := strconv.ParseBool(options[bridgeIptablesOption]); err == nil && !enabled {
+		return fmt.Errorf("An internal network requires iptables, %s can't be false", bridgeIptablesOption)
+	}
+	if ipMasq, _ := strconv.ParseBool(options[bridgeIPMasqOption]); ipMasq {
+		return fmt.Errorf("The traffic of an internal network can't be masqueraded, %s can't be true", bridgeIPMasqOption)
+	}
+	return nil
+}
+
+// checkOverlayOptions checks the options of a new network of the overlay
+// driver.
+func checkOverlayOptions(options map[string]string) error {
+	for k, v := range options {
+		if k != mtuOption {
+			return fmt.Errorf("Unknown option of the overlay driver: %s", k)
+		}
+		if err := checkMtu(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLanOptions checks the options of a new network of driver, the macvlan
+// or ipvlan driver, which requires a parent.
+func checkLanOptions(driver string, options map[string]string) error {
+	for k, v := range options {
+		switch k {
+		case macvlan.ParentOption:
+			if v == "" || len(v) > 15 || strings.ContainsAny(v, "/ \t\n:") {
+				return fmt.Errorf("Invalid parent interface: %q", v)
+			}
+		case macvlan.ModeOption(driver):
+			valid := false
+			for _, mode := range macvlan.Modes[driver] {
+				valid = valid || v == mode
+			}
+			if !valid {
+				return fmt.Errorf("Invalid value for %s: %q, it must be one of %s", k, v, strings.Join(macvlan.Modes[driver], ", "))
+			}
+		case mtuOption:
+			if err := checkMtu(v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Unknown option of the %s driver: %s", driver, k)
+		}
+	}
+	if options[macvlan.ParentOption] == "" {
+		return fmt.Errorf("The %s driver requires the %s option, the interface of the host whose LAN the network is on", driver, macvlan.ParentOption)
+	}
+	return nil
+}
+
+// checkMtu checks the value of mtuOption.
+func checkMtu(v string) error {
+	if mtu, err := strconv.Atoi(v); err != nil || mtu < 68 || mtu > 65535 {
+		return fmt.Errorf("Invalid value for %s: %q, it must be an MTU between 68 and 65535", mtuOption, v)
+	}
+	return nil
+}
+
+// checkSubnet checks subnet, the subnet of a new network whose bridge, or
+// parent, is named iface, and gateway, the address of its bridge, or its
+// router, in the subnet. It returns them normalized, gateway being the first
+// address of the subnet if it's empty.
+func (daemon *Daemon) checkSubnet(subnet, gateway, iface string) (string, string, error) {
+	_, ipNet, err := net.ParseCIDR(subnet)
+	if err != nil || ipNet.IP.To4() == nil {
+		return "", "", fmt.Errorf("Invalid subnet %s, it must be an IPv4 subnet in CIDR notation", subnet)
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits-ones < 2 {
+		return "", "", fmt.Errorf("Subnet %s is too small for containers", subnet)
+	}
+	first, last := networkdriver.NetworkRange(ipNet)
+
+	var ip net.IP
+	if gateway == "" {
+		ip = make(net.IP, len(first))
+		copy(ip, first)
+		ip[len(ip)-1]++
+	} else if ip = net.ParseIP(gateway).To4(); ip == nil || !ipNet.Contains(ip) || ip.Equal(first) || ip.Equal(last) {
+		return "", "", fmt.Errorf("Invalid gateway %s, it must be a host address of subnet %s", gateway, ipNet)
+	}
+
+	for _, n := range daemon.networks.List() {
+		if other := n.subnet(); other != nil && networkdriver.NetworkOverlaps(ipNet, other) {
+			return "", "", fmt.Errorf("Subnet %s overlaps with the subnet %s of network %s", ipNet, other, n.Name)
+		}
+	}
+	// An existing bridge is expected to have the address already, and an
+	// existing parent to be on the subnet.
+	if _, err := net.InterfaceByName(iface); iface == "" || err != nil {
+		if err := networkdriver.CheckRouteOverlaps(ipNet); err != nil {
+			return "", "", fmt.Errorf("Subnet %s overlaps with the routes of the host", ipNet)
+		}
+	}
+	return ipNet.String(), ip.String(), nil
+}
+
+// createOverlayNetwork creates a network of the overlay driver in the
+// cluster store, where the other daemons of the cluster find it.
+func (daemon *Daemon) createOverlayNetwork(job *engine.Job, name string, options map[string]string) error {
+	if daemon.clusterStore == nil {
+		return fmt.Errorf("Cannot create network %s: the overlay driver requires a cluster store, see --cluster-store", name)
+	}
+	if err := daemon.syncClusterNetworks(); err != nil {
+		return err
+	}
+	daemon.networks.Lock()
+	err := daemon.networks.checkName(name)
+	daemon.networks.Unlock()
+	if err != nil {
+		return err
+	}
+
+	n := &Network{
+		ID:      stringid.GenerateRandomID(),
+		Name:    name,
+		Driver:  "overlay",
+		Options: make(map[string]string),
+	}
+	for k, v := range options {
+		n.Options[k] = v
+	}
+	// Another daemon may be creating a network of the same name.
+	nameKey := path.Join(clusterNetworkNamesKey, name)
+	if _, err := daemon.clusterStore.AtomicPut(nameKey, []byte(n.ID), nil, nil); err == kvstore.ErrKeyModified {
+		return fmt.Errorf("Conflict. The network name %s is already in use.", name)
+	} else if err != nil {
+		return err
+	}
+
+	subnet, vni, err := daemon.overlay.AllocateNetwork(n.ID)
+	if err != nil {
+		daemon.clusterStore.Delete(nameKey)
+		return err
+	}
+	n.Subnet = subnet.String()
+	n.Options[vxlanIDOption] = strconv.FormatUint(uint64(vni), 10)
+
+	data, err := json.Marshal(n)
+	if err == nil {
+		_, err = daemon.clusterStore.AtomicPut(path.Join(clusterNetworksKey, n.ID), data, nil, nil)
+	}
+	if err != nil {
+		daemon.overlay.ReleaseNetwork(n.ID, subnet, vni)
+		daemon.clusterStore.Delete(nameKey)
+		return err
+	}
+	if err := daemon.syncClusterNetworks(); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(job.Stdout).Encode(&types.NetworkCreateResponse{ID: n.ID})
+}
+
+// removeOverlayNetwork removes n, a network of the overlay driver, from the
+// cluster store, unless a container of another daemon is on it.
+func (daemon *Daemon) removeOverlayNetwork(n *Network) error {
+	endpoints, err := daemon.overlay.Endpoints(n.ID)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) > 0 {
+		return fmt.Errorf("Conflict. Network %s is in use by container %s.", n.Name, stringid.TruncateID(endpoints[0].ContainerID))
+	}
+	if err := daemon.clusterStore.Delete(path.Join(clusterNetworksKey, n.ID)); err != nil {
+		return err
+	}
+	if err := daemon.clusterStore.Delete(path.Join(clusterNetworkNamesKey, n.Name)); err != nil {
+		return err
+	}
+	if subnet := n.subnet(); subnet != nil {
+		return daemon.overlay.ReleaseNetwork(n.ID, subnet, n.vxlanID())
+	}
+	return nil
+}
+
+// updateOverlayHosts rewrites the hosts files of the containers of the
+// host on a network of the overlay driver, whose endpoints changed.
+func (daemon *Daemon) updateOverlayHosts(networkID string) {
+	n, err := daemon.networks.Get(networkID)
+	if err != nil {
+		return
+	}
+	for _, container := range daemon.List() {
+		container.Lock()
+		endpoint := container.NetworkSettings.Networks[n.Name]
+		if container.Running && endpoint != nil && endpoint.IPAddress != "" {
+			if err := container.buildHostsFiles(container.NetworkSettings.IPAddress); err != nil {
+				logrus.Errorf("Unable to update the hosts file of %s: %v", container.ID, err)
+			}
+		}
+		container.Unlock()
+	}
+}
+
+func (daemon *Daemon) deleteBridge(n *Network) error {
+	daemon.stopResolver(n)
+	job := daemon.eng.Job("delete_network")
+	job.Setenv("Bridge", n.bridge())
+	job.SetenvBool("DeleteBridge", n.BridgeCreated)
+	return job.Run()
+}
+
+// Networks lists the networks of the daemon.
+func (daemon *Daemon) Networks(job *engine.Job) error {
+	networks := []*types.NetworkResource{}
+	for _, n := range daemon.networks.List() {
+		networks = append(networks, daemon.networkResource(n))
+	}
+	return json.NewEncoder(job.Stdout).Encode(networks)
+}
+
+// NetworkInspect returns the settings of a network, with the containers
+// connected to it.
+func (daemon *Daemon) NetworkInspect(job *engine.Job) error {
+	if len(job.Args) != 1 {
+		return fmt.Errorf("Usage: %s NETWORK", job.Name)
+	}
+	n, err := daemon.networks.Get(job.Args[0])
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(job.Stdout).Encode(daemon.networkResource(n))
+}
+
+// networkResource returns the settings of n: the ranges its addresses are
+// allocated from, the options of its driver, the defaults of the daemon
+// included, and the containers attached to it. Those that aren't running
+// have no addresses, and the ones of the other hosts of an overlay network
+// the address of their host.
+func (daemon *Daemon) networkResource(n *Network) *types.NetworkResource {
+	r := &types.NetworkResource{
+		Name:       n.Name,
+		ID:         n.ID,
+		Driver:     n.Driver,
+		Internal:   n.Internal,
+		IPAM:       types.IPAM{Driver: ipam.DefaultDriver, Config: []types.IPAMConfig{}},
+		Containers: make(map[string]types.EndpointResource),
+		Options:    daemon.networkOptions(n),
+	}
+	if n.IPAMDriver != "" {
+		r.IPAM.Driver = n.IPAMDriver
+	}
+	if n.Subnet != "" {
+		config := types.IPAMConfig{Subnet: n.Subnet, Gateway: n.Gateway}
+		if n.Name == "bridge" {
+			// The containers of the default bridge are given the
+			// addresses of --fixed-cidr only
+			config.IPRange = daemon.config.FixedCIDR
+		}
+		r.IPAM.Config = append(r.IPAM.Config, config)
+	}
+	if n.Name == "bridge" && daemon.config.FixedCIDRv6 != "" {
+		r.IPAM.Config = append(r.IPAM.Config, types.IPAMConfig{Subnet: daemon.config.FixedCIDRv6})
+	}
+	for _, container := range daemon.List() {
+		container.Lock()
+		endpoint, connected := container.NetworkSettings.Networks[n.Name]
+		if !connected && container.hostConfig.NetworkMode.NetworkName() != n.Name {
+			container.Unlock()
+			continue
+		}
+		e := types.EndpointResource{Name: strings.TrimPrefix(container.Name, "/")}
+		if endpoint != nil && endpoint.IPAddress != "" {
+			e.MacAddress = endpoint.MacAddress
+			e.IPv4Address = fmt.Sprintf("%s/%d", endpoint.IPAddress, endpoint.IPPrefixLen)
+			if endpoint.GlobalIPv6Address != "" {
+				e.IPv6Address = fmt.Sprintf("%s/%d", endpoint.GlobalIPv6Address, endpoint.GlobalIPv6PrefixLen)
+			}
+		}
+		container.Unlock()
+		r.Containers[container.ID] = e
+	}
+	if n.Driver == "overlay" && daemon.overlay != nil {
+		endpoints, err := daemon.overlay.Endpoints(n.ID)
+		if err != nil {
+			logrus.Errorf("Unable to list the endpoints of network %s: %v", n.Name, err)
+		}
+		for _, endpoint := range endpoints {
+			if _, local := r.Containers[endpoint.ContainerID]; local {
+				continue
+			}
+			e := types.EndpointResource{
+				Name:       endpoint.Name,
+				MacAddress: endpoint.MacAddress,
+				Host:       endpoint.Host,
+			}
+			if subnet := n.subnet(); subnet != nil {
+				ones, _ := subnet.Mask.Size()
+				e.IPv4Address = fmt.Sprintf("%s/%d", endpoint.IP, ones)
+			}
+			r.Containers[endpoint.ContainerID] = e
+		}
+	}
+	return r
+}
+
+// networkOptions returns the options of n, with the values the daemon
+// gives the ones that weren't set.
+func (daemon *Daemon) networkOptions(n *Network) map[string]string {
+	options := make(map[string]string)
+	switch {
+	case n.Driver == "bridge":
+		options[bridgeICCOption] = strconv.FormatBool(daemon.config.InterContainerCommunication)
+		options[bridgeIPMasqOption] = strconv.FormatBool(daemon.config.EnableIpMasq && !n.Internal)
+		options[bridgeIptablesOption] = strconv.FormatBool(daemon.config.EnableIptables)
+		options[mtuOption] = strconv.Itoa(daemon.config.Mtu)
+	case n.Driver == "overlay":
+		options[mtuOption] = strconv.Itoa(daemon.config.Mtu - overlay.Overhead)
+	case n.onLan():
+		if iface, err := net.InterfaceByName(n.parent()); err == nil {
+			options[mtuOption] = strconv.Itoa(iface.MTU)
+		}
+	}
+	for k, v := range n.Options {
+		options[k] = v
+	}
+	return options
+}
+
+// NetworkRm removes a network no container uses, and its bridge.
+func (daemon *Daemon) NetworkRm(job *engine.Job) error {
+	if len(job.Args) != 1 {
+		return fmt.Errorf("Usage: %s NETWORK", job.Name)
+	}
+	n, err := daemon.networks.Get(job.Args[0])
+	if err != nil {
+		return err
+	}
+	if n.predefined() {
+		return fmt.Errorf("%s is a pre-defined network and cannot be removed", n.Name)
+	}
+	for _, container := range daemon.List() {
+		_, connected := container.NetworkSettings.Networks[n.Name]
+		if connected || container.hostConfig.NetworkMode.NetworkName() == n.Name {
+			return fmt.Errorf("Conflict. Network %s is in use by container %s.", n.Name, stringid.TruncateID(container.ID))
+		}
+	}
+	switch {
+	case n.Driver == "overlay":
+		if err := daemon.removeOverlayNetwork(n); err != nil {
+			return err
+		}
+	case daemon.config.DisableNetwork:
+	case n.onLan():
+		if err := daemon.deleteLanNetwork(n); err != nil {
+			return err
+		}
+	default:
+		if err := daemon.deleteBridge(n); err != nil {
+			return err
+		}
+	}
+	return daemon.networks.remove(n)
+}
+
+// NetworkConnect connects a container to a network, in addition to the
+// network of its network mode.
+func (daemon *Daemon) NetworkConnect(job *engine.Job) error {
+	n, container, err := daemon.networkAndContainer(job)
+	if err != nil {
+		return err
+	}
+	return container.ConnectToNetwork(n, &EndpointIPAMConfig{
+		IPv4Address: job.Getenv("IPv4Address"),
+		IPv6Address: job.Getenv("IPv6Address"),
+	}, job.GetenvList("Aliases"))
+}
+
+// NetworkDisconnect disconnects a container from a network it was connected
+// to with NetworkConnect.
+func (daemon *Daemon) NetworkDisconnect(job *engine.Job) error {
+	n, container, err := daemon.networkAndContainer(job)
+	if err != nil {
+		return err
+	}
+	return container.DisconnectFromNetwork(n)
+}
+
+func (daemon *Daemon) networkAndContainer(job *engine.Job) (*Network, *Container, error) {
+	if len(job.Args) != 2 {
+		return nil, nil, fmt.Errorf("Usage: %s NETWORK CONTAINER", job.Name)
+	}
+	n, err := daemon.networks.Get(job.Args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	container, err := daemon.Get(job.Args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return n, container, nil
+}
+
+// verifyNetworkMode checks that the network of a user-defined network mode
+// exists, and names it by its name rather than its ID.
+func (daemon *Daemon) verifyNetworkMode(hostConfig *runconfig.HostConfig) error {
+	if hostConfig == nil || !hostConfig.NetworkMode.IsUserDefined() {
+		return nil
+	}
+	n, err := daemon.networks.Get(hostConfig.NetworkMode.NetworkName())
+	if err != nil {
+		return err
+	}
+	if n.Driver != "bridge" && n.Driver != "overlay" && !n.onLan() {
+		return fmt.Errorf("Use --net=%s to run a container on the %s network", n.Name, n.Name)
+	}
+	if len(hostConfig.Links) > 0 {
+		return runconfig.ErrConflictNetworkAndLinks
+	}
+	if n.onLan() && (len(hostConfig.PortBindings) > 0 || hostConfig.PublishAllPorts) {
+		return fmt.Errorf("Ports can't be published on network %s, its containers are reachable on the LAN directly", n.Name)
+	}
+	if n.Internal && (len(hostConfig.PortBindings) > 0 || hostConfig.PublishAllPorts) {
+		return fmt.Errorf("Ports can't be published on network %s, it is internal", n.Name)
+	}
+	hostConfig.NetworkMode = runconfig.NetworkMode(n.Name)
+	return nil
+}
+
+// verifyIPAMConfig checks the addresses config asks for on the network of
+// the network mode of hostConfig, which verifyNetworkMode checked.
+func (daemon *Daemon) verifyIPAMConfig(config *runconfig.Config, hostConfig *runconfig.HostConfig) error {
+	if config.IPv4Address == "" && config.IPv6Address == "" {
+		return nil
+	}
+	if hostConfig == nil || !hostConfig.NetworkMode.IsUserDefined() {
+		return fmt.Errorf("User specified IP addresses are only supported on user-defined networks")
+	}
+	n, err := daemon.networks.Get(hostConfig.NetworkMode.NetworkName())
+	if err != nil {
+		return err
+	}
+	return checkIPAMConfig(n, &EndpointIPAMConfig{IPv4Address: config.IPv4Address, IPv6Address: config.IPv6Address})
+}
+
+// verifyNetworkAliases checks the aliases config asks for on the network of
+// the network mode of hostConfig.
+func (daemon *Daemon) verifyNetworkAliases(config *runconfig.Config, hostConfig *runconfig.HostConfig) error {
+	if len(config.NetworkAliases) == 0 {
+		return nil
+	}
+	if hostConfig == nil || !hostConfig.NetworkMode.IsUserDefined() {
+		return fmt.Errorf("Network-scoped aliases are only supported on user-defined networks")
+	}
+	n, err := daemon.networks.Get(hostConfig.NetworkMode.NetworkName())
+	if err != nil {
+		return err
+	}
+	return checkNetworkAliases(n, config.NetworkAliases)
+}
+
+// verifyBandwidth checks the bandwidth limits of hostConfig, whose network
+// mode verifyNetworkMode checked.
+func (daemon *Daemon) verifyBandwidth(hostConfig *runconfig.HostConfig) error {
+	if hostConfig == nil || hostConfig.NetEgressBps == 0 && hostConfig.NetIngressBps == 0 {
+		return nil
+	}
+	if hostConfig.NetEgressBps < 0 || hostConfig.NetIngressBps < 0 {
+		return fmt.Errorf("Invalid bandwidth limit: the rate must be a positive number of bytes per second")
+	}
+	if !hostConfig.NetworkMode.IsPrivate() {
+		return fmt.Errorf("The bandwidth of a container can only be limited on a network stack of its own, not with --net=%s", hostConfig.NetworkMode)
+	}
+	if n, err := daemon.networks.Get(hostConfig.NetworkMode.NetworkName()); err == nil {
+		if err := checkIngressBandwidth(n, hostConfig); err != nil {
+			return err
+		}
+	}
+	if _, err := exec.LookPath("tc"); err != nil {
+		return fmt.Errorf("Limiting the bandwidth of containers requires tc, of iproute2, on the host")
+	}
+	return nil
+}
+
+// checkIngressBandwidth checks that the traffic a container of hostConfig
+// receives on n can be limited: the interfaces of the macvlan and ipvlan
+// networks have no peer on the host to shape it on.
+func checkIngressBandwidth(n *Network, hostConfig *runconfig.HostConfig) error {
+	if hostConfig.NetIngressBps != 0 && n.onLan() {
+		return fmt.Errorf("The traffic the containers of network %s receive can't be limited, see --net-ingress-bps", n.Name)
+	}
+	return nil
+}
+
+// checkNetworkAliases checks the aliases of a container on n.
+func checkNetworkAliases(n *Network, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	if n.predefined() {
+		return fmt.Errorf("Network-scoped aliases are only supported on user-defined networks")
+	}
+	for _, alias := range aliases {
+		if !validNetworkNamePattern.MatchString(alias) {
+			return fmt.Errorf("Invalid network alias (%s), only %s are allowed", alias, validContainerNameChars)
+		}
+	}
+	return nil
+}
+
+// checkIPAMConfig checks the addresses config asks for on n.
+func checkIPAMConfig(n *Network, config *EndpointIPAMConfig) error {
+	if *config == (EndpointIPAMConfig{}) {
+		return nil
+	}
+	if n.predefined() {
+		return fmt.Errorf("User specified IP addresses are only supported on user-defined networks")
+	}
+	if config.IPv4Address != "" {
+		ip := net.ParseIP(config.IPv4Address)
+		if ip == nil || ip.To4() == nil {
+			return fmt.Errorf("%s is not a valid IPv4 address", config.IPv4Address)
+		}
+		if subnet := n.subnet(); subnet != nil && !subnet.Contains(ip) {
+			return fmt.Errorf("Address %s is not in the subnet %s of network %s", ip, subnet, n.Name)
+		}
+	}
+	if config.IPv6Address != "" {
+		if ip := net.ParseIP(config.IPv6Address); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("%s is not a valid IPv6 address", config.IPv6Address)
+		}
+		if n.Driver == "overlay" || n.onLan() {
+			return fmt.Errorf("Network %s has no IPv6 subnet", n.Name)
+		}
+	}
+	return nil
+}
@@ -20,4 +20,38 @@ type NetworkSettings struct {
 	Bridge                 string
 	PortMapping            map[string]PortMapping // Deprecated
 	Ports                  nat.PortMap
+
+	// Networks are the endpoints of the container on its networks, by the
+	// name of the network: the network of its network mode and the ones it
+	// was connected to with `docker network connect`. The settings of an
+	// endpoint are empty while the container isn't running.
+	Networks map[string]*EndpointSettings
+}
+
+// EndpointSettings are the settings of the interface of a container on a
+// network.
+type EndpointSettings struct {
+	NetworkID           string
+	IPAddress           string
+	IPPrefixLen         int
+	MacAddress          string
+	Gateway             string
+	GlobalIPv6Address   string
+	GlobalIPv6PrefixLen int
+	IPv6Gateway         string
+
+	// IPAMConfig holds the addresses the container asked for on the
+	// network, which it gets every time it starts.
+	IPAMConfig *EndpointIPAMConfig `json:",omitempty"`
+
+	// Aliases are the names of the container on the network, in addition
+	// to its own, which the other containers of the network resolve.
+	Aliases []string `json:",omitempty"`
+}
+
+// EndpointIPAMConfig are the addresses a container asked for on a network,
+// empty for any free one.
+type EndpointIPAMConfig struct {
+	IPv4Address string `json:",omitempty"`
+	IPv6Address string `json:",omitempty"`
 }
@@ -0,0 +1,387 @@
+package daemon
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/nat"
+	"github.com/docker/docker/runconfig"
+)
+
+func TestNetworkStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker-networks-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	s, err := newNetworkStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.add("foo", "bridge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Subnet = "10.0.0.0/16"
+	if err := s.save(n); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.add("foo", "bridge"); err == nil {
+		t.Fatal("Expected an error adding a second network named foo")
+	}
+
+	// The networks are restored from disk
+	if s, err = newNetworkStore(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, nameOrID := range []string{"foo", n.ID, n.ID[:12]} {
+		found, err := s.Get(nameOrID)
+		if err != nil {
+			t.Fatalf("Expected to find network foo by %s: %s", nameOrID, err)
+		}
+		if found.ID != n.ID || found.Subnet != "10.0.0.0/16" {
+			t.Fatalf("Expected network %+v, got %+v", n, found)
+		}
+	}
+	if _, err := s.Get("bar"); err == nil {
+		t.Fatal("Expected an error getting a network that doesn't exist")
+	}
+
+	if err := s.remove(n); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = newNetworkStore(root); err != nil {
+		t.Fatal(err)
+	}
+	if networks := s.List(); len(networks) != 0 {
+		t.Fatalf("Expected no network left, got %v", networks)
+	}
+}
+
+func TestVerifyNetworkMode(t *testing.T) {
+	daemon := &Daemon{networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "foo", Driver: "bridge"},
+		"ba9876543210": {ID: "ba9876543210", Name: "host", Driver: "host"},
+		"0a1b2c3d4e5f": {ID: "0a1b2c3d4e5f", Name: "lan", Driver: "macvlan"},
+		"f5e4d3c2b1a0": {ID: "f5e4d3c2b1a0", Name: "backend", Driver: "bridge", Internal: true},
+	}}}
+
+	hostConfig := &runconfig.HostConfig{NetworkMode: "0123"}
+	if err := daemon.verifyNetworkMode(hostConfig); err != nil {
+		t.Fatal(err)
+	}
+	if hostConfig.NetworkMode != "foo" {
+		t.Fatalf("Expected the network mode to be named foo, got %s", hostConfig.NetworkMode)
+	}
+
+	for _, hostConfig := range []*runconfig.HostConfig{
+		{NetworkMode: "bar"},
+		{NetworkMode: "ba98"},
+		{NetworkMode: "foo", Links: []string{"zip:zap"}},
+		{NetworkMode: "lan", PublishAllPorts: true},
+		{NetworkMode: "lan", PortBindings: nat.PortMap{"80/tcp": {{HostPort: "8080"}}}},
+		{NetworkMode: "backend", PublishAllPorts: true},
+		{NetworkMode: "backend", PortBindings: nat.PortMap{"5432/tcp": {{HostPort: "5432"}}}},
+	} {
+		if err := daemon.verifyNetworkMode(hostConfig); err == nil {
+			t.Fatalf("Expected an error for %+v", hostConfig)
+		}
+	}
+}
+
+func TestCheckBridgeOptions(t *testing.T) {
+	daemon := &Daemon{config: &Config{EnableIptables: true}, networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "foo", Driver: "bridge", Options: map[string]string{bridgeNameOption: "br0"}},
+	}}}
+
+	if err := daemon.checkBridgeOptions(map[string]string{
+		bridgeNameOption:     "tenant0",
+		bridgeICCOption:      "false",
+		bridgeIPMasqOption:   "true",
+		bridgeIptablesOption: "false",
+		mtuOption:            "1400",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, options := range []map[string]string{
+		{bridgeNameOption: "br0"},
+		{bridgeNameOption: "a-bridge-name-too-long"},
+		{bridgeICCOption: "maybe"},
+		{bridgeIptablesOption: "maybe"},
+		{mtuOption: "20"},
+		{"foo": "bar"},
+	} {
+		if err := daemon.checkBridgeOptions(options); err == nil {
+			t.Fatalf("Expected an error for %v", options)
+		}
+	}
+
+	// A network can't have iptables rules if the daemon doesn't.
+	daemon.config.EnableIptables = false
+	if err := daemon.checkBridgeOptions(map[string]string{bridgeIptablesOption: "true"}); err == nil {
+		t.Fatal("Expected an error enabling iptables on a network of a daemon without them")
+	}
+}
+
+func TestCheckInternal(t *testing.T) {
+	daemon := &Daemon{config: &Config{EnableIptables: true}}
+
+	for _, options := range []map[string]string{
+		{},
+		{bridgeICCOption: "false"},
+		{bridgeIPMasqOption: "false"},
+		{bridgeIptablesOption: "true"},
+	} {
+		if err := daemon.checkInternal("bridge", options); err != nil {
+			t.Fatalf("Unexpected error for %v: %v", options, err)
+		}
+	}
+	for _, options := range []map[string]string{
+		{bridgeIPMasqOption: "true"},
+		{bridgeIptablesOption: "false"},
+	} {
+		if err := daemon.checkInternal("bridge", options); err == nil {
+			t.Fatalf("Expected an error for %v", options)
+		}
+	}
+	for _, driver := range []string{"overlay", "macvlan", "ipvlan"} {
+		if err := daemon.checkInternal(driver, map[string]string{}); err == nil {
+			t.Fatalf("Expected an error for an internal network of the %s driver", driver)
+		}
+	}
+
+	// The daemon can't isolate a network without iptables.
+	daemon.config.EnableIptables = false
+	if err := daemon.checkInternal("bridge", map[string]string{}); err == nil {
+		t.Fatal("Expected an error for an internal network of a daemon without iptables")
+	}
+}
+
+func TestNetworkResource(t *testing.T) {
+	daemon := &Daemon{
+		config: &Config{InterContainerCommunication: true, Mtu: 1500},
+		containers: &contStore{s: map[string]*Container{
+			"web": {
+				ID: "web", State: NewState(), Name: "/web", NetworkSettings: &NetworkSettings{Networks: map[string]*EndpointSettings{
+					"foo": {IPAddress: "10.1.0.2", IPPrefixLen: 16, MacAddress: "02:42:0a:01:00:02"},
+				}},
+				hostConfig: &runconfig.HostConfig{NetworkMode: "bridge"},
+			},
+			"db": {
+				ID: "db", State: NewState(), Name: "/db", NetworkSettings: &NetworkSettings{},
+				hostConfig: &runconfig.HostConfig{NetworkMode: "foo"},
+			},
+			"cache": {
+				ID: "cache", State: NewState(), Name: "/cache", NetworkSettings: &NetworkSettings{},
+				hostConfig: &runconfig.HostConfig{NetworkMode: "bridge"},
+			},
+		}},
+	}
+	n := &Network{ID: "0123456789ab", Name: "foo", Driver: "bridge", Subnet: "10.1.0.0/16", Gateway: "10.1.0.1", Options: map[string]string{
+		bridgeNameOption: "br-0123456789ab",
+		mtuOption:        "1400",
+	}}
+
+	r := daemon.networkResource(n)
+	if len(r.IPAM.Config) != 1 || r.IPAM.Config[0].Subnet != n.Subnet || r.IPAM.Config[0].Gateway != n.Gateway {
+		t.Fatalf("Unexpected IPAM config %+v", r.IPAM.Config)
+	}
+	if len(r.Containers) != 2 {
+		t.Fatalf("Expected the 2 containers of the network, got %+v", r.Containers)
+	}
+	if e := r.Containers["web"]; e.Name != "web" || e.IPv4Address != "10.1.0.2/16" || e.MacAddress != "02:42:0a:01:00:02" {
+		t.Fatalf("Unexpected endpoint of the connected container %+v", e)
+	}
+	if e, exists := r.Containers["db"]; !exists || e.IPv4Address != "" {
+		t.Fatalf("Expected the stopped container of the network without address, got %+v", e)
+	}
+	for k, v := range map[string]string{
+		bridgeNameOption:     "br-0123456789ab",
+		bridgeICCOption:      "true",
+		bridgeIPMasqOption:   "false",
+		bridgeIptablesOption: "false",
+		mtuOption:            "1400",
+	} {
+		if r.Options[k] != v {
+			t.Fatalf("Expected %s to be %q, got %q", k, v, r.Options[k])
+		}
+	}
+	if n.Options[bridgeICCOption] != "" {
+		t.Fatal("The defaults of the options were stored in the network")
+	}
+
+	// The containers of the default bridge get the addresses of
+	// --fixed-cidr, and of --fixed-cidr-v6.
+	daemon.config.FixedCIDR = "172.17.1.0/24"
+	daemon.config.FixedCIDRv6 = "2001:db8::/64"
+	r = daemon.networkResource(&Network{ID: "bridge", Name: "bridge", Driver: "bridge", Subnet: "172.17.0.0/16", Gateway: "172.17.42.1"})
+	if len(r.IPAM.Config) != 2 || r.IPAM.Config[0].IPRange != "172.17.1.0/24" || r.IPAM.Config[1].Subnet != "2001:db8::/64" {
+		t.Fatalf("Unexpected IPAM config of the default bridge %+v", r.IPAM.Config)
+	}
+	if _, exists := r.Containers["cache"]; len(r.Containers) != 2 || !exists {
+		t.Fatalf("Expected the 2 containers of the default bridge, got %+v", r.Containers)
+	}
+}
+
+func TestCheckOverlayOptions(t *testing.T) {
+	if err := checkOverlayOptions(map[string]string{mtuOption: "8950"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, options := range []map[string]string{
+		{mtuOption: "mtu"},
+		{mtuOption: "65536"},
+		{bridgeNameOption: "br0"},
+	} {
+		if err := checkOverlayOptions(options); err == nil {
+			t.Fatalf("Expected an error for %v", options)
+		}
+	}
+}
+
+func TestCheckLanOptions(t *testing.T) {
+	if err := checkLanOptions("macvlan", map[string]string{"parent": "eth0.10", "macvlan_mode": "vepa", mtuOption: "1400"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkLanOptions("ipvlan", map[string]string{"parent": "eth0", "ipvlan_mode": "l3"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		driver  string
+		options map[string]string
+	}{
+		{"macvlan", map[string]string{}},
+		{"macvlan", map[string]string{"parent": "eth0", "macvlan_mode": "l2"}},
+		{"macvlan", map[string]string{"parent": "eth0", "ipvlan_mode": "l2"}},
+		{"ipvlan", map[string]string{"parent": "eth0", "ipvlan_mode": "bridge"}},
+		{"ipvlan", map[string]string{"parent": "an-interface-too-long"}},
+		{"ipvlan", map[string]string{"parent": "eth0", bridgeNameOption: "br0"}},
+	} {
+		if err := checkLanOptions(c.driver, c.options); err == nil {
+			t.Fatalf("Expected an error for %v of the %s driver", c.options, c.driver)
+		}
+	}
+}
+
+func TestCheckSubnet(t *testing.T) {
+	daemon := &Daemon{networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "foo", Driver: "bridge", Subnet: "172.28.0.0/16"},
+	}}}
+
+	// The routes of the host aren't checked for the subnet of an existing
+	// interface.
+	subnet, gateway, err := daemon.checkSubnet("10.9.1.2/16", "", "lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subnet != "10.9.0.0/16" || gateway != "10.9.0.1" {
+		t.Fatalf("Expected subnet 10.9.0.0/16 and gateway 10.9.0.1, got %s and %s", subnet, gateway)
+	}
+	if _, gateway, err = daemon.checkSubnet("10.9.0.0/16", "10.9.5.254", "lo"); err != nil || gateway != "10.9.5.254" {
+		t.Fatalf("Expected gateway 10.9.5.254, got %s: %v", gateway, err)
+	}
+
+	for _, c := range [][2]string{
+		{"172.28.1.0/24", ""},
+		{"10.9.0.0/16", "10.8.0.1"},
+		{"10.9.0.0/16", "10.9.255.255"},
+		{"10.9.0.0/31", ""},
+		{"fd00::/64", ""},
+		{"10.9.0.0", ""},
+	} {
+		if _, _, err := daemon.checkSubnet(c[0], c[1], "lo"); err == nil {
+			t.Fatalf("Expected an error for subnet %s and gateway %q", c[0], c[1])
+		}
+	}
+}
+
+func TestVerifyIPAMConfig(t *testing.T) {
+	daemon := &Daemon{networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "foo", Driver: "bridge", Subnet: "172.28.0.0/16"},
+		"ba9876543210": {ID: "ba9876543210", Name: "bar", Driver: "overlay", Subnet: "10.0.0.0/24"},
+		"0a1b2c3d4e5f": {ID: "0a1b2c3d4e5f", Name: "bridge", Driver: "bridge"},
+		"a0b1c2d3e4f5": {ID: "a0b1c2d3e4f5", Name: "lan", Driver: "ipvlan", Subnet: "192.168.1.0/24"},
+	}}}
+
+	for _, c := range []struct {
+		config     *runconfig.Config
+		hostConfig *runconfig.HostConfig
+	}{
+		{&runconfig.Config{IPv4Address: "192.168.1.20"}, &runconfig.HostConfig{NetworkMode: "lan"}},
+		{&runconfig.Config{}, &runconfig.HostConfig{NetworkMode: "bridge"}},
+		{&runconfig.Config{IPv4Address: "172.28.5.9"}, &runconfig.HostConfig{NetworkMode: "foo"}},
+		{&runconfig.Config{IPv6Address: "2001:db8::33"}, &runconfig.HostConfig{NetworkMode: "foo"}},
+		{&runconfig.Config{IPv4Address: "10.0.0.9"}, &runconfig.HostConfig{NetworkMode: "bar"}},
+	} {
+		if err := daemon.verifyIPAMConfig(c.config, c.hostConfig); err != nil {
+			t.Fatalf("Unexpected error for %+v: %v", c.config, err)
+		}
+	}
+
+	for _, c := range []struct {
+		config     *runconfig.Config
+		hostConfig *runconfig.HostConfig
+	}{
+		{&runconfig.Config{IPv4Address: "172.17.0.9"}, &runconfig.HostConfig{NetworkMode: "bridge"}},
+		{&runconfig.Config{IPv4Address: "172.17.0.9"}, &runconfig.HostConfig{NetworkMode: "host"}},
+		{&runconfig.Config{IPv4Address: "172.29.0.9"}, &runconfig.HostConfig{NetworkMode: "foo"}},
+		{&runconfig.Config{IPv4Address: "2001:db8::33"}, &runconfig.HostConfig{NetworkMode: "foo"}},
+		{&runconfig.Config{IPv6Address: "172.28.5.9"}, &runconfig.HostConfig{NetworkMode: "foo"}},
+		{&runconfig.Config{IPv6Address: "2001:db8::33"}, &runconfig.HostConfig{NetworkMode: "bar"}},
+		{&runconfig.Config{IPv6Address: "2001:db8::33"}, &runconfig.HostConfig{NetworkMode: "lan"}},
+		{&runconfig.Config{IPv4Address: "10.0.0.9"}, &runconfig.HostConfig{NetworkMode: "baz"}},
+	} {
+		if err := daemon.verifyIPAMConfig(c.config, c.hostConfig); err == nil {
+			t.Fatalf("Expected an error for %+v on %s", c.config, c.hostConfig.NetworkMode)
+		}
+	}
+}
+
+func TestVerifyNetworkAliases(t *testing.T) {
+	daemon := &Daemon{networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "foo", Driver: "bridge"},
+		"0a1b2c3d4e5f": {ID: "0a1b2c3d4e5f", Name: "bridge", Driver: "bridge"},
+	}}}
+
+	if err := daemon.verifyNetworkAliases(&runconfig.Config{NetworkAliases: []string{"db", "db.primary"}}, &runconfig.HostConfig{NetworkMode: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.verifyNetworkAliases(&runconfig.Config{}, &runconfig.HostConfig{NetworkMode: "bridge"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		alias string
+		mode  runconfig.NetworkMode
+	}{
+		{"db", "bridge"},
+		{"db", "host"},
+		{"db", "bar"},
+		{"-db", "foo"},
+		{"d b", "foo"},
+	} {
+		if err := daemon.verifyNetworkAliases(&runconfig.Config{NetworkAliases: []string{c.alias}}, &runconfig.HostConfig{NetworkMode: c.mode}); err == nil {
+			t.Fatalf("Expected an error for the alias %s on %s", c.alias, c.mode)
+		}
+	}
+}
+
+func TestVerifyBandwidth(t *testing.T) {
+	daemon := &Daemon{networks: &networkStore{networks: map[string]*Network{
+		"0123456789ab": {ID: "0123456789ab", Name: "lan", Driver: "macvlan", Options: map[string]string{"parent": "eth0"}},
+	}}}
+
+	if err := daemon.verifyBandwidth(&runconfig.HostConfig{NetworkMode: "host"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, hostConfig := range []*runconfig.HostConfig{
+		{NetworkMode: "bridge", NetEgressBps: -1},
+		{NetworkMode: "host", NetEgressBps: 1024},
+		{NetworkMode: "container:web", NetIngressBps: 1024},
+		{NetworkMode: "lan", NetIngressBps: 1024},
+	} {
+		if err := daemon.verifyBandwidth(hostConfig); err == nil {
+			t.Fatalf("Expected an error for %+v", hostConfig)
+		}
+	}
+}
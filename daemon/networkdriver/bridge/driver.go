@@ -17,6 +17,7 @@ import (
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/pkg/iptables"
+	"github.com/docker/docker/pkg/parsers"
 	"github.com/docker/docker/pkg/parsers/kernel"
 	"github.com/docker/docker/pkg/resolvconf"
 	"github.com/docker/libcontainer/netlink"
@@ -80,6 +81,18 @@ var (
 	portMapper        *portmapper.PortMapper
 	once              sync.Once
 
+	// The iptables settings of the daemon, which the networks created
+	// with `docker network create` get too.
+	iptablesEnabled             bool
+	chainPrefix                 = "DOCKER" // The name of the chain of the port mappings, and the prefix of the others
+	interContainerCommunication bool
+	ipMasquerade                bool
+
+	// The first 2 bytes of the generated MAC addresses, and whether their
+	// last 4 bytes come from the name of the container rather than its IP.
+	macPrefix   = net.HardwareAddr{0x02, 0x42}
+	macFromName bool
+
 	defaultBindingIP  = net.ParseIP("0.0.0.0")
 	currentInterfaces = ifaces{c: make(map[string]*networkInterface)}
 	ipAllocator       = ipallocator.New()
@@ -109,6 +122,29 @@ func InitDriver(job *engine.Job) error {
 	)
 	initPortMapper()
 
+	if prefix := job.Getenv("IptablesChainPrefix"); prefix != "" {
+		chainPrefix = prefix
+	}
+
+	if portRange := job.Getenv("PublishedPortRange"); portRange != "" {
+		start, end, err := parsers.ParsePortRange(portRange)
+		if err != nil {
+			return err
+		}
+		if err := portMapper.Allocator.SetRange(int(start), int(end)); err != nil {
+			return fmt.Errorf("Invalid published port range %s: %v", portRange, err)
+		}
+	}
+
+	if prefix := job.Getenv("MacAddressPrefix"); prefix != "" {
+		mac, err := net.ParseMAC(prefix + ":00:00:00:00")
+		if err != nil {
+			return fmt.Errorf("Invalid MAC address prefix %s: %v", prefix, err)
+		}
+		macPrefix = mac[:2]
+	}
+	macFromName = job.Getenv("MacAddressMode") == "name"
+
 	if defaultIP := job.Getenv("DefaultBindingIP"); defaultIP != "" {
 		defaultBindingIP = net.ParseIP(defaultIP)
 	}
@@ -130,7 +166,7 @@ func InitDriver(job *engine.Job) error {
 		}
 
 		// If the iface is not found, try to create it
-		if err := configureBridge(bridgeIP, bridgeIPv6, enableIPv6); err != nil {
+		if err := configureBridge(bridgeIface, bridgeIP, bridgeIPv6, enableIPv6); err != nil {
 			return err
 		}
 
@@ -207,7 +243,11 @@ func InitDriver(job *engine.Job) error {
 
 	// Configure iptables for link support
 	if enableIPTables {
-		if err := setupIPTables(addrv4, icc, ipMasq); err != nil {
+		if err := iptables.FirewalldInit(); err != nil {
+			logrus.Debugf("Unable to connect to firewalld: %v", err)
+		}
+		addToFirewalldZone(bridgeIface)
+		if err := setupIPTables(bridgeIface, addrv4, icc, ipMasq, false); err != nil {
 			return err
 		}
 
@@ -231,20 +271,14 @@ func InitDriver(job *engine.Job) error {
 	}
 
 	// We can always try removing the iptables
-	if err := iptables.RemoveExistingChain("DOCKER", iptables.Nat); err != nil {
+	if err := iptables.RemoveExistingChain(chainPrefix, iptables.Nat); err != nil {
 		return err
 	}
 
 	if enableIPTables {
-		_, err := iptables.NewChain("DOCKER", bridgeIface, iptables.Nat)
-		if err != nil {
+		if err := setupChains(enableIPv6); err != nil {
 			return err
 		}
-		chain, err := iptables.NewChain("DOCKER", bridgeIface, iptables.Filter)
-		if err != nil {
-			return err
-		}
-		portMapper.SetIptablesChain(chain)
 	}
 
 	bridgeIPv4Network = networkv4
@@ -277,23 +311,37 @@ func InitDriver(job *engine.Job) error {
 	// https://github.com/docker/docker/issues/2768
 	job.Eng.HackSetGlobalVar("httpapi.bridgeIP", bridgeIPv4Network.IP)
 
+	iptablesEnabled = enableIPTables
+	interContainerCommunication = icc
+	ipMasquerade = ipMasq
+	if enableIPTables {
+		iptables.OnReloaded(reloadIptables)
+	}
+
 	for name, f := range map[string]engine.Handler{
 		"allocate_interface": Allocate,
 		"release_interface":  Release,
 		"allocate_port":      AllocatePort,
 		"link":               LinkContainers,
+		"create_network":     CreateNetwork,
+		"delete_network":     DeleteNetwork,
 	} {
 		if err := job.Eng.Register(name, f); err != nil {
 			return err
 		}
 	}
-	return nil
+
+	return writeNetwork(job, bridgeIface, bridgeIPv4Network, false)
 }
 
-func setupIPTables(addr net.Addr, icc, ipmasq bool) error {
+// setupIPTables adds the iptables rules of the network of the bridge
+// bridgeIface, whose address is addr. The packets of an internal network
+// are dropped rather than forwarded between the bridge and the other
+// interfaces of the host, and aren't masqueraded.
+func setupIPTables(bridgeIface string, addr net.Addr, icc, ipmasq, internal bool) error {
 	// Enable NAT
 
-	if ipmasq {
+	if ipmasq && !internal {
 		natArgs := []string{"-s", addr.String(), "!", "-o", bridgeIface, "-j", "MASQUERADE"}
 
 		if !iptables.Exists(iptables.Nat, "POSTROUTING", natArgs...) {
@@ -336,6 +384,10 @@ func setupIPTables(addr net.Addr, icc, ipmasq bool) error {
 		}
 	}
 
+	if internal {
+		return isolateInternal(bridgeIface)
+	}
+
 	// Accept all non-intercontainer outgoing packets
 	outgoingArgs := []string{"-i", bridgeIface, "!", "-o", bridgeIface, "-j", "ACCEPT"}
 	if !iptables.Exists(iptables.Filter, "FORWARD", outgoingArgs...) {
@@ -359,6 +411,69 @@ func setupIPTables(addr net.Addr, icc, ipmasq bool) error {
 	return nil
 }
 
+// isolateInternal inserts the rules dropping the packets forwarded from the
+// bridge bridgeIface to the other interfaces of the host, and the other way
+// around, before any rule accepting them.
+func isolateInternal(bridgeIface string) error {
+	for _, args := range internalArgs(bridgeIface) {
+		if iptables.Exists(iptables.Filter, "FORWARD", args...) {
+			continue
+		}
+		if output, err := iptables.Raw(append([]string{"-I", "FORWARD"}, args...)...); err != nil {
+			return fmt.Errorf("Unable to isolate the internal network: %s", err)
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "FORWARD internal", Output: output}
+		}
+	}
+	return nil
+}
+
+// internalArgs returns the rules isolateInternal inserts for the bridge
+// bridgeIface.
+func internalArgs(bridgeIface string) [][]string {
+	return [][]string{
+		{"-i", bridgeIface, "!", "-o", bridgeIface, "-j", "DROP"},
+		{"!", "-i", bridgeIface, "-o", bridgeIface, "-j", "DROP"},
+	}
+}
+
+// setupChains sets up the chains of the port mappings and the isolation
+// chain of the networks.
+func setupChains(enableIPv6 bool) error {
+	if _, err := iptables.NewChain(chainPrefix, bridgeIface, iptables.Nat); err != nil {
+		return err
+	}
+	chain, err := iptables.NewChain(chainPrefix, bridgeIface, iptables.Filter)
+	if err != nil {
+		return err
+	}
+	portMapper.SetIptablesChain(chain)
+	if err := setupIsolationChain(); err != nil {
+		return err
+	}
+	if enableIPv6 {
+		setupIp6tablesChains(bridgeIface)
+	}
+	return nil
+}
+
+// setupIp6tablesChains sets up the ip6tables chains of the port mappings on
+// the IPv6 addresses of the host. Without them, for instance when the kernel
+// has no IPv6 NAT, ports are only published on IPv6 by the userland proxy.
+func setupIp6tablesChains(bridgeIface string) {
+	iptables.RemoveExistingChain6(chainPrefix, iptables.Nat)
+	if _, err := iptables.NewChain6(chainPrefix, bridgeIface, iptables.Nat); err != nil {
+		logrus.Warnf("Unable to set up ip6tables, ports won't be forwarded on IPv6: %v", err)
+		return
+	}
+	chain, err := iptables.NewChain6(chainPrefix, bridgeIface, iptables.Filter)
+	if err != nil {
+		logrus.Warnf("Unable to set up ip6tables, ports won't be forwarded on IPv6: %v", err)
+		return
+	}
+	portMapper.SetIp6tablesChain(chain)
+}
+
 func RequestPort(ip net.IP, proto string, port int) (int, error) {
 	initPortMapper()
 	return portMapper.Allocator.RequestPort(ip, proto, port)
@@ -369,7 +484,7 @@ func RequestPort(ip net.IP, proto string, port int) (int, error) {
 // If the bridge `bridgeIface` already exists, it will only perform the IP address association with the existing
 // bridge (fixes issue #8444)
 // If an address which doesn't conflict with existing interfaces can't be found, an error is returned.
-func configureBridge(bridgeIP string, bridgeIPv6 string, enableIPv6 bool) error {
+func configureBridge(bridgeIface, bridgeIP string, bridgeIPv6 string, enableIPv6 bool) error {
 	nameservers := []string{}
 	resolvConf, _ := resolvconf.Get()
 	// We don't check for an error here, because we don't really care
@@ -475,29 +590,16 @@ func createBridgeIface(name string) error {
 	return netlink.CreateBridge(name, setBridgeMacAddr)
 }
 
-// Generate a IEEE802 compliant MAC address from the given IP address.
-//
-// The generator is guaranteed to be consistent: the same IP will always yield the same
-// MAC address. This is to avoid ARP cache issues.
+// generateMacAddr returns the MAC address of the interface at ip, with the
+// prefix the daemon was given.
 func generateMacAddr(ip net.IP) net.HardwareAddr {
-	hw := make(net.HardwareAddr, 6)
-
-	// The first byte of the MAC address has to comply with these rules:
-	// 1. Unicast: Set the least-significant bit to 0.
-	// 2. Address is locally administered: Set the second-least-significant bit (U/L) to 1.
-	// 3. As "small" as possible: The veth address has to be "smaller" than the bridge address.
-	hw[0] = 0x02
-
-	// The first 24 bits of the MAC represent the Organizationally Unique Identifier (OUI).
-	// Since this address is locally administered, we can do whatever we want as long as
-	// it doesn't conflict with other addresses.
-	hw[1] = 0x42
-
-	// Insert the IP address into the last 32 bits of the MAC address.
-	// This is a simple way to guarantee the address will be consistent and unique.
-	copy(hw[2:], ip.To4())
+	return networkdriver.GenerateMacAddr(macPrefix, ip)
+}
 
-	return hw
+// generateMacAddrFromName returns the MAC address of the interfaces of the
+// container named name, with the prefix the daemon was given.
+func generateMacAddrFromName(name string) net.HardwareAddr {
+	return networkdriver.GenerateMacAddrFromName(macPrefix, name)
 }
 
 func linkLocalIPv6FromMac(mac string) (string, error) {
@@ -524,28 +626,42 @@ func Allocate(job *engine.Job) error {
 		globalIPv6    net.IP
 	)
 
-	ip, err = ipAllocator.RequestIP(bridgeIPv4Network, requestedIP)
+	network, err := getNetwork(job.Getenv("Bridge"))
 	if err != nil {
 		return err
 	}
 
-	// If no explicit mac address was given, generate a random one.
+	if requestedIPv6 != nil && network.globalIPv6 == nil {
+		return fmt.Errorf("No IPv6 subnet on bridge %s for address %s", network.iface, requestedIPv6)
+	}
+
+	ip, err = network.ipam.RequestIP(network.ipv4Net, requestedIP)
+	if err != nil {
+		return err
+	}
+
+	// If no explicit mac address was given, generate one from the IP, or
+	// the name of the container.
 	if mac, err = net.ParseMAC(job.Getenv("RequestedMac")); err != nil {
-		mac = generateMacAddr(ip)
+		if name := job.Getenv("ContainerName"); macFromName && name != "" {
+			mac = generateMacAddrFromName(name)
+		} else {
+			mac = generateMacAddr(ip)
+		}
 	}
 
-	if globalIPv6Network != nil {
+	if network.globalIPv6 != nil {
 		// If globalIPv6Network Size is at least a /80 subnet generate IPv6 address from MAC address
-		netmaskOnes, _ := globalIPv6Network.Mask.Size()
+		netmaskOnes, _ := network.globalIPv6.Mask.Size()
 		if requestedIPv6 == nil && netmaskOnes <= 80 {
-			requestedIPv6 = make(net.IP, len(globalIPv6Network.IP))
-			copy(requestedIPv6, globalIPv6Network.IP)
+			requestedIPv6 = make(net.IP, len(network.globalIPv6.IP))
+			copy(requestedIPv6, network.globalIPv6.IP)
 			for i, h := range mac {
 				requestedIPv6[i+10] = h
 			}
 		}
 
-		globalIPv6, err = ipAllocator.RequestIP(globalIPv6Network, requestedIPv6)
+		globalIPv6, err = network.ipam.RequestIP(network.globalIPv6, requestedIPv6)
 		if err != nil {
 			logrus.Errorf("Allocator: RequestIP v6: %v", err)
 			return err
@@ -555,12 +671,16 @@ func Allocate(job *engine.Job) error {
 
 	out := engine.Env{}
 	out.Set("IP", ip.String())
-	out.Set("Mask", bridgeIPv4Network.Mask.String())
-	out.Set("Gateway", bridgeIPv4Network.IP.String())
+	out.Set("Mask", network.ipv4Net.Mask.String())
+	if !network.internal {
+		// The bridge is the default gateway of the container, unless
+		// the network is internal and there's no route out of it.
+		out.Set("Gateway", network.ipv4Net.IP.String())
+	}
 	out.Set("MacAddress", mac.String())
-	out.Set("Bridge", bridgeIface)
+	out.Set("Bridge", network.iface)
 
-	size, _ := bridgeIPv4Network.Mask.Size()
+	size, _ := network.ipv4Net.Mask.Size()
 	out.SetInt("IPPrefixLen", size)
 
 	// If linklocal IPv6
@@ -572,14 +692,14 @@ func Allocate(job *engine.Job) error {
 	out.Set("LinkLocalIPv6", localIPv6.String())
 	out.Set("MacAddress", mac.String())
 
-	if globalIPv6Network != nil {
+	if network.globalIPv6 != nil {
 		out.Set("GlobalIPv6", globalIPv6.String())
-		sizev6, _ := globalIPv6Network.Mask.Size()
+		sizev6, _ := network.globalIPv6.Mask.Size()
 		out.SetInt("GlobalIPv6PrefixLen", sizev6)
-		out.Set("IPv6Gateway", bridgeIPv6Addr.String())
+		out.Set("IPv6Gateway", network.ipv6Addr.String())
 	}
 
-	currentInterfaces.Set(id, &networkInterface{
+	network.interfaces.Set(id, &networkInterface{
 		IP:   ip,
 		IPv6: globalIPv6,
 	})
@@ -591,33 +711,37 @@ func Allocate(job *engine.Job) error {
 
 // Release an interface for a select ip
 func Release(job *engine.Job) error {
-	var (
-		id                 = job.Args[0]
-		containerInterface = currentInterfaces.Get(id)
-	)
+	id := job.Args[0]
+	network, err := getNetwork(job.Getenv("Bridge"))
+	if err != nil {
+		return err
+	}
+	containerInterface := network.interfaces.Get(id)
 
 	if containerInterface == nil {
 		return fmt.Errorf("No network information to release for %s", id)
 	}
 
 	for _, nat := range containerInterface.PortMappings {
-		if err := portMapper.Unmap(nat); err != nil {
+		if err := network.portMapper.Unmap(nat); err != nil {
 			logrus.Infof("Unable to unmap port %s: %s", nat, err)
 		}
 	}
 
-	if err := ipAllocator.ReleaseIP(bridgeIPv4Network, containerInterface.IP); err != nil {
+	if err := network.ipam.ReleaseIP(network.ipv4Net, containerInterface.IP); err != nil {
 		logrus.Infof("Unable to release IPv4 %s", err)
 	}
-	if globalIPv6Network != nil {
-		if err := ipAllocator.ReleaseIP(globalIPv6Network, containerInterface.IPv6); err != nil {
+	if network.globalIPv6 != nil {
+		if err := network.ipam.ReleaseIP(network.globalIPv6, containerInterface.IPv6); err != nil {
 			logrus.Infof("Unable to release IPv6 %s", err)
 		}
 	}
 	return nil
 }
 
-// Allocate an external port and map it to the interface
+// Allocate an external port and map it to the interface, or a range of
+// them up to ContainerPortEnd, mapped to the same number of ports of the
+// host from HostPort.
 func AllocatePort(job *engine.Job) error {
 	var (
 		err error
@@ -628,8 +752,19 @@ func AllocatePort(job *engine.Job) error {
 		hostPort      = job.GetenvInt("HostPort")
 		containerPort = job.GetenvInt("ContainerPort")
 		proto         = job.Getenv("Proto")
-		network       = currentInterfaces.Get(id)
+		count         = 1
 	)
+	if job.EnvExists("ContainerPortEnd") {
+		if end := job.GetenvInt("ContainerPortEnd"); end > containerPort {
+			count = end - containerPort + 1
+		}
+	}
+
+	bridgeNetwork, err := getNetwork(job.Getenv("Bridge"))
+	if err != nil {
+		return err
+	}
+	network := bridgeNetwork.interfaces.Get(id)
 
 	if hostIP != "" {
 		ip = net.ParseIP(hostIP)
@@ -638,13 +773,25 @@ func AllocatePort(job *engine.Job) error {
 		}
 	}
 
+	// A port published on an IPv6 address of the host goes to the IPv6
+	// address of the container.
+	containerIP := network.IP
+	if ip.To4() == nil {
+		if network.IPv6 == nil {
+			return fmt.Errorf("Unable to publish a port on %s, the container has no global IPv6 address", ip)
+		}
+		containerIP = network.IPv6
+	}
+
 	// host ip, proto, and host port
 	var container net.Addr
 	switch proto {
 	case "tcp":
-		container = &net.TCPAddr{IP: network.IP, Port: containerPort}
+		container = &net.TCPAddr{IP: containerIP, Port: containerPort}
 	case "udp":
-		container = &net.UDPAddr{IP: network.IP, Port: containerPort}
+		container = &net.UDPAddr{IP: containerIP, Port: containerPort}
+	case "sctp":
+		container = &portmapper.SCTPAddr{IP: containerIP, Port: containerPort}
 	default:
 		return fmt.Errorf("unsupported address type %s", proto)
 	}
@@ -658,12 +805,12 @@ func AllocatePort(job *engine.Job) error {
 
 	var host net.Addr
 	for i := 0; i < MaxAllocatedPortAttempts; i++ {
-		if host, err = portMapper.Map(container, ip, hostPort); err == nil {
+		if host, err = bridgeNetwork.portMapper.MapRange(container, network.IPv6, ip, hostPort, count); err == nil {
 			break
 		}
 		// There is no point in immediately retrying to map an explicitly
 		// chosen port.
-		if hostPort != 0 {
+		if hostPort != 0 || err == portmapper.ErrSCTPWithoutIptables {
 			logrus.Warnf("Failed to allocate and map port %d: %s", hostPort, err)
 			break
 		}
@@ -684,6 +831,9 @@ func AllocatePort(job *engine.Job) error {
 	case *net.UDPAddr:
 		out.Set("HostIP", netAddr.IP.String())
 		out.SetInt("HostPort", netAddr.Port)
+	case *portmapper.SCTPAddr:
+		out.Set("HostIP", netAddr.IP.String())
+		out.SetInt("HostPort", netAddr.Port)
 	}
 	if _, err := out.WriteTo(job.Stdout); err != nil {
 		return err
@@ -722,12 +872,19 @@ func LinkContainers(job *engine.Job) error {
 		return fmt.Errorf("Child IP '%s' is invalid", childIP)
 	}
 
-	chain := iptables.Chain{Name: "DOCKER", Bridge: bridgeIface}
+	chain := iptables.Chain{Name: chainPrefix, Bridge: bridgeIface}
 	for _, p := range ports {
 		port := nat.Port(p)
 		if err := chain.Link(nfAction, ip1, ip2, port.Int(), port.Proto()); !ignoreErrors && err != nil {
 			return err
 		}
+		linksLock.Lock()
+		if nfAction == iptables.Delete {
+			delete(links, link{parentIP, childIP, port})
+		} else {
+			links[link{parentIP, childIP, port}] = true
+		}
+		linksLock.Unlock()
 	}
 	return nil
 }
@@ -238,6 +238,25 @@ func TestMacAddrGeneration(t *testing.T) {
 	}
 }
 
+func TestMacAddrFromName(t *testing.T) {
+	defer func(prefix net.HardwareAddr) { macPrefix = prefix }(macPrefix)
+	macPrefix = net.HardwareAddr{0x06, 0x10}
+
+	mac := generateMacAddrFromName("/web")
+	if mac[0] != 0x06 || mac[1] != 0x10 {
+		t.Fatalf("MAC address %s doesn't start with the prefix", mac)
+	}
+	if generateMacAddrFromName("web").String() != mac.String() {
+		t.Fatal("Inconsistent MAC address")
+	}
+	if generateMacAddrFromName("/db").String() == mac.String() {
+		t.Fatal("Non-unique MAC address")
+	}
+	if generateMacAddr(net.ParseIP("192.168.0.1")).String() != "06:10:c0:a8:00:01" {
+		t.Fatal("The MAC address of an IP doesn't start with the prefix")
+	}
+}
+
 func TestLinkContainers(t *testing.T) {
 	eng := engine.New()
 	eng.Logging = false
@@ -277,3 +296,77 @@ func TestLinkContainers(t *testing.T) {
 	}
 
 }
+
+func TestCreateNetwork(t *testing.T) {
+	eng := engine.New()
+	eng.Logging = false
+
+	// Init driver
+	job := eng.Job("initdriver")
+	if res := InitDriver(job); res != nil {
+		t.Fatal("Failed to initialize network driver")
+	}
+
+	job = eng.Job("create_network")
+	job.Setenv("Bridge", "br-dockertest")
+	job.Setenv("Address", "10.201.0.1/16")
+	job.SetenvBool("DeleteBridge", true)
+	if err := CreateNetwork(job); err != nil {
+		t.Fatalf("Failed to create network: %s", err)
+	}
+	defer DeleteNetwork(job)
+	if err := CreateNetwork(job); err == nil {
+		t.Fatal("Created the network of a bridge twice")
+	}
+
+	output := newInterfaceAllocation(t, engine.Env{"Bridge=br-dockertest"})
+	if ip := net.ParseIP(output.Get("IP")); ip == nil || ip.Mask(net.CIDRMask(16, 32)).String() != "10.201.0.0" {
+		t.Fatalf("Expected an address of 10.201.0.0/16, got %s", output.Get("IP"))
+	}
+	if gw := output.Get("Gateway"); gw != "10.201.0.1" {
+		t.Fatalf("Expected gateway 10.201.0.1, got %s", gw)
+	}
+
+	job = eng.Job("release_interface", "container_id")
+	job.Setenv("Bridge", "br-dockertest")
+	if err := Release(job); err != nil {
+		t.Fatalf("Failed to release network interface: %s", err)
+	}
+
+	job = eng.Job("delete_network")
+	job.Setenv("Bridge", "br-dockertest")
+	job.SetenvBool("DeleteBridge", true)
+	if err := DeleteNetwork(job); err != nil {
+		t.Fatalf("Failed to delete network: %s", err)
+	}
+	if err := DeleteNetwork(job); err == nil {
+		t.Fatal("Deleted the network of a bridge twice")
+	}
+}
+
+func TestAllocateInternal(t *testing.T) {
+	subnet := &net.IPNet{IP: net.IPv4(10, 202, 0, 1).To4(), Mask: net.CIDRMask(16, 32)}
+	bridgeNetworksLock.Lock()
+	bridgeNetworks["br-internal"] = &bridgeNetwork{
+		iface:      "br-internal",
+		ipv4Net:    subnet,
+		interfaces: &ifaces{c: make(map[string]*networkInterface)},
+		ipam:       ipAllocator,
+		internal:   true,
+	}
+	bridgeNetworksLock.Unlock()
+	defer func() {
+		bridgeNetworksLock.Lock()
+		delete(bridgeNetworks, "br-internal")
+		bridgeNetworksLock.Unlock()
+	}()
+
+	// The containers of an internal network have no default gateway.
+	output := newInterfaceAllocation(t, engine.Env{"Bridge=br-internal"})
+	if ip := net.ParseIP(output.Get("IP")); ip == nil || !subnet.Contains(ip) {
+		t.Fatalf("Expected an address of 10.202.0.0/16, got %s", output.Get("IP"))
+	}
+	if gw := output.Get("Gateway"); gw != "" {
+		t.Fatalf("Expected no gateway, got %s", gw)
+	}
+}
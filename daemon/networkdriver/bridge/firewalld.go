@@ -0,0 +1,88 @@
+package bridge
+
+import (
+	"net"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/nat"
+	"github.com/docker/docker/pkg/iptables"
+)
+
+// link is a port of a container opened to a linked container.
+type link struct {
+	parentIP string
+	childIP  string
+	port     nat.Port
+}
+
+var (
+	// links are the ports opened by LinkContainers, whose rules are added
+	// again when firewalld flushes them.
+	links     = make(map[link]bool)
+	linksLock sync.Mutex
+)
+
+// addToFirewalldZone puts the bridge iface in the zone of firewalld of the
+// bridges of the daemon, if firewalld runs. Its default zone would reject
+// the packets of the containers to the host.
+func addToFirewalldZone(iface string) {
+	if !iptables.FirewalldRunning() {
+		return
+	}
+	if err := iptables.AddInterfaceToZone(iface); err != nil {
+		logrus.Warnf("Unable to add %s to the %s zone of firewalld: %v", iface, iptables.DockerZone, err)
+	}
+}
+
+// reloadIptables adds the iptables rules of the daemon again once firewalld
+// flushed them, when it reloaded, started or stopped: the rules of the
+// bridges, of the port mappings and of the links.
+func reloadIptables() {
+	addToFirewalldZone(bridgeIface)
+	if err := setupIPTables(bridgeIface, bridgeIPv4Network, interContainerCommunication, ipMasquerade, false); err != nil {
+		logrus.Errorf("Unable to add the iptables rules of %s again: %v", bridgeIface, err)
+	}
+	if err := setupChains(bridgeIPv6Addr != nil); err != nil {
+		logrus.Errorf("Unable to add the iptables chains again: %v", err)
+		return
+	}
+	portMapper.ReMapAll()
+
+	bridgeNetworksLock.Lock()
+	for iface, network := range bridgeNetworks {
+		if !network.iptables {
+			continue
+		}
+		addToFirewalldZone(iface)
+		if err := setupIPTables(iface, network.ipv4Net, network.icc, network.ipMasq, network.internal); err != nil {
+			logrus.Errorf("Unable to add the iptables rules of %s again: %v", iface, err)
+			continue
+		}
+		chain, err := iptables.NewChain(chainPrefix, iface, iptables.Filter)
+		if err != nil {
+			logrus.Errorf("Unable to add the iptables rules of %s again: %v", iface, err)
+			continue
+		}
+		network.portMapper.SetIptablesChain(chain)
+		if err := isolateBridge(iptables.Insert, iface); err != nil {
+			logrus.Errorf("Unable to isolate %s again: %v", iface, err)
+		}
+		network.portMapper.ReMapAll()
+	}
+	bridgeNetworksLock.Unlock()
+
+	linksLock.Lock()
+	defer linksLock.Unlock()
+	chain := iptables.Chain{Name: chainPrefix, Bridge: bridgeIface}
+	for l := range links {
+		var (
+			ip1 = net.ParseIP(l.parentIP)
+			ip2 = net.ParseIP(l.childIP)
+		)
+		chain.Link(iptables.Delete, ip1, ip2, l.port.Int(), l.port.Proto())
+		if err := chain.Link(iptables.Append, ip1, ip2, l.port.Int(), l.port.Proto()); err != nil {
+			logrus.Errorf("Unable to link %s to %s again: %v", l.childIP, l.parentIP, err)
+		}
+	}
+}
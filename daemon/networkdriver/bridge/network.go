@@ -0,0 +1,315 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/daemon/networkdriver/ipam"
+	"github.com/docker/docker/daemon/networkdriver/portmapper"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/iptables"
+	"github.com/docker/libcontainer/netlink"
+)
+
+// bridgeNetwork is a network of containers attached to a bridge of the host:
+// the default one, or one of those created with `docker network create`.
+type bridgeNetwork struct {
+	iface      string
+	ipv4Net    *net.IPNet // The address of the bridge in the network
+	ipv6Addr   net.IP
+	globalIPv6 *net.IPNet
+	portMapper *portmapper.PortMapper
+	interfaces *ifaces
+	ipam       ipam.Driver // The driver of the addresses of the containers
+	iptables   bool        // Whether the daemon manages the iptables rules of the network
+	icc        bool
+	ipMasq     bool
+	internal   bool // Whether the containers are cut off from the outside world
+}
+
+var (
+	// bridgeNetworks are the networks created with `docker network
+	// create`, by the name of their bridge.
+	bridgeNetworks     = make(map[string]*bridgeNetwork)
+	bridgeNetworksLock sync.Mutex
+)
+
+// getNetwork returns the network of the bridge named iface, the default
+// network if iface is empty.
+func getNetwork(iface string) (*bridgeNetwork, error) {
+	if iface == "" || iface == bridgeIface {
+		return &bridgeNetwork{
+			iface:      bridgeIface,
+			ipv4Net:    bridgeIPv4Network,
+			ipv6Addr:   bridgeIPv6Addr,
+			globalIPv6: globalIPv6Network,
+			portMapper: portMapper,
+			interfaces: &currentInterfaces,
+			ipam:       ipAllocator,
+			iptables:   iptablesEnabled,
+			icc:        interContainerCommunication,
+			ipMasq:     ipMasquerade,
+		}, nil
+	}
+
+	bridgeNetworksLock.Lock()
+	defer bridgeNetworksLock.Unlock()
+	network, exists := bridgeNetworks[iface]
+	if !exists {
+		return nil, fmt.Errorf("No such network bridge: %s", iface)
+	}
+	return network, nil
+}
+
+// getIPAMDriver returns the IPAM driver named name, the built-in one if name
+// is empty.
+func getIPAMDriver(name string) (ipam.Driver, error) {
+	if name == "" || name == ipam.DefaultDriver {
+		return ipAllocator, nil
+	}
+	return ipam.NewPlugin(name)
+}
+
+// CreateNetwork sets up the bridge of a network, creating it if it doesn't
+// exist yet. Unless Address gives the address of the bridge, with the prefix
+// length of the network, a range that doesn't conflict with the networks of
+// the host is picked. The addresses of the containers are allocated by the
+// IPAM driver IPAMDriver, the built-in one if it's empty. EnableICC and
+// EnableIpMasq default to the settings of the daemon, and EnableIptables
+// can only turn off the iptables rules of the network. The containers of an
+// Internal network have no default gateway, and its iptables rules drop
+// their packets to and from the outside world.
+func CreateNetwork(job *engine.Job) error {
+	var (
+		iface       = job.Getenv("Bridge")
+		address     = job.Getenv("Address")
+		icc         = interContainerCommunication
+		ipMasq      = ipMasquerade
+		useIptables = iptablesEnabled
+		internal    = job.GetenvBool("Internal")
+	)
+	if job.EnvExists("EnableIptables") && !job.GetenvBool("EnableIptables") {
+		useIptables = false
+	}
+	if job.EnvExists("EnableICC") {
+		icc = job.GetenvBool("EnableICC")
+	}
+	if job.EnvExists("EnableIpMasq") {
+		ipMasq = job.GetenvBool("EnableIpMasq")
+	}
+	if iface == "" {
+		return fmt.Errorf("Bad parameter: the bridge of the network is required")
+	}
+	if internal && !useIptables {
+		return fmt.Errorf("Bad parameter: an internal network requires iptables")
+	}
+	ipamDriver, err := getIPAMDriver(job.Getenv("IPAMDriver"))
+	if err != nil {
+		return err
+	}
+
+	bridgeNetworksLock.Lock()
+	defer bridgeNetworksLock.Unlock()
+
+	if _, exists := bridgeNetworks[iface]; exists || iface == bridgeIface {
+		return fmt.Errorf("Bridge %s is already used by another network", iface)
+	}
+
+	created := false
+	addr, _, err := networkdriver.GetIfaceAddr(iface)
+	if err != nil {
+		if err := configureBridge(iface, address, "", false); err != nil {
+			return err
+		}
+		created = true
+		if addr, _, err = networkdriver.GetIfaceAddr(iface); err != nil {
+			netlink.DeleteBridge(iface)
+			return err
+		}
+	} else if address != "" {
+		bip, _, err := net.ParseCIDR(address)
+		if err != nil {
+			return err
+		}
+		if ip := addr.(*net.IPNet).IP; !ip.Equal(bip) {
+			return fmt.Errorf("Bridge ip (%s) does not match existing bridge configuration %s", ip, bip)
+		}
+	}
+
+	network := &bridgeNetwork{
+		iface:      iface,
+		ipv4Net:    addr.(*net.IPNet),
+		portMapper: portmapper.NewWithPortAllocator(portMapper.Allocator),
+		interfaces: &ifaces{c: make(map[string]*networkInterface)},
+		ipam:       ipamDriver,
+		iptables:   useIptables,
+		icc:        icc,
+		ipMasq:     ipMasq && !internal,
+		internal:   internal,
+	}
+
+	// Block the address of the bridge in the IP allocator
+	if _, err := network.ipam.RequestIP(network.ipv4Net, network.ipv4Net.IP); err != nil {
+		if created {
+			netlink.DeleteBridge(iface)
+		}
+		return fmt.Errorf("Unable to reserve the address of the bridge %s: %v", iface, err)
+	}
+	if useIptables {
+		if err := setupNetworkIPTables(network); err != nil {
+			network.ipam.ReleaseIP(network.ipv4Net, network.ipv4Net.IP)
+			if created {
+				netlink.DeleteBridge(iface)
+			}
+			return err
+		}
+	}
+	bridgeNetworks[iface] = network
+
+	return writeNetwork(job, iface, network.ipv4Net, created)
+}
+
+// setupNetworkIPTables adds the iptables rules of network, and removes
+// those it added if it fails.
+func setupNetworkIPTables(network *bridgeNetwork) error {
+	iface := network.iface
+	addToFirewalldZone(iface)
+	if err := setupIPTables(iface, network.ipv4Net, network.icc, network.ipMasq, network.internal); err != nil {
+		removeIPTables(iface, network.ipv4Net)
+		return err
+	}
+	chain, err := iptables.NewChain(chainPrefix, iface, iptables.Filter)
+	if err != nil {
+		removeIPTables(iface, network.ipv4Net)
+		return err
+	}
+	network.portMapper.SetIptablesChain(chain)
+	if err := isolateBridge(iptables.Insert, iface); err != nil {
+		isolateBridge(iptables.Delete, iface)
+		removeIPTables(iface, network.ipv4Net)
+		return err
+	}
+	return nil
+}
+
+// DeleteNetwork removes the iptables rules of a network, and its bridge if
+// DeleteBridge is set: the bridges the network was given that the host
+// already had are left to it.
+func DeleteNetwork(job *engine.Job) error {
+	iface := job.Getenv("Bridge")
+
+	bridgeNetworksLock.Lock()
+	defer bridgeNetworksLock.Unlock()
+
+	network, exists := bridgeNetworks[iface]
+	if !exists {
+		return fmt.Errorf("No such network bridge: %s", iface)
+	}
+	if network.iptables {
+		isolateBridge(iptables.Delete, iface)
+		removeIPTables(iface, network.ipv4Net)
+	}
+	network.ipam.ReleaseIP(network.ipv4Net, network.ipv4Net.IP)
+	delete(bridgeNetworks, iface)
+
+	if !job.GetenvBool("DeleteBridge") {
+		return nil
+	}
+	return netlink.DeleteBridge(iface)
+}
+
+// writeNetwork writes the bridge, the subnet and the gateway of a network to
+// the standard output of job, and whether the bridge was just created.
+func writeNetwork(job *engine.Job, iface string, ipv4Net *net.IPNet, created bool) error {
+	subnet := &net.IPNet{IP: ipv4Net.IP.Mask(ipv4Net.Mask), Mask: ipv4Net.Mask}
+
+	out := engine.Env{}
+	out.Set("Bridge", iface)
+	out.Set("Subnet", subnet.String())
+	out.Set("Gateway", ipv4Net.IP.String())
+	out.SetBool("Created", created)
+	_, err := out.WriteTo(job.Stdout)
+	return err
+}
+
+// isolationChain returns the name of the chain of the rules isolating the
+// networks from each other, which FORWARD jumps to first.
+func isolationChain() string {
+	return chainPrefix + "-ISOLATION"
+}
+
+// setupIsolationChain creates the isolation chain, or empties it for the
+// networks to add their rules again, and makes FORWARD jump to it.
+func setupIsolationChain() error {
+	chain := isolationChain()
+	if _, err := iptables.Raw("-n", "-L", chain); err != nil {
+		if output, err := iptables.Raw("-N", chain); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return fmt.Errorf("Could not create the %s chain: %s", chain, output)
+		}
+	} else if _, err := iptables.Raw("-F", chain); err != nil {
+		return err
+	}
+	if !iptables.Exists(iptables.Filter, "FORWARD", "-j", chain) {
+		if output, err := iptables.Raw("-I", "FORWARD", "-j", chain); err != nil {
+			return err
+		} else if len(output) != 0 {
+			return &iptables.ChainError{Chain: "FORWARD isolation", Output: output}
+		}
+	}
+	return nil
+}
+
+// isolateBridge inserts, or deletes, the rules of the isolation chain
+// dropping the packets forwarded between iface and the bridges of the other
+// networks whose iptables rules the daemon manages, so that the containers
+// of different networks can't reach each other.
+func isolateBridge(action iptables.Action, iface string) error {
+	others := []string{bridgeIface}
+	for other, network := range bridgeNetworks {
+		if other != iface && network.iptables {
+			others = append(others, other)
+		}
+	}
+	for _, other := range others {
+		for _, args := range [][]string{
+			{"-i", iface, "-o", other, "-j", "DROP"},
+			{"-i", other, "-o", iface, "-j", "DROP"},
+		} {
+			if action == iptables.Insert && iptables.Exists(iptables.Filter, isolationChain(), args...) {
+				continue
+			}
+			if output, err := iptables.Raw(append([]string{string(action), isolationChain()}, args...)...); err != nil {
+				return err
+			} else if len(output) != 0 {
+				return &iptables.ChainError{Chain: "FORWARD isolation", Output: output}
+			}
+		}
+	}
+	return nil
+}
+
+// removeIPTables deletes the rules setupIPTables added for the bridge iface,
+// and the jump to the chain of its port mappings.
+func removeIPTables(iface string, addr net.Addr) {
+	rules := [][]string{
+		{"-t", string(iptables.Nat), "-D", "POSTROUTING", "-s", addr.String(), "!", "-o", iface, "-j", "MASQUERADE"},
+		{"-D", "FORWARD", "-i", iface, "-o", iface, "-j", "ACCEPT"},
+		{"-D", "FORWARD", "-i", iface, "-o", iface, "-j", "DROP"},
+		{"-D", "FORWARD", "-i", iface, "!", "-o", iface, "-j", "ACCEPT"},
+		{"-D", "FORWARD", "-o", iface, "-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", "ACCEPT"},
+		{"-D", "FORWARD", "-o", iface, "-j", chainPrefix},
+	}
+	for _, args := range internalArgs(iface) {
+		rules = append(rules, append([]string{"-D", "FORWARD"}, args...))
+	}
+	for _, args := range rules {
+		if output, err := iptables.Raw(args...); err != nil || len(output) != 0 {
+			logrus.Debugf("Unable to remove iptables rule %v: %v %s", args, err, output)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package ipam defines the drivers that manage the addresses of the
+// containers on the networks of the bridge driver: the built-in one, and
+// remote plugins that allocate them from an external IP address management
+// system.
+//
+// A plugin is an HTTP server listening on a unix socket. The daemon POSTs a
+// JSON encoded Request to its /IpamDriver.RequestAddress endpoint to
+// allocate an address, and to its /IpamDriver.ReleaseAddress endpoint to
+// release one, and expects a JSON encoded Response back.
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+	"github.com/docker/docker/pkg/plugins"
+)
+
+const (
+	// DefaultDriver is the name of the built-in driver.
+	DefaultDriver = "default"
+
+	// The endpoints plugins must serve.
+	RequestAddressPath = "/IpamDriver.RequestAddress"
+	ReleaseAddressPath = "/IpamDriver.ReleaseAddress"
+)
+
+// Driver allocates the addresses of the containers on networks.
+type Driver interface {
+	// RequestIP allocates ip on network, or any free address of network if
+	// ip is nil.
+	RequestIP(network *net.IPNet, ip net.IP) (net.IP, error)
+
+	// ReleaseIP makes ip available again on network.
+	ReleaseIP(network *net.IPNet, ip net.IP) error
+}
+
+// The built-in driver.
+var _ Driver = (*ipallocator.IPAllocator)(nil)
+
+// Request is what a plugin gets to know about an address to allocate or
+// release.
+type Request struct {
+	// Subnet is the subnet of the network, e.g. 172.18.0.0/16.
+	Subnet string
+
+	// Address is the address to allocate or release. When allocating, it
+	// is empty if any free address of Subnet will do.
+	Address string `json:",omitempty"`
+}
+
+// Response is the answer of a plugin.
+type Response struct {
+	// Address is the allocated address.
+	Address string `json:",omitempty"`
+
+	// Err reports why the plugin couldn't allocate or release the
+	// address.
+	Err string `json:",omitempty"`
+}
+
+// Plugin is a remote driver reachable over a unix socket.
+type Plugin struct {
+	name   string
+	client *plugins.Client
+}
+
+// NewPlugin returns the plugin listening at the socket given by name, which
+// is either an absolute path or the name of a socket in
+// plugins.DefaultPluginDir.
+func NewPlugin(name string) (*Plugin, error) {
+	socket := plugins.Socket(name)
+	if _, err := os.Stat(socket); err != nil {
+		return nil, fmt.Errorf("IPAM plugin %s not found: %v", name, err)
+	}
+	return &Plugin{name: name, client: plugins.NewClient(socket)}, nil
+}
+
+// Name returns the name the plugin was configured with.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+func (p *Plugin) call(path string, req *Request) (*Response, error) {
+	var res Response
+	if err := p.client.Call(path, req, &res); err != nil {
+		return nil, fmt.Errorf("IPAM plugin %s failed with error: %v", p.name, err)
+	}
+	if res.Err != "" {
+		return nil, fmt.Errorf("IPAM plugin %s: %s", p.name, res.Err)
+	}
+	return &res, nil
+}
+
+func subnet(network *net.IPNet) string {
+	return (&net.IPNet{IP: network.IP.Mask(network.Mask), Mask: network.Mask}).String()
+}
+
+// RequestIP asks the plugin for ip, or for any free address of network if
+// ip is nil.
+func (p *Plugin) RequestIP(network *net.IPNet, ip net.IP) (net.IP, error) {
+	req := &Request{Subnet: subnet(network)}
+	if ip != nil {
+		req.Address = ip.String()
+	}
+	res, err := p.call(RequestAddressPath, req)
+	if err != nil {
+		return nil, err
+	}
+	allocated := net.ParseIP(res.Address)
+	if allocated == nil || !network.Contains(allocated) {
+		return nil, fmt.Errorf("IPAM plugin %s allocated %q, which isn't an address of %s", p.name, res.Address, req.Subnet)
+	}
+	if ip4 := allocated.To4(); ip4 != nil {
+		allocated = ip4
+	}
+	return allocated, nil
+}
+
+// ReleaseIP tells the plugin that ip is no longer used.
+func (p *Plugin) ReleaseIP(network *net.IPNet, ip net.IP) error {
+	_, err := p.call(ReleaseAddressPath, &Request{Subnet: subnet(network), Address: ip.String()})
+	return err
+}
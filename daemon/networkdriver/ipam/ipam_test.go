@@ -0,0 +1,107 @@
+package ipam
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// startPlugin serves a plugin on a unix socket that allocates the addresses
+// of 10.1.0.0/16 from 10.1.0.100 up.
+func startPlugin(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ipam-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "corp.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu        sync.Mutex
+		next      = 100
+		allocated = make(map[string]bool)
+	)
+	handler := func(release bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			var req Request
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+				return
+			}
+			res := Response{}
+			switch {
+			case req.Subnet != "10.1.0.0/16":
+				res.Err = "unknown subnet " + req.Subnet
+			case release:
+				delete(allocated, req.Address)
+			case req.Address == "":
+				res.Address = net.IPv4(10, 1, 0, byte(next)).String()
+				next++
+			case allocated[req.Address]:
+				res.Err = req.Address + " is in use"
+			default:
+				res.Address = req.Address
+			}
+			if res.Address != "" {
+				allocated[res.Address] = true
+			}
+			json.NewEncoder(w).Encode(res)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(RequestAddressPath, handler(false))
+	mux.HandleFunc(ReleaseAddressPath, handler(true))
+	go http.Serve(l, mux)
+	return socket, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPlugin(t *testing.T) {
+	socket, cleanup := startPlugin(t)
+	defer cleanup()
+	p, err := NewPlugin(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	network := &net.IPNet{IP: net.ParseIP("10.1.0.1").To4(), Mask: net.CIDRMask(16, 32)}
+	ip, err := p.RequestIP(network, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("10.1.0.100")) {
+		t.Fatalf("Expected 10.1.0.100, got %s", ip)
+	}
+	if _, err := p.RequestIP(network, ip); err == nil {
+		t.Fatalf("Expected an error requesting %s twice", ip)
+	}
+	if err := p.ReleaseIP(network, ip); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.RequestIP(network, ip); err != nil {
+		t.Fatalf("Expected %s to be available once released, got %v", ip, err)
+	}
+
+	other := &net.IPNet{IP: net.ParseIP("10.2.0.1").To4(), Mask: net.CIDRMask(16, 32)}
+	if _, err := p.RequestIP(other, nil); err == nil {
+		t.Fatal("Expected the error of the plugin for an unknown subnet")
+	}
+}
+
+func TestPluginNotFound(t *testing.T) {
+	if _, err := NewPlugin("/nonexistent/corp.sock"); err == nil {
+		t.Fatal("Expected an error for a plugin without a socket")
+	}
+}
@@ -0,0 +1,159 @@
+// Package macvlan implements the macvlan and ipvlan drivers, whose networks
+// are the LAN of an interface of the host, their parent: every container has
+// an interface of its own on the parent, with an address of the LAN, rather
+// than a veth on a bridge of the host, behind NAT. A parent named after an
+// interface and a VLAN ID, as eth0.10, is a VLAN sub-interface of a trunk,
+// which the driver creates if the host doesn't have it.
+//
+// The execution drivers create the interfaces of the containers, the driver
+// keeps the addresses the containers of the host have on every network.
+package macvlan
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/daemon/networkdriver/ipallocator"
+)
+
+const (
+	// ParentOption is the option of the networks of both drivers that
+	// names their parent interface.
+	ParentOption = "parent"
+
+	// The options of the networks of the macvlan and ipvlan drivers that
+	// give the mode of the interfaces of their containers.
+	MacvlanModeOption = "macvlan_mode"
+	IpvlanModeOption  = "ipvlan_mode"
+)
+
+// Modes are the modes of the interfaces of each driver, the default first.
+var Modes = map[string][]string{
+	"macvlan": {"bridge", "private", "vepa", "passthru"},
+	"ipvlan":  {"l2", "l3"},
+}
+
+// IsDriver returns whether driver is the macvlan or the ipvlan driver.
+func IsDriver(driver string) bool {
+	_, exists := Modes[driver]
+	return exists
+}
+
+// ModeOption returns the option of the mode of the interfaces of driver.
+func ModeOption(driver string) string {
+	if driver == "ipvlan" {
+		return IpvlanModeOption
+	}
+	return MacvlanModeOption
+}
+
+// ParseParent returns the interface parent is a VLAN sub-interface of, and
+// its VLAN ID, when it's named as one. The VLAN ID is 0 otherwise.
+func ParseParent(parent string) (string, uint16) {
+	i := strings.LastIndex(parent, ".")
+	if i <= 0 {
+		return parent, 0
+	}
+	vlan, err := strconv.ParseUint(parent[i+1:], 10, 16)
+	if err != nil || vlan == 0 || vlan > 4094 {
+		return parent, 0
+	}
+	return parent[:i], uint16(vlan)
+}
+
+// Driver keeps the addresses of the containers of the host on the networks.
+type Driver struct {
+	sync.Mutex
+	networks map[string]*network
+}
+
+type network struct {
+	subnet    *net.IPNet
+	allocator *ipallocator.IPAllocator
+}
+
+func New() *Driver {
+	return &Driver{networks: make(map[string]*network)}
+}
+
+// CreateNetwork sets up the network id, whose parent is parent and whose
+// LAN is subnet, routed by gateway. The parent is created if it's a VLAN
+// sub-interface the host doesn't have, CreateNetwork returns whether it
+// was. The gateway, and the addresses of the parent, aren't given to
+// containers.
+func (d *Driver) CreateNetwork(id, parent string, subnet *net.IPNet, gateway net.IP) (bool, error) {
+	created := false
+	if _, err := net.InterfaceByName(parent); err != nil {
+		master, vlan := ParseParent(parent)
+		if vlan == 0 {
+			return false, fmt.Errorf("Invalid parent %s: %v", parent, err)
+		}
+		if err := createVlan(master, parent, vlan); err != nil {
+			return false, fmt.Errorf("Unable to create the VLAN sub-interface %s: %v", parent, err)
+		}
+		created = true
+	}
+
+	n := &network{subnet: subnet, allocator: ipallocator.New()}
+	reserved := []net.IP{gateway}
+	if iface, err := net.InterfaceByName(parent); err == nil {
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && subnet.Contains(ipNet.IP) {
+				reserved = append(reserved, ipNet.IP)
+			}
+		}
+	}
+	for _, ip := range reserved {
+		n.allocator.RequestIP(subnet, ip)
+	}
+
+	d.Lock()
+	d.networks[id] = n
+	d.Unlock()
+	return created, nil
+}
+
+// DeleteNetwork forgets the network id, and deletes its parent if
+// deleteParent.
+func (d *Driver) DeleteNetwork(id, parent string, deleteParent bool) error {
+	d.Lock()
+	delete(d.networks, id)
+	d.Unlock()
+	if deleteParent {
+		return deleteVlan(parent)
+	}
+	return nil
+}
+
+// RequestIP takes an address of the network id for a container: ip, or a
+// free one if ip is nil.
+func (d *Driver) RequestIP(id string, ip net.IP) (net.IP, error) {
+	n, err := d.network(id)
+	if err != nil {
+		return nil, err
+	}
+	return n.allocator.RequestIP(n.subnet, ip)
+}
+
+// ReleaseIP gives back the address ip of the network id.
+func (d *Driver) ReleaseIP(id string, ip net.IP) error {
+	n, err := d.network(id)
+	if err != nil {
+		return err
+	}
+	return n.allocator.ReleaseIP(n.subnet, ip)
+}
+
+func (d *Driver) network(id string) (*network, error) {
+	d.Lock()
+	defer d.Unlock()
+	n := d.networks[id]
+	if n == nil {
+		return nil, fmt.Errorf("Network %s isn't set up", id)
+	}
+	return n, nil
+}
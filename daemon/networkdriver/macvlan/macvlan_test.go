@@ -0,0 +1,49 @@
+package macvlan
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParseParent(t *testing.T) {
+	for parent, expected := range map[string]struct {
+		master string
+		vlan   uint16
+	}{
+		"eth0":       {"eth0", 0},
+		"eth0.10":    {"eth0", 10},
+		"bond0.4094": {"bond0", 4094},
+		"eth0.4095":  {"eth0.4095", 0},
+		"eth0.0":     {"eth0.0", 0},
+		"eth0.lan":   {"eth0.lan", 0},
+		".10":        {".10", 0},
+	} {
+		if master, vlan := ParseParent(parent); master != expected.master || vlan != expected.vlan {
+			t.Errorf("ParseParent(%q) = %q, %d, expected %q, %d", parent, master, vlan, expected.master, expected.vlan)
+		}
+	}
+}
+
+func TestRequestIPSkipsGateway(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("10.99.0.0/24")
+	d := New()
+	if created, err := d.CreateNetwork("n", "lo", subnet, net.ParseIP("10.99.0.1")); err != nil || created {
+		t.Fatalf("Unable to create the network, created %t: %v", created, err)
+	}
+	ip, err := d.RequestIP("n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.ParseIP("10.99.0.2")) {
+		t.Fatalf("Expected 10.99.0.2, got %s", ip)
+	}
+	if _, err := d.RequestIP("n", net.ParseIP("10.99.0.1")); err == nil {
+		t.Fatal("The gateway was given to a container")
+	}
+	if err := d.DeleteNetwork("n", "lo", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.RequestIP("n", nil); err == nil {
+		t.Fatal("Got an address of a deleted network")
+	}
+}
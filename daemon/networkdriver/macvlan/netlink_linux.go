@@ -0,0 +1,30 @@
+// +build linux
+
+package macvlan
+
+import (
+	"net"
+
+	"github.com/docker/libcontainer/netlink"
+)
+
+// createVlan creates the VLAN sub-interface name of master, whose VLAN ID
+// is vlan, and brings it up.
+func createVlan(master, name string, vlan uint16) error {
+	if err := netlink.NetworkLinkAddVlan(master, name, vlan); err != nil {
+		return err
+	}
+	iface, err := net.InterfaceByName(name)
+	if err == nil {
+		err = netlink.NetworkLinkUp(iface)
+	}
+	if err != nil {
+		netlink.NetworkLinkDel(name)
+		return err
+	}
+	return nil
+}
+
+func deleteVlan(name string) error {
+	return netlink.NetworkLinkDel(name)
+}
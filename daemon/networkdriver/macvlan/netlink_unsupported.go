@@ -0,0 +1,15 @@
+// +build !linux
+
+package macvlan
+
+import "errors"
+
+var errUnsupported = errors.New("VLAN sub-interfaces are not supported on this platform")
+
+func createVlan(master, name string, vlan uint16) error {
+	return errUnsupported
+}
+
+func deleteVlan(name string) error {
+	return errUnsupported
+}
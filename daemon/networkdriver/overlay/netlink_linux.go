@@ -0,0 +1,225 @@
+// +build linux
+
+package overlay
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/docker/libcontainer/netlink"
+)
+
+// The rtnetlink attributes the package uses, which the syscall package
+// lacks.
+const (
+	iflaInfoKind      = 1
+	iflaInfoData      = 2
+	iflaVxlanID       = 1
+	iflaVxlanLocal    = 4
+	iflaVxlanLearning = 7
+	iflaVxlanProxy    = 11
+	iflaVxlanPort     = 15
+
+	ndaDst    = 1
+	ndaLladdr = 2
+
+	nudPermanent = 0x80
+	ntfSelf      = 0x02
+)
+
+// createNetwork creates the bridge of a network and its VXLAN interface,
+// unless they exist.
+func createNetwork(bridge, vxlan string, vni uint32, local net.IP, mtu int) error {
+	if _, err := net.InterfaceByName(bridge); err != nil {
+		if err := netlink.CreateBridge(bridge, true); err != nil {
+			return err
+		}
+	}
+	if _, err := net.InterfaceByName(vxlan); err != nil {
+		if err := createVxlan(vxlan, vni, local, mtu); err != nil {
+			deleteNetwork(bridge, "")
+			return err
+		}
+	}
+
+	br, err := net.InterfaceByName(bridge)
+	if err != nil {
+		return err
+	}
+	vx, err := net.InterfaceByName(vxlan)
+	if err != nil {
+		return err
+	}
+	if err := netlink.AddToBridge(vx, br); err != nil {
+		return err
+	}
+	for _, iface := range []*net.Interface{vx, br} {
+		if err := netlink.NetworkLinkUp(iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteNetwork removes the VXLAN interface of a network and its bridge.
+func deleteNetwork(bridge, vxlan string) error {
+	if vxlan != "" {
+		if err := netlink.NetworkLinkDel(vxlan); err != nil {
+			return err
+		}
+	}
+	return netlink.DeleteBridge(bridge)
+}
+
+// createVxlan creates the VXLAN interface of the network vni, without
+// learning: the forwarding entries are set up by the driver, and the ARP
+// requests are answered from the neighbor entries.
+func createVxlan(name string, vni uint32, local net.IP, mtu int) error {
+	port := make([]byte, 2)
+	binary.BigEndian.PutUint16(port, vxlanPort)
+
+	data := attr(iflaInfoData,
+		attr(iflaVxlanID, uint32Bytes(vni)),
+		attr(iflaVxlanLocal, local.To4()),
+		attr(iflaVxlanLearning, []byte{0}),
+		attr(iflaVxlanProxy, []byte{1}),
+		attr(iflaVxlanPort, port),
+	)
+	msg := make([]byte, syscall.SizeofIfInfomsg)
+	msg[0] = syscall.AF_UNSPEC
+	msg = append(msg, attr(syscall.IFLA_IFNAME, append([]byte(name), 0))...)
+	msg = append(msg, attr(syscall.IFLA_MTU, uint32Bytes(uint32(mtu)))...)
+	msg = append(msg, attr(syscall.IFLA_LINKINFO, attr(iflaInfoKind, []byte("vxlan")), data)...)
+	return request(syscall.RTM_NEWLINK, syscall.NLM_F_CREATE|syscall.NLM_F_EXCL, msg)
+}
+
+// addPeer sets up the entries of the remote endpoint e on the VXLAN
+// interface vxlan: the ARP entry of its address, and the forwarding entry
+// of its MAC address to its host.
+func addPeer(vxlan string, e *Endpoint) error {
+	flags := syscall.NLM_F_CREATE | syscall.NLM_F_REPLACE
+	neigh, fdb, err := peerMessages(vxlan, e)
+	if err != nil {
+		return err
+	}
+	if err := request(syscall.RTM_NEWNEIGH, flags, neigh); err != nil {
+		return err
+	}
+	return request(syscall.RTM_NEWNEIGH, flags, fdb)
+}
+
+// deletePeer removes the entries addPeer set up.
+func deletePeer(vxlan string, e *Endpoint) error {
+	neigh, fdb, err := peerMessages(vxlan, e)
+	if err != nil {
+		return err
+	}
+	err = request(syscall.RTM_DELNEIGH, 0, neigh)
+	if err := request(syscall.RTM_DELNEIGH, 0, fdb); err != nil {
+		return err
+	}
+	return err
+}
+
+func peerMessages(vxlan string, e *Endpoint) ([]byte, []byte, error) {
+	iface, err := net.InterfaceByName(vxlan)
+	if err != nil {
+		return nil, nil, err
+	}
+	mac, err := net.ParseMAC(e.MacAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	ip, host := net.ParseIP(e.IP).To4(), net.ParseIP(e.Host).To4()
+	if ip == nil || host == nil {
+		return nil, nil, &net.AddrError{Err: "invalid IPv4 address", Addr: e.IP + " " + e.Host}
+	}
+
+	neigh := append(ndmsg(syscall.AF_INET, iface.Index, 0), attr(ndaDst, ip)...)
+	neigh = append(neigh, attr(ndaLladdr, mac)...)
+	fdb := append(ndmsg(syscall.AF_BRIDGE, iface.Index, ntfSelf), attr(ndaLladdr, mac)...)
+	fdb = append(fdb, attr(ndaDst, host)...)
+	return neigh, fdb, nil
+}
+
+// ndmsg returns a struct ndmsg of a permanent entry.
+func ndmsg(family uint8, index int, flags uint8) []byte {
+	b := make([]byte, 12)
+	b[0] = family
+	*(*int32)(unsafe.Pointer(&b[4])) = int32(index)
+	*(*uint16)(unsafe.Pointer(&b[8])) = nudPermanent
+	b[10] = flags
+	return b
+}
+
+// attr returns a struct rtattr of type typ, whose payload is the
+// concatenation of data.
+func attr(typ int, data ...[]byte) []byte {
+	var payload []byte
+	for _, d := range data {
+		payload = append(payload, d...)
+	}
+	b := make([]byte, syscall.SizeofRtAttr, rtaAlign(syscall.SizeofRtAttr+len(payload)))
+	*(*uint16)(unsafe.Pointer(&b[0])) = uint16(syscall.SizeofRtAttr + len(payload))
+	*(*uint16)(unsafe.Pointer(&b[2])) = uint16(typ)
+	b = append(b, payload...)
+	return b[:cap(b)]
+}
+
+func rtaAlign(length int) int {
+	return (length + syscall.RTA_ALIGNTO - 1) & ^(syscall.RTA_ALIGNTO - 1)
+}
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	*(*uint32)(unsafe.Pointer(&b[0])) = v
+	return b
+}
+
+// request sends a rtnetlink request, and waits for its acknowledgement.
+func request(typ uint16, flags int, data []byte) error {
+	s, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(s)
+	if err := syscall.Bind(s, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	const seq = 1
+	msg := make([]byte, syscall.NLMSG_HDRLEN, syscall.NLMSG_HDRLEN+len(data))
+	*(*syscall.NlMsghdr)(unsafe.Pointer(&msg[0])) = syscall.NlMsghdr{
+		Len:   uint32(syscall.NLMSG_HDRLEN + len(data)),
+		Type:  typ,
+		Flags: uint16(syscall.NLM_F_REQUEST | syscall.NLM_F_ACK | flags),
+		Seq:   seq,
+	}
+	msg = append(msg, data...)
+	if err := syscall.Sendto(s, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return err
+	}
+
+	buf := make([]byte, syscall.Getpagesize())
+	for {
+		n, _, err := syscall.Recvfrom(s, buf, 0)
+		if err != nil {
+			return err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != seq || m.Header.Type != syscall.NLMSG_ERROR {
+				continue
+			}
+			if errno := -*(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+				return syscall.Errno(errno)
+			}
+			return nil
+		}
+	}
+}
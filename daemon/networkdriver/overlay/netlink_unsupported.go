@@ -0,0 +1,26 @@
+// +build !linux
+
+package overlay
+
+import (
+	"errors"
+	"net"
+)
+
+var errUnsupported = errors.New("Overlay networks are not supported on this platform")
+
+func createNetwork(bridge, vxlan string, vni uint32, local net.IP, mtu int) error {
+	return errUnsupported
+}
+
+func deleteNetwork(bridge, vxlan string) error {
+	return errUnsupported
+}
+
+func addPeer(vxlan string, e *Endpoint) error {
+	return errUnsupported
+}
+
+func deletePeer(vxlan string, e *Endpoint) error {
+	return errUnsupported
+}
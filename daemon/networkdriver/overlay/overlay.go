@@ -0,0 +1,444 @@
+// Package overlay implements the networks that span the hosts of a
+// cluster: the containers of a network are attached to a bridge on each
+// host, and the bridges are connected by VXLAN tunnels.
+//
+// The daemons share the state of the networks in a key-value store: the
+// subnet and the VXLAN ID of every network, and the endpoints of the
+// containers, with the address of their host, which expire unless their
+// host refreshes them. Every host sets up the forwarding and ARP entries
+// of the remote endpoints of the networks it has containers on, there's no
+// learning nor flooding.
+package overlay
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver"
+	"github.com/docker/docker/pkg/kvstore"
+	"github.com/docker/docker/pkg/stringid"
+)
+
+// Overhead is the size of the headers VXLAN adds to the packets of the
+// containers, to take off their MTU.
+const Overhead = 50
+
+const (
+	// The directories of the driver in the store: the subnets and the
+	// VXLAN IDs taken, with the ID of their network, and the endpoints of
+	// every network by IP address.
+	subnetsKey   = "overlay/subnet"
+	vnisKey      = "overlay/vni"
+	endpointsKey = "overlay/endpoint"
+
+	// endpointTTL is how long the endpoints of a host outlive it in the
+	// store, the host writing them again every third of it.
+	endpointTTL = 30 * time.Second
+
+	vxlanPort = 4789
+	firstVNI  = 256
+	maxVNI    = 1<<24 - 1
+)
+
+// subnetPool is where the subnets of the networks are picked, a /24 each.
+var subnetPool = &net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}
+
+// Endpoint is the interface of a container on a network.
+type Endpoint struct {
+	ContainerID string
+	// Name is the name of the container, which the other containers of
+	// the network resolve, as well as its Aliases.
+	Name       string
+	Aliases    []string `json:",omitempty"`
+	IP         string
+	MacAddress string
+	// Host is the address of the host of the container, the remote end of
+	// the tunnels to it.
+	Host string
+}
+
+// Driver sets up the networks the containers of the host are on.
+type Driver struct {
+	store  kvstore.Store
+	hostIP net.IP
+	mtu    int
+	notify func(networkID string)
+
+	sync.Mutex
+	networks map[string]*network
+}
+
+// network is a network the host has containers on.
+type network struct {
+	joined int
+	// peers are the endpoints on the other hosts, whose entries are set
+	// up, by IP address.
+	peers map[string]*Endpoint
+	// endpoints are the endpoints on the host, which it keeps in the
+	// store, by IP address.
+	endpoints map[string]*Endpoint
+	stopCh    chan struct{}
+}
+
+// New returns a driver sharing the state of the networks in store. hostIP
+// is the address the other hosts reach the tunnels of the host at, and mtu
+// the one of its network. notify is called with the ID of a network every
+// time its endpoints change.
+func New(store kvstore.Store, hostIP net.IP, mtu int, notify func(networkID string)) *Driver {
+	return &Driver{
+		store:    store,
+		hostIP:   hostIP,
+		mtu:      mtu,
+		notify:   notify,
+		networks: make(map[string]*network),
+	}
+}
+
+// BridgeName returns the name of the bridge of a network on the host.
+func BridgeName(networkID string) string {
+	return "ov-" + stringid.TruncateID(networkID)
+}
+
+func vxlanName(networkID string) string {
+	return "vx-" + stringid.TruncateID(networkID)
+}
+
+// AllocateNetwork reserves a subnet and a VXLAN ID for a new network,
+// across the cluster.
+func (d *Driver) AllocateNetwork(id string) (*net.IPNet, uint32, error) {
+	subnet, err := d.allocateSubnet(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	vni, err := d.allocateVNI(id)
+	if err != nil {
+		d.store.Delete(path.Join(subnetsKey, subnet.IP.String()))
+		return nil, 0, err
+	}
+	return subnet, vni, nil
+}
+
+// taken returns the names of the keys of directory.
+func (d *Driver) taken(directory string) (map[string]bool, error) {
+	pairs, err := d.store.List(directory)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(pairs))
+	for _, p := range pairs {
+		names[path.Base(p.Key)] = true
+	}
+	return names, nil
+}
+
+func (d *Driver) allocateSubnet(id string) (*net.IPNet, error) {
+	taken, err := d.taken(subnetsKey)
+	if err != nil {
+		return nil, err
+	}
+	ones, _ := subnetPool.Mask.Size()
+	base := binary.BigEndian.Uint32(subnetPool.IP)
+	for i := uint32(0); i < 1<<uint(24-ones); i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, base+i<<8)
+		subnet := &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}
+		if taken[ip.String()] || networkdriver.CheckRouteOverlaps(subnet) != nil {
+			continue
+		}
+		_, err := d.store.AtomicPut(path.Join(subnetsKey, ip.String()), []byte(id), nil, nil)
+		if err == kvstore.ErrKeyModified {
+			// Another daemon took it in the meantime.
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return subnet, nil
+	}
+	return nil, fmt.Errorf("No available subnet for an overlay network in %s", subnetPool)
+}
+
+func (d *Driver) allocateVNI(id string) (uint32, error) {
+	taken, err := d.taken(vnisKey)
+	if err != nil {
+		return 0, err
+	}
+	for vni := uint32(firstVNI); vni <= maxVNI; vni++ {
+		name := strconv.FormatUint(uint64(vni), 10)
+		if taken[name] {
+			continue
+		}
+		_, err := d.store.AtomicPut(path.Join(vnisKey, name), []byte(id), nil, nil)
+		if err == kvstore.ErrKeyModified {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		return vni, nil
+	}
+	return 0, fmt.Errorf("No available VXLAN ID")
+}
+
+// ReleaseNetwork frees the subnet and the VXLAN ID of a network, and
+// forgets its endpoints.
+func (d *Driver) ReleaseNetwork(id string, subnet *net.IPNet, vni uint32) error {
+	if err := d.store.DeleteTree(path.Join(endpointsKey, id)); err != nil {
+		return err
+	}
+	if err := d.store.Delete(path.Join(vnisKey, strconv.FormatUint(uint64(vni), 10))); err != nil {
+		return err
+	}
+	return d.store.Delete(path.Join(subnetsKey, subnet.IP.String()))
+}
+
+// Endpoints returns the endpoints of a network, on every host.
+func (d *Driver) Endpoints(id string) ([]*Endpoint, error) {
+	pairs, err := d.store.List(path.Join(endpointsKey, id))
+	if err != nil {
+		return nil, err
+	}
+	return decodeEndpoints(pairs), nil
+}
+
+func decodeEndpoints(pairs []*kvstore.KVPair) []*Endpoint {
+	var endpoints []*Endpoint
+	for _, p := range pairs {
+		e := &Endpoint{}
+		if err := json.Unmarshal(p.Value, e); err != nil {
+			logrus.Debugf("Ignoring the endpoint %s: %v", p.Key, err)
+			continue
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// Join gives the container an endpoint on a network, whose address is ip
+// if it isn't empty, and sets up the network on the host if it's its first
+// endpoint there, with the MTU mtu, 0 for the one of the driver.
+func (d *Driver) Join(id string, subnet *net.IPNet, vni uint32, mtu int, containerID, name string, aliases []string, ip string) (*Endpoint, error) {
+	e := &Endpoint{ContainerID: containerID, Name: name, Aliases: aliases, Host: d.hostIP.String()}
+	if ip != "" {
+		if err := d.requestIP(id, e, ip); err != nil {
+			return nil, err
+		}
+	} else if err := d.allocateIP(id, subnet, e); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	n, exists := d.networks[id]
+	if !exists {
+		var err error
+		if n, err = d.setupNetwork(id, vni, mtu); err != nil {
+			d.store.Delete(path.Join(endpointsKey, id, e.IP))
+			return nil, err
+		}
+		d.networks[id] = n
+	}
+	n.joined++
+	n.endpoints[e.IP] = e
+	return e, nil
+}
+
+// requestIP takes ip for e, unless another container has it. It's the
+// container's already when the daemon restores it.
+func (d *Driver) requestIP(id string, e *Endpoint, ip string) error {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return fmt.Errorf("Invalid IP address %s", ip)
+	}
+	e.IP = parsed.String()
+	e.MacAddress = generateMacAddr(parsed).String()
+	return d.putEndpoint(id, e)
+}
+
+// putEndpoint writes e to the store, with a TTL, unless another container
+// has its address.
+func (d *Driver) putEndpoint(id string, e *Endpoint) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	key := path.Join(endpointsKey, id, e.IP)
+	options := &kvstore.WriteOptions{TTL: endpointTTL}
+	if _, err := d.store.AtomicPut(key, value, nil, options); err != kvstore.ErrKeyModified {
+		return err
+	}
+	previous, err := d.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if owner := decodeEndpoints([]*kvstore.KVPair{previous}); len(owner) == 0 || owner[0].ContainerID != e.ContainerID {
+		return fmt.Errorf("IP address %s is already in use on the network", e.IP)
+	}
+	_, err = d.store.AtomicPut(key, value, previous, options)
+	return err
+}
+
+// allocateIP takes the first free address of subnet for e.
+func (d *Driver) allocateIP(id string, subnet *net.IPNet, e *Endpoint) error {
+	taken, err := d.taken(path.Join(endpointsKey, id))
+	if err != nil {
+		return err
+	}
+	first, last := networkdriver.NetworkRange(subnet)
+	for i := binary.BigEndian.Uint32(first.To4()) + 1; i < binary.BigEndian.Uint32(last.To4()); i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, i)
+		if taken[ip.String()] {
+			continue
+		}
+		e.IP = ip.String()
+		e.MacAddress = generateMacAddr(ip).String()
+		value, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = d.store.AtomicPut(path.Join(endpointsKey, id, e.IP), value, nil, &kvstore.WriteOptions{TTL: endpointTTL})
+		if err == kvstore.ErrKeyModified {
+			continue
+		}
+		return err
+	}
+	return fmt.Errorf("No available IP address in the subnet %s of the network", subnet)
+}
+
+// Leave removes the endpoint at ip from a network, and tears the network
+// down on the host if it was its last endpoint there.
+func (d *Driver) Leave(id, ip string) error {
+	d.Lock()
+	defer d.Unlock()
+	// Under the lock, for refreshEndpoints not to write it back.
+	err := d.store.Delete(path.Join(endpointsKey, id, ip))
+	n, exists := d.networks[id]
+	if !exists {
+		return err
+	}
+	delete(n.endpoints, ip)
+	if n.joined--; n.joined == 0 {
+		close(n.stopCh)
+		delete(d.networks, id)
+		if err := d.teardownNetwork(id); err != nil {
+			logrus.Errorf("Unable to remove the overlay network %s from the host: %v", id, err)
+		}
+	}
+	return err
+}
+
+// setupNetwork creates the bridge and the VXLAN interface of a network on
+// the host, unless they survived the daemon, and starts watching its
+// endpoints.
+func (d *Driver) setupNetwork(id string, vni uint32, mtu int) (*network, error) {
+	if mtu == 0 {
+		mtu = d.mtu - Overhead
+	}
+	if err := createNetwork(BridgeName(id), vxlanName(id), vni, d.hostIP, mtu); err != nil {
+		return nil, err
+	}
+	n := &network{
+		peers:     make(map[string]*Endpoint),
+		endpoints: make(map[string]*Endpoint),
+		stopCh:    make(chan struct{}),
+	}
+	ch, err := d.store.WatchTree(path.Join(endpointsKey, id), n.stopCh)
+	if err != nil {
+		d.teardownNetwork(id)
+		return nil, err
+	}
+	go d.watchEndpoints(id, n, ch)
+	go d.refreshEndpoints(id, n)
+	return n, nil
+}
+
+func (d *Driver) teardownNetwork(id string) error {
+	return deleteNetwork(BridgeName(id), vxlanName(id))
+}
+
+// watchEndpoints keeps the entries of the remote endpoints of a network
+// up to date.
+func (d *Driver) watchEndpoints(id string, n *network, ch <-chan []*kvstore.KVPair) {
+	for pairs := range ch {
+		d.Lock()
+		select {
+		case <-n.stopCh:
+			// The network is gone from the host, with its entries.
+			d.Unlock()
+			return
+		default:
+		}
+		d.updatePeers(id, n, decodeEndpoints(pairs))
+		d.Unlock()
+
+		if d.notify != nil {
+			d.notify(id)
+		}
+	}
+}
+
+// refreshEndpoints writes the endpoints of the host on a network to the
+// store again before their TTL runs out, until the network is gone from
+// the host.
+func (d *Driver) refreshEndpoints(id string, n *network) {
+	for {
+		select {
+		case <-n.stopCh:
+			return
+		case <-time.After(endpointTTL / 3):
+		}
+		d.Lock()
+		for _, e := range n.endpoints {
+			if err := d.putEndpoint(id, e); err != nil {
+				logrus.Errorf("Unable to refresh the endpoint %s of the overlay network %s: %v", e.IP, id, err)
+			}
+		}
+		d.Unlock()
+	}
+}
+
+func (d *Driver) updatePeers(id string, n *network, endpoints []*Endpoint) {
+	vxlan := vxlanName(id)
+	current := make(map[string]*Endpoint)
+	for _, e := range endpoints {
+		if e.Host != d.hostIP.String() {
+			current[e.IP] = e
+		}
+	}
+	for ip, e := range n.peers {
+		if c, exists := current[ip]; exists && c.MacAddress == e.MacAddress && c.Host == e.Host {
+			continue
+		}
+		if err := deletePeer(vxlan, e); err != nil {
+			logrus.Debugf("Unable to remove the entries of %s on %s: %v", ip, vxlan, err)
+		}
+		delete(n.peers, ip)
+	}
+	for ip, e := range current {
+		if _, exists := n.peers[ip]; exists {
+			continue
+		}
+		if err := addPeer(vxlan, e); err != nil {
+			logrus.Errorf("Unable to add the entries of %s on %s: %v", ip, vxlan, err)
+			continue
+		}
+		n.peers[ip] = e
+	}
+}
+
+func generateMacAddr(ip net.IP) net.HardwareAddr {
+	hw := make(net.HardwareAddr, 6)
+	// Locally administered unicast addresses, like the ones of the bridge
+	// driver.
+	hw[0] = 0x02
+	hw[1] = 0x42
+	copy(hw[2:], ip.To4())
+	return hw
+}
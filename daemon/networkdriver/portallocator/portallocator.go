@@ -21,6 +21,7 @@ type ipMapping map[string]protoMap
 var (
 	ErrAllPortsAllocated = errors.New("all ports are allocated")
 	ErrUnknownProtocol   = errors.New("unknown protocol")
+	ErrInvalidPortRange  = errors.New("invalid port range")
 	defaultIP            = net.ParseIP("0.0.0.0")
 )
 
@@ -80,6 +81,24 @@ func New() *PortAllocator {
 	}
 }
 
+// SetRange sets the range of the ports allocated when none is requested,
+// from begin to end.
+func (p *PortAllocator) SetRange(begin, end int) error {
+	if begin < 1 || end > 65535 || begin > end {
+		return ErrInvalidPortRange
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.Begin, p.End = begin, end
+	for _, protomap := range p.ipMap {
+		for _, mapping := range protomap {
+			mapping.begin, mapping.end, mapping.last = begin, end, end
+		}
+	}
+	return nil
+}
+
 func getDynamicPortRange() (start int, end int, err error) {
 	const portRangeKernelParam = "/proc/sys/net/ipv4/ip_local_port_range"
 	portRangeFallback := fmt.Sprintf("using fallback port range %d-%d", DefaultPortRangeStart, DefaultPortRangeEnd)
@@ -104,7 +123,7 @@ func (p *PortAllocator) RequestPort(ip net.IP, proto string, port int) (int, err
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
-	if proto != "tcp" && proto != "udp" {
+	if proto != "tcp" && proto != "udp" && proto != "sctp" {
 		return 0, ErrUnknownProtocol
 	}
 
@@ -115,8 +134,9 @@ func (p *PortAllocator) RequestPort(ip net.IP, proto string, port int) (int, err
 	protomap, ok := p.ipMap[ipstr]
 	if !ok {
 		protomap = protoMap{
-			"tcp": p.newPortMap(),
-			"udp": p.newPortMap(),
+			"tcp":  p.newPortMap(),
+			"udp":  p.newPortMap(),
+			"sctp": p.newPortMap(),
 		}
 
 		p.ipMap[ipstr] = protomap
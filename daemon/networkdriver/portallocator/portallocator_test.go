@@ -93,6 +93,38 @@ func TestUnknowProtocol(t *testing.T) {
 	}
 }
 
+func TestSetRange(t *testing.T) {
+	p := New()
+	if _, err := p.RequestPort(defaultIP, "tcp", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetRange(40000, 40001); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int{40000, 40001} {
+		port, err := p.RequestPort(defaultIP, "tcp", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if port != expected {
+			t.Fatalf("Expected port %d got %d", expected, port)
+		}
+	}
+	if _, err := p.RequestPort(defaultIP, "tcp", 0); err != ErrAllPortsAllocated {
+		t.Fatalf("Expected error %s got %v", ErrAllPortsAllocated, err)
+	}
+	// Ports out of the range are still requested explicitly.
+	if _, err := p.RequestPort(defaultIP, "tcp", 8080); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range [][2]int{{0, 100}, {100, 99}, {60000, 70000}} {
+		if err := p.SetRange(r[0], r[1]); err != ErrInvalidPortRange {
+			t.Fatalf("Expected error %s for %d-%d, got %v", ErrInvalidPortRange, r[0], r[1], err)
+		}
+	}
+}
+
 func TestAllocateAllPorts(t *testing.T) {
 	p := New()
 
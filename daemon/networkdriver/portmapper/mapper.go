@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 
 	"github.com/Sirupsen/logrus"
@@ -16,6 +17,8 @@ type mapping struct {
 	userlandProxy UserlandProxy
 	host          net.Addr
 	container     net.Addr
+	containerIPv6 net.IP // Where the port is forwarded to on IPv6, if anywhere
+	count         int    // The number of ports of the range, from the ports of host and container
 }
 
 var NewProxy = NewProxyCommand
@@ -24,10 +27,28 @@ var (
 	ErrUnknownBackendAddressType = errors.New("unknown container address type not supported")
 	ErrPortMappedForIP           = errors.New("port is already mapped to ip")
 	ErrPortNotMapped             = errors.New("port is not mapped")
+	ErrSCTPWithoutIptables       = errors.New("SCTP ports are only published with iptables")
+	ErrRangeWithoutHostPort      = errors.New("a range of ports is only mapped to given ports of the host")
 )
 
+// SCTPAddr is the address of an SCTP end point, which the net package
+// doesn't have.
+type SCTPAddr struct {
+	IP   net.IP
+	Port int
+}
+
+func (a *SCTPAddr) Network() string {
+	return "sctp"
+}
+
+func (a *SCTPAddr) String() string {
+	return net.JoinHostPort(a.IP.String(), strconv.Itoa(a.Port))
+}
+
 type PortMapper struct {
-	chain *iptables.Chain
+	chain  *iptables.Chain
+	chain6 *iptables.Chain
 
 	// udp:ip:port
 	currentMappings map[string]*mapping
@@ -51,80 +72,106 @@ func (pm *PortMapper) SetIptablesChain(c *iptables.Chain) {
 	pm.chain = c
 }
 
+// SetIp6tablesChain sets the ip6tables chain of the mappings on IPv6
+// addresses of the host.
+func (pm *PortMapper) SetIp6tablesChain(c *iptables.Chain) {
+	pm.chain6 = c
+}
+
 func (pm *PortMapper) Map(container net.Addr, hostIP net.IP, hostPort int) (host net.Addr, err error) {
+	return pm.MapIPv6(container, nil, hostIP, hostPort)
+}
+
+// MapIPv6 is Map for a container that also has the IPv6 address
+// containerIPv6: a port mapped on all the IPv4 addresses of the host is
+// forwarded to it from all the IPv6 addresses of the host too.
+func (pm *PortMapper) MapIPv6(container net.Addr, containerIPv6 net.IP, hostIP net.IP, hostPort int) (host net.Addr, err error) {
+	return pm.MapRange(container, containerIPv6, hostIP, hostPort, 1)
+}
+
+// MapRange is MapIPv6 for the count ports from the port of container,
+// mapped to the count ports of the host from hostPort, which is required
+// for a range. The range has a single userland proxy, and a single set of
+// iptables rules if it's mapped to the same ports of the host.
+func (pm *PortMapper) MapRange(container net.Addr, containerIPv6 net.IP, hostIP net.IP, hostPort, count int) (host net.Addr, err error) {
 	pm.lock.Lock()
 	defer pm.lock.Unlock()
 
 	var (
-		m                 *mapping
-		proto             string
-		allocatedHostPort int
-		proxy             UserlandProxy
+		proto                      string
+		allocated                  []int
+		proxy                      UserlandProxy
+		containerIP, containerPort = getIPAndPort(container)
 	)
 
 	switch container.(type) {
 	case *net.TCPAddr:
 		proto = "tcp"
-		if allocatedHostPort, err = pm.Allocator.RequestPort(hostIP, proto, hostPort); err != nil {
-			return nil, err
-		}
-
-		m = &mapping{
-			proto:     proto,
-			host:      &net.TCPAddr{IP: hostIP, Port: allocatedHostPort},
-			container: container,
-		}
-
-		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.TCPAddr).IP, container.(*net.TCPAddr).Port)
 	case *net.UDPAddr:
 		proto = "udp"
-		if allocatedHostPort, err = pm.Allocator.RequestPort(hostIP, proto, hostPort); err != nil {
-			return nil, err
-		}
-
-		m = &mapping{
-			proto:     proto,
-			host:      &net.UDPAddr{IP: hostIP, Port: allocatedHostPort},
-			container: container,
+	case *SCTPAddr:
+		proto = "sctp"
+		// There is no userland proxy for SCTP, its ports are only
+		// forwarded by iptables.
+		if pm.chainOf(hostIP) == nil {
+			return nil, ErrSCTPWithoutIptables
 		}
-
-		proxy = NewProxy(proto, hostIP, allocatedHostPort, container.(*net.UDPAddr).IP, container.(*net.UDPAddr).Port)
 	default:
 		return nil, ErrUnknownBackendAddressType
 	}
+	if count > 1 && hostPort == 0 {
+		return nil, ErrRangeWithoutHostPort
+	}
 
-	// release the allocated port on any further error during return.
+	// release the allocated ports on any further error during return.
 	defer func() {
 		if err != nil {
-			pm.Allocator.ReleasePort(hostIP, proto, allocatedHostPort)
+			for _, port := range allocated {
+				pm.Allocator.ReleasePort(hostIP, proto, port)
+			}
 		}
 	}()
 
+	for i := 0; i < count; i++ {
+		var port int
+		if port, err = pm.Allocator.RequestPort(hostIP, proto, hostPort+i); err != nil {
+			return nil, err
+		}
+		allocated = append(allocated, port)
+	}
+
+	m := &mapping{
+		proto:     proto,
+		host:      newAddr(proto, hostIP, allocated[0]),
+		container: container,
+		count:     count,
+	}
+	if hostIP.Equal(net.IPv4zero) {
+		m.containerIPv6 = containerIPv6
+	}
+	if proto == "sctp" {
+		proxy = &noProxy{}
+	} else {
+		proxy = NewProxy(proto, hostIP, allocated[0], containerIP, containerPort, count)
+	}
+
 	key := getKey(m.host)
 	if _, exists := pm.currentMappings[key]; exists {
 		return nil, ErrPortMappedForIP
 	}
 
-	containerIP, containerPort := getIPAndPort(m.container)
-	if err := pm.forward(iptables.Append, m.proto, hostIP, allocatedHostPort, containerIP.String(), containerPort); err != nil {
+	if err := pm.forwardMapping(iptables.Append, m); err != nil {
 		return nil, err
 	}
 
-	cleanup := func() error {
+	cleanup := func() {
 		// need to undo the iptables rules before we return
 		proxy.Stop()
-		pm.forward(iptables.Delete, m.proto, hostIP, allocatedHostPort, containerIP.String(), containerPort)
-		if err := pm.Allocator.ReleasePort(hostIP, m.proto, allocatedHostPort); err != nil {
-			return err
-		}
-
-		return nil
+		pm.forwardMapping(iptables.Delete, m)
 	}
 
 	if err := proxy.Start(); err != nil {
-		if err := cleanup(); err != nil {
-			return nil, fmt.Errorf("Error during port allocation cleanup: %v", err)
-		}
+		cleanup()
 		return nil, err
 	}
 	m.userlandProxy = proxy
@@ -146,27 +193,53 @@ func (pm *PortMapper) Unmap(host net.Addr) error {
 
 	delete(pm.currentMappings, key)
 
-	containerIP, containerPort := getIPAndPort(data.container)
-	hostIP, hostPort := getIPAndPort(data.host)
-	if err := pm.forward(iptables.Delete, data.proto, hostIP, hostPort, containerIP.String(), containerPort); err != nil {
+	if err := pm.forwardMapping(iptables.Delete, data); err != nil {
 		logrus.Errorf("Error on iptables delete: %s", err)
 	}
 
-	switch a := host.(type) {
-	case *net.TCPAddr:
-		return pm.Allocator.ReleasePort(a.IP, "tcp", a.Port)
-	case *net.UDPAddr:
-		return pm.Allocator.ReleasePort(a.IP, "udp", a.Port)
+	hostIP, hostPort := getIPAndPort(host)
+	for i := 0; i < data.count; i++ {
+		if err := pm.Allocator.ReleasePort(hostIP, data.proto, hostPort+i); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// ReMapAll adds the iptables rules of the current mappings again, once
+// they were flushed.
+func (pm *PortMapper) ReMapAll() {
+	pm.lock.Lock()
+	defer pm.lock.Unlock()
+
+	for key, m := range pm.currentMappings {
+		// Some of them may have been left.
+		pm.forwardMapping(iptables.Delete, m)
+		if err := pm.forwardMapping(iptables.Append, m); err != nil {
+			logrus.Errorf("Unable to add the iptables rules of port mapping %s again: %s", key, err)
+		}
+	}
+}
+
+// newAddr returns the address of port on ip for proto.
+func newAddr(proto string, ip net.IP, port int) net.Addr {
+	switch proto {
+	case "udp":
+		return &net.UDPAddr{IP: ip, Port: port}
+	case "sctp":
+		return &SCTPAddr{IP: ip, Port: port}
+	}
+	return &net.TCPAddr{IP: ip, Port: port}
+}
+
 func getKey(a net.Addr) string {
 	switch t := a.(type) {
 	case *net.TCPAddr:
 		return fmt.Sprintf("%s:%d/%s", t.IP.String(), t.Port, "tcp")
 	case *net.UDPAddr:
 		return fmt.Sprintf("%s:%d/%s", t.IP.String(), t.Port, "udp")
+	case *SCTPAddr:
+		return fmt.Sprintf("%s:%d/%s", t.IP.String(), t.Port, "sctp")
 	}
 	return ""
 }
@@ -177,13 +250,45 @@ func getIPAndPort(a net.Addr) (net.IP, int) {
 		return t.IP, t.Port
 	case *net.UDPAddr:
 		return t.IP, t.Port
+	case *SCTPAddr:
+		return t.IP, t.Port
 	}
 	return nil, 0
 }
 
-func (pm *PortMapper) forward(action iptables.Action, proto string, sourceIP net.IP, sourcePort int, containerIP string, containerPort int) error {
-	if pm.chain == nil {
+// forwardMapping appends, or deletes, the iptables rules of m, and the
+// ip6tables ones if it's forwarded on IPv6 too.
+func (pm *PortMapper) forwardMapping(action iptables.Action, m *mapping) error {
+	var (
+		hostIP, hostPort           = getIPAndPort(m.host)
+		containerIP, containerPort = getIPAndPort(m.container)
+	)
+	if err := pm.forward(action, m.proto, hostIP, hostPort, m.count, containerIP.String(), containerPort); err != nil {
+		return err
+	}
+	if m.containerIPv6 == nil {
 		return nil
 	}
-	return pm.chain.Forward(action, sourceIP, sourcePort, proto, containerIP, containerPort)
+	err := pm.forward(action, m.proto, net.IPv6unspecified, hostPort, m.count, m.containerIPv6.String(), containerPort)
+	if err != nil && action == iptables.Append {
+		pm.forward(iptables.Delete, m.proto, hostIP, hostPort, m.count, containerIP.String(), containerPort)
+	}
+	return err
+}
+
+func (pm *PortMapper) forward(action iptables.Action, proto string, sourceIP net.IP, sourcePort, count int, containerIP string, containerPort int) error {
+	chain := pm.chainOf(sourceIP)
+	if chain == nil {
+		return nil
+	}
+	return chain.ForwardRange(action, sourceIP, sourcePort, count, proto, containerIP, containerPort)
+}
+
+// chainOf returns the chain of the mappings on ip, nil if ports aren't
+// forwarded by iptables on it.
+func (pm *PortMapper) chainOf(ip net.IP) *iptables.Chain {
+	if ip.To4() == nil {
+		return pm.chain6
+	}
+	return pm.chain
 }
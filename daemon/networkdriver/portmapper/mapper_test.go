@@ -96,6 +96,29 @@ func TestGetTCPKey(t *testing.T) {
 	}
 }
 
+func TestGetSCTPKey(t *testing.T) {
+	addr := &SCTPAddr{IP: net.ParseIP("192.168.1.5"), Port: 2905}
+
+	key := getKey(addr)
+
+	if expected := "192.168.1.5:2905/sctp"; key != expected {
+		t.Fatalf("expected key %s got %s", expected, key)
+	}
+}
+
+func TestMapSCTPWithoutIptables(t *testing.T) {
+	pm := New()
+	container := &SCTPAddr{IP: net.ParseIP("172.17.0.2"), Port: 2905}
+
+	if _, err := pm.Map(container, net.IPv4zero, 2905); err != ErrSCTPWithoutIptables {
+		t.Fatalf("Expected ErrSCTPWithoutIptables, got %v", err)
+	}
+	// The port isn't kept.
+	if port, err := pm.Allocator.RequestPort(net.IPv4zero, "sctp", 2905); err != nil || port != 2905 {
+		t.Fatalf("Expected port 2905 to be available, got %d, %v", port, err)
+	}
+}
+
 func TestGetUDPIPAndPort(t *testing.T) {
 	addr := &net.UDPAddr{IP: net.ParseIP("192.168.1.5"), Port: 53}
 
@@ -147,3 +170,68 @@ func TestMapAllPortsSingleInterface(t *testing.T) {
 		hosts = []net.Addr{}
 	}
 }
+
+func TestMapIPv6(t *testing.T) {
+	pm := New()
+	container := &net.TCPAddr{IP: net.ParseIP("172.16.0.1"), Port: 80}
+	containerIPv6 := net.ParseIP("2001:db8::1")
+
+	// Only the ports mapped on all the addresses of the host are
+	// forwarded on IPv6 too.
+	for _, tc := range []struct {
+		hostIP   string
+		expected net.IP
+	}{
+		{"0.0.0.0", containerIPv6},
+		{"127.0.0.1", nil},
+	} {
+		host, err := pm.MapIPv6(container, containerIPv6, net.ParseIP(tc.hostIP), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := pm.currentMappings[getKey(host)]; !m.containerIPv6.Equal(tc.expected) {
+			t.Fatalf("Expected a mapping on %s to be forwarded to %v on IPv6, got %v", tc.hostIP, tc.expected, m.containerIPv6)
+		}
+		if err := pm.Unmap(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMapRange(t *testing.T) {
+	pm := New()
+	container := &net.TCPAddr{IP: net.ParseIP("172.16.0.1"), Port: 5000}
+	hostIP := net.ParseIP("0.0.0.0")
+
+	if _, err := pm.MapRange(container, nil, hostIP, 0, 3); err != ErrRangeWithoutHostPort {
+		t.Fatalf("Expected ErrRangeWithoutHostPort, got %v", err)
+	}
+
+	host, err := pm.MapRange(container, nil, hostIP, 5000, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pm.currentMappings) != 1 {
+		t.Fatalf("Expected a single mapping for the range, got %d", len(pm.currentMappings))
+	}
+	if _, err := pm.Map(&net.TCPAddr{IP: net.ParseIP("172.16.0.2"), Port: 80}, hostIP, 5002); err == nil {
+		t.Fatal("Port 5002 should be bound by the range but is not")
+	}
+
+	// A range overlapping it gives back the ports it got.
+	if _, err := pm.MapRange(container, nil, hostIP, 4998, 3); err == nil {
+		t.Fatal("Expected an error mapping a range overlapping another")
+	}
+	if _, err := pm.Allocator.RequestPort(hostIP, "tcp", 4998); err != nil {
+		t.Fatalf("Expected port 4998 to be released, got %v", err)
+	}
+
+	if err := pm.Unmap(host); err != nil {
+		t.Fatal(err)
+	}
+	for port := 5000; port <= 5002; port++ {
+		if _, err := pm.Allocator.RequestPort(hostIP, "tcp", port); err != nil {
+			t.Fatalf("Expected port %d to be released, got %v", port, err)
+		}
+	}
+}
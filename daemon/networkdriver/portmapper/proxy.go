@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -37,61 +38,83 @@ type proxyCommand struct {
 // execProxy is the reexec function that is registered to start the userland proxies
 func execProxy() {
 	f := os.NewFile(3, "signal-parent")
-	host, container := parseHostContainerAddrs()
-
-	p, err := proxy.NewProxy(host, container)
-	if err != nil {
-		fmt.Fprintf(f, "1\n%s", err)
-		f.Close()
-		os.Exit(1)
+	hosts, containers := parseHostContainerAddrs()
+
+	proxies := make([]proxy.Proxy, 0, len(hosts))
+	for i := range hosts {
+		p, err := proxy.NewProxy(hosts[i], containers[i])
+		if err != nil {
+			for _, p := range proxies {
+				p.Close()
+			}
+			fmt.Fprintf(f, "1\n%s", err)
+			f.Close()
+			os.Exit(1)
+		}
+		proxies = append(proxies, p)
 	}
-	go handleStopSignals(p)
+	go handleStopSignals(proxies)
 	fmt.Fprint(f, "0\n")
 	f.Close()
 
-	// Run will block until the proxy stops
-	p.Run()
+	// Run will block until the proxies stop
+	var wg sync.WaitGroup
+	for _, p := range proxies {
+		wg.Add(1)
+		go func(p proxy.Proxy) {
+			p.Run()
+			wg.Done()
+		}(p)
+	}
+	wg.Wait()
 }
 
 // parseHostContainerAddrs parses the flags passed on reexec to create the TCP or UDP
-// net.Addrs to map the host and container ports
-func parseHostContainerAddrs() (host net.Addr, container net.Addr) {
+// net.Addrs to map the host and container ports, one for each port of the range
+func parseHostContainerAddrs() (hosts []net.Addr, containers []net.Addr) {
 	var (
 		proto         = flag.String("proto", "tcp", "proxy protocol")
 		hostIP        = flag.String("host-ip", "", "host ip")
 		hostPort      = flag.Int("host-port", -1, "host port")
 		containerIP   = flag.String("container-ip", "", "container ip")
 		containerPort = flag.Int("container-port", -1, "container port")
+		portCount     = flag.Int("port-count", 1, "number of ports of the range")
 	)
 
 	flag.Parse()
 
-	switch *proto {
-	case "tcp":
-		host = &net.TCPAddr{IP: net.ParseIP(*hostIP), Port: *hostPort}
-		container = &net.TCPAddr{IP: net.ParseIP(*containerIP), Port: *containerPort}
-	case "udp":
-		host = &net.UDPAddr{IP: net.ParseIP(*hostIP), Port: *hostPort}
-		container = &net.UDPAddr{IP: net.ParseIP(*containerIP), Port: *containerPort}
-	default:
-		log.Fatalf("unsupported protocol %s", *proto)
+	for i := 0; i < *portCount; i++ {
+		switch *proto {
+		case "tcp":
+			hosts = append(hosts, &net.TCPAddr{IP: net.ParseIP(*hostIP), Port: *hostPort + i})
+			containers = append(containers, &net.TCPAddr{IP: net.ParseIP(*containerIP), Port: *containerPort + i})
+		case "udp":
+			hosts = append(hosts, &net.UDPAddr{IP: net.ParseIP(*hostIP), Port: *hostPort + i})
+			containers = append(containers, &net.UDPAddr{IP: net.ParseIP(*containerIP), Port: *containerPort + i})
+		default:
+			log.Fatalf("unsupported protocol %s", *proto)
+		}
 	}
 
-	return host, container
+	return hosts, containers
 }
 
-func handleStopSignals(p proxy.Proxy) {
+func handleStopSignals(proxies []proxy.Proxy) {
 	s := make(chan os.Signal, 10)
 	signal.Notify(s, os.Interrupt, syscall.SIGTERM, syscall.SIGSTOP)
 
 	for _ = range s {
-		p.Close()
+		for _, p := range proxies {
+			p.Close()
+		}
 
 		os.Exit(0)
 	}
 }
 
-func NewProxyCommand(proto string, hostIP net.IP, hostPort int, containerIP net.IP, containerPort int) UserlandProxy {
+// NewProxyCommand returns the userland proxy of the count ports from
+// hostPort, forwarded to the count ports from containerPort.
+func NewProxyCommand(proto string, hostIP net.IP, hostPort int, containerIP net.IP, containerPort, count int) UserlandProxy {
 	args := []string{
 		userlandProxyCommandName,
 		"-proto", proto,
@@ -99,6 +122,7 @@ func NewProxyCommand(proto string, hostIP net.IP, hostPort int, containerIP net.
 		"-host-port", strconv.Itoa(hostPort),
 		"-container-ip", containerIP.String(),
 		"-container-port", strconv.Itoa(containerPort),
+		"-port-count", strconv.Itoa(count),
 	}
 
 	return &proxyCommand{
@@ -159,3 +183,15 @@ func (p *proxyCommand) Stop() error {
 	}
 	return nil
 }
+
+// noProxy is the userland proxy of the protocols without one, whose ports
+// are only forwarded by iptables.
+type noProxy struct{}
+
+func (p *noProxy) Start() error {
+	return nil
+}
+
+func (p *noProxy) Stop() error {
+	return nil
+}
@@ -0,0 +1,204 @@
+// Package resolver implements the DNS server embedded in the daemon, which
+// resolves the names of the containers of the user-defined networks for the
+// containers on them, and forwards their other queries to the nameservers
+// they would use without it.
+//
+// A server listens on an address the containers reach, the gateway of their
+// network, and tells its clients apart by their address: it only answers
+// the containers its Backend knows, so that it isn't an open resolver for
+// the host.
+package resolver
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/miekg/dns"
+)
+
+const (
+	// Port is the port servers listen on.
+	Port = "53"
+
+	// ttl is the time to live of the records of containers, short since
+	// their addresses change when they restart.
+	ttl = 10
+
+	forwardTimeout = 5 * time.Second
+)
+
+// Backend knows the clients of a server.
+type Backend interface {
+	// Client returns the client at ip, nil if the server doesn't answer
+	// its queries.
+	Client(ip net.IP) Client
+}
+
+// Client is a container whose queries a server answers.
+type Client interface {
+	// ResolveName returns the addresses of the container named name for
+	// the client, nil if there's no such container on its networks.
+	ResolveName(name string) []net.IP
+
+	// ResolveIP returns the name of the container at ip for the client,
+	// empty if there's no such container on its networks.
+	ResolveIP(ip net.IP) string
+
+	// Upstreams returns the nameservers, as host:port, to forward the
+	// queries of the client about other names to.
+	Upstreams() []string
+}
+
+// Server is a DNS server listening on UDP and TCP.
+type Server struct {
+	backend Backend
+	udp     *dns.Server
+	tcp     *dns.Server
+}
+
+// Listen starts a server on addr, a host:port address, whose clients are
+// the ones of backend. A port 0 is a free port, the same for UDP and TCP.
+func Listen(addr string, backend Backend) (*Server, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		pc.Close()
+		return nil, err
+	}
+	var (
+		s       = &Server{backend: backend}
+		started = make(chan struct{}, 2)
+		notify  = func() { started <- struct{}{} }
+	)
+	s.udp = &dns.Server{PacketConn: pc, Handler: s, NotifyStartedFunc: notify}
+	s.tcp = &dns.Server{Listener: l, Handler: s, NotifyStartedFunc: notify}
+	for _, srv := range []*dns.Server{s.udp, s.tcp} {
+		go func(srv *dns.Server) {
+			if err := srv.ActivateAndServe(); err != nil {
+				logrus.Debugf("DNS server on %s stopped: %v", addr, err)
+			}
+		}(srv)
+	}
+	// Close can't stop them before they started.
+	<-started
+	<-started
+	return s, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.udp.PacketConn.LocalAddr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() {
+	s.udp.Shutdown()
+	s.tcp.Shutdown()
+}
+
+// ServeDNS answers a query.
+func (s *Server) ServeDNS(w dns.ResponseWriter, query *dns.Msg) {
+	var ip net.IP
+	switch addr := w.RemoteAddr().(type) {
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	}
+	client := s.backend.Client(ip)
+
+	var resp *dns.Msg
+	switch {
+	case client == nil:
+		resp = new(dns.Msg)
+		resp.SetRcode(query, dns.RcodeRefused)
+	case len(query.Question) == 1:
+		resp = s.resolve(client, query)
+	}
+	if resp == nil && client != nil {
+		resp = s.forward(client, query, w.RemoteAddr().Network())
+	}
+	if resp == nil {
+		resp = new(dns.Msg)
+		resp.SetRcode(query, dns.RcodeServerFailure)
+	}
+	if err := w.WriteMsg(resp); err != nil {
+		logrus.Debugf("Unable to answer the DNS query of %s: %v", ip, err)
+	}
+}
+
+// resolve answers query if it's about a container, and returns nil if it
+// isn't.
+func (s *Server) resolve(client Client, query *dns.Msg) *dns.Msg {
+	q := query.Question[0]
+	if q.Qclass != dns.ClassINET {
+		return nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(q.Name, "."))
+	header := dns.RR_Header{Name: q.Name, Rrtype: q.Qtype, Class: dns.ClassINET, Ttl: ttl}
+
+	resp := new(dns.Msg)
+	resp.SetReply(query)
+	resp.Authoritative = true
+	resp.RecursionAvailable = true
+	switch q.Qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		ips := client.ResolveName(name)
+		if len(ips) == 0 {
+			return nil
+		}
+		for _, ip := range ips {
+			if ip4 := ip.To4(); ip4 != nil && q.Qtype == dns.TypeA {
+				resp.Answer = append(resp.Answer, &dns.A{Hdr: header, A: ip4})
+			} else if ip4 == nil && q.Qtype == dns.TypeAAAA {
+				resp.Answer = append(resp.Answer, &dns.AAAA{Hdr: header, AAAA: ip})
+			}
+		}
+	case dns.TypePTR:
+		ip := reverseIP(name)
+		if ip == nil {
+			return nil
+		}
+		ptr := client.ResolveIP(ip)
+		if ptr == "" {
+			return nil
+		}
+		resp.Answer = append(resp.Answer, &dns.PTR{Hdr: header, Ptr: dns.Fqdn(ptr)})
+	default:
+		return nil
+	}
+	return resp
+}
+
+// reverseIP returns the IPv4 address of name, a name of the in-addr.arpa
+// domain, nil if it isn't one.
+func reverseIP(name string) net.IP {
+	labels := strings.Split(strings.TrimSuffix(name, ".in-addr.arpa"), ".")
+	if len(labels) != 4 || !strings.HasSuffix(name, ".in-addr.arpa") {
+		return nil
+	}
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return net.ParseIP(strings.Join(labels, ".")).To4()
+}
+
+// forward sends query to the upstream nameservers of client in turn, over
+// proto, and returns the first response, nil if none answered.
+func (s *Server) forward(client Client, query *dns.Msg, proto string) *dns.Msg {
+	c := &dns.Client{Net: proto, Timeout: forwardTimeout}
+	for _, upstream := range client.Upstreams() {
+		resp, _, err := c.Exchange(query, upstream)
+		if err != nil {
+			logrus.Debugf("Unable to forward DNS query to %s: %v", upstream, err)
+			continue
+		}
+		return resp
+	}
+	return nil
+}
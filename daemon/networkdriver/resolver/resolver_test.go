@@ -0,0 +1,149 @@
+package resolver
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+// fakeClient knows the container web, at 172.18.0.2 and 2001:db8::2.
+type fakeClient struct {
+	upstreams []string
+}
+
+func (c *fakeClient) ResolveName(name string) []net.IP {
+	if name == "web" {
+		return []net.IP{net.ParseIP("172.18.0.2"), net.ParseIP("2001:db8::2")}
+	}
+	return nil
+}
+
+func (c *fakeClient) ResolveIP(ip net.IP) string {
+	if ip.Equal(net.ParseIP("172.18.0.2")) {
+		return "web"
+	}
+	return ""
+}
+
+func (c *fakeClient) Upstreams() []string {
+	return c.upstreams
+}
+
+type fakeBackend struct {
+	client *fakeClient
+}
+
+func (b *fakeBackend) Client(ip net.IP) Client {
+	if b.client == nil || !ip.IsLoopback() {
+		return nil
+	}
+	return b.client
+}
+
+// startUpstream starts a nameserver that answers any A query with
+// 192.0.2.1.
+func startUpstream(t *testing.T) *dns.Server {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	s := &dns.Server{PacketConn: pc, NotifyStartedFunc: func() { close(started) }}
+	s.Handler = dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(r)
+		resp.Answer = append(resp.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   net.ParseIP("192.0.2.1"),
+		})
+		w.WriteMsg(resp)
+	})
+	go s.ActivateAndServe()
+	<-started
+	return s
+}
+
+func query(t *testing.T, s *Server, proto, name string, qtype uint16) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetQuestion(name, qtype)
+	c := &dns.Client{Net: proto}
+	resp, _, err := c.Exchange(m, s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestResolve(t *testing.T) {
+	s, err := Listen("127.0.0.1:0", &fakeBackend{client: &fakeClient{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, proto := range []string{"udp", "tcp"} {
+		resp := query(t, s, proto, "Web.", dns.TypeA)
+		if resp.Rcode != dns.RcodeSuccess || len(resp.Answer) != 1 {
+			t.Fatalf("Expected an answer over %s, got %v", proto, resp)
+		}
+		if a, ok := resp.Answer[0].(*dns.A); !ok || !a.A.Equal(net.ParseIP("172.18.0.2")) {
+			t.Fatalf("Expected web at 172.18.0.2, got %v", resp.Answer[0])
+		}
+	}
+
+	resp := query(t, s, "udp", "web.", dns.TypeAAAA)
+	if len(resp.Answer) != 1 || !resp.Answer[0].(*dns.AAAA).AAAA.Equal(net.ParseIP("2001:db8::2")) {
+		t.Fatalf("Expected web at 2001:db8::2, got %v", resp.Answer)
+	}
+
+	resp = query(t, s, "udp", "2.0.18.172.in-addr.arpa.", dns.TypePTR)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.PTR).Ptr != "web." {
+		t.Fatalf("Expected 172.18.0.2 to be web, got %v", resp.Answer)
+	}
+
+	// Without upstream nameservers, the server can't answer the others.
+	if resp = query(t, s, "udp", "example.com.", dns.TypeA); resp.Rcode != dns.RcodeServerFailure {
+		t.Fatalf("Expected SERVFAIL, got %v", resp)
+	}
+}
+
+func TestForward(t *testing.T) {
+	upstream := startUpstream(t)
+	defer upstream.Shutdown()
+
+	client := &fakeClient{upstreams: []string{"127.0.0.1:1", upstream.PacketConn.LocalAddr().String()}}
+	s, err := Listen("127.0.0.1:0", &fakeBackend{client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resp := query(t, s, "udp", "example.com.", dns.TypeA)
+	if len(resp.Answer) != 1 || !resp.Answer[0].(*dns.A).A.Equal(net.ParseIP("192.0.2.1")) {
+		t.Fatalf("Expected the answer of the upstream nameserver, got %v", resp)
+	}
+}
+
+func TestRefuseUnknownClients(t *testing.T) {
+	s, err := Listen("127.0.0.1:0", &fakeBackend{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if resp := query(t, s, "udp", "web.", dns.TypeA); resp.Rcode != dns.RcodeRefused || len(resp.Answer) != 0 {
+		t.Fatalf("Expected the query to be refused, got %v", resp)
+	}
+}
+
+func TestReverseIP(t *testing.T) {
+	if ip := reverseIP("4.3.2.1.in-addr.arpa"); !ip.Equal(net.ParseIP("1.2.3.4")) {
+		t.Fatalf("Expected 1.2.3.4, got %v", ip)
+	}
+	for _, name := range []string{"3.2.1.in-addr.arpa", "4.3.2.1.example.com", "a.3.2.1.in-addr.arpa"} {
+		if ip := reverseIP(name); ip != nil {
+			t.Fatalf("Expected no address for %s, got %v", name, ip)
+		}
+	}
+}
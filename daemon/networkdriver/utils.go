@@ -1,9 +1,11 @@
 package networkdriver
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/docker/libcontainer/netlink"
 )
@@ -116,3 +118,41 @@ func GetDefaultRouteIface() (*net.Interface, error) {
 	}
 	return nil, ErrNoDefaultRoute
 }
+
+// GenerateMacAddr generates an IEEE802 compliant MAC address from the given
+// IP address, whose first 2 bytes are prefix.
+//
+// The generator is guaranteed to be consistent: the same IP will always yield the same
+// MAC address. This is to avoid ARP cache issues.
+func GenerateMacAddr(prefix net.HardwareAddr, ip net.IP) net.HardwareAddr {
+	hw := make(net.HardwareAddr, 6)
+
+	// The first 2 bytes are prefix, 02:42 by default, whose first byte has to comply
+	// with these rules (the daemon checks the first one for --mac-address-prefix):
+	// 1. Unicast: Set the least-significant bit to 0.
+	// 2. Address is locally administered: Set the second-least-significant bit (U/L) to 1.
+	// 3. As "small" as possible: The veth address has to be "smaller" than the bridge address.
+	//
+	// The first 24 bits of the MAC represent the Organizationally Unique Identifier (OUI).
+	// Since this address is locally administered, we can do whatever we want as long as
+	// it doesn't conflict with other addresses.
+	copy(hw, prefix)
+
+	// Insert the IP address into the last 32 bits of the MAC address.
+	// This is a simple way to guarantee the address will be consistent and unique.
+	copy(hw[2:], ip.To4())
+
+	return hw
+}
+
+// GenerateMacAddrFromName is GenerateMacAddr for the container named name,
+// whose MAC address stays the same whatever its IP address, on all the
+// networks. The last 32 bits are the start of the SHA-256 of the name, two
+// containers only get the same one by chance.
+func GenerateMacAddrFromName(prefix net.HardwareAddr, name string) net.HardwareAddr {
+	hw := make(net.HardwareAddr, 6)
+	copy(hw, prefix)
+	sum := sha256.Sum256([]byte(strings.TrimPrefix(name, "/")))
+	copy(hw[2:], sum[:4])
+	return hw
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/pkg/directory"
+	"github.com/docker/docker/pkg/parsers/filters"
+)
+
+var acceptedPruneFilterTags = map[string]struct{}{
+	"label": {},
+	"until": {},
+}
+
+// SystemPrune removes the stopped containers, the dangling images and the
+// volumes no container uses, and reports the space it reclaimed.
+func (daemon *Daemon) SystemPrune(job *engine.Job) error {
+	pruneFilters, err := filters.FromParam(job.Getenv("filters"))
+	if err != nil {
+		return err
+	}
+	for name := range pruneFilters {
+		if _, ok := acceptedPruneFilterTags[name]; !ok {
+			return fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
+	var until time.Time
+	if values, ok := pruneFilters["until"]; ok {
+		if until, err = pruneUntil(values, time.Now()); err != nil {
+			return err
+		}
+	}
+	match := func(created time.Time, labels map[string]string) bool {
+		if !until.IsZero() && !created.Before(until) {
+			return false
+		}
+		return pruneFilters.MatchKVList("label", labels)
+	}
+
+	report := &types.SystemPruneReport{
+		ContainersDeleted: []string{},
+		ImagesDeleted:     []types.ImageDelete{},
+		VolumesDeleted:    []string{},
+	}
+
+	// The containers go first, for the images and the volumes they used
+	// to be pruned as well.
+	for _, container := range daemon.List() {
+		if container.IsRunning() || !match(container.Created, container.Config.Labels) {
+			continue
+		}
+		sizeRw, _ := container.GetSize()
+		if err := job.Eng.Job("rm", container.ID).Run(); err != nil {
+			logrus.Warnf("Cannot prune container %s: %s", container.ID, err)
+			continue
+		}
+		report.ContainersDeleted = append(report.ContainersDeleted, container.ID)
+		report.SpaceReclaimed += sizeRw
+	}
+
+	images, err := daemon.Graph().Map()
+	if err != nil {
+		return err
+	}
+	heads, err := daemon.Graph().Heads()
+	if err != nil {
+		return err
+	}
+	refs := daemon.Repositories().ByID()
+	for id, img := range heads {
+		if _, tagged := refs[id]; tagged || !match(img.Created, img.ContainerConfig.Labels) {
+			continue
+		}
+		// An image still used by a container can't be deleted, which is
+		// not worth a warning.
+		if err := daemon.canDeleteImage(id, false); err != nil {
+			continue
+		}
+		list := []types.ImageDelete{}
+		if err := daemon.DeleteImage(job.Eng, id, &list, true, false, false); err != nil {
+			logrus.Warnf("Cannot prune image %s: %s", id, err)
+		}
+		for _, deleted := range list {
+			if img, exists := images[deleted.Deleted]; exists {
+				report.SpaceReclaimed += img.Size
+			}
+		}
+		report.ImagesDeleted = append(report.ImagesDeleted, list...)
+	}
+
+	// Volumes have neither a creation time nor labels, they are only
+	// pruned when no filter is given.
+	if len(pruneFilters) == 0 {
+		for _, v := range daemon.volumes.List() {
+			if v.IsBindMount || len(v.Containers()) > 0 {
+				continue
+			}
+			size, err := directory.Size(v.Path)
+			if err != nil {
+				logrus.Errorf("Failed to compute size of volume %s: %s", v.ID, err)
+			}
+			if err := daemon.volumes.Delete(v.Path); err != nil {
+				logrus.Warnf("Cannot prune volume %s: %s", v.ID, err)
+				continue
+			}
+			report.VolumesDeleted = append(report.VolumesDeleted, v.ID)
+			report.SpaceReclaimed += size
+		}
+	}
+
+	return json.NewEncoder(job.Stdout).Encode(report)
+}
+
+// pruneUntil returns the time the until filter refers to, given either as a
+// duration before now, e.g. 24h, as a unix timestamp or in RFC 3339 format.
+func pruneUntil(values []string, now time.Time) (time.Time, error) {
+	if len(values) != 1 {
+		return time.Time{}, fmt.Errorf("Invalid filter 'until': it takes a single value")
+	}
+	value := values[0]
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid filter 'until': %s is neither a duration nor a timestamp", value)
+}
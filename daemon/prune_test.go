@@ -0,0 +1,30 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneUntil(t *testing.T) {
+	now := time.Unix(1430000000, 0)
+	for value, expected := range map[string]time.Time{
+		"24h":                  now.Add(-24 * time.Hour),
+		"90m":                  now.Add(-90 * time.Minute),
+		"1429000000":           time.Unix(1429000000, 0),
+		"2015-04-25T00:00:00Z": time.Date(2015, 4, 25, 0, 0, 0, 0, time.UTC),
+	} {
+		until, err := pruneUntil([]string{value}, now)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", value, err)
+		}
+		if !until.Equal(expected) {
+			t.Fatalf("Expected %s for %s, got %s", expected, value, until)
+		}
+	}
+
+	for _, values := range [][]string{{"yesterday"}, {"24h", "48h"}} {
+		if _, err := pruneUntil(values, now); err == nil {
+			t.Fatalf("Expected an error for %v", values)
+		}
+	}
+}
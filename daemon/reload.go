@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"github.com/docker/docker/runconfig"
+)
+
+// Reload replaces, while the daemon runs, its labels and the logging driver
+// and options of the containers that don't set theirs.
+func (daemon *Daemon) Reload(labels []string, logConfig runconfig.LogConfig) error {
+	if err := daemon.verifyLogConfig(logConfig); err != nil {
+		return err
+	}
+	daemon.reloadLock.Lock()
+	defer daemon.reloadLock.Unlock()
+	daemon.config.Labels = labels
+	daemon.defaultLogConfig = logConfig
+	return nil
+}
+
+// labels returns the labels of the daemon.
+func (daemon *Daemon) labels() []string {
+	daemon.reloadLock.Lock()
+	defer daemon.reloadLock.Unlock()
+	return daemon.config.Labels
+}
+
+// logConfig returns cfg, with the logging driver of the daemon unless cfg
+// names one. The options of the daemon apply when cfg has none.
+func (daemon *Daemon) logConfig(cfg runconfig.LogConfig) runconfig.LogConfig {
+	daemon.reloadLock.Lock()
+	defer daemon.reloadLock.Unlock()
+	if cfg.Type == "" {
+		cfg.Type = daemon.defaultLogConfig.Type
+		if len(cfg.Config) == 0 && len(daemon.defaultLogConfig.Config) > 0 {
+			cfg.Config = daemon.defaultLogConfig.Config
+		}
+	}
+	return cfg
+}
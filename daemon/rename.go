@@ -44,5 +44,6 @@ func (daemon *Daemon) ContainerRename(job *engine.Job) error {
 		return err
 	}
 
+	container.LogEvent("rename")
 	return nil
 }
@@ -0,0 +1,248 @@
+package daemon
+
+import (
+	"net"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon/networkdriver/resolver"
+	"github.com/docker/docker/pkg/resolvconf"
+)
+
+// startResolver starts the DNS server of n on its gateway, which the
+// containers of n use as their nameserver. The default bridge network gets
+// one for the containers of the overlay networks, which reach it through
+// their interface on it.
+func (daemon *Daemon) startResolver(n *Network) {
+	ip := net.ParseIP(n.Gateway)
+	if ip == nil {
+		return
+	}
+	s, err := resolver.Listen(net.JoinHostPort(ip.String(), resolver.Port), &networkResolver{daemon: daemon, network: n})
+	if err != nil {
+		logrus.Warnf("Unable to start the DNS server of network %s, its containers won't resolve each other's names: %v", n.Name, err)
+		return
+	}
+	daemon.resolversLock.Lock()
+	defer daemon.resolversLock.Unlock()
+	if daemon.resolvers == nil {
+		daemon.resolvers = make(map[string]*resolver.Server)
+	}
+	if old := daemon.resolvers[n.ID]; old != nil {
+		old.Close()
+	}
+	daemon.resolvers[n.ID] = s
+}
+
+// stopResolver stops the DNS server of n, if it has one.
+func (daemon *Daemon) stopResolver(n *Network) {
+	daemon.resolversLock.Lock()
+	defer daemon.resolversLock.Unlock()
+	if s := daemon.resolvers[n.ID]; s != nil {
+		s.Close()
+		delete(daemon.resolvers, n.ID)
+	}
+}
+
+// stopResolvers stops the DNS servers of all the networks.
+func (daemon *Daemon) stopResolvers() {
+	daemon.resolversLock.Lock()
+	defer daemon.resolversLock.Unlock()
+	for id, s := range daemon.resolvers {
+		s.Close()
+		delete(daemon.resolvers, id)
+	}
+}
+
+// resolverAddress returns the address of the DNS server of the containers
+// whose network mode is mode, empty if they don't have one: only the
+// containers of the user-defined networks do.
+func (daemon *Daemon) resolverAddress(mode string) string {
+	n, err := daemon.networks.Get(mode)
+	if err != nil || n.predefined() {
+		return ""
+	}
+	if n.Driver == "overlay" {
+		if n, err = daemon.networks.Get("bridge"); err != nil {
+			return ""
+		}
+	}
+	daemon.resolversLock.Lock()
+	defer daemon.resolversLock.Unlock()
+	if daemon.resolvers[n.ID] == nil {
+		return ""
+	}
+	return n.Gateway
+}
+
+// networkResolver is the backend of the DNS server of a network, whose
+// clients are the containers with an interface on it. They have one from the
+// time their network is allocated, right before they start.
+type networkResolver struct {
+	daemon  *Daemon
+	network *Network
+}
+
+func (r *networkResolver) Client(ip net.IP) resolver.Client {
+	for _, container := range r.daemon.List() {
+		addr := ""
+		container.Lock()
+		if endpoint := container.NetworkSettings.Networks[r.network.Name]; endpoint != nil {
+			addr = endpoint.IPAddress
+		} else if container.NetworkSettings.Bridge == r.network.bridge() {
+			addr = container.NetworkSettings.IPAddress
+		}
+		container.Unlock()
+		if addr != "" && ip.Equal(net.ParseIP(addr)) {
+			return &containerResolver{daemon: r.daemon, container: container}
+		}
+	}
+	return nil
+}
+
+// containerResolver resolves names for a container: the names of the
+// containers on its user-defined networks, looked up on the network of its
+// network mode first.
+type containerResolver struct {
+	daemon    *Daemon
+	container *Container
+}
+
+// networks returns the user-defined networks of the container, in the
+// order they are searched.
+func (r *containerResolver) networks() []*Network {
+	var networks []*Network
+	r.container.Lock()
+	names := r.container.connectedNetworks()
+	mode := r.container.hostConfig.NetworkMode.NetworkName()
+	r.container.Unlock()
+	if len(names) == 0 || names[0] != mode {
+		names = append([]string{mode}, names...)
+	}
+	for _, name := range names {
+		if n, err := r.daemon.networks.Get(name); err == nil && !n.predefined() {
+			networks = append(networks, n)
+		}
+	}
+	return networks
+}
+
+// peer is a container on a network, as the DNS server knows it.
+type peer struct {
+	name    string
+	aliases []string
+	ips     []net.IP
+}
+
+// is returns whether the container is named name on the network.
+func (p *peer) is(name string) bool {
+	if strings.EqualFold(p.name, name) {
+		return true
+	}
+	for _, alias := range p.aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// peers returns the running containers on n, including the ones of the
+// other hosts of the cluster if n is a network of the overlay driver.
+func (r *containerResolver) peers(n *Network) []peer {
+	var peers []peer
+	if n.Driver == "overlay" {
+		endpoints, err := r.daemon.overlay.Endpoints(n.ID)
+		if err != nil {
+			logrus.Warnf("Unable to get the containers of network %s: %v", n.Name, err)
+			return nil
+		}
+		for _, e := range endpoints {
+			if ip := net.ParseIP(e.IP); ip != nil {
+				peers = append(peers, peer{name: e.Name, aliases: e.Aliases, ips: []net.IP{ip}})
+			}
+		}
+		return peers
+	}
+	for _, container := range r.daemon.List() {
+		container.Lock()
+		endpoint := container.NetworkSettings.Networks[n.Name]
+		if endpoint == nil || endpoint.IPAddress == "" || !container.Running {
+			container.Unlock()
+			continue
+		}
+		p := peer{name: strings.TrimPrefix(container.Name, "/"), aliases: append([]string(nil), endpoint.Aliases...)}
+		for _, addr := range []string{endpoint.IPAddress, endpoint.GlobalIPv6Address} {
+			if ip := net.ParseIP(addr); ip != nil {
+				p.ips = append(p.ips, ip)
+			}
+		}
+		container.Unlock()
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// ResolveName returns the addresses of the containers named name, by their
+// name or an alias, on the first network of the container they are on. name
+// may be qualified with the name of the network, as in web.frontend.
+func (r *containerResolver) ResolveName(name string) []net.IP {
+	for _, n := range r.networks() {
+		var (
+			ips   []net.IP
+			short = strings.TrimSuffix(name, "."+strings.ToLower(n.Name))
+		)
+		for _, p := range r.peers(n) {
+			if p.is(name) || p.is(short) {
+				ips = append(ips, p.ips...)
+			}
+		}
+		if len(ips) > 0 {
+			return ips
+		}
+	}
+	return nil
+}
+
+// ResolveIP returns the name of the container at ip on the networks of the
+// container.
+func (r *containerResolver) ResolveIP(ip net.IP) string {
+	for _, n := range r.networks() {
+		for _, p := range r.peers(n) {
+			for _, addr := range p.ips {
+				if addr.Equal(ip) {
+					return p.name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Upstreams returns the nameservers the container would use without the
+// DNS server: the ones given with --dns, or the ones of the host. The
+// nameservers of the host on localhost are fine, the server runs on the
+// host. The containers of an internal network have none, their queries
+// about the outside world would leak out of it.
+func (r *containerResolver) Upstreams() []string {
+	if n, err := r.daemon.networks.Get(r.container.hostConfig.NetworkMode.NetworkName()); err == nil && n.Internal {
+		return nil
+	}
+	dns := r.container.hostConfig.Dns
+	if len(dns) == 0 {
+		dns = r.daemon.config.Dns
+	}
+	if len(dns) == 0 {
+		resolvConf, err := resolvconf.Get()
+		if err != nil {
+			logrus.Warnf("Unable to read the nameservers of the host: %v", err)
+			return nil
+		}
+		dns = resolvconf.GetNameservers(resolvConf)
+	}
+	upstreams := make([]string, 0, len(dns))
+	for _, ns := range dns {
+		upstreams = append(upstreams, net.JoinHostPort(ns, "53"))
+	}
+	return upstreams
+}
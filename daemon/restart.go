@@ -10,18 +10,20 @@ func (daemon *Daemon) ContainerRestart(job *engine.Job) error {
 	if len(job.Args) != 1 {
 		return fmt.Errorf("Usage: %s CONTAINER\n", job.Name)
 	}
-	var (
-		name = job.Args[0]
-		t    = 10
-	)
-	if job.EnvExists("t") {
-		t = job.GetenvInt("t")
-	}
+	name := job.Args[0]
 	container, err := daemon.Get(name)
 	if err != nil {
 		return err
 	}
-	if err := container.Restart(int(t)); err != nil {
+	t := container.stopTimeout()
+	if job.EnvExists("t") {
+		t = job.GetenvInt("t")
+	}
+	if err := container.Restart(t); err != nil {
+		// Not started again, the container run with --rm is removed
+		if container.hostConfig.AutoRemove {
+			daemon.autoRemove(container)
+		}
 		return fmt.Errorf("Cannot restart container %s: %s\n", name, err)
 	}
 	container.LogEvent("restart")
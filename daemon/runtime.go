@@ -0,0 +1,79 @@
+package daemon
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/docker/docker/daemon/execdriver"
+	"github.com/docker/docker/daemon/execdriver/oci"
+	"github.com/docker/docker/runconfig"
+)
+
+// newRuntimes returns the drivers of the OCI runtimes added to the daemon,
+// by name, and checks that the default runtime exists. The exec driver is
+// the runtime named after it.
+func newRuntimes(config *Config, runDir string) (map[string]execdriver.Driver, error) {
+	runtimes := make(map[string]execdriver.Driver)
+	for name, path := range config.Runtimes {
+		if name == config.ExecDriver {
+			return nil, fmt.Errorf("Runtime %s has the name of the exec driver", name)
+		}
+		d, err := oci.NewDriver(name, path, filepath.Join(runDir, "execdriver", oci.DriverName, name))
+		if err != nil {
+			return nil, err
+		}
+		runtimes[name] = d
+	}
+	if name := config.DefaultRuntime; name != "" && name != config.ExecDriver {
+		if _, ok := runtimes[name]; !ok {
+			return nil, fmt.Errorf("Unknown default runtime %s", name)
+		}
+	}
+	return runtimes, nil
+}
+
+// runtime returns the driver of the runtime called name, the exec driver if
+// name is empty.
+func (daemon *Daemon) runtime(name string) (execdriver.Driver, error) {
+	if name == "" || name == daemon.config.ExecDriver {
+		return daemon.execDriver, nil
+	}
+	if d, ok := daemon.runtimes[name]; ok {
+		return d, nil
+	}
+	return nil, fmt.Errorf("Unknown runtime %s", name)
+}
+
+// setRuntime gives hostConfig the default runtime of the daemon unless it
+// names one, and returns its driver.
+func (daemon *Daemon) setRuntime(hostConfig *runconfig.HostConfig) (execdriver.Driver, error) {
+	if hostConfig.Runtime == "" {
+		hostConfig.Runtime = daemon.defaultRuntime()
+	}
+	return daemon.runtime(hostConfig.Runtime)
+}
+
+// defaultRuntime returns the name of the runtime of the containers that
+// don't choose one.
+func (daemon *Daemon) defaultRuntime() string {
+	if daemon.config.DefaultRuntime != "" {
+		return daemon.config.DefaultRuntime
+	}
+	return daemon.config.ExecDriver
+}
+
+// runtimeNames returns the names of the runtimes of the daemon, sorted.
+func (daemon *Daemon) runtimeNames() []string {
+	names := []string{daemon.config.ExecDriver}
+	for name := range daemon.runtimes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// execDriver returns the driver of the runtime container runs with.
+func (container *Container) execDriver() (execdriver.Driver, error) {
+	return container.daemon.runtime(container.hostConfig.Runtime)
+}
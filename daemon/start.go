@@ -33,12 +33,24 @@ func (daemon *Daemon) ContainerStart(job *engine.Job) error {
 	// creating a container, not during start.
 	if len(job.Environ()) > 0 {
 		hostConfig := runconfig.ContainerHostConfigFromJob(job)
+		if err := daemon.verifyNetworkMode(hostConfig); err != nil {
+			return err
+		}
+		if err := daemon.verifyIPAMConfig(container.Config, hostConfig); err != nil {
+			return err
+		}
+		if err := daemon.verifyNetworkAliases(container.Config, hostConfig); err != nil {
+			return err
+		}
 		if err := daemon.setHostConfig(container, hostConfig); err != nil {
 			return err
 		}
 	}
 	if err := container.Start(); err != nil {
 		container.LogEvent("die")
+		if container.hostConfig.AutoRemove {
+			daemon.autoRemove(container)
+		}
 		return fmt.Errorf("Cannot start container %s: %s", name, err)
 	}
 
@@ -57,6 +69,10 @@ func (daemon *Daemon) setHostConfig(container *Container, hostConfig *runconfig.
 		return err
 	}
 
+	// the runtime of a container is chosen when it is created
+	if container.hostConfig.Runtime != "" {
+		hostConfig.Runtime = container.hostConfig.Runtime
+	}
 	container.hostConfig = hostConfig
 	container.toDisk()
 
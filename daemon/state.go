@@ -22,12 +22,15 @@ type State struct {
 	Error             string // contains last known error when starting the container
 	StartedAt         time.Time
 	FinishedAt        time.Time
+	Health            *Health `json:",omitempty"` // nil unless the container has a health check
 	waitChan          chan struct{}
+	removedChan       chan struct{}
 }
 
 func NewState() *State {
 	return &State{
-		waitChan: make(chan struct{}),
+		waitChan:    make(chan struct{}),
+		removedChan: make(chan struct{}),
 	}
 }
 
@@ -41,6 +44,9 @@ func (s *State) String() string {
 			return fmt.Sprintf("Restarting (%d) %s ago", s.ExitCode, units.HumanDuration(time.Now().UTC().Sub(s.FinishedAt)))
 		}
 
+		if s.Health != nil {
+			return fmt.Sprintf("Up %s (%s)", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)), s.Health.Status)
+		}
 		return fmt.Sprintf("Up %s", units.HumanDuration(time.Now().UTC().Sub(s.StartedAt)))
 	}
 
@@ -78,6 +84,13 @@ func (s *State) StateString() string {
 	return "exited"
 }
 
+// waitTimeoutError is the error of the waits on the state that timed out.
+type waitTimeoutError time.Duration
+
+func (e waitTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out: %v", time.Duration(e))
+}
+
 func wait(waitChan <-chan struct{}, timeout time.Duration) error {
 	if timeout < 0 {
 		<-waitChan
@@ -85,7 +98,7 @@ func wait(waitChan <-chan struct{}, timeout time.Duration) error {
 	}
 	select {
 	case <-time.After(timeout):
-		return fmt.Errorf("Timed out: %v", timeout)
+		return waitTimeoutError(timeout)
 	case <-waitChan:
 		return nil
 	}
@@ -127,6 +140,38 @@ func (s *State) WaitStop(timeout time.Duration) (int, error) {
 	return s.GetExitCode(), nil
 }
 
+// WaitNextStop waits until the container stops. Unlike WaitStop, it waits for
+// the container to be started and stopped again if it isn't running.
+// If you want wait forever you must supply negative timeout.
+func (s *State) WaitNextStop(timeout time.Duration) (int, error) {
+	s.Lock()
+	running := s.Running
+	waitChan := s.waitChan
+	s.Unlock()
+	if !running {
+		start := time.Now()
+		if err := wait(waitChan, timeout); err != nil {
+			return -1, err
+		}
+		if timeout >= 0 {
+			if timeout -= time.Since(start); timeout < 0 {
+				timeout = 0
+			}
+		}
+	}
+	return s.WaitStop(timeout)
+}
+
+// WaitRemoved waits until the container is removed from the daemon.
+// If you want wait forever you must supply negative timeout.
+// Returns the last exit code of the container.
+func (s *State) WaitRemoved(timeout time.Duration) (int, error) {
+	if err := wait(s.removedChan, timeout); err != nil {
+		return -1, err
+	}
+	return s.GetExitCode(), nil
+}
+
 func (s *State) IsRunning() bool {
 	s.Lock()
 	res := s.Running
@@ -249,6 +294,17 @@ func (s *State) ResetRemovalInProgress() {
 	s.Unlock()
 }
 
+// SetRemoved fires the waiters for the removal of the container.
+func (s *State) SetRemoved() {
+	s.Lock()
+	select {
+	case <-s.removedChan:
+	default:
+		close(s.removedChan)
+	}
+	s.Unlock()
+}
+
 func (s *State) SetDead() {
 	s.Lock()
 	s.Dead = true
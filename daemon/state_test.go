@@ -102,3 +102,48 @@ func TestStateTimeoutWait(t *testing.T) {
 	}
 
 }
+
+func TestStateWaitNextStop(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 1})
+
+	if _, err := s.WaitNextStop(50 * time.Millisecond); err == nil {
+		t.Fatal("Expected WaitNextStop to time out on a stopped container")
+	} else if _, ok := err.(waitTimeoutError); !ok {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+
+	stopped := make(chan int)
+	go func() {
+		exitCode, _ := s.WaitNextStop(-1 * time.Second)
+		stopped <- exitCode
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.SetRunning(43)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 2})
+	select {
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Stop callback doesn't fire in 100 milliseconds")
+	case exitCode := <-stopped:
+		if exitCode != 2 {
+			t.Fatalf("ExitCode %v, expected 2", exitCode)
+		}
+	}
+}
+
+func TestStateWaitRemoved(t *testing.T) {
+	s := NewState()
+	s.SetRunning(42)
+	s.SetStopped(&execdriver.ExitStatus{ExitCode: 3})
+
+	if _, err := s.WaitRemoved(50 * time.Millisecond); err == nil {
+		t.Fatal("Expected WaitRemoved to time out before the removal")
+	}
+	s.SetRemoved()
+	if exitCode, err := s.WaitRemoved(-1 * time.Second); err != nil || exitCode != 3 {
+		t.Fatalf("WaitRemoved returned exitCode: %v, err: %v, expected exitCode: 3", exitCode, err)
+	}
+	// Removing twice must not panic
+	s.SetRemoved()
+}
@@ -11,18 +11,46 @@ import (
 )
 
 func (daemon *Daemon) ContainerStats(job *engine.Job) error {
+	container, err := daemon.Get(job.Args[0])
+	if err != nil {
+		return err
+	}
+	// a stopped container would never send a sample, so it gets an empty one.
+	if !job.GetenvBool("stream") && !container.IsRunning() {
+		return json.NewEncoder(job.Stdout).Encode(&types.Stats{})
+	}
 	updates, err := daemon.SubscribeToContainerStats(job.Args[0])
 	if err != nil {
 		return err
 	}
-	enc := json.NewEncoder(job.Stdout)
-	for v := range updates {
+	var preCpuStats types.CpuStats
+	getStat := func(v interface{}) *types.Stats {
 		update := v.(*execdriver.ResourceStats)
 		ss := convertToAPITypes(update.Stats)
+		ss.PreCpuStats = preCpuStats
 		ss.MemoryStats.Limit = uint64(update.MemoryLimit)
 		ss.Read = update.Read
 		ss.CpuStats.SystemUsage = update.SystemUsage
-		if err := enc.Encode(ss); err != nil {
+		preCpuStats = ss.CpuStats
+		return ss
+	}
+	enc := json.NewEncoder(job.Stdout)
+	if !job.GetenvBool("stream") {
+		defer daemon.UnsubscribeToContainerStats(job.Args[0], updates)
+		// the first sample only primes the cpu stats, so that the usage can
+		// be computed from the one that is sent.
+		v, ok := <-updates
+		if !ok {
+			return nil
+		}
+		getStat(v)
+		if v, ok = <-updates; !ok {
+			return nil
+		}
+		return enc.Encode(getStat(v))
+	}
+	for v := range updates {
+		if err := enc.Encode(getStat(v)); err != nil {
 			// TODO: handle the specific broken pipe
 			daemon.UnsubscribeToContainerStats(job.Args[0], updates)
 			return err
@@ -10,21 +10,20 @@ func (daemon *Daemon) ContainerStop(job *engine.Job) error {
 	if len(job.Args) != 1 {
 		return fmt.Errorf("Usage: %s CONTAINER\n", job.Name)
 	}
-	var (
-		name = job.Args[0]
-		t    = 10
-	)
-	if job.EnvExists("t") {
-		t = job.GetenvInt("t")
-	}
+	name := job.Args[0]
 	container, err := daemon.Get(name)
 	if err != nil {
 		return err
 	}
+	t := container.stopTimeout()
+	if job.EnvExists("t") {
+		t = job.GetenvInt("t")
+	}
 	if !container.IsRunning() {
 		return fmt.Errorf("Container already stopped")
 	}
-	if err := container.Stop(int(t)); err != nil {
+	container.setManuallyStopped()
+	if err := container.Stop(t); err != nil {
 		return fmt.Errorf("Cannot stop container %s: %s\n", name, err)
 	}
 	container.LogEvent("stop")
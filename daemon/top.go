@@ -3,6 +3,7 @@ package daemon
 import (
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -29,10 +30,20 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) error {
 	if !container.IsRunning() {
 		return fmt.Errorf("Container %s is not running", name)
 	}
-	pids, err := daemon.ExecutionDriver().GetPidsForContainer(container.ID)
+	ed, err := container.execDriver()
 	if err != nil {
 		return err
 	}
+	pids, err := ed.GetPidsForContainer(container.ID)
+	if err != nil {
+		return err
+	}
+	if fields := job.Getenv("fields"); fields != "" {
+		if len(job.Args) == 2 && job.Args[1] != "" {
+			return fmt.Errorf("Bad parameter: fields can't be given along with ps arguments")
+		}
+		return topFields(job, pids, strings.Split(fields, ","))
+	}
 	output, err := exec.Command("ps", strings.Split(psArgs, " ")...).Output()
 	if err != nil {
 		return fmt.Errorf("Error running ps: %s", err)
@@ -64,17 +75,92 @@ func (daemon *Daemon) ContainerTop(job *engine.Job) error {
 			return fmt.Errorf("Unexpected pid '%s': %s", fields[pidIndex], err)
 		}
 
-		for _, pid := range pids {
-			if pid == p {
-				// Make sure number of fields equals number of header titles
-				// merging "overhanging" fields
-				process := fields[:len(header)-1]
-				process = append(process, strings.Join(fields[len(header)-1:], " "))
-				processes = append(processes, process)
+		if hasPid(pids, p) {
+			processes = append(processes, psProcess(fields, len(header)))
+		}
+	}
+	out.SetJson("Processes", processes)
+	out.WriteTo(job.Stdout)
+	return nil
+}
+
+var validPsField = regexp.MustCompile(`^[a-z%_]+$`)
+
+// topFields lists the processes of a container with the given ps format
+// fields, e.g. pid, user or args. The fields are the titles of the columns,
+// whatever headers ps would have given them.
+//
+// Values such as the ones of args or lstart contain spaces, so the columns
+// can't be told apart in the output of ps: each field is read from a ps of
+// its own, as the rest of the line after the pid.
+func topFields(job *engine.Job, pids []int, fields []string) error {
+	for _, field := range fields {
+		if !validPsField.MatchString(field) {
+			return fmt.Errorf("Bad parameter: invalid ps field %q", field)
+		}
+	}
+
+	var (
+		order  []int
+		values = make(map[int][]string)
+	)
+	for i, field := range fields {
+		output, err := exec.Command("ps", "-e", "-o", "pid=", "-o", field+"=").Output()
+		if err != nil {
+			return fmt.Errorf("Error running ps: %s", err)
+		}
+		for _, line := range strings.Split(string(output), "\n") {
+			parts := strings.SplitN(strings.TrimSpace(line), " ", 2)
+			if parts[0] == "" {
+				continue
+			}
+			p, err := strconv.Atoi(parts[0])
+			if err != nil {
+				return fmt.Errorf("Unexpected pid '%s': %s", parts[0], err)
+			}
+			if !hasPid(pids, p) {
+				continue
+			}
+			var value string
+			if len(parts) == 2 {
+				value = strings.TrimSpace(parts[1])
+			}
+			if i == 0 {
+				order = append(order, p)
+				values[p] = []string{value}
+			} else if v := values[p]; len(v) == i {
+				// the processes which exited or started in between are
+				// left out
+				values[p] = append(v, value)
 			}
 		}
 	}
+
+	processes := [][]string{}
+	for _, p := range order {
+		if v := values[p]; len(v) == len(fields) {
+			processes = append(processes, v)
+		}
+	}
+	out := &engine.Env{}
+	out.SetList("Titles", fields)
 	out.SetJson("Processes", processes)
 	out.WriteTo(job.Stdout)
 	return nil
 }
+
+func hasPid(pids []int, p int) bool {
+	for _, pid := range pids {
+		if pid == p {
+			return true
+		}
+	}
+	return false
+}
+
+// psProcess makes sure the number of fields of a process equals the number
+// of columns, merging the "overhanging" fields into the last one.
+func psProcess(fields []string, columns int) []string {
+	process := fields[:columns-1]
+	return append(process, strings.Join(fields[columns-1:], " "))
+}
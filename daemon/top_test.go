@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/engine"
+)
+
+func TestTopFields(t *testing.T) {
+	var (
+		eng = engine.New()
+		job = eng.Job("top")
+		buf = bytes.NewBuffer(nil)
+		pid = os.Getpid()
+	)
+	job.Stdout.Add(buf)
+	if err := topFields(job, []int{pid}, []string{"pid", "args"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &engine.Env{}
+	if err := out.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	if titles := out.GetList("Titles"); len(titles) != 2 || titles[0] != "pid" || titles[1] != "args" {
+		t.Fatalf("Expected the fields as titles, got %v", titles)
+	}
+	processes := [][]string{}
+	if err := out.GetJson("Processes", &processes); err != nil {
+		t.Fatal(err)
+	}
+	if len(processes) != 1 || len(processes[0]) != 2 || processes[0][0] != strconv.Itoa(pid) {
+		t.Fatalf("Expected the test process only, got %v", processes)
+	}
+
+	if err := topFields(eng.Job("top"), []int{pid}, []string{"pid", "-A"}); err == nil {
+		t.Fatal("Expected an error for an invalid field")
+	}
+}
+
+func TestTopFieldsWithSpaces(t *testing.T) {
+	var (
+		eng = engine.New()
+		job = eng.Job("top")
+		buf = bytes.NewBuffer(nil)
+		pid = os.Getpid()
+	)
+	job.Stdout.Add(buf)
+	// lstart and args both contain spaces, and neither is last
+	if err := topFields(job, []int{pid}, []string{"lstart", "args", "pid"}); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &engine.Env{}
+	if err := out.Decode(buf); err != nil {
+		t.Fatal(err)
+	}
+	processes := [][]string{}
+	if err := out.GetJson("Processes", &processes); err != nil {
+		t.Fatal(err)
+	}
+	if len(processes) != 1 || len(processes[0]) != 3 {
+		t.Fatalf("Expected the 3 fields of the test process, got %v", processes)
+	}
+	if !strings.Contains(processes[0][0], " ") || processes[0][1] != strings.Join(os.Args, " ") || processes[0][2] != strconv.Itoa(pid) {
+		t.Fatalf("Expected the start time, command line and pid of the test process, got %q", processes[0])
+	}
+}
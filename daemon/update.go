@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/engine"
+)
+
+// defaultCpuPeriod is the CFS period of the kernel, in microseconds, which
+// a period of 0 stands for.
+const defaultCpuPeriod = 100000
+
+// ContainerUpdate changes the resource limits of a container. The limits of
+// a running container are applied to it right away, and they all persist
+// across restarts.
+func (daemon *Daemon) ContainerUpdate(job *engine.Job) error {
+	if len(job.Args) != 1 {
+		return fmt.Errorf("Usage: %s CONTAINER", job.Name)
+	}
+	name := job.Args[0]
+	container, err := daemon.Get(name)
+	if err != nil {
+		return err
+	}
+
+	container.Lock()
+	defer container.Unlock()
+
+	hostConfig := *container.hostConfig
+	if job.EnvExists("Memory") {
+		hostConfig.Memory = job.GetenvInt64("Memory")
+	}
+	if job.EnvExists("MemorySwap") {
+		hostConfig.MemorySwap = job.GetenvInt64("MemorySwap")
+	}
+	if job.EnvExists("CpuShares") {
+		hostConfig.CpuShares = job.GetenvInt64("CpuShares")
+	}
+	if job.EnvExists("CpuPeriod") {
+		hostConfig.CpuPeriod = job.GetenvInt64("CpuPeriod")
+	}
+	if job.EnvExists("CpuQuota") {
+		hostConfig.CpuQuota = job.GetenvInt64("CpuQuota")
+	}
+	if job.EnvExists("CpusetCpus") {
+		hostConfig.CpusetCpus = job.Getenv("CpusetCpus")
+	}
+	if job.EnvExists("BlkioWeight") {
+		hostConfig.BlkioWeight = job.GetenvInt64("BlkioWeight")
+	}
+	warnings, err := daemon.verifyResources(&hostConfig)
+	if err != nil {
+		return err
+	}
+	for _, warning := range warnings {
+		job.Errorf("%s\n", warning)
+	}
+
+	if container.Running && container.command != nil && container.command.Resources != nil {
+		old := container.command.Resources
+		resources := *old
+		resources.Memory = hostConfig.Memory
+		resources.MemorySwap = hostConfig.MemorySwap
+		resources.CpuShares = hostConfig.CpuShares
+		resources.CpuPeriod = hostConfig.CpuPeriod
+		resources.CpuQuota = hostConfig.CpuQuota
+		if resources.CpuPeriod == 0 && old.CpuPeriod != 0 {
+			// The drivers leave the period alone when it's 0, it has to
+			// be set back to the default.
+			resources.CpuPeriod = defaultCpuPeriod
+		}
+		if resources.CpuQuota == 0 && old.CpuQuota != 0 {
+			// The drivers leave the limits that are 0 alone, the quota
+			// is removed by the kernel when it's -1.
+			resources.CpuQuota = -1
+		}
+		resources.CpusetCpus = hostConfig.CpusetCpus
+		resources.BlkioWeight = hostConfig.BlkioWeight
+		container.command.Resources = &resources
+		ed, err := container.execDriver()
+		if err != nil {
+			return err
+		}
+		if err := ed.Update(container.command); err != nil {
+			container.command.Resources = old
+			return fmt.Errorf("Cannot update container %s: %s", name, err)
+		}
+	}
+
+	*container.hostConfig = hostConfig
+	if err := container.WriteHostConfig(); err != nil {
+		return err
+	}
+	container.LogEvent("update")
+	return nil
+}
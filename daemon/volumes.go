@@ -39,6 +39,19 @@ func (mnt *Mount) Export(resource string) (io.ReadCloser, error) {
 	return mnt.volume.Export(path, name)
 }
 
+// Extract unpacks the tar archive content into the directory resource, a path
+// in the container within the volume.
+func (mnt *Mount) Extract(resource string, content io.Reader) error {
+	if !mnt.Writable {
+		return fmt.Errorf("Cannot extract to %s: the volume %s is read-only", resource, mnt.MountToPath)
+	}
+	path, err := filepath.Rel(mnt.MountToPath[1:], resource)
+	if err != nil {
+		return err
+	}
+	return mnt.volume.Extract(path, content)
+}
+
 func (container *Container) prepareVolumes() error {
 	if container.Volumes == nil || len(container.Volumes) == 0 {
 		container.Volumes = make(map[string]string)
@@ -348,6 +361,10 @@ func (container *Container) setupMounts() error {
 		mounts = append(mounts, execdriver.Mount{Source: container.HostsPath, Destination: "/etc/hosts", Writable: true, Private: true})
 	}
 
+	if container.runsInit() {
+		mounts = append(mounts, execdriver.Mount{Source: container.daemon.sysInitPath, Destination: execdriver.InitPath, Private: true})
+	}
+
 	container.command.Mounts = mounts
 	return nil
 }
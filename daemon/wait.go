@@ -16,7 +16,29 @@ func (daemon *Daemon) ContainerWait(job *engine.Job) error {
 	if err != nil {
 		return fmt.Errorf("%s: %v", job.Name, err)
 	}
-	status, _ := container.WaitStop(-1 * time.Second)
+	timeout := -1 * time.Second
+	if job.EnvExists("timeout") {
+		timeout = time.Duration(job.GetenvInt("timeout")) * time.Second
+	}
+	var status int
+	switch condition := job.Getenv("condition"); condition {
+	case "", "not-running":
+		status, err = container.WaitStop(timeout)
+	case "next-exit":
+		status, err = container.WaitNextStop(timeout)
+	case "removed":
+		// Let the caller know that the container is being watched, so
+		// that it can start it without missing its removal.
+		job.Printf("\n")
+		status, err = container.WaitRemoved(timeout)
+	default:
+		return fmt.Errorf("Bad parameter: unknown wait condition %q", condition)
+	}
+	if _, ok := err.(waitTimeoutError); ok {
+		return fmt.Errorf("Timed out waiting for container %s", name)
+	} else if err != nil {
+		return err
+	}
 	job.Printf("%d\n", status)
 	return nil
 }
@@ -86,7 +86,13 @@ func mainDaemon() {
 	logrus.SetFormatter(&logrus.TextFormatter{TimestampFormat: timeutils.RFC3339NanoFixed})
 
 	eng := engine.New()
-	signal.Trap(eng.Shutdown)
+	signal.Trap(func() {
+		// Give in-flight API requests a chance to complete first
+		if err := eng.Job("drainconnections").Run(); err != nil {
+			logrus.Errorf("Error draining API connections: %s", err)
+		}
+		eng.Shutdown()
+	})
 
 	if err := migrateKey(); err != nil {
 		logrus.Fatal(err)
@@ -121,6 +127,7 @@ func mainDaemon() {
 			daemonInitWait <- err
 			return
 		}
+		reloadOnSIGHUP(d, registryService)
 
 		b := &builder.BuilderJob{eng, d}
 		b.Install()
@@ -139,6 +146,12 @@ func mainDaemon() {
 	job.SetenvBool("Logging", true)
 	job.SetenvBool("EnableCors", daemonCfg.EnableCors)
 	job.Setenv("CorsHeaders", daemonCfg.CorsHeaders)
+	job.SetenvInt("CompressionThreshold", daemonCfg.CompressionThreshold)
+	job.SetenvList("AuthzPlugins", daemonCfg.AuthzPlugins)
+	job.Setenv("AuditLog", daemonCfg.AuditLog)
+	job.SetenvInt("DrainTimeout", daemonCfg.DrainTimeout)
+	job.Setenv("MaxBuildContext", daemonCfg.MaxBuildContext)
+	job.Setenv("MaxJSONBody", daemonCfg.MaxJSONBody)
 	job.Setenv("Version", dockerversion.VERSION)
 	job.Setenv("SocketGroup", daemonCfg.SocketGroup)
 
@@ -0,0 +1,360 @@
+// +build daemon
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	gosignal "os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/daemon"
+	"github.com/docker/docker/opts"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/registry"
+	"github.com/docker/docker/runconfig"
+)
+
+// defaultDaemonConfigFile is the configuration file of the daemon, in the
+// daemon configuration directory.
+const defaultDaemonConfigFile = "daemon.json"
+
+var (
+	flConfigFile = flag.String([]string{"-config-file"}, "", "Daemon configuration file")
+
+	// daemonConfigOptions are the options of the configuration file the
+	// daemon was started with.
+	daemonConfigOptions map[string]json.RawMessage
+
+	// commandLineFlags are the flags given on the command line, rather than
+	// in the configuration file.
+	commandLineFlags = make(map[*flag.Flag]bool)
+)
+
+// loadDaemonConfigFile sets the daemon flags from the configuration file, a
+// JSON object, or a TOML document for the files ending in .toml, whose keys
+// are the long names of the flags. Lists are given as arrays, and key=value
+// options, such as --log-opt, as objects or tables. The file is optional
+// unless --config-file names it.
+func loadDaemonConfigFile() error {
+	flag.Visit(func(f *flag.Flag) {
+		commandLineFlags[f] = true
+	})
+	explicit := *flConfigFile != ""
+	setDefaultConfFlag(flConfigFile, defaultDaemonConfigFile)
+
+	options, err := readDaemonConfigFile(*flConfigFile)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return err
+	}
+
+	var conflicts []string
+	for _, key := range sortedKeys(options) {
+		f, err := lookupDaemonFlag(key)
+		if err != nil {
+			return fmt.Errorf("%s in %s", err, *flConfigFile)
+		}
+		if commandLineFlags[f] {
+			conflicts = append(conflicts, key)
+			continue
+		}
+		values, err := configValues(options[key])
+		if err != nil {
+			return fmt.Errorf("Invalid value of %s in %s: %v", key, *flConfigFile, err)
+		}
+		for _, v := range values {
+			if err := flag.Set("-"+key, v); err != nil {
+				return fmt.Errorf("Invalid value of %s in %s: %v", key, *flConfigFile, err)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("Options given both on the command line and in %s: %s", *flConfigFile, strings.Join(conflicts, ", "))
+	}
+	daemonConfigOptions = options
+	return nil
+}
+
+// readDaemonConfigFile returns the options of the configuration file path,
+// in JSON whatever the format of the file.
+func readDaemonConfigFile(path string) (map[string]json.RawMessage, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var options map[string]json.RawMessage
+	if filepath.Ext(path) != ".toml" {
+		if err := json.Unmarshal(b, &options); err != nil {
+			return nil, fmt.Errorf("Error parsing %s: %v", path, err)
+		}
+		return options, nil
+	}
+
+	var values map[string]interface{}
+	if _, err := toml.Decode(string(b), &values); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %v", path, err)
+	}
+	options = make(map[string]json.RawMessage, len(values))
+	for key, v := range values {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value of %s in %s: %v", key, path, err)
+		}
+		options[key] = raw
+	}
+	return options, nil
+}
+
+// lookupDaemonFlag returns the flag called --key. Deprecated names aren't
+// accepted, nor --config-file.
+func lookupDaemonFlag(key string) (*flag.Flag, error) {
+	if f := flag.Lookup("-" + key); f != nil && key != "config-file" {
+		for _, name := range f.Names {
+			if name == "-"+key {
+				return f, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Unknown option %s", key)
+}
+
+// configValues returns the values of a flag that an option of the
+// configuration file stands for: one per element of an array, and key=value
+// per member of an object.
+func configValues(raw json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var values []string
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			s, err := configScalar(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			s, err := configScalar(v[k])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, k+"="+s)
+		}
+	default:
+		s, err := configScalar(v)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+func configScalar(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool, json.Number:
+		return fmt.Sprint(v), nil
+	}
+	return "", fmt.Errorf("expected a string, a number or a boolean")
+}
+
+func sortedKeys(options map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(options))
+	for k := range options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// reloadableOptions are the options of the configuration file that the
+// daemon applies when it receives SIGHUP.
+var reloadableOptions = map[string]bool{
+	"debug":             true,
+	"insecure-registry": true,
+	"label":             true,
+	"log-driver":        true,
+	"log-level":         true,
+	"log-opt":           true,
+}
+
+// reloadOnSIGHUP reloads the configuration file every time the daemon
+// receives SIGHUP.
+func reloadOnSIGHUP(d *daemon.Daemon, registryService *registry.Service) {
+	startCfg := *d.Config()
+	c := make(chan os.Signal, 1)
+	gosignal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for range c {
+			if err := reloadDaemonConfig(d, &startCfg, registryService); err != nil {
+				logrus.Errorf("Error reloading %s: %v", *flConfigFile, err)
+				continue
+			}
+			logrus.Infof("Reloaded %s", *flConfigFile)
+		}
+	}()
+}
+
+// reloadDaemonConfig applies the reloadable options of the configuration file
+// to the running daemon. Those that aren't in the file any more get back the
+// value of the command line, as in startCfg, or their default. The other
+// options only take effect when the daemon restarts.
+func reloadDaemonConfig(d *daemon.Daemon, startCfg *daemon.Config, registryService *registry.Service) error {
+	options, err := readDaemonConfigFile(*flConfigFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		options = nil
+	}
+
+	// values returns the values of the option called key, and whether the
+	// file or the command line gives some.
+	values := func(key string) ([]string, bool, error) {
+		raw, ok := options[key]
+		if !ok {
+			return nil, false, nil
+		}
+		f, err := lookupDaemonFlag(key)
+		if err != nil {
+			return nil, false, err
+		}
+		if commandLineFlags[f] {
+			return nil, false, fmt.Errorf("%s is given on the command line", key)
+		}
+		v, err := configValues(raw)
+		if err != nil {
+			return nil, false, fmt.Errorf("Invalid value of %s: %v", key, err)
+		}
+		return v, true, nil
+	}
+	for _, key := range sortedKeys(options) {
+		if _, err := lookupDaemonFlag(key); err != nil {
+			return err
+		}
+		if !reloadableOptions[key] && !reflect.DeepEqual(options[key], daemonConfigOptions[key]) {
+			logrus.Warnf("Changing %s requires restarting the daemon", key)
+		}
+	}
+
+	level := logrus.InfoLevel
+	if v, ok, err := values("log-level"); err != nil {
+		return err
+	} else if ok {
+		if level, err = logrus.ParseLevel(v[0]); err != nil {
+			return fmt.Errorf("Invalid value of log-level: %v", err)
+		}
+	} else if commandLineFlags[flag.Lookup("-log-level")] {
+		if l, err := logrus.ParseLevel(*flLogLevel); err == nil {
+			level = l
+		}
+	}
+	debug := *flDebug && commandLineFlags[flag.Lookup("-debug")]
+	if v, ok, err := values("debug"); err != nil {
+		return err
+	} else if ok {
+		debug = v[0] == "true"
+	}
+	if debug {
+		level = logrus.DebugLevel
+	}
+
+	var labels []string
+	if commandLineFlags[flag.Lookup("-label")] {
+		labels = startCfg.Labels
+	}
+	if v, ok, err := values("label"); err != nil {
+		return err
+	} else if ok {
+		labels = nil
+		for _, l := range v {
+			if _, err := opts.ValidateLabel(l); err != nil {
+				return err
+			}
+			labels = append(labels, l)
+		}
+	}
+
+	logConfig := runconfig.LogConfig{Type: flag.Lookup("-log-driver").DefValue, Config: make(map[string]string)}
+	if commandLineFlags[flag.Lookup("-log-driver")] {
+		logConfig.Type = startCfg.LogConfig.Type
+	}
+	if v, ok, err := values("log-driver"); err != nil {
+		return err
+	} else if ok {
+		logConfig.Type = v[0]
+	}
+	if commandLineFlags[flag.Lookup("-log-opt")] {
+		logConfig.Config = startCfg.LogConfig.Config
+	}
+	if v, ok, err := values("log-opt"); err != nil {
+		return err
+	} else if ok {
+		for _, o := range v {
+			k, val, err := parsers.ParseKeyValueOpt(o)
+			if err != nil {
+				return err
+			}
+			logConfig.Config[k] = val
+		}
+	}
+
+	var registries []string
+	if commandLineFlags[flag.Lookup("-insecure-registry")] {
+		registries = registryCfg.InsecureRegistries.GetAll()
+	}
+	if v, ok, err := values("insecure-registry"); err != nil {
+		return err
+	} else if ok {
+		registries = v
+	}
+	insecureRegistries := opts.NewListOpts(registry.ValidateIndexName)
+	for _, r := range registries {
+		if err := insecureRegistries.Set(r); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Reload(labels, logConfig); err != nil {
+		return err
+	}
+	setLogLevel(level)
+	if debug {
+		os.Setenv("DEBUG", "1")
+	} else {
+		os.Unsetenv("DEBUG")
+	}
+	registryService.SetConfig(registry.NewServiceConfig(&registry.Options{
+		Mirrors:            registryCfg.Mirrors,
+		InsecureRegistries: insecureRegistries,
+	}))
+	daemonConfigOptions = options
+	return nil
+}
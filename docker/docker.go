@@ -15,6 +15,7 @@ import (
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/reexec"
 	"github.com/docker/docker/pkg/term"
+	"github.com/docker/docker/pkg/version"
 	"github.com/docker/docker/utils"
 )
 
@@ -38,6 +39,12 @@ func main() {
 	flag.Parse()
 	// FIXME: validate daemon flags here
 
+	if *flDaemon {
+		if err := loadDaemonConfigFile(); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
 	if *flVersion {
 		showVersion()
 		return
@@ -60,6 +67,14 @@ func main() {
 		setLogLevel(logrus.DebugLevel)
 	}
 
+	var apiVersion version.Version
+	if *flEndpoint != "" && !*flDaemon {
+		var err error
+		if apiVersion, err = useEndpoint(*flEndpoint); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
 	if len(flHosts) == 0 {
 		defaultHost := os.Getenv("DOCKER_HOST")
 		if defaultHost == "" || *flDaemon {
@@ -134,6 +149,18 @@ func main() {
 	} else {
 		cli = client.NewDockerCli(stdin, stdout, stderr, *flTrustKey, protoAddrParts[0], protoAddrParts[1], nil)
 	}
+	if apiVersion != "" {
+		cli.SetAPIVersion(apiVersion)
+	}
+	if *flRetries < 0 {
+		logrus.Fatalf("Invalid value for --retries: %d", *flRetries)
+	}
+	cli.SetRetries(*flRetries)
+	if config, _, err := loadClientConfig(); err == nil {
+		cli.SetDetachKeys(config.DetachKeys)
+	} else if !os.IsNotExist(err) {
+		logrus.Warn(err)
+	}
 
 	if err := cli.Cmd(flag.Args()...); err != nil {
 		if sterr, ok := err.(*utils.StatusError); ok {
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/pkg/homedir"
+	flag "github.com/docker/docker/pkg/mflag"
+	"github.com/docker/docker/pkg/version"
+)
+
+// clientConfigFile is the configuration file of the client, in ~/.docker.
+const clientConfigFile = "config.json"
+
+// endpoint is a daemon the client can connect to, named in the client
+// configuration file.
+type endpoint struct {
+	Host       string `json:"host"`
+	TLS        bool   `json:"tls,omitempty"`
+	TLSVerify  bool   `json:"tlsverify,omitempty"`
+	CertPath   string `json:"certpath,omitempty"`
+	APIVersion string `json:"apiversion,omitempty"`
+}
+
+type clientConfig struct {
+	Endpoints  map[string]endpoint `json:"endpoints"`
+	DetachKeys string              `json:"detachKeys,omitempty"`
+}
+
+// loadClientConfig reads the client configuration file, and returns its
+// path. The error satisfies os.IsNotExist if the file doesn't exist.
+func loadClientConfig() (*clientConfig, string, error) {
+	path := filepath.Join(homedir.Get(), ".docker", clientConfigFile)
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, path, err
+	}
+	var config clientConfig
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, path, fmt.Errorf("Error parsing %s: %v", path, err)
+	}
+	return &config, path, nil
+}
+
+// loadEndpoint returns the endpoint called name in the client configuration
+// file. A certpath starting with ~ is relative to the home directory.
+func loadEndpoint(name string) (*endpoint, error) {
+	config, path, err := loadClientConfig()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Unknown endpoint %s: %s doesn't exist", name, path)
+		}
+		return nil, err
+	}
+	ep, ok := config.Endpoints[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown endpoint %s: it isn't defined in %s", name, path)
+	}
+	if ep.Host == "" {
+		return nil, fmt.Errorf("Endpoint %s in %s has no host", name, path)
+	}
+	if strings.HasPrefix(ep.CertPath, "~") {
+		ep.CertPath = filepath.Join(homedir.Get(), ep.CertPath[1:])
+	}
+	return &ep, nil
+}
+
+// useEndpoint points the client flags at the endpoint called name: its host,
+// and its TLS settings unless they were given on the command line. It returns
+// the API version the endpoint is pinned to, if any.
+func useEndpoint(name string) (version.Version, error) {
+	if len(flHosts) > 0 {
+		return "", fmt.Errorf("Please specify either --endpoint or -H, not both")
+	}
+	ep, err := loadEndpoint(name)
+	if err != nil {
+		return "", err
+	}
+	host, err := api.ValidateHost(ep.Host)
+	if err != nil {
+		return "", err
+	}
+	flHosts = append(flHosts, host)
+
+	if !flag.IsSet("-tls") {
+		*flTls = ep.TLS
+	}
+	if !flag.IsSet("-tlsverify") {
+		*flTlsVerify = ep.TLSVerify
+	}
+	if ep.CertPath != "" {
+		if !flag.IsSet("-tlscacert") {
+			*flCa = filepath.Join(ep.CertPath, defaultCaFile)
+		}
+		if !flag.IsSet("-tlscert") {
+			*flCert = filepath.Join(ep.CertPath, defaultCertFile)
+		}
+		if !flag.IsSet("-tlskey") {
+			*flKey = filepath.Join(ep.CertPath, defaultKeyFile)
+		}
+	}
+
+	apiVersion := version.Version(ep.APIVersion)
+	if apiVersion != "" && apiVersion.GreaterThan(api.APIVERSION) {
+		return "", fmt.Errorf("Endpoint %s is pinned to API version %s, newer than the client's %s", name, apiVersion, api.APIVERSION)
+	}
+	return apiVersion, nil
+}
@@ -38,6 +38,8 @@ var (
 	flTls       = flag.Bool([]string{"-tls"}, false, "Use TLS; implied by --tlsverify")
 	flHelp      = flag.Bool([]string{"h", "-help"}, false, "Print usage")
 	flTlsVerify = flag.Bool([]string{"-tlsverify"}, dockerTlsVerify, "Use TLS and verify the remote")
+	flEndpoint  = flag.String([]string{"-endpoint"}, os.Getenv("DOCKER_ENDPOINT"), "Named endpoint of ~/.docker/config.json to connect to")
+	flRetries   = flag.Int([]string{"-retries"}, 0, "Number of times to retry idempotent API requests failing with a transient error")
 
 	// these are initialized in init() below since their default values depend on dockerCertPath which isn't fully initialized until init() runs
 	flTrustKey *string
@@ -86,15 +88,17 @@ func init() {
 			{"exec", "Run a command in a running container"},
 			{"export", "Stream the contents of a container as a tar archive"},
 			{"history", "Show the history of an image"},
+			{"image", "Manage images"},
 			{"images", "List images"},
 			{"import", "Create a new filesystem image from the contents of a tarball"},
 			{"info", "Display system-wide information"},
-			{"inspect", "Return low-level information on a container or image"},
+			{"inspect", "Return low-level information on a container, image or exec instance"},
 			{"kill", "Kill a running container"},
 			{"load", "Load an image from a tar archive"},
 			{"login", "Register or log in to a Docker registry server"},
 			{"logout", "Log out from a Docker registry server"},
 			{"logs", "Fetch the logs of a container"},
+			{"network", "Manage Docker networks"},
 			{"port", "Lookup the public-facing port that is NAT-ed to PRIVATE_PORT"},
 			{"pause", "Pause all processes within a container"},
 			{"ps", "List containers"},
@@ -110,9 +114,11 @@ func init() {
 			{"start", "Start a stopped container"},
 			{"stats", "Display a stream of a containers' resource usage statistics"},
 			{"stop", "Stop a running container"},
+			{"system", "Manage Docker"},
 			{"tag", "Tag an image into a repository"},
 			{"top", "Lookup the running processes of a container"},
 			{"unpause", "Unpause a paused container"},
+			{"update", "Update the resource limits of one or more containers"},
 			{"version", "Show the Docker version information"},
 			{"wait", "Block until a container stops, then print its exit code"},
 		} {
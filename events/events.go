@@ -16,17 +16,31 @@ import (
 
 const eventsLimit = 64
 
-type listener chan<- *jsonmessage.JSONMessage
+var acceptedEventFilterTags = map[string]struct{}{
+	"container": {},
+	"event":     {},
+	"image":     {},
+	"label":     {},
+}
+
+// event is an event as sent to the subscribers, along with the labels of
+// the container it is about, which the label filter matches.
+type event struct {
+	*jsonmessage.JSONMessage
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type listener chan<- *event
 
 type Events struct {
 	mu          sync.RWMutex
-	events      []*jsonmessage.JSONMessage
+	events      []*event
 	subscribers []listener
 }
 
 func New() *Events {
 	return &Events{
-		events: make([]*jsonmessage.JSONMessage, 0, eventsLimit),
+		events: make([]*event, 0, eventsLimit),
 	}
 }
 
@@ -57,13 +71,18 @@ func (e *Events) Get(job *engine.Job) error {
 	if err != nil {
 		return err
 	}
+	for name := range eventFilters {
+		if _, ok := acceptedEventFilterTags[name]; !ok {
+			return fmt.Errorf("Invalid filter '%s'", name)
+		}
+	}
 
 	// If no until, disable timeout
 	if job.Getenv("until") == "" {
 		timeout.Stop()
 	}
 
-	listener := make(chan *jsonmessage.JSONMessage)
+	listener := make(chan *event)
 	e.subscribe(listener)
 	defer e.unsubscribe(listener)
 
@@ -91,12 +110,20 @@ func (e *Events) Get(job *engine.Job) error {
 	}
 }
 
+// Log records an event. The labels of the container the event is about can
+// be given as the "labels" environment variable.
 func (e *Events) Log(job *engine.Job) error {
 	if len(job.Args) != 3 {
 		return fmt.Errorf("usage: %s ACTION ID FROM", job.Name)
 	}
+	var labels map[string]string
+	if job.EnvExists("labels") {
+		if err := job.GetenvJson("labels", &labels); err != nil {
+			return err
+		}
+	}
 	// not waiting for receivers
-	go e.log(job.Args[0], job.Args[1], job.Args[2])
+	go e.log(job.Args[0], job.Args[1], job.Args[2], labels)
 	return nil
 }
 
@@ -107,7 +134,7 @@ func (e *Events) SubscribersCount(job *engine.Job) error {
 	return nil
 }
 
-func writeEvent(job *engine.Job, event *jsonmessage.JSONMessage, eventFilters filters.Args) error {
+func writeEvent(job *engine.Job, event *event, eventFilters filters.Args) error {
 	isFiltered := func(field string, filter []string) bool {
 		if len(filter) == 0 {
 			return false
@@ -132,7 +159,7 @@ func writeEvent(job *engine.Job, event *jsonmessage.JSONMessage, eventFilters fi
 	}
 
 	if isFiltered(event.Status, eventFilters["event"]) || isFiltered(event.From, eventFilters["image"]) ||
-		isFiltered(event.ID, eventFilters["container"]) {
+		isFiltered(event.ID, eventFilters["container"]) || !eventFilters.MatchKVList("label", event.Labels) {
 		return nil
 	}
 
@@ -167,10 +194,13 @@ func (e *Events) subscribersCount() int {
 	return c
 }
 
-func (e *Events) log(action, id, from string) {
+func (e *Events) log(action, id, from string, labels map[string]string) {
 	e.mu.Lock()
 	now := time.Now().UTC().Unix()
-	jm := &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: now}
+	jm := &event{
+		JSONMessage: &jsonmessage.JSONMessage{Status: action, ID: id, From: from, Time: now},
+		Labels:      labels,
+	}
 	if len(e.events) == cap(e.events) {
 		// discard oldest event
 		copy(e.events, e.events[1:])
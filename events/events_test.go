@@ -14,15 +14,15 @@ import (
 
 func TestEventsPublish(t *testing.T) {
 	e := New()
-	l1 := make(chan *jsonmessage.JSONMessage)
-	l2 := make(chan *jsonmessage.JSONMessage)
+	l1 := make(chan *event)
+	l2 := make(chan *event)
 	e.subscribe(l1)
 	e.subscribe(l2)
 	count := e.subscribersCount()
 	if count != 2 {
 		t.Fatalf("Must be 2 subscribers, got %d", count)
 	}
-	go e.log("test", "cont", "image")
+	go e.log("test", "cont", "image", nil)
 	select {
 	case msg := <-l1:
 		if len(e.events) != 1 {
@@ -61,12 +61,12 @@ func TestEventsPublish(t *testing.T) {
 
 func TestEventsPublishTimeout(t *testing.T) {
 	e := New()
-	l := make(chan *jsonmessage.JSONMessage)
+	l := make(chan *event)
 	e.subscribe(l)
 
 	c := make(chan struct{})
 	go func() {
-		e.log("test", "cont", "image")
+		e.log("test", "cont", "image", nil)
 		close(c)
 	}()
 
@@ -132,14 +132,79 @@ func TestLogEvents(t *testing.T) {
 	}
 }
 
+func TestEventsFilters(t *testing.T) {
+	e := New()
+	eng := engine.New()
+	if err := e.Install(eng); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ev := range []struct {
+		action, id string
+		labels     map[string]string
+	}{
+		{"die", "cont_1", map[string]string{"tier": "db"}},
+		{"oom", "cont_1", map[string]string{"tier": "db"}},
+		{"die", "cont_2", map[string]string{"tier": "web"}},
+		{"die", "cont_3", nil},
+	} {
+		job := eng.Job("log", ev.action, ev.id, "image")
+		if ev.labels != nil {
+			job.SetenvJson("labels", ev.labels)
+		}
+		if err := job.Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	job := eng.Job("events")
+	job.SetenvInt64("since", 1)
+	job.SetenvInt64("until", time.Now().Unix())
+	job.Setenv("filters", `{"label":["tier=db"],"event":["die"]}`)
+	buf := bytes.NewBuffer(nil)
+	job.Stdout.Add(buf)
+	if err := job.Run(); err != nil {
+		t.Fatal(err)
+	}
+	type labeledMessage struct {
+		jsonmessage.JSONMessage
+		Labels map[string]string `json:"labels"`
+	}
+	var msgs []labeledMessage
+	dec := json.NewDecoder(buf)
+	for {
+		var jm labeledMessage
+		if err := dec.Decode(&jm); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		msgs = append(msgs, jm)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "cont_1" || msgs[0].Status != "die" {
+		t.Fatalf("Expected only the die event of cont_1, got %+v", msgs)
+	}
+	if msgs[0].Labels["tier"] != "db" {
+		t.Fatalf("Expected the event to carry the labels of cont_1, got %v", msgs[0].Labels)
+	}
+
+	job = eng.Job("events")
+	job.Setenv("filters", `{"foo":["bar"]}`)
+	if err := job.Run(); err == nil {
+		t.Fatal("Expected an error for an unknown filter")
+	}
+}
+
 func TestEventsCountJob(t *testing.T) {
 	e := New()
 	eng := engine.New()
 	if err := e.Install(eng); err != nil {
 		t.Fatal(err)
 	}
-	l1 := make(chan *jsonmessage.JSONMessage)
-	l2 := make(chan *jsonmessage.JSONMessage)
+	l1 := make(chan *event)
+	l2 := make(chan *event)
 	e.subscribe(l1)
 	e.subscribe(l2)
 	job := eng.Job("subscribers_count")
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/engine"
@@ -15,15 +16,52 @@ import (
 	"github.com/docker/docker/registry"
 )
 
+// imagesFile is the file of the archives of CmdImageExport listing the
+// images. It isn't named manifest.json, as its entries aren't those of the
+// archives of later versions, whose Config is the image configuration
+// rather than the json of a v1 image.
+const imagesFile = "images.json"
+
+// manifestItem is the entry of an image in the imagesFile of the archives
+// of CmdImageExport: the paths in the archive of its json and of its
+// layers, base layer first, and its tags.
+type manifestItem struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+// addRepoTag adds repoTag to the tags of the image, unless it has it
+// already, as when both busybox and busybox:latest are exported.
+func (item *manifestItem) addRepoTag(repoTag string) {
+	for _, t := range item.RepoTags {
+		if t == repoTag {
+			return
+		}
+	}
+	item.RepoTags = append(item.RepoTags, repoTag)
+}
+
 // CmdImageExport exports all images with the given tag. All versions
-// containing the same tag are exported. The resulting output is an
-// uncompressed tar ball.
+// containing the same tag are exported. The resulting output is a tar
+// ball, uncompressed unless the compress env is gzip or xz, holding the
+// layers the images share once.
 // name is the set of tags to export.
 // out is the writer where the images are written to.
 func (s *TagStore) CmdImageExport(job *engine.Job) error {
 	if len(job.Args) < 1 {
 		return fmt.Errorf("Usage: %s IMAGE [IMAGE...]\n", job.Name)
 	}
+	compression := archive.Uncompressed
+	switch c := job.Getenv("compress"); c {
+	case "", "none":
+	case "gzip":
+		compression = archive.Gzip
+	case "xz":
+		compression = archive.Xz
+	default:
+		return fmt.Errorf("Invalid compression %q, it must be gzip or xz", c)
+	}
 	// get image json
 	tempdir, err := ioutil.TempDir("", "docker-export-")
 	if err != nil {
@@ -40,6 +78,24 @@ func (s *TagStore) CmdImageExport(job *engine.Job) error {
 			repo[tag] = id
 		}
 	}
+	var manifest []*manifestItem
+	manifestItems := make(map[string]*manifestItem)
+	addManifest := func(id, repoTag string) error {
+		item, exists := manifestItems[id]
+		if !exists {
+			layers, err := s.manifestLayers(id)
+			if err != nil {
+				return err
+			}
+			item = &manifestItem{Config: path.Join(id, "json"), RepoTags: []string{}, Layers: layers}
+			manifestItems[id] = item
+			manifest = append(manifest, item)
+		}
+		if repoTag != "" {
+			item.addRepoTag(repoTag)
+		}
+		return nil
+	}
 	for _, name := range job.Args {
 		name = registry.NormalizeLocalName(name)
 		logrus.Debugf("Serializing %s", name)
@@ -51,6 +107,9 @@ func (s *TagStore) CmdImageExport(job *engine.Job) error {
 				if err := s.exportImage(job.Eng, id, tempdir); err != nil {
 					return err
 				}
+				if err := addManifest(id, name+":"+tag); err != nil {
+					return err
+				}
 			}
 		} else {
 			img, err := s.LookupImage(name)
@@ -64,18 +123,26 @@ func (s *TagStore) CmdImageExport(job *engine.Job) error {
 
 				// check this length, because a lookup of a truncated has will not have a tag
 				// and will not need to be added to this map
+				manifestTag := ""
 				if len(repoTag) > 0 {
 					addKey(repoName, repoTag, img.ID)
+					manifestTag = repoName + ":" + repoTag
 				}
 				if err := s.exportImage(job.Eng, img.ID, tempdir); err != nil {
 					return err
 				}
+				if err := addManifest(img.ID, manifestTag); err != nil {
+					return err
+				}
 
 			} else {
 				// this must be an ID that didn't get looked up just right?
 				if err := s.exportImage(job.Eng, name, tempdir); err != nil {
 					return err
 				}
+				if err := addManifest(name, ""); err != nil {
+					return err
+				}
 			}
 		}
 		logrus.Debugf("End Serializing %s", name)
@@ -89,8 +156,18 @@ func (s *TagStore) CmdImageExport(job *engine.Job) error {
 	} else {
 		logrus.Debugf("There were no repositories to write")
 	}
+	for _, item := range manifest {
+		sort.Strings(item.RepoTags)
+	}
+	manifestJson, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path.Join(tempdir, imagesFile), manifestJson, os.FileMode(0644)); err != nil {
+		return err
+	}
 
-	fs, err := archive.Tar(tempdir, archive.Uncompressed)
+	fs, err := archive.Tar(tempdir, compression)
 	if err != nil {
 		return err
 	}
@@ -103,6 +180,21 @@ func (s *TagStore) CmdImageExport(job *engine.Job) error {
 	return nil
 }
 
+// manifestLayers returns the paths in the archives of CmdImageExport of the
+// layers of the image id, base layer first.
+func (s *TagStore) manifestLayers(id string) ([]string, error) {
+	var layers []string
+	for n := id; n != ""; {
+		img, err := s.graph.Get(n)
+		if err != nil {
+			return nil, err
+		}
+		layers = append([]string{path.Join(n, "layer.tar")}, layers...)
+		n = img.Parent
+	}
+	return layers, nil
+}
+
 // FIXME: this should be a top-level function, not a class method
 func (s *TagStore) exportImage(eng *engine.Engine, name, tempdir string) error {
 	for n := name; n != ""; {
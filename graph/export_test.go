@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/utils"
+)
+
+func TestManifestLayers(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	childID := "3a2d3c4d4e5fa2d2a21acea242a5e2345d3aefc3e7dfa2a2a2a21a2a2ad2d234"
+	layer, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.graph.Register(&image.Image{ID: childID, Parent: testOfficialImageID}, layer); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := store.manifestLayers(childID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{path.Join(testOfficialImageID, "layer.tar"), path.Join(childID, "layer.tar")}
+	if !reflect.DeepEqual(layers, expected) {
+		t.Fatalf("Expected the layers %v, got %v", expected, layers)
+	}
+
+	if _, err := store.manifestLayers("4a2d3c4d4e5fa2d2a21acea242a5e2345d3aefc3e7dfa2a2a2a21a2a2ad2d234"); err == nil {
+		t.Fatal("Expected an error for a missing image")
+	}
+}
+
+func TestManifestItemAddRepoTag(t *testing.T) {
+	item := &manifestItem{RepoTags: []string{}}
+	// busybox and busybox:latest both add busybox:latest
+	for _, repoTag := range []string{"busybox:latest", "busybox:musl", "busybox:latest"} {
+		item.addRepoTag(repoTag)
+	}
+	expected := []string{"busybox:latest", "busybox:musl"}
+	if !reflect.DeepEqual(item.RepoTags, expected) {
+		t.Fatalf("Expected the tags %v, got %v", expected, item.RepoTags)
+	}
+}
@@ -119,10 +119,11 @@ func (graph *Graph) Get(name string) (*image.Image, error) {
 	return img, nil
 }
 
-// Create creates a new image and registers it in the graph.
+// Create creates a new image and registers it in the graph. Its ID is derived
+// from its content: if the graph already has an image with the same layer,
+// parent and configuration, that image is returned instead.
 func (graph *Graph) Create(layerData archive.ArchiveReader, containerID, containerImage, comment, author string, containerConfig, config *runconfig.Config) (*image.Image, error) {
 	img := &image.Image{
-		ID:            stringid.GenerateRandomID(),
 		Comment:       comment,
 		Created:       time.Now().UTC(),
 		DockerVersion: dockerversion.VERSION,
@@ -138,14 +139,95 @@ func (graph *Graph) Create(layerData archive.ArchiveReader, containerID, contain
 		img.ContainerConfig = *containerConfig
 	}
 
-	if err := graph.Register(img, layerData); err != nil {
+	return graph.createContent(img, layerData)
+}
+
+// createContent registers img with the layer layerData under an ID derived
+// from the digest of the layer, or returns the image of the graph which
+// already has that ID.
+func (graph *Graph) createContent(img *image.Image, layerData archive.ArchiveReader) (*image.Image, error) {
+	// The layer is spooled to disk to be digested before it is registered
+	layerDigest := digest.Digest(digest.DigestSha256EmptyTar)
+	if layerData != nil {
+		f, err := graph.newTempFile()
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(filepath.Dir(f.Name()))
+		defer f.Close()
+
+		layer, err := archive.DecompressStream(layerData)
+		if err != nil {
+			return nil, err
+		}
+		defer layer.Close()
+		digester := digest.NewCanonicalDigester()
+		if _, err := io.Copy(io.MultiWriter(f, &digester), layer); err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, 0); err != nil {
+			return nil, err
+		}
+		layerData = f
+		layerDigest = digester.Digest()
+	}
+	id, err := image.ContentID(img, layerDigest)
+	if err != nil {
+		return nil, err
+	}
+	if graph.Exists(id) {
+		return graph.Get(id)
+	}
+	img.ID = id
+
+	if err := graph.register(img, layerData, layerDigest); err != nil {
 		return nil, err
 	}
 	return img, nil
 }
 
+// blobLayerDigest returns the digest of the uncompressed layer of the blob
+// blobSum of a v2 registry, or "" if no blob with that digest was pulled.
+func (graph *Graph) blobLayerDigest(blobSum digest.Digest) (digest.Digest, error) {
+	b, err := ioutil.ReadFile(graph.blobSumPath(blobSum))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	layerDigest, err := digest.ParseDigest(string(b))
+	if err != nil {
+		// Interrupted while recording it, the blob is pulled again
+		return "", nil
+	}
+	return layerDigest, nil
+}
+
+// setBlobLayerDigest records the digest of the uncompressed layer of the
+// blob blobSum, for the next pulls to know the IDs of the images with that
+// blob before downloading it.
+func (graph *Graph) setBlobLayerDigest(blobSum, layerDigest digest.Digest) error {
+	p := graph.blobSumPath(blobSum)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(p, []byte(layerDigest), 0600)
+}
+
+func (graph *Graph) blobSumPath(blobSum digest.Digest) string {
+	return path.Join(graph.Root, "_blobsums", blobSum.Algorithm(), blobSum.Hex())
+}
+
 // Register imports a pre-existing image into the graph.
-func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader) (err error) {
+func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader) error {
+	return graph.register(img, layerData, "")
+}
+
+// register registers img like Register. Unless idDigest is empty, it is the
+// digest the ID of img is derived from, recorded for the image not to be
+// migrated to a content ID again.
+func (graph *Graph) register(img *image.Image, layerData archive.ArchiveReader, idDigest digest.Digest) (err error) {
 	defer func() {
 		// If any error occurs, remove the new dir from the driver.
 		// Don't check for errors since the dir might not have been created.
@@ -190,6 +272,11 @@ func (graph *Graph) Register(img *image.Image, layerData archive.ArchiveReader)
 	if err := image.StoreImage(img, layerData, tmp); err != nil {
 		return err
 	}
+	if idDigest != "" {
+		if err := img.SaveIDDigest(tmp, idDigest); err != nil {
+			return err
+		}
+	}
 	// Commit
 	if err := os.Rename(tmp, graph.ImageRoot(img.ID)); err != nil {
 		return err
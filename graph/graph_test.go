@@ -0,0 +1,140 @@
+package graph
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/digest"
+	"github.com/docker/docker/image"
+	"github.com/docker/docker/utils"
+)
+
+func TestCreateContentID(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	layer, err := fakeTar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadAll(layer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDigest, err := digest.FromBytes(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	w.Write(content)
+	w.Close()
+
+	created := time.Now().UTC()
+	img, err := store.graph.createContent(&image.Image{Comment: "layer", Created: created}, bytes.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := image.ContentID(&image.Image{Comment: "layer", Created: created}, layerDigest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID != id {
+		t.Fatalf("Expected the ID of the image to be derived from its content, got %s instead of %s", img.ID, id)
+	}
+	root := store.graph.ImageRoot(img.ID)
+	for _, get := range []func(string) (digest.Digest, error){img.GetLayerDigest, img.GetIDDigest} {
+		dgst, err := get(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dgst != layerDigest {
+			t.Fatalf("Expected the digest of the uncompressed layer %s, got %s", layerDigest, dgst)
+		}
+	}
+
+	// The same content, compressed, is the same image
+	same, err := store.graph.createContent(&image.Image{Comment: "layer", Created: created}, &compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if same.ID != img.ID {
+		t.Fatalf("Expected the image %s to be shared, got %s", img.ID, same.ID)
+	}
+
+	// Another configuration or parent is another image
+	for _, other := range []*image.Image{
+		{Comment: "other layer", Created: created},
+		{Comment: "layer", Created: created, Parent: testOfficialImageID},
+	} {
+		otherImg, err := store.graph.createContent(other, bytes.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if otherImg.ID == img.ID {
+			t.Fatalf("Expected image %+v to get another ID than %s", other, img.ID)
+		}
+	}
+
+	// An image without a layer gets the ID of an empty one
+	empty, err := store.graph.createContent(&image.Image{Created: created}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err = image.ContentID(&image.Image{Created: created}, digest.DigestSha256EmptyTar); err != nil {
+		t.Fatal(err)
+	}
+	if empty.ID != id {
+		t.Fatalf("Expected the ID of an empty layer %s, got %s", id, empty.ID)
+	}
+}
+
+func TestBlobLayerDigest(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	blobSum := digest.Digest("sha256:" + strings.Repeat("ab", 32))
+	if dgst, err := store.graph.blobLayerDigest(blobSum); err != nil || dgst != "" {
+		t.Fatalf("Expected no layer digest for a blob never pulled, got %q, %v", dgst, err)
+	}
+	if err := store.graph.setBlobLayerDigest(blobSum, digest.DigestSha256EmptyTar); err != nil {
+		t.Fatal(err)
+	}
+	dgst, err := store.graph.blobLayerDigest(blobSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dgst != digest.DigestSha256EmptyTar {
+		t.Fatalf("Expected the layer digest %s, got %s", digest.DigestSha256EmptyTar, dgst)
+	}
+
+	// The ID of an image pulled with the blob is the ID of the image
+	// created from its uncompressed layer
+	img := &image.Image{Comment: "layer", Created: time.Now().UTC()}
+	id, err := store.blobImageID(img, testOfficialImageID, blobSum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := image.ContentID(&image.Image{Comment: "layer", Created: img.Created, Parent: testOfficialImageID}, digest.DigestSha256EmptyTar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != expected {
+		t.Fatalf("Expected the content ID %s, got %s", expected, id)
+	}
+}
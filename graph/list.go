@@ -16,8 +16,10 @@ import (
 )
 
 var acceptedImageFilterTags = map[string]struct{}{
+	"before":   {},
 	"dangling": {},
 	"label":    {},
+	"since":    {},
 }
 
 type ByCreated []*types.Image
@@ -54,6 +56,29 @@ func (s *TagStore) CmdImages(job *engine.Job) error {
 
 	_, filtLabel = imageFilters["label"]
 
+	// before and since are resolved to the creation time of their images,
+	// which the listed images are compared to.
+	var beforeImage, sinceImage *image.Image
+	if i, ok := imageFilters["before"]; ok {
+		if beforeImage, err = s.lookupFilterImage("before", i); err != nil {
+			return err
+		}
+	}
+	if i, ok := imageFilters["since"]; ok {
+		if sinceImage, err = s.lookupFilterImage("since", i); err != nil {
+			return err
+		}
+	}
+	match := func(img *image.Image) bool {
+		if beforeImage != nil && !img.Created.Before(beforeImage.Created) {
+			return false
+		}
+		if sinceImage != nil && !img.Created.After(sinceImage.Created) {
+			return false
+		}
+		return imageFilters.MatchKVList("label", img.ContainerConfig.Labels)
+	}
+
 	if job.GetenvBool("all") && filtTagged {
 		allImages, err = s.graph.Map()
 	} else {
@@ -90,7 +115,7 @@ func (s *TagStore) CmdImages(job *engine.Job) error {
 			} else {
 				// get the boolean list for if only the untagged images are requested
 				delete(allImages, id)
-				if !imageFilters.MatchKVList("label", image.ContainerConfig.Labels) {
+				if !match(image) {
 					continue
 				}
 				if filtTagged {
@@ -126,7 +151,7 @@ func (s *TagStore) CmdImages(job *engine.Job) error {
 	// Display images which aren't part of a repository/tag
 	if job.Getenv("filter") == "" || filtLabel {
 		for _, image := range allImages {
-			if !imageFilters.MatchKVList("label", image.ContainerConfig.Labels) {
+			if !match(image) {
 				continue
 			}
 			newImage := new(types.Image)
@@ -150,3 +175,15 @@ func (s *TagStore) CmdImages(job *engine.Job) error {
 	}
 	return nil
 }
+
+// lookupFilterImage returns the image a before or since filter refers to.
+func (s *TagStore) lookupFilterImage(filter string, values []string) (*image.Image, error) {
+	if len(values) != 1 {
+		return nil, fmt.Errorf("Filter '%s' takes a single image", filter)
+	}
+	img, err := s.LookupImage(values[0])
+	if err != nil || img == nil {
+		return nil, fmt.Errorf("No such image: %s", values[0])
+	}
+	return img, nil
+}
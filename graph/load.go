@@ -4,6 +4,7 @@ package graph
 
 import (
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -13,12 +14,26 @@ import (
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/chrootarchive"
+	"github.com/docker/docker/pkg/progressreader"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/utils"
 )
 
 // Loads a set of images into the repository. This is the complementary of ImageExport.
 // The input stream is an uncompressed tar ball containing images and metadata.
+// With json, the progress of the loading of each layer is written to the
+// output as it goes.
 func (s *TagStore) CmdLoad(job *engine.Job) error {
+	var (
+		sf = streamformatter.NewStreamFormatter(job.GetenvBool("json"))
+		// The clients not asking for json expect no progress
+		progressOut io.Writer = ioutil.Discard
+	)
+	if job.GetenvBool("json") {
+		progressOut = job.Stdout
+	}
+
 	tmpImageDir, err := ioutil.TempDir("", "docker-import-")
 	if err != nil {
 		return err
@@ -42,9 +57,17 @@ func (s *TagStore) CmdLoad(job *engine.Job) error {
 		excludes[i] = k
 		i++
 	}
+	// Nothing can be written before the end of the input, which is the body
+	// of the request of the clients and isn't readable anymore once the
+	// response started.
 	if err := chrootarchive.Untar(job.Stdin, repoDir, &archive.TarOptions{ExcludePatterns: excludes}); err != nil {
 		return err
 	}
+	// The padding after the end of the archive isn't read by Untar, and the
+	// clients wait for the end of their input to terminate their own progress
+	if _, err := io.Copy(ioutil.Discard, job.Stdin); err != nil {
+		return err
+	}
 
 	dirs, err := ioutil.ReadDir(repoDir)
 	if err != nil {
@@ -53,7 +76,7 @@ func (s *TagStore) CmdLoad(job *engine.Job) error {
 
 	for _, d := range dirs {
 		if d.IsDir() {
-			if err := s.recursiveLoad(job.Eng, d.Name(), tmpImageDir); err != nil {
+			if err := s.recursiveLoad(job.Eng, d.Name(), tmpImageDir, progressOut, sf); err != nil {
 				return err
 			}
 		}
@@ -68,7 +91,7 @@ func (s *TagStore) CmdLoad(job *engine.Job) error {
 
 		for imageName, tagMap := range repositories {
 			for tag, address := range tagMap {
-				if err := s.SetLoad(imageName, tag, address, true, job.Stdout); err != nil {
+				if err := s.SetLoad(imageName, tag, address, true, job.Stdout, sf); err != nil {
 					return err
 				}
 			}
@@ -80,7 +103,7 @@ func (s *TagStore) CmdLoad(job *engine.Job) error {
 	return nil
 }
 
-func (s *TagStore) recursiveLoad(eng *engine.Engine, address, tmpImageDir string) error {
+func (s *TagStore) recursiveLoad(eng *engine.Engine, address, tmpImageDir string, out io.Writer, sf *streamformatter.StreamFormatter) error {
 	if err := eng.Job("image_get", address).Run(); err != nil {
 		logrus.Debugf("Loading %s", address)
 
@@ -120,14 +143,31 @@ func (s *TagStore) recursiveLoad(eng *engine.Engine, address, tmpImageDir string
 
 		if img.Parent != "" {
 			if !s.graph.Exists(img.Parent) {
-				if err := s.recursiveLoad(eng, img.Parent, tmpImageDir); err != nil {
+				if err := s.recursiveLoad(eng, img.Parent, tmpImageDir, out, sf); err != nil {
 					return err
 				}
 			}
 		}
-		if err := s.graph.Register(img, layer); err != nil {
+		fi, err := layer.Stat()
+		if err != nil {
+			layer.Close()
+			return err
+		}
+		progressReader := progressreader.New(progressreader.Config{
+			In:        layer,
+			Out:       out,
+			Formatter: sf,
+			Size:      int(fi.Size()),
+			NewLines:  false,
+			ID:        stringid.TruncateID(img.ID),
+			Action:    "Loading layer",
+		})
+		err = s.graph.Register(img, progressReader)
+		progressReader.Close()
+		if err != nil {
 			return err
 		}
+		out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Loaded", nil))
 	}
 	logrus.Debugf("Completed processing %s", address)
 
@@ -0,0 +1,88 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/image"
+)
+
+// MigrateImageIDs registers the tagged images whose IDs aren't derived from
+// their content again, under content IDs, and points the tags and digests of
+// the repositories to them. Those are the images registered before content
+// IDs, and the images loaded or pulled from v1 registries, which keep the IDs
+// they come with.
+//
+// The images are left in the graph under their old IDs for the containers
+// created from them. Once untagged, they are removed like any dangling
+// image. The migration can be run again, after a failure or for the images
+// loaded since.
+func (store *TagStore) MigrateImageIDs() error {
+	images, err := store.graph.Map()
+	if err != nil {
+		return err
+	}
+
+	migrated := make(map[string]string)
+	var migrate func(img *image.Image) (string, error)
+	migrate = func(img *image.Image) (string, error) {
+		if id, exists := migrated[img.ID]; exists {
+			return id, nil
+		}
+		var parentID string
+		if img.Parent != "" {
+			parent, exists := images[img.Parent]
+			if !exists {
+				return "", fmt.Errorf("Parent image %s of image %s not found", img.Parent, img.ID)
+			}
+			id, err := migrate(parent)
+			if err != nil {
+				return "", err
+			}
+			parentID = id
+		}
+		dgst, err := img.GetIDDigest(store.graph.ImageRoot(img.ID))
+		if err != nil {
+			return "", err
+		}
+		if dgst != "" && parentID == img.Parent {
+			migrated[img.ID] = img.ID
+			return img.ID, nil
+		}
+
+		layer, err := store.graph.driver.Diff(img.ID, img.Parent)
+		if err != nil {
+			return "", fmt.Errorf("Cannot export the layer of image %s: %s", img.ID, err)
+		}
+		defer layer.Close()
+		newImg := *img
+		newImg.ID = ""
+		newImg.Parent = parentID
+		created, err := store.graph.createContent(&newImg, layer)
+		if err != nil {
+			return "", fmt.Errorf("Cannot migrate image %s: %s", img.ID, err)
+		}
+		logrus.Infof("Migrated image %s to %s", img.ID, created.ID)
+		migrated[img.ID] = created.ID
+		return created.ID, nil
+	}
+
+	store.Lock()
+	defer store.Unlock()
+	for _, repo := range store.Repositories {
+		for ref, id := range repo {
+			img, exists := images[id]
+			if !exists {
+				continue
+			}
+			newID, err := migrate(img)
+			if err != nil {
+				// Keep the tags migrated so far
+				store.save()
+				return err
+			}
+			repo[ref] = newID
+		}
+	}
+	return store.save()
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/docker/utils"
+)
+
+func TestMigrateImageIDs(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	if err := store.MigrateImageIDs(); err != nil {
+		t.Fatal(err)
+	}
+	img, err := store.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID == testOfficialImageID {
+		t.Fatalf("Expected %s to be migrated to a content ID", testOfficialImageName)
+	}
+	dgst, err := img.GetIDDigest(store.graph.ImageRoot(img.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dgst == "" {
+		t.Fatalf("Expected the digest the ID of %s is derived from to be recorded", img.ID)
+	}
+	// The images of both repositories have the same content
+	for _, ref := range []string{testPrivateImageName, testPrivateImageName + "@" + testPrivateImageDigest} {
+		private, err := store.LookupImage(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if private.ID != img.ID {
+			t.Fatalf("Expected %s to be migrated to %s, got %s", ref, img.ID, private.ID)
+		}
+	}
+	// The images with the legacy IDs are left for their containers
+	if !store.graph.Exists(testOfficialImageID) || !store.graph.Exists(testPrivateImageID) {
+		t.Fatal("Expected the images with the legacy IDs to be left in the graph")
+	}
+
+	// The images migrated already are skipped
+	if err := store.MigrateImageIDs(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != img.ID {
+		t.Fatalf("Expected %s to stay %s, got %s", testOfficialImageName, img.ID, again.ID)
+	}
+}
@@ -376,13 +376,99 @@ func WriteStatus(requestedTag string, out io.Writer, sf *streamformatter.StreamF
 type downloadInfo struct {
 	imgJSON    []byte
 	img        *image.Image
-	digest     digest.Digest
+	digest     digest.Digest // The digest of the blob of the layer
 	tmpFile    *os.File
 	length     int64
+	exists     bool // Whether the image was registered by a previous pull
 	downloaded bool
 	err        chan error
 }
 
+// blobImageID returns the ID of img, the image of a v2 manifest with the
+// blob blobSum, on top of the image parentID, or "" if the blob wasn't
+// pulled before, in which case the digest of its layer isn't known.
+func (s *TagStore) blobImageID(img *image.Image, parentID string, blobSum digest.Digest) (string, error) {
+	layerDigest, err := s.graph.blobLayerDigest(blobSum)
+	if err != nil || layerDigest == "" {
+		return "", err
+	}
+	config := *img
+	config.Parent = parentID
+	return image.ContentID(&config, layerDigest)
+}
+
+// downloadV2Blob downloads the blob of di to a temporary file, and verifies
+// its digest. If pool is set, it waits instead for the other clients
+// downloading the blob, and leaves di not downloaded.
+func (s *TagStore) downloadV2Blob(r *registry.Session, out io.Writer, endpoint *registry.Endpoint, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter, auth *registry.RequestAuthorization, di *downloadInfo, verified *bool, pool bool) error {
+	shortID := stringid.TruncateID(di.digest.Hex())
+	logrus.Debugf("pulling blob %q", di.digest)
+
+	if pool {
+		if c, err := s.poolAdd("pull", "blob:"+di.digest.String()); err != nil {
+			if c != nil {
+				out.Write(sf.FormatProgress(shortID, "Layer already being pulled by another client. Waiting.", nil))
+				<-c
+				out.Write(sf.FormatProgress(shortID, "Download complete", nil))
+			} else {
+				logrus.Debugf("Blob %s pull is already running, skipping: %v", di.digest, err)
+			}
+			return nil
+		}
+		defer s.poolRemove("pull", "blob:"+di.digest.String())
+	}
+
+	tmpFile, err := ioutil.TempFile("", "GetV2ImageBlob")
+	if err != nil {
+		return err
+	}
+
+	rc, l, err := r.GetV2ImageBlobReader(endpoint, repoInfo.RemoteName, di.digest.Algorithm(), di.digest.Hex(), auth)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	verifier, err := digest.NewDigestVerifier(di.digest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tmpFile, progressreader.New(progressreader.Config{
+		In:        ioutil.NopCloser(io.TeeReader(rc, verifier)),
+		Out:       out,
+		Formatter: sf,
+		Size:      int(l),
+		NewLines:  false,
+		ID:        shortID,
+		Action:    "Downloading",
+	})); err != nil {
+		return fmt.Errorf("unable to copy v2 image blob data: %s", err)
+	}
+
+	out.Write(sf.FormatProgress(shortID, "Verifying Checksum", nil))
+
+	if !verifier.Verified() {
+		// The layers of an image pulled by digest are only
+		// what the digest says if their blobs are
+		if utils.DigestReference(tag) {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+			return fmt.Errorf("Image verification failed for digest %s: checksum mismatch for layer %s", tag, di.digest)
+		}
+		logrus.Infof("Image verification failed: checksum mismatch for %q", di.digest.String())
+		*verified = false
+	}
+
+	out.Write(sf.FormatProgress(shortID, "Download complete", nil))
+
+	logrus.Debugf("Downloaded blob %s to tempfile %s", di.digest, tmpFile.Name())
+	di.tmpFile = tmpFile
+	di.length = l
+	di.downloaded = true
+	return nil
+}
+
 func (s *TagStore) pullV2Repository(eng *engine.Engine, r *registry.Session, out io.Writer, repoInfo *registry.RepositoryInfo, tag string, sf *streamformatter.StreamFormatter, parallel bool) error {
 	endpoint, err := r.V2RegistryEndpoint(repoInfo.Index)
 	if err != nil {
@@ -455,100 +541,53 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 
 	downloads := make([]downloadInfo, len(manifest.FSLayers))
 
+	// The IDs of the images are derived from the digests of their
+	// uncompressed layers, as those of the images built or imported, so
+	// that a layer is only shared with the images which have the same
+	// content. They are known before the download for the blobs pulled
+	// before only, from the base image up: the IDs of the images above a
+	// layer to download are derived as they are registered.
+	var (
+		parentID string
+		resolved = true
+	)
 	for i := len(manifest.FSLayers) - 1; i >= 0; i-- {
-		var (
-			sumStr  = manifest.FSLayers[i].BlobSum
-			imgJSON = []byte(manifest.History[i].V1Compatibility)
-		)
-
+		imgJSON := []byte(manifest.History[i].V1Compatibility)
 		img, err := image.NewImgJSON(imgJSON)
 		if err != nil {
 			return false, fmt.Errorf("failed to parse json: %s", err)
 		}
-		downloads[i].img = img
-
-		// Check if exists
-		if s.graph.Exists(img.ID) {
-			logrus.Debugf("Image already exists: %s", img.ID)
-			continue
-		}
-
-		dgst, err := digest.ParseDigest(sumStr)
+		dgst, err := digest.ParseDigest(manifest.FSLayers[i].BlobSum)
 		if err != nil {
 			return false, err
 		}
+		downloads[i].img = img
+		downloads[i].imgJSON = imgJSON
 		downloads[i].digest = dgst
 
-		out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Pulling fs layer", nil))
-
-		downloadFunc := func(di *downloadInfo) error {
-			logrus.Debugf("pulling blob %q to V1 img %s", sumStr, img.ID)
-
-			if c, err := s.poolAdd("pull", "img:"+img.ID); err != nil {
-				if c != nil {
-					out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Layer already being pulled by another client. Waiting.", nil))
-					<-c
-					out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Download complete", nil))
-				} else {
-					logrus.Debugf("Image (id: %s) pull is already running, skipping: %v", img.ID, err)
-				}
-			} else {
-				defer s.poolRemove("pull", "img:"+img.ID)
-				tmpFile, err := ioutil.TempFile("", "GetV2ImageBlob")
-				if err != nil {
-					return err
-				}
-
-				r, l, err := r.GetV2ImageBlobReader(endpoint, repoInfo.RemoteName, di.digest.Algorithm(), di.digest.Hex(), auth)
-				if err != nil {
-					return err
-				}
-				defer r.Close()
-
-				verifier, err := digest.NewDigestVerifier(di.digest)
-				if err != nil {
-					return err
-				}
-
-				if _, err := io.Copy(tmpFile, progressreader.New(progressreader.Config{
-					In:        ioutil.NopCloser(io.TeeReader(r, verifier)),
-					Out:       out,
-					Formatter: sf,
-					Size:      int(l),
-					NewLines:  false,
-					ID:        stringid.TruncateID(img.ID),
-					Action:    "Downloading",
-				})); err != nil {
-					return fmt.Errorf("unable to copy v2 image blob data: %s", err)
-				}
-
-				out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Verifying Checksum", nil))
-
-				if !verifier.Verified() {
-					logrus.Infof("Image verification failed: checksum mismatch for %q", di.digest.String())
-					verified = false
-				}
-
-				out.Write(sf.FormatProgress(stringid.TruncateID(img.ID), "Download complete", nil))
-
-				logrus.Debugf("Downloaded %s to tempfile %s", img.ID, tmpFile.Name())
-				di.tmpFile = tmpFile
-				di.length = l
-				di.downloaded = true
+		if resolved {
+			id, err := s.blobImageID(img, parentID, dgst)
+			if err != nil {
+				return false, err
+			}
+			if resolved = id != "" && s.graph.Exists(id); resolved {
+				logrus.Debugf("Image already exists: %s", id)
+				img.ID = id
+				parentID = id
+				downloads[i].exists = true
+				continue
 			}
-			di.imgJSON = imgJSON
-
-			return nil
 		}
 
+		out.Write(sf.FormatProgress(stringid.TruncateID(dgst.Hex()), "Pulling fs layer", nil))
+
 		if parallel {
 			downloads[i].err = make(chan error)
 			go func(di *downloadInfo) {
-				di.err <- downloadFunc(di)
+				di.err <- s.downloadV2Blob(r, out, endpoint, repoInfo, tag, sf, auth, di, &verified, true)
 			}(&downloads[i])
 		} else {
-			err := downloadFunc(&downloads[i])
-			if err != nil {
+			if err := s.downloadV2Blob(r, out, endpoint, repoInfo, tag, sf, auth, &downloads[i], &verified, true); err != nil {
 				return false, err
 			}
 		}
@@ -563,33 +602,56 @@ func (s *TagStore) pullV2Tag(eng *engine.Engine, r *registry.Session, out io.Wri
 				return false, err
 			}
 		}
-		if d.downloaded {
-			// if tmpFile is empty assume download and extracted elsewhere
-			defer os.Remove(d.tmpFile.Name())
-			defer d.tmpFile.Close()
-			d.tmpFile.Seek(0, 0)
-			if d.tmpFile != nil {
-				err = s.graph.Register(d.img,
-					progressreader.New(progressreader.Config{
-						In:        d.tmpFile,
-						Out:       out,
-						Formatter: sf,
-						Size:      int(d.length),
-						ID:        stringid.TruncateID(d.img.ID),
-						Action:    "Extracting",
-					}))
-				if err != nil {
-					return false, err
-				}
+		shortID := stringid.TruncateID(d.digest.Hex())
+		if d.exists {
+			out.Write(sf.FormatProgress(shortID, "Already exists", nil))
+			parentID = d.img.ID
+			continue
+		}
+		d.img.Parent = parentID
+
+		if !d.downloaded {
+			// Another client pulled the blob meanwhile, its image is
+			// ours if it was registered on the same parent
+			id, err := s.blobImageID(d.img, parentID, d.digest)
+			if err != nil {
+				return false, err
+			}
+			if id != "" && s.graph.Exists(id) {
+				out.Write(sf.FormatProgress(shortID, "Already exists", nil))
+				d.img.ID = id
+				parentID = id
+				continue
+			}
+			if err := s.downloadV2Blob(r, out, endpoint, repoInfo, tag, sf, auth, d, &verified, false); err != nil {
+				return false, err
+			}
+		}
 
-				// FIXME: Pool release here for parallel tag pull (ensures any downloads block until fully extracted)
+		defer os.Remove(d.tmpFile.Name())
+		defer d.tmpFile.Close()
+		d.tmpFile.Seek(0, 0)
+		img, err := s.graph.createContent(d.img, progressreader.New(progressreader.Config{
+			In:        d.tmpFile,
+			Out:       out,
+			Formatter: sf,
+			Size:      int(d.length),
+			ID:        shortID,
+			Action:    "Extracting",
+		}))
+		if err != nil {
+			return false, err
+		}
+		if layerDigest, err := img.GetLayerDigest(s.graph.ImageRoot(img.ID)); err == nil && layerDigest != "" {
+			if err := s.graph.setBlobLayerDigest(d.digest, layerDigest); err != nil {
+				logrus.Warnf("Unable to record the layer digest of blob %s: %v", d.digest, err)
 			}
-			out.Write(sf.FormatProgress(stringid.TruncateID(d.img.ID), "Pull complete", nil))
-			tagUpdated = true
-		} else {
-			out.Write(sf.FormatProgress(stringid.TruncateID(d.img.ID), "Already exists", nil))
 		}
+		d.img = img
+		parentID = img.ID
 
+		out.Write(sf.FormatProgress(shortID, "Pull complete", nil))
+		tagUpdated = true
 	}
 
 	// Check for new tag if no layers downloaded
@@ -18,6 +18,7 @@ func (s *TagStore) Install(eng *engine.Engine) error {
 		"image_tarlayer": s.CmdTarLayer,
 		"image_export":   s.CmdImageExport,
 		"history":        s.CmdHistory,
+		"image_verify":   s.CmdImageVerify,
 		"images":         s.CmdImages,
 		"viz":            s.CmdViz,
 		"load":           s.CmdLoad,
@@ -149,6 +150,11 @@ func (s *TagStore) CmdLookup(job *engine.Job) error {
 		out.Set("Os", image.OS)
 		out.SetInt64("Size", image.Size)
 		out.SetInt64("VirtualSize", image.GetParentsSize(0)+image.Size)
+		layerDigest, err := image.GetLayerDigest(s.graph.ImageRoot(image.ID))
+		if err != nil {
+			return err
+		}
+		out.Set("LayerDigest", layerDigest.String())
 		if _, err = out.WriteTo(job.Stdout); err != nil {
 			return err
 		}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/docker/docker/image"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/streamformatter"
 	"github.com/docker/docker/pkg/stringid"
 	"github.com/docker/docker/registry"
 	"github.com/docker/docker/utils"
@@ -222,10 +223,10 @@ func (store *TagStore) Delete(repoName, ref string) (bool, error) {
 }
 
 func (store *TagStore) Set(repoName, tag, imageName string, force bool) error {
-	return store.SetLoad(repoName, tag, imageName, force, nil)
+	return store.SetLoad(repoName, tag, imageName, force, nil, nil)
 }
 
-func (store *TagStore) SetLoad(repoName, tag, imageName string, force bool, out io.Writer) error {
+func (store *TagStore) SetLoad(repoName, tag, imageName string, force bool, out io.Writer, sf *streamformatter.StreamFormatter) error {
 	img, err := store.LookupImage(imageName)
 	store.Lock()
 	defer store.Unlock()
@@ -256,7 +257,7 @@ func (store *TagStore) SetLoad(repoName, tag, imageName string, force bool, out
 
 			if old != img.ID && out != nil {
 
-				fmt.Fprintf(out, "The image %s:%s already exists, renaming the old one with ID %s to empty string\n", repoName, tag, old[:12])
+				out.Write(sf.FormatStatus("", "The image %s:%s already exists, renaming the old one with ID %s to empty string", repoName, tag, old[:12]))
 
 			}
 		}
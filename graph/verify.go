@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/engine"
+	"github.com/docker/docker/image"
+)
+
+// CmdImageVerify computes the sums of the layers of an image and of its
+// parents from the storage driver again, and compares them to the ones
+// recorded when the layers were registered, to find the layers whose
+// content changed since.
+func (s *TagStore) CmdImageVerify(job *engine.Job) error {
+	if n := len(job.Args); n != 1 {
+		return fmt.Errorf("Usage: %s IMAGE", job.Name)
+	}
+	foundImage, err := s.LookupImage(job.Args[0])
+	if err != nil {
+		return err
+	}
+	if foundImage == nil {
+		return fmt.Errorf("No such image: %s", job.Args[0])
+	}
+
+	verification := types.ImageVerification{
+		ID:     foundImage.ID,
+		Layers: []types.LayerVerification{},
+	}
+	err = foundImage.WalkHistory(func(img *image.Image) error {
+		layer, err := s.verifyLayer(img)
+		if err != nil {
+			return err
+		}
+		verification.Layers = append(verification.Layers, layer)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(job.Stdout).Encode(verification)
+}
+
+func (s *TagStore) verifyLayer(img *image.Image) (types.LayerVerification, error) {
+	layer := types.LayerVerification{ID: img.ID}
+	expected, err := img.GetLayerSum(s.graph.ImageRoot(img.ID))
+	if err != nil {
+		return layer, err
+	}
+	if expected == "" {
+		// The layer digest, if any, is the one of the stream the layer was
+		// registered with, which the driver can't reproduce to compare it
+		layer.Status = "unverified"
+		return layer, nil
+	}
+
+	// The layers registered before LayerSum have their tarsums recorded
+	var actual string
+	if strings.HasPrefix(expected, "tarsum.") {
+		actual, err = img.ComputeLayerTarsum()
+	} else {
+		actual, err = img.ComputeLayerSum()
+	}
+	if err != nil {
+		return layer, fmt.Errorf("Error computing the sum of layer %s: %s", img.ID, err)
+	}
+	layer.Expected, layer.Actual = expected, actual
+	if actual != expected {
+		logrus.Errorf("Layer %s is corrupted: its sum is %s instead of %s", img.ID, actual, expected)
+		layer.Status = "corrupted"
+	} else {
+		layer.Status = "ok"
+	}
+	return layer, nil
+}
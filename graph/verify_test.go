@@ -0,0 +1,79 @@
+package graph
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/docker/docker/utils"
+)
+
+func TestVerifyLayer(t *testing.T) {
+	tmp, err := utils.TestDirectory("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	store := mkTestTagStore(tmp, t)
+	defer store.graph.driver.Cleanup()
+
+	img, err := store.LookupImage(testOfficialImageName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layer, err := store.verifyLayer(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layer.Status != "ok" || layer.Actual != layer.Expected {
+		t.Fatalf("Expected the layer to be verified, got %+v", layer)
+	}
+
+	rootfs, err := store.graph.driver.Get(img.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(path.Join(rootfs, "etc", "passwd"), []byte("Hello world?\n"), 0644)
+	store.graph.driver.Put(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layer, err = store.verifyLayer(img); err != nil {
+		t.Fatal(err)
+	}
+	if layer.Status != "corrupted" || layer.Actual == layer.Expected {
+		t.Fatalf("Expected the modified layer to be corrupted, got %+v", layer)
+	}
+
+	// The layers registered before LayerSum are verified with their tarsums
+	root := store.graph.ImageRoot(img.ID)
+	tarsum, err := img.ComputeLayerTarsum()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path.Join(root, "layersum")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path.Join(root, "layertarsum"), []byte(tarsum), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if layer, err = store.verifyLayer(img); err != nil {
+		t.Fatal(err)
+	}
+	if layer.Status != "ok" || layer.Expected != tarsum {
+		t.Fatalf("Expected the layer to be verified with its tarsum, got %+v", layer)
+	}
+
+	// The layers registered before their sums were recorded can't be
+	// verified, even with their digests
+	if err := os.Remove(path.Join(root, "layertarsum")); err != nil {
+		t.Fatal(err)
+	}
+	if layer, err = store.verifyLayer(img); err != nil {
+		t.Fatal(err)
+	}
+	if layer.Status != "unverified" {
+		t.Fatalf("Expected the layer to be unverified, got %+v", layer)
+	}
+}
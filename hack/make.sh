@@ -106,6 +106,15 @@ if \
 	DOCKER_BUILDTAGS+=' btrfs_noversion'
 fi
 
+# test whether libdevmapper supports deferred removal, as of 1.02.89, and
+# apply libdm_no_deferred_remove appropriately
+if \
+	command -v gcc &> /dev/null \
+	&& ! ( echo -e '#include <libdevmapper.h>\nint main() { dm_task_deferred_remove(NULL); }' | gcc -o /dev/null -xc - -ldevmapper &> /dev/null ) \
+; then
+	DOCKER_BUILDTAGS+=' libdm_no_deferred_remove'
+fi
+
 # Use these flags when compiling the tests and final binary
 
 IAMSTATIC='true'
@@ -57,6 +57,12 @@ clone git github.com/Sirupsen/logrus v0.7.2
 
 clone git github.com/go-fsnotify/fsnotify v1.0.4
 
+clone git github.com/samuel/go-zookeeper 7117e9ea2414
+
+clone git github.com/miekg/dns 79bfde677fa8
+
+clone git github.com/BurntSushi/toml v0.3.1
+
 # get Go tip's archive/tar, for xattr support and improved performance
 # TODO after Go 1.4 drops, bump our minimum supported version and drop this vendored dep
 if [ "$1" = '--go' ]; then
@@ -75,6 +81,25 @@ rm -rf src/github.com/docker/distribution
 mkdir -p src/github.com/docker/distribution
 mv tmp-digest src/github.com/docker/distribution/digest
 
+# get the KV clients of consul and etcd, without the tests, which need the servers
+clone git github.com/hashicorp/consul v0.5.2
+mv src/github.com/hashicorp/consul/api tmp-consul-api
+rm -rf src/github.com/hashicorp/consul
+mkdir -p src/github.com/hashicorp/consul
+mv tmp-consul-api src/github.com/hashicorp/consul/api
+rm src/github.com/hashicorp/consul/api/*_test.go
+
+clone git github.com/coreos/etcd v2.1.1
+mv src/github.com/coreos/etcd/client tmp-etcd-client
+mv src/github.com/coreos/etcd/pkg/types tmp-etcd-types
+mv src/github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net/context tmp-etcd-context
+rm -rf src/github.com/coreos/etcd
+mkdir -p src/github.com/coreos/etcd/pkg src/github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net
+mv tmp-etcd-client src/github.com/coreos/etcd/client
+mv tmp-etcd-types src/github.com/coreos/etcd/pkg/types
+mv tmp-etcd-context src/github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net/context
+rm src/github.com/coreos/etcd/client/*_test.go src/github.com/coreos/etcd/pkg/types/*_test.go
+
 clone git github.com/docker/libcontainer bd8ec36106086f72b66e1be85a81202b93503e44
 # see src/github.com/docker/libcontainer/update-vendor.sh which is the "source of truth" for libcontainer deps (just like this file)
 rm -rf src/github.com/docker/libcontainer/vendor
@@ -1,15 +1,20 @@
 package image
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"strconv"
 	"time"
 
+	"github.com/docker/distribution/digest"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/tarsum"
 	"github.com/docker/docker/runconfig"
 	"github.com/docker/docker/utils"
 )
@@ -80,9 +85,48 @@ func LoadImage(root string) (*Image, error) {
 // image's registered storage driver. Image metadata is stored in a file
 // at the specified root directory.
 func StoreImage(img *Image, layerData archive.ArchiveReader, root string) (err error) {
-	// Store the layer. If layerData is not nil, unpack it into the new layer
+	// Store the layer. If layerData is not nil, unpack it into the new layer,
+	// and digest and sum its uncompressed content on the way
 	if layerData != nil {
-		if img.Size, err = img.graph.Driver().ApplyDiff(img.ID, img.Parent, layerData); err != nil {
+		layer, err := archive.DecompressStream(layerData)
+		if err != nil {
+			return err
+		}
+		defer layer.Close()
+
+		var (
+			digester = digest.NewCanonicalDigester()
+			pr, pw   = io.Pipe()
+			sum      string
+			sumErr   error
+			summed   = make(chan struct{})
+		)
+		go func() {
+			sum, sumErr = LayerSum(pr)
+			// Whatever LayerSum left, for the writes to the pipe to return
+			io.Copy(ioutil.Discard, pr)
+			close(summed)
+		}()
+		content := io.TeeReader(layer, io.MultiWriter(&digester, pw))
+		img.Size, err = img.graph.Driver().ApplyDiff(img.ID, img.Parent, content)
+		if err == nil {
+			// The padding at the end of the tar stream may not have been read
+			_, err = io.Copy(ioutil.Discard, content)
+		}
+		pw.CloseWithError(err)
+		<-summed
+		if err != nil {
+			return err
+		}
+		if sumErr != nil {
+			return fmt.Errorf("Error computing the sum of layer %s: %s", img.ID, sumErr)
+		}
+		if err := img.SaveLayerDigest(root, digester.Digest()); err != nil {
+			return err
+		}
+		// The sum of the stream is the one of the layer as the driver stores
+		// it, which can be computed again later on to verify its content
+		if err := img.SaveLayerSum(root, sum); err != nil {
 			return err
 		}
 	}
@@ -131,6 +175,131 @@ func (img *Image) GetCheckSum(root string) (string, error) {
 	return string(cs), err
 }
 
+// SaveLayerDigest stores the digest of the content of the layer of img, its
+// uncompressed tar stream, in the directory root.
+func (img *Image) SaveLayerDigest(root string, dgst digest.Digest) error {
+	if err := ioutil.WriteFile(path.Join(root, "layerdigest"), []byte(dgst), 0600); err != nil {
+		return fmt.Errorf("Error storing layer digest in %s/layerdigest: %s", root, err)
+	}
+	return nil
+}
+
+// GetLayerDigest returns the digest of the content of the layer of img
+// stored in the directory root, or "" if the image was registered before
+// the digests of the layers were recorded.
+func (img *Image) GetLayerDigest(root string) (digest.Digest, error) {
+	dgst, err := ioutil.ReadFile(path.Join(root, "layerdigest"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return digest.ParseDigest(string(dgst))
+}
+
+// SaveIDDigest stores the digest the ID of img is derived from, by
+// ContentID, in the directory root.
+func (img *Image) SaveIDDigest(root string, dgst digest.Digest) error {
+	if err := ioutil.WriteFile(path.Join(root, "iddigest"), []byte(dgst), 0600); err != nil {
+		return fmt.Errorf("Error storing ID digest in %s/iddigest: %s", root, err)
+	}
+	return nil
+}
+
+// GetIDDigest returns the digest the ID of img stored in the directory root
+// is derived from, or "" if the ID of the image isn't a content ID.
+func (img *Image) GetIDDigest(root string) (digest.Digest, error) {
+	dgst, err := ioutil.ReadFile(path.Join(root, "iddigest"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return digest.ParseDigest(string(dgst))
+}
+
+// ContentID returns the ID of img derived from the digest of the content of
+// its layer, the ID of its parent and its configuration. Images registered
+// with the same layer on the same parent with the same configuration get the
+// same ID, whichever registry or archive they come from.
+func ContentID(img *Image, layerDigest digest.Digest) (string, error) {
+	config := *img
+	config.ID = ""
+	config.Parent = ""
+	config.Size = 0
+	b, err := json.Marshal(&config)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", img.Parent, layerDigest)
+	h.Write(b)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SaveLayerSum stores the sum of the content of the layer of img, as the
+// storage driver stores it, in the directory root.
+func (img *Image) SaveLayerSum(root, sum string) error {
+	if err := ioutil.WriteFile(path.Join(root, "layersum"), []byte(sum), 0600); err != nil {
+		return fmt.Errorf("Error storing layer sum in %s/layersum: %s", root, err)
+	}
+	return nil
+}
+
+// GetLayerSum returns the sum of the content of the layer of img stored in
+// the directory root, a tarsum for the layers registered before LayerSum,
+// or "" if the image was registered before the sums of the layers were
+// recorded. The layer digest can't stand in for it: the storage drivers
+// don't reproduce the stream it is the digest of.
+func (img *Image) GetLayerSum(root string) (string, error) {
+	sum, err := ioutil.ReadFile(path.Join(root, "layersum"))
+	if os.IsNotExist(err) {
+		// The tarsums were stored in layertarsum
+		sum, err = ioutil.ReadFile(path.Join(root, "layertarsum"))
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(sum), nil
+}
+
+// ComputeLayerSum computes the sum of the content of the layer of img from
+// the storage driver, with LayerSum. It is the sum stored for img as long as
+// the content of the layer is left untouched.
+func (img *Image) ComputeLayerSum() (string, error) {
+	layer, err := img.TarLayer()
+	if err != nil {
+		return "", err
+	}
+	defer layer.Close()
+	return LayerSum(layer)
+}
+
+// ComputeLayerTarsum computes the tarsum of the content of the layer of img
+// from the storage driver, the sum stored for the layers registered before
+// LayerSum.
+func (img *Image) ComputeLayerTarsum() (string, error) {
+	layer, err := img.TarLayer()
+	if err != nil {
+		return "", err
+	}
+	defer layer.Close()
+
+	ts, err := tarsum.NewTarSum(layer, true, tarsum.Version1)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(ioutil.Discard, ts); err != nil {
+		return "", err
+	}
+	return ts.Sum(nil), nil
+}
+
 func jsonPath(root string) string {
 	return path.Join(root, "json")
 }
@@ -0,0 +1,72 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+)
+
+// layerSumPrefix identifies the sums computed by LayerSum, as opposed to the
+// tarsums recorded for the layers registered before.
+const layerSumPrefix = "layersum.v1+sha256:"
+
+// LayerSum computes the sum of the content of the layer given as the tar
+// stream r. It is the same for the stream a layer is registered with and for
+// the diff the storage driver gives of it later on, which can differ in the
+// order of their entries and in the directories they hold: only the entries
+// other than directories count, by their names, permissions, owners, link
+// targets, extended attributes and contents.
+func LayerSum(r io.Reader) (string, error) {
+	var (
+		tr   = tar.NewReader(r)
+		sums []string
+	)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		// The metadata of aufs, such as .wh..wh.aufs, is not content
+		if hdr.Typeflag == tar.TypeDir || strings.HasPrefix(path.Base(hdr.Name), ".wh..wh.") {
+			continue
+		}
+		typeflag, linkname := hdr.Typeflag, hdr.Linkname
+		if typeflag == tar.TypeRegA {
+			typeflag = tar.TypeReg
+		}
+		if typeflag == tar.TypeLink {
+			linkname = path.Clean("/" + linkname)
+		}
+		h := sha256.New()
+		fmt.Fprintf(h, "%s\x00%c\x00%o\x00%d\x00%d\x00%s\x00%d\x00%d\x00",
+			path.Clean("/"+hdr.Name), typeflag, hdr.Mode&07777, hdr.Uid, hdr.Gid, linkname, hdr.Devmajor, hdr.Devminor)
+		keys := make([]string, 0, len(hdr.Xattrs))
+		for k := range hdr.Xattrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s=%s\x00", k, hdr.Xattrs[k])
+		}
+		if _, err := io.Copy(h, tr); err != nil {
+			return "", err
+		}
+		sums = append(sums, hex.EncodeToString(h.Sum(nil)))
+	}
+
+	sort.Strings(sums)
+	h := sha256.New()
+	for _, sum := range sums {
+		io.WriteString(h, sum)
+	}
+	return layerSumPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
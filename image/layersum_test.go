@@ -0,0 +1,69 @@
+package image
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
+)
+
+type testEntry struct {
+	hdr     tar.Header
+	content string
+}
+
+func testLayer(t *testing.T, entries ...testEntry) io.Reader {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		hdr := e.hdr
+		hdr.Size = int64(len(e.content))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func layerSum(t *testing.T, entries ...testEntry) string {
+	sum, err := LayerSum(testLayer(t, entries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+// The stream a layer is registered with and the diff of the storage driver
+// differ in the order of their entries, and in the directories they hold.
+func TestLayerSumOfRegisteredStreamAndDiff(t *testing.T) {
+	var (
+		dir    = testEntry{hdr: tar.Header{Name: "etc/", Typeflag: tar.TypeDir, Mode: 0755}}
+		passwd = testEntry{hdr: tar.Header{Name: "etc/passwd", Typeflag: tar.TypeRegA, Mode: 0644}, content: "root:x:0:0::/root:/bin/sh\n"}
+		group  = testEntry{hdr: tar.Header{Name: "./etc/group", Typeflag: tar.TypeReg, Mode: 0644}, content: "root:x:0:\n"}
+		link   = testEntry{hdr: tar.Header{Name: "etc/mtab", Typeflag: tar.TypeSymlink, Linkname: "/proc/mounts", Mode: 0777}}
+		aufs   = testEntry{hdr: tar.Header{Name: ".wh..wh.aufs", Typeflag: tar.TypeReg, Mode: 0600}}
+	)
+	registered := layerSum(t, dir, passwd, group, link, aufs)
+	diff := layerSum(t, testEntry{hdr: tar.Header{Name: "/etc/group", Typeflag: tar.TypeReg, Mode: 0644}, content: group.content}, link, passwd)
+	if registered != diff {
+		t.Fatalf("Expected the sums of the stream and of the diff to match, got %s and %s", registered, diff)
+	}
+
+	for _, changed := range []testEntry{
+		{hdr: passwd.hdr, content: "root:x:0:0::/root:/bin/bash\n"},
+		{hdr: tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0600}, content: passwd.content},
+		{hdr: tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0644, Uid: 1000}, content: passwd.content},
+		{hdr: tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0644, Xattrs: map[string]string{"security.capability": "x"}}, content: passwd.content},
+	} {
+		if sum := layerSum(t, dir, changed, group, link); sum == registered {
+			t.Fatalf("Expected the sum to change with %+v", changed.hdr)
+		}
+	}
+}
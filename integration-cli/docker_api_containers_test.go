@@ -320,6 +320,47 @@ func TestGetContainerStats(t *testing.T) {
 	logDone("container REST API - check GET containers/stats")
 }
 
+func TestGetContainerStatsNoStream(t *testing.T) {
+	defer deleteAllContainers()
+	var (
+		name   = "statscontainer"
+		runCmd = exec.Command(dockerBinary, "run", "-d", "--name", name, "busybox", "top")
+	)
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("Error on container creation: %v, output: %q", err, out)
+	}
+	type b struct {
+		body []byte
+		err  error
+	}
+	bc := make(chan b, 1)
+	go func() {
+		body, err := sockRequest("GET", "/containers/"+name+"/stats?stream=0", nil)
+		bc <- b{body, err}
+	}()
+
+	// the request must return on its own while the container keeps running
+	select {
+	case <-time.After(10 * time.Second):
+		t.Fatal("stream was not closed after the first sample")
+	case sr := <-bc:
+		if sr.err != nil {
+			t.Fatal(sr.err)
+		}
+
+		dec := json.NewDecoder(bytes.NewBuffer(sr.body))
+		var s *types.Stats
+		if err := dec.Decode(&s); err != nil {
+			t.Fatal(err)
+		}
+		if err := dec.Decode(&s); err != io.EOF {
+			t.Fatalf("Expected a single sample, got %v", err)
+		}
+	}
+	logDone("container REST API - check GET containers/stats with stream=0")
+}
+
 func TestGetStoppedContainerStats(t *testing.T) {
 	defer deleteAllContainers()
 	var (
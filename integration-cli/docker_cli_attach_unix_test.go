@@ -137,3 +137,67 @@ func TestAttachAfterDetach(t *testing.T) {
 
 	logDone("attach - reconnect after detaching")
 }
+
+func TestAttachDetachKeys(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-dti", "busybox", "cat"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+	if err := waitRun(id); err != nil {
+		t.Fatal(err)
+	}
+
+	cpty, tty, err := pty.Open()
+	if err != nil {
+		t.Fatalf("Could not open pty: %v", err)
+	}
+	defer cpty.Close()
+	cmd := exec.Command(dockerBinary, "attach", "--detach-keys=ctrl-a,a", id)
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	detached := make(chan error)
+	go func() {
+		detached <- cmd.Wait()
+	}()
+
+	time.Sleep(500 * time.Millisecond)
+	// ctrl-p ctrl-q no longer detaches
+	for _, key := range []byte{16, 17, 1, 'a'} {
+		cpty.Write([]byte{key})
+		time.Sleep(100 * time.Millisecond)
+		if key == 17 {
+			select {
+			case err := <-detached:
+				t.Fatalf("Expected ctrl-p ctrl-q not to detach, attach returned %v", err)
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	}
+
+	select {
+	case err := <-detached:
+		if err != nil {
+			t.Fatalf("attach returned error %s", err)
+		}
+	case <-time.After(attachWait):
+		t.Fatal("timed out without detaching")
+	}
+
+	if running, err := inspectField(id, "State.Running"); err != nil || running != "true" {
+		t.Fatalf("Expected the container to keep running after detaching, got %s %v", running, err)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "attach", "--detach-keys=ctrl-1", id)); err == nil {
+		t.Fatalf("Expected invalid detach keys to fail, got %s", out)
+	}
+
+	logDone("attach - detach with custom keys")
+}
@@ -5627,3 +5627,27 @@ func TestBuildEmptyStringVolume(t *testing.T) {
 
 	logDone("build - empty string volume")
 }
+
+func TestBuildStopSignal(t *testing.T) {
+	name := "testbuildstopsignal"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		 STOPSIGNAL SIGKILL`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := inspectField(name, "Config.StopSignal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "SIGKILL" {
+		t.Fatalf("StopSignal %s, expected SIGKILL", res)
+	}
+
+	if _, err := buildImage(name+"bad", "FROM busybox\nSTOPSIGNAL SIGFOO", true); err == nil {
+		t.Fatal("Expected the build to fail with an invalid stop signal")
+	}
+	logDone("build - stop signal")
+}
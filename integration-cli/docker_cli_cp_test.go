@@ -620,3 +620,116 @@ func TestCpToStdout(t *testing.T) {
 	}
 	logDone("cp - to stdout")
 }
+
+func TestCpToContainer(t *testing.T) {
+	out, exitCode, err := dockerCmd(t, "run", "-d", "busybox", "top")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("failed to create a container:%s\n%s", out, err)
+	}
+	cID := strings.TrimSpace(out)
+	defer deleteContainer(cID)
+
+	tmpdir, err := ioutil.TempDir("", "docker-integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+	if err := os.Mkdir(filepath.Join(tmpdir, "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	hostFile := filepath.Join(tmpdir, "conf", "app.conf")
+	if err := ioutil.WriteFile(hostFile, []byte(cpHostContents), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, _, err := dockerCmd(t, "cp", hostFile, cID+":/tmp"); err != nil {
+		t.Fatalf("failed to copy a file into the container: %s, %v", out, err)
+	}
+	out, _, err = dockerCmd(t, "exec", cID, "cat", "/tmp/app.conf")
+	if err != nil || out != cpHostContents {
+		t.Fatalf("Wrong content in the copied file %q, %v", out, err)
+	}
+
+	if out, _, err := dockerCmd(t, "cp", filepath.Join(tmpdir, "conf"), cID+":/"); err != nil {
+		t.Fatalf("failed to copy a directory into the container: %s, %v", out, err)
+	}
+	out, _, err = dockerCmd(t, "exec", cID, "cat", "/conf/app.conf")
+	if err != nil || out != cpHostContents {
+		t.Fatalf("Wrong content in the copied directory %q, %v", out, err)
+	}
+
+	cpCmd := exec.Command(dockerBinary, "cp", hostFile, cID+":/tmp/app.conf")
+	if out, _, err := runCommandWithOutput(cpCmd); err == nil || !strings.Contains(out, "not a directory") {
+		t.Fatalf("Expected an error copying into a file, got %s, %v", out, err)
+	}
+
+	cpCmd = exec.Command(dockerBinary, "cp", hostFile, cID+":/nonexistent")
+	if out, _, err := runCommandWithOutput(cpCmd); err == nil {
+		t.Fatalf("Expected an error copying into a missing directory, got %s", out)
+	}
+
+	logDone("cp - from the host into a container")
+}
+
+func TestCpToContainerReadonlyRootfs(t *testing.T) {
+	out, exitCode, err := dockerCmd(t, "run", "-d", "--read-only", "-v", "/data", "busybox", "top")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("failed to create a container:%s\n%s", out, err)
+	}
+	cID := strings.TrimSpace(out)
+	defer deleteContainer(cID)
+
+	tmpfile, err := ioutil.TempFile("", "docker-integration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpfile.Close()
+	defer os.Remove(tmpfile.Name())
+
+	cpCmd := exec.Command(dockerBinary, "cp", tmpfile.Name(), cID+":/tmp")
+	if out, _, err := runCommandWithOutput(cpCmd); err == nil || !strings.Contains(out, "read-only") {
+		t.Fatalf("Expected an error copying into a read-only container, got %s, %v", out, err)
+	}
+
+	// The volume at /data is writable, but not the directory the path
+	// resolves to
+	cpCmd = exec.Command(dockerBinary, "cp", tmpfile.Name(), cID+":/data/../tmp")
+	if out, _, err := runCommandWithOutput(cpCmd); err == nil || !strings.Contains(out, "read-only") {
+		t.Fatalf("Expected an error copying out of a volume into a read-only container, got %s, %v", out, err)
+	}
+
+	logDone("cp - from the host into a read-only container")
+}
+
+func TestCpFromStdin(t *testing.T) {
+	out, exitCode, err := dockerCmd(t, "run", "-d", "busybox", "/bin/sh", "-c", "mkdir -p /data && echo lololol > /data/test && top")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("failed to create a container:%s\n%s", out, err)
+	}
+	cID := strings.TrimSpace(out)
+	defer deleteContainer(cID)
+
+	out, exitCode, err = dockerCmd(t, "run", "-d", "busybox", "top")
+	if err != nil || exitCode != 0 {
+		t.Fatalf("failed to create a container:%s\n%s", out, err)
+	}
+	dstID := strings.TrimSpace(out)
+	defer deleteContainer(dstID)
+
+	// The archive written to STDOUT by one container is compressed, and
+	// extracted into the other from STDIN.
+	out, _, err = runCommandPipelineWithOutput(
+		exec.Command(dockerBinary, "cp", cID+":/data", "-"),
+		exec.Command("gzip"),
+		exec.Command(dockerBinary, "cp", "-", dstID+":/tmp"))
+	if err != nil {
+		t.Fatalf("Failed to run commands: %s, %s", out, err)
+	}
+
+	out, _, err = dockerCmd(t, "exec", dstID, "cat", "/tmp/data/test")
+	if err != nil || out != "lololol\n" {
+		t.Fatalf("Wrong content in the copied file %q, %v", out, err)
+	}
+
+	logDone("cp - from stdin")
+}
@@ -305,3 +305,15 @@ func TestCreateLabelFromImage(t *testing.T) {
 
 	logDone("create - labels from image")
 }
+
+func TestCreateRmWithRestartPolicy(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "create", "--rm", "--restart=always", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil || !strings.Contains(out, "Conflicting options: --restart and --rm") {
+		t.Fatalf("Expected docker create to fail with conflicting options, got %s, %v", out, err)
+	}
+
+	logDone("create - --rm conflicts with a restart policy")
+}
@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
@@ -232,6 +233,147 @@ func TestDaemonIptablesCreate(t *testing.T) {
 	logDone("daemon - run,iptables - iptables rules for always restarted container created after daemon restart")
 }
 
+func TestDaemonRestartUnlessStopped(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "running", "--restart=unless-stopped", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run top: %s, %v", out, err)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "stopped", "--restart=unless-stopped", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run top: %s, %v", out, err)
+	}
+	if out, err := d.Cmd("stop", "stopped"); err != nil {
+		t.Fatalf("Could not stop container: %s, %v", out, err)
+	}
+
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	for name, expected := range map[string]string{"running": "true", "stopped": "false"} {
+		out, err := d.Cmd("inspect", "--format={{.State.Running}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: %s, %v", name, out, err)
+		}
+		if strings.TrimSpace(out) != expected {
+			t.Fatalf("Expected %s to be running: %s after the daemon restart, got %q", name, expected, strings.TrimSpace(out))
+		}
+	}
+
+	// Starting the container again makes the daemon restart it.
+	if out, err := d.Cmd("start", "stopped"); err != nil {
+		t.Fatalf("Could not start container: %s, %v", out, err)
+	}
+	if err := d.Restart(); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+	out, err := d.Cmd("inspect", "--format={{.State.Running}}", "stopped")
+	if err != nil {
+		t.Fatalf("Could not inspect stopped: %s, %v", out, err)
+	}
+	if strings.TrimSpace(out) != "true" {
+		t.Fatalf("Expected the restarted container to be running after the daemon restart, got %q", strings.TrimSpace(out))
+	}
+
+	logDone("daemon - unless-stopped containers are restarted with the daemon unless they were stopped")
+}
+
+func TestDaemonRestartContainersWithLinks(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox(); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	if out, err := d.Cmd("run", "-d", "--name", "db", "--restart=always", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run top: %s, %v", out, err)
+	}
+	if out, err := d.Cmd("run", "-d", "--name", "web", "--link", "db:db", "--restart=always", "busybox:latest", "top"); err != nil {
+		t.Fatalf("Could not run top: %s, %v", out, err)
+	}
+
+	since := time.Now().Unix()
+	if err := d.Restart("--restart-concurrency=1"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	for _, name := range []string{"db", "web"} {
+		out, err := d.Cmd("inspect", "--format={{.State.Running}}", name)
+		if err != nil {
+			t.Fatalf("Could not inspect %s: %s, %v", name, out, err)
+		}
+		if strings.TrimSpace(out) != "true" {
+			t.Fatalf("Expected %s to be restarted with the daemon, got %q", name, strings.TrimSpace(out))
+		}
+	}
+
+	out, err := d.Cmd("events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", time.Now().Unix()+1))
+	if err != nil {
+		t.Fatalf("Could not get events: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "daemon: restart_containers: 2/2") {
+		t.Fatalf("Expected the progress of the restart in the events, got %q", out)
+	}
+
+	logDone("daemon - containers are restarted with the daemon after the containers they link to")
+}
+
+func TestDaemonLiveRestore(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--live-restore"); err != nil {
+		t.Fatalf("Could not start daemon with busybox: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("run", "-d", "--name", "live", "busybox:latest", "sh", "-c", "echo before; sleep 3; echo after; exec top")
+	if err != nil {
+		t.Fatalf("Could not run container: %s, %v", out, err)
+	}
+	pid, err := d.Cmd("inspect", "--format={{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect live: %s, %v", pid, err)
+	}
+
+	if err := d.Restart("--live-restore"); err != nil {
+		t.Fatalf("Could not restart daemon: %v", err)
+	}
+
+	out, err = d.Cmd("inspect", "--format={{.State.Running}} {{.State.Pid}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect live: %s, %v", out, err)
+	}
+	if expected := "true " + strings.TrimSpace(pid); strings.TrimSpace(out) != expected {
+		t.Fatalf("Expected the container to keep running as %q, got %q", expected, strings.TrimSpace(out))
+	}
+
+	// The output written while the daemon was down or after it restarted is logged.
+	time.Sleep(4 * time.Second)
+	out, err = d.Cmd("logs", "live")
+	if err != nil {
+		t.Fatalf("Could not get logs: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "before") || !strings.Contains(out, "after") {
+		t.Fatalf("Expected the logs to have the output of before and after the restart, got %q", out)
+	}
+
+	if out, err := d.Cmd("stop", "live"); err != nil {
+		t.Fatalf("Could not stop container: %s, %v", out, err)
+	}
+	out, err = d.Cmd("inspect", "--format={{.State.Running}}", "live")
+	if err != nil {
+		t.Fatalf("Could not inspect live: %s, %v", out, err)
+	}
+	if strings.TrimSpace(out) != "false" {
+		t.Fatalf("Expected the restored container to be stopped, got %q", strings.TrimSpace(out))
+	}
+
+	logDone("daemon - containers keep running across a daemon restart with --live-restore")
+}
+
 func TestDaemonLoggingLevel(t *testing.T) {
 	d := NewDaemon(t)
 
@@ -896,3 +1038,142 @@ func TestDaemonwithwrongkey(t *testing.T) {
 	os.Remove("/etc/docker/key.json")
 	logDone("daemon - it should be failed to start daemon with wrong key")
 }
+
+func TestDaemonConfigFile(t *testing.T) {
+	d := NewDaemon(t)
+	configFile := filepath.Join(d.folder, "daemon.json")
+	if err := ioutil.WriteFile(configFile, []byte(`{"label": ["foo=bar"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start("--config-file", configFile); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("info")
+	if err != nil {
+		t.Fatalf("Could not get info: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "foo=bar") {
+		t.Fatalf("Expected the labels of the configuration file, got %q", out)
+	}
+
+	// The labels are reloaded on SIGHUP.
+	if err := ioutil.WriteFile(configFile, []byte(`{"label": ["foo=baz"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.cmd.Process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		if out, err = d.Cmd("info"); err != nil {
+			t.Fatalf("Could not get info: %s, %v", out, err)
+		}
+		if strings.Contains(out, "foo=baz") {
+			break
+		}
+		if i == 20 {
+			t.Fatalf("Expected the labels to be reloaded, got %q", out)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if strings.Contains(out, "foo=bar") {
+		t.Fatalf("Expected the previous labels to be removed, got %q", out)
+	}
+
+	logDone("daemon - options are read from the configuration file and reloaded on SIGHUP")
+}
+
+func TestDaemonConfigFileConflict(t *testing.T) {
+	d := NewDaemon(t)
+	configFile := filepath.Join(d.folder, "daemon.json")
+	if err := ioutil.WriteFile(configFile, []byte(`{"label": ["foo=bar"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start("--config-file", configFile, "--label", "foo=baz"); err == nil {
+		d.Stop()
+		t.Fatal("Expected the daemon to fail to start with an option both on the command line and in the configuration file")
+	}
+
+	content, _ := ioutil.ReadFile(d.logFile.Name())
+	if !strings.Contains(string(content), "both on the command line and in") {
+		t.Fatalf("Expected the conflicting options in the daemon logs, got %q", content)
+	}
+
+	logDone("daemon - options can't be both on the command line and in the configuration file")
+}
+
+func TestDaemonRuntimes(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.Start("--add-runtime", "fake=/bin/true"); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("info")
+	if err != nil {
+		t.Fatalf("Could not get info: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "Runtimes: fake native") || !strings.Contains(out, "Default Runtime: native") {
+		t.Fatalf("Expected the runtimes of the daemon, got %q", out)
+	}
+
+	if out, err := d.Cmd("create", "--runtime", "unknown", "busybox", "true"); err == nil || !strings.Contains(out, "Unknown runtime unknown") {
+		t.Fatalf("Expected creating a container with an unknown runtime to fail, got %s, %v", out, err)
+	}
+	if out, err := d.Cmd("create", "--name", "test", "--runtime", "fake", "busybox", "true"); err != nil {
+		t.Fatalf("Could not create a container with the fake runtime: %s, %v", out, err)
+	}
+	out, err = d.Cmd("inspect", "-f", "{{.HostConfig.Runtime}} {{.ExecDriver}}", "test")
+	if err != nil {
+		t.Fatalf("Could not inspect the container: %s, %v", out, err)
+	}
+	if strings.TrimSpace(out) != "fake oci-fake" {
+		t.Fatalf("Expected the container to run with the fake runtime, got %q", out)
+	}
+
+	logDone("daemon - containers choose a runtime among those of the daemon")
+}
+
+func TestDaemonUnknownDefaultRuntime(t *testing.T) {
+	d := NewDaemon(t)
+	if err := d.Start("--default-runtime", "unknown"); err == nil {
+		d.Stop()
+		t.Fatal("Expected the daemon to fail to start with an unknown default runtime")
+	}
+
+	content, _ := ioutil.ReadFile(d.logFile.Name())
+	if !strings.Contains(string(content), "Unknown default runtime unknown") {
+		t.Fatalf("Expected the unknown default runtime in the daemon logs, got %q", content)
+	}
+
+	logDone("daemon - the default runtime must be known")
+}
+
+func TestDaemonCgroupParent(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+
+	d := NewDaemon(t)
+	if err := d.StartWithBusybox("--cgroup-parent", "/daemon-cgroup-parent"); err != nil {
+		t.Fatalf("Could not start daemon: %v", err)
+	}
+	defer d.Stop()
+
+	out, err := d.Cmd("run", "--rm", "busybox", "cat", "/proc/self/cgroup")
+	if err != nil {
+		t.Fatalf("Could not run a container: %s, %v", out, err)
+	}
+	if !strings.Contains(out, ":/daemon-cgroup-parent/") {
+		t.Fatalf("Expected the container to be created under the cgroup parent of the daemon, got %s", out)
+	}
+
+	out, err = d.Cmd("run", "--rm", "--cgroup-parent", "/container-cgroup-parent", "busybox", "cat", "/proc/self/cgroup")
+	if err != nil {
+		t.Fatalf("Could not run a container: %s, %v", out, err)
+	}
+	if strings.Contains(out, ":/daemon-cgroup-parent/") || !strings.Contains(out, ":/container-cgroup-parent/") {
+		t.Fatalf("Expected the cgroup parent of the container to override the daemon's, got %s", out)
+	}
+
+	logDone("daemon - containers are created under the cgroup parent of the daemon")
+}
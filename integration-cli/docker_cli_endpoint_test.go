@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEndpoint(t *testing.T) {
+	home, err := ioutil.TempDir("", "docker-endpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err := os.Mkdir(filepath.Join(home, ".docker"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	config := fmt.Sprintf(`{"endpoints": {"local": {"host": %q}, "pinned": {"host": %q, "apiversion": "1.18"}}}`, daemonHost(), daemonHost())
+	if err := ioutil.WriteFile(filepath.Join(home, ".docker", "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := []string{"HOME=" + home}
+
+	versionCmd := exec.Command(dockerBinary, "--endpoint", "local", "version")
+	versionCmd.Env = env
+	out, _, err := runCommandWithOutput(versionCmd)
+	if err != nil {
+		t.Fatalf("failed to execute docker version: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "Server version:") {
+		t.Fatalf("Expected the version of the server, got %s", out)
+	}
+
+	versionCmd = exec.Command(dockerBinary, "version")
+	versionCmd.Env = append(env, "DOCKER_ENDPOINT=pinned")
+	out, _, err = runCommandWithOutput(versionCmd)
+	if err != nil {
+		t.Fatalf("failed to execute docker version: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "Client API version: 1.18") {
+		t.Fatalf("Expected the client to talk API version 1.18, got %s", out)
+	}
+
+	versionCmd = exec.Command(dockerBinary, "--endpoint", "unknown", "version")
+	versionCmd.Env = env
+	if out, _, err := runCommandWithOutput(versionCmd); err == nil || !strings.Contains(out, "Unknown endpoint unknown") {
+		t.Fatalf("Expected an error for an unknown endpoint, got %s, %v", out, err)
+	}
+
+	versionCmd = exec.Command(dockerBinary, "--endpoint", "local", "-H", daemonHost(), "version")
+	versionCmd.Env = env
+	if out, _, err := runCommandWithOutput(versionCmd); err == nil || !strings.Contains(out, "not both") {
+		t.Fatalf("Expected an error for --endpoint with -H, got %s, %v", out, err)
+	}
+
+	logDone("endpoint - connect to a named endpoint")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -286,6 +287,46 @@ func TestEventsFilters(t *testing.T) {
 	logDone("events - filters")
 }
 
+func TestEventsFormatJSON(t *testing.T) {
+	defer deleteAllContainers()
+	since := daemonTime(t).Unix()
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "-l", "events=json", "busybox", "true"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+	if out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "wait", id)); err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(t).Unix()), "--filter", "label=events=json", "--format", "{{json .}}"))
+	if err != nil {
+		t.Fatalf("Failed to get events: %s, %v", out, err)
+	}
+	var statuses []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var event struct {
+			Status string            `json:"status"`
+			ID     string            `json:"id"`
+			From   string            `json:"from"`
+			Time   int64             `json:"time"`
+			Labels map[string]string `json:"labels"`
+		}
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Expected one JSON object per line, got %q: %v", line, err)
+		}
+		if event.ID != id || event.From != "busybox:latest" || event.Time == 0 || event.Labels["events"] != "json" {
+			t.Fatalf("Unexpected event %+v", event)
+		}
+		statuses = append(statuses, event.Status)
+	}
+	if strings.Join(statuses, " ") != "create start die" {
+		t.Fatalf("Expected create, start and die events, got %v", statuses)
+	}
+
+	logDone("events - format events as JSON")
+}
+
 func TestEventsFilterImageName(t *testing.T) {
 	since := daemonTime(t).Unix()
 	defer deleteAllContainers()
@@ -224,6 +224,42 @@ func TestExecEnv(t *testing.T) {
 	logDone("exec - exec inherits correct env")
 }
 
+func TestExecUserAndEnv(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-e", "LALA=value1", "-d", "--name", "testing", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "-u", "nobody", "testing", "id", "-u"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if out = strings.TrimSpace(out); out != "65534" {
+		t.Fatalf("Expected exec to run as nobody (65534), got %q", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "exec", "-e", "LALA=value2", "-e", "FOO=bar", "testing", "env"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if strings.Contains(out, "LALA=value1") || !strings.Contains(out, "LALA=value2") || !strings.Contains(out, "FOO=bar") {
+		t.Fatalf("Expected exec env to contain LALA=value2 and FOO=bar, got %q", out)
+	}
+
+	// Other execs keep the user and the environment of the container.
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "exec", "testing", "sh", "-c", "id -u; env"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.HasPrefix(out, "0\n") || !strings.Contains(out, "LALA=value1") || strings.Contains(out, "FOO=bar") {
+		t.Fatalf("Expected exec to run as root with the container's env, got %q", out)
+	}
+
+	logDone("exec - user and env options")
+}
+
 func TestExecExitStatus(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -496,6 +532,48 @@ func TestInspectExecID(t *testing.T) {
 	logDone("inspect - inspect a container with ExecIDs")
 }
 
+func TestInspectExecInstance(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "busybox", "top"))
+	if err != nil {
+		t.Fatalf("failed to run container: %s, %v", out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "-d", id, "sleep", "1234")); err != nil {
+		t.Fatalf("failed to exec in container: %s, %v", out, err)
+	}
+	out, err = inspectField(id, "ExecIDs")
+	if err != nil {
+		t.Fatalf("failed to inspect container: %s, %v", out, err)
+	}
+	execID := strings.Trim(strings.TrimSpace(out), "[]")
+	if execID == "" || strings.Contains(execID, " ") {
+		t.Fatalf("Expected a single exec instance, got %s", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "inspect", "-f", "{{.Running}} {{.Pid}} {{.ProcessConfig.entrypoint}}", execID))
+	if err != nil {
+		t.Fatalf("failed to inspect exec instance: %s, %v", out, err)
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 3 || fields[0] != "true" || fields[1] == "0" || fields[2] != "sleep" {
+		t.Fatalf("Expected a running sleep with a pid, got %q", out)
+	}
+
+	// The processes of exec instances are listed with those of the container.
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "top", id))
+	if err != nil {
+		t.Fatalf("failed to list the processes of the container: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "sleep 1234") || !strings.Contains(out, fields[1]) {
+		t.Fatalf("Expected the exec process %s in docker top, got %s", fields[1], out)
+	}
+
+	logDone("inspect - inspect an exec instance")
+}
+
 func TestLinksPingLinkedContainersOnRename(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthcheckBuildAndRun(t *testing.T) {
+	testRequires(t, ExecSupport)
+	defer deleteAllContainers()
+
+	name := "testhealthcheck"
+	defer deleteImages(name)
+	_, err := buildImage(name,
+		`FROM busybox
+		RUN echo OK > /status
+		HEALTHCHECK --interval=1s --timeout=3s --retries=1 CMD cat /status`,
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := inspectFieldJSON(name, "Config.Healthcheck.Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `["CMD-SHELL","cat /status"]` {
+		t.Fatalf("Expected the health check of the image to be cat /status, got %s", out)
+	}
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "healthy", name, "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if err := waitInspect("healthy", "{{.State.Health.Status}}", "healthy", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "ps"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "(healthy)") {
+		t.Fatalf("Expected docker ps to show the container as healthy:\n%s", out)
+	}
+
+	// Make the check fail.
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "healthy", "rm", "/status")); err != nil {
+		t.Fatal(out, err)
+	}
+	if err := waitInspect("healthy", "{{.State.Health.Status}}", "unhealthy", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	var health struct {
+		FailingStreak int
+		Log           []struct {
+			ExitCode int
+			Output   string
+		}
+	}
+	if err := inspectFieldAndMarshall("healthy", "State.Health", &health); err != nil {
+		t.Fatal(err)
+	}
+	if health.FailingStreak < 1 || len(health.Log) == 0 {
+		t.Fatalf("Expected failed checks in the health state, got %+v", health)
+	}
+	if last := health.Log[len(health.Log)-1]; last.ExitCode == 0 || !strings.Contains(last.Output, "No such file") {
+		t.Fatalf("Expected the last check to fail with the error of cat, got %+v", last)
+	}
+
+	logDone("health - health check of an image reports healthy and unhealthy")
+}
+
+func TestHealthcheckRunFlags(t *testing.T) {
+	testRequires(t, ExecSupport)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "flags",
+		"--health-cmd", "exit 1", "--health-interval", "1s", "--health-retries", "2", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if err := waitInspect("flags", "{{.State.Health.Status}}", "unhealthy", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--name", "nocheck", "--no-healthcheck", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	out, err := inspectFieldJSON("nocheck", "State.Health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "null" {
+		t.Fatalf("Expected no health state with --no-healthcheck, got %s", out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--health-cmd", "true", "--no-healthcheck", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil {
+		t.Fatalf("Expected --health-cmd with --no-healthcheck to fail:\n%s", out)
+	}
+
+	logDone("health - health check flags of docker run")
+}
+
+func TestHealthcheckEvents(t *testing.T) {
+	testRequires(t, ExecSupport)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "events",
+		"--health-cmd", "true", "--health-interval", "1s", "busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+	if err := waitInspect("events", "{{.State.Health.Status}}", "healthy", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	eventsCmd := exec.Command(dockerBinary, "events", "--since=0", fmt.Sprintf("--until=%d", daemonTime(t).Unix()))
+	out, _, err = runCommandWithOutput(eventsCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	found := false
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, id) && strings.Contains(line, "health_status: healthy") {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("Expected a health_status: healthy event for %s:\n%s", id, out)
+	}
+
+	logDone("health - health status changes are reported as events")
+}
+
+func TestHealthcheckRestartOnUnhealthy(t *testing.T) {
+	testRequires(t, ExecSupport)
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "restarted", "--restart-on-unhealthy",
+		"--health-cmd", "exit 1", "--health-interval", "1s", "--health-retries", "1", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	startedAt, err := inspectField("restarted", "State.StartedAt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first failed check makes the container unhealthy, and restarts it
+	// with its health status back to starting.
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		out, err := inspectField("restarted", "State.StartedAt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != startedAt {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("The unhealthy container wasn't restarted")
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	if err := waitRun("restarted"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--rm", "--restart-on-unhealthy", "busybox", "true"))
+	if err == nil || !strings.Contains(out, "Conflicting options: --restart-on-unhealthy and --rm") {
+		t.Fatalf("Expected --restart-on-unhealthy to conflict with --rm, got %v:\n%s", err, out)
+	}
+
+	logDone("health - unhealthy containers are restarted with --restart-on-unhealthy")
+}
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -133,6 +134,47 @@ func TestImagesFilterLabel(t *testing.T) {
 	logDone("images - filter label")
 }
 
+func TestImagesFilterBeforeSince(t *testing.T) {
+	defer deleteImages("images-filter:a")
+	defer deleteImages("images-filter:b")
+	defer deleteImages("images-filter:c")
+	var ids []string
+	for i, name := range []string{"images-filter:a", "images-filter:b", "images-filter:c"} {
+		if i > 0 {
+			time.Sleep(time.Second)
+		}
+		id, err := buildImage(name, fmt.Sprintf(`FROM scratch
+		MAINTAINER dockerio%d`, i), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "images", "-q", "--no-trunc", "--filter", "since=images-filter:a", "--filter", "before=images-filter:c"))
+	if err != nil {
+		t.Fatalf("listing images failed with errors: %s, %v", out, err)
+	}
+	if strings.TrimSpace(out) != ids[1] {
+		t.Fatalf("Expected only %s between images-filter:a and images-filter:c, got %q", ids[1], out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "images", "-q", "--no-trunc", "--filter", "since=images-filter:a"))
+	if err != nil {
+		t.Fatalf("listing images failed with errors: %s, %v", out, err)
+	}
+	if strings.Contains(out, ids[0]) || !strings.Contains(out, ids[1]) || !strings.Contains(out, ids[2]) {
+		t.Fatalf("Expected images created after images-filter:a, got %q", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "images", "--filter", "since=images-filter:nonexistent"))
+	if err == nil || !strings.Contains(out, "No such image") {
+		t.Fatalf("Expected a missing image error, got %s, %v", out, err)
+	}
+
+	logDone("images - filter before and since")
+}
+
 func TestImagesFilterWhiteSpaceTrimmingAndLowerCasingWorking(t *testing.T) {
 	imageName := "images_filter_test"
 	defer deleteAllContainers()
@@ -219,3 +261,60 @@ func TestImagesEnsureDanglingImageOnlyListedOnce(t *testing.T) {
 
 	logDone("images - dangling image only listed once")
 }
+
+func TestImagesFormat(t *testing.T) {
+	defer deleteImages("format:test")
+	id, err := buildImage("format:test",
+		`FROM scratch
+		MAINTAINER dockerio`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "images", "--no-trunc", "--format", "{{.ID}} {{.Repository}}:{{.Tag}}", "format"))
+	if err != nil {
+		t.Fatalf("listing images failed with errors: %s, %v", out, err)
+	}
+	if expected := fmt.Sprintf("%s format:test\n", id); out != expected {
+		t.Fatalf("Expected %q, got %q", expected, out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "images", "--format", "{{.Foo"))
+	if err == nil || !strings.Contains(out, "Template parsing error") {
+		t.Fatalf("Expected a template parsing error, got %s, %v", out, err)
+	}
+
+	logDone("images - format output with a template")
+}
+
+func TestImagesNotTerminal(t *testing.T) {
+	defer deleteImages("notterminal:test")
+	id, err := buildImage("notterminal:test",
+		`FROM scratch
+		MAINTAINER dockerio`, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The output of the command isn't a terminal
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "images", "--no-trunc", "notterminal"))
+	if err != nil {
+		t.Fatalf("listing images failed with errors: %s, %v", out, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || lines[0] != "REPOSITORY\tTAG\tIMAGE ID\tCREATED\tVIRTUAL SIZE" {
+		t.Fatalf("Expected a header and one image separated by tabs, got %q", out)
+	}
+	fields := strings.Split(lines[1], "\t")
+	if len(fields) != 5 || fields[0] != "notterminal" || fields[1] != "test" || fields[2] != id {
+		t.Fatalf("Expected the image separated by tabs, got %q", lines[1])
+	}
+	if _, err := time.Parse(time.RFC3339, fields[3]); err != nil {
+		t.Fatalf("Expected the creation time in RFC 3339 format, got %q", fields[3])
+	}
+	if _, err := strconv.Atoi(fields[4]); err != nil {
+		t.Fatalf("Expected the size in bytes, got %q", fields[4])
+	}
+
+	logDone("images - tab separated output when not a terminal")
+}
@@ -21,3 +21,30 @@ func TestInspectImage(t *testing.T) {
 
 	logDone("inspect - inspect an image")
 }
+
+func TestInspectTemplateFunctions(t *testing.T) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--name", "inspect-funcs", "busybox", "sh", "-c", "sleep 1000"))
+	if err != nil {
+		t.Fatalf("failed to run container: %s, %v", out, err)
+	}
+	defer deleteAllContainers()
+
+	tests := map[string]string{
+		`{{join .Config.Cmd "|"}}`:              "sh|-c|sleep 1000",
+		`{{index (split .Config.Image ":") 0}}`: "busybox",
+		`{{upper .Name}}`:                       "/INSPECT-FUNCS",
+		`{{lower (upper .Name)}}`:               "/inspect-funcs",
+		`{{json .Config.Cmd}}`:                  `["sh","-c","sleep 1000"]`,
+	}
+	for format, expected := range tests {
+		out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "inspect", "--format", format, "inspect-funcs"))
+		if err != nil {
+			t.Fatalf("failed to inspect container with %s: %s, %v", format, out, err)
+		}
+		if out = strings.TrimSpace(out); out != expected {
+			t.Fatalf("Expected %q for %s, got %q", expected, format, out)
+		}
+	}
+
+	logDone("inspect - template functions")
+}
@@ -251,6 +251,79 @@ func TestLogsTail(t *testing.T) {
 	logDone("logs - logs tail")
 }
 
+func TestLogsSince(t *testing.T) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "sh", "-c", "echo before; sleep 3; echo after")
+	out, _, _, err := runCommandWithStdoutStderr(runCmd)
+	if err != nil {
+		t.Fatalf("run failed with errors: %s, %v", out, err)
+	}
+	cleanedContainerID := strings.TrimSpace(out)
+	defer deleteContainer(cleanedContainerID)
+	exec.Command(dockerBinary, "wait", cleanedContainerID).Run()
+
+	logsCmd := exec.Command(dockerBinary, "logs", "--since", "2s", cleanedContainerID)
+	out, _, _, err = runCommandWithStdoutStderr(logsCmd)
+	if err != nil {
+		t.Fatalf("failed to log container: %s, %v", out, err)
+	}
+	if out != "after\n" {
+		t.Fatalf("Expected only the last line, got %q", out)
+	}
+
+	logsCmd = exec.Command(dockerBinary, "logs", "--since", time.Now().Add(-time.Minute).Format(time.RFC3339), cleanedContainerID)
+	out, _, _, err = runCommandWithStdoutStderr(logsCmd)
+	if err != nil {
+		t.Fatalf("failed to log container: %s, %v", out, err)
+	}
+	if out != "before\nafter\n" {
+		t.Fatalf("Expected both lines, got %q", out)
+	}
+
+	logsCmd = exec.Command(dockerBinary, "logs", "--since", "yesterday", cleanedContainerID)
+	if out, _, _, err = runCommandWithStdoutStderr(logsCmd); err == nil {
+		t.Fatalf("Expected an error for an invalid --since, got %s", out)
+	}
+
+	logDone("logs - logs since")
+}
+
+func TestLogsDetails(t *testing.T) {
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--label", "app=shop", "-e", "ENV=prod",
+		"--log-opt", "labels=app,missing", "--log-opt", "env=ENV", "busybox", "echo", "hello")
+	out, _, _, err := runCommandWithStdoutStderr(runCmd)
+	if err != nil {
+		t.Fatalf("run failed with errors: %s, %v", out, err)
+	}
+	cleanedContainerID := strings.TrimSpace(out)
+	defer deleteContainer(cleanedContainerID)
+	exec.Command(dockerBinary, "wait", cleanedContainerID).Run()
+
+	logsCmd := exec.Command(dockerBinary, "logs", "--details", cleanedContainerID)
+	out, _, _, err = runCommandWithStdoutStderr(logsCmd)
+	if err != nil {
+		t.Fatalf("failed to log container: %s, %v", out, err)
+	}
+	if out != "ENV=prod,app=shop hello\n" {
+		t.Fatalf("Unexpected logs with details %q", out)
+	}
+
+	logsCmd = exec.Command(dockerBinary, "logs", cleanedContainerID)
+	out, _, _, err = runCommandWithStdoutStderr(logsCmd)
+	if err != nil {
+		t.Fatalf("failed to log container: %s, %v", out, err)
+	}
+	if out != "hello\n" {
+		t.Fatalf("Unexpected logs %q", out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--log-opt", "max-size=10m", "busybox", "true")
+	if out, _, err := runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Unknown log opt 'max-size'") {
+		t.Fatalf("Expected an error for an unknown log opt, got %s, %v", out, err)
+	}
+
+	logDone("logs - logs details")
+}
+
 func TestLogsFollowStopped(t *testing.T) {
 	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "echo", "hello")
 
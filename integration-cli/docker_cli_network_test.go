@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNetworkCreateLsRm(t *testing.T) {
+	out, _, _ := dockerCmd(t, "network", "create", "testnet")
+	id := strings.TrimSpace(out)
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "create", "testnet")); err == nil || !strings.Contains(out, "already in use") {
+		t.Fatalf("Expected an error creating a second network named testnet, got %q", out)
+	}
+
+	out, _, _ = dockerCmd(t, "network", "ls")
+	for _, name := range []string{"bridge", "host", "none", "testnet"} {
+		if !strings.Contains(out, name) {
+			t.Fatalf("Expected network %s in the list, got %q", name, out)
+		}
+	}
+
+	out, _, _ = dockerCmd(t, "network", "inspect", "-f", "{{.Id}} {{.Driver}}", "testnet")
+	if strings.TrimSpace(out) != id+" bridge" {
+		t.Fatalf("Expected the network %s of the bridge driver, got %q", id, out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "bridge")); err == nil {
+		t.Fatalf("Expected an error removing the bridge network, got %q", out)
+	}
+	dockerCmd(t, "network", "rm", id[:12])
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "inspect", "testnet")); err == nil {
+		t.Fatalf("Expected testnet to be removed, got %q", out)
+	}
+
+	logDone("network - create, list and remove networks")
+}
+
+func TestNetworkRunAndConnect(t *testing.T) {
+	defer deleteAllContainers()
+	dockerCmd(t, "network", "create", "testnet")
+	defer exec.Command(dockerBinary, "network", "rm", "testnet").Run()
+
+	out, _, _ := dockerCmd(t, "network", "inspect", "-f", "{{(index .IPAM.Config 0).Subnet}}", "testnet")
+	_, subnet, err := net.ParseCIDR(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	out, _, _ = dockerCmd(t, "run", "--net=testnet", "busybox", "ip", "-o", "-4", "a", "show", "eth0")
+	fields := strings.Fields(out)
+	if len(fields) < 4 {
+		t.Fatalf("Expected the address of eth0, got %q", out)
+	}
+	if ip, _, err := net.ParseCIDR(fields[3]); err != nil || !subnet.Contains(ip) {
+		t.Fatalf("Expected an address of testnet %s, got %q", subnet, out)
+	}
+
+	dockerCmd(t, "run", "-d", "--name", "connected", "busybox", "top")
+	dockerCmd(t, "network", "connect", "testnet", "connected")
+	out, _, _ = dockerCmd(t, "exec", "connected", "ip", "-o", "-4", "a", "show", "eth1")
+	if !strings.Contains(out, "eth1") {
+		t.Fatalf("Expected the container to get eth1 on testnet, got %q", out)
+	}
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "network", "rm", "testnet")); err == nil {
+		t.Fatalf("Expected an error removing a network in use, got %q", out)
+	}
+
+	dockerCmd(t, "network", "disconnect", "testnet", "connected")
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "exec", "connected", "ip", "a", "show", "eth1")); err == nil {
+		t.Fatalf("Expected eth1 to be removed, got %q", out)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--net=testnet", "--link=connected:c", "busybox", "true")); err == nil {
+		t.Fatalf("Expected an error linking containers on testnet, got %q", out)
+	}
+
+	logDone("network - run a container on a network and connect one to it")
+}
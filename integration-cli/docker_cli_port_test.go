@@ -126,6 +126,45 @@ func TestPortList(t *testing.T) {
 	logDone("port - test port list")
 }
 
+func TestPortFormat(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d",
+		"-p", "9876:80",
+		"-p", "9000-9001:9000-9001",
+		"-p", "127.0.0.1:5353:53/udp",
+		"busybox", "top")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	ID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "port", "--format", "{{.PrivatePort}}-{{.PrivatePortEnd}}/{{.Proto}} {{.HostIP}}:{{.HostPort}}-{{.HostPortEnd}}", ID)
+	out, _, err = runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !assertPortList(t, out, []string{
+		"80-80/tcp 0.0.0.0:9876-9876",
+		"9000-9001/tcp 0.0.0.0:9000-9001",
+		"53-53/udp 127.0.0.1:5353-5353"}) {
+		t.Error("Port list is not correct")
+	}
+
+	runCmd = exec.Command(dockerBinary, "port", "--format", "{{json .}}", ID, "53/udp")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	expected := `{"PrivatePort":53,"PrivatePortEnd":53,"Proto":"udp","HostIP":"127.0.0.1","HostPort":5353,"HostPortEnd":5353}`
+	if strings.TrimSpace(out) != expected {
+		t.Fatalf("Expected %s, got %s", expected, out)
+	}
+
+	logDone("port - test port list with --format")
+}
+
 func assertPortList(t *testing.T, out string, expected []string) bool {
 	//lines := strings.Split(out, "\n")
 	lines := strings.Split(strings.Trim(out, "\n "), "\n")
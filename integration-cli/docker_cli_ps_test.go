@@ -483,6 +483,53 @@ func TestPsListContainersFilterLabel(t *testing.T) {
 	logDone("ps - test ps filter label")
 }
 
+func TestPsListContainersFilterAncestor(t *testing.T) {
+	defer deleteImages("ps-ancestor")
+	defer deleteAllContainers()
+	if _, err := buildImage("ps-ancestor",
+		`FROM busybox
+		LABEL ps=ancestor`, true); err != nil {
+		t.Fatal(err)
+	}
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	baseID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "run", "-d", "ps-ancestor", "true")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	childID := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc", "--filter=ancestor=ps-ancestor")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if containerOut := strings.TrimSpace(out); containerOut != childID {
+		t.Fatalf("Expected id %s, got %s for ancestor filter, output: %q", childID, containerOut, out)
+	}
+
+	// containers of images built on busybox match as well
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc", "--filter=ancestor=busybox")
+	if out, _, err = runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, baseID) || !strings.Contains(out, childID) {
+		t.Fatalf("Expected ids %s and %s for ancestor filter, output: %q", baseID, childID, out)
+	}
+
+	runCmd = exec.Command(dockerBinary, "ps", "-a", "--filter=foo=bar")
+	if out, _, err = runCommandWithOutput(runCmd); err == nil || !strings.Contains(out, "Invalid filter") {
+		t.Fatalf("Expected an invalid filter error, got %s, %v", out, err)
+	}
+
+	logDone("ps - test ps filter ancestor")
+}
+
 func TestPsListContainersFilterExited(t *testing.T) {
 	defer deleteAllContainers()
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os/exec"
 	"strings"
 	"testing"
@@ -93,7 +94,7 @@ func TestRenameCheckNames(t *testing.T) {
 	}
 
 	name, err = inspectField("first_name", "Name")
-	if err == nil && !strings.Contains(err.Error(), "No such image or container: first_name") {
+	if err == nil && !strings.Contains(err.Error(), "No such image, container or exec instance: first_name") {
 		t.Fatal(err)
 	}
 
@@ -119,3 +120,30 @@ func TestRenameInvalidName(t *testing.T) {
 
 	logDone("rename - invalid container name")
 }
+
+func TestRenameLogsEvent(t *testing.T) {
+	defer deleteAllContainers()
+	since := daemonTime(t).Unix()
+	runCmd := exec.Command(dockerBinary, "run", "--name", "event_name", "-d", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	runCmd = exec.Command(dockerBinary, "rename", "event_name", "event_new_name")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf(out, err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "events", fmt.Sprintf("--since=%d", since), fmt.Sprintf("--until=%d", daemonTime(t).Unix()), "--filter", "event=rename")
+	out, _, err = runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf(out, err)
+	}
+	if !strings.Contains(out, id) {
+		t.Fatalf("Expected a rename event for %s, got %s", id, out)
+	}
+
+	logDone("rename - rename event")
+}
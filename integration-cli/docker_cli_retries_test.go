@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRetriesUnreachableDaemon(t *testing.T) {
+	sock := "unix://" + filepath.Join(randomUnixTmpDirPath("docker-retries"), "docker.sock")
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "-D", "--retries", "2", "-H", sock, "ps"))
+	if err == nil {
+		t.Fatalf("Expected docker ps to fail without a daemon, got %s", out)
+	}
+	if n := strings.Count(out, "retrying in"); n != 2 {
+		t.Fatalf("Expected 2 retries, got %d: %s", n, out)
+	}
+
+	// Creating a container isn't idempotent, so it's never retried.
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "-D", "--retries", "2", "-H", sock, "create", "busybox"))
+	if err == nil {
+		t.Fatalf("Expected docker create to fail without a daemon, got %s", out)
+	}
+	if strings.Contains(out, "retrying in") {
+		t.Fatalf("Expected docker create not to be retried: %s", out)
+	}
+
+	logDone("retries - retry idempotent requests to an unreachable daemon")
+}
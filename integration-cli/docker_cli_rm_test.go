@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
@@ -140,6 +141,33 @@ func TestRmInvalidContainer(t *testing.T) {
 	logDone("rm - delete unknown container")
 }
 
+func TestRmMultipleContainers(t *testing.T) {
+	defer deleteAllContainers()
+
+	var names []string
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("rm-multiple-%d", i)
+		if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "create", "--name", name, "busybox", "true")); err != nil {
+			t.Fatal(out, err)
+		}
+		names = append(names, name)
+	}
+
+	// The containers are removed in parallel, but reported in order.
+	stdout, stderr, _, err := runCommandWithStdoutStderr(exec.Command(dockerBinary, append([]string{"rm"}, append(names, "unknown")...)...))
+	if err == nil {
+		t.Fatal("Expected error on rm unknown container, got none")
+	}
+	if expected := strings.Join(names, "\n") + "\n"; stdout != expected {
+		t.Fatalf("Expected the removed containers in order %q, got %q", expected, stdout)
+	}
+	if !strings.Contains(stderr, "failed to remove one or more containers") {
+		t.Fatalf("Expected stderr to contain 'failed to remove one or more containers', got %q", stderr)
+	}
+
+	logDone("rm - multiple containers in parallel")
+}
+
 func createRunningContainer(t *testing.T, name string) {
 	cmd := exec.Command(dockerBinary, "run", "-dt", "--name", name, "busybox", "top")
 	if _, err := runCommand(cmd); err != nil {
@@ -3034,6 +3034,39 @@ func TestRunModeIpcContainerNotExists(t *testing.T) {
 	logDone("run - ipc from a non exists container failed with correct error out")
 }
 
+func TestRunModePidContainerNativeDriver(t *testing.T) {
+	defer deleteAllContainers()
+	testRequires(t, NativeExecDriver)
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "busybox", "top")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+	if err := waitRun(id); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", fmt.Sprintf("--pid=container:%s", id), "busybox", "ps")
+	if out, _, err = runCommandWithOutput(cmd); err != nil || !strings.Contains(out, "top") {
+		t.Fatalf("Expected the processes of %s in the PID namespace, got %s, %v", id, out, err)
+	}
+
+	logDone("run - pid container mode with the native driver")
+}
+
+func TestRunModePidContainerNotExists(t *testing.T) {
+	defer deleteAllContainers()
+	cmd := exec.Command(dockerBinary, "run", "-d", "--pid", "container:abcd1234", "busybox", "top")
+	out, _, err := runCommandWithOutput(cmd)
+	if !strings.Contains(out, "abcd1234") || err == nil {
+		t.Fatalf("run PID from a non exists container should with correct error out")
+	}
+
+	logDone("run - pid from a non exists container failed with correct error out")
+}
+
 func TestContainerNetworkMode(t *testing.T) {
 	defer deleteAllContainers()
 	testRequires(t, SameHostDaemon)
@@ -3104,6 +3137,188 @@ func TestRunModePidHost(t *testing.T) {
 	logDone("run - pid host mode")
 }
 
+func TestRunModeUTSHost(t *testing.T) {
+	testRequires(t, NativeExecDriver, SameHostDaemon)
+	defer deleteAllContainers()
+
+	hostUTS, err := os.Readlink("/proc/1/ns/uts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(dockerBinary, "run", "--uts=host", "busybox", "readlink", "/proc/self/ns/uts")
+	out2, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out2)
+	}
+
+	out2 = strings.Trim(out2, "\n")
+	if hostUTS != out2 {
+		t.Fatalf("UTS different with --uts=host %s != %s\n", hostUTS, out2)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "busybox", "readlink", "/proc/self/ns/uts")
+	out2, _, err = runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out2)
+	}
+
+	out2 = strings.Trim(out2, "\n")
+	if hostUTS == out2 {
+		t.Fatalf("UTS should be different without --uts=host %s == %s\n", hostUTS, out2)
+	}
+
+	logDone("run - uts host mode")
+}
+
+func TestRunPidsLimit(t *testing.T) {
+	testRequires(t, NativeExecDriver, SameHostDaemon, PidsLimit)
+	defer deleteAllContainers()
+
+	// The shell and its 3 first processes fill the limit.
+	cmd := exec.Command(dockerBinary, "run", "--pids-limit=4", "busybox", "sh", "-c", "for i in 1 2 3 4 5; do sleep 5 & done; wait")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(out, "can't fork") {
+		t.Fatalf("Expected the container to be unable to fork more than 4 processes, got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "busybox", "sh", "-c", "for i in 1 2 3 4 5; do true & done; wait")
+	if out, _, err = runCommandWithOutput(cmd); err != nil || strings.Contains(out, "can't fork") {
+		t.Fatalf("Expected the container to fork without limit, got %s, %v", out, err)
+	}
+
+	logDone("run - pids limit")
+}
+
+func TestRunCpuQuota(t *testing.T) {
+	testRequires(t, NativeExecDriver, SameHostDaemon, CpuCfsQuota)
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "--cpu-period=50000", "--cpu-quota=25000", "busybox", "sleep", "60")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+	for file, expected := range map[string]string{"cpu.cfs_period_us": "50000", "cpu.cfs_quota_us": "25000"} {
+		value, err := readContainerCgroupFile(id, "cpu", file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Fatalf("Expected %s to be %s, got %s", file, expected, value)
+		}
+	}
+
+	logDone("run - cpu quota and period")
+}
+
+func TestRunDeviceReadBps(t *testing.T) {
+	testRequires(t, NativeExecDriver, SameHostDaemon, BlkioThrottle)
+	defer deleteAllContainers()
+
+	// the first disk of the host
+	partitions, err := ioutil.ReadFile("/proc/partitions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(partitions), "\n")
+	if len(lines) < 3 {
+		t.Skip("Test requires a block device")
+	}
+	fields := strings.Fields(lines[2])
+	device, number := "/dev/"+fields[3], fields[0]+":"+fields[1]
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "--device-read-bps", device+":1mb", "--device-write-iops", device+":100", "busybox", "sleep", "60")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	id := strings.TrimSpace(out)
+	for file, expected := range map[string]string{"blkio.throttle.read_bps_device": number + " 1048576", "blkio.throttle.write_iops_device": number + " 100"} {
+		value, err := readContainerCgroupFile(id, "blkio", file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Fatalf("Expected %s to be %s, got %s", file, expected, value)
+		}
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--device-read-bps", "/dev/null:1mb", "busybox", "true")
+	if out, _, err := runCommandWithOutput(cmd); err == nil || !strings.Contains(out, "not a block device") {
+		t.Fatalf("Expected an error for a character device, got %s, %v", out, err)
+	}
+
+	logDone("run - device read bps and write iops")
+}
+
+func TestRunOomScoreAdj(t *testing.T) {
+	testRequires(t, NativeExecDriver)
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "--oom-score-adj=500", "busybox", "sh", "-c", "cat /proc/self/oom_score_adj")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if strings.TrimSpace(out) != "500" {
+		t.Fatalf("Expected the processes of the container to have an OOM score adjustment of 500, got %s", out)
+	}
+
+	cmd = exec.Command(dockerBinary, "run", "--oom-score-adj=1001", "busybox", "true")
+	if out, _, err := runCommandWithOutput(cmd); err == nil || !strings.Contains(out, "Invalid --oom-score-adj") {
+		t.Fatalf("Expected an error for an adjustment out of range, got %s, %v", out, err)
+	}
+
+	logDone("run - oom score adj")
+}
+
+func TestRunOomKillDisable(t *testing.T) {
+	testRequires(t, NativeExecDriver, SameHostDaemon)
+	defer deleteAllContainers()
+
+	cmd := exec.Command(dockerBinary, "run", "-d", "-m", "32m", "--oom-kill-disable", "busybox", "sleep", "60")
+	out, _, err := runCommandWithOutput(cmd)
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	control, err := readContainerCgroupFile(strings.TrimSpace(out), "memory", "memory.oom_control")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(control, "oom_kill_disable 1") {
+		t.Fatalf("Expected the OOM killer of the container to be disabled, got %s", control)
+	}
+
+	logDone("run - oom kill disable")
+}
+
+// readContainerCgroupFile returns the content of file in the cgroup of the
+// subsystem of the running container id.
+func readContainerCgroupFile(id, subsystem, file string) (string, error) {
+	pid, err := inspectField(id, "State.Pid")
+	if err != nil {
+		return "", err
+	}
+	cgroup, err := ioutil.ReadFile(fmt.Sprintf("/proc/%s/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	for subsystems, path := range parseCgroupPaths(string(cgroup)) {
+		for _, s := range strings.Split(subsystems, ",") {
+			if s == subsystem {
+				value, err := ioutil.ReadFile(filepath.Join("/sys/fs/cgroup", subsystems, path, file))
+				return strings.TrimSpace(string(value)), err
+			}
+		}
+	}
+	return "", fmt.Errorf("The container %s has no %s cgroup", id, subsystem)
+}
+
 func TestRunTLSverify(t *testing.T) {
 	cmd := exec.Command(dockerBinary, "ps")
 	out, ec, err := runCommandWithOutput(cmd)
@@ -3466,3 +3681,118 @@ func TestRunContainerWithRmFlagCannotStartContainer(t *testing.T) {
 
 	logDone("run - container is removed if run with --rm and cannot start")
 }
+
+func TestRunContainerWithRmFlagDetached(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--rm", "busybox", "true")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+
+	// the daemon removes the container once it exits
+	for i := 0; ; i++ {
+		if out, err = getAllContainers(); err != nil {
+			t.Fatal(out, err)
+		}
+		if out == "" {
+			break
+		}
+		if i == 50 {
+			t.Fatal("Expected not to have containers", out)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	logDone("run - detached container is removed if run with --rm")
+}
+
+func TestRunStopTimeout(t *testing.T) {
+	defer deleteAllContainers()
+
+	// sleep ignores SIGTERM as the init of the container
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--stop-timeout=1", "busybox", "sleep", "60"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	timeout, err := inspectField(id, "Config.StopTimeout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if timeout != "1" {
+		t.Fatalf("Expected a stop timeout of 1, got %s", timeout)
+	}
+
+	start := time.Now()
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", id)); err != nil {
+		t.Fatal(out, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("Expected the container to be killed after 1 second, stopping it took %s", d)
+	}
+
+	logDone("run - stop timeout of the container")
+}
+
+func TestRunStopSignal(t *testing.T) {
+	defer deleteAllContainers()
+
+	// the shell only exits on SIGUSR1, SIGTERM would leave it to be killed
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--stop-signal=SIGUSR1", "busybox", "sh", "-c", "trap 'exit 3' USR1; while true; do sleep 1; done"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", id)); err != nil {
+		t.Fatal(out, err)
+	}
+	exitCode, err := inspectField(id, "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "3" {
+		t.Fatalf("Expected the container to exit on SIGUSR1 with 3, got %s", exitCode)
+	}
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--stop-signal=SIGFOO", "busybox", "true")); err == nil {
+		t.Fatalf("Expected an invalid stop signal to fail, got %s", out)
+	}
+
+	logDone("run - stop signal of the container")
+}
+
+func TestRunInit(t *testing.T) {
+	defer deleteAllContainers()
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "run", "--init", "busybox", "cat", "/proc/1/cmdline"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.HasPrefix(out, "/dev/init\x00cat\x00") {
+		t.Fatalf("Expected the init to run the command as PID 1, got %q", out)
+	}
+
+	// the shell only exits with 3 if the init forwards SIGTERM to it
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "run", "-d", "--init", "busybox", "sh", "-c", "trap 'exit 3' TERM; while true; do sleep 1; done"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	id := strings.TrimSpace(out)
+
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "stop", id)); err != nil {
+		t.Fatal(out, err)
+	}
+	exitCode, err := inspectField(id, "State.ExitCode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != "3" {
+		t.Fatalf("Expected the container to exit on SIGTERM with 3, got %s", exitCode)
+	}
+
+	logDone("run - init of the container")
+}
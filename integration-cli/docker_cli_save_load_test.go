@@ -10,6 +10,8 @@ import (
 	"sort"
 	"strings"
 	"testing"
+
+	"github.com/docker/docker/pkg/stringid"
 )
 
 // save a repo using gz compression and try to load it using stdout
@@ -422,3 +424,47 @@ func TestSaveDirectoryPermissions(t *testing.T) {
 
 	logDone("save - ensure directories exist in exported layers")
 }
+
+func TestLoadProgress(t *testing.T) {
+	repoName := "foobar-load-progress"
+	runCmd := exec.Command(dockerBinary, "run", "--name", "load-progress", "busybox", "touch", "/file")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatalf("failed to create a container: %s, %v", out, err)
+	}
+	defer deleteContainer("load-progress")
+
+	commitCmd := exec.Command(dockerBinary, "commit", "load-progress", repoName)
+	out, _, err := runCommandWithOutput(commitCmd)
+	if err != nil {
+		t.Fatalf("failed to commit container: %s, %v", out, err)
+	}
+	imageID := stringid.TruncateID(strings.TrimSpace(out))
+
+	tmpDir, err := ioutil.TempDir("", "load-progress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+	tarball := filepath.Join(tmpDir, "image.tar")
+
+	saveCmd := exec.Command(dockerBinary, "save", "-o", tarball, repoName)
+	if out, _, err := runCommandWithOutput(saveCmd); err != nil {
+		t.Fatalf("failed to save repo: %s, %v", out, err)
+	}
+	deleteImages(repoName)
+	defer deleteImages(repoName)
+
+	loadCmd := exec.Command(dockerBinary, "load", "-i", tarball)
+	out, _, err = runCommandWithOutput(loadCmd)
+	if err != nil {
+		t.Fatalf("failed to load repo: %s, %v", out, err)
+	}
+	if !strings.Contains(out, "Sending image archive to Docker daemon") {
+		t.Fatalf("Expected the sending of the archive to be reported, got %q", out)
+	}
+	if !strings.Contains(out, imageID+": Loaded") {
+		t.Fatalf("Expected the layer %s to be reported loaded, got %q", imageID, out)
+	}
+
+	logDone("load - report the progress of the loading of the layers")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsNoStream(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "stats_nostream", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	type output struct {
+		out string
+		err error
+	}
+	ch := make(chan output, 1)
+	go func() {
+		statsCmd := exec.Command(dockerBinary, "stats", "--no-stream", "stats_nostream")
+		out, _, err := runCommandWithOutput(statsCmd)
+		ch <- output{out, err}
+	}()
+
+	select {
+	case <-time.After(10 * time.Second):
+		t.Fatal("docker stats --no-stream did not exit")
+	case o := <-ch:
+		if o.err != nil {
+			t.Fatal(o.out, o.err)
+		}
+		lines := strings.Split(strings.TrimSpace(o.out), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected a header and a single line, got %q", o.out)
+		}
+		if !strings.HasPrefix(lines[1], "stats_nostream") {
+			t.Fatalf("Expected the stats of stats_nostream, got %q", lines[1])
+		}
+	}
+
+	logDone("stats - --no-stream prints a single sample")
+}
+
+func TestStatsAllFormat(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "stats_running", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	createCmd := exec.Command(dockerBinary, "create", "--name", "stats_stopped", "busybox", "top")
+	if out, _, err := runCommandWithOutput(createCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	statsCmd := exec.Command(dockerBinary, "stats", "--no-stream", "--format", "{{.Container}} {{.MemUsage}}")
+	out, _, err := runCommandWithOutput(statsCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "stats_running ") {
+		t.Fatalf("Expected the running container, got %q", out)
+	}
+	if strings.Contains(out, "stats_stopped") {
+		t.Fatalf("Expected no stopped container without --all, got %q", out)
+	}
+
+	statsCmd = exec.Command(dockerBinary, "stats", "--no-stream", "--all", "--format", "{{.Container}} {{.MemUsage}}")
+	out, _, err = runCommandWithOutput(statsCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "stats_running ") {
+		t.Fatalf("Expected the running container, got %q", out)
+	}
+	if !strings.Contains(out, "stats_stopped 0 B\n") {
+		t.Fatalf("Expected zeros for the stopped container, got %q", out)
+	}
+
+	logDone("stats - --all shows stopped containers with --format")
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSystemDf(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "--name", "df_stopped", "-v", "/data", "busybox", "sh", "-c", "dd if=/dev/zero of=/data/file bs=1k count=64 && touch /rootfile")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "system", "df"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 lines, got %q", out)
+	}
+	for i, prefix := range []string{"TYPE", "Images", "Containers", "Local Volumes"} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Fatalf("Expected line %d to start with %s, got %q", i, prefix, lines[i])
+		}
+	}
+	// the stopped container doesn't count as active
+	if fields := strings.Fields(lines[2]); fields[2] != "0" {
+		t.Fatalf("Expected no active container, got %q", lines[2])
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "system", "df", "-v"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "df_stopped") {
+		t.Fatalf("Expected the container in the verbose output, got %q", out)
+	}
+	if !strings.Contains(out, "busybox") {
+		t.Fatalf("Expected the busybox image in the verbose output, got %q", out)
+	}
+	if !strings.Contains(out, "65.54 kB") {
+		t.Fatalf("Expected the size of the volume in the verbose output, got %q", out)
+	}
+
+	logDone("system df - show disk usage")
+}
+
+func TestSystemPrune(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "--name", "prune_stopped", "-v", "/data", "busybox", "touch", "/data/file")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	// an image committed without a tag is dangling
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "commit", "prune_stopped"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	danglingID := strings.TrimSpace(out)
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--name", "prune_running", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	runningID, err := inspectField("prune_running", "Id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing was created more than a day ago
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "system", "prune", "--force", "--filter", "until=24h"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if strings.Contains(out, "Deleted") {
+		t.Fatalf("Expected nothing to be pruned, got %q", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "system", "prune", "--force"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "Deleted Containers:") || !strings.Contains(out, danglingID) || !strings.Contains(out, "Deleted Volumes:") {
+		t.Fatalf("Expected the stopped container, the dangling image and the volume to be pruned, got %q", out)
+	}
+	if strings.Contains(out, runningID) {
+		t.Fatalf("Expected the running container to be kept, got %q", out)
+	}
+	if !strings.Contains(out, "Total reclaimed space:") {
+		t.Fatalf("Expected the reclaimed space, got %q", out)
+	}
+
+	out, _, err = runCommandWithOutput(exec.Command(dockerBinary, "ps", "-a", "-q", "--no-trunc"))
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if strings.TrimSpace(out) != runningID {
+		t.Fatalf("Expected only the running container to be left, got %q", out)
+	}
+
+	logDone("system prune - remove unused data")
+}
+
+func TestSystemPruneInvalidFilter(t *testing.T) {
+	out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "system", "prune", "--force", "--filter", "until=yesterday"))
+	if err == nil || !strings.Contains(out, "Invalid filter 'until'") {
+		t.Fatalf("Expected an invalid filter error, got %v: %q", err, out)
+	}
+
+	logDone("system prune - reject an invalid until filter")
+}
@@ -106,3 +106,31 @@ func TestTopPrivileged(t *testing.T) {
 
 	logDone("top - sleep process should be listed in privileged mode")
 }
+
+func TestTopFieldsFormat(t *testing.T) {
+	runCmd := exec.Command(dockerBinary, "run", "-i", "-d", "busybox", "sleep", "20")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatalf("failed to start the container: %s, %v", out, err)
+	}
+
+	cleanedContainerID := strings.TrimSpace(out)
+	defer deleteContainer(cleanedContainerID)
+
+	topCmd := exec.Command(dockerBinary, "top", "--fields", "pid,args", "--format", "{{json .}}", cleanedContainerID)
+	out, _, err = runCommandWithOutput(topCmd)
+	if err != nil {
+		t.Fatalf("failed to run top: %s, %v", out, err)
+	}
+
+	if !strings.Contains(out, `"args":"sleep 20"`) || !strings.Contains(out, `"pid":"`) {
+		t.Fatalf("did not see the pid and args of sleep 20 with --fields: %s", out)
+	}
+
+	topCmd = exec.Command(dockerBinary, "top", "--fields", "pid", cleanedContainerID, "-x")
+	if out, _, err = runCommandWithOutput(topCmd); err == nil {
+		t.Fatalf("expected an error with both --fields and ps options: %s", out)
+	}
+
+	logDone("top - fields and format")
+}
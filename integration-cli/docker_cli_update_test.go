@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpdateRunningContainer(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "update_running", "-m", "32M", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	updateCmd := exec.Command(dockerBinary, "update", "-m", "64M", "--cpu-shares", "512", "update_running")
+	out, _, err := runCommandWithOutput(updateCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if !strings.Contains(out, "update_running") {
+		t.Fatalf("Expected the name of the updated container, got %s", out)
+	}
+
+	memory, err := inspectField("update_running", "HostConfig.Memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if memory != "67108864" {
+		t.Fatalf("Expected a memory limit of 67108864, got %s", memory)
+	}
+	shares, err := inspectField("update_running", "HostConfig.CpuShares")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares != "512" {
+		t.Fatalf("Expected 512 cpu shares, got %s", shares)
+	}
+
+	// the limits are kept across restarts
+	if out, _, err := runCommandWithOutput(exec.Command(dockerBinary, "restart", "update_running")); err != nil {
+		t.Fatal(out, err)
+	}
+	if memory, err = inspectField("update_running", "HostConfig.Memory"); err != nil || memory != "67108864" {
+		t.Fatalf("Expected a memory limit of 67108864 after a restart, got %s, %v", memory, err)
+	}
+
+	logDone("update - update the limits of a running container")
+}
+
+func TestUpdateInvalidLimits(t *testing.T) {
+	defer deleteAllContainers()
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "update_invalid", "busybox", "top")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	updateCmd := exec.Command(dockerBinary, "update", "-m", "1M", "update_invalid")
+	if out, _, err := runCommandWithOutput(updateCmd); err == nil || !strings.Contains(out, "Minimum memory limit") {
+		t.Fatalf("Expected a minimum memory limit error, got %s, %v", out, err)
+	}
+
+	updateCmd = exec.Command(dockerBinary, "update", "update_invalid")
+	if out, _, err := runCommandWithOutput(updateCmd); err == nil {
+		t.Fatalf("Expected an error when no limit is given, got %s", out)
+	}
+
+	logDone("update - reject invalid limits")
+}
@@ -120,3 +120,69 @@ func TestWaitBlockedExitRandom(t *testing.T) {
 
 	logDone("wait - blocking wait with random exit code")
 }
+
+// blocking wait on several containers at once
+func TestWaitMultipleContainers(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "wait_slow", "busybox", "sh", "-c", "sleep 5; exit 3")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--name", "wait_fast", "busybox", "sh", "-c", "sleep 1; exit 7")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	// the exit codes are printed in the order of the arguments
+	start := time.Now()
+	runCmd = exec.Command(dockerBinary, "wait", "wait_slow", "wait_fast")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err != nil {
+		t.Fatal(out, err)
+	}
+	if out != "3\n7\n" {
+		t.Fatalf("Expected exit codes 3 and 7, got %q", out)
+	}
+	// the containers are waited for concurrently, not one after the other
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("Waiting took %s", elapsed)
+	}
+
+	logDone("wait - blocking wait on multiple containers")
+}
+
+// with a format, the exit codes are printed as they arrive
+func TestWaitMultipleContainersFormat(t *testing.T) {
+	defer deleteAllContainers()
+
+	runCmd := exec.Command(dockerBinary, "run", "-d", "--name", "wait_slow", "busybox", "sh", "-c", "sleep 5; exit 3")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+	runCmd = exec.Command(dockerBinary, "run", "-d", "--name", "wait_fast", "busybox", "sh", "-c", "exit 7")
+	if out, _, err := runCommandWithOutput(runCmd); err != nil {
+		t.Fatal(out, err)
+	}
+
+	runCmd = exec.Command(dockerBinary, "wait", "--format", "{{json .}}", "wait_slow", "wait_fast", "wait_missing")
+	out, _, err := runCommandWithOutput(runCmd)
+	if err == nil {
+		t.Fatalf("Expected an error for the missing container, got %q", out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", out)
+	}
+	if lines[2] != `{"Container":"wait_slow","StatusCode":3}` {
+		t.Fatalf("Expected the slow container last, got %q", out)
+	}
+	if !strings.Contains(out, `{"Container":"wait_fast","StatusCode":7}`) {
+		t.Fatalf("Expected the exit code of the fast container, got %q", out)
+	}
+	if !strings.Contains(out, `"Container":"wait_missing","StatusCode":-1,"Error":`) {
+		t.Fatalf("Expected an error for the missing container, got %q", out)
+	}
+
+	logDone("wait - blocking wait on multiple containers with --format")
+}
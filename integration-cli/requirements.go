@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -88,6 +89,30 @@ var (
 		},
 		"Test requires underlying root filesystem not be backed by overlay.",
 	}
+
+	PidsLimit = TestRequirement{
+		func() bool {
+			cmd := exec.Command("grep", "-E", "^cgroup .*[ ,]pids[ ,]", "/proc/mounts")
+			return cmd.Run() == nil
+		},
+		"Test requires the pids cgroup.",
+	}
+
+	CpuCfsQuota = TestRequirement{
+		func() bool {
+			files, err := filepath.Glob("/sys/fs/cgroup/cpu*/cpu.cfs_quota_us")
+			return err == nil && len(files) > 0
+		},
+		"Test requires the CPU cfs quota.",
+	}
+
+	BlkioThrottle = TestRequirement{
+		func() bool {
+			files, err := filepath.Glob("/sys/fs/cgroup/blkio*/blkio.throttle.read_bps_device")
+			return err == nil && len(files) > 0
+		},
+		"Test requires the block IO throttling.",
+	}
 )
 
 // testRequires checks if the environment satisfies the requirements
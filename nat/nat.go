@@ -76,7 +76,7 @@ func SplitProtoPort(rawPort string) (string, string) {
 }
 
 func validateProto(proto string) bool {
-	for _, availableProto := range []string{"tcp", "udp"} {
+	for _, availableProto := range []string{"tcp", "udp", "sctp"} {
 		if availableProto == proto {
 			return true
 		}
@@ -93,12 +93,28 @@ func ParsePortSpecs(ports []string) (map[Port]struct{}, map[Port][]PortBinding,
 	)
 
 	for _, rawPort := range ports {
-		proto := "tcp"
+		var (
+			proto   = "tcp"
+			ipv6    string
+			rawSpec = rawPort
+		)
 
 		if i := strings.LastIndex(rawPort, "/"); i != -1 {
 			proto = rawPort[i+1:]
 			rawPort = rawPort[:i]
 		}
+		// An IPv6 address is in brackets, as in [::1]:80:80, since it
+		// has colons of its own.
+		if strings.HasPrefix(rawPort, "[") {
+			i := strings.Index(rawPort, "]:")
+			if i == -1 || strings.Count(rawPort[i+1:], ":") != 2 {
+				return nil, nil, fmt.Errorf("Invalid port specification: %s", rawSpec)
+			}
+			if ipv6 = rawPort[1:i]; ipv6 == "" {
+				return nil, nil, fmt.Errorf("Invalid ip address: %s", rawSpec)
+			}
+			rawPort = rawPort[i+1:]
+		}
 		if !strings.Contains(rawPort, ":") {
 			rawPort = fmt.Sprintf("::%s", rawPort)
 		} else if len(strings.Split(rawPort, ":")) == 2 {
@@ -115,6 +131,11 @@ func ParsePortSpecs(ports []string) (map[Port]struct{}, map[Port][]PortBinding,
 			rawIp         = parts["ip"]
 			hostPort      = parts["hostPort"]
 		)
+		if ipv6 != "" {
+			if rawIp = ipv6; net.ParseIP(rawIp).To4() != nil {
+				return nil, nil, fmt.Errorf("Invalid IPv6 address: %s", rawIp)
+			}
+		}
 
 		if rawIp != "" && net.ParseIP(rawIp) == nil {
 			return nil, nil, fmt.Errorf("Invalid ip address: %s", rawIp)
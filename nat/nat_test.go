@@ -200,6 +200,42 @@ func TestParsePortSpecs(t *testing.T) {
 	}
 }
 
+func TestParsePortSpecsIPv6(t *testing.T) {
+	_, bindingMap, err := ParsePortSpecs([]string{"[::1]:8080:80/tcp", "[2001:db8::1]::53/udp"})
+	if err != nil {
+		t.Fatalf("Error while processing ParsePortSpecs: %s", err)
+	}
+	if b := bindingMap[Port("80/tcp")]; len(b) != 1 || b[0].HostIp != "::1" || b[0].HostPort != "8080" {
+		t.Fatalf("80/tcp was not parsed properly: %v", b)
+	}
+	if b := bindingMap[Port("53/udp")]; len(b) != 1 || b[0].HostIp != "2001:db8::1" || b[0].HostPort != "" {
+		t.Fatalf("53/udp was not parsed properly: %v", b)
+	}
+
+	for _, spec := range []string{"[::1]:80", "[::1:80:80", "[]:80:80", "[127.0.0.1]:80:80", "[::g]:80:80", "::1:80:80"} {
+		if _, _, err := ParsePortSpecs([]string{spec}); err == nil {
+			t.Fatalf("Received no error while trying to parse %s", spec)
+		}
+	}
+}
+
+func TestParsePortSpecsSCTP(t *testing.T) {
+	portMap, bindingMap, err := ParsePortSpecs([]string{"2905/sctp", "0.0.0.0:3868:3868/sctp"})
+	if err != nil {
+		t.Fatalf("Error while processing ParsePortSpecs: %s", err)
+	}
+	if _, ok := portMap[Port("2905/sctp")]; !ok {
+		t.Fatal("2905/sctp was not parsed properly")
+	}
+	if b := bindingMap[Port("3868/sctp")]; len(b) != 1 || b[0].HostIp != "0.0.0.0" || b[0].HostPort != "3868" {
+		t.Fatalf("3868/sctp was not parsed properly: %v", b)
+	}
+
+	if _, _, err := ParsePortSpecs([]string{"2905/dccp"}); err == nil {
+		t.Fatal("Received no error while trying to parse an unknown protocol")
+	}
+}
+
 func TestParsePortSpecsWithRange(t *testing.T) {
 	var (
 		portMap    map[Port]struct{}
@@ -0,0 +1,47 @@
+package opts
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseHostsFile reads a file in the format of /etc/hosts, and returns its
+// host-to-IP mappings in the format of --add-host, host:ip, one per host
+// name. Comments, from '#' to the end of the line, and blank lines are
+// ignored.
+func ParseHostsFile(filename string) ([]string, error) {
+	fh, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	var hosts []string
+	scanner := bufio.NewScanner(fh)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, fmt.Errorf("%s:%d: no host name for %s", filename, n, fields[0])
+		}
+		ip, err := ValidateIPAddress(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, n, err)
+		}
+		for _, host := range fields[1:] {
+			hosts = append(hosts, host+":"+ip)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
@@ -0,0 +1,29 @@
+package opts
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/pkg/parsers"
+)
+
+// MapOpts is a flag setting key=value pairs in a map.
+type MapOpts struct {
+	values map[string]string
+}
+
+func NewMapOpts(ref map[string]string) *MapOpts {
+	return &MapOpts{ref}
+}
+
+func (o *MapOpts) Set(val string) error {
+	k, v, err := parsers.ParseKeyValueOpt(val)
+	if err != nil {
+		return err
+	}
+	o.values[k] = v
+	return nil
+}
+
+func (o *MapOpts) String() string {
+	return fmt.Sprintf("%v", o.values)
+}
@@ -1,6 +1,7 @@
 package opts
 
 import (
+	"bytes"
 	"fmt"
 	"net"
 	"os"
@@ -18,6 +19,7 @@ import (
 var (
 	alphaRegexp  = regexp.MustCompile(`[a-zA-Z]`)
 	domainRegexp = regexp.MustCompile(`^(:?(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9]))(:?\.(:?[a-zA-Z0-9]|(:?[a-zA-Z0-9][a-zA-Z0-9\-]*[a-zA-Z0-9])))*)\.?\s*$`)
+	dnsOptRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-]*(:[0-9]+)?$`)
 )
 
 func ListVar(values *[]string, names []string, usage string) {
@@ -36,6 +38,10 @@ func DnsSearchListVar(values *[]string, names []string, usage string) {
 	flag.Var(newListOptsRef(values, ValidateDnsSearch), names, usage)
 }
 
+func DnsOptListVar(values *[]string, names []string, usage string) {
+	flag.Var(newListOptsRef(values, ValidateDnsOpt), names, usage)
+}
+
 func IPVar(value *net.IP, names []string, defaultValue, usage string) {
 	flag.Var(NewIpOpt(value, defaultValue), names, usage)
 }
@@ -44,6 +50,10 @@ func LabelListVar(values *[]string, names []string, usage string) {
 	flag.Var(newListOptsRef(values, ValidateLabel), names, usage)
 }
 
+func MapVar(values map[string]string, names []string, usage string) {
+	flag.Var(NewMapOpts(values), names, usage)
+}
+
 func UlimitMapVar(values map[string]*ulimit.Ulimit, names []string, usage string) {
 	flag.Var(NewUlimitOpt(values), names, usage)
 }
@@ -188,11 +198,22 @@ func ValidateIPAddress(val string) (string, error) {
 	return "", fmt.Errorf("%s is not an ip address", val)
 }
 
+// ValidateMACAddress checks val is a MAC address a container can have: the
+// 6 bytes of the address of a single interface, not all zeros.
 func ValidateMACAddress(val string) (string, error) {
-	_, err := net.ParseMAC(strings.TrimSpace(val))
+	mac, err := net.ParseMAC(strings.TrimSpace(val))
 	if err != nil {
 		return "", err
 	}
+	if len(mac) != 6 {
+		return "", fmt.Errorf("%s is not an ethernet MAC address, it must be 6 bytes", val)
+	}
+	if mac[0]&1 != 0 {
+		return "", fmt.Errorf("%s is a multicast MAC address, it must be unicast", val)
+	}
+	if bytes.Equal(mac, make(net.HardwareAddr, 6)) {
+		return "", fmt.Errorf("%s is not a valid MAC address, it is all zeros", val)
+	}
 	return val, nil
 }
 
@@ -205,6 +226,14 @@ func ValidateDnsSearch(val string) (string, error) {
 	return validateDomain(val)
 }
 
+// Validates an option for resolvconf, such as ndots:5 or rotate.
+func ValidateDnsOpt(val string) (string, error) {
+	if !dnsOptRegexp.MatchString(val) {
+		return "", fmt.Errorf("%s is not a valid DNS option", val)
+	}
+	return val, nil
+}
+
 func validateDomain(val string) (string, error) {
 	if alphaRegexp.FindString(val) == "" {
 		return "", fmt.Errorf("%s is not a valid domain", val)
@@ -1,6 +1,8 @@
 package opts
 
 import (
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 )
@@ -40,6 +42,12 @@ func TestValidateMACAddress(t *testing.T) {
 	if _, err := ValidateMACAddress(`random invalid string`); err == nil {
 		t.Fatalf("ValidateMACAddress(`random invalid string`) succeeded; expected failure on invalid MAC")
 	}
+
+	for _, mac := range []string{`00:00:5e:00:53:01:00:01`, `01:00:5e:00:00:fb`, `ff:ff:ff:ff:ff:ff`, `00:00:00:00:00:00`} {
+		if _, err := ValidateMACAddress(mac); err == nil {
+			t.Fatalf("ValidateMACAddress(`%s`) succeeded; expected failure on a MAC a container can't have", mac)
+		}
+	}
 }
 
 func TestListOpts(t *testing.T) {
@@ -64,6 +72,36 @@ func TestListOpts(t *testing.T) {
 	}
 }
 
+func TestMapOpts(t *testing.T) {
+	values := make(map[string]string)
+	o := NewMapOpts(values)
+	if err := o.Set("max-size=1m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Set("labels=a=b"); err != nil {
+		t.Fatal(err)
+	}
+	if values["max-size"] != "1m" || values["labels"] != "a=b" {
+		t.Errorf("Unexpected values %v", values)
+	}
+	if err := o.Set("invalid"); err == nil {
+		t.Error("Expected an error setting an option without a value")
+	}
+}
+
+func TestValidateDnsOpt(t *testing.T) {
+	for _, opt := range []string{"ndots:5", "timeout:2", "attempts:3", "rotate", "single-request-reopen"} {
+		if ret, err := ValidateDnsOpt(opt); err != nil || ret != opt {
+			t.Fatalf("ValidateDnsOpt(`%s`) should succeed: error %v", opt, err)
+		}
+	}
+	for _, opt := range []string{"", "ndots:", "ndots:five", "ndots:5 rotate", ":5", "-rotate"} {
+		if _, err := ValidateDnsOpt(opt); err == nil {
+			t.Fatalf("ValidateDnsOpt(`%s`) should have failed validation", opt)
+		}
+	}
+}
+
 func TestValidateDnsSearch(t *testing.T) {
 	valid := []string{
 		`.`,
@@ -152,3 +190,23 @@ func TestValidateExtraHosts(t *testing.T) {
 		}
 	}
 }
+
+func TestParseHostsFile(t *testing.T) {
+	for content, expectedError := range map[string]string{
+		"10.0.0.1 db\n10.0.0.2\n":       `:2: no host name for 10.0.0.2`,
+		"db 10.0.0.1\n":                 `:1: db is not an ip address`,
+		"# comment\n10.0.0.300 cache\n": `:2: 10.0.0.300 is not an ip address`,
+	} {
+		f, err := ioutil.TempFile("", "hosts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(content)
+		f.Close()
+		_, err = ParseHostsFile(f.Name())
+		os.Remove(f.Name())
+		if err == nil || !strings.Contains(err.Error(), expectedError) {
+			t.Fatalf("Expected an error containing %q for %q, got %v", expectedError, content, err)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/fileutils"
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/pkg/pools"
 	"github.com/docker/docker/pkg/promise"
 	"github.com/docker/docker/pkg/system"
@@ -94,6 +95,29 @@ func xzDecompress(archive io.Reader) (io.ReadCloser, error) {
 	return CmdStream(exec.Command(args[0], args[1:]...), archive)
 }
 
+// xzCompress returns a writer compressing what's written to it to dest
+// with the xz command, which is done once the writer is closed.
+func xzCompress(dest io.Writer) (io.WriteCloser, error) {
+	cmd := exec.Command("xz", "-z", "-c", "-q")
+	cmd.Stdout = dest
+	errBuf := new(bytes.Buffer)
+	cmd.Stderr = errBuf
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return ioutils.NewWriteCloserWrapper(stdin, func() error {
+		stdin.Close()
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("%s: %s", err, errBuf)
+		}
+		return nil
+	}), nil
+}
+
 func DecompressStream(archive io.Reader) (io.ReadCloser, error) {
 	p := pools.BufioReader32KPool
 	buf := p.Get(archive)
@@ -141,9 +165,16 @@ func CompressStream(dest io.WriteCloser, compression Compression) (io.WriteClose
 		gzWriter := gzip.NewWriter(dest)
 		writeBufWrapper := p.NewWriteCloserWrapper(buf, gzWriter)
 		return writeBufWrapper, nil
-	case Bzip2, Xz:
-		// archive/bzip2 does not support writing, and there is no xz support at all
-		// However, this is not a problem as docker only currently generates gzipped tars
+	case Xz:
+		xzWriter, err := xzCompress(dest)
+		if err != nil {
+			return nil, err
+		}
+		writeBufWrapper := p.NewWriteCloserWrapper(buf, xzWriter)
+		return writeBufWrapper, nil
+	case Bzip2:
+		// archive/bzip2 does not support writing, which is not a problem as
+		// docker only generates gzipped and xz tars
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
 	default:
 		return nil, fmt.Errorf("Unsupported compression format %s", (&compression).Extension())
@@ -415,96 +446,148 @@ func TarWithOptions(srcPath string, options *TarOptions) (io.ReadCloser, error)
 	}
 
 	go func() {
-		ta := &tarAppender{
-			TarWriter: tar.NewWriter(compressWriter),
-			Buffer:    pools.BufioWriter32KPool.Get(nil),
-			SeenFiles: make(map[uint64]string),
+		if err := writeTar(compressWriter, srcPath, options); err != nil {
+			logrus.Debugf("Can't tar %s: %s", srcPath, err)
+		}
+		if err := compressWriter.Close(); err != nil {
+			logrus.Debugf("Can't close compress writer: %s", err)
 		}
-		// this buffer is needed for the duration of this piped stream
-		defer pools.BufioWriter32KPool.Put(ta.Buffer)
+		if err := pipeWriter.Close(); err != nil {
+			logrus.Debugf("Can't close pipe writer: %s", err)
+		}
+	}()
 
-		// In general we log errors here but ignore them because
-		// during e.g. a diff operation the container can continue
-		// mutating the filesystem and we can see transient errors
-		// from this
+	return pipeReader, nil
+}
 
-		if options.IncludeFiles == nil {
-			options.IncludeFiles = []string{"."}
-		}
+// WriteTar writes an archive of the directory at `srcPath` to dest as
+// TarWithOptions creates it, as it walks the directory, with a fixed
+// amount of memory. It stops at the first error writing to dest, e.g. when
+// the client the archive is sent to goes away, instead of walking the rest
+// of the directory, and returns it.
+func WriteTar(dest io.Writer, srcPath string, options *TarOptions) error {
+	compressWriter, err := CompressStream(ioutils.NopWriteCloser(dest), options.Compression)
+	if err != nil {
+		return err
+	}
+	err = writeTar(compressWriter, srcPath, options)
+	if err2 := compressWriter.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
 
-		seen := make(map[string]bool)
+// stickyErrWriter remembers the first error writing to its writer.
+type stickyErrWriter struct {
+	w   io.Writer
+	err error
+}
 
-		var renamedRelFilePath string // For when tar.Options.Name is set
-		for _, include := range options.IncludeFiles {
-			filepath.Walk(filepath.Join(srcPath, include), func(filePath string, f os.FileInfo, err error) error {
-				if err != nil {
-					logrus.Debugf("Tar: Can't stat file %s to tar: %s", srcPath, err)
-					return nil
-				}
+func (w *stickyErrWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
 
-				relFilePath, err := filepath.Rel(srcPath, filePath)
-				if err != nil || (relFilePath == "." && f.IsDir()) {
-					// Error getting relative path OR we are looking
-					// at the root path. Skip in both situations.
-					return nil
-				}
+func writeTar(dest io.Writer, srcPath string, options *TarOptions) error {
+	out := &stickyErrWriter{w: dest}
+	ta := &tarAppender{
+		TarWriter: tar.NewWriter(out),
+		Buffer:    pools.BufioWriter32KPool.Get(nil),
+		SeenFiles: make(map[uint64]string),
+	}
+	defer pools.BufioWriter32KPool.Put(ta.Buffer)
+
+	// In general we log errors here but ignore them because
+	// during e.g. a diff operation the container can continue
+	// mutating the filesystem and we can see transient errors
+	// from this
+
+	if options.IncludeFiles == nil {
+		options.IncludeFiles = []string{"."}
+	}
+
+	// The files of several includes may overlap, a single one is walked
+	// without remembering every file of it
+	var seen map[string]bool
+	if len(options.IncludeFiles) > 1 {
+		seen = make(map[string]bool)
+	}
+
+	var renamedRelFilePath string // For when tar.Options.Name is set
+	for _, include := range options.IncludeFiles {
+		filepath.Walk(filepath.Join(srcPath, include), func(filePath string, f os.FileInfo, err error) error {
+			if err != nil {
+				logrus.Debugf("Tar: Can't stat file %s to tar: %s", srcPath, err)
+				return nil
+			}
+
+			relFilePath, err := filepath.Rel(srcPath, filePath)
+			if err != nil || (relFilePath == "." && f.IsDir()) {
+				// Error getting relative path OR we are looking
+				// at the root path. Skip in both situations.
+				return nil
+			}
 
-				skip := false
-
-				// If "include" is an exact match for the current file
-				// then even if there's an "excludePatterns" pattern that
-				// matches it, don't skip it. IOW, assume an explicit 'include'
-				// is asking for that file no matter what - which is true
-				// for some files, like .dockerignore and Dockerfile (sometimes)
-				if include != relFilePath {
-					skip, err = fileutils.Matches(relFilePath, options.ExcludePatterns)
-					if err != nil {
-						logrus.Debugf("Error matching %s", relFilePath, err)
-						return err
-					}
+			skip := false
+
+			// If "include" is an exact match for the current file
+			// then even if there's an "excludePatterns" pattern that
+			// matches it, don't skip it. IOW, assume an explicit 'include'
+			// is asking for that file no matter what - which is true
+			// for some files, like .dockerignore and Dockerfile (sometimes)
+			if include != relFilePath {
+				skip, err = fileutils.Matches(relFilePath, options.ExcludePatterns)
+				if err != nil {
+					logrus.Debugf("Error matching %s", relFilePath, err)
+					return err
 				}
+			}
 
-				if skip {
-					if f.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
+			if skip {
+				if f.IsDir() {
+					return filepath.SkipDir
 				}
+				return nil
+			}
 
+			if seen != nil {
 				if seen[relFilePath] {
 					return nil
 				}
 				seen[relFilePath] = true
+			}
 
-				// Rename the base resource
-				if options.Name != "" && filePath == srcPath+"/"+filepath.Base(relFilePath) {
-					renamedRelFilePath = relFilePath
-				}
-				// Set this to make sure the items underneath also get renamed
-				if options.Name != "" {
-					relFilePath = strings.Replace(relFilePath, renamedRelFilePath, options.Name, 1)
-				}
+			// Rename the base resource
+			if options.Name != "" && filePath == srcPath+"/"+filepath.Base(relFilePath) {
+				renamedRelFilePath = relFilePath
+			}
+			// Set this to make sure the items underneath also get renamed
+			if options.Name != "" {
+				relFilePath = strings.Replace(relFilePath, renamedRelFilePath, options.Name, 1)
+			}
 
-				if err := ta.addTarFile(filePath, relFilePath); err != nil {
-					logrus.Debugf("Can't add file %s to tar: %s", filePath, err)
+			if err := ta.addTarFile(filePath, relFilePath); err != nil {
+				if out.err != nil {
+					// Nothing more can be written
+					return out.err
 				}
-				return nil
-			})
-		}
-
-		// Make sure to check the error on Close.
-		if err := ta.TarWriter.Close(); err != nil {
-			logrus.Debugf("Can't close tar writer: %s", err)
-		}
-		if err := compressWriter.Close(); err != nil {
-			logrus.Debugf("Can't close compress writer: %s", err)
-		}
-		if err := pipeWriter.Close(); err != nil {
-			logrus.Debugf("Can't close pipe writer: %s", err)
+				logrus.Debugf("Can't add file %s to tar: %s", filePath, err)
+			}
+			return nil
+		})
+		if out.err != nil {
+			return out.err
 		}
-	}()
+	}
 
-	return pipeReader, nil
+	// Make sure to check the error on Close.
+	return ta.TarWriter.Close()
 }
 
 func Unpack(decompressedArchive io.Reader, dest string, options *TarOptions) error {
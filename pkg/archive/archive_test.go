@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/docker/docker/vendor/src/code.google.com/p/go/src/pkg/archive/tar"
 )
 
@@ -211,6 +212,94 @@ func TestTarWithOptions(t *testing.T) {
 	}
 }
 
+func TestCompressStreamXz(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz is not installed")
+	}
+	buf := new(bytes.Buffer)
+	w, err := CompressStream(ioutils.NopWriteCloser(buf), Xz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("hello world\n"), 1024)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if compression := DetectCompression(buf.Bytes()); compression != Xz {
+		t.Fatalf("Expected an xz stream, got %s", (&compression).Extension())
+	}
+	r, err := DecompressStream(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	decompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Fatalf("Expected %d bytes to be decompressed, got %d", len(data), len(decompressed))
+	}
+}
+
+// failingWriter fails once n bytes are written.
+type failingWriter struct {
+	n      int
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if len(p) > w.n {
+		return 0, fmt.Errorf("failing writer")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteTar(t *testing.T) {
+	origin, err := ioutil.TempDir("", "docker-test-write-tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(origin)
+	for i := 0; i < 100; i++ {
+		if err := ioutil.WriteFile(path.Join(origin, fmt.Sprint(i)), bytes.Repeat([]byte{'a'}, 64*1024), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteTar(buf, origin, &TarOptions{Compression: Uncompressed}); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(buf)
+	files := 0
+	for {
+		if _, err := tr.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		files++
+	}
+	if files != 100 {
+		t.Fatalf("Expected 100 files in the archive, got %d", files)
+	}
+
+	// The walk stops at the first error writing the archive
+	w := &failingWriter{n: 256 * 1024}
+	if err := WriteTar(w, origin, &TarOptions{Compression: Uncompressed}); err == nil || err.Error() != "failing writer" {
+		t.Fatalf("Expected the error of the writer, got %v", err)
+	}
+	if w.writes > 20 {
+		t.Fatalf("Expected the archive to stop after the error, got %d writes", w.writes)
+	}
+}
+
 // Some tar archives such as http://haproxy.1wt.eu/download/1.5/src/devel/haproxy-1.5-dev21.tar.gz
 // use PAX Global Extended Headers.
 // Failing prevents the archives from being uncompressed during ADD
@@ -0,0 +1,68 @@
+package archive
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// ficlone is the FICLONE ioctl, which makes a file share the extents of
+// another on the filesystems supporting reflinks, such as btrfs and xfs.
+const ficlone = 0x40049409
+
+// sysCopyFileRange is the number of the copy_file_range system call of
+// Linux 4.5 and later, which the syscall package doesn't know of, or 0 if
+// it's unknown on this architecture.
+var sysCopyFileRange = map[string]uintptr{
+	"386":     377,
+	"amd64":   326,
+	"arm":     391,
+	"arm64":   285,
+	"ppc64":   379,
+	"ppc64le": 379,
+	"s390x":   375,
+}[runtime.GOARCH]
+
+// CopyRegular copies the content of the regular file at srcPath to the file
+// at dstPath, created with mode if it doesn't exist. When both are on a
+// filesystem supporting reflinks, dstPath shares the data of srcPath until
+// either is modified, and the copy takes no time nor space. Otherwise the
+// data is copied by the kernel with copy_file_range, which some filesystems
+// turn into reflinks or copies on the server side, and with a plain copy if
+// the kernel can't.
+func CopyRegular(srcPath, dstPath string, mode os.FileMode) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, dstFile.Fd(), ficlone, srcFile.Fd()); errno == 0 {
+		return nil
+	}
+
+	if sysCopyFileRange != 0 {
+		for {
+			n, _, errno := syscall.Syscall6(sysCopyFileRange, srcFile.Fd(), 0, dstFile.Fd(), 0, 1<<30, 0)
+			if errno != 0 {
+				// The kernel can't copy between these files, e.g. before
+				// Linux 5.3 when they are on different filesystems, the
+				// rest is copied below, from where it stopped.
+				break
+			}
+			if n == 0 {
+				return nil
+			}
+		}
+	}
+
+	_, err = io.Copy(dstFile, srcFile)
+	return err
+}
@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCopyRegular(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "docker-test-copy-regular")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	data := make([]byte, 3*1024*1024+7)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	src := filepath.Join(tmp, "src")
+	if err := ioutil.WriteFile(src, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(tmp, "dst")
+	// A longer file at dst is truncated
+	if err := ioutil.WriteFile(dst, make([]byte, len(data)+10), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyRegular(src, dst, 0644); err != nil {
+		t.Fatal(err)
+	}
+	copied, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("Expected %d bytes of %s to be copied, got %d different ones", len(data), src, len(copied))
+	}
+
+	// The source is left as it is when the copy is modified
+	if err := ioutil.WriteFile(dst, []byte("modified"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	original, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, data) {
+		t.Fatalf("Expected %s to be left unmodified", src)
+	}
+
+	if err := CopyRegular(filepath.Join(tmp, "missing"), dst, 0644); !os.IsNotExist(err) {
+		t.Fatalf("Expected an error for a missing file, got %v", err)
+	}
+}
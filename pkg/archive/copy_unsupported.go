@@ -0,0 +1,27 @@
+// +build !linux
+
+package archive
+
+import (
+	"io"
+	"os"
+)
+
+// CopyRegular copies the content of the regular file at srcPath to the file
+// at dstPath, created with mode if it doesn't exist.
+func CopyRegular(srcPath, dstPath string, mode os.FileMode) error {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+
+	_, err = io.Copy(dstFile, srcFile)
+	return err
+}
@@ -0,0 +1,108 @@
+// Package authorization asks external authorization plugins whether a
+// request made to the remote API should be allowed.
+//
+// A plugin is an HTTP server listening on a unix socket. For every API
+// request the daemon POSTs a JSON encoded Request to the plugin's
+// /AuthZPlugin.AuthZReq endpoint and expects a JSON encoded Response back.
+package authorization
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/pkg/plugins"
+)
+
+// AuthZApiRequest is the endpoint plugins must serve.
+const AuthZApiRequest = "/AuthZPlugin.AuthZReq"
+
+// Request holds everything a plugin gets to know about an API request.
+type Request struct {
+	// User is the identity of the caller, e.g. the common name of its TLS
+	// client certificate. It is empty for unauthenticated connections.
+	User string `json:",omitempty"`
+
+	RequestMethod  string            `json:",omitempty"`
+	RequestURI     string            `json:",omitempty"`
+	RequestHeaders map[string]string `json:",omitempty"`
+
+	// RequestBody is the json request body. Other bodies, e.g. build
+	// contexts, are never forwarded.
+	RequestBody []byte `json:",omitempty"`
+}
+
+// Response is the verdict of a plugin.
+type Response struct {
+	// Allow tells whether the request may proceed.
+	Allow bool `json:"Allow"`
+
+	// Msg explains the verdict to the user.
+	Msg string `json:",omitempty"`
+
+	// Err reports a failure of the plugin itself.
+	Err string `json:",omitempty"`
+}
+
+// Plugin is an authorization plugin reachable over a unix socket.
+type Plugin struct {
+	name   string
+	client *plugins.Client
+}
+
+// NewPlugin returns the plugin listening at the socket given by name, which
+// is either an absolute path or the name of a socket in
+// plugins.DefaultPluginDir.
+func NewPlugin(name string) *Plugin {
+	return &Plugin{name: name, client: plugins.NewClient(plugins.Socket(name))}
+}
+
+// NewPlugins returns the plugins for the given names, in the same order.
+func NewPlugins(names []string) []*Plugin {
+	plugins := make([]*Plugin, 0, len(names))
+	for _, name := range names {
+		plugins = append(plugins, NewPlugin(name))
+	}
+	return plugins
+}
+
+// Name returns the name the plugin was configured with.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// AuthZRequest asks the plugin for its verdict on req.
+func (p *Plugin) AuthZRequest(req *Request) (*Response, error) {
+	var authRes Response
+	if err := p.client.Call(AuthZApiRequest, req, &authRes); err != nil {
+		return nil, err
+	}
+	if authRes.Err != "" {
+		return nil, fmt.Errorf("%s", authRes.Err)
+	}
+	return &authRes, nil
+}
+
+// Authorize runs req through every plugin in turn. It returns an error
+// describing the first plugin that denied the request or failed to answer;
+// a request is only allowed when all plugins allow it.
+func Authorize(plugins []*Plugin, req *Request) error {
+	for _, plugin := range plugins {
+		res, err := plugin.AuthZRequest(req)
+		if err != nil {
+			return fmt.Errorf("plugin %s failed with error: %s", plugin.Name(), err)
+		}
+		if !res.Allow {
+			return &DeniedError{Plugin: plugin.Name(), Msg: res.Msg}
+		}
+	}
+	return nil
+}
+
+// DeniedError is returned by Authorize when a plugin denied a request.
+type DeniedError struct {
+	Plugin string
+	Msg    string
+}
+
+func (e *DeniedError) Error() string {
+	return fmt.Sprintf("authorization denied by plugin %s: %s", e.Plugin, e.Msg)
+}
@@ -0,0 +1,76 @@
+package authorization
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// startPlugin serves a plugin on a unix socket that denies any request whose
+// body mentions "Privileged":true.
+func startPlugin(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "authz-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "policy.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(AuthZApiRequest, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		res := Response{Allow: true}
+		if bytes.Contains(req.RequestBody, []byte(`"Privileged":true`)) {
+			res = Response{Allow: false, Msg: req.User + " can't run privileged containers"}
+		}
+		json.NewEncoder(w).Encode(res)
+	})
+	go http.Serve(l, mux)
+	return socket, func() {
+		l.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	socket, cleanup := startPlugin(t)
+	defer cleanup()
+	plugins := NewPlugins([]string{socket})
+
+	req := &Request{User: "dev", RequestMethod: "POST", RequestURI: "/containers/create", RequestBody: []byte(`{"HostConfig":{"Privileged":false}}`)}
+	if err := Authorize(plugins, req); err != nil {
+		t.Fatal(err)
+	}
+
+	req.RequestBody = []byte(`{"HostConfig":{"Privileged":true}}`)
+	err := Authorize(plugins, req)
+	denied, ok := err.(*DeniedError)
+	if !ok {
+		t.Fatalf("Expected the request to be denied, got %v", err)
+	}
+	if denied.Plugin != socket || denied.Msg != "dev can't run privileged containers" {
+		t.Fatalf("Unexpected denial %#v", denied)
+	}
+}
+
+func TestAuthorizeUnreachablePlugin(t *testing.T) {
+	plugins := NewPlugins([]string{"/nonexistent/policy.sock"})
+	err := Authorize(plugins, &Request{RequestMethod: "GET", RequestURI: "/info"})
+	if err == nil {
+		t.Fatal("Expected an error when the plugin can't be reached")
+	}
+	if _, ok := err.(*DeniedError); ok {
+		t.Fatalf("A failing plugin should not be reported as a denial: %v", err)
+	}
+}
@@ -132,3 +132,33 @@ func TestUpdate(t *testing.T) {
 		t.Fatalf("Expected to find '%s' got '%s'", expected, content)
 	}
 }
+
+func TestUpdateOnlyHostname(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), "", "", "", []Record{
+		{Hosts: "db db.example other", IP: "172.17.0.2"},
+		{Hosts: "db2 db2.example", IP: "172.17.0.3"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Update(file.Name(), "172.17.0.9", "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"172.17.0.9\tdb db.example other\n", "172.17.0.3\tdb2 db2.example\n"} {
+		if !bytes.Contains(content, []byte(expected)) {
+			t.Fatalf("Expected to find '%s' got '%s'", expected, content)
+		}
+	}
+}
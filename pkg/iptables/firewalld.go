@@ -0,0 +1,199 @@
+package iptables
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/godbus/dbus"
+)
+
+// IPV is the family of the rules passed through firewalld.
+type IPV string
+
+const (
+	Iptables  IPV = "ipv4"
+	Ip6tables IPV = "ipv6"
+
+	// DockerZone is the firewalld zone of the bridges of the daemon.
+	DockerZone = "docker"
+
+	dbusInterface  = "org.fedoraproject.FirewallD1"
+	dbusPath       = "/org/fedoraproject/FirewallD1"
+	dbusConfigPath = "/org/fedoraproject/FirewallD1/config"
+)
+
+var (
+	firewalld        *dbus.Conn
+	firewalldRunning bool
+	onReloaded       []func()
+	firewalldLock    sync.Mutex
+)
+
+// zoneSettings are the settings of a firewalld zone, in the order of the
+// config.addZone method of firewalld.
+type zoneSettings struct {
+	Version            string
+	Short              string
+	Description        string
+	Unused             bool
+	Target             string
+	Services           []string
+	Ports              []zonePort
+	IcmpBlocks         []string
+	Masquerade         bool
+	ForwardPorts       []zoneForwardPort
+	Interfaces         []string
+	Sources            []string
+	RichRules          []string
+	Protocols          []string
+	SourcePorts        []zonePort
+	IcmpBlockInversion bool
+}
+
+type zonePort struct {
+	Port     string
+	Protocol string
+}
+
+type zoneForwardPort struct {
+	Port      string
+	Protocol  string
+	ToPort    string
+	ToAddress string
+}
+
+// FirewalldInit connects to firewalld on the system bus. While firewalld
+// runs, Raw and Raw6 pass the rules through it, and the functions given to
+// OnReloaded are called whenever it flushes them: when it reloads, starts or
+// stops.
+func FirewalldInit() error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	for _, rule := range []string{
+		fmt.Sprintf("type='signal',path='%s',interface='%s',sender='%s',member='Reloaded'", dbusPath, dbusInterface, dbusInterface),
+		fmt.Sprintf("type='signal',path='/org/freedesktop/DBus',interface='org.freedesktop.DBus',sender='org.freedesktop.DBus',member='NameOwnerChanged',arg0='%s'", dbusInterface),
+	} {
+		if err := conn.BusObject().Call("org.freedesktop.DBus.AddMatch", 0, rule).Err; err != nil {
+			return err
+		}
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	firewalld = conn
+	running := checkFirewalld()
+	logrus.Infof("Firewalld running: %t", running)
+	firewalldLock.Lock()
+	firewalldRunning = running
+	firewalldLock.Unlock()
+
+	go handleSignals(signals)
+	return nil
+}
+
+// FirewalldRunning returns whether the rules go through firewalld.
+func FirewalldRunning() bool {
+	firewalldLock.Lock()
+	defer firewalldLock.Unlock()
+	return firewalldRunning
+}
+
+// OnReloaded registers f to be called when firewalld flushed the rules.
+func OnReloaded(f func()) {
+	firewalldLock.Lock()
+	defer firewalldLock.Unlock()
+	onReloaded = append(onReloaded, f)
+}
+
+// handleSignals calls the functions given to OnReloaded after the signals
+// of firewalld flushing the rules. They run out of the way of the signals,
+// behind which the replies to their calls to firewalld are delivered.
+func handleSignals(signals chan *dbus.Signal) {
+	flushed := make(chan struct{}, 1)
+	go func() {
+		for range flushed {
+			running := checkFirewalld()
+			firewalldLock.Lock()
+			firewalldRunning = running
+			callbacks := onReloaded
+			firewalldLock.Unlock()
+			logrus.Infof("Firewalld flushed the rules, running: %t", running)
+			for _, f := range callbacks {
+				f()
+			}
+		}
+	}()
+	for signal := range signals {
+		if signal.Name == dbusInterface+".Reloaded" || strings.HasSuffix(signal.Name, ".NameOwnerChanged") {
+			select {
+			case flushed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// checkFirewalld returns whether firewalld answers on the bus.
+func checkFirewalld() bool {
+	var zone string
+	return firewallObject().Call(dbusInterface+".getDefaultZone", 0).Store(&zone) == nil
+}
+
+func firewallObject() *dbus.Object {
+	return firewalld.Object(dbusInterface, dbus.ObjectPath(dbusPath))
+}
+
+// Passthrough passes the arguments of an iptables, or ip6tables, command to
+// firewalld, which runs it.
+func Passthrough(ipv IPV, args ...string) ([]byte, error) {
+	logrus.Debugf("firewalld passthrough: %s, %v", ipv, args)
+	var output string
+	if err := firewallObject().Call(dbusInterface+".direct.passthrough", 0, string(ipv), args).Store(&output); err != nil {
+		return nil, fmt.Errorf("iptables failed: firewalld passthrough %s %v: %v", ipv, strings.Join(args, " "), err)
+	}
+	return []byte(output), nil
+}
+
+// AddInterfaceToZone puts iface in DockerZone, which is created first if
+// firewalld doesn't have it yet. The zone accepts the packets of its
+// interfaces, the rules of the daemon filter them.
+func AddInterfaceToZone(iface string) error {
+	obj := firewallObject()
+	var zones []string
+	if err := obj.Call(dbusInterface+".zone.getZones", 0).Store(&zones); err != nil {
+		return err
+	}
+	if !contains(zones, DockerZone) {
+		settings := zoneSettings{
+			Short:       DockerZone,
+			Description: "The bridges of the containers of Docker.",
+			Target:      "ACCEPT",
+		}
+		config := firewalld.Object(dbusInterface, dbus.ObjectPath(dbusConfigPath))
+		if err := config.Call(dbusInterface+".config.addZone", 0, DockerZone, settings).Err; err != nil {
+			return fmt.Errorf("Unable to create the %s zone of firewalld: %v", DockerZone, err)
+		}
+		// The permanent zone is only used once firewalld reloads.
+		if err := obj.Call(dbusInterface+".reload", 0).Err; err != nil {
+			return err
+		}
+	}
+	var zone string
+	if err := obj.Call(dbusInterface+".zone.getZoneOfInterface", 0, iface).Store(&zone); err == nil && zone == DockerZone {
+		return nil
+	}
+	return obj.Call(dbusInterface+".zone.changeZoneOfInterface", 0, DockerZone, iface).Err
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
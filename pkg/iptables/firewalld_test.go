@@ -0,0 +1,15 @@
+package iptables
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus"
+)
+
+func TestZoneSettingsSignature(t *testing.T) {
+	// The signature of the zone settings of config.addZone.
+	expected := "(sssbsasa(ss)asba(ssss)asasasasa(ss)b)"
+	if sig := dbus.SignatureOf(zoneSettings{}).String(); sig != expected {
+		t.Fatalf("Expected the zone settings to be %s, got %s", expected, sig)
+	}
+}
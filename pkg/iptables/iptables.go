@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -25,15 +26,20 @@ const (
 )
 
 var (
-	iptablesPath        string
-	supportsXlock       = false
-	ErrIptablesNotFound = errors.New("Iptables not found")
+	iptablesPath         string
+	ip6tablesPath        string
+	supportsXlock        = false
+	supportsXlock6       = false
+	ErrIptablesNotFound  = errors.New("Iptables not found")
+	ErrIp6tablesNotFound = errors.New("Ip6tables not found")
 )
 
+// Chain is a chain of iptables, or of ip6tables if IPv6 is set.
 type Chain struct {
 	Name   string
 	Bridge string
 	Table  Table
+	IPv6   bool
 }
 
 type ChainError struct {
@@ -58,32 +64,47 @@ func initCheck() error {
 	return nil
 }
 
-func NewChain(name, bridge string, table Table) (*Chain, error) {
-	c := &Chain{
-		Name:   name,
-		Bridge: bridge,
-		Table:  table,
+func initCheck6() error {
+	if ip6tablesPath == "" {
+		path, err := exec.LookPath("ip6tables")
+		if err != nil {
+			return ErrIp6tablesNotFound
+		}
+		ip6tablesPath = path
+		supportsXlock6 = exec.Command(ip6tablesPath, "--wait", "-L", "-n").Run() == nil
 	}
+	return nil
+}
+
+func NewChain(name, bridge string, table Table) (*Chain, error) {
+	return newChain(&Chain{Name: name, Bridge: bridge, Table: table})
+}
+
+// NewChain6 is NewChain for ip6tables.
+func NewChain6(name, bridge string, table Table) (*Chain, error) {
+	return newChain(&Chain{Name: name, Bridge: bridge, Table: table, IPv6: true})
+}
 
+func newChain(c *Chain) (*Chain, error) {
 	if string(c.Table) == "" {
 		c.Table = Filter
 	}
 
 	// Add chain if it doesn't exist
-	if _, err := Raw("-t", string(c.Table), "-n", "-L", c.Name); err != nil {
-		if output, err := Raw("-t", string(c.Table), "-N", c.Name); err != nil {
+	if _, err := c.raw("-t", string(c.Table), "-n", "-L", c.Name); err != nil {
+		if output, err := c.raw("-t", string(c.Table), "-N", c.Name); err != nil {
 			return nil, err
 		} else if len(output) != 0 {
 			return nil, fmt.Errorf("Could not create %s/%s chain: %s", c.Table, c.Name, output)
 		}
 	}
 
-	switch table {
+	switch c.Table {
 	case Nat:
 		preroute := []string{
 			"-m", "addrtype",
 			"--dst-type", "LOCAL"}
-		if !Exists(Nat, "PREROUTING", preroute...) {
+		if !exists(c.IPv6, Nat, "PREROUTING", preroute...) {
 			if err := c.Prerouting(Append, preroute...); err != nil {
 				return nil, fmt.Errorf("Failed to inject docker in PREROUTING chain: %s", err)
 			}
@@ -91,8 +112,8 @@ func NewChain(name, bridge string, table Table) (*Chain, error) {
 		output := []string{
 			"-m", "addrtype",
 			"--dst-type", "LOCAL",
-			"!", "--dst", "127.0.0.0/8"}
-		if !Exists(Nat, "OUTPUT", output...) {
+			"!", "--dst", c.loopback()}
+		if !exists(c.IPv6, Nat, "OUTPUT", output...) {
 			if err := c.Output(Append, output...); err != nil {
 				return nil, fmt.Errorf("Failed to inject docker in OUTPUT chain: %s", err)
 			}
@@ -101,9 +122,9 @@ func NewChain(name, bridge string, table Table) (*Chain, error) {
 		link := []string{
 			"-o", c.Bridge,
 			"-j", c.Name}
-		if !Exists(Filter, "FORWARD", link...) {
+		if !exists(c.IPv6, Filter, "FORWARD", link...) {
 			insert := append([]string{string(Insert), "FORWARD"}, link...)
-			if output, err := Raw(insert...); err != nil {
+			if output, err := c.raw(insert...); err != nil {
 				return nil, err
 			} else if len(output) != 0 {
 				return nil, fmt.Errorf("Could not create linking rule to %s/%s: %s", c.Table, c.Name, output)
@@ -124,6 +145,35 @@ func RemoveExistingChain(name string, table Table) error {
 	return c.Remove()
 }
 
+// RemoveExistingChain6 is RemoveExistingChain for ip6tables.
+func RemoveExistingChain6(name string, table Table) error {
+	c := &Chain{
+		Name:  name,
+		Table: table,
+		IPv6:  true,
+	}
+	if string(c.Table) == "" {
+		c.Table = Filter
+	}
+	return c.Remove()
+}
+
+// raw runs the iptables command of the chain, ip6tables for IPv6.
+func (c *Chain) raw(args ...string) ([]byte, error) {
+	if c.IPv6 {
+		return Raw6(args...)
+	}
+	return Raw(args...)
+}
+
+// loopback returns the loopback network of the chain.
+func (c *Chain) loopback() string {
+	if c.IPv6 {
+		return "::1/128"
+	}
+	return "127.0.0.0/8"
+}
+
 // Add forwarding rule to 'filter' table and corresponding nat rule to 'nat' table
 func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr string, destPort int) error {
 	daddr := ip.String()
@@ -133,7 +183,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		// value" by both iptables and ip6tables.
 		daddr = "0/0"
 	}
-	if output, err := Raw("-t", string(Nat), string(action), c.Name,
+	if output, err := c.raw("-t", string(Nat), string(action), c.Name,
 		"-p", proto,
 		"-d", daddr,
 		"--dport", strconv.Itoa(port),
@@ -145,7 +195,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		return &ChainError{Chain: "FORWARD", Output: output}
 	}
 
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
 		"!", "-i", c.Bridge,
 		"-o", c.Bridge,
 		"-p", proto,
@@ -157,7 +207,7 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 		return &ChainError{Chain: "FORWARD", Output: output}
 	}
 
-	if output, err := Raw("-t", string(Nat), string(action), "POSTROUTING",
+	if output, err := c.raw("-t", string(Nat), string(action), "POSTROUTING",
 		"-p", proto,
 		"-s", destAddr,
 		"-d", destAddr,
@@ -171,10 +221,66 @@ func (c *Chain) Forward(action Action, ip net.IP, port int, proto, destAddr stri
 	return nil
 }
 
+// ForwardRange is Forward for the count ports from port, forwarded to the
+// count ports from destPort. A range forwarded to the same ports has a
+// single set of rules, the others a set per port: DNAT can't shift a range.
+func (c *Chain) ForwardRange(action Action, ip net.IP, port, count int, proto, destAddr string, destPort int) error {
+	if count == 1 || port != destPort {
+		for i := 0; i < count; i++ {
+			if err := c.Forward(action, ip, port+i, proto, destAddr, destPort+i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	daddr := ip.String()
+	if ip.IsUnspecified() {
+		daddr = "0/0"
+	}
+	ports := fmt.Sprintf("%d:%d", port, port+count-1)
+	if output, err := c.raw("-t", string(Nat), string(action), c.Name,
+		"-p", proto,
+		"-d", daddr,
+		"--dport", ports,
+		"!", "-i", c.Bridge,
+		"-j", "DNAT",
+		"--to-destination", destAddr); err != nil {
+		return err
+	} else if len(output) != 0 {
+		return &ChainError{Chain: "FORWARD", Output: output}
+	}
+
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
+		"!", "-i", c.Bridge,
+		"-o", c.Bridge,
+		"-p", proto,
+		"-d", destAddr,
+		"--dport", ports,
+		"-j", "ACCEPT"); err != nil {
+		return err
+	} else if len(output) != 0 {
+		return &ChainError{Chain: "FORWARD", Output: output}
+	}
+
+	if output, err := c.raw("-t", string(Nat), string(action), "POSTROUTING",
+		"-p", proto,
+		"-s", destAddr,
+		"-d", destAddr,
+		"--dport", ports,
+		"-j", "MASQUERADE"); err != nil {
+		return err
+	} else if len(output) != 0 {
+		return &ChainError{Chain: "FORWARD", Output: output}
+	}
+
+	return nil
+}
+
 // Add reciprocal ACCEPT rule for two supplied IP addresses.
 // Traffic is allowed from ip1 to ip2 and vice-versa
 func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) error {
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip1.String(),
@@ -185,7 +291,7 @@ func (c *Chain) Link(action Action, ip1, ip2 net.IP, port int, proto string) err
 	} else if len(output) != 0 {
 		return fmt.Errorf("Error iptables forward: %s", output)
 	}
-	if output, err := Raw("-t", string(Filter), string(action), c.Name,
+	if output, err := c.raw("-t", string(Filter), string(action), c.Name,
 		"-i", c.Bridge, "-o", c.Bridge,
 		"-p", proto,
 		"-s", ip2.String(),
@@ -205,7 +311,7 @@ func (c *Chain) Prerouting(action Action, args ...string) error {
 	if len(args) > 0 {
 		a = append(a, args...)
 	}
-	if output, err := Raw(append(a, "-j", c.Name)...); err != nil {
+	if output, err := c.raw(append(a, "-j", c.Name)...); err != nil {
 		return err
 	} else if len(output) != 0 {
 		return &ChainError{Chain: "PREROUTING", Output: output}
@@ -219,7 +325,7 @@ func (c *Chain) Output(action Action, args ...string) error {
 	if len(args) > 0 {
 		a = append(a, args...)
 	}
-	if output, err := Raw(append(a, "-j", c.Name)...); err != nil {
+	if output, err := c.raw(append(a, "-j", c.Name)...); err != nil {
 		return err
 	} else if len(output) != 0 {
 		return &ChainError{Chain: "OUTPUT", Output: output}
@@ -231,29 +337,39 @@ func (c *Chain) Remove() error {
 	// Ignore errors - This could mean the chains were never set up
 	if c.Table == Nat {
 		c.Prerouting(Delete, "-m", "addrtype", "--dst-type", "LOCAL")
-		c.Output(Delete, "-m", "addrtype", "--dst-type", "LOCAL", "!", "--dst", "127.0.0.0/8")
+		c.Output(Delete, "-m", "addrtype", "--dst-type", "LOCAL", "!", "--dst", c.loopback())
 		c.Output(Delete, "-m", "addrtype", "--dst-type", "LOCAL") // Created in versions <= 0.1.6
 
 		c.Prerouting(Delete)
 		c.Output(Delete)
 	}
-	Raw("-t", string(c.Table), "-F", c.Name)
-	Raw("-t", string(c.Table), "-X", c.Name)
+	c.raw("-t", string(c.Table), "-F", c.Name)
+	c.raw("-t", string(c.Table), "-X", c.Name)
 	return nil
 }
 
 // Check if a rule exists
 func Exists(table Table, chain string, rule ...string) bool {
+	return exists(false, table, chain, rule...)
+}
+
+// Exists6 is Exists for ip6tables.
+func Exists6(table Table, chain string, rule ...string) bool {
+	return exists(true, table, chain, rule...)
+}
+
+func exists(ipv6 bool, table Table, chain string, rule ...string) bool {
 	if string(table) == "" {
 		table = Filter
 	}
+	c := &Chain{Table: table, IPv6: ipv6}
 
 	// iptables -C, --check option was added in v.1.4.11
 	// http://ftp.netfilter.org/pub/iptables/changes-iptables-1.4.11.txt
 
 	// try -C
 	// if exit status is 0 then return true, the rule exists
-	if _, err := Raw(append([]string{
+	if _, err := c.raw(append([]string{
 		"-t", string(table), "-C", chain}, rule...)...); err == nil {
 		return true
 	}
@@ -261,7 +377,7 @@ func Exists(table Table, chain string, rule ...string) bool {
 	// parse "iptables -S" for the rule (this checks rules in a specific chain
 	// in a specific table)
 	ruleString := strings.Join(rule, " ")
-	existingRules, _ := exec.Command("iptables", "-t", string(table), "-S", chain).Output()
+	existingRules, _ := c.raw("-t", string(table), "-S", chain)
 
 	// regex to replace ips in rule
 	// because MASQUERADE rule will not be exactly what was passed
@@ -273,21 +389,40 @@ func Exists(table Table, chain string, rule ...string) bool {
 	)
 }
 
-// Call 'iptables' system command, passing supplied arguments
+// Call 'iptables' system command, passing supplied arguments, through
+// firewalld while it runs.
 func Raw(args ...string) ([]byte, error) {
-
+	if FirewalldRunning() {
+		return Passthrough(Iptables, args...)
+	}
 	if err := initCheck(); err != nil {
 		return nil, err
 	}
-	if supportsXlock {
+	return raw(iptablesPath, supportsXlock, args...)
+}
+
+// Raw6 calls the 'ip6tables' system command, passing supplied arguments,
+// through firewalld while it runs.
+func Raw6(args ...string) ([]byte, error) {
+	if FirewalldRunning() {
+		return Passthrough(Ip6tables, args...)
+	}
+	if err := initCheck6(); err != nil {
+		return nil, err
+	}
+	return raw(ip6tablesPath, supportsXlock6, args...)
+}
+
+func raw(path string, xlock bool, args ...string) ([]byte, error) {
+	if xlock {
 		args = append([]string{"--wait"}, args...)
 	}
 
-	logrus.Debugf("%s, %v", iptablesPath, args)
+	logrus.Debugf("%s, %v", path, args)
 
-	output, err := exec.Command(iptablesPath, args...).CombinedOutput()
+	output, err := exec.Command(path, args...).CombinedOutput()
 	if err != nil {
-		return nil, fmt.Errorf("iptables failed: iptables %v: %s (%s)", strings.Join(args, " "), output, err)
+		return nil, fmt.Errorf("iptables failed: %s %v: %s (%s)", filepath.Base(path), strings.Join(args, " "), output, err)
 	}
 
 	// ignore iptables' message about xtables lock
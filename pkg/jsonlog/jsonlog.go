@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -13,6 +16,9 @@ type JSONLog struct {
 	Log     string    `json:"log,omitempty"`
 	Stream  string    `json:"stream,omitempty"`
 	Created time.Time `json:"time"`
+	// Attrs are the extra attributes of the container, selected by the
+	// options of its logging driver, that the entry was written with.
+	Attrs map[string]string `json:"attrs,omitempty"`
 }
 
 func (jl *JSONLog) Format(format string) (string, error) {
@@ -26,13 +32,34 @@ func (jl *JSONLog) Format(format string) (string, error) {
 	return fmt.Sprintf("%s %s", jl.Created.Format(format), jl.Log), nil
 }
 
+// Details returns the attributes of the entry as a "key=value,..." prefix for
+// its line, sorted by key, or "" when it has none.
+func (jl *JSONLog) Details() string {
+	if len(jl.Attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(jl.Attrs))
+	for k := range jl.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	details := make([]string, len(keys))
+	for i, k := range keys {
+		details[i] = url.QueryEscape(k) + "=" + url.QueryEscape(jl.Attrs[k])
+	}
+	return strings.Join(details, ",") + " "
+}
+
 func (jl *JSONLog) Reset() {
 	jl.Log = ""
 	jl.Stream = ""
 	jl.Created = time.Time{}
+	jl.Attrs = nil
 }
 
-func WriteLog(src io.Reader, dst io.Writer, format string) error {
+// WriteLog decodes the entries read from src and writes their lines to dst.
+// When attrs isn't nil, every line is prefixed with them, as its details.
+func WriteLog(src io.Reader, dst io.Writer, format string, attrs map[string]string) error {
 	dec := json.NewDecoder(src)
 	l := &JSONLog{}
 	for {
@@ -42,6 +69,10 @@ func WriteLog(src io.Reader, dst io.Writer, format string) error {
 			logrus.Printf("Error streaming logs: %s", err)
 			return err
 		}
+		if attrs != nil {
+			l.Attrs = attrs
+			l.Log = l.Details() + l.Log
+		}
 		line, err := l.Format(format)
 		if err != nil {
 			return err
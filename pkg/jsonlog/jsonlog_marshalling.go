@@ -97,6 +97,15 @@ func (mj *JSONLog) MarshalJSONBuf(buf *bytes.Buffer) error {
 		buf.WriteString(`"stream":`)
 		ffjson_WriteJsonString(buf, mj.Stream)
 	}
+	if len(mj.Attrs) != 0 {
+		if first == true {
+			first = false
+		} else {
+			buf.WriteString(`,`)
+		}
+		buf.WriteString(`"attrs":`)
+		writeJsonAttrs(buf, mj.Attrs)
+	}
 	if first == true {
 		first = false
 	} else {
@@ -21,7 +21,7 @@ func TestWriteLog(t *testing.T) {
 	}
 	w := bytes.NewBuffer(nil)
 	format := timeutils.RFC3339NanoFixed
-	if err := WriteLog(&buf, w, format); err != nil {
+	if err := WriteLog(&buf, w, format, nil); err != nil {
 		t.Fatal(err)
 	}
 	res := w.String()
@@ -39,6 +39,34 @@ func TestWriteLog(t *testing.T) {
 	}
 }
 
+func TestWriteLogDetails(t *testing.T) {
+	var buf bytes.Buffer
+	e := json.NewEncoder(&buf)
+	e.Encode(JSONLog{Log: "line1\n", Stream: "stdout", Attrs: map[string]string{"env": "prod", "app": "web server"}})
+	e.Encode(JSONLog{Log: "line2\n", Stream: "stdout"})
+	w := bytes.NewBuffer(nil)
+	if err := WriteLog(&buf, w, "", map[string]string{"app": "web"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "app=web line1\napp=web line2\n"
+	if w.String() != expected {
+		t.Fatalf("Expected %q, got %q", expected, w.String())
+	}
+
+	l := &JSONLog{Log: "line1\n", Stream: "stdout", Attrs: map[string]string{"env": "prod", "app": "web server"}}
+	if details := l.Details(); details != "app=web+server,env=prod " {
+		t.Fatalf("Unexpected details %q", details)
+	}
+	m, err := l.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"log":"line1\n","stream":"stdout","attrs":{"app":"web server","env":"prod"},"time":"0001-01-01T00:00:00Z"}`
+	if string(m) != expected {
+		t.Fatalf("Expected %s, got %s", expected, m)
+	}
+}
+
 func BenchmarkWriteLog(b *testing.B) {
 	var buf bytes.Buffer
 	e := json.NewEncoder(&buf)
@@ -52,7 +80,7 @@ func BenchmarkWriteLog(b *testing.B) {
 	b.SetBytes(int64(r.Len()))
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		if err := WriteLog(r, w, format); err != nil {
+		if err := WriteLog(r, w, format, nil); err != nil {
 			b.Fatal(err)
 		}
 		b.StopTimer()
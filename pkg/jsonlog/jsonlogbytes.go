@@ -2,6 +2,7 @@ package jsonlog
 
 import (
 	"bytes"
+	"sort"
 	"unicode/utf8"
 )
 
@@ -9,9 +10,10 @@ import (
 // It allows marshalling JSONLog from Log as []byte
 // and an already marshalled Created timestamp.
 type JSONLogBytes struct {
-	Log     []byte `json:"log,omitempty"`
-	Stream  string `json:"stream,omitempty"`
-	Created string `json:"time"`
+	Log     []byte            `json:"log,omitempty"`
+	Stream  string            `json:"stream,omitempty"`
+	Created string            `json:"time"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
 }
 
 // MarshalJSONBuf is based on the same method from JSONLog
@@ -38,6 +40,15 @@ func (mj *JSONLogBytes) MarshalJSONBuf(buf *bytes.Buffer) error {
 		buf.WriteString(`"stream":`)
 		ffjson_WriteJsonString(buf, mj.Stream)
 	}
+	if len(mj.Attrs) != 0 {
+		if first == true {
+			first = false
+		} else {
+			buf.WriteString(`,`)
+		}
+		buf.WriteString(`"attrs":`)
+		writeJsonAttrs(buf, mj.Attrs)
+	}
 	if first == true {
 		first = false
 	} else {
@@ -49,6 +60,26 @@ func (mj *JSONLogBytes) MarshalJSONBuf(buf *bytes.Buffer) error {
 	return nil
 }
 
+// writeJsonAttrs writes attrs as a JSON object, sorted by key so that the
+// entries of a container are written the same way.
+func writeJsonAttrs(buf *bytes.Buffer, attrs map[string]string) {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		ffjson_WriteJsonString(buf, k)
+		buf.WriteByte(':')
+		ffjson_WriteJsonString(buf, attrs[k])
+	}
+	buf.WriteByte('}')
+}
+
 // This is based on ffjson_WriteJsonString. It has been changed
 // to accept a string passed as a slice of bytes.
 func ffjson_WriteJsonBytesAsString(buf *bytes.Buffer, s []byte) {
@@ -0,0 +1,247 @@
+package kvstore
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// consul is a store on the KV API of consul agents. The keys written with
+// a TTL are held by a session of that TTL, which deletes them when it
+// expires, every write renewing it.
+type consul struct {
+	agents []*consulAgent
+	prefix string
+
+	sync.Mutex
+	// sessions are the IDs of the sessions holding the keys, by TTL.
+	sessions map[time.Duration]string
+}
+
+// consulAgent are the clients of an agent, for the requests and for the
+// watches, which last longer.
+type consulAgent struct {
+	client, watch *api.Client
+}
+
+func newConsul(addrs []string, prefix string) (*consul, error) {
+	s := &consul{prefix: prefix, sessions: make(map[time.Duration]string)}
+	for _, addr := range addrs {
+		client, err := api.NewClient(&api.Config{
+			Address:    addr,
+			HttpClient: &http.Client{Timeout: requestTimeout},
+		})
+		if err != nil {
+			return nil, err
+		}
+		watch, err := api.NewClient(&api.Config{
+			Address:    addr,
+			HttpClient: &http.Client{Timeout: requestTimeout + watchTimeout},
+			WaitTime:   watchTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.agents = append(s.agents, &consulAgent{client: client, watch: watch})
+	}
+	return s, nil
+}
+
+// do calls f with the agents in turn, until one succeeds.
+func (s *consul) do(f func(a *consulAgent) error) error {
+	var err error
+	for _, a := range s.agents {
+		if err = f(a); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// key returns key, relative to the prefix, as a key of consul, which has
+// no leading slash.
+func (s *consul) key(key string) string {
+	return strings.TrimPrefix(normalize(s.prefix, key), "/")
+}
+
+func (s *consul) pair(p *api.KVPair) *KVPair {
+	return &KVPair{Key: relative(s.prefix, p.Key), Value: p.Value, LastIndex: p.ModifyIndex}
+}
+
+func (s *consul) Get(key string) (*KVPair, error) {
+	var pair *api.KVPair
+	err := s.do(func(a *consulAgent) error {
+		var err error
+		pair, _, err = a.client.KV().Get(s.key(key), nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, ErrKeyNotFound
+	}
+	return s.pair(pair), nil
+}
+
+// session returns the ID of the session holding the keys written with
+// ttl, renewed, or a new one if it expired.
+func (s *consul) session(a *consulAgent, ttl time.Duration) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if id, exists := s.sessions[ttl]; exists {
+		if entry, _, err := a.client.Session().Renew(id, nil); err == nil && entry != nil {
+			return id, nil
+		}
+	}
+	id, _, err := a.client.Session().Create(&api.SessionEntry{
+		TTL:      ttl.String(),
+		Behavior: api.SessionBehaviorDelete,
+		// Let another daemon take the keys right away.
+		LockDelay: time.Millisecond,
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	s.sessions[ttl] = id
+	return id, nil
+}
+
+// put writes pair, with a check-and-set if cas is set, and has it held by
+// the session of the TTL of options, if any. It returns whether the key
+// was written.
+func (s *consul) put(pair *api.KVPair, cas bool, options *WriteOptions) (bool, error) {
+	var ok bool
+	err := s.do(func(a *consulAgent) error {
+		var err error
+		if cas {
+			ok, _, err = a.client.KV().CAS(pair, nil)
+		} else {
+			_, err = a.client.KV().Put(pair, nil)
+			ok = err == nil
+		}
+		if err != nil || !ok || options == nil || options.TTL == 0 {
+			return err
+		}
+		if pair.Session, err = s.session(a, options.TTL); err != nil {
+			return err
+		}
+		// Acquiring the key fails if another session holds it.
+		ok, _, err = a.client.KV().Acquire(pair, nil)
+		return err
+	})
+	return ok, err
+}
+
+func (s *consul) Put(key string, value []byte, options *WriteOptions) error {
+	ok, err := s.put(&api.KVPair{Key: s.key(key), Value: value}, false, options)
+	if err == nil && !ok {
+		err = ErrKeyModified
+	}
+	return err
+}
+
+func (s *consul) AtomicPut(key string, value []byte, previous *KVPair, options *WriteOptions) (*KVPair, error) {
+	// A check-and-set index of 0 creates the key only if it doesn't exist.
+	pair := &api.KVPair{Key: s.key(key), Value: value}
+	if previous != nil {
+		pair.ModifyIndex = previous.LastIndex
+	}
+	ok, err := s.put(pair, true, options)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrKeyModified
+	}
+	return s.Get(key)
+}
+
+func (s *consul) Delete(key string) error {
+	return s.do(func(a *consulAgent) error {
+		_, err := a.client.KV().Delete(s.key(key), nil)
+		return err
+	})
+}
+
+func (s *consul) DeleteTree(directory string) error {
+	// Without the trailing slash, the keys which only start with the name
+	// of the directory would go too.
+	return s.do(func(a *consulAgent) error {
+		_, err := a.client.KV().DeleteTree(s.key(directory)+"/", nil)
+		return err
+	})
+}
+
+// list returns the keys right under directory, waiting for the index of
+// the store to get past index first, if it isn't 0.
+func (s *consul) list(directory string, index uint64) ([]*KVPair, uint64, error) {
+	var (
+		pairs api.KVPairs
+		meta  *api.QueryMeta
+	)
+	err := s.do(func(a *consulAgent) error {
+		var err error
+		if index == 0 {
+			pairs, meta, err = a.client.KV().List(s.key(directory)+"/", nil)
+		} else {
+			pairs, meta, err = a.watch.KV().List(s.key(directory)+"/", &api.QueryOptions{WaitIndex: index})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	kvs := make([]*KVPair, 0, len(pairs))
+	for _, p := range pairs {
+		kvs = append(kvs, s.pair(p))
+	}
+	return children(directory, kvs), meta.LastIndex, nil
+}
+
+func (s *consul) List(directory string) ([]*KVPair, error) {
+	pairs, _, err := s.list(directory, 0)
+	return pairs, err
+}
+
+func (s *consul) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*KVPair, error) {
+	var index uint64
+	return watchLoop(func() ([]*KVPair, error) {
+		pairs, newIndex, err := s.list(directory, index)
+		if err == nil {
+			index = newIndex
+		}
+		return pairs, err
+	}, stopCh)
+}
+
+// Close destroys the sessions of the store, with the keys they hold.
+func (s *consul) Close() {
+	s.Lock()
+	defer s.Unlock()
+	for ttl, id := range s.sessions {
+		s.do(func(a *consulAgent) error {
+			_, err := a.client.Session().Destroy(id, nil)
+			return err
+		})
+		delete(s.sessions, ttl)
+	}
+}
+
+// children returns the pairs right under directory, consul returning every
+// key it prefixes.
+func children(directory string, pairs []*KVPair) []*KVPair {
+	dir := strings.Trim(directory, "/") + "/"
+	var kvs []*KVPair
+	for _, p := range pairs {
+		name := strings.TrimPrefix(p.Key, dir)
+		if name == "" || name == p.Key || strings.Contains(name, "/") {
+			continue
+		}
+		kvs = append(kvs, p)
+	}
+	return kvs
+}
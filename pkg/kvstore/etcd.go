@@ -0,0 +1,151 @@
+package kvstore
+
+import (
+	"time"
+
+	"github.com/coreos/etcd/Godeps/_workspace/src/golang.org/x/net/context"
+	"github.com/coreos/etcd/client"
+)
+
+// etcd is a store on the v2 keys API of etcd members.
+type etcd struct {
+	keys   client.KeysAPI
+	prefix string
+}
+
+func newEtcd(addrs []string, prefix string) (*etcd, error) {
+	endpoints := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		endpoints = append(endpoints, "http://"+addr)
+	}
+	c, err := client.New(client.Config{Endpoints: endpoints})
+	if err != nil {
+		return nil, err
+	}
+	return &etcd{keys: client.NewKeysAPI(c), prefix: prefix}, nil
+}
+
+// errorCode returns the code of err if etcd returned it, 0 otherwise.
+func errorCode(err error) int {
+	if e, ok := err.(client.Error); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func (s *etcd) pair(n *client.Node) *KVPair {
+	return &KVPair{Key: relative(s.prefix, n.Key), Value: []byte(n.Value), LastIndex: n.ModifiedIndex}
+}
+
+// get returns the node of key, within timeout.
+func (s *etcd) get(key string, timeout time.Duration) (*client.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.keys.Get(ctx, normalize(s.prefix, key), nil)
+}
+
+func (s *etcd) Get(key string) (*KVPair, error) {
+	r, err := s.get(key, requestTimeout)
+	if errorCode(err) == client.ErrorCodeKeyNotFound {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return s.pair(r.Node), nil
+}
+
+func (s *etcd) set(key string, value []byte, options *client.SetOptions) (*client.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	r, err := s.keys.Set(ctx, normalize(s.prefix, key), string(value), options)
+	switch errorCode(err) {
+	case client.ErrorCodeTestFailed, client.ErrorCodeNodeExist, client.ErrorCodeKeyNotFound:
+		return nil, ErrKeyModified
+	}
+	return r, err
+}
+
+func (s *etcd) Put(key string, value []byte, options *WriteOptions) error {
+	setOptions := &client.SetOptions{}
+	if options != nil {
+		setOptions.TTL = options.TTL
+	}
+	_, err := s.set(key, value, setOptions)
+	return err
+}
+
+func (s *etcd) AtomicPut(key string, value []byte, previous *KVPair, options *WriteOptions) (*KVPair, error) {
+	setOptions := &client.SetOptions{PrevExist: client.PrevNoExist}
+	if previous != nil {
+		setOptions = &client.SetOptions{PrevIndex: previous.LastIndex}
+	}
+	if options != nil {
+		setOptions.TTL = options.TTL
+	}
+	r, err := s.set(key, value, setOptions)
+	if err != nil {
+		return nil, err
+	}
+	return s.pair(r.Node), nil
+}
+
+func (s *etcd) delete(key string, options *client.DeleteOptions) error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	_, err := s.keys.Delete(ctx, normalize(s.prefix, key), options)
+	if errorCode(err) == client.ErrorCodeKeyNotFound {
+		return nil
+	}
+	return err
+}
+
+func (s *etcd) Delete(key string) error {
+	return s.delete(key, nil)
+}
+
+func (s *etcd) DeleteTree(directory string) error {
+	return s.delete(directory, &client.DeleteOptions{Recursive: true})
+}
+
+// list returns the keys right under directory, with the index of the
+// store.
+func (s *etcd) list(directory string) ([]*KVPair, uint64, error) {
+	r, err := s.get(directory, requestTimeout)
+	if e, ok := err.(client.Error); ok && e.Code == client.ErrorCodeKeyNotFound {
+		return nil, e.Index, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	var pairs []*KVPair
+	for _, n := range r.Node.Nodes {
+		if !n.Dir {
+			pairs = append(pairs, s.pair(n))
+		}
+	}
+	return pairs, r.Index, nil
+}
+
+func (s *etcd) List(directory string) ([]*KVPair, error) {
+	pairs, _, err := s.list(directory)
+	return pairs, err
+}
+
+func (s *etcd) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*KVPair, error) {
+	var index uint64
+	return watchLoop(func() ([]*KVPair, error) {
+		if index != 0 {
+			// Whether it's an event, a timeout or an index gone out of
+			// the history of etcd, the keys are listed again.
+			ctx, cancel := context.WithTimeout(context.Background(), watchTimeout)
+			s.keys.Watcher(normalize(s.prefix, directory), &client.WatcherOptions{AfterIndex: index, Recursive: true}).Next(ctx)
+			cancel()
+		}
+		pairs, newIndex, err := s.list(directory)
+		if err == nil {
+			index = newIndex
+		}
+		return pairs, err
+	}, stopCh)
+}
+
+func (s *etcd) Close() {}
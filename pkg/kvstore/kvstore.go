@@ -0,0 +1,188 @@
+// Package kvstore is a client of the key-value stores the daemons of a
+// cluster share their state in: consul, etcd and zookeeper.
+//
+// A store is given by a URL, e.g. consul://10.0.0.1:8500/docker, whose path
+// prefixes every key. Keys are paths, made of directories separated by
+// slashes: List and WatchTree return the keys right under a directory.
+package kvstore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPrefix is the directory of the keys of a store whose URL has
+	// no path.
+	DefaultPrefix = "docker"
+
+	requestTimeout = 10 * time.Second
+	watchTimeout   = 5 * time.Minute
+)
+
+var (
+	// ErrKeyNotFound is returned by Get for a key that doesn't exist.
+	ErrKeyNotFound = errors.New("Key not found in store")
+
+	// ErrKeyModified is returned by AtomicPut when the key was modified,
+	// or created, since it was read.
+	ErrKeyModified = errors.New("Unable to complete atomic operation, key modified")
+)
+
+// KVPair is a key of a store, with its value.
+type KVPair struct {
+	Key   string
+	Value []byte
+
+	// LastIndex is the version of the value, which AtomicPut compares.
+	LastIndex uint64
+}
+
+// WriteOptions are the options of the writes of a key.
+type WriteOptions struct {
+	// TTL, if not 0, makes the key expire unless it's written again
+	// within about that long, so that the keys of a daemon which went
+	// away don't outlive it. Zookeeper removes the key with the session
+	// of the store instead.
+	TTL time.Duration
+}
+
+// Store is a key-value store. Keys are relative to the path of its URL.
+type Store interface {
+	// Get returns the value of key, ErrKeyNotFound if it doesn't exist.
+	Get(key string) (*KVPair, error)
+
+	// Put sets the value of key, creating it if it doesn't exist.
+	// options may be nil.
+	Put(key string, value []byte, options *WriteOptions) error
+
+	// AtomicPut sets the value of key if it wasn't modified since
+	// previous was read. A nil previous makes it create key, which must
+	// not exist. It returns ErrKeyModified otherwise. options may be nil.
+	AtomicPut(key string, value []byte, previous *KVPair, options *WriteOptions) (*KVPair, error)
+
+	// Delete removes key. Removing a key that doesn't exist isn't an
+	// error.
+	Delete(key string) error
+
+	// DeleteTree removes the directory and every key under it.
+	DeleteTree(directory string) error
+
+	// List returns the keys right under directory, none if it doesn't
+	// exist.
+	List(directory string) ([]*KVPair, error)
+
+	// WatchTree sends the keys right under directory to the returned
+	// channel, first right away, then every time they change, until
+	// stopCh is closed.
+	WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*KVPair, error)
+
+	// Close releases the connections to the store.
+	Close()
+}
+
+// New returns the store of url, scheme://host:port[,host:port...][/path].
+func New(rawurl string) (Store, error) {
+	scheme, addrs, prefix, err := parseURL(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch scheme {
+	case "consul":
+		return newConsul(addrs, prefix)
+	case "etcd":
+		return newEtcd(addrs, prefix)
+	case "zk":
+		return newZookeeper(addrs, prefix)
+	}
+	return nil, fmt.Errorf("Unsupported key-value store %q, use consul://, etcd:// or zk://", scheme)
+}
+
+func parseURL(rawurl string) (string, []string, string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", nil, "", fmt.Errorf("Invalid key-value store URL %q, expected scheme://host:port[/path]", rawurl)
+	}
+	prefix := strings.Trim(u.Path, "/")
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return u.Scheme, strings.Split(u.Host, ","), prefix, nil
+}
+
+// normalize returns key, a path relative to prefix, as an absolute key
+// without trailing slash.
+func normalize(prefix, key string) string {
+	return path.Join("/", prefix, key)
+}
+
+// relative returns key, an absolute key, relative to prefix.
+func relative(prefix, key string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(key, "/"), strings.Trim(prefix, "/")+"/")
+}
+
+// watchLoop calls list until stopCh is closed, sending what it returns to
+// the returned channel every time it changes. But for the first call, list
+// blocks until the keys may have changed, or a timeout.
+func watchLoop(list func() ([]*KVPair, error), stopCh <-chan struct{}) (<-chan []*KVPair, error) {
+	pairs, err := list()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan []*KVPair, 1)
+	ch <- pairs
+	go func() {
+		defer close(ch)
+		last := pairs
+		for {
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+			pairs, err := list()
+			if err != nil {
+				// The store may be unreachable for a while.
+				select {
+				case <-stopCh:
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			if equal(pairs, last) {
+				continue
+			}
+			last = pairs
+			select {
+			case ch <- pairs:
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func equal(a, b []*KVPair) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := make(map[string]string, len(a))
+	for _, p := range a {
+		values[p.Key] = string(p.Value)
+	}
+	for _, p := range b {
+		if v, exists := values[p.Key]; !exists || v != string(p.Value) {
+			return false
+		}
+	}
+	return true
+}
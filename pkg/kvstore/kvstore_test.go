@@ -0,0 +1,219 @@
+package kvstore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+func TestParseURL(t *testing.T) {
+	scheme, addrs, prefix, err := parseURL("consul://10.0.0.1:8500,10.0.0.2:8500/cluster/docker/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme != "consul" || len(addrs) != 2 || addrs[1] != "10.0.0.2:8500" || prefix != "cluster/docker" {
+		t.Fatalf("Unexpected store %s %v %s", scheme, addrs, prefix)
+	}
+	if _, _, prefix, _ = parseURL("etcd://127.0.0.1:4001"); prefix != DefaultPrefix {
+		t.Fatalf("Expected the keys under %s by default, got %s", DefaultPrefix, prefix)
+	}
+
+	for _, url := range []string{"127.0.0.1:8500", "consul:///docker"} {
+		if _, _, _, err := parseURL(url); err == nil {
+			t.Fatalf("Expected an error parsing %s", url)
+		}
+	}
+	if _, err := New("redis://127.0.0.1:6379"); err == nil {
+		t.Fatal("Expected an error for an unsupported store")
+	}
+}
+
+// fakeConsul serves the parts of the KV and session APIs of consul the
+// store uses.
+type fakeConsul struct {
+	sync.Mutex
+	index    uint64
+	values   map[string]*api.KVPair
+	sessions map[string]bool
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.Lock()
+	defer c.Unlock()
+	if strings.HasPrefix(r.URL.Path, "/v1/session/") {
+		c.serveSession(w, r)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	query := r.URL.Query()
+	w.Header().Set("X-Consul-LastContact", "0")
+	switch r.Method {
+	case "GET":
+		var pairs []*api.KVPair
+		for k, p := range c.values {
+			if k == key || query["recurse"] != nil && strings.HasPrefix(k, key) {
+				pairs = append(pairs, p)
+			}
+		}
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(c.index, 10))
+		if len(pairs) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(pairs)
+	case "PUT":
+		if cas := query.Get("cas"); cas != "" {
+			var index uint64
+			if p, exists := c.values[key]; exists {
+				index = p.ModifyIndex
+			}
+			if cas != strconv.FormatUint(index, 10) {
+				w.Write([]byte("false"))
+				return
+			}
+		}
+		session := query.Get("acquire")
+		if session != "" && !c.sessions[session] {
+			w.Write([]byte("false"))
+			return
+		}
+		value, _ := ioutil.ReadAll(r.Body)
+		c.index++
+		c.values[key] = &api.KVPair{Key: key, Value: value, ModifyIndex: c.index, Session: session}
+		w.Write([]byte("true"))
+	case "DELETE":
+		for k := range c.values {
+			if k == key || query["recurse"] != nil && strings.HasPrefix(k, key) {
+				delete(c.values, k)
+			}
+		}
+		c.index++
+	}
+}
+
+func (c *fakeConsul) serveSession(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/session/create":
+		id := strconv.Itoa(len(c.sessions) + 1)
+		c.sessions[id] = true
+		json.NewEncoder(w).Encode(map[string]string{"ID": id})
+	case strings.HasPrefix(r.URL.Path, "/v1/session/renew/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/session/renew/")
+		if !c.sessions[id] {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode([]*api.SessionEntry{{ID: id}})
+	case strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+		id := strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/")
+		delete(c.sessions, id)
+		for k, p := range c.values {
+			if p.Session == id {
+				delete(c.values, k)
+			}
+		}
+		c.index++
+	}
+}
+
+func TestConsul(t *testing.T) {
+	server := httptest.NewServer(&fakeConsul{values: make(map[string]*api.KVPair), sessions: make(map[string]bool)})
+	defer server.Close()
+	s, err := New("consul://" + strings.TrimPrefix(server.URL, "http://") + "/test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get("network/foo"); err != ErrKeyNotFound {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+	foo, err := s.AtomicPut("network/foo", []byte("1"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foo.Key != "network/foo" || string(foo.Value) != "1" {
+		t.Fatalf("Unexpected pair %+v", foo)
+	}
+	if _, err := s.AtomicPut("network/foo", []byte("2"), nil, nil); err != ErrKeyModified {
+		t.Fatalf("Expected ErrKeyModified creating an existing key, got %v", err)
+	}
+	if foo, err = s.AtomicPut("network/foo", []byte("2"), foo, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("network/bar", []byte("3"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("network/bar/baz", []byte("4"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	ch, err := s.WatchTree("network", stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pairs := <-ch; len(pairs) != 2 {
+		t.Fatalf("Expected the 2 keys right under network, got %v", pairs)
+	}
+
+	if err := s.Delete("network/foo"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case pairs := <-ch:
+		if len(pairs) != 1 || pairs[0].Key != "network/bar" {
+			t.Fatalf("Expected network/bar left, got %v", pairs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the watch to send the keys left")
+	}
+
+	if err := s.DeleteTree("network"); err != nil {
+		t.Fatal(err)
+	}
+	if pairs, err := s.List("network"); err != nil || len(pairs) != 0 {
+		t.Fatalf("Expected no key left, got %v %v", pairs, err)
+	}
+}
+
+func TestConsulTTL(t *testing.T) {
+	fake := &fakeConsul{values: make(map[string]*api.KVPair), sessions: make(map[string]bool)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	s, err := New("consul://" + strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := &WriteOptions{TTL: 30 * time.Second}
+	if _, err := s.AtomicPut("endpoint/foo", []byte("1"), nil, options); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("endpoint/bar", []byte("2"), options); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("network/baz", []byte("3"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.sessions) != 1 {
+		t.Fatalf("Expected the keys with a TTL held by a single session, got %d", len(fake.sessions))
+	}
+
+	// The keys held by the session go with it.
+	s.Close()
+	if _, err := s.Get("endpoint/foo"); err != ErrKeyNotFound {
+		t.Fatalf("Expected endpoint/foo gone with the session, got %v", err)
+	}
+	if _, err := s.Get("network/baz"); err != nil {
+		t.Fatalf("Expected network/baz to stay, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package kvstore
+
+import (
+	"path"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+const zkSessionTimeout = 10 * time.Second
+
+// zookeeper is a store on a zookeeper ensemble, a key being a znode.
+type zookeeper struct {
+	conn   *zk.Conn
+	prefix string
+}
+
+type zkLogger struct{}
+
+func (zkLogger) Printf(format string, args ...interface{}) {
+	logrus.Debugf("zookeeper: "+format, args...)
+}
+
+func newZookeeper(addrs []string, prefix string) (*zookeeper, error) {
+	conn, _, err := zk.Connect(addrs, zkSessionTimeout, zk.WithLogger(zkLogger{}))
+	if err != nil {
+		return nil, err
+	}
+	return &zookeeper{conn: conn, prefix: prefix}, nil
+}
+
+func (s *zookeeper) Get(key string) (*KVPair, error) {
+	value, stat, err := s.conn.Get(normalize(s.prefix, key))
+	if err == zk.ErrNoNode {
+		return nil, ErrKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &KVPair{Key: key, Value: value, LastIndex: uint64(stat.Version)}, nil
+}
+
+// create creates the znode of key, and the missing ones of its
+// directories. The znode of a key with a TTL is ephemeral, removed with
+// the session.
+func (s *zookeeper) create(key string, value []byte, options *WriteOptions) error {
+	var flags int32
+	if options != nil && options.TTL != 0 {
+		flags = zk.FlagEphemeral
+	}
+	p := normalize(s.prefix, key)
+	_, err := s.conn.Create(p, value, flags, zk.WorldACL(zk.PermAll))
+	if err != zk.ErrNoNode {
+		return err
+	}
+	if err := s.createDirectory(path.Dir(p)); err != nil {
+		return err
+	}
+	_, err = s.conn.Create(p, value, flags, zk.WorldACL(zk.PermAll))
+	return err
+}
+
+func (s *zookeeper) createDirectory(p string) error {
+	if p == "/" {
+		return nil
+	}
+	_, err := s.conn.Create(p, nil, 0, zk.WorldACL(zk.PermAll))
+	if err == zk.ErrNoNode {
+		if err = s.createDirectory(path.Dir(p)); err == nil {
+			_, err = s.conn.Create(p, nil, 0, zk.WorldACL(zk.PermAll))
+		}
+	}
+	if err == zk.ErrNodeExists {
+		return nil
+	}
+	return err
+}
+
+func (s *zookeeper) Put(key string, value []byte, options *WriteOptions) error {
+	err := s.create(key, value, options)
+	if err == zk.ErrNodeExists {
+		_, err = s.conn.Set(normalize(s.prefix, key), value, -1)
+	}
+	return err
+}
+
+func (s *zookeeper) AtomicPut(key string, value []byte, previous *KVPair, options *WriteOptions) (*KVPair, error) {
+	if previous == nil {
+		err := s.create(key, value, options)
+		if err == zk.ErrNodeExists {
+			return nil, ErrKeyModified
+		} else if err != nil {
+			return nil, err
+		}
+		return &KVPair{Key: key, Value: value}, nil
+	}
+
+	stat, err := s.conn.Set(normalize(s.prefix, key), value, int32(previous.LastIndex))
+	if err == zk.ErrBadVersion || err == zk.ErrNoNode {
+		return nil, ErrKeyModified
+	} else if err != nil {
+		return nil, err
+	}
+	return &KVPair{Key: key, Value: value, LastIndex: uint64(stat.Version)}, nil
+}
+
+func (s *zookeeper) Delete(key string) error {
+	err := s.conn.Delete(normalize(s.prefix, key), -1)
+	if err == zk.ErrNoNode {
+		return nil
+	}
+	return err
+}
+
+func (s *zookeeper) DeleteTree(directory string) error {
+	names, _, err := s.conn.Children(normalize(s.prefix, directory))
+	if err == zk.ErrNoNode {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := s.DeleteTree(path.Join(directory, name)); err != nil {
+			return err
+		}
+	}
+	return s.Delete(directory)
+}
+
+// values returns the pairs of the znodes named names under directory, but
+// those which were removed in the meantime.
+func (s *zookeeper) values(directory string, names []string) ([]*KVPair, error) {
+	var pairs []*KVPair
+	for _, name := range names {
+		pair, err := s.Get(path.Join(directory, name))
+		if err == ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+func (s *zookeeper) List(directory string) ([]*KVPair, error) {
+	names, _, err := s.conn.Children(normalize(s.prefix, directory))
+	if err == zk.ErrNoNode {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return s.values(directory, names)
+}
+
+func (s *zookeeper) WatchTree(directory string, stopCh <-chan struct{}) (<-chan []*KVPair, error) {
+	var events <-chan zk.Event
+	return watchLoop(func() ([]*KVPair, error) {
+		if events != nil {
+			select {
+			case <-events:
+			case <-stopCh:
+			case <-time.After(watchTimeout):
+			}
+		}
+		p := normalize(s.prefix, directory)
+		names, _, ch, err := s.conn.ChildrenW(p)
+		if err == zk.ErrNoNode {
+			// Wait for the directory to be created instead.
+			var exists bool
+			if exists, _, ch, err = s.conn.ExistsW(p); err == nil && exists {
+				names, _, ch, err = s.conn.ChildrenW(p)
+			}
+		}
+		if err != nil {
+			events = nil
+			return nil, err
+		}
+		events = ch
+		return s.values(directory, names)
+	}, stopCh)
+}
+
+func (s *zookeeper) Close() {
+	s.conn.Close()
+}
@@ -17,16 +17,33 @@ func ParseHost(defaultTCPAddr, defaultUnixAddr, addr string) (string, error) {
 		addrParts = []string{"tcp", addrParts[0]}
 	}
 
+	// Options for the listener, e.g. "?tlsverify=1", are kept as is.
+	var (
+		options string
+		err     error
+	)
+	if addrParts[0] != "fd" && addrParts[0] != "ssh" {
+		if i := strings.Index(addrParts[1], "?"); i >= 0 {
+			addrParts[1], options = addrParts[1][:i], addrParts[1][i:]
+		}
+	}
+
 	switch addrParts[0] {
 	case "tcp":
-		return ParseTCPAddr(addrParts[1], defaultTCPAddr)
+		addr, err = ParseTCPAddr(addrParts[1], defaultTCPAddr)
 	case "unix":
-		return ParseUnixAddr(addrParts[1], defaultUnixAddr)
+		addr, err = ParseUnixAddr(addrParts[1], defaultUnixAddr)
 	case "fd":
 		return addr, nil
+	case "ssh":
+		return ParseSSHAddr(addr)
 	default:
 		return "", fmt.Errorf("Invalid bind address format: %s", addr)
 	}
+	if err != nil {
+		return "", err
+	}
+	return addr + options, nil
 }
 
 func ParseUnixAddr(addr string, defaultAddr string) (string, error) {
@@ -40,6 +57,30 @@ func ParseUnixAddr(addr string, defaultAddr string) (string, error) {
 	return fmt.Sprintf("unix://%s", addr), nil
 }
 
+// ParseSSHAddr checks an address of the form [user@]host[:port][/path], the
+// path being the daemon socket on host.
+func ParseSSHAddr(addr string) (string, error) {
+	addr = strings.TrimPrefix(addr, "ssh://")
+	target := addr
+	if i := strings.Index(target, "/"); i >= 0 {
+		target = target[:i]
+	}
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		target = target[i+1:]
+	}
+	host := target
+	if i := strings.LastIndex(target, ":"); i >= 0 {
+		host = target[:i]
+		if _, err := strconv.ParseUint(target[i+1:], 10, 16); err != nil {
+			return "", fmt.Errorf("Invalid ssh address, bad port: %s", addr)
+		}
+	}
+	if host == "" || strings.HasPrefix(host, "-") || strings.Contains(addr, "://") {
+		return "", fmt.Errorf("Invalid ssh address, expected ssh://[user@]host[:port][/path]: %s", addr)
+	}
+	return "ssh://" + addr, nil
+}
+
 func ParseTCPAddr(addr string, defaultAddr string) (string, error) {
 	addr = strings.TrimPrefix(addr, "tcp://")
 	if strings.Contains(addr, "://") || addr == "" {
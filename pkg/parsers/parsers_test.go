@@ -25,6 +25,12 @@ func TestParseHost(t *testing.T) {
 	if addr, err := ParseHost(defaultHttpHost, defaultUnix, "tcp://:7777"); err != nil || addr != "tcp://127.0.0.1:7777" {
 		t.Errorf("tcp://:7777 -> expected tcp://127.0.0.1:7777, got %s", addr)
 	}
+	if addr, err := ParseHost(defaultHttpHost, defaultUnix, "tcp://:2376?tlsverify=1"); err != nil || addr != "tcp://127.0.0.1:2376?tlsverify=1" {
+		t.Errorf("tcp://:2376?tlsverify=1 -> expected tcp://127.0.0.1:2376?tlsverify=1, got %s", addr)
+	}
+	if addr, err := ParseHost(defaultHttpHost, defaultUnix, "unix://?group=monitoring"); err != nil || addr != "unix:///var/run/docker.sock?group=monitoring" {
+		t.Errorf("unix://?group=monitoring -> expected unix:///var/run/docker.sock?group=monitoring, got %s", addr)
+	}
 	if addr, err := ParseHost(defaultHttpHost, defaultUnix, ""); err != nil || addr != "unix:///var/run/docker.sock" {
 		t.Errorf("empty argument -> expected unix:///var/run/docker.sock, got %s", addr)
 	}
@@ -42,6 +48,38 @@ func TestParseHost(t *testing.T) {
 	}
 }
 
+func TestParseSSHHost(t *testing.T) {
+	var (
+		defaultHttpHost = "127.0.0.1"
+		defaultUnix     = "/var/run/docker.sock"
+	)
+	valid := []string{
+		"ssh://example.com",
+		"ssh://me@example.com",
+		"ssh://me@example.com:2222",
+		"ssh://example.com/var/run/docker.sock",
+		"ssh://me@example.com:2222/var/run/docker-ro.sock",
+	}
+	for _, host := range valid {
+		if addr, err := ParseHost(defaultHttpHost, defaultUnix, host); err != nil || addr != host {
+			t.Errorf("%s -> expected %s, got %s (%v)", host, host, addr, err)
+		}
+	}
+	invalid := []string{
+		"ssh://",
+		"ssh://me@",
+		"ssh://:22",
+		"ssh://example.com:port",
+		"ssh://-oProxyCommand=evil",
+		"ssh://tcp://example.com",
+	}
+	for _, host := range invalid {
+		if addr, err := ParseHost(defaultHttpHost, defaultUnix, host); err == nil {
+			t.Errorf("%s -> expected error return, but err == nil. Got %s", host, addr)
+		}
+	}
+}
+
 func TestParseRepositoryTag(t *testing.T) {
 	if repo, tag := ParseRepositoryTag("root"); repo != "root" || tag != "" {
 		t.Errorf("Expected repo: '%s' and tag: '%s', got '%s' and '%s'", "root", "", repo, tag)
@@ -0,0 +1,76 @@
+// Package plugins is the client of the plugins extending the daemon.
+//
+// A plugin is an HTTP server listening on a unix socket. The daemon POSTs a
+// JSON encoded request to one of its endpoints and expects a JSON encoded
+// response back.
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPluginDir is where plugins referred to by name are expected to
+	// have their socket, e.g. "corp" is /run/docker/plugins/corp.sock.
+	DefaultPluginDir = "/run/docker/plugins"
+
+	// timeout bounds a call to a plugin, from dialing its socket to reading
+	// its response.
+	timeout = 30 * time.Second
+)
+
+// Socket returns the socket of the plugin given by name, which is either an
+// absolute path or the name of a socket in DefaultPluginDir.
+func Socket(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(DefaultPluginDir, name+".sock")
+}
+
+// Client calls the endpoints of a plugin.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client of the plugin listening at socket.
+func NewClient(socket string) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Dial: func(_, _ string) (net.Conn, error) {
+					return net.DialTimeout("unix", socket, timeout)
+				},
+				DisableCompression: true,
+			},
+		},
+	}
+}
+
+// Call POSTs args to the endpoint path of the plugin, and decodes its
+// response into ret.
+func (c *Client) Call(path string, args, ret interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post("http://plugin"+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("plugin returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(ret)
+}
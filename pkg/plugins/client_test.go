@@ -0,0 +1,54 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSocket(t *testing.T) {
+	if socket := Socket("corp"); socket != "/run/docker/plugins/corp.sock" {
+		t.Fatalf("Expected corp in the default plugin directory, got %s", socket)
+	}
+	if socket := Socket("/var/run/corp.sock"); socket != "/var/run/corp.sock" {
+		t.Fatalf("Expected an absolute path to be kept, got %s", socket)
+	}
+}
+
+func TestCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "echo.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/Echo", func(w http.ResponseWriter, r *http.Request) {
+		var args map[string]string
+		json.NewDecoder(r.Body).Decode(&args)
+		json.NewEncoder(w).Encode(args)
+	})
+	go http.Serve(l, mux)
+
+	c := NewClient(socket)
+	var ret map[string]string
+	if err := c.Call("/Echo", map[string]string{"Name": "corp"}, &ret); err != nil {
+		t.Fatal(err)
+	}
+	if ret["Name"] != "corp" {
+		t.Fatalf("Expected the arguments back, got %v", ret)
+	}
+	if err := c.Call("/Missing", nil, &ret); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("Expected the status of an unknown endpoint, got %v", err)
+	}
+}
@@ -31,6 +31,7 @@ var (
 	nsIPv6Regexp      = regexp.MustCompile(`(?m)^nameserver\s+` + ipv6Address + `\s*\n*`)
 	nsRegexp          = regexp.MustCompile(`^\s*nameserver\s*((` + ipv4Address + `)|(` + ipv6Address + `))\s*$`)
 	searchRegexp      = regexp.MustCompile(`^\s*search\s*(([^\s]+\s*)*)$`)
+	optionsRegexp     = regexp.MustCompile(`^\s*options\s*(([^\s]+\s*)*)$`)
 )
 
 var lastModified struct {
@@ -173,10 +174,22 @@ func GetSearchDomains(resolvConf []byte) []string {
 	return domains
 }
 
+// GetOptions returns the resolver options (if any) listed in
+// /etc/resolv.conf, on all its "options" lines.
+func GetOptions(resolvConf []byte) []string {
+	options := []string{}
+	for _, line := range getLines(resolvConf, []byte("#")) {
+		if match := optionsRegexp.FindSubmatch(line); match != nil {
+			options = append(options, strings.Fields(string(match[1]))...)
+		}
+	}
+	return options
+}
+
 // Build writes a configuration file to path containing a "nameserver" entry
-// for every element in dns, and a "search" entry for every element in
-// dnsSearch.
-func Build(path string, dns, dnsSearch []string) error {
+// for every element in dns, a "search" entry for every element in
+// dnsSearch, and an "options" entry for every element in dnsOptions.
+func Build(path string, dns, dnsSearch, dnsOptions []string) error {
 	content := bytes.NewBuffer(nil)
 	for _, dns := range dns {
 		if _, err := content.WriteString("nameserver " + dns + "\n"); err != nil {
@@ -190,6 +203,11 @@ func Build(path string, dns, dnsSearch []string) error {
 			}
 		}
 	}
+	if len(dnsOptions) > 0 {
+		if _, err := content.WriteString("options " + strings.Join(dnsOptions, " ") + "\n"); err != nil {
+			return err
+		}
+	}
 
 	return ioutil.WriteFile(path, content.Bytes(), 0644)
 }
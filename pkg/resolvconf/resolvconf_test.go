@@ -96,6 +96,24 @@ nameserver 4.30.20.100`: {"foo.example.com", "example.com"},
 	}
 }
 
+func TestGetOptions(t *testing.T) {
+	for resolv, result := range map[string][]string{
+		`options ndots:5`:                      {"ndots:5"},
+		`  options  ndots:5  rotate # ignored`: {"ndots:5", "rotate"},
+		``:                                     {},
+		`# options`:                            {},
+		`nameserver 1.2.3.4
+options timeout:2
+search example.com
+options attempts:3`: {"timeout:2", "attempts:3"},
+	} {
+		test := GetOptions([]byte(resolv))
+		if !strSlicesEqual(test, result) {
+			t.Fatalf("Wrong options {%s} should be %v. Input: %s", test, result, resolv)
+		}
+	}
+}
+
 func strSlicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -117,7 +135,7 @@ func TestBuild(t *testing.T) {
 	}
 	defer os.Remove(file.Name())
 
-	err = Build(file.Name(), []string{"ns1", "ns2", "ns3"}, []string{"search1"})
+	err = Build(file.Name(), []string{"ns1", "ns2", "ns3"}, []string{"search1"}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -139,7 +157,7 @@ func TestBuildWithZeroLengthDomainSearch(t *testing.T) {
 	}
 	defer os.Remove(file.Name())
 
-	err = Build(file.Name(), []string{"ns1", "ns2", "ns3"}, []string{"."})
+	err = Build(file.Name(), []string{"ns1", "ns2", "ns3"}, []string{"."}, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -157,6 +175,27 @@ func TestBuildWithZeroLengthDomainSearch(t *testing.T) {
 	}
 }
 
+func TestBuildWithOptions(t *testing.T) {
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+
+	if err := Build(file.Name(), []string{"ns1"}, nil, []string{"ndots:5", "rotate"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "nameserver ns1\noptions ndots:5 rotate\n"; string(content) != expected {
+		t.Fatalf("Expected '%s' got '%s'", expected, content)
+	}
+}
+
 func TestFilterResolvDns(t *testing.T) {
 	ns0 := "nameserver 10.16.60.14\nnameserver 10.16.60.21\n"
 
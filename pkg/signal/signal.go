@@ -1,8 +1,12 @@
 package signal
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 )
 
 func CatchAll(sigc chan os.Signal) {
@@ -17,3 +21,19 @@ func StopCatch(sigc chan os.Signal) {
 	signal.Stop(sigc)
 	close(sigc)
 }
+
+// ParseSignal returns the signal named by rawSignal, either by number or by
+// name, with or without the "SIG" prefix, e.g. "15", "TERM" or "SIGTERM".
+func ParseSignal(rawSignal string) (syscall.Signal, error) {
+	if n, err := strconv.ParseUint(rawSignal, 10, 8); err == nil {
+		if n == 0 {
+			return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+		}
+		return syscall.Signal(n), nil
+	}
+	sig, ok := SignalMap[strings.TrimPrefix(strings.ToUpper(rawSignal), "SIG")]
+	if !ok {
+		return -1, fmt.Errorf("Invalid signal: %s", rawSignal)
+	}
+	return sig, nil
+}
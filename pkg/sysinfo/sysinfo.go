@@ -13,6 +13,11 @@ import (
 type SysInfo struct {
 	MemoryLimit            bool
 	SwapLimit              bool
+	OomKillDisable         bool
+	CpuCfsPeriod           bool
+	CpuCfsQuota            bool
+	BlkioThrottle          bool
+	PidsLimit              bool
 	IPv4ForwardingDisabled bool
 	AppArmor               bool
 }
@@ -37,6 +42,50 @@ func New(quiet bool) *SysInfo {
 		if !sysInfo.SwapLimit && !quiet {
 			logrus.Warnf("Your kernel does not support cgroup swap limit.")
 		}
+
+		_, err = ioutil.ReadFile(path.Join(cgroupMemoryMountpoint, "memory.oom_control"))
+		sysInfo.OomKillDisable = err == nil
+		if !sysInfo.OomKillDisable && !quiet {
+			logrus.Warnf("Your kernel does not support oom control.")
+		}
+	}
+
+	if cgroupCpuMountpoint, err := cgroups.FindCgroupMountpoint("cpu"); err != nil {
+		if !quiet {
+			logrus.Warnf("%s", err)
+		}
+	} else {
+		_, err = ioutil.ReadFile(path.Join(cgroupCpuMountpoint, "cpu.cfs_period_us"))
+		sysInfo.CpuCfsPeriod = err == nil
+		if !sysInfo.CpuCfsPeriod && !quiet {
+			logrus.Warnf("Your kernel does not support cgroup cfs period.")
+		}
+
+		_, err = ioutil.ReadFile(path.Join(cgroupCpuMountpoint, "cpu.cfs_quota_us"))
+		sysInfo.CpuCfsQuota = err == nil
+		if !sysInfo.CpuCfsQuota && !quiet {
+			logrus.Warnf("Your kernel does not support cgroup cfs quota.")
+		}
+	}
+
+	if cgroupBlkioMountpoint, err := cgroups.FindCgroupMountpoint("blkio"); err != nil {
+		if !quiet {
+			logrus.Warnf("%s", err)
+		}
+	} else {
+		_, err = ioutil.ReadFile(path.Join(cgroupBlkioMountpoint, "blkio.throttle.read_bps_device"))
+		sysInfo.BlkioThrottle = err == nil
+		if !sysInfo.BlkioThrottle && !quiet {
+			logrus.Warnf("Your kernel does not support cgroup blkio throttling.")
+		}
+	}
+
+	if _, err := cgroups.FindCgroupMountpoint("pids"); err != nil {
+		if !quiet {
+			logrus.Warnf("Your kernel does not support cgroup pids limit.")
+		}
+	} else {
+		sysInfo.PidsLimit = true
 	}
 
 	// Check if AppArmor is supported.
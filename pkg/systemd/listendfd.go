@@ -2,39 +2,61 @@ package systemd
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/coreos/go-systemd/activation"
 )
 
+var (
+	activated     []net.Listener
+	activatedErr  error
+	activatedOnce sync.Once
+)
+
 // ListenFD returns the specified socket activated files as a slice of
-// net.Listeners or all of the activated files if "*" is given.
+// net.Listeners or all of the activated files if "*" is given. A file is
+// specified either by its number, starting at 3, or by the name systemd
+// passed it with, i.e. the FileDescriptorName of its socket unit.
 func ListenFD(addr string) ([]net.Listener, error) {
-	// socket activation
-	listeners, err := activation.Listeners(false)
-	if err != nil {
-		return nil, err
+	// socket activation; the files are only wrapped once so that several
+	// fd:// addresses can pick from the same set
+	activatedOnce.Do(func() {
+		activated, activatedErr = activation.Listeners(false)
+	})
+	if activatedErr != nil {
+		return nil, activatedErr
 	}
 
-	if listeners == nil || len(listeners) == 0 {
+	if len(activated) == 0 {
 		return nil, errors.New("No sockets found")
 	}
 
 	// default to all fds just like unix:// and tcp://
-	if addr == "" {
-		addr = "*"
+	if addr == "" || addr == "*" {
+		return activated, nil
 	}
 
-	fdNum, _ := strconv.Atoi(addr)
-	fdOffset := fdNum - 3
-	if (addr != "*") && (len(listeners) < int(fdOffset)+1) {
-		return nil, errors.New("Too few socket activated files passed in")
+	if fdNum, err := strconv.Atoi(addr); err == nil {
+		fdOffset := fdNum - 3
+		if fdOffset < 0 || len(activated) < fdOffset+1 {
+			return nil, errors.New("Too few socket activated files passed in")
+		}
+		return []net.Listener{activated[fdOffset]}, nil
 	}
 
-	if addr == "*" {
-		return listeners, nil
+	var listeners []net.Listener
+	for i, name := range strings.Split(os.Getenv("LISTEN_FDNAMES"), ":") {
+		if name == addr && i < len(activated) {
+			listeners = append(listeners, activated[i])
+		}
 	}
-
-	return []net.Listener{listeners[fdOffset]}, nil
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("No socket activated file named %s", addr)
+	}
+	return listeners, nil
 }
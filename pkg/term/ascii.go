@@ -0,0 +1,44 @@
+package term
+
+import (
+	"fmt"
+	"strings"
+)
+
+// printable is the printable ASCII characters a key can be given as, but the
+// comma separating the keys.
+const printable = " !\"#$%&'()*+-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// ToBytes converts a sequence of keys separated by commas, such as
+// "ctrl-p,ctrl-q", to the bytes a terminal sends for them. A key is either a
+// printable ASCII character but the comma, or ctrl- followed by a letter or
+// one of @[\]^_.
+func ToBytes(keys string) ([]byte, error) {
+	var codes []byte
+	if keys == "" {
+		return codes, nil
+	}
+	for _, key := range strings.Split(keys, ",") {
+		switch {
+		case len(key) == 1 && strings.Contains(printable, key):
+			codes = append(codes, key[0])
+		case strings.HasPrefix(strings.ToLower(key), "ctrl-") && len(key) == 6:
+			c := key[5]
+			switch {
+			case c >= 'a' && c <= 'z':
+				codes = append(codes, c-'a'+1)
+			case c >= 'A' && c <= 'Z':
+				codes = append(codes, c-'A'+1)
+			case c == '@':
+				codes = append(codes, 0)
+			case c >= '[' && c <= '_':
+				codes = append(codes, c-'['+27)
+			default:
+				return nil, fmt.Errorf("Invalid key %s in %s", key, keys)
+			}
+		default:
+			return nil, fmt.Errorf("Invalid key %s in %s", key, keys)
+		}
+	}
+	return codes, nil
+}
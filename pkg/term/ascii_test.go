@@ -0,0 +1,33 @@
+package term
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestToBytes(t *testing.T) {
+	cases := []struct {
+		keys  string
+		codes []byte
+	}{
+		{"", nil},
+		{"ctrl-p,ctrl-q", []byte{16, 17}},
+		{"ctrl-A,a", []byte{1, 'a'}},
+		{"ctrl-@,ctrl-[,ctrl-_", []byte{0, 27, 31}},
+	}
+	for _, c := range cases {
+		codes, err := ToBytes(c.keys)
+		if err != nil {
+			t.Fatalf("Unexpected error converting %q: %s", c.keys, err)
+		}
+		if !bytes.Equal(codes, c.codes) {
+			t.Fatalf("Expected %q to be %v, got %v", c.keys, c.codes, codes)
+		}
+	}
+
+	for _, keys := range []string{"ctrl-1", "ctrl-", "ctrl-pq", "ab", "ctrl-p,", ",", "é"} {
+		if _, err := ToBytes(keys); err == nil {
+			t.Fatalf("Expected an error converting %q", keys)
+		}
+	}
+}
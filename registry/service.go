@@ -1,17 +1,35 @@
 package registry
 
+import "sync"
+
 type Service struct {
-	Config *ServiceConfig
+	mu     sync.RWMutex
+	config *ServiceConfig
 }
 
 // NewService returns a new instance of Service ready to be
 // installed no an engine.
 func NewService(options *Options) *Service {
 	return &Service{
-		Config: NewServiceConfig(options),
+		config: NewServiceConfig(options),
 	}
 }
 
+// Config returns the configuration of the registries.
+func (s *Service) Config() *ServiceConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// SetConfig replaces the configuration of the registries, while the service
+// is in use. The calls in progress go on with the previous configuration.
+func (s *Service) SetConfig(config *ServiceConfig) {
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+}
+
 // Auth contacts the public registry with the provided credentials,
 // and returns OK if authentication was sucessful.
 // It can be used to verify the validity of a client's credentials.
@@ -55,10 +73,10 @@ func (s *Service) Search(term string, authConfig *AuthConfig, headers map[string
 // ResolveRepository splits a repository name into its components
 // and configuration of the associated registry.
 func (s *Service) ResolveRepository(name string) (*RepositoryInfo, error) {
-	return s.Config.NewRepositoryInfo(name)
+	return s.Config().NewRepositoryInfo(name)
 }
 
 // ResolveIndex takes indexName and returns index info
 func (s *Service) ResolveIndex(name string) (*IndexInfo, error) {
-	return s.Config.NewIndexInfo(name)
+	return s.Config().NewIndexInfo(name)
 }
@@ -14,7 +14,8 @@ func Compare(a, b *Config) bool {
 		a.MemorySwap != b.MemorySwap ||
 		a.CpuShares != b.CpuShares ||
 		a.OpenStdin != b.OpenStdin ||
-		a.Tty != b.Tty {
+		a.Tty != b.Tty ||
+		a.StopSignal != b.StopSignal {
 		return false
 	}
 	if len(a.Cmd) != len(b.Cmd) ||
@@ -62,5 +63,21 @@ func Compare(a, b *Config) bool {
 			return false
 		}
 	}
+	return compareHealthConfig(a.Healthcheck, b.Healthcheck)
+}
+
+func compareHealthConfig(a, b *HealthConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Interval != b.Interval || a.Timeout != b.Timeout || a.Retries != b.Retries ||
+		len(a.Test) != len(b.Test) {
+		return false
+	}
+	for i := 0; i < len(a.Test); i++ {
+		if a.Test[i] != b.Test[i] {
+			return false
+		}
+	}
 	return true
 }
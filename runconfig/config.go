@@ -1,6 +1,8 @@
 package runconfig
 
 import (
+	"time"
+
 	"github.com/docker/docker/engine"
 	"github.com/docker/docker/nat"
 )
@@ -32,8 +34,27 @@ type Config struct {
 	Entrypoint      []string
 	NetworkDisabled bool
 	MacAddress      string
+	IPv4Address     string   `json:",omitempty"` // Address of the container on the user-defined network of its network mode
+	IPv6Address     string   `json:",omitempty"` // Same as IPv4Address, for IPv6
+	NetworkAliases  []string `json:",omitempty"` // Names of the container on the user-defined network of its network mode
 	OnBuild         []string
 	Labels          map[string]string
+	Healthcheck     *HealthConfig // Healthcheck describes how to check the container is healthy
+	StopTimeout     *int          `json:",omitempty"` // Seconds to wait for the container to stop before killing it
+	StopSignal      string        `json:",omitempty"` // Signal to stop the container with, SIGTERM if empty
+}
+
+// HealthConfig holds the configuration of the health check of a container.
+type HealthConfig struct {
+	// Test is the check to run: an empty list inherits the check of the
+	// image, {"NONE"} disables it, {"CMD", args...} runs args and
+	// {"CMD-SHELL", command} runs command with the shell.
+	Test []string `json:",omitempty"`
+
+	// Zero means the default for each of these.
+	Interval time.Duration `json:",omitempty"` // Time to wait between two checks
+	Timeout  time.Duration `json:",omitempty"` // Time after which a check that hasn't exited fails
+	Retries  int           `json:",omitempty"` // Consecutive failures needed to be unhealthy
 }
 
 func ContainerConfigFromJob(job *engine.Job) *Config {
@@ -55,6 +76,9 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 		WorkingDir:      job.Getenv("WorkingDir"),
 		NetworkDisabled: job.GetenvBool("NetworkDisabled"),
 		MacAddress:      job.Getenv("MacAddress"),
+		IPv4Address:     job.Getenv("IPv4Address"),
+		IPv6Address:     job.Getenv("IPv6Address"),
+		StopSignal:      job.Getenv("StopSignal"),
 	}
 	job.GetenvJson("ExposedPorts", &config.ExposedPorts)
 	job.GetenvJson("Volumes", &config.Volumes)
@@ -67,8 +91,11 @@ func ContainerConfigFromJob(job *engine.Job) *Config {
 	if Cmd := job.GetenvList("Cmd"); Cmd != nil {
 		config.Cmd = Cmd
 	}
+	config.NetworkAliases = job.GetenvList("NetworkAliases")
 
 	job.GetenvJson("Labels", &config.Labels)
+	job.GetenvJson("Healthcheck", &config.Healthcheck)
+	job.GetenvJson("StopTimeout", &config.StopTimeout)
 
 	if Entrypoint := job.GetenvList("Entrypoint"); Entrypoint != nil {
 		config.Entrypoint = Entrypoint
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/nat"
 )
@@ -262,3 +263,27 @@ func TestMerge(t *testing.T) {
 	}
 
 }
+
+func TestMergeHealthcheck(t *testing.T) {
+	imageHealth := &HealthConfig{Test: []string{"CMD", "true"}, Interval: time.Minute, Retries: 5}
+
+	configUser := &Config{}
+	if err := Merge(configUser, &Config{Healthcheck: imageHealth}); err != nil {
+		t.Fatal(err)
+	}
+	if configUser.Healthcheck != imageHealth {
+		t.Fatalf("Expected the health check of the image, got %v", configUser.Healthcheck)
+	}
+
+	configUser = &Config{Healthcheck: &HealthConfig{Interval: time.Second}}
+	if err := Merge(configUser, &Config{Healthcheck: imageHealth}); err != nil {
+		t.Fatal(err)
+	}
+	health := configUser.Healthcheck
+	if len(health.Test) != 2 || health.Test[1] != "true" || health.Interval != time.Second || health.Retries != 5 {
+		t.Fatalf("Expected the image's check with an interval of 1s, got %v", health)
+	}
+	if imageHealth.Interval != time.Minute {
+		t.Fatalf("Expected the health check of the image to be left alone, got %v", imageHealth)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/docker/docker/engine"
+	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 )
 
@@ -16,19 +17,22 @@ type ExecConfig struct {
 	AttachStderr bool
 	AttachStdout bool
 	Detach       bool
+	DetachKeys   string
+	Env          []string
 	Cmd          []string
 }
 
 func ExecConfigFromJob(job *engine.Job) (*ExecConfig, error) {
 	execConfig := &ExecConfig{
-		// TODO(vishh): Expose 'User' once it is supported.
-		//User:         job.Getenv("User"),
+		User: job.Getenv("User"),
+		Env:  job.GetenvList("Env"),
 		// TODO(vishh): Expose 'Privileged' once it is supported.
 		//Privileged:   job.GetenvBool("Privileged"),
 		Tty:          job.GetenvBool("Tty"),
 		AttachStdin:  job.GetenvBool("AttachStdin"),
 		AttachStderr: job.GetenvBool("AttachStderr"),
 		AttachStdout: job.GetenvBool("AttachStdout"),
+		DetachKeys:   job.Getenv("DetachKeys"),
 	}
 	cmd := job.GetenvList("Cmd")
 	if len(cmd) == 0 {
@@ -45,9 +49,13 @@ func ParseExec(cmd *flag.FlagSet, args []string) (*ExecConfig, error) {
 		flStdin   = cmd.Bool([]string{"i", "-interactive"}, false, "Keep STDIN open even if not attached")
 		flTty     = cmd.Bool([]string{"t", "-tty"}, false, "Allocate a pseudo-TTY")
 		flDetach  = cmd.Bool([]string{"d", "-detach"}, false, "Detached mode: run command in the background")
+		flUser    = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
+		flKeys    = cmd.String([]string{"-detach-keys"}, "", "Override the key sequence for detaching a container")
+		flEnv     = opts.NewListOpts(opts.ValidateEnv)
 		execCmd   []string
 		container string
 	)
+	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
 	cmd.Require(flag.Min, 2)
 	if err := cmd.ParseFlags(args, true); err != nil {
 		return nil, err
@@ -57,14 +65,15 @@ func ParseExec(cmd *flag.FlagSet, args []string) (*ExecConfig, error) {
 	execCmd = parsedArgs[1:]
 
 	execConfig := &ExecConfig{
-		// TODO(vishh): Expose '-u' flag once it is supported.
-		User: "",
+		User: *flUser,
 		// TODO(vishh): Expose '-p' flag once it is supported.
 		Privileged: false,
 		Tty:        *flTty,
+		Env:        flEnv.GetAll(),
 		Cmd:        execCmd,
 		Container:  container,
 		Detach:     *flDetach,
+		DetachKeys: *flKeys,
 	}
 
 	// If -d is not set, attach to everything by default
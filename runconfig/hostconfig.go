@@ -29,6 +29,29 @@ func (n NetworkMode) IsNone() bool {
 	return n == "none"
 }
 
+// IsBridge indicates whether container uses the default bridge network
+func (n NetworkMode) IsBridge() bool {
+	return n == "bridge" || n == "" // empty string to support existing containers
+}
+
+// IsUserDefined indicates whether container uses a network created with
+// `docker network create`, the network mode being its name or ID
+func (n NetworkMode) IsUserDefined() bool {
+	return !(n.IsBridge() || n.IsHost() || n.IsContainer() || n.IsNone())
+}
+
+// NetworkName returns the name of the network of the container, empty if it
+// joins the network stack of another container
+func (n NetworkMode) NetworkName() string {
+	switch {
+	case n.IsBridge():
+		return "bridge"
+	case n.IsContainer():
+		return ""
+	}
+	return string(n)
+}
+
 type IpcMode string
 
 // IsPrivate indicates whether container use it's private ipc stack
@@ -71,32 +94,76 @@ type PidMode string
 
 // IsPrivate indicates whether container use it's private pid stack
 func (n PidMode) IsPrivate() bool {
-	return !(n.IsHost())
+	return !(n.IsHost() || n.IsContainer())
 }
 
 func (n PidMode) IsHost() bool {
 	return n == "host"
 }
 
+// IsContainer indicates whether the container joins the PID namespace of
+// another container.
+func (n PidMode) IsContainer() bool {
+	parts := strings.SplitN(string(n), ":", 2)
+	return len(parts) > 1 && parts[0] == "container"
+}
+
 func (n PidMode) Valid() bool {
 	parts := strings.Split(string(n), ":")
 	switch mode := parts[0]; mode {
 	case "", "host":
+	case "container":
+		if len(parts) != 2 || parts[1] == "" {
+			return false
+		}
 	default:
 		return false
 	}
 	return true
 }
 
+// Container returns the name or ID of the container whose PID namespace is
+// joined, if any.
+func (n PidMode) Container() string {
+	parts := strings.SplitN(string(n), ":", 2)
+	if len(parts) > 1 {
+		return parts[1]
+	}
+	return ""
+}
+
+type UTSMode string
+
+// IsPrivate indicates whether container use it's private UTS namespace
+func (n UTSMode) IsPrivate() bool {
+	return !(n.IsHost())
+}
+
+func (n UTSMode) IsHost() bool {
+	return n == "host"
+}
+
+func (n UTSMode) Valid() bool {
+	return n == "" || n.IsHost()
+}
+
 type DeviceMapping struct {
 	PathOnHost        string
 	PathInContainer   string
 	CgroupPermissions string
 }
 
+// ThrottleDevice limits the rate of the block IO of a container to a device
+// of the host.
+type ThrottleDevice struct {
+	Path string
+	Rate uint64
+}
+
 type RestartPolicy struct {
 	Name              string
 	MaximumRetryCount int
+	OnUnhealthy       bool // restart the container when its health check fails
 }
 
 type LogConfig struct {
@@ -111,19 +178,33 @@ type HostConfig struct {
 	Memory          int64  // Memory limit (in bytes)
 	MemorySwap      int64  // Total memory usage (memory + swap); set `-1` to disable swap
 	CpuShares       int64  // CPU shares (relative weight vs. other containers)
+	CpuPeriod       int64  // CPU CFS (Completely Fair Scheduler) period, in microseconds
+	CpuQuota        int64  // CPU CFS (Completely Fair Scheduler) quota, in microseconds per period
 	CpusetCpus      string // CpusetCpus 0-2, 0,1
+	BlkioWeight     int64  // Block IO weight (relative weight vs. other containers)
+	PidsLimit       int64  // Maximum number of processes, the daemon default if 0, no limit if -1
+	OomKillDisable  bool   // Whether to disable the OOM killer of the container
+	OomScoreAdj     int    // OOM score adjustment of the processes of the container, between -1000 and 1000
+	NetEgressBps    int64  // Rate limit of the traffic sent on each interface, in bytes per second
+	NetIngressBps   int64  // Rate limit of the traffic received on each interface, in bytes per second
 	Privileged      bool
 	PortBindings    nat.PortMap
 	Links           []string
 	PublishAllPorts bool
 	Dns             []string
 	DnsSearch       []string
+	DnsOptions      []string
 	ExtraHosts      []string
 	VolumesFrom     []string
 	Devices         []DeviceMapping
+	DeviceReadBps   []ThrottleDevice // Read rate limits of host devices, in bytes per second
+	DeviceWriteBps  []ThrottleDevice // Write rate limits of host devices, in bytes per second
+	DeviceReadIOps  []ThrottleDevice // Read rate limits of host devices, in IO per second
+	DeviceWriteIOps []ThrottleDevice // Write rate limits of host devices, in IO per second
 	NetworkMode     NetworkMode
 	IpcMode         IpcMode
 	PidMode         PidMode
+	UTSMode         UTSMode
 	CapAdd          []string
 	CapDrop         []string
 	RestartPolicy   RestartPolicy
@@ -131,7 +212,11 @@ type HostConfig struct {
 	ReadonlyRootfs  bool
 	Ulimits         []*ulimit.Ulimit
 	LogConfig       LogConfig
+	StorageOpt      map[string]string
 	CgroupParent    string // Parent cgroup.
+	Runtime         string // Runtime to run the container with
+	AutoRemove      bool   // Remove the container when it exits
+	Init            *bool  `json:",omitempty"` // Run an init in the container, the daemon default if nil
 }
 
 // This is used by the create command when you want to set both the
@@ -176,14 +261,25 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 		Memory:          job.GetenvInt64("Memory"),
 		MemorySwap:      job.GetenvInt64("MemorySwap"),
 		CpuShares:       job.GetenvInt64("CpuShares"),
+		CpuPeriod:       job.GetenvInt64("CpuPeriod"),
+		CpuQuota:        job.GetenvInt64("CpuQuota"),
 		CpusetCpus:      job.Getenv("CpusetCpus"),
+		BlkioWeight:     job.GetenvInt64("BlkioWeight"),
+		PidsLimit:       job.GetenvInt64("PidsLimit"),
+		OomKillDisable:  job.GetenvBool("OomKillDisable"),
+		OomScoreAdj:     job.GetenvInt("OomScoreAdj"),
+		NetEgressBps:    job.GetenvInt64("NetEgressBps"),
+		NetIngressBps:   job.GetenvInt64("NetIngressBps"),
 		Privileged:      job.GetenvBool("Privileged"),
 		PublishAllPorts: job.GetenvBool("PublishAllPorts"),
 		NetworkMode:     NetworkMode(job.Getenv("NetworkMode")),
 		IpcMode:         IpcMode(job.Getenv("IpcMode")),
 		PidMode:         PidMode(job.Getenv("PidMode")),
+		UTSMode:         UTSMode(job.Getenv("UTSMode")),
 		ReadonlyRootfs:  job.GetenvBool("ReadonlyRootfs"),
 		CgroupParent:    job.Getenv("CgroupParent"),
+		Runtime:         job.Getenv("Runtime"),
+		AutoRemove:      job.GetenvBool("AutoRemove"),
 	}
 
 	// FIXME: This is for backward compatibility, if people use `Cpuset`
@@ -196,9 +292,15 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	job.GetenvJson("LxcConf", &hostConfig.LxcConf)
 	job.GetenvJson("PortBindings", &hostConfig.PortBindings)
 	job.GetenvJson("Devices", &hostConfig.Devices)
+	job.GetenvJson("DeviceReadBps", &hostConfig.DeviceReadBps)
+	job.GetenvJson("DeviceWriteBps", &hostConfig.DeviceWriteBps)
+	job.GetenvJson("DeviceReadIOps", &hostConfig.DeviceReadIOps)
+	job.GetenvJson("DeviceWriteIOps", &hostConfig.DeviceWriteIOps)
 	job.GetenvJson("RestartPolicy", &hostConfig.RestartPolicy)
 	job.GetenvJson("Ulimits", &hostConfig.Ulimits)
 	job.GetenvJson("LogConfig", &hostConfig.LogConfig)
+	job.GetenvJson("StorageOpt", &hostConfig.StorageOpt)
+	job.GetenvJson("Init", &hostConfig.Init)
 	hostConfig.SecurityOpt = job.GetenvList("SecurityOpt")
 	if Binds := job.GetenvList("Binds"); Binds != nil {
 		hostConfig.Binds = Binds
@@ -212,6 +314,9 @@ func ContainerHostConfigFromJob(job *engine.Job) *HostConfig {
 	if DnsSearch := job.GetenvList("DnsSearch"); DnsSearch != nil {
 		hostConfig.DnsSearch = DnsSearch
 	}
+	if DnsOptions := job.GetenvList("DnsOptions"); DnsOptions != nil {
+		hostConfig.DnsOptions = DnsOptions
+	}
 	if ExtraHosts := job.GetenvList("ExtraHosts"); ExtraHosts != nil {
 		hostConfig.ExtraHosts = ExtraHosts
 	}
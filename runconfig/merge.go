@@ -113,5 +113,32 @@ func Merge(userConf, imageConf *Config) error {
 			userConf.Volumes[k] = v
 		}
 	}
+	if userConf.StopTimeout == nil {
+		userConf.StopTimeout = imageConf.StopTimeout
+	}
+	if userConf.StopSignal == "" {
+		userConf.StopSignal = imageConf.StopSignal
+	}
+	if imageConf.Healthcheck != nil {
+		if userConf.Healthcheck == nil {
+			userConf.Healthcheck = imageConf.Healthcheck
+		} else {
+			// The settings the user left out are those of the image.
+			health := *userConf.Healthcheck
+			if len(health.Test) == 0 {
+				health.Test = imageConf.Healthcheck.Test
+			}
+			if health.Interval == 0 {
+				health.Interval = imageConf.Healthcheck.Interval
+			}
+			if health.Timeout == 0 {
+				health.Timeout = imageConf.Healthcheck.Timeout
+			}
+			if health.Retries == 0 {
+				health.Retries = imageConf.Healthcheck.Retries
+			}
+			userConf.Healthcheck = &health
+		}
+	}
 	return nil
 }
@@ -2,14 +2,18 @@ package runconfig
 
 import (
 	"fmt"
+	"net"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/nat"
 	"github.com/docker/docker/opts"
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
+	"github.com/docker/docker/pkg/signal"
 	"github.com/docker/docker/pkg/ulimit"
 	"github.com/docker/docker/pkg/units"
 	"github.com/docker/docker/utils"
@@ -20,10 +24,22 @@ var (
 	ErrConflictContainerNetworkAndLinks = fmt.Errorf("Conflicting options: --net=container can't be used with links. This would result in undefined behavior.")
 	ErrConflictContainerNetworkAndDns   = fmt.Errorf("Conflicting options: --net=container can't be used with --dns. This configuration is invalid.")
 	ErrConflictNetworkHostname          = fmt.Errorf("Conflicting options: -h and the network mode (--net)")
+	ErrConflictUTSHostname              = fmt.Errorf("Conflicting options: -h and the UTS mode (--uts)")
 	ErrConflictHostNetworkAndDns        = fmt.Errorf("Conflicting options: --net=host can't be used with --dns. This configuration is invalid.")
 	ErrConflictHostNetworkAndLinks      = fmt.Errorf("Conflicting options: --net=host can't be used with links. This would result in undefined behavior.")
+	ErrConflictNetworkAndLinks          = fmt.Errorf("Conflicting options: links are only supported on the default bridge network (--net=bridge).")
+	ErrConflictNetworkAliases           = fmt.Errorf("Conflicting options: --net-alias is only supported on user-defined networks.")
+	ErrInvalidCpuPeriod                 = fmt.Errorf("Invalid --cpu-period: it must be between 1000 (1ms) and 1000000 (1s).")
+	ErrInvalidCpuQuota                  = fmt.Errorf("Invalid --cpu-quota: it must be at least 1000 (1ms).")
+	ErrInvalidBlkioWeight               = fmt.Errorf("Invalid --blkio-weight: it must be between 10 and 1000.")
+	ErrInvalidPidsLimit                 = fmt.Errorf("Invalid --pids-limit: it must be positive, or -1 for no limit.")
+	ErrInvalidOomScoreAdj               = fmt.Errorf("Invalid --oom-score-adj: it must be between -1000 and 1000.")
 )
 
+// validNetworkName matches the names and IDs of the networks created with
+// `docker network create`.
+var validNetworkName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSet, error) {
 	var (
 		// FIXME: use utils.ListOpts for attach and volumes?
@@ -34,21 +50,31 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flLabels  = opts.NewListOpts(opts.ValidateEnv)
 		flDevices = opts.NewListOpts(opts.ValidatePath)
 
+		flDeviceReadBps   = opts.NewListOpts(nil)
+		flDeviceWriteBps  = opts.NewListOpts(nil)
+		flDeviceReadIOps  = opts.NewListOpts(nil)
+		flDeviceWriteIOps = opts.NewListOpts(nil)
+
 		ulimits   = make(map[string]*ulimit.Ulimit)
 		flUlimits = opts.NewUlimitOpt(ulimits)
 
-		flPublish     = opts.NewListOpts(nil)
-		flExpose      = opts.NewListOpts(nil)
-		flDns         = opts.NewListOpts(opts.ValidateIPAddress)
-		flDnsSearch   = opts.NewListOpts(opts.ValidateDnsSearch)
-		flExtraHosts  = opts.NewListOpts(opts.ValidateExtraHost)
-		flVolumesFrom = opts.NewListOpts(nil)
-		flLxcOpts     = opts.NewListOpts(nil)
-		flEnvFile     = opts.NewListOpts(nil)
-		flCapAdd      = opts.NewListOpts(nil)
-		flCapDrop     = opts.NewListOpts(nil)
-		flSecurityOpt = opts.NewListOpts(nil)
-		flLabelsFile  = opts.NewListOpts(nil)
+		flPublish        = opts.NewListOpts(nil)
+		flExpose         = opts.NewListOpts(nil)
+		flDns            = opts.NewListOpts(opts.ValidateIPAddress)
+		flDnsSearch      = opts.NewListOpts(opts.ValidateDnsSearch)
+		flDnsOptions     = opts.NewListOpts(opts.ValidateDnsOpt)
+		flExtraHosts     = opts.NewListOpts(opts.ValidateExtraHost)
+		flNetAliases     = opts.NewListOpts(nil)
+		flVolumesFrom    = opts.NewListOpts(nil)
+		flLxcOpts        = opts.NewListOpts(nil)
+		flEnvFile        = opts.NewListOpts(nil)
+		flCapAdd         = opts.NewListOpts(nil)
+		flCapDrop        = opts.NewListOpts(nil)
+		flSecurityOpt    = opts.NewListOpts(nil)
+		flLabelsFile     = opts.NewListOpts(nil)
+		flExtraHostsFile = opts.NewListOpts(nil)
+		flLoggingOpts    = opts.NewListOpts(nil)
+		flStorageOpt     = opts.NewListOpts(nil)
 
 		flNetwork         = cmd.Bool([]string{"#n", "#-networking"}, true, "Enable networking for this container")
 		flPrivileged      = cmd.Bool([]string{"#privileged", "-privileged"}, false, "Give extended privileges to this container")
@@ -64,20 +90,46 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		flUser            = cmd.String([]string{"u", "-user"}, "", "Username or UID (format: <name|uid>[:<group|gid>])")
 		flWorkingDir      = cmd.String([]string{"w", "-workdir"}, "", "Working directory inside the container")
 		flCpuShares       = cmd.Int64([]string{"c", "-cpu-shares"}, 0, "CPU shares (relative weight)")
+		flCpuPeriod       = cmd.Int64([]string{"-cpu-period"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) period")
+		flCpuQuota        = cmd.Int64([]string{"-cpu-quota"}, 0, "Limit the CPU CFS (Completely Fair Scheduler) quota")
 		flCpusetCpus      = cmd.String([]string{"#-cpuset", "-cpuset-cpus"}, "", "CPUs in which to allow execution (0-3, 0,1)")
+		flBlkioWeight     = cmd.Int64([]string{"-blkio-weight"}, 0, "Block IO (relative weight), between 10 and 1000")
+		flPidsLimit       = cmd.Int64([]string{"-pids-limit"}, 0, "Maximum number of processes (-1 for no limit)")
+		flOomKillDisable  = cmd.Bool([]string{"-oom-kill-disable"}, false, "Disable OOM Killer")
+		flOomScoreAdj     = cmd.Int([]string{"-oom-score-adj"}, 0, "Tune host's OOM preferences (-1000 to 1000)")
 		flNetMode         = cmd.String([]string{"-net"}, "bridge", "Set the Network mode for the container")
+		flNetEgressBps    = cmd.String([]string{"-net-egress-bps"}, "", "Limit the rate (bytes per second) of the traffic sent on each interface")
+		flNetIngressBps   = cmd.String([]string{"-net-ingress-bps"}, "", "Limit the rate (bytes per second) of the traffic received on each interface")
 		flMacAddress      = cmd.String([]string{"-mac-address"}, "", "Container MAC address (e.g. 92:d0:c6:0a:29:33)")
+		flIPv4Address     = cmd.String([]string{"-ip"}, "", "Container IPv4 address on its network (e.g. 172.30.100.104)")
+		flIPv6Address     = cmd.String([]string{"-ip6"}, "", "Container IPv6 address on its network (e.g. 2001:db8::33)")
 		flIpcMode         = cmd.String([]string{"-ipc"}, "", "IPC namespace to use")
+		flUTSMode         = cmd.String([]string{"-uts"}, "", "UTS namespace to use")
 		flRestartPolicy   = cmd.String([]string{"-restart"}, "no", "Restart policy to apply when a container exits")
 		flReadonlyRootfs  = cmd.Bool([]string{"-read-only"}, false, "Mount the container's root filesystem as read only")
 		flLoggingDriver   = cmd.String([]string{"-log-driver"}, "", "Logging driver for container")
 		flCgroupParent    = cmd.String([]string{"-cgroup-parent"}, "", "Optional parent cgroup for the container")
+		flRuntime         = cmd.String([]string{"-runtime"}, "", "Runtime to use for this container")
+		flAutoRemove      = cmd.Bool([]string{"#rm", "-rm"}, false, "Automatically remove the container when it exits")
+		flStopTimeout     = cmd.Int([]string{"-stop-timeout"}, 10, "Seconds to wait for the container to stop before killing it")
+		flStopSignal      = cmd.String([]string{"-stop-signal"}, "SIGTERM", "Signal to stop the container with")
+		flInit            = cmd.Bool([]string{"-init"}, false, "Run an init in the container to forward signals and reap processes")
+		flHealthCmd       = cmd.String([]string{"-health-cmd"}, "", "Command to run to check health")
+		flHealthInterval  = cmd.Duration([]string{"-health-interval"}, 0, "Time between running the check (default 30s)")
+		flHealthTimeout   = cmd.Duration([]string{"-health-timeout"}, 0, "Maximum time to allow one check to run (default 30s)")
+		flHealthRetries   = cmd.Int([]string{"-health-retries"}, 0, "Consecutive failures needed to report unhealthy (default 3)")
+		flNoHealthcheck   = cmd.Bool([]string{"-no-healthcheck"}, false, "Disable any container-specified HEALTHCHECK")
+		flHealthRestart   = cmd.Bool([]string{"-restart-on-unhealthy"}, false, "Restart the container when its health check reports it unhealthy")
 	)
 
 	cmd.Var(&flAttach, []string{"a", "-attach"}, "Attach to STDIN, STDOUT or STDERR")
 	cmd.Var(&flVolumes, []string{"v", "-volume"}, "Bind mount a volume")
 	cmd.Var(&flLinks, []string{"#link", "-link"}, "Add link to another container")
 	cmd.Var(&flDevices, []string{"-device"}, "Add a host device to the container")
+	cmd.Var(&flDeviceReadBps, []string{"-device-read-bps"}, "Limit the read rate (bytes per second) from a device")
+	cmd.Var(&flDeviceWriteBps, []string{"-device-write-bps"}, "Limit the write rate (bytes per second) to a device")
+	cmd.Var(&flDeviceReadIOps, []string{"-device-read-iops"}, "Limit the read rate (IO per second) from a device")
+	cmd.Var(&flDeviceWriteIOps, []string{"-device-write-iops"}, "Limit the write rate (IO per second) to a device")
 	cmd.Var(&flLabels, []string{"l", "-label"}, "Set meta data on a container")
 	cmd.Var(&flLabelsFile, []string{"-label-file"}, "Read in a line delimited file of labels")
 	cmd.Var(&flEnv, []string{"e", "-env"}, "Set environment variables")
@@ -86,13 +138,18 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	cmd.Var(&flExpose, []string{"#expose", "-expose"}, "Expose a port or a range of ports")
 	cmd.Var(&flDns, []string{"#dns", "-dns"}, "Set custom DNS servers")
 	cmd.Var(&flDnsSearch, []string{"-dns-search"}, "Set custom DNS search domains")
+	cmd.Var(&flDnsOptions, []string{"-dns-opt"}, "Set DNS options")
 	cmd.Var(&flExtraHosts, []string{"-add-host"}, "Add a custom host-to-IP mapping (host:ip)")
+	cmd.Var(&flExtraHostsFile, []string{"-add-host-file"}, "Read in a hosts-format file of host-to-IP mappings")
+	cmd.Var(&flNetAliases, []string{"-net-alias"}, "Add a name of the container on its user-defined network")
 	cmd.Var(&flVolumesFrom, []string{"#volumes-from", "-volumes-from"}, "Mount volumes from the specified container(s)")
 	cmd.Var(&flLxcOpts, []string{"#lxc-conf", "-lxc-conf"}, "Add custom lxc options")
 	cmd.Var(&flCapAdd, []string{"-cap-add"}, "Add Linux capabilities")
 	cmd.Var(&flCapDrop, []string{"-cap-drop"}, "Drop Linux capabilities")
 	cmd.Var(&flSecurityOpt, []string{"-security-opt"}, "Security Options")
 	cmd.Var(flUlimits, []string{"-ulimit"}, "Ulimit options")
+	cmd.Var(&flLoggingOpts, []string{"-log-opt"}, "Log driver options")
+	cmd.Var(&flStorageOpt, []string{"-storage-opt"}, "Storage driver options for the container")
 
 	cmd.Require(flag.Min, 1)
 
@@ -111,13 +168,23 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 			return nil, nil, cmd, fmt.Errorf("%s is not a valid mac address", *flMacAddress)
 		}
 	}
+	if *flIPv4Address != "" {
+		if ip := net.ParseIP(*flIPv4Address); ip == nil || ip.To4() == nil {
+			return nil, nil, cmd, fmt.Errorf("%s is not a valid IPv4 address", *flIPv4Address)
+		}
+	}
+	if *flIPv6Address != "" {
+		if ip := net.ParseIP(*flIPv6Address); ip == nil || ip.To4() != nil {
+			return nil, nil, cmd, fmt.Errorf("%s is not a valid IPv6 address", *flIPv6Address)
+		}
+	}
 	var (
 		attachStdin  = flAttach.Get("stdin")
 		attachStdout = flAttach.Get("stdout")
 		attachStderr = flAttach.Get("stderr")
 	)
 
-	if *flNetMode != "bridge" && *flNetMode != "none" && *flHostname != "" {
+	if (*flNetMode == "host" || strings.HasPrefix(*flNetMode, "container")) && *flHostname != "" {
 		return nil, nil, cmd, ErrConflictNetworkHostname
 	}
 
@@ -129,6 +196,19 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, ErrConflictContainerNetworkAndLinks
 	}
 
+	if NetworkMode(*flNetMode).IsUserDefined() && flLinks.Len() > 0 {
+		return nil, nil, cmd, ErrConflictNetworkAndLinks
+	}
+
+	if !NetworkMode(*flNetMode).IsUserDefined() && flNetAliases.Len() > 0 {
+		return nil, nil, cmd, ErrConflictNetworkAliases
+	}
+	for _, alias := range flNetAliases.GetAll() {
+		if !validNetworkName.MatchString(alias) {
+			return nil, nil, cmd, fmt.Errorf("invalid --net-alias: %s", alias)
+		}
+	}
+
 	if *flNetMode == "host" && flDns.Len() > 0 {
 		return nil, nil, cmd, ErrConflictHostNetworkAndDns
 	}
@@ -168,6 +248,35 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		}
 	}
 
+	if *flCpuPeriod != 0 && (*flCpuPeriod < 1000 || *flCpuPeriod > 1000000) {
+		return nil, nil, cmd, ErrInvalidCpuPeriod
+	}
+
+	if *flCpuQuota != 0 && *flCpuQuota < 1000 {
+		return nil, nil, cmd, ErrInvalidCpuQuota
+	}
+
+	if *flBlkioWeight != 0 && (*flBlkioWeight < 10 || *flBlkioWeight > 1000) {
+		return nil, nil, cmd, ErrInvalidBlkioWeight
+	}
+
+	if *flPidsLimit < -1 {
+		return nil, nil, cmd, ErrInvalidPidsLimit
+	}
+
+	if *flOomScoreAdj < -1000 || *flOomScoreAdj > 1000 {
+		return nil, nil, cmd, ErrInvalidOomScoreAdj
+	}
+
+	netEgressBps, err := parseNetworkRate("--net-egress-bps", *flNetEgressBps)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	netIngressBps, err := parseNetworkRate("--net-ingress-bps", *flNetIngressBps)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	var binds []string
 	// add any bind targets to the list of container volumes
 	for bind := range flVolumes.GetMap() {
@@ -248,6 +357,24 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		deviceMappings = append(deviceMappings, deviceMapping)
 	}
 
+	// parse the block IO limits of the devices
+	deviceReadBps, err := parseThrottleDevices(flDeviceReadBps.GetAll(), true)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceWriteBps, err := parseThrottleDevices(flDeviceWriteBps.GetAll(), true)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceReadIOps, err := parseThrottleDevices(flDeviceReadIOps.GetAll(), false)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+	deviceWriteIOps, err := parseThrottleDevices(flDeviceWriteIOps.GetAll(), false)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	// collect all the environment variables for the container
 	envVariables, err := readKVStrings(flEnvFile.GetAll(), flEnv.GetAll())
 	if err != nil {
@@ -260,6 +387,12 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, err
 	}
 
+	// collect all the host-to-IP mappings of the container
+	extraHosts, err := readExtraHosts(flExtraHostsFile.GetAll(), flExtraHosts.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
 	ipcMode := IpcMode(*flIpcMode)
 	if !ipcMode.Valid() {
 		return nil, nil, cmd, fmt.Errorf("--ipc: invalid IPC mode")
@@ -270,6 +403,14 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		return nil, nil, cmd, fmt.Errorf("--pid: invalid PID mode")
 	}
 
+	utsMode := UTSMode(*flUTSMode)
+	if !utsMode.Valid() {
+		return nil, nil, cmd, fmt.Errorf("--uts: invalid UTS mode")
+	}
+	if utsMode.IsHost() && *flHostname != "" {
+		return nil, nil, cmd, ErrConflictUTSHostname
+	}
+
 	netMode, err := parseNetMode(*flNetMode)
 	if err != nil {
 		return nil, nil, cmd, fmt.Errorf("--net: invalid net mode: %v", err)
@@ -279,6 +420,43 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 	if err != nil {
 		return nil, nil, cmd, err
 	}
+	restartPolicy.OnUnhealthy = *flHealthRestart
+
+	loggingOpts, err := parseLoggingOpts(*flLoggingDriver, flLoggingOpts.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	storageOpts, err := parseStorageOpts(flStorageOpt.GetAll())
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	healthConfig, err := parseHealthConfig(*flHealthCmd, *flHealthInterval, *flHealthTimeout, *flHealthRetries, *flNoHealthcheck)
+	if err != nil {
+		return nil, nil, cmd, err
+	}
+
+	var stopTimeout *int
+	if cmd.IsSet("-stop-timeout") {
+		if *flStopTimeout < 0 {
+			return nil, nil, cmd, fmt.Errorf("--stop-timeout can't be negative")
+		}
+		stopTimeout = flStopTimeout
+	}
+
+	var runInit *bool
+	if cmd.IsSet("-init") {
+		runInit = flInit
+	}
+
+	var stopSignal string
+	if cmd.IsSet("-stop-signal") {
+		if _, err := signal.ParseSignal(*flStopSignal); err != nil {
+			return nil, nil, cmd, err
+		}
+		stopSignal = *flStopSignal
+	}
 
 	config := &Config{
 		Hostname:        hostname,
@@ -301,9 +479,15 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		Image:           image,
 		Volumes:         flVolumes.GetMap(),
 		MacAddress:      *flMacAddress,
+		IPv4Address:     *flIPv4Address,
+		IPv6Address:     *flIPv6Address,
+		NetworkAliases:  flNetAliases.GetAll(),
 		Entrypoint:      entrypoint,
 		WorkingDir:      *flWorkingDir,
 		Labels:          convertKVStringsToMap(labels),
+		Healthcheck:     healthConfig,
+		StopTimeout:     stopTimeout,
+		StopSignal:      stopSignal,
 	}
 
 	hostConfig := &HostConfig{
@@ -313,27 +497,45 @@ func Parse(cmd *flag.FlagSet, args []string) (*Config, *HostConfig, *flag.FlagSe
 		Memory:          flMemory,
 		MemorySwap:      MemorySwap,
 		CpuShares:       *flCpuShares,
+		CpuPeriod:       *flCpuPeriod,
+		CpuQuota:        *flCpuQuota,
 		CpusetCpus:      *flCpusetCpus,
+		BlkioWeight:     *flBlkioWeight,
+		PidsLimit:       *flPidsLimit,
+		OomKillDisable:  *flOomKillDisable,
+		OomScoreAdj:     *flOomScoreAdj,
+		NetEgressBps:    netEgressBps,
+		NetIngressBps:   netIngressBps,
 		Privileged:      *flPrivileged,
 		PortBindings:    portBindings,
 		Links:           flLinks.GetAll(),
 		PublishAllPorts: *flPublishAll,
 		Dns:             flDns.GetAll(),
 		DnsSearch:       flDnsSearch.GetAll(),
-		ExtraHosts:      flExtraHosts.GetAll(),
+		DnsOptions:      flDnsOptions.GetAll(),
+		ExtraHosts:      extraHosts,
 		VolumesFrom:     flVolumesFrom.GetAll(),
 		NetworkMode:     netMode,
 		IpcMode:         ipcMode,
 		PidMode:         pidMode,
+		UTSMode:         utsMode,
 		Devices:         deviceMappings,
+		DeviceReadBps:   deviceReadBps,
+		DeviceWriteBps:  deviceWriteBps,
+		DeviceReadIOps:  deviceReadIOps,
+		DeviceWriteIOps: deviceWriteIOps,
 		CapAdd:          flCapAdd.GetAll(),
 		CapDrop:         flCapDrop.GetAll(),
 		RestartPolicy:   restartPolicy,
 		SecurityOpt:     flSecurityOpt.GetAll(),
 		ReadonlyRootfs:  *flReadonlyRootfs,
 		Ulimits:         flUlimits.GetList(),
-		LogConfig:       LogConfig{Type: *flLoggingDriver},
+		LogConfig:       LogConfig{Type: *flLoggingDriver, Config: loggingOpts},
+		StorageOpt:      storageOpts,
 		CgroupParent:    *flCgroupParent,
+		Runtime:         *flRuntime,
+		AutoRemove:      *flAutoRemove,
+		Init:            runInit,
 	}
 
 	// When allocating stdin in attached mode, close stdin at client disconnect
@@ -359,6 +561,33 @@ func readKVStrings(files []string, override []string) ([]string, error) {
 	return envVariables, nil
 }
 
+// parseNetworkRate parses the value of the rate limit flag, a number of bytes
+// per second with an optional unit, 0 for no limit if it's empty.
+func parseNetworkRate(flag, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	rate, err := units.RAMInBytes(value)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("Invalid %s %s: the rate must be a positive number of bytes per second", flag, value)
+	}
+	return rate, nil
+}
+
+// reads hosts-format files of host-to-IP mappings, and returns them after
+// the --add-host ones, which take precedence: the first entry of a host in
+// /etc/hosts is the one resolved
+func readExtraHosts(files []string, hosts []string) ([]string, error) {
+	for _, f := range files {
+		parsedHosts, err := opts.ParseHostsFile(f)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, parsedHosts...)
+	}
+	return hosts, nil
+}
+
 // converts ["key=value"] to {"key":"value"}
 func convertKVStringsToMap(values []string) map[string]string {
 	result := make(map[string]string, len(values))
@@ -374,6 +603,68 @@ func convertKVStringsToMap(values []string) map[string]string {
 	return result
 }
 
+// parseLoggingOpts returns the key=value options given to the logging driver
+// with --log-opt as a map, or nil when there are none.
+func parseLoggingOpts(loggingDriver string, loggingOpts []string) (map[string]string, error) {
+	if len(loggingOpts) == 0 {
+		return nil, nil
+	}
+	if loggingDriver == "none" {
+		return nil, fmt.Errorf("Invalid logging opts for driver %s", loggingDriver)
+	}
+	result := make(map[string]string, len(loggingOpts))
+	for _, o := range loggingOpts {
+		k, v, err := parsers.ParseKeyValueOpt(o)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// parseStorageOpts returns the key=value options of the --storage-opt flags.
+func parseStorageOpts(storageOpts []string) (map[string]string, error) {
+	if len(storageOpts) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(storageOpts))
+	for _, o := range storageOpts {
+		k, v, err := parsers.ParseKeyValueOpt(o)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
+// parseHealthConfig returns the health check set by the --health-* options,
+// nil if none of them is given so that the check of the image is kept.
+func parseHealthConfig(cmd string, interval, timeout time.Duration, retries int, disable bool) (*HealthConfig, error) {
+	if disable {
+		if cmd != "" || interval != 0 || timeout != 0 || retries != 0 {
+			return nil, fmt.Errorf("--no-healthcheck conflicts with --health-* options")
+		}
+		return &HealthConfig{Test: []string{"NONE"}}, nil
+	}
+	if interval < 0 || timeout < 0 || retries < 0 {
+		return nil, fmt.Errorf("--health-interval, --health-timeout and --health-retries can't be negative")
+	}
+	if cmd == "" && interval == 0 && timeout == 0 && retries == 0 {
+		return nil, nil
+	}
+	health := &HealthConfig{
+		Interval: interval,
+		Timeout:  timeout,
+		Retries:  retries,
+	}
+	if cmd != "" {
+		health.Test = []string{"CMD-SHELL", cmd}
+	}
+	return health, nil
+}
+
 // parseRestartPolicy returns the parsed policy or an error indicating what is incorrect
 func parseRestartPolicy(policy string) (RestartPolicy, error) {
 	p := RestartPolicy{}
@@ -389,9 +680,9 @@ func parseRestartPolicy(policy string) (RestartPolicy, error) {
 
 	p.Name = name
 	switch name {
-	case "always":
+	case "always", "unless-stopped":
 		if len(parts) == 2 {
-			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"always\"")
+			return p, fmt.Errorf("maximum restart count not valid with restart policy of \"%s\"", name)
 		}
 	case "no":
 		// do nothing
@@ -451,7 +742,10 @@ func parseNetMode(netMode string) (NetworkMode, error) {
 			return "", fmt.Errorf("invalid container format container:<name|id>")
 		}
 	default:
-		return "", fmt.Errorf("invalid --net: %s", netMode)
+		// The name or ID of a network created with `docker network create`
+		if !validNetworkName.MatchString(netMode) {
+			return "", fmt.Errorf("invalid --net: %s", netMode)
+		}
 	}
 	return NetworkMode(netMode), nil
 }
@@ -485,3 +779,43 @@ func ParseDevice(device string) (DeviceMapping, error) {
 	}
 	return deviceMapping, nil
 }
+
+// ParseThrottleDevice parses the block IO limit of a device, in the format
+// <device-path>:<rate>. The rate of bps limits takes a unit (b, k, m or g),
+// the rate of IO limits is a number of IO per second.
+func ParseThrottleDevice(device string, bps bool) (ThrottleDevice, error) {
+	i := strings.LastIndex(device, ":")
+	if i <= 0 || i == len(device)-1 {
+		return ThrottleDevice{}, fmt.Errorf("Invalid device limit %s: the format is <device-path>:<rate>", device)
+	}
+	devicePath, value := device[:i], device[i+1:]
+	if !path.IsAbs(devicePath) {
+		return ThrottleDevice{}, fmt.Errorf("Invalid device limit %s: %s is not an absolute path", device, devicePath)
+	}
+	var rate uint64
+	if bps {
+		bytes, err := units.RAMInBytes(value)
+		if err != nil || bytes <= 0 {
+			return ThrottleDevice{}, fmt.Errorf("Invalid device limit %s: the rate must be a positive number of bytes per second", device)
+		}
+		rate = uint64(bytes)
+	} else {
+		var err error
+		if rate, err = strconv.ParseUint(value, 10, 64); err != nil || rate == 0 {
+			return ThrottleDevice{}, fmt.Errorf("Invalid device limit %s: the rate must be a positive number of IO per second", device)
+		}
+	}
+	return ThrottleDevice{Path: devicePath, Rate: rate}, nil
+}
+
+func parseThrottleDevices(devices []string, bps bool) ([]ThrottleDevice, error) {
+	var throttleDevices []ThrottleDevice
+	for _, device := range devices {
+		throttleDevice, err := ParseThrottleDevice(device, bps)
+		if err != nil {
+			return nil, err
+		}
+		throttleDevices = append(throttleDevices, throttleDevice)
+	}
+	return throttleDevices, nil
+}
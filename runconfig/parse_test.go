@@ -1,8 +1,11 @@
 package runconfig
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"testing"
+	"time"
 
 	flag "github.com/docker/docker/pkg/mflag"
 	"github.com/docker/docker/pkg/parsers"
@@ -56,6 +59,10 @@ func TestNetHostname(t *testing.T) {
 	if _, _, _, err := parseRun([]string{"-h=name", "--net=container:other", "img", "cmd"}); err != ErrConflictNetworkHostname {
 		t.Fatalf("Expected error ErrConflictNetworkHostname, got: %s", err)
 	}
+
+	if _, _, _, err := parseRun([]string{"-h=name", "--net=mynet", "img", "cmd"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
 }
 
 func TestConflictContainerNetworkAndLinks(t *testing.T) {
@@ -63,3 +70,420 @@ func TestConflictContainerNetworkAndLinks(t *testing.T) {
 		t.Fatalf("Expected error ErrConflictContainerNetworkAndLinks, got: %s", err)
 	}
 }
+
+func TestParseNetworkName(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--net=my-net.1", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if mode := hostConfig.NetworkMode; !mode.IsUserDefined() || mode.NetworkName() != "my-net.1" {
+		t.Fatalf("Expected the user-defined network my-net.1, got %q", mode)
+	}
+
+	for _, name := range []string{"--net=-net", "--net=my/net", "--net=my net"} {
+		if _, _, _, err := parseRun([]string{name, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", name)
+		}
+	}
+	if _, _, _, err := parseRun([]string{"--net=mynet", "--link=zip:zap", "img", "cmd"}); err != ErrConflictNetworkAndLinks {
+		t.Fatalf("Expected error ErrConflictNetworkAndLinks, got: %s", err)
+	}
+}
+
+func TestParseIPAddresses(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--net=mynet", "--ip=172.30.100.104", "--ip6=2001:db8::33", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.IPv4Address != "172.30.100.104" || config.IPv6Address != "2001:db8::33" {
+		t.Fatalf("Expected addresses 172.30.100.104 and 2001:db8::33, got %q and %q", config.IPv4Address, config.IPv6Address)
+	}
+
+	for _, flag := range []string{"--ip=172.30.100", "--ip=2001:db8::33", "--ip6=172.30.100.104", "--ip6=foo"} {
+		if _, _, _, err := parseRun([]string{flag, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", flag)
+		}
+	}
+}
+
+func TestParseNetworkAliases(t *testing.T) {
+	config, _, _, err := parseRun([]string{"--net=mynet", "--net-alias=db", "--net-alias=db.primary", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(config.NetworkAliases) != 2 || config.NetworkAliases[0] != "db" || config.NetworkAliases[1] != "db.primary" {
+		t.Fatalf("Expected the aliases db and db.primary, got %v", config.NetworkAliases)
+	}
+
+	if _, _, _, err := parseRun([]string{"--net-alias=db", "img", "cmd"}); err != ErrConflictNetworkAliases {
+		t.Fatalf("Expected error ErrConflictNetworkAliases, got: %v", err)
+	}
+	if _, _, _, err := parseRun([]string{"--net=mynet", "--net-alias=-db", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for the alias -db")
+	}
+}
+
+func TestParseCpuQuota(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--cpu-period=50000", "--cpu-quota=25000", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.CpuPeriod != 50000 || hostConfig.CpuQuota != 25000 {
+		t.Fatalf("Expected a CPU quota of 25000 per period of 50000, got %d per %d", hostConfig.CpuQuota, hostConfig.CpuPeriod)
+	}
+
+	for _, period := range []string{"--cpu-period=999", "--cpu-period=1000001"} {
+		if _, _, _, err := parseRun([]string{period, "img", "cmd"}); err != ErrInvalidCpuPeriod {
+			t.Fatalf("Expected error ErrInvalidCpuPeriod for %s, got %s", period, err)
+		}
+	}
+	if _, _, _, err := parseRun([]string{"--cpu-quota=999", "img", "cmd"}); err != ErrInvalidCpuQuota {
+		t.Fatalf("Expected error ErrInvalidCpuQuota, got %s", err)
+	}
+}
+
+func TestParseBlkioWeight(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--blkio-weight=300", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.BlkioWeight != 300 {
+		t.Fatalf("Expected a blkio weight of 300, got %d", hostConfig.BlkioWeight)
+	}
+
+	for _, weight := range []string{"--blkio-weight=5", "--blkio-weight=1001"} {
+		if _, _, _, err := parseRun([]string{weight, "img", "cmd"}); err != ErrInvalidBlkioWeight {
+			t.Fatalf("Expected error ErrInvalidBlkioWeight for %s, got %s", weight, err)
+		}
+	}
+}
+
+func TestParseThrottleDevices(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--device-read-bps=/dev/sda:1mb", "--device-write-iops=/dev/sdb:100", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(hostConfig.DeviceReadBps) != 1 || hostConfig.DeviceReadBps[0] != (ThrottleDevice{"/dev/sda", 1048576}) {
+		t.Fatalf("Expected a read limit of 1048576 bytes per second for /dev/sda, got %v", hostConfig.DeviceReadBps)
+	}
+	if len(hostConfig.DeviceWriteIOps) != 1 || hostConfig.DeviceWriteIOps[0] != (ThrottleDevice{"/dev/sdb", 100}) {
+		t.Fatalf("Expected a write limit of 100 IO per second for /dev/sdb, got %v", hostConfig.DeviceWriteIOps)
+	}
+	if hostConfig.DeviceWriteBps != nil || hostConfig.DeviceReadIOps != nil {
+		t.Fatalf("Expected no other device limits, got %v, %v", hostConfig.DeviceWriteBps, hostConfig.DeviceReadIOps)
+	}
+
+	for _, limit := range []string{"--device-read-bps=/dev/sda", "--device-read-bps=sda:1mb", "--device-read-bps=/dev/sda:fast", "--device-read-iops=/dev/sda:1k", "--device-write-iops=/dev/sda:0"} {
+		if _, _, _, err := parseRun([]string{limit, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", limit)
+		}
+	}
+}
+
+func TestParsePidsLimit(t *testing.T) {
+	for _, limit := range []int64{100, -1} {
+		_, hostConfig, _, err := parseRun([]string{fmt.Sprintf("--pids-limit=%d", limit), "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if hostConfig.PidsLimit != limit {
+			t.Fatalf("Expected a pids limit of %d, got %d", limit, hostConfig.PidsLimit)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--pids-limit=-2", "img", "cmd"}); err != ErrInvalidPidsLimit {
+		t.Fatalf("Expected error ErrInvalidPidsLimit, got %s", err)
+	}
+}
+
+func TestParseOomScoreAdj(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--oom-kill-disable", "--oom-score-adj=-500", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !hostConfig.OomKillDisable || hostConfig.OomScoreAdj != -500 {
+		t.Fatalf("Expected the OOM killer to be disabled with a score adjustment of -500, got %v, %d", hostConfig.OomKillDisable, hostConfig.OomScoreAdj)
+	}
+
+	for _, adj := range []string{"--oom-score-adj=-1001", "--oom-score-adj=1001"} {
+		if _, _, _, err := parseRun([]string{adj, "img", "cmd"}); err != ErrInvalidOomScoreAdj {
+			t.Fatalf("Expected error ErrInvalidOomScoreAdj for %s, got %s", adj, err)
+		}
+	}
+}
+
+func TestParseNetworkRates(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--net-egress-bps=10mb", "--net-ingress-bps=512k", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.NetEgressBps != 10*1024*1024 || hostConfig.NetIngressBps != 512*1024 {
+		t.Fatalf("Unexpected rates %d and %d", hostConfig.NetEgressBps, hostConfig.NetIngressBps)
+	}
+
+	for _, rate := range []string{"--net-egress-bps=0", "--net-ingress-bps=fast", "--net-egress-bps=-1m"} {
+		if _, _, _, err := parseRun([]string{rate, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error for %s", rate)
+		}
+	}
+}
+
+func TestParseLoggingOpts(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--log-opt=labels=app,tier", "--log-opt=env=ENV", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.LogConfig.Config["labels"] != "app,tier" || hostConfig.LogConfig.Config["env"] != "ENV" {
+		t.Fatalf("Unexpected log opts %v", hostConfig.LogConfig.Config)
+	}
+
+	if _, _, _, err := parseRun([]string{"--log-driver=none", "--log-opt=labels=app", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for log opts with the none driver")
+	}
+	if _, _, _, err := parseRun([]string{"--log-opt=labels", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a log opt without a value")
+	}
+}
+
+func TestParseStorageOpts(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"--storage-opt=size=20G", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(hostConfig.StorageOpt) != 1 || hostConfig.StorageOpt["size"] != "20G" {
+		t.Fatalf("Unexpected storage opts %v", hostConfig.StorageOpt)
+	}
+
+	if _, _, _, err := parseRun([]string{"--storage-opt=size", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for a storage opt without a value")
+	}
+}
+
+func TestParseHealth(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.Healthcheck != nil {
+		t.Fatalf("Expected no health check by default, got %v", config.Healthcheck)
+	}
+
+	config, _, _, err = parseRun([]string{"--health-cmd=curl -f http://localhost/", "--health-interval=5s", "--health-retries=2", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	health := config.Healthcheck
+	if health == nil || len(health.Test) != 2 || health.Test[0] != "CMD-SHELL" || health.Test[1] != "curl -f http://localhost/" ||
+		health.Interval != 5*time.Second || health.Timeout != 0 || health.Retries != 2 {
+		t.Fatalf("Unexpected health check %v", health)
+	}
+
+	config, _, _, err = parseRun([]string{"--no-healthcheck", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if health := config.Healthcheck; health == nil || len(health.Test) != 1 || health.Test[0] != "NONE" {
+		t.Fatalf("Expected a disabled health check, got %v", health)
+	}
+
+	if _, _, _, err := parseRun([]string{"--no-healthcheck", "--health-cmd=true", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for --no-healthcheck with --health-cmd")
+	}
+	if _, _, _, err := parseRun([]string{"--health-retries=-1", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error for negative --health-retries")
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	valid := map[string]RestartPolicy{
+		"":               {},
+		"no":             {Name: "no"},
+		"always":         {Name: "always"},
+		"unless-stopped": {Name: "unless-stopped"},
+		"on-failure":     {Name: "on-failure"},
+		"on-failure:3":   {Name: "on-failure", MaximumRetryCount: 3},
+	}
+	for policy, expected := range valid {
+		p, err := parseRestartPolicy(policy)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %s", policy, err)
+		}
+		if p != expected {
+			t.Fatalf("Expected %v for %q, got %v", expected, policy, p)
+		}
+	}
+
+	for _, policy := range []string{"always:3", "unless-stopped:3", "on-failure:x", "sometimes"} {
+		if _, err := parseRestartPolicy(policy); err == nil {
+			t.Fatalf("Expected an error for %q", policy)
+		}
+	}
+}
+
+func TestParseRestartOnUnhealthy(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.RestartPolicy.OnUnhealthy {
+		t.Fatal("Expected no restart on unhealthy by default")
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--restart=on-failure:2", "--restart-on-unhealthy", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := RestartPolicy{Name: "on-failure", MaximumRetryCount: 2, OnUnhealthy: true}
+	if hostConfig.RestartPolicy != expected {
+		t.Fatalf("Expected %v, got %v", expected, hostConfig.RestartPolicy)
+	}
+}
+
+func TestParseAutoRemove(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.AutoRemove {
+		t.Fatal("Expected no auto-remove by default")
+	}
+
+	_, hostConfig, _, err = parseRun([]string{"--rm", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !hostConfig.AutoRemove {
+		t.Fatal("Expected --rm to set auto-remove")
+	}
+}
+
+func TestParseStopTimeout(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.StopTimeout != nil {
+		t.Fatalf("Expected no stop timeout by default, got %d", *config.StopTimeout)
+	}
+
+	config, _, _, err = parseRun([]string{"--stop-timeout=60", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.StopTimeout == nil || *config.StopTimeout != 60 {
+		t.Fatalf("Expected a stop timeout of 60, got %v", config.StopTimeout)
+	}
+
+	if _, _, _, err := parseRun([]string{"--stop-timeout=-1", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error with a negative stop timeout")
+	}
+}
+
+func TestParseStopSignal(t *testing.T) {
+	config, _, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if config.StopSignal != "" {
+		t.Fatalf("Expected no stop signal by default, got %s", config.StopSignal)
+	}
+
+	for _, sig := range []string{"SIGQUIT", "QUIT", "3"} {
+		config, _, _, err = parseRun([]string{"--stop-signal=" + sig, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if config.StopSignal != sig {
+			t.Fatalf("Expected a stop signal of %s, got %s", sig, config.StopSignal)
+		}
+	}
+
+	if _, _, _, err := parseRun([]string{"--stop-signal=SIGFOO", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error with an invalid stop signal")
+	}
+}
+
+func TestParseInit(t *testing.T) {
+	_, hostConfig, _, err := parseRun([]string{"img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if hostConfig.Init != nil {
+		t.Fatalf("Expected the daemon to choose whether to run an init by default, got %v", *hostConfig.Init)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		_, hostConfig, _, err = parseRun([]string{fmt.Sprintf("--init=%v", enabled), "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if hostConfig.Init == nil || *hostConfig.Init != enabled {
+			t.Fatalf("Expected --init=%v to be kept in the host config, got %v", enabled, hostConfig.Init)
+		}
+	}
+}
+
+func TestParsePidMode(t *testing.T) {
+	for _, mode := range []string{"", "host", "container:web"} {
+		_, hostConfig, _, err := parseRun([]string{"--pid=" + mode, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error parsing --pid=%s: %s", mode, err)
+		}
+		if string(hostConfig.PidMode) != mode {
+			t.Fatalf("Expected a PID mode of %q, got %q", mode, hostConfig.PidMode)
+		}
+	}
+	for _, mode := range []string{"container", "container:", "container:a:b", "other"} {
+		if _, _, _, err := parseRun([]string{"--pid=" + mode, "img", "cmd"}); err == nil {
+			t.Fatalf("Expected an error parsing --pid=%s", mode)
+		}
+	}
+
+	if mode := PidMode("container:web"); !mode.IsContainer() || mode.IsPrivate() || mode.Container() != "web" {
+		t.Fatalf("Expected %s to join the PID namespace of web", mode)
+	}
+	if mode := PidMode(""); mode.IsContainer() || !mode.IsPrivate() || mode.Container() != "" {
+		t.Fatalf("Expected an empty PID mode to be private")
+	}
+}
+
+func TestParseUTSMode(t *testing.T) {
+	for _, mode := range []string{"", "host"} {
+		_, hostConfig, _, err := parseRun([]string{"--uts=" + mode, "img", "cmd"})
+		if err != nil {
+			t.Fatalf("Unexpected error parsing --uts=%s: %s", mode, err)
+		}
+		if string(hostConfig.UTSMode) != mode {
+			t.Fatalf("Expected a UTS mode of %q, got %q", mode, hostConfig.UTSMode)
+		}
+	}
+	if _, _, _, err := parseRun([]string{"--uts=container:web", "img", "cmd"}); err == nil {
+		t.Fatalf("Expected an error parsing --uts=container:web")
+	}
+	if _, _, _, err := parseRun([]string{"--uts=host", "-h", "name", "img", "cmd"}); err != ErrConflictUTSHostname {
+		t.Fatalf("Expected error ErrConflictUTSHostname, got %s", err)
+	}
+}
+
+func TestParseAddHostFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	fmt.Fprint(f, "# the hosts of the site\n10.1.0.10  db db.example.com  # primary\n\n2001:db8::1 registry\n")
+	f.Close()
+
+	_, hostConfig, _, err := parseRun([]string{"--add-host-file", f.Name(), "--add-host", "db:10.1.0.20", "img", "cmd"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []string{"db:10.1.0.20", "db:10.1.0.10", "db.example.com:10.1.0.10", "registry:2001:db8::1"}
+	if fmt.Sprint(hostConfig.ExtraHosts) != fmt.Sprint(expected) {
+		t.Fatalf("Expected the extra hosts %v, got %v", expected, hostConfig.ExtraHosts)
+	}
+
+	if _, _, _, err := parseRun([]string{"--add-host-file", f.Name() + ".missing", "img", "cmd"}); err == nil {
+		t.Fatal("Expected an error reading a missing hosts file")
+	}
+}
@@ -172,26 +172,37 @@ func ValidateID(id string) error {
 	return nil
 }
 
-// Code c/c from io.Copy() modified to handle escape sequence
-func CopyEscapable(dst io.Writer, src io.ReadCloser) (written int64, err error) {
+// Code c/c from io.Copy() modified to handle escape sequence: src is closed
+// once it reads keys, ctrl-p ctrl-q if keys is empty.
+func CopyEscapable(dst io.Writer, src io.ReadCloser, keys []byte) (written int64, err error) {
+	if len(keys) == 0 {
+		// char 16 is C-p, char 17 is C-q
+		keys = []byte{16, 17}
+	}
 	buf := make([]byte, 32*1024)
 	for {
 		nr, er := src.Read(buf)
 		if nr > 0 {
 			// ---- Docker addition
-			// char 16 is C-p
-			if nr == 1 && buf[0] == 16 {
-				nr, er = src.Read(buf)
-				// char 17 is C-q
-				if nr == 1 && buf[0] == 17 {
+			// The keys are typed one at a time. Those read before one that
+			// doesn't follow the sequence are copied along with it.
+			var pending []byte
+			for i := 0; nr == 1 && buf[0] == keys[i]; i++ {
+				if i == len(keys)-1 {
 					if err := src.Close(); err != nil {
 						return 0, err
 					}
 					return 0, nil
 				}
+				pending = append(pending, buf[0])
+				nr, er = src.Read(buf)
+			}
+			chunk := buf[0:nr]
+			if pending != nil {
+				chunk = append(pending, chunk...)
 			}
 			// ---- End of docker
-			nw, ew := dst.Write(buf[0:nr])
+			nw, ew := dst.Write(chunk)
 			if nw > 0 {
 				written += int64(nw)
 			}
@@ -199,7 +210,7 @@ func CopyEscapable(dst io.Writer, src io.ReadCloser) (written int64, err error)
 				err = ew
 				break
 			}
-			if nr != nw {
+			if len(chunk) != nw {
 				err = io.ErrShortWrite
 				break
 			}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -152,3 +153,48 @@ func TestDigestReference(t *testing.T) {
 		t.Errorf("Unexpected DigestReference=true for input %q", input)
 	}
 }
+
+// chunkReader returns one of its chunks per read.
+type chunkReader struct {
+	chunks []string
+	closed bool
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func (r *chunkReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestCopyEscapable(t *testing.T) {
+	cases := []struct {
+		chunks []string
+		keys   []byte
+		out    string
+		closed bool
+	}{
+		{[]string{"ls\n", "\x10", "\x11", "pwd\n"}, nil, "ls\n", true},
+		{[]string{"\x10", "x", "\x10\x11"}, nil, "\x10x\x10\x11", false},
+		{[]string{"\x10", "\x11"}, []byte{1, 'd'}, "\x10\x11", false},
+		{[]string{"a", "\x01", "d", "b"}, []byte{1, 'd'}, "a", true},
+		{[]string{"\x01", "\x01", "x"}, []byte{1, 'd'}, "\x01\x01x", false},
+	}
+	for _, c := range cases {
+		src := &chunkReader{chunks: c.chunks}
+		var dst bytes.Buffer
+		if _, err := CopyEscapable(&dst, src, c.keys); err != nil {
+			t.Fatal(err)
+		}
+		if dst.String() != c.out || src.closed != c.closed {
+			t.Fatalf("Expected %q to be copied as %q with closed=%v, got %q with closed=%v", c.chunks, c.out, c.closed, dst.String(), src.closed)
+		}
+	}
+}
@@ -125,6 +125,17 @@ func (r *Repository) get(path string) *Volume {
 	return r.volumes[filepath.Clean(path)]
 }
 
+// List returns all the volumes of the repository, in no particular order.
+func (r *Repository) List() []*Volume {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	volumes := make([]*Volume, 0, len(r.volumes))
+	for _, v := range r.volumes {
+		volumes = append(volumes, v)
+	}
+	return volumes
+}
+
 func (r *Repository) add(volume *Volume) error {
 	if vol := r.get(volume.Path); vol != nil {
 		return fmt.Errorf("Volume exists: %s", volume.ID)
@@ -152,6 +152,48 @@ func TestRepositoryDelete(t *testing.T) {
 
 }
 
+func TestRepositoryList(t *testing.T) {
+	root, err := ioutil.TempDir(os.TempDir(), "volumes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	repo, err := newRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if volumes := repo.List(); len(volumes) != 0 {
+		t.Fatalf("expected no volumes, got %d", len(volumes))
+	}
+
+	v, err := repo.FindOrCreateVolume("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := repo.FindOrCreateVolume(filepath.Join(root, "test"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	volumes := repo.List()
+	if len(volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %d", len(volumes))
+	}
+	for _, vol := range volumes {
+		if vol != v && vol != v2 {
+			t.Fatalf("unexpected volume %s", vol.Path)
+		}
+	}
+
+	if err := repo.Delete(v.Path); err != nil {
+		t.Fatal(err)
+	}
+	if volumes := repo.List(); len(volumes) != 1 || volumes[0] != v2 {
+		t.Fatalf("expected only the volume %s, got %v", v2.Path, volumes)
+	}
+}
+
 func newRepo(root string) (*Repository, error) {
 	configPath := filepath.Join(root, "repo-config")
 	graphDir := filepath.Join(root, "repo-graph")
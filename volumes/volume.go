@@ -2,6 +2,7 @@ package volumes
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -10,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/chrootarchive"
 	"github.com/docker/docker/pkg/symlink"
 )
 
@@ -53,6 +55,23 @@ func (v *Volume) Export(resource, name string) (io.ReadCloser, error) {
 	})
 }
 
+// Extract unpacks the tar archive content into the directory resource of the
+// volume.
+func (v *Volume) Extract(resource string, content io.Reader) error {
+	basePath, err := v.getResourcePath(resource)
+	if err != nil {
+		return err
+	}
+	stat, err := os.Stat(basePath)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("Cannot extract to %s: not a directory", resource)
+	}
+	return chrootarchive.Untar(content, basePath, &archive.TarOptions{NoLchown: true})
+}
+
 func (v *Volume) IsDir() (bool, error) {
 	stat, err := os.Stat(v.Path)
 	if err != nil {